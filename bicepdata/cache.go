@@ -0,0 +1,100 @@
+package bicepdata
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Cache holds the index and types files fetched through it in memory, so loads
+// sharing it download and parse each file at most once, also when they run
+// concurrently. Failed fetches are not cached. The returned index data and type
+// arrays are shared and must not be modified. Types files left on disk stay open
+// until the cache is closed.
+type Cache struct {
+	mu      sync.Mutex
+	entries map[string]*cacheEntry
+}
+
+// cacheEntry is a file being fetched or fetched; done is closed once value and
+// err are set.
+type cacheEntry struct {
+	done  chan struct{}
+	value any
+	err   error
+}
+
+// NewCache returns an empty cache.
+func NewCache() *Cache {
+	return &Cache{entries: map[string]*cacheEntry{}}
+}
+
+// Close closes the types files held by the cache, removing temporary
+// downloads, and empties it. Fetches in progress are waited for first.
+func (c *Cache) Close() error {
+	c.mu.Lock()
+	entries := c.entries
+	c.entries = map[string]*cacheEntry{}
+	c.mu.Unlock()
+
+	var errs []error
+	for _, e := range entries {
+		<-e.done
+		if closer, ok := e.value.(io.Closer); ok && e.err == nil {
+			errs = append(errs, closer.Close())
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// load returns the cached value of key, calling fetch to fill it when it is not
+// cached. Concurrent calls for the same key wait for the first one.
+func (c *Cache) load(key string, fetch func() (any, error)) (any, error) {
+	c.mu.Lock()
+	if e, ok := c.entries[key]; ok {
+		c.mu.Unlock()
+		<-e.done
+		if e.err == nil {
+			return e.value, nil
+		}
+		// The fetch that failed removed the entry; try again.
+		return c.load(key, fetch)
+	}
+	e := &cacheEntry{done: make(chan struct{})}
+	c.entries[key] = e
+	c.mu.Unlock()
+
+	e.value, e.err = fetch()
+	if e.err != nil {
+		c.mu.Lock()
+		delete(c.entries, key)
+		c.mu.Unlock()
+	}
+	close(e.done)
+	return e.value, e.err
+}
+
+// cacheKey identifies a file by the source it is fetched from.
+func cacheKey(relativePath string, opts *FetchOptions) string {
+	if opts != nil && opts.LocalPath != "" {
+		return "local:" + opts.LocalPath + ":" + relativePath
+	}
+	return opts.baseURL() + "/" + relativePath
+}
+
+// cached returns the value of the file at relativePath, in the form fetch
+// returns it, through the cache of opts. Without a cache it calls fetch.
+func cached[T any](relativePath string, opts *FetchOptions, fetch func() (T, error)) (T, error) {
+	if opts == nil || opts.Cache == nil {
+		return fetch()
+	}
+	var zero T
+	// The key carries the type, so a file may be cached both raw and parsed.
+	key := fmt.Sprintf("%s#%T", cacheKey(relativePath, opts), zero)
+	v, err := opts.Cache.load(key, func() (any, error) { return fetch() })
+	if err != nil {
+		return zero, err
+	}
+	return v.(T), nil
+}
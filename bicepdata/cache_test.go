@@ -0,0 +1,145 @@
+package bicepdata
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/Azure/bicep-types/src/bicep-types-go/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// containerAppsServer serves an index and a types.json with two resource types,
+// counting the requests of each path.
+func containerAppsServer(t *testing.T) (*httptest.Server, *sync.Map) {
+	t.Helper()
+	typesContent := buildTypesJSONLoader(t,
+		&types.ResourceType{Name: "Microsoft.App/containerApps@2025-01-01", Body: types.TypeReference{Ref: 2}},
+		&types.ResourceType{Name: "Microsoft.App/jobs@2025-01-01", Body: types.TypeReference{Ref: 2}},
+		&types.ObjectType{Name: "Body", Properties: map[string]types.ObjectTypeProperty{}},
+	)
+	indexJSON := `{"resources": {
+		"Microsoft.App/containerApps@2025-01-01": {"$ref": "microsoft.app/2025-01-01/types.json#/0"},
+		"Microsoft.App/jobs@2025-01-01": {"$ref": "microsoft.app/2025-01-01/types.json#/1"}
+	}}`
+
+	var requests sync.Map
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n, _ := requests.LoadOrStore(r.URL.Path, new(atomic.Int32))
+		n.(*atomic.Int32).Add(1)
+		if strings.HasSuffix(r.URL.Path, "index.json") {
+			_, _ = w.Write([]byte(indexJSON))
+			return
+		}
+		_, _ = w.Write(typesContent)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &requests
+}
+
+func requestCount(requests *sync.Map, path string) int32 {
+	n, ok := requests.Load(path)
+	if !ok {
+		return 0
+	}
+	return n.(*atomic.Int32).Load()
+}
+
+func TestCache_ConcurrentLoadsFetchOnce(t *testing.T) {
+	srv, requests := containerAppsServer(t)
+	opts := &FetchOptions{BaseURL: srv.URL, Cache: NewCache()}
+
+	var wg sync.WaitGroup
+	loaded := make([]*LoadedResource, 16)
+	errs := make([]error, len(loaded))
+	for i := range loaded {
+		resourceType := "Microsoft.App/containerApps"
+		if i%2 == 1 {
+			resourceType = "Microsoft.App/jobs"
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			loaded[i], errs[i] = LoadResource(context.Background(), resourceType, "", false, opts)
+		}()
+	}
+	wg.Wait()
+
+	for i := range loaded {
+		require.NoError(t, errs[i])
+	}
+	assert.Equal(t, "Microsoft.App/containerApps@2025-01-01", loaded[0].ResourceType.Name)
+	assert.Equal(t, "Microsoft.App/jobs@2025-01-01", loaded[1].ResourceType.Name)
+	assert.Equal(t, int32(1), requestCount(requests, "/index.json"))
+	assert.Equal(t, int32(1), requestCount(requests, "/microsoft.app/2025-01-01/types.json"))
+}
+
+func TestCache_WithoutCacheFetchesEveryTime(t *testing.T) {
+	srv, requests := containerAppsServer(t)
+	opts := &FetchOptions{BaseURL: srv.URL}
+
+	for range 2 {
+		_, err := LoadResource(context.Background(), "Microsoft.App/jobs", "2025-01-01", false, opts)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int32(2), requestCount(requests, "/index.json"))
+	assert.Equal(t, int32(2), requestCount(requests, "/microsoft.app/2025-01-01/types.json"))
+}
+
+func TestCache_DoesNotCacheFailures(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"resources": {}}`))
+	}))
+	defer srv.Close()
+	opts := &FetchOptions{BaseURL: srv.URL, Cache: NewCache()}
+
+	_, err := FetchIndex(context.Background(), opts)
+	require.ErrorContains(t, err, "HTTP 503")
+	data, err := FetchIndex(context.Background(), opts)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"resources": {}}`, string(data))
+	_, err = FetchIndex(context.Background(), opts)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestCache_CloseReleasesStreamedFiles(t *testing.T) {
+	srv, _ := containerAppsServer(t)
+	tempDir := t.TempDir()
+	t.Setenv("TMPDIR", tempDir)
+	cacheDir := t.TempDir()
+
+	var files []*TypesFile
+	for _, opts := range []*FetchOptions{
+		{BaseURL: srv.URL, StreamingThreshold: 16, Cache: NewCache()},
+		{BaseURL: srv.URL, CacheDir: cacheDir, StreamingThreshold: 16, Cache: NewCache()},
+	} {
+		_, err := LoadResource(context.Background(), "Microsoft.App/jobs", "2025-01-01", false, opts)
+		require.NoError(t, err)
+		f, err := FetchTypes(context.Background(), "microsoft.app/2025-01-01/types.json", opts)
+		require.NoError(t, err)
+		require.NotNil(t, f.file, "the file is above the threshold")
+		files = append(files, f)
+
+		require.NoError(t, opts.Cache.Close())
+	}
+
+	for _, f := range files {
+		_, err := f.file.Stat()
+		assert.ErrorIs(t, err, os.ErrClosed)
+	}
+	entries, err := os.ReadDir(tempDir)
+	require.NoError(t, err)
+	assert.Empty(t, entries, "no temporary download is left behind")
+}
@@ -0,0 +1,46 @@
+package bicepdata
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrResourceNotFound is matched by the errors reporting a resource type or API
+// version that is not in the index.
+var ErrResourceNotFound = errors.New("not found")
+
+// ErrSpecFetch is matched by the errors reporting that an index or types file
+// could not be downloaded or read.
+var ErrSpecFetch = errors.New("fetching types failed")
+
+// FetchError reports an index or types file that could not be downloaded or
+// read. It matches ErrSpecFetch.
+type FetchError struct {
+	// Path is the file relative to the generated directory, e.g. index.json.
+	Path string
+	// Source is the URL the file was downloaded from, or its local path.
+	Source string
+	// Local reports whether the file was read from a local checkout.
+	Local bool
+	// StatusCode is the HTTP status of a download that did not return 200 OK,
+	// and zero otherwise.
+	StatusCode int
+	// Err is the underlying error, nil for an unexpected status.
+	Err error
+}
+
+func (e *FetchError) Error() string {
+	switch {
+	case e.StatusCode != 0:
+		return fmt.Sprintf("downloading %s: HTTP %d", e.Source, e.StatusCode)
+	case e.Local:
+		return fmt.Sprintf("reading local file %s: %v", e.Source, e.Err)
+	default:
+		return fmt.Sprintf("downloading %s: %v", e.Source, e.Err)
+	}
+}
+
+func (e *FetchError) Unwrap() error { return e.Err }
+
+// Is reports whether target is ErrSpecFetch.
+func (e *FetchError) Is(target error) bool { return target == ErrSpecFetch }
@@ -4,19 +4,32 @@ package bicepdata
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/Azure/bicep-types/src/bicep-types-go/types"
+	"github.com/matt-FFFFFF/tfmodmake/profile"
 )
 
 const (
 	// defaultBaseURL is the raw GitHub URL for the bicep-types-az generated output.
-	defaultBaseURL = "https://raw.githubusercontent.com/Azure/bicep-types-az/main/generated"
+	defaultBaseURL = rawContentURL + "/" + defaultRef + "/generated"
+
+	// rawContentURL serves the files of bicep-types-az at a ref.
+	rawContentURL = "https://raw.githubusercontent.com/Azure/bicep-types-az"
+
+	// commitsURL resolves a ref of bicep-types-az to its commit.
+	commitsURL = "https://api.github.com/repos/Azure/bicep-types-az/commits/"
+
+	// defaultRef is the branch the published types are read from.
+	defaultRef = "main"
 
 	// defaultUserAgent identifies this tool in HTTP requests.
 	defaultUserAgent = "tfmodmake/1.0"
@@ -44,17 +57,90 @@ type FetchOptions struct {
 	// Useful for testing or using a mirror.
 	BaseURL string
 
+	// Ref is the bicep-types-az branch, tag or commit the published types are
+	// read from, "main" when empty. It is ignored with BaseURL or LocalPath.
+	Ref string
+
 	// HTTPClient overrides the default HTTP client.
 	HTTPClient *http.Client
+
+	// Cache is an optional in-memory cache shared by the loads using it, so
+	// each file is fetched and parsed only once per run.
+	Cache *Cache
+
+	// StreamingThreshold is the size in bytes above which a types file is
+	// parsed from disk instead of memory. Zero selects
+	// DefaultStreamingThreshold; a negative value reads every file into memory.
+	StreamingThreshold int64
 }
 
 func (o *FetchOptions) baseURL() string {
 	if o != nil && o.BaseURL != "" {
 		return o.BaseURL
 	}
+	if o != nil && o.Ref != "" {
+		return rawContentURL + "/" + o.Ref + "/generated"
+	}
 	return defaultBaseURL
 }
 
+// SourceURL returns where the file at relativePath is read from: its URL, or
+// its path in the local checkout.
+func SourceURL(relativePath string, opts *FetchOptions) string {
+	if opts != nil && opts.LocalPath != "" {
+		return filepath.Join(opts.LocalPath, "generated", relativePath)
+	}
+	return opts.baseURL() + "/" + relativePath
+}
+
+// ResolveCommit returns the bicep-types-az commit the types are read from: the
+// HEAD of the local checkout, or the commit of Ref on GitHub. Types read from
+// another BaseURL have no known commit.
+func ResolveCommit(ctx context.Context, opts *FetchOptions) (string, error) {
+	if opts != nil && opts.LocalPath != "" {
+		out, err := exec.CommandContext(ctx, "git", "-C", opts.LocalPath, "rev-parse", "HEAD").Output()
+		if err != nil {
+			var exitErr *exec.ExitError
+			if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+				err = errors.New(strings.TrimSpace(string(exitErr.Stderr)))
+			}
+			return "", fmt.Errorf("resolving the commit of %s: %w", opts.LocalPath, err)
+		}
+		return strings.TrimSpace(string(out)), nil
+	}
+	if opts != nil && opts.BaseURL != "" {
+		return "", fmt.Errorf("the commit of types read from %s is unknown", opts.BaseURL)
+	}
+
+	ref := defaultRef
+	if opts != nil && opts.Ref != "" {
+		ref = opts.Ref
+	}
+	url := commitsURL + ref
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("User-Agent", defaultUserAgent)
+	req.Header.Set("Accept", "application/vnd.github.sha")
+	if opts != nil && opts.GitHubToken != "" {
+		req.Header.Set("Authorization", "token "+opts.GitHubToken)
+	}
+	resp, err := opts.httpClient().Do(req)
+	if err != nil {
+		return "", fmt.Errorf("resolving bicep-types-az %s: %w", ref, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("resolving bicep-types-az %s: HTTP %d", ref, resp.StatusCode)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("resolving bicep-types-az %s: %w", ref, err)
+	}
+	return strings.TrimSpace(string(body)), nil
+}
+
 func (o *FetchOptions) httpClient() *http.Client {
 	if o != nil && o.HTTPClient != nil {
 		return o.HTTPClient
@@ -65,19 +151,24 @@ func (o *FetchOptions) httpClient() *http.Client {
 // FetchIndex downloads and parses the bicep-types-az index.json file.
 // The index maps resource types and API versions to their types.json file paths.
 func FetchIndex(ctx context.Context, opts *FetchOptions) ([]byte, error) {
-	return fetchFile(ctx, "index.json", opts)
+	return cached("index.json", opts, func() ([]byte, error) {
+		return fetchFile(ctx, "index.json", opts)
+	})
 }
 
-// FetchTypes downloads and parses a specific types.json file.
+// FetchTypes fetches a specific types.json file without parsing it. Files above
+// the streaming threshold are left on disk and read through the returned file.
 // The relativePath is the path relative to the generated/ directory,
-// e.g. "microsoft.app/2025-01-01/types.json".
-func FetchTypes(ctx context.Context, relativePath string, opts *FetchOptions) ([]types.Type, error) {
-	data, err := fetchFile(ctx, relativePath, opts)
-	if err != nil {
-		return nil, fmt.Errorf("fetching types file %s: %w", relativePath, err)
-	}
-
-	return DeserializeTypes(data)
+// e.g. "microsoft.app/2025-01-01/types.json". Without opts.Cache the caller
+// closes the file; a cached file is shared and closed by Cache.Close.
+func FetchTypes(ctx context.Context, relativePath string, opts *FetchOptions) (*TypesFile, error) {
+	return cached(relativePath, opts, func() (*TypesFile, error) {
+		f, err := fetchTypesFile(ctx, relativePath, opts)
+		if err != nil {
+			return nil, fmt.Errorf("fetching types file %s: %w", relativePath, err)
+		}
+		return f, nil
+	})
 }
 
 // DeserializeTypes parses a types.json byte slice into a slice of typed objects.
@@ -101,9 +192,11 @@ func DeserializeTypes(data []byte) ([]types.Type, error) {
 
 // fetchFile retrieves a file either from the local filesystem or via HTTP.
 func fetchFile(ctx context.Context, relativePath string, opts *FetchOptions) ([]byte, error) {
+	defer profile.FromContext(ctx).Track(profile.PhaseFetch)()
+
 	// Try local filesystem first
 	if opts != nil && opts.LocalPath != "" {
-		return readLocalFile(filepath.Join(opts.LocalPath, "generated", relativePath))
+		return readLocalFile(relativePath, filepath.Join(opts.LocalPath, "generated", relativePath))
 	}
 
 	// Try cache
@@ -128,10 +221,10 @@ func fetchFile(ctx context.Context, relativePath string, opts *FetchOptions) ([]
 	return data, nil
 }
 
-func readLocalFile(path string) ([]byte, error) {
+func readLocalFile(relativePath, path string) ([]byte, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
-		return nil, fmt.Errorf("reading local file %s: %w", path, err)
+		return nil, &FetchError{Path: relativePath, Source: path, Local: true, Err: err}
 	}
 	return data, nil
 }
@@ -150,11 +243,28 @@ func writeCacheFile(cacheDir, relativePath string, data []byte) error {
 }
 
 func downloadFile(ctx context.Context, relativePath string, opts *FetchOptions) ([]byte, error) {
+	resp, err := get(ctx, relativePath, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, &FetchError{Path: relativePath, Source: resp.Request.URL.String(), Err: fmt.Errorf("reading response body: %w", err)}
+	}
+
+	return data, nil
+}
+
+// get requests the file at relativePath and returns the response when it is
+// 200 OK. The caller closes its body.
+func get(ctx context.Context, relativePath string, opts *FetchOptions) (*http.Response, error) {
 	url := opts.baseURL() + "/" + relativePath
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
-		return nil, fmt.Errorf("creating request for %s: %w", url, err)
+		return nil, &FetchError{Path: relativePath, Source: url, Err: fmt.Errorf("creating request: %w", err)}
 	}
 
 	req.Header.Set("User-Agent", defaultUserAgent)
@@ -165,18 +275,12 @@ func downloadFile(ctx context.Context, relativePath string, opts *FetchOptions)
 	client := opts.httpClient()
 	resp, err := client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("downloading %s: %w", url, err)
+		return nil, &FetchError{Path: relativePath, Source: url, Err: err}
 	}
-	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("downloading %s: HTTP %d", url, resp.StatusCode)
+		resp.Body.Close()
+		return nil, &FetchError{Path: relativePath, Source: url, StatusCode: resp.StatusCode}
 	}
-
-	data, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("reading response body from %s: %w", url, err)
-	}
-
-	return data, nil
+	return resp, nil
 }
@@ -3,6 +3,7 @@ package bicepdata
 import (
 	"context"
 	"encoding/json"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -141,6 +142,19 @@ func TestFetchIndex_LocalPath(t *testing.T) {
 	assert.Equal(t, indexContent, data)
 }
 
+// readTypes fetches the types file at relativePath and parses all of it.
+func readTypes(t *testing.T, relativePath string, opts *FetchOptions) []types.Type {
+	t.Helper()
+	f, err := FetchTypes(context.Background(), relativePath, opts)
+	require.NoError(t, err)
+	defer f.Close()
+	data, err := io.ReadAll(io.NewSectionReader(f, 0, f.Size()))
+	require.NoError(t, err)
+	result, err := DeserializeTypes(data)
+	require.NoError(t, err)
+	return result
+}
+
 func TestFetchTypes_LocalPath(t *testing.T) {
 	tmpDir := t.TempDir()
 	typesDir := filepath.Join(tmpDir, "generated", "microsoft.app", "2025-01-01")
@@ -150,8 +164,7 @@ func TestFetchTypes_LocalPath(t *testing.T) {
 	require.NoError(t, os.WriteFile(filepath.Join(typesDir, "types.json"), typesContent, 0o644))
 
 	opts := &FetchOptions{LocalPath: tmpDir}
-	result, err := FetchTypes(context.Background(), "microsoft.app/2025-01-01/types.json", opts)
-	require.NoError(t, err)
+	result := readTypes(t, "microsoft.app/2025-01-01/types.json", opts)
 	require.Len(t, result, 1)
 	assert.IsType(t, &types.StringType{}, result[0])
 }
@@ -230,6 +243,11 @@ func TestFetchIndex_HTTPError(t *testing.T) {
 	_, err := FetchIndex(context.Background(), opts)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "HTTP 404")
+	assert.ErrorIs(t, err, ErrSpecFetch)
+	var fetchErr *FetchError
+	require.ErrorAs(t, err, &fetchErr)
+	assert.Equal(t, http.StatusNotFound, fetchErr.StatusCode)
+	assert.Equal(t, "index.json", fetchErr.Path)
 }
 
 func TestFetchTypes_HTTPDownload(t *testing.T) {
@@ -242,8 +260,7 @@ func TestFetchTypes_HTTPDownload(t *testing.T) {
 	defer srv.Close()
 
 	opts := &FetchOptions{BaseURL: srv.URL}
-	result, err := FetchTypes(context.Background(), "microsoft.app/2025-01-01/types.json", opts)
-	require.NoError(t, err)
+	result := readTypes(t, "microsoft.app/2025-01-01/types.json", opts)
 	require.Len(t, result, 2)
 	assert.IsType(t, &types.BooleanType{}, result[0])
 	assert.IsType(t, &types.IntegerType{}, result[1])
@@ -303,8 +320,7 @@ func TestFetchTypes_CacheWriteNestedPath(t *testing.T) {
 	opts := &FetchOptions{BaseURL: srv.URL, CacheDir: cacheDir}
 
 	relPath := "microsoft.app/2025-01-01/types.json"
-	result, err := FetchTypes(context.Background(), relPath, opts)
-	require.NoError(t, err)
+	result := readTypes(t, relPath, opts)
 	require.Len(t, result, 1)
 
 	// Verify nested cache file was created
@@ -325,6 +341,40 @@ func TestFetchOptions_BaseURL_Override(t *testing.T) {
 	assert.Equal(t, "https://example.com/types", opts.baseURL())
 }
 
+func TestFetchOptions_BaseURL_Ref(t *testing.T) {
+	opts := &FetchOptions{Ref: "abc123"}
+	assert.Equal(t, "https://raw.githubusercontent.com/Azure/bicep-types-az/abc123/generated", opts.baseURL())
+	assert.Equal(t, "https://raw.githubusercontent.com/Azure/bicep-types-az/abc123/generated/index.json", SourceURL("index.json", opts))
+	assert.Equal(t, filepath.Join("/types", "generated", "index.json"), SourceURL("index.json", &FetchOptions{LocalPath: "/types", Ref: "abc123"}))
+}
+
+// roundTripFunc serves HTTP requests with a function.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+func TestResolveCommit(t *testing.T) {
+	var gotURL, gotAccept string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("0123abcd\n"))
+	}))
+	defer srv.Close()
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		gotURL, gotAccept = r.URL.String(), r.Header.Get("Accept")
+		r.URL.Scheme, r.URL.Host = "http", srv.Listener.Addr().String()
+		return http.DefaultTransport.RoundTrip(r)
+	})}
+
+	commit, err := ResolveCommit(context.Background(), &FetchOptions{HTTPClient: client, Ref: "v1"})
+	require.NoError(t, err)
+	assert.Equal(t, "0123abcd", commit)
+	assert.Equal(t, "https://api.github.com/repos/Azure/bicep-types-az/commits/v1", gotURL)
+	assert.Equal(t, "application/vnd.github.sha", gotAccept)
+
+	_, err = ResolveCommit(context.Background(), &FetchOptions{BaseURL: "https://example.com/types"})
+	assert.ErrorContains(t, err, "unknown")
+}
+
 func TestFetchOptions_HTTPClient_Default(t *testing.T) {
 	var opts *FetchOptions
 	client := opts.httpClient()
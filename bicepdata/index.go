@@ -3,6 +3,7 @@ package bicepdata
 import (
 	"encoding/json"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/Azure/bicep-types/src/bicep-types-go/index"
@@ -87,42 +88,124 @@ func unmarshalITypeReference(data []byte) (types.ITypeReference, error) {
 	return ref, nil
 }
 
+// ResourceMatch is an entry of the index matching a resource type.
+type ResourceMatch struct {
+	// ResourceType is the type as cased in the index, which may differ from
+	// the requested casing.
+	ResourceType string
+	APIVersion   string
+	// RelativePath is the types.json file holding the resource type, and Ref
+	// its index in the file's type array. RelativePath is empty when the index
+	// entry does not point to a types file.
+	RelativePath string
+	Ref          int
+	Preview      bool
+	// ExactCase reports whether ResourceType is cased as requested.
+	ExactCase bool
+}
+
+// FindResources returns every entry of the index whose resource type matches
+// resourceType case-insensitively, sorted by API version. The index may hold a
+// type under several casings, e.g. when a service renamed a segment between API
+// versions; for the same version, entries cased as requested come first.
+func FindResources(idx *index.TypeIndex, resourceType string) []ResourceMatch {
+	var matches []ResourceMatch
+	for rt, versionMap := range idx.Resources {
+		if !strings.EqualFold(rt, resourceType) {
+			continue
+		}
+		for v, ref := range versionMap {
+			m := ResourceMatch{
+				ResourceType: rt,
+				APIVersion:   v,
+				Preview:      isPreviewVersion(v),
+				ExactCase:    rt == resourceType,
+			}
+			if crossRef, ok := asCrossFileReference(ref); ok {
+				m.RelativePath = crossRef.RelativePath
+				m.Ref = crossRef.Ref
+			}
+			matches = append(matches, m)
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if a.APIVersion != b.APIVersion {
+			return a.APIVersion < b.APIVersion
+		}
+		if a.ExactCase != b.ExactCase {
+			return a.ExactCase
+		}
+		return a.ResourceType < b.ResourceType
+	})
+	return matches
+}
+
 // LookupResource finds the types.json file path and type index for a given resource type and API version.
 // It returns the CrossFileTypeReference which contains the RelativePath and Ref (type array index).
-// The bicep-types-go index package may return either value or pointer CrossFileTypeReference
-// depending on whether the index was built programmatically or deserialized from JSON.
+// The resource type is matched case-insensitively when the index has no entry
+// cased as requested; if the entries of other casings point to different types,
+// the lookup is ambiguous and fails rather than picking one.
 func LookupResource(idx *index.TypeIndex, resourceType, apiVersion string) (*types.CrossFileTypeReference, error) {
 	ref, ok := idx.GetResource(resourceType, apiVersion)
 	if !ok {
-		return nil, fmt.Errorf("resource %s@%s not found in index", resourceType, apiVersion)
+		var candidates []ResourceMatch
+		for _, m := range FindResources(idx, resourceType) {
+			if m.APIVersion == apiVersion {
+				candidates = append(candidates, m)
+			}
+		}
+		if len(candidates) == 0 {
+			versions := ListVersions(idx, resourceType)
+			if len(versions) == 0 {
+				return nil, fmt.Errorf("resource %s@%s %w in index: no API versions exist for this resource type", resourceType, apiVersion, ErrResourceNotFound)
+			}
+			return nil, fmt.Errorf("resource %s@%s %w in index (available API versions: %s)", resourceType, apiVersion, ErrResourceNotFound, strings.Join(versions, ", "))
+		}
+		for _, c := range candidates[1:] {
+			if c.RelativePath != candidates[0].RelativePath || c.Ref != candidates[0].Ref {
+				names := make([]string, len(candidates))
+				for i, c := range candidates {
+					names[i] = fmt.Sprintf("%s (%s#/%d)", c.ResourceType, c.RelativePath, c.Ref)
+				}
+				return nil, fmt.Errorf("resource %s@%s is ambiguous: the index has %s; use the exact casing of one of them", resourceType, apiVersion, strings.Join(names, ", "))
+			}
+		}
+		ref, _ = idx.GetResource(candidates[0].ResourceType, apiVersion)
 	}
 
-	// Handle both pointer and value types of CrossFileTypeReference.
-	// BuildIndex and JSON unmarshaling produce value types, while programmatic
-	// construction may use pointers.
+	if r, ok := asCrossFileReference(ref); ok {
+		return r, nil
+	}
+	return nil, fmt.Errorf("unexpected reference type %T for %s@%s: expected CrossFileTypeReference", ref, resourceType, apiVersion)
+}
+
+// asCrossFileReference returns ref as a CrossFileTypeReference. The
+// bicep-types-go index package may hold either value or pointer references:
+// BuildIndex and JSON unmarshaling produce value types, while programmatic
+// construction may use pointers.
+func asCrossFileReference(ref types.ITypeReference) (*types.CrossFileTypeReference, bool) {
 	switch r := ref.(type) {
 	case *types.CrossFileTypeReference:
-		return r, nil
+		return r, true
 	case types.CrossFileTypeReference:
-		return &r, nil
-	default:
-		return nil, fmt.Errorf("unexpected reference type %T for %s@%s: expected CrossFileTypeReference", ref, resourceType, apiVersion)
+		return &r, true
 	}
+	return nil, false
 }
 
-// ListVersions returns all available API versions for a given resource type.
+// ListVersions returns all available API versions for a given resource type,
+// sorted, across every casing of the type in the index.
 func ListVersions(idx *index.TypeIndex, resourceType string) []string {
-	// The index stores resource types case-insensitively, so we need to search.
-	for rt, versionMap := range idx.Resources {
-		if strings.EqualFold(rt, resourceType) {
-			versions := make([]string, 0, len(versionMap))
-			for v := range versionMap {
-				versions = append(versions, v)
-			}
-			return versions
+	seen := map[string]bool{}
+	var versions []string
+	for _, m := range FindResources(idx, resourceType) {
+		if !seen[m.APIVersion] {
+			seen[m.APIVersion] = true
+			versions = append(versions, m.APIVersion)
 		}
 	}
-	return nil
+	return versions
 }
 
 // ChildEntry represents a child resource type discovered from the index.
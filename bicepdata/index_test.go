@@ -123,6 +123,8 @@ func TestLookupResource_NotFound_APIVersion(t *testing.T) {
 	_, err := LookupResource(idx, "Microsoft.App/containerApps", "2099-01-01")
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "not found in index")
+	assert.Contains(t, err.Error(), "available API versions: 2025-01-01")
+	assert.ErrorIs(t, err, ErrResourceNotFound)
 }
 
 func TestLookupResource_NonCrossFileRef(t *testing.T) {
@@ -135,6 +137,79 @@ func TestLookupResource_NonCrossFileRef(t *testing.T) {
 	assert.Contains(t, err.Error(), "expected CrossFileTypeReference")
 }
 
+func TestLookupResource_OtherCasing(t *testing.T) {
+	idx := newTestIndex(map[string]map[string]*types.CrossFileTypeReference{
+		"Microsoft.Web/sites": {
+			"2024-01-01": {RelativePath: "web/types.json", Ref: 3},
+		},
+	})
+
+	ref, err := LookupResource(idx, "microsoft.web/Sites", "2024-01-01")
+	require.NoError(t, err)
+	assert.Equal(t, "web/types.json", ref.RelativePath)
+	assert.Equal(t, 3, ref.Ref)
+}
+
+func TestLookupResource_AmbiguousCasing(t *testing.T) {
+	idx := newTestIndex(map[string]map[string]*types.CrossFileTypeReference{
+		"Microsoft.Web/sites": {
+			"2024-01-01": {RelativePath: "web/a/types.json", Ref: 3},
+		},
+		"Microsoft.Web/Sites": {
+			"2024-01-01": {RelativePath: "web/b/types.json", Ref: 7},
+		},
+	})
+
+	_, err := LookupResource(idx, "microsoft.web/sites", "2024-01-01")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "ambiguous")
+	assert.Contains(t, err.Error(), "Microsoft.Web/Sites (web/b/types.json#/7)")
+	assert.Contains(t, err.Error(), "Microsoft.Web/sites (web/a/types.json#/3)")
+
+	// The exact casing selects its entry.
+	ref, err := LookupResource(idx, "Microsoft.Web/Sites", "2024-01-01")
+	require.NoError(t, err)
+	assert.Equal(t, "web/b/types.json", ref.RelativePath)
+}
+
+// --- FindResources ---
+
+func TestFindResources_AllCasingsSorted(t *testing.T) {
+	idx := newTestIndex(map[string]map[string]*types.CrossFileTypeReference{
+		"Microsoft.Web/sites": {
+			"2024-01-01":         {RelativePath: "web/2024-01-01/types.json", Ref: 3},
+			"2024-06-01-preview": {RelativePath: "web/2024-06-01-preview/types.json", Ref: 4},
+		},
+		"Microsoft.Web/Sites": {
+			"2020-01-01": {RelativePath: "web/2020-01-01/types.json", Ref: 5},
+			"2024-01-01": {RelativePath: "web/2024-01-01/types.json", Ref: 3},
+		},
+		"Microsoft.Web/serverfarms": {
+			"2024-01-01": {RelativePath: "web/2024-01-01/types.json", Ref: 9},
+		},
+	})
+
+	matches := FindResources(idx, "Microsoft.Web/sites")
+	assert.Equal(t, []ResourceMatch{
+		{ResourceType: "Microsoft.Web/Sites", APIVersion: "2020-01-01", RelativePath: "web/2020-01-01/types.json", Ref: 5},
+		{ResourceType: "Microsoft.Web/sites", APIVersion: "2024-01-01", RelativePath: "web/2024-01-01/types.json", Ref: 3, ExactCase: true},
+		{ResourceType: "Microsoft.Web/Sites", APIVersion: "2024-01-01", RelativePath: "web/2024-01-01/types.json", Ref: 3},
+		{ResourceType: "Microsoft.Web/sites", APIVersion: "2024-06-01-preview", RelativePath: "web/2024-06-01-preview/types.json", Ref: 4, Preview: true, ExactCase: true},
+	}, matches)
+
+	assert.Equal(t, []string{"2020-01-01", "2024-01-01", "2024-06-01-preview"}, ListVersions(idx, "microsoft.web/sites"))
+}
+
+func TestFindResources_NotFound(t *testing.T) {
+	idx := newTestIndex(map[string]map[string]*types.CrossFileTypeReference{
+		"Microsoft.Web/sites": {
+			"2024-01-01": {RelativePath: "types.json", Ref: 0},
+		},
+	})
+
+	assert.Empty(t, FindResources(idx, "Microsoft.Web/site"))
+}
+
 // --- ListVersions ---
 
 func TestListVersions_Found(t *testing.T) {
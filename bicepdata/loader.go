@@ -3,11 +3,11 @@ package bicepdata
 import (
 	"context"
 	"fmt"
-	"sort"
 	"strings"
 
 	"github.com/Azure/bicep-types/src/bicep-types-go/index"
 	"github.com/Azure/bicep-types/src/bicep-types-go/types"
+	"github.com/matt-FFFFFF/tfmodmake/profile"
 )
 
 // LoadedResource contains a resolved resource type and its supporting type array.
@@ -15,7 +15,9 @@ type LoadedResource struct {
 	// ResourceType is the resolved ResourceType entry from types.json.
 	ResourceType *types.ResourceType
 
-	// Types is the full type array from the types.json file, used to resolve type references.
+	// Types is the type array from the types.json file, used to resolve type
+	// references. Only the entries the resource type references are parsed; the
+	// others are nil.
 	Types []types.Type
 
 	// APIVersion is the resolved API version.
@@ -23,29 +25,48 @@ type LoadedResource struct {
 
 	// ResourceTypeName is the fully qualified resource type name (e.g. "Microsoft.App/containerApps").
 	ResourceTypeName string
+
+	// SingletonName is the static name the requested instance path ended in,
+	// e.g. "default", or empty when the resource was requested by type.
+	SingletonName string
 }
 
 // LoadResource orchestrates loading a resource type: fetch index, lookup resource,
 // fetch types.json, and return the resolved ResourceType.
 // If apiVersion is empty, the latest stable version is selected (or latest preview if includePreview is true).
 func LoadResource(ctx context.Context, resourceType, apiVersion string, includePreview bool, opts *FetchOptions) (*LoadedResource, error) {
-	// Fetch and parse the index
-	indexData, err := FetchIndex(ctx, opts)
-	if err != nil {
-		return nil, fmt.Errorf("fetching index: %w", err)
-	}
-
-	idx, err := ParseIndex(indexData)
+	idx, err := LoadIndex(ctx, opts)
 	if err != nil {
-		return nil, fmt.Errorf("parsing index: %w", err)
+		return nil, err
 	}
 
 	return LoadResourceFromIndex(ctx, idx, resourceType, apiVersion, includePreview, opts)
 }
 
+// LoadIndex fetches and parses the index, once per cache.
+func LoadIndex(ctx context.Context, opts *FetchOptions) (*index.TypeIndex, error) {
+	return cached("index.json", opts, func() (*index.TypeIndex, error) {
+		indexData, err := FetchIndex(ctx, opts)
+		if err != nil {
+			return nil, fmt.Errorf("fetching index: %w", err)
+		}
+
+		done := profile.FromContext(ctx).Track(profile.PhaseParse)
+		idx, err := ParseIndex(indexData)
+		done()
+		if err != nil {
+			return nil, fmt.Errorf("parsing index: %w", err)
+		}
+		return idx, nil
+	})
+}
+
 // LoadResourceFromIndex loads a resource type using a pre-fetched index.
 // This is useful when you need to perform multiple lookups against the same index.
+// The resource may be given as an instance path, see ParseResourcePath.
 func LoadResourceFromIndex(ctx context.Context, idx *index.TypeIndex, resourceType, apiVersion string, includePreview bool, opts *FetchOptions) (*LoadedResource, error) {
+	resourceType, singletonName := ParseResourcePath(resourceType)
+
 	// Resolve API version if not specified
 	if apiVersion == "" {
 		var err error
@@ -61,16 +82,19 @@ func LoadResourceFromIndex(ctx context.Context, idx *index.TypeIndex, resourceTy
 		return nil, err
 	}
 
-	// Fetch and parse the types.json file
-	typesArray, err := FetchTypes(ctx, crossRef.RelativePath, opts)
+	// Fetch the types.json file and parse the part of it the resource needs
+	file, err := FetchTypes(ctx, crossRef.RelativePath, opts)
 	if err != nil {
 		return nil, fmt.Errorf("fetching types for %s@%s: %w", resourceType, apiVersion, err)
 	}
-
-	// Resolve the ResourceType entry from the types array
-	if crossRef.Ref < 0 || crossRef.Ref >= len(typesArray) {
-		return nil, fmt.Errorf("type reference index %d out of bounds (array length %d) for %s@%s",
-			crossRef.Ref, len(typesArray), resourceType, apiVersion)
+	if opts == nil || opts.Cache == nil {
+		defer file.Close()
+	}
+	done := profile.FromContext(ctx).Track(profile.PhaseParse)
+	typesArray, err := deserializeResourceTypes(file, file.Size(), crossRef.Ref, resourceType, apiVersion)
+	done()
+	if err != nil {
+		return nil, fmt.Errorf("parsing types for %s@%s: %w", resourceType, apiVersion, err)
 	}
 
 	rt, ok := typesArray[crossRef.Ref].(*types.ResourceType)
@@ -84,6 +108,7 @@ func LoadResourceFromIndex(ctx context.Context, idx *index.TypeIndex, resourceTy
 		Types:            typesArray,
 		APIVersion:       apiVersion,
 		ResourceTypeName: resourceType,
+		SingletonName:    singletonName,
 	}, nil
 }
 
@@ -117,53 +142,26 @@ func (lr *LoadedResource) ResolveType(ref types.ITypeReference) (types.Type, err
 // When includePreview is true, both stable and preview versions are compared
 // and the overall latest (by lexicographic sort) is returned.
 func resolveLatestVersion(idx *index.TypeIndex, resourceType string, includePreview bool) (string, error) {
-	versions := ListVersions(idx, resourceType)
-	if len(versions) == 0 {
-		return "", fmt.Errorf("no API versions found for resource type %s", resourceType)
-	}
+	choice, err := ResolveVersion(idx, resourceType, VersionPolicy{IncludePreview: includePreview})
+	return choice.Version, err
+}
 
-	// Separate stable and preview versions
-	var stable, preview []string
+// PreferredVersion returns the latest stable version in versions or, when
+// includePreview is true, the latest version overall. It returns an empty string
+// when no version qualifies.
+func PreferredVersion(versions []string, includePreview bool) string {
+	preferred := ""
 	for _, v := range versions {
-		if isPreviewVersion(v) {
-			preview = append(preview, v)
-		} else {
-			stable = append(stable, v)
-		}
-	}
-
-	// Sort in descending order (latest first)
-	sort.Sort(sort.Reverse(sort.StringSlice(stable)))
-	sort.Sort(sort.Reverse(sort.StringSlice(preview)))
-
-	if includePreview {
-		// Compare latest stable and latest preview, return whichever is newer.
-		// API versions are date-based (YYYY-MM-DD[-preview]) so lexicographic
-		// comparison works correctly.
-		var candidates []string
-		if len(stable) > 0 {
-			candidates = append(candidates, stable[0])
+		if !includePreview && isPreviewVersion(v) {
+			continue
 		}
-		if len(preview) > 0 {
-			candidates = append(candidates, preview[0])
+		// API versions are date-based (YYYY-MM-DD[-preview]), so lexicographic
+		// comparison orders them.
+		if v > preferred {
+			preferred = v
 		}
-		if len(candidates) == 0 {
-			return "", fmt.Errorf("no API versions found for resource type %s", resourceType)
-		}
-		sort.Sort(sort.Reverse(sort.StringSlice(candidates)))
-		return candidates[0], nil
-	}
-
-	if len(stable) > 0 {
-		return stable[0], nil
 	}
-
-	if len(preview) > 0 {
-		return "", fmt.Errorf("no stable API versions found for %s (preview versions available: %s); use --include-preview to select one",
-			resourceType, strings.Join(preview, ", "))
-	}
-
-	return "", fmt.Errorf("no API versions found for resource type %s", resourceType)
+	return preferred
 }
 
 // isPreviewVersion returns true if the API version string contains "preview".
@@ -541,3 +541,14 @@ func buildTypesJSONLoader(t *testing.T, typeObjs ...types.Type) []byte {
 	require.NoError(t, err)
 	return data
 }
+
+// --- PreferredVersion ---
+
+func TestPreferredVersion(t *testing.T) {
+	versions := []string{"2023-01-01", "2025-06-01-preview", "2025-01-01", "2024-01-01-preview"}
+
+	assert.Equal(t, "2025-01-01", PreferredVersion(versions, false))
+	assert.Equal(t, "2025-06-01-preview", PreferredVersion(versions, true))
+	assert.Empty(t, PreferredVersion([]string{"2024-01-01-preview"}, false))
+	assert.Empty(t, PreferredVersion(nil, true))
+}
@@ -0,0 +1,52 @@
+package bicepdata
+
+import "strings"
+
+// ParseResourcePath returns the resource type an ARM instance path addresses,
+// and the fixed name it ends in, if any. The path may be a full template, e.g.
+// /subscriptions/{subscriptionId}/providers/Microsoft.Sql/servers/{serverName},
+// or start at the provider namespace. Below the namespace the segments alternate
+// between a type and a name; names in braces are parameters, while a name
+// without braces is static, as in the paths of singletons:
+//
+//	Microsoft.Sql/servers/{serverName}/advancedThreatProtectionSettings/current
+//
+// addresses Microsoft.Sql/servers/advancedThreatProtectionSettings named
+// "current". Strings that neither start with a slash nor hold a parameter or a
+// providers segment are resource types already and are returned unchanged.
+func ParseResourcePath(path string) (resourceType, name string) {
+	if !isResourcePath(path) {
+		return path, ""
+	}
+	if i := strings.LastIndex(strings.ToLower(path), "/providers/"); i >= 0 {
+		path = path[i+len("/providers/"):]
+	}
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	typeSegments := segments[:1]
+	for i := 1; i < len(segments); i += 2 {
+		typeSegments = append(typeSegments, segments[i])
+		name = ""
+		if i+1 < len(segments) && !isPathParameter(segments[i+1]) {
+			name = segments[i+1]
+		}
+	}
+	return strings.Join(typeSegments, "/"), name
+}
+
+// isResourcePath reports whether s is an instance path rather than a resource
+// type.
+func isResourcePath(s string) bool {
+	if strings.HasPrefix(s, "/") || strings.Contains(strings.ToLower(s), "/providers/") {
+		return true
+	}
+	for _, segment := range strings.Split(s, "/") {
+		if isPathParameter(segment) {
+			return true
+		}
+	}
+	return false
+}
+
+func isPathParameter(segment string) bool {
+	return strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}")
+}
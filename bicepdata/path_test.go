@@ -0,0 +1,40 @@
+package bicepdata
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseResourcePath(t *testing.T) {
+	tests := []struct {
+		path, resourceType, name string
+	}{
+		{"Microsoft.App/containerApps", "Microsoft.App/containerApps", ""},
+		{"Microsoft.Web/sites/config", "Microsoft.Web/sites/config", ""},
+		{"Microsoft.CustomProviders/resourceProviders", "Microsoft.CustomProviders/resourceProviders", ""},
+		{"Microsoft.App/containerApps/{containerAppName}", "Microsoft.App/containerApps", ""},
+		{"Microsoft.Sql/servers/{serverName}/databases/{databaseName}", "Microsoft.Sql/servers/databases", ""},
+		{"Microsoft.Sql/servers/{serverName}/advancedThreatProtectionSettings/current", "Microsoft.Sql/servers/advancedThreatProtectionSettings", "current"},
+		{"Microsoft.Web/sites/{name}/config/web", "Microsoft.Web/sites/config", "web"},
+		{
+			"/subscriptions/{subscriptionId}/resourceGroups/{resourceGroupName}/providers/Microsoft.Sql/servers/{serverName}/databases/{databaseName}/advancedThreatProtectionSettings/Default",
+			"Microsoft.Sql/servers/databases/advancedThreatProtectionSettings", "Default",
+		},
+		{
+			"/{scope}/providers/Microsoft.Security/pricings/{pricingName}",
+			"Microsoft.Security/pricings", "",
+		},
+		{
+			"/subscriptions/{subscriptionId}/providers/Microsoft.Security/autoProvisioningSettings/default",
+			"Microsoft.Security/autoProvisioningSettings", "default",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			resourceType, name := ParseResourcePath(tt.path)
+			assert.Equal(t, tt.resourceType, resourceType)
+			assert.Equal(t, tt.name, name)
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package bicepdata
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/matt-FFFFFF/tfmodmake/profile"
+)
+
+// DefaultStreamingThreshold is the size in bytes above which a types file is
+// not held in memory: it is parsed from disk, a download being written to a
+// temporary file first, and only the entries the resource needs are read.
+const DefaultStreamingThreshold int64 = 32 << 20
+
+func (o *FetchOptions) streamingThreshold() int64 {
+	if o == nil || o.StreamingThreshold == 0 {
+		return DefaultStreamingThreshold
+	}
+	return o.StreamingThreshold
+}
+
+// streams reports whether a file of size bytes is parsed from disk.
+func (o *FetchOptions) streams(size int64) bool {
+	threshold := o.streamingThreshold()
+	return threshold > 0 && size > threshold
+}
+
+// TypesFile is the content of a types file, in memory or, above the streaming
+// threshold, in a file on disk. It is read through ReadAt, so a file on disk is
+// never read whole.
+type TypesFile struct {
+	data []byte
+	file *os.File
+	size int64
+	// temp is set for a download spooled to a temporary file that could not
+	// be removed while open; Close removes it.
+	temp bool
+}
+
+// ReadAt implements io.ReaderAt over the content.
+func (f *TypesFile) ReadAt(p []byte, off int64) (int, error) {
+	if f.file != nil {
+		return f.file.ReadAt(p, off)
+	}
+	return bytes.NewReader(f.data).ReadAt(p, off)
+}
+
+// Size returns the size of the content in bytes.
+func (f *TypesFile) Size() int64 {
+	return f.size
+}
+
+// Close releases the file on disk, if any, removing a temporary download.
+func (f *TypesFile) Close() error {
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	if f.temp {
+		_ = os.Remove(f.file.Name())
+	}
+	return err
+}
+
+// fetchTypesFile retrieves a types file like fetchFile, but leaves files above
+// the streaming threshold on disk.
+func fetchTypesFile(ctx context.Context, relativePath string, opts *FetchOptions) (*TypesFile, error) {
+	defer profile.FromContext(ctx).Track(profile.PhaseFetch)()
+
+	if opts != nil && opts.LocalPath != "" {
+		path := filepath.Join(opts.LocalPath, "generated", relativePath)
+		f, err := openTypesFile(path, opts)
+		if err != nil {
+			return nil, &FetchError{Path: relativePath, Source: path, Local: true, Err: err}
+		}
+		return f, nil
+	}
+
+	if opts != nil && opts.CacheDir != "" {
+		if f, err := openTypesFile(filepath.Join(opts.CacheDir, relativePath), opts); err == nil {
+			return f, nil
+		}
+	}
+
+	return downloadTypesFile(ctx, relativePath, opts)
+}
+
+// openTypesFile reads the file at path, or opens it when it is above the
+// streaming threshold.
+func openTypesFile(path string, opts *FetchOptions) (*TypesFile, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	if opts.streams(info.Size()) {
+		return &TypesFile{file: file, size: info.Size()}, nil
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+	return &TypesFile{data: data, size: int64(len(data))}, nil
+}
+
+// downloadTypesFile downloads a types file into memory or, once it exceeds the
+// streaming threshold, into the cache directory or a temporary file, so at most
+// the threshold is buffered.
+func downloadTypesFile(ctx context.Context, relativePath string, opts *FetchOptions) (*TypesFile, error) {
+	resp, err := get(ctx, relativePath, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	url := resp.Request.URL.String()
+	fail := func(err error) (*TypesFile, error) {
+		return nil, &FetchError{Path: relativePath, Source: url, Err: fmt.Errorf("reading response body: %w", err)}
+	}
+
+	var head []byte
+	if threshold := opts.streamingThreshold(); threshold > 0 {
+		head, err = io.ReadAll(io.LimitReader(resp.Body, threshold+1))
+	} else {
+		head, err = io.ReadAll(resp.Body)
+	}
+	if err != nil {
+		return fail(err)
+	}
+	if !opts.streams(int64(len(head))) {
+		if opts != nil && opts.CacheDir != "" {
+			_ = writeCacheFile(opts.CacheDir, relativePath, head)
+		}
+		return &TypesFile{data: head, size: int64(len(head))}, nil
+	}
+
+	dir, cachePath := os.TempDir(), ""
+	if opts != nil && opts.CacheDir != "" {
+		cachePath = filepath.Join(opts.CacheDir, relativePath)
+		dir = filepath.Dir(cachePath)
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fail(err)
+		}
+	}
+	tmp, err := os.CreateTemp(dir, "types-*.json")
+	if err != nil {
+		return fail(err)
+	}
+	size, err := io.Copy(tmp, io.MultiReader(bytes.NewReader(head), resp.Body))
+	if err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fail(err)
+	}
+
+	if cachePath != "" {
+		// The download becomes the cache entry, reopened after the rename.
+		tmp.Close()
+		if err := os.Rename(tmp.Name(), cachePath); err != nil {
+			os.Remove(tmp.Name())
+			return fail(err)
+		}
+		file, err := os.Open(cachePath)
+		if err != nil {
+			return fail(err)
+		}
+		return &TypesFile{file: file, size: size}, nil
+	}
+	// The open file stays readable once removed, except on Windows, where it is
+	// removed when closed.
+	return &TypesFile{file: tmp, size: size, temp: os.Remove(tmp.Name()) != nil}, nil
+}
@@ -0,0 +1,116 @@
+package bicepdata
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/bicep-types/src/bicep-types-go/index"
+	"github.com/Azure/bicep-types/src/bicep-types-go/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// streamingTypesJSON returns a types file holding Microsoft.App/containerApps
+// at index 0, its body and an unrelated type that is not parsed.
+func streamingTypesJSON(t *testing.T) []byte {
+	return buildTypesJSONLoader(t,
+		&types.ResourceType{Name: "Microsoft.App/containerApps@2025-01-01", Body: types.TypeReference{Ref: 1}, WritableScopes: types.ScopeTypeResourceGroup},
+		&types.ObjectType{Name: "Microsoft.App/containerApps", Properties: map[string]types.ObjectTypeProperty{
+			"name": {Type: types.TypeReference{Ref: 2}, Flags: types.TypePropertyFlagsRequired},
+		}},
+		&types.StringType{},
+		&types.ObjectType{Name: "Unrelated", Properties: map[string]types.ObjectTypeProperty{}},
+	)
+}
+
+func streamingIndex() *index.TypeIndex {
+	idx := index.NewTypeIndex()
+	idx.AddResource("Microsoft.App/containerApps", "2025-01-01",
+		&types.CrossFileTypeReference{RelativePath: "microsoft.app/2025-01-01/types.json", Ref: 0})
+	return idx
+}
+
+func assertStreamedResource(t *testing.T, loaded *LoadedResource) {
+	t.Helper()
+	assert.Equal(t, "Microsoft.App/containerApps@2025-01-01", loaded.ResourceType.Name)
+	require.Len(t, loaded.Types, 4)
+	assert.IsType(t, &types.StringType{}, loaded.Types[2])
+	assert.Nil(t, loaded.Types[3], "unreferenced entries are not parsed")
+}
+
+func TestLoadResourceFromIndex_StreamsLocalFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "generated", "microsoft.app", "2025-01-01", "types.json")
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, streamingTypesJSON(t), 0o644))
+
+	opts := &FetchOptions{LocalPath: dir, StreamingThreshold: 16}
+	f, err := FetchTypes(context.Background(), "microsoft.app/2025-01-01/types.json", opts)
+	require.NoError(t, err)
+	assert.NotNil(t, f.file, "a file above the threshold is left on disk")
+	assert.Nil(t, f.data)
+	require.NoError(t, f.Close())
+
+	loaded, err := LoadResourceFromIndex(context.Background(), streamingIndex(), "Microsoft.App/containerApps", "2025-01-01", false, opts)
+	require.NoError(t, err)
+	assertStreamedResource(t, loaded)
+
+	// A shared cache keeps the file open for the loads after the first.
+	opts.Cache = NewCache()
+	for range 2 {
+		loaded, err := LoadResourceFromIndex(context.Background(), streamingIndex(), "Microsoft.App/containerApps", "2025-01-01", false, opts)
+		require.NoError(t, err)
+		assertStreamedResource(t, loaded)
+	}
+}
+
+func TestLoadResourceFromIndex_StreamsDownload(t *testing.T) {
+	content := streamingTypesJSON(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(content)
+	}))
+	defer srv.Close()
+
+	t.Run("temporary file", func(t *testing.T) {
+		opts := &FetchOptions{BaseURL: srv.URL, StreamingThreshold: 16}
+		f, err := FetchTypes(context.Background(), "microsoft.app/2025-01-01/types.json", opts)
+		require.NoError(t, err)
+		require.NotNil(t, f.file)
+		data, err := io.ReadAll(io.NewSectionReader(f, 0, f.Size()))
+		require.NoError(t, err)
+		assert.Equal(t, content, data)
+		require.NoError(t, f.Close())
+
+		loaded, err := LoadResourceFromIndex(context.Background(), streamingIndex(), "Microsoft.App/containerApps", "2025-01-01", false, opts)
+		require.NoError(t, err)
+		assertStreamedResource(t, loaded)
+	})
+
+	t.Run("cache directory", func(t *testing.T) {
+		cacheDir := t.TempDir()
+		opts := &FetchOptions{BaseURL: srv.URL, CacheDir: cacheDir, StreamingThreshold: 16}
+		loaded, err := LoadResourceFromIndex(context.Background(), streamingIndex(), "Microsoft.App/containerApps", "2025-01-01", false, opts)
+		require.NoError(t, err)
+		assertStreamedResource(t, loaded)
+
+		cached, err := os.ReadFile(filepath.Join(cacheDir, "microsoft.app", "2025-01-01", "types.json"))
+		require.NoError(t, err)
+		assert.Equal(t, content, cached, "the spooled download becomes the cache entry")
+		entries, err := os.ReadDir(filepath.Join(cacheDir, "microsoft.app", "2025-01-01"))
+		require.NoError(t, err)
+		assert.Len(t, entries, 1, "no temporary file is left behind")
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		opts := &FetchOptions{BaseURL: srv.URL, StreamingThreshold: -1}
+		f, err := FetchTypes(context.Background(), "microsoft.app/2025-01-01/types.json", opts)
+		require.NoError(t, err)
+		assert.Nil(t, f.file)
+		assert.Equal(t, content, f.data)
+	})
+}
@@ -0,0 +1,133 @@
+package bicepdata
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Azure/bicep-types/src/bicep-types-go/types"
+)
+
+// typeHeader is the part of a type entry read for every entry of a types file.
+// Decoding it skips the rest of the entry without building it.
+type typeHeader struct {
+	Type         string `json:"$type"`
+	ResourceType string `json:"resourceType"`
+	APIVersion   string `json:"apiVersion"`
+}
+
+// DeserializeResourceTypes parses the entries of a types.json byte slice that a
+// resource type needs: the ResourceType entry at ref, the resource functions of
+// resourceType at apiVersion, and every type they reference, transitively. The
+// other entries are left nil, so references keep their indices. A types file
+// holds every resource of a provider API version, which for large providers is
+// many times what one resource needs; the skipped entries are only scanned.
+func DeserializeResourceTypes(data []byte, ref int, resourceType, apiVersion string) ([]types.Type, error) {
+	return deserializeResourceTypes(bytes.NewReader(data), int64(len(data)), ref, resourceType, apiVersion)
+}
+
+// deserializeResourceTypes is DeserializeResourceTypes reading the size bytes
+// of r. The entries are scanned as a stream and the needed ones read back by
+// offset, so only one entry at a time is buffered besides those kept; r can be
+// a file too large to hold in memory.
+func deserializeResourceTypes(r io.ReaderAt, size int64, ref int, resourceType, apiVersion string) ([]types.Type, error) {
+	dec := json.NewDecoder(io.NewSectionReader(r, 0, size))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('[') {
+		return nil, fmt.Errorf("parsing types array: expected an array")
+	}
+
+	type span struct{ start, end int64 }
+	var spans []span
+	roots := []int{ref}
+	for dec.More() {
+		start := dec.InputOffset()
+		var header typeHeader
+		if err := dec.Decode(&header); err != nil {
+			return nil, fmt.Errorf("parsing types array: %w", err)
+		}
+		if header.Type == "ResourceFunctionType" && strings.EqualFold(header.ResourceType, resourceType) && strings.EqualFold(header.APIVersion, apiVersion) {
+			roots = append(roots, len(spans))
+		}
+		spans = append(spans, span{start, dec.InputOffset()})
+	}
+	if ref < 0 || ref >= len(spans) {
+		return nil, fmt.Errorf("type reference index %d out of bounds (array length %d)", ref, len(spans))
+	}
+
+	result := make([]types.Type, len(spans))
+	queue := roots
+	for len(queue) > 0 {
+		i := queue[0]
+		queue = queue[1:]
+		if i < 0 || i >= len(spans) || result[i] != nil {
+			continue
+		}
+		// The span of an entry starts at the separator after the previous one.
+		raw := make([]byte, spans[i].end-spans[i].start)
+		if _, err := r.ReadAt(raw, spans[i].start); err != nil && err != io.EOF {
+			return nil, fmt.Errorf("reading type at index %d: %w", i, err)
+		}
+		raw = bytes.TrimLeft(raw, ", \t\r\n")
+		t, err := types.UnmarshalType(raw)
+		if err != nil {
+			return nil, fmt.Errorf("unmarshaling type at index %d: %w", i, err)
+		}
+		result[i] = t
+		queue = append(queue, typeReferences(t)...)
+	}
+	return result, nil
+}
+
+// typeReferences returns the indices of the types in the same file that t refers to.
+func typeReferences(t types.Type) []int {
+	var refs []int
+	add := func(ref types.ITypeReference) {
+		switch r := ref.(type) {
+		case types.TypeReference:
+			refs = append(refs, r.Ref)
+		case *types.TypeReference:
+			refs = append(refs, r.Ref)
+		}
+	}
+	switch tt := t.(type) {
+	case *types.ObjectType:
+		for _, p := range tt.Properties {
+			add(p.Type)
+		}
+		add(tt.AdditionalProperties)
+	case *types.DiscriminatedObjectType:
+		for _, p := range tt.BaseProperties {
+			add(p.Type)
+		}
+		for _, e := range tt.Elements {
+			add(e)
+		}
+	case *types.ResourceType:
+		add(tt.Body)
+		for _, f := range tt.Functions {
+			add(f.Type)
+		}
+	case *types.ArrayType:
+		add(tt.ItemType)
+	case *types.UnionType:
+		for _, e := range tt.Elements {
+			add(e)
+		}
+	case *types.ResourceFunctionType:
+		add(tt.Output)
+		add(tt.Input)
+	case *types.FunctionType:
+		for _, p := range tt.Parameters {
+			add(p.Type)
+		}
+		add(tt.Output)
+	case *types.NamespaceFunctionType:
+		for _, p := range tt.Parameters {
+			add(p.Type)
+		}
+		add(tt.OutputType)
+	}
+	return refs
+}
@@ -0,0 +1,68 @@
+package bicepdata
+
+import (
+	"testing"
+
+	"github.com/Azure/bicep-types/src/bicep-types-go/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeserializeResourceTypes(t *testing.T) {
+	data := buildTypesJSONLoader(t,
+		// 0-3: containerApps, with a self-referencing body and an action.
+		&types.ResourceType{Name: "Microsoft.App/containerApps@2025-01-01", Body: types.TypeReference{Ref: 1}},
+		&types.ObjectType{Name: "ContainerApp", Properties: map[string]types.ObjectTypeProperty{
+			"name":     {Type: types.TypeReference{Ref: 2}},
+			"children": {Type: types.TypeReference{Ref: 3}},
+		}},
+		&types.StringType{},
+		&types.ArrayType{ItemType: types.TypeReference{Ref: 1}},
+		// 4-6: jobs, not referenced by containerApps.
+		&types.ResourceType{Name: "Microsoft.App/jobs@2025-01-01", Body: types.TypeReference{Ref: 5}},
+		&types.ObjectType{Name: "Job", Properties: map[string]types.ObjectTypeProperty{
+			"schedule": {Type: types.TypeReference{Ref: 6}},
+		}},
+		&types.UnionType{Elements: []types.ITypeReference{types.TypeReference{Ref: 2}}},
+		// 7-8: the listSecrets action of containerApps and its output.
+		&types.ResourceFunctionType{Name: "listSecrets", ResourceType: "Microsoft.App/containerApps", ApiVersion: "2025-01-01", Output: types.TypeReference{Ref: 8}},
+		&types.ObjectType{Name: "Secrets", Properties: map[string]types.ObjectTypeProperty{}},
+		// 9: the same action of another API version.
+		&types.ResourceFunctionType{Name: "listSecrets", ResourceType: "Microsoft.App/containerApps", ApiVersion: "2024-01-01", Output: types.TypeReference{Ref: 5}},
+	)
+
+	result, err := DeserializeResourceTypes(data, 0, "microsoft.app/containerapps", "2025-01-01")
+	require.NoError(t, err)
+	require.Len(t, result, 10)
+
+	for _, i := range []int{0, 1, 2, 3, 7, 8} {
+		assert.NotNil(t, result[i], "entry %d is needed by containerApps", i)
+	}
+	for _, i := range []int{4, 5, 6, 9} {
+		assert.Nil(t, result[i], "entry %d is not needed by containerApps", i)
+	}
+	assert.Equal(t, "ContainerApp", result[1].(*types.ObjectType).Name)
+	assert.Equal(t, "listSecrets", result[7].(*types.ResourceFunctionType).Name)
+
+	// Parsing the whole file yields the same entries.
+	full, err := DeserializeTypes(data)
+	require.NoError(t, err)
+	for i, entry := range result {
+		if entry != nil {
+			assert.Equal(t, full[i], entry)
+		}
+	}
+}
+
+func TestDeserializeResourceTypes_Errors(t *testing.T) {
+	data := buildTypesJSONLoader(t, &types.StringType{})
+
+	_, err := DeserializeResourceTypes(data, 3, "Microsoft.App/containerApps", "2025-01-01")
+	assert.ErrorContains(t, err, "type reference index 3 out of bounds (array length 1)")
+
+	_, err = DeserializeResourceTypes([]byte(`{"not": "an array"}`), 0, "Microsoft.App/containerApps", "2025-01-01")
+	assert.ErrorContains(t, err, "expected an array")
+
+	_, err = DeserializeResourceTypes([]byte(`[{"$type": "StringType"}, {"$type": "Unknown"}`), 0, "Microsoft.App/containerApps", "2025-01-01")
+	assert.Error(t, err)
+}
@@ -0,0 +1,125 @@
+package bicepdata
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Azure/bicep-types/src/bicep-types-go/index"
+)
+
+// VersionPolicy controls which API version is selected for a resource type
+// when none is given.
+type VersionPolicy struct {
+	// IncludePreview allows preview versions to be selected.
+	IncludePreview bool
+	// PreferStable selects the latest stable version even when a newer preview
+	// is allowed, falling back to a preview only when no stable version
+	// qualifies.
+	PreferStable bool
+	// MinVersion, when set, is the oldest API version that may be used, e.g.
+	// "2024-01-01". Previews of the same date qualify.
+	MinVersion string
+}
+
+// VersionChoice is the API version a VersionPolicy selected and why.
+type VersionChoice struct {
+	Version string
+	Reason  string
+}
+
+// apiVersionDate matches the date an API version starts with.
+var apiVersionDate = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}`)
+
+// Validate reports a MinVersion that is not an API version.
+func (p VersionPolicy) Validate() error {
+	if p.MinVersion != "" && !apiVersionDate.MatchString(p.MinVersion) {
+		return fmt.Errorf("minimum API version %q is not an API version (YYYY-MM-DD[-preview])", p.MinVersion)
+	}
+	return nil
+}
+
+// Check reports a version the policy does not allow: one older than
+// MinVersion. Explicitly requested versions may be previews.
+func (p VersionPolicy) Check(version string) error {
+	if p.MinVersion != "" && version < p.MinVersion {
+		return fmt.Errorf("API version %s is older than the minimum API version %s", version, p.MinVersion)
+	}
+	return nil
+}
+
+// SelectVersion selects the version of versions the policy prefers. It reports
+// false when no version qualifies.
+func SelectVersion(versions []string, p VersionPolicy) (VersionChoice, bool) {
+	// API versions are date-based (YYYY-MM-DD[-preview]), so lexicographic
+	// comparison orders them.
+	var stable, preview string
+	for _, v := range versions {
+		if p.Check(v) != nil {
+			continue
+		}
+		if isPreviewVersion(v) {
+			preview = max(preview, v)
+		} else {
+			stable = max(stable, v)
+		}
+	}
+
+	floor := ""
+	if p.MinVersion != "" {
+		floor = " at or after " + p.MinVersion
+	}
+	switch {
+	case !p.IncludePreview || (p.PreferStable && stable != ""):
+		if stable == "" {
+			return VersionChoice{}, false
+		}
+		reason := "latest stable version" + floor
+		if p.IncludePreview && preview > stable {
+			reason += ", preferred over the newer preview " + preview
+		}
+		return VersionChoice{Version: stable, Reason: reason}, true
+	case p.PreferStable:
+		if preview == "" {
+			return VersionChoice{}, false
+		}
+		return VersionChoice{Version: preview, Reason: "latest preview version" + floor + "; there is no stable version" + floor}, true
+	case preview > stable:
+		return VersionChoice{Version: preview, Reason: "latest version" + floor + ", a preview"}, true
+	case stable != "":
+		return VersionChoice{Version: stable, Reason: "latest version" + floor + ", stable"}, true
+	}
+	return VersionChoice{}, false
+}
+
+// ResolveVersion selects the API version of a resource type in the index by the
+// policy. The resource type may be given as an instance path.
+func ResolveVersion(idx *index.TypeIndex, resourceType string, p VersionPolicy) (VersionChoice, error) {
+	resourceType, _ = ParseResourcePath(resourceType)
+	if err := p.Validate(); err != nil {
+		return VersionChoice{}, err
+	}
+	versions := ListVersions(idx, resourceType)
+	if len(versions) == 0 {
+		return VersionChoice{}, fmt.Errorf("resource type %s %w: no API versions found", resourceType, ErrResourceNotFound)
+	}
+	if choice, ok := SelectVersion(versions, p); ok {
+		return choice, nil
+	}
+
+	sort.Sort(sort.Reverse(sort.StringSlice(versions)))
+	if p.MinVersion != "" && versions[0] < p.MinVersion {
+		return VersionChoice{}, fmt.Errorf("resource type %s %w: no API versions at or after %s (latest: %s)",
+			resourceType, ErrResourceNotFound, p.MinVersion, versions[0])
+	}
+	// Only preview versions qualify and they were not asked for.
+	var previews []string
+	for _, v := range versions {
+		if isPreviewVersion(v) && p.Check(v) == nil {
+			previews = append(previews, v)
+		}
+	}
+	return VersionChoice{}, fmt.Errorf("resource type %s %w: no stable API versions found (preview versions available: %s); use --include-preview to select one",
+		resourceType, ErrResourceNotFound, strings.Join(previews, ", "))
+}
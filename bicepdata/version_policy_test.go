@@ -0,0 +1,88 @@
+package bicepdata
+
+import (
+	"testing"
+
+	"github.com/Azure/bicep-types/src/bicep-types-go/index"
+	"github.com/Azure/bicep-types/src/bicep-types-go/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelectVersion(t *testing.T) {
+	versions := []string{"2023-01-01", "2025-06-01-preview", "2025-01-01", "2024-01-01-preview"}
+
+	tests := []struct {
+		name    string
+		policy  VersionPolicy
+		version string
+		reason  string
+	}{
+		{
+			name:    "latest stable by default",
+			version: "2025-01-01",
+			reason:  "latest stable version",
+		},
+		{
+			name:    "preview when included",
+			policy:  VersionPolicy{IncludePreview: true},
+			version: "2025-06-01-preview",
+			reason:  "latest version, a preview",
+		},
+		{
+			name:    "stable preferred over newer preview",
+			policy:  VersionPolicy{IncludePreview: true, PreferStable: true},
+			version: "2025-01-01",
+			reason:  "latest stable version, preferred over the newer preview 2025-06-01-preview",
+		},
+		{
+			name:    "preview when no stable version reaches the floor",
+			policy:  VersionPolicy{IncludePreview: true, PreferStable: true, MinVersion: "2025-02-01"},
+			version: "2025-06-01-preview",
+			reason:  "latest preview version at or after 2025-02-01; there is no stable version at or after 2025-02-01",
+		},
+		{
+			name:    "floor keeps the latest stable",
+			policy:  VersionPolicy{MinVersion: "2024-01-01"},
+			version: "2025-01-01",
+			reason:  "latest stable version at or after 2024-01-01",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			choice, ok := SelectVersion(versions, tt.policy)
+			require.True(t, ok)
+			assert.Equal(t, tt.version, choice.Version)
+			assert.Equal(t, tt.reason, choice.Reason)
+		})
+	}
+
+	_, ok := SelectVersion(versions, VersionPolicy{MinVersion: "2025-02-01"})
+	assert.False(t, ok, "only a preview reaches the floor and previews are not included")
+}
+
+func TestVersionPolicy_Check(t *testing.T) {
+	p := VersionPolicy{MinVersion: "2024-01-01"}
+	assert.NoError(t, p.Check("2024-01-01"))
+	assert.NoError(t, p.Check("2024-01-01-preview"))
+	err := p.Check("2023-12-01")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "older than the minimum API version 2024-01-01")
+
+	assert.Error(t, VersionPolicy{MinVersion: "latest"}.Validate())
+	assert.NoError(t, VersionPolicy{MinVersion: "2024-01-01-preview"}.Validate())
+}
+
+func TestResolveVersion_BelowFloor(t *testing.T) {
+	idx := index.NewTypeIndex()
+	idx.AddResource("Microsoft.App/containerApps", "2023-08-01", &types.CrossFileTypeReference{Ref: 0})
+	idx.AddResource("Microsoft.App/containerApps", "2024-03-01", &types.CrossFileTypeReference{Ref: 1})
+
+	_, err := ResolveVersion(idx, "Microsoft.App/containerApps", VersionPolicy{MinVersion: "2025-01-01"})
+	require.ErrorIs(t, err, ErrResourceNotFound)
+	assert.Contains(t, err.Error(), "no API versions at or after 2025-01-01 (latest: 2024-03-01)")
+
+	choice, err := ResolveVersion(idx, "Microsoft.App/containerApps", VersionPolicy{MinVersion: "2024-01-01"})
+	require.NoError(t, err)
+	assert.Equal(t, "2024-03-01", choice.Version)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/matt-FFFFFF/tfmodmake/bicepdata"
 	"github.com/matt-FFFFFF/tfmodmake/schema"
@@ -25,7 +26,7 @@ func AddCommand() *cli.Command {
 			},
 			{
 				Name:      "avm-interfaces",
-				Usage:     "Generate main.interfaces.tf",
+				Usage:     "Scaffold AVM interfaces into main.<interface>.tf and variables.<interface>.tf",
 				ArgsUsage: "[path]",
 				Flags: []cli.Flag{
 					&cli.StringFlag{
@@ -36,6 +37,12 @@ func AddCommand() *cli.Command {
 						Name:  "include-preview",
 						Usage: "Include preview API versions",
 					},
+					typesPathFlag(),
+					typesRefFlag(),
+					&cli.StringFlag{
+						Name:  "only",
+						Usage: "Optional: comma-separated interfaces to scaffold (" + strings.Join(terraform.InterfaceNames(), ", ") + "). Defaults to the interfaces applicable to the resource type",
+					},
 				},
 				Action: runAddAVMInterfaces,
 			},
@@ -48,9 +55,12 @@ func runAddSubmodule(ctx context.Context, cmd *cli.Command) error {
 		return cli.ShowSubcommandHelp(cmd)
 	}
 	path := cmd.Args().First()
-	if err := submodule.Generate(path); err != nil {
+	if err := submodule.Generate(path, nil); err != nil {
 		return fmt.Errorf("failed to add submodule: %w", err)
 	}
+	if err := terraform.WriteTFVarsExample(".", nil); err != nil {
+		return fmt.Errorf("failed to update %s: %w", terraform.TFVarsExampleFileName, err)
+	}
 	fmt.Println("Successfully generated submodule wrapper files")
 	return nil
 }
@@ -58,6 +68,10 @@ func runAddSubmodule(ctx context.Context, cmd *cli.Command) error {
 func runAddAVMInterfaces(ctx context.Context, cmd *cli.Command) error {
 	apiVersion := cmd.String("api-version")
 	includePreview := cmd.Bool("include-preview")
+	var only []string
+	if v := cmd.String("only"); v != "" {
+		only = strings.Split(v, ",")
+	}
 	targetDir := "."
 	if cmd.NArg() > 0 {
 		targetDir = cmd.Args().First()
@@ -80,7 +94,9 @@ func runAddAVMInterfaces(ctx context.Context, cmd *cli.Command) error {
 
 	var rs *schema.ResourceSchema
 	if finalResourceType != "" {
-		loaded, err := bicepdata.LoadResource(ctx, finalResourceType, apiVersion, includePreview, nil)
+		spec := specOptions(cmd, nil)
+		defer spec.Cache.Close()
+		loaded, err := bicepdata.LoadResource(ctx, finalResourceType, apiVersion, includePreview, spec)
 		if err != nil {
 			return fmt.Errorf("failed to load resource: %w", err)
 		}
@@ -90,10 +106,25 @@ func runAddAVMInterfaces(ctx context.Context, cmd *cli.Command) error {
 		}
 	}
 
-	if err := terraform.GenerateInterfacesFile(finalResourceType, rs, "."); err != nil {
+	result, err := terraform.GenerateInterfaces(finalResourceType, rs, ".", only, nil)
+	if err != nil {
 		return fmt.Errorf("failed to generate AVM interfaces: %w", err)
 	}
-
-	fmt.Println("Successfully generated main.interfaces.tf")
+	printInterfacesResult(result)
 	return nil
 }
+
+func printInterfacesResult(result *terraform.InterfacesResult) {
+	for _, name := range result.Added {
+		fmt.Printf("Added %s interface (main.%s.tf, variables.%s.tf)\n", name, name, name)
+	}
+	for _, name := range result.Skipped {
+		fmt.Printf("Skipped %s interface: already present\n", name)
+	}
+	for _, note := range result.Notes {
+		fmt.Println(note)
+	}
+	if len(result.Added) == 0 && len(result.Skipped) == 0 {
+		fmt.Println("No AVM interfaces selected")
+	}
+}
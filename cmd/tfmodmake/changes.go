@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/matt-FFFFFF/tfmodmake/hclgen"
+)
+
+// fileChange is a file generation touched below a module directory, with its
+// content before generation and now.
+type fileChange struct {
+	name            string
+	before, after   []byte
+	existed, exists bool
+}
+
+// moduleChanges returns the files journal recorded below dir, sorted by their
+// slash-separated path relative to dir.
+func moduleChanges(dir string, journal *hclgen.Journal) ([]fileChange, error) {
+	var changes []fileChange
+	for _, name := range journal.Touched(dir) {
+		c := fileChange{name: name}
+		c.before, c.existed = journal.Before(dir, name)
+		after, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+		switch {
+		case err == nil:
+			c.after, c.exists = after, true
+		case !errors.Is(err, os.ErrNotExist):
+			return nil, err
+		}
+		changes = append(changes, c)
+	}
+	return changes, nil
+}
+
+// printChangeSummary lists the files below dir that generation added, changed
+// or removed, as recorded in journal, and counts those it left unchanged.
+// Regenerated files with identical content are not rewritten, so they count as
+// unchanged.
+func printChangeSummary(w io.Writer, dir string, journal *hclgen.Journal) error {
+	changes, err := moduleChanges(dir, journal)
+	if err != nil {
+		return err
+	}
+
+	changed, unchanged := 0, 0
+	for _, c := range changes {
+		switch {
+		case !c.existed && !c.exists:
+			continue
+		case !c.existed:
+			fmt.Fprintf(w, "  new:       %s\n", c.name)
+			changed++
+		case !c.exists:
+			fmt.Fprintf(w, "  removed:   %s\n", c.name)
+			changed++
+		case !bytes.Equal(c.before, c.after):
+			fmt.Fprintf(w, "  changed:   %s\n", c.name)
+			changed++
+		default:
+			unchanged++
+		}
+	}
+	fmt.Fprintf(w, "%d file(s) changed, %d unchanged\n", changed, unchanged)
+	return nil
+}
+
+// restoreOnCancel returns err, after restoring the files generation touched
+// below dir to their content before, as recorded in journal, when err follows
+// the cancellation of ctx, so an interrupted run does not leave a partly
+// generated module that looks complete.
+func restoreOnCancel(ctx context.Context, dir string, journal *hclgen.Journal, err error) error {
+	if err == nil || ctx.Err() == nil {
+		return err
+	}
+	if restoreErr := restoreModuleFiles(dir, journal); restoreErr != nil {
+		return fmt.Errorf("%w; restoring the files below %s failed, so they may be incomplete: %w", err, dir, restoreErr)
+	}
+	return fmt.Errorf("%w; the files below %s were restored to their content before generation", err, dir)
+}
+
+// restoreModuleFiles puts the files journal recorded below dir back to their
+// content before generation: files it added are removed, with the directories
+// that leaves empty, and changed or removed files are rewritten. Files
+// generation never touched are left alone.
+func restoreModuleFiles(dir string, journal *hclgen.Journal) error {
+	changes, err := moduleChanges(dir, journal)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	emptied := map[string]bool{}
+	for _, c := range changes {
+		path := filepath.Join(dir, filepath.FromSlash(c.name))
+		switch {
+		case !c.existed && c.exists:
+			errs = append(errs, os.Remove(path))
+			for parent := filepath.Dir(path); parent != filepath.Clean(dir); parent = filepath.Dir(parent) {
+				emptied[parent] = true
+			}
+		case c.existed && !c.exists:
+			if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			errs = append(errs, os.WriteFile(path, c.before, 0o644))
+		case c.existed && !bytes.Equal(c.before, c.after):
+			errs = append(errs, os.WriteFile(path, c.before, 0o644))
+		}
+	}
+
+	// Deeper directories are removed first; those still holding files stay.
+	dirs := make([]string, 0, len(emptied))
+	for d := range emptied {
+		dirs = append(dirs, d)
+	}
+	sort.Slice(dirs, func(i, j int) bool { return len(dirs[i]) > len(dirs[j]) })
+	for _, d := range dirs {
+		if entries, err := os.ReadDir(d); err == nil && len(entries) == 0 {
+			errs = append(errs, os.Remove(d))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/matt-FFFFFF/tfmodmake/hclgen"
+)
+
+// readFiles returns the content of every file below dir, keyed by its
+// slash-separated path relative to dir.
+func readFiles(t *testing.T, dir string) map[string][]byte {
+	t.Helper()
+	files := map[string][]byte{}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = content
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func TestPrintChangeSummary(t *testing.T) {
+	dir := t.TempDir()
+	path := func(name string) string {
+		return filepath.Join(dir, filepath.FromSlash(name))
+	}
+	// Files are written without a journal until generation starts.
+	var journal *hclgen.Journal
+	writeFile := func(name, content string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(path(name)), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := journal.WriteIfChanged(path(name), []byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile("main.tf", "a")
+	writeFile("variables.tf", "b")
+	writeFile("outputs.old.tf", "c")
+	writeFile("README.md", "d")
+
+	journal = hclgen.NewJournal()
+	writeFile("main.tf", "changed")
+	writeFile("variables.tf", "b")
+	writeFile("modules/child/main.tf", "new")
+	if err := journal.Remove(path("outputs.old.tf")); err != nil {
+		t.Fatal(err)
+	}
+	// Files generation does not write are not listed or counted.
+	if err := os.WriteFile(path("README.md"), []byte("edited"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := printChangeSummary(&out, dir, journal); err != nil {
+		t.Fatalf("printChangeSummary returned error: %v", err)
+	}
+	want := "  changed:   main.tf\n" +
+		"  new:       modules/child/main.tf\n" +
+		"  removed:   outputs.old.tf\n" +
+		"3 file(s) changed, 1 unchanged\n"
+	if out.String() != want {
+		t.Fatalf("unexpected summary:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
+
+func TestRestoreOnCancel(t *testing.T) {
+	dir := t.TempDir()
+	path := func(name string) string {
+		return filepath.Join(dir, filepath.FromSlash(name))
+	}
+	writeFile := func(name, content string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(path(name)), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path(name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	journal := hclgen.NewJournal()
+	generate := func(name, content string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(path(name)), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := journal.WriteIfChanged(path(name), []byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile("main.tf", "a")
+	writeFile("variables.tf", "b")
+	writeFile("modules/existing/main.tf", "c")
+	before := readFiles(t, dir)
+
+	generate("main.tf", "half")
+	generate("outputs.tf", "new")
+	generate("modules/child/nested/main.tf", "new")
+	generate("modules/existing/variables.tf", "new")
+	if err := journal.Remove(path("variables.tf")); err != nil {
+		t.Fatal(err)
+	}
+	// Files generation did not write are not its to restore.
+	writeFile("notes.txt", "user")
+	failure := errors.New("generation failed")
+
+	// A failure without cancellation leaves the files for inspection.
+	if err := restoreOnCancel(context.Background(), dir, journal, failure); err != failure {
+		t.Fatalf("expected the error to be returned as is, got %v", err)
+	}
+	if _, err := os.Stat(path("outputs.tf")); err != nil {
+		t.Fatalf("expected outputs.tf to be kept: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := restoreOnCancel(ctx, dir, journal, failure)
+	if !errors.Is(err, failure) {
+		t.Fatalf("expected the generation error, got %v", err)
+	}
+	after := readFiles(t, dir)
+	if len(after) != len(before)+1 {
+		t.Fatalf("expected the files before generation and notes.txt, got %v", after)
+	}
+	for name, content := range before {
+		if !bytes.Equal(after[name], content) {
+			t.Fatalf("expected %s to be restored to %q, got %q", name, content, after[name])
+		}
+	}
+	if string(after["notes.txt"]) != "user" {
+		t.Fatalf("expected notes.txt to be left alone, got %q", after["notes.txt"])
+	}
+	if _, err := os.Stat(path("modules/child")); !os.IsNotExist(err) {
+		t.Fatalf("expected the new module directory to be removed, got %v", err)
+	}
+	if _, err := os.Stat(path("modules/existing")); err != nil {
+		t.Fatalf("expected the existing module directory to be kept: %v", err)
+	}
+}
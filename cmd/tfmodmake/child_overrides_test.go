@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/matt-FFFFFF/tfmodmake/bicepdata"
+	"github.com/matt-FFFFFF/tfmodmake/config"
+	"github.com/matt-FFFFFF/tfmodmake/internal/typestest"
+	"github.com/matt-FFFFFF/tfmodmake/lockfile"
+	"github.com/matt-FFFFFF/tfmodmake/schema"
+)
+
+func TestApplyChildAPIVersionFlags(t *testing.T) {
+	cfg := &config.Config{Children: map[string]config.ChildOverride{
+		"microsoft.app/managedenvironments/certificates": {APIVersion: "2024-03-01", TypesPath: "../types"},
+	}}
+
+	if err := applyChildAPIVersionFlags(cfg, []string{
+		"Microsoft.App/managedEnvironments/certificates@2025-01-01",
+		"Microsoft.App/managedEnvironments/storages@2024-10-02-preview",
+	}); err != nil {
+		t.Fatalf("applyChildAPIVersionFlags returned error: %v", err)
+	}
+
+	certificates, _ := cfg.ChildOverride("Microsoft.App/managedEnvironments/certificates")
+	if certificates.APIVersion != "2025-01-01" || certificates.TypesPath != "../types" {
+		t.Fatalf("expected the flag to override only the API version, got %+v", certificates)
+	}
+	if len(cfg.Children) != 2 {
+		t.Fatalf("expected 2 child overrides, got %d", len(cfg.Children))
+	}
+	storages, _ := cfg.ChildOverride("Microsoft.App/managedEnvironments/storages")
+	if storages.APIVersion != "2024-10-02-preview" {
+		t.Fatalf("expected storages to be pinned, got %+v", storages)
+	}
+
+	for _, invalid := range []string{"Microsoft.App/managedEnvironments/storages", "@2025-01-01", "Microsoft.App/managedEnvironments/storages@"} {
+		if err := applyChildAPIVersionFlags(&config.Config{}, []string{invalid}); err == nil {
+			t.Fatalf("expected an error for %q", invalid)
+		}
+	}
+}
+
+func TestPinChildVersions(t *testing.T) {
+	cfg := &config.Config{Children: map[string]config.ChildOverride{
+		"Microsoft.Test/resources/pinned":  {APIVersion: "2023-01-01"},
+		"Microsoft.Test/resources/preview": {IncludePreview: true},
+	}}
+	children := []schema.ChildResource{
+		{ResourceType: "Microsoft.Test/resources/pinned", APIVersions: []string{"2023-01-01", "2024-01-01"}},
+		{ResourceType: "Microsoft.Test/resources/preview", APIVersions: []string{"2024-01-01", "2025-01-01-preview"}},
+		{ResourceType: "Microsoft.Test/resources/other", APIVersions: []string{"2024-01-01", "2025-01-01-preview"}},
+	}
+
+	pinChildVersions(children, cfg)
+	selected, _ := schema.SelectAPIVersions(children, false)
+
+	want := map[string]string{
+		"Microsoft.Test/resources/pinned":  "2023-01-01",
+		"Microsoft.Test/resources/preview": "2025-01-01-preview",
+		"Microsoft.Test/resources/other":   "2024-01-01",
+	}
+	for _, child := range selected {
+		if child.APIVersion != want[child.ResourceType] {
+			t.Errorf("%s: expected API version %s, got %s", child.ResourceType, want[child.ResourceType], child.APIVersion)
+		}
+	}
+}
+
+func TestApplyInlineChildFlags(t *testing.T) {
+	cfg := &config.Config{Children: map[string]config.ChildOverride{
+		"microsoft.app/managedenvironments/certificates": {APIVersion: "2024-03-01"},
+	}}
+
+	applyInlineChildFlags(cfg, []string{"Microsoft.App/managedEnvironments/certificates", "Microsoft.App/managedEnvironments/storages"})
+
+	certificates, _ := cfg.ChildOverride("Microsoft.App/managedEnvironments/certificates")
+	if !certificates.Inline || certificates.APIVersion != "2024-03-01" {
+		t.Fatalf("expected certificates to be inlined and keep its API version, got %+v", certificates)
+	}
+	storages, _ := cfg.ChildOverride("Microsoft.App/managedEnvironments/storages")
+	if !storages.Inline {
+		t.Fatalf("expected storages to be inlined, got %+v", storages)
+	}
+}
+
+func TestApplyVersionFlags(t *testing.T) {
+	cfg := &config.Config{Versions: config.Versions{
+		Terraform:         "~> 1.9",
+		RequiredProviders: map[string]config.ProviderVersion{"azapi": {Source: "azure/azapi", Version: "~> 2.5"}},
+	}}
+	if err := applyVersionFlags(cfg, ">= 1.10", []string{"azapi=>= 2.6, < 3.0", "time = ~> 0.12"}); err != nil {
+		t.Fatalf("applyVersionFlags returned error: %v", err)
+	}
+
+	if cfg.Versions.Terraform != ">= 1.10" {
+		t.Fatalf("expected the flag to replace the Terraform version, got %q", cfg.Versions.Terraform)
+	}
+	if got := cfg.Versions.RequiredProviders["azapi"]; got != (config.ProviderVersion{Source: "azure/azapi", Version: ">= 2.6, < 3.0"}) {
+		t.Fatalf("expected the flag to override only the azapi version, got %+v", got)
+	}
+	if got := cfg.Versions.RequiredProviders["time"]; got.Version != "~> 0.12" {
+		t.Fatalf("expected time to be added, got %+v", got)
+	}
+
+	for _, invalid := range []string{"azapi", "=~> 2.5", "azapi="} {
+		if err := applyVersionFlags(&config.Config{}, "", []string{invalid}); err == nil {
+			t.Fatalf("expected an error for %q", invalid)
+		}
+	}
+}
+
+func TestChildSpec(t *testing.T) {
+	cache := bicepdata.NewCache()
+	parent := &bicepdata.FetchOptions{LocalPath: "../bicep-types-az", Cache: cache}
+
+	tests := []struct {
+		name     string
+		override config.ChildOverride
+		want     bicepdata.FetchOptions
+	}{
+		{"inherited", config.ChildOverride{APIVersion: "2024-01-01"}, bicepdata.FetchOptions{LocalPath: "../bicep-types-az", Cache: cache}},
+		{"other checkout", config.ChildOverride{TypesPath: "../insights-types"}, bicepdata.FetchOptions{LocalPath: "../insights-types", Cache: cache}},
+		{"published ref", config.ChildOverride{TypesRef: "v0.5"}, bicepdata.FetchOptions{Ref: "v0.5", Cache: cache}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := childSpec(parent, tt.override); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("childSpec = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenTypesPathFromConfig(t *testing.T) {
+	typesPath := typestest.Write(t, typestest.Widgets)
+	chdirTemp(t)
+	data, err := json.Marshal(config.Config{TypesPath: typesPath})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(config.FileName, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := GenCommand().Run(context.Background(), []string{"gen", "-resource", "Microsoft.Test/widgets"}); err != nil {
+		t.Fatal(err)
+	}
+	lock, err := lockfile.Read(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lock.Resources) != 1 || lock.Resources[0].Source != (lockfile.Source{Repository: typesPath, Local: true}) {
+		t.Fatalf("Resources = %+v, want one read from %s", lock.Resources, typesPath)
+	}
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/matt-FFFFFF/tfmodmake/bicepdata"
+	"github.com/matt-FFFFFF/tfmodmake/schema"
+	"github.com/urfave/cli/v3"
+)
+
+func DiffCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "diff",
+		Usage: "Compare resource type schemas",
+		Commands: []*cli.Command{
+			{
+				Name:  "api-versions",
+				Usage: "Compare the writable properties of two API versions of a resource type",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:     "resource",
+						Usage:    "Resource type to compare (e.g., Microsoft.App/containerApps)",
+						Required: true,
+					},
+					&cli.StringFlag{
+						Name:     "from",
+						Usage:    "API version to compare from",
+						Required: true,
+					},
+					&cli.StringFlag{
+						Name:  "to",
+						Usage: "API version to compare to (default: the latest)",
+					},
+					&cli.BoolFlag{
+						Name:  "include-preview",
+						Usage: "Compare to the latest preview API version when -to is not given",
+					},
+					typesPathFlag(),
+					typesRefFlag(),
+					&cli.BoolFlag{
+						Name:  "json",
+						Usage: "Output results as JSON",
+					},
+				},
+				Action: runDiffAPIVersions,
+			},
+		},
+	}
+}
+
+// apiVersionDiff is the JSON output of diff api-versions.
+type apiVersionDiff struct {
+	ResourceType string                 `json:"resource_type"`
+	From         string                 `json:"from"`
+	To           string                 `json:"to"`
+	Changes      []schema.VersionChange `json:"changes"`
+}
+
+func runDiffAPIVersions(ctx context.Context, cmd *cli.Command) error {
+	resourceType := cmd.String("resource")
+	opts := specOptions(cmd, nil)
+	defer opts.Cache.Close()
+	from, err := loadSchema(ctx, resourceType, cmd.String("from"), false, opts)
+	if err != nil {
+		return err
+	}
+	to, err := loadSchema(ctx, resourceType, cmd.String("to"), cmd.Bool("include-preview"), opts)
+	if err != nil {
+		return err
+	}
+
+	diff := apiVersionDiff{
+		ResourceType: from.ResourceType,
+		From:         from.APIVersion,
+		To:           to.APIVersion,
+		Changes:      schema.DiffVersions(from, to),
+	}
+	if cmd.Bool("json") {
+		if diff.Changes == nil {
+			diff.Changes = []schema.VersionChange{}
+		}
+		return printJSON(os.Stdout, diff)
+	}
+	printAPIVersionDiff(os.Stdout, diff)
+	return nil
+}
+
+// loadSchema loads and converts resourceType at apiVersion, or at the latest
+// version when apiVersion is empty.
+func loadSchema(ctx context.Context, resourceType, apiVersion string, includePreview bool, opts *bicepdata.FetchOptions) (*schema.ResourceSchema, error) {
+	loaded, err := bicepdata.LoadResource(ctx, resourceType, apiVersion, includePreview, opts)
+	if err != nil {
+		return nil, fmt.Errorf("loading resource %s: %w", resourceType, err)
+	}
+	rs, err := schema.ConvertResource(loaded)
+	if err != nil {
+		return nil, fmt.Errorf("converting resource %s@%s: %w", resourceType, loaded.APIVersion, err)
+	}
+	return rs, nil
+}
+
+func printAPIVersionDiff(w io.Writer, diff apiVersionDiff) {
+	fmt.Fprintf(w, "%s %s -> %s:\n", diff.ResourceType, diff.From, diff.To)
+	for _, c := range diff.Changes {
+		fmt.Fprintf(w, "  %-13s %s%s\n", string(c.Kind)+":", c.Path, describeVersionChange(c))
+	}
+	if len(diff.Changes) == 0 {
+		fmt.Fprintln(w, "  No changes to writable properties")
+		return
+	}
+	fmt.Fprintf(w, "%d change(s)\n", len(diff.Changes))
+}
+
+// describeVersionChange returns the details of c printed after its path.
+func describeVersionChange(c schema.VersionChange) string {
+	switch c.Kind {
+	case schema.VersionChangeAdded:
+		if c.Required {
+			return " (required)"
+		}
+	case schema.VersionChangeType:
+		return fmt.Sprintf(" (%s -> %s)", c.OldType, c.NewType)
+	case schema.VersionChangeEnum, schema.VersionChangeRestricted, schema.VersionChangeUnrestricted:
+		var values []string
+		for _, v := range c.AddedValues {
+			values = append(values, "+"+v)
+		}
+		for _, v := range c.RemovedValues {
+			values = append(values, "-"+v)
+		}
+		return " (" + strings.Join(values, ", ") + ")"
+	}
+	return ""
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/matt-FFFFFF/tfmodmake/schema"
+)
+
+func TestPrintAPIVersionDiff(t *testing.T) {
+	var buf bytes.Buffer
+	printAPIVersionDiff(&buf, apiVersionDiff{
+		ResourceType: "Microsoft.Test/widgets",
+		From:         "2024-01-01",
+		To:           "2025-01-01",
+		Changes: []schema.VersionChange{
+			{Path: "properties.region", Kind: schema.VersionChangeAdded, Required: true},
+			{Path: "properties.size", Kind: schema.VersionChangeType, OldType: "string", NewType: "integer"},
+			{Path: "properties.sku", Kind: schema.VersionChangeEnum, AddedValues: []string{"Ultra"}, RemovedValues: []string{"Basic"}},
+		},
+	})
+	want := `Microsoft.Test/widgets 2024-01-01 -> 2025-01-01:
+  added:        properties.region (required)
+  type-changed: properties.size (string -> integer)
+  enum-changed: properties.sku (+Ultra, -Basic)
+3 change(s)
+`
+	if got := buf.String(); got != want {
+		t.Fatalf("output = %q, want %q", got, want)
+	}
+}
+
+func TestPrintAPIVersionDiff_NoChanges(t *testing.T) {
+	var buf bytes.Buffer
+	printAPIVersionDiff(&buf, apiVersionDiff{ResourceType: "Microsoft.Test/widgets", From: "2024-01-01", To: "2024-01-01"})
+	want := "Microsoft.Test/widgets 2024-01-01 -> 2024-01-01:\n  No changes to writable properties\n"
+	if got := buf.String(); got != want {
+		t.Fatalf("output = %q, want %q", got, want)
+	}
+}
+
+func TestPrintJSON(t *testing.T) {
+	var out bytes.Buffer
+	if err := printJSON(&out, map[string]string{"version": ">= 1.9, < 2.0"}); err != nil {
+		t.Fatal(err)
+	}
+	if want := "{\n  \"version\": \">= 1.9, < 2.0\"\n}\n"; out.String() != want {
+		t.Fatalf("printJSON = %q, want %q", out.String(), want)
+	}
+}
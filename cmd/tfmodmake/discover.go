@@ -4,11 +4,14 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"os"
 	"sort"
 	"strings"
+	"text/tabwriter"
 
 	"github.com/matt-FFFFFF/tfmodmake/bicepdata"
 	"github.com/matt-FFFFFF/tfmodmake/schema"
+	"github.com/matt-FFFFFF/tfmodmake/terraform"
 	"github.com/urfave/cli/v3"
 )
 
@@ -30,18 +33,27 @@ func DiscoverCommand() *cli.Command {
 						Name:  "json",
 						Usage: "Output results as JSON",
 					},
+					&cli.BoolFlag{
+						Name:  "include-preview",
+						Usage: "Select the latest preview API version when newer and include children that only have preview versions",
+					},
+					&cli.StringSliceFlag{
+						Name:  "children-include",
+						Usage: "Only list children whose last type segment matches a glob pattern (repeatable; descendants must match too)",
+					},
+					&cli.StringSliceFlag{
+						Name:  "children-exclude",
+						Usage: "Skip children whose last type segment matches a glob pattern, along with their descendants (repeatable)",
+					},
 					&cli.IntFlag{
-						Name:     "depth",
-						Usage:    "Depth of child resource discovery (default: 1)",
-						OnlyOnce: true,
-						Value:    1,
-						Validator: func(i int) error {
-							if i < 1 || i > 6 {
-								return fmt.Errorf("depth must be at least 1 and at most 6")
-							}
-							return nil
-						},
+						Name:      "depth",
+						Usage:     "Depth of child resource discovery (default: 1)",
+						OnlyOnce:  true,
+						Value:     1,
+						Validator: validateDiscoveryDepth,
 					},
+					typesPathFlag(),
+					typesRefFlag(),
 				},
 				Action: runDiscoverChildren,
 			},
@@ -54,9 +66,36 @@ func DiscoverCommand() *cli.Command {
 						Usage:    "Resource type to list versions for",
 						Required: true,
 					},
+					typesPathFlag(),
+					typesRefFlag(),
 				},
 				Action: runDiscoverVersions,
 			},
+			{
+				Name:  "actions",
+				Usage: "List the POST actions (e.g. listKeys) invoked on an instance of a resource type",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:     "resource",
+						Usage:    "Resource type to list actions for",
+						Required: true,
+					},
+					&cli.StringFlag{
+						Name:  "api-version",
+						Usage: "Specific API version to use",
+					},
+					&cli.BoolFlag{
+						Name:  "include-preview",
+						Usage: "Include latest preview API version",
+					},
+					typesPathFlag(),
+					&cli.BoolFlag{
+						Name:  "json",
+						Usage: "Output results as JSON",
+					},
+				},
+				Action: runDiscoverActions,
+			},
 		},
 	}
 }
@@ -65,7 +104,9 @@ func runDiscoverChildren(ctx context.Context, cmd *cli.Command) error {
 	parent := cmd.String("parent")
 	jsonOutput := cmd.Bool("json")
 
-	indexData, err := bicepdata.FetchIndex(ctx, nil)
+	spec := specOptions(cmd, nil)
+	defer spec.Cache.Close()
+	indexData, err := bicepdata.FetchIndex(ctx, spec)
 	if err != nil {
 		return fmt.Errorf("failed to fetch bicep-types index: %w", err)
 	}
@@ -74,7 +115,14 @@ func runDiscoverChildren(ctx context.Context, cmd *cli.Command) error {
 		return fmt.Errorf("failed to parse bicep-types index: %w", err)
 	}
 
-	children := schema.DiscoverChildren(idx, parent, cmd.Int("depth"))
+	discovered, err := schema.FilterChildren(schema.DiscoverChildren(idx, parent, cmd.Int("depth")), cmd.StringSlice("children-include"), cmd.StringSlice("children-exclude"))
+	if err != nil {
+		return err
+	}
+	children, previewOnly := schema.SelectAPIVersions(discovered, cmd.Bool("include-preview"))
+	if err := schema.MarkScopeResources(ctx, idx, children, spec); err != nil {
+		return err
+	}
 
 	if jsonOutput {
 		data, err := json.MarshalIndent(children, "", "  ")
@@ -85,17 +133,56 @@ func runDiscoverChildren(ctx context.Context, cmd *cli.Command) error {
 	} else {
 		if len(children) == 0 {
 			fmt.Printf("No child resources found for %s\n", parent)
+			printPreviewOnlyChildren(previewOnly)
 			return nil
 		}
 		fmt.Printf("Child resources of %s:\n", parent)
-		// Sort children by resource type for consistent output
-		sort.Slice(children, func(i, j int) bool {
-			return strings.ToLower(children[i].ResourceType) < strings.ToLower(children[j].ResourceType)
-		})
+		// Print descendants as a tree below their own parent
+		schema.SortChildren(children)
 		for _, child := range children {
 			sort.Strings(child.APIVersions)
-			fmt.Printf("  %s (API versions: %s)\n", child.ResourceType, strings.Join(child.APIVersions, ", "))
+			indent := strings.Repeat("  ", child.Depth)
+			fmt.Printf("%s%s@%s (API versions: %s)%s\n", indent, child.ResourceType, child.APIVersion, strings.Join(child.APIVersions, ", "), scopeResourceMarker(child))
 		}
+		printPreviewOnlyChildren(previewOnly)
+	}
+	return nil
+}
+
+// printPreviewOnlyChildren lists the children left out because they have no
+// stable API version.
+func printPreviewOnlyChildren(children []schema.ChildResource) {
+	if len(children) == 0 {
+		return
+	}
+	schema.SortChildren(children)
+	fmt.Printf("%d child resource type(s) only have preview API versions; use -include-preview to include them:\n", len(children))
+	for _, child := range children {
+		fmt.Printf("  %s\n", child.ResourceType)
+	}
+}
+
+// scopeResourceMarker returns the suffix marking a child that is applied at the
+// scope of its parent, or "" for a nested child.
+func scopeResourceMarker(child schema.ChildResource) string {
+	if child.ScopeResource {
+		return " (scope resource)"
+	}
+	return ""
+}
+
+// validateDiscoveryDepth bounds the depth of child resource discovery.
+func validateDiscoveryDepth(i int) error {
+	if i < 1 || i > 6 {
+		return fmt.Errorf("depth must be at least 1 and at most 6")
+	}
+	return nil
+}
+
+// validateConcurrency requires at least one concurrent load.
+func validateConcurrency(i int) error {
+	if i < 1 {
+		return fmt.Errorf("concurrency must be at least 1")
 	}
 	return nil
 }
@@ -103,7 +190,9 @@ func runDiscoverChildren(ctx context.Context, cmd *cli.Command) error {
 func runDiscoverVersions(ctx context.Context, cmd *cli.Command) error {
 	resourceType := cmd.String("resource")
 
-	indexData, err := bicepdata.FetchIndex(ctx, nil)
+	spec := specOptions(cmd, nil)
+	defer spec.Cache.Close()
+	indexData, err := bicepdata.FetchIndex(ctx, spec)
 	if err != nil {
 		return fmt.Errorf("failed to fetch bicep-types index: %w", err)
 	}
@@ -112,15 +201,128 @@ func runDiscoverVersions(ctx context.Context, cmd *cli.Command) error {
 		return fmt.Errorf("failed to parse bicep-types index: %w", err)
 	}
 
-	versions := bicepdata.ListVersions(idx, resourceType)
-	if len(versions) == 0 {
+	matches := bicepdata.FindResources(idx, resourceType)
+	if len(matches) == 0 {
 		return fmt.Errorf("no versions found for resource type %s", resourceType)
 	}
 
-	sort.Strings(versions)
+	// Each version is listed with its types file; entries the index holds
+	// under another casing of the type are marked, since they may differ.
 	fmt.Printf("Available API versions for %s:\n", resourceType)
-	for _, v := range versions {
-		fmt.Printf("  %s\n", v)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, m := range matches {
+		line := fmt.Sprintf("  %s\t%s", m.APIVersion, m.RelativePath)
+		if !m.ExactCase {
+			line += "\t(as " + m.ResourceType + ")"
+		}
+		fmt.Fprintln(w, line)
 	}
+	w.Flush()
 	return nil
 }
+
+// actionSummary describes a POST action of a resource: the top-level properties
+// of its request and response bodies, mapped to a short description of their
+// types.
+type actionSummary struct {
+	Name       string            `json:"name"`
+	APIVersion string            `json:"api_version"`
+	Input      map[string]string `json:"input,omitempty"`
+	Output     map[string]string `json:"output,omitempty"`
+}
+
+func runDiscoverActions(ctx context.Context, cmd *cli.Command) error {
+	resourceType := cmd.String("resource")
+	rs, err := terraform.LoadResourceSchema(ctx, resourceType, childLoadOptions(cmd.String("api-version"), cmd.Bool("include-preview"), cmd.String("types-path"))...)
+	if err != nil {
+		return err
+	}
+	actions := summarizeActions(rs)
+
+	if cmd.Bool("json") {
+		data, err := json.MarshalIndent(actions, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to format as JSON: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+	if len(actions) == 0 {
+		fmt.Printf("No actions found for %s@%s\n", resourceType, rs.APIVersion)
+		return nil
+	}
+	fmt.Printf("Actions of %s@%s:\n", resourceType, rs.APIVersion)
+	for _, a := range actions {
+		fmt.Printf("  %s (API version %s)\n", a.Name, a.APIVersion)
+		printActionBody("input", a.Input)
+		printActionBody("output", a.Output)
+	}
+	return nil
+}
+
+func printActionBody(label string, properties map[string]string) {
+	if properties == nil {
+		fmt.Printf("    %s: none\n", label)
+		return
+	}
+	fmt.Printf("    %s:\n", label)
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Printf("      %s: %s\n", name, properties[name])
+	}
+}
+
+// summarizeActions describes the actions of rs, which are sorted by name.
+func summarizeActions(rs *schema.ResourceSchema) []actionSummary {
+	actions := make([]actionSummary, 0, len(rs.Functions))
+	for _, fn := range rs.Functions {
+		actions = append(actions, actionSummary{
+			Name:       fn.Name,
+			APIVersion: fn.APIVersion,
+			Input:      summarizeBody(fn.Input),
+			Output:     summarizeBody(fn.Output),
+		})
+	}
+	return actions
+}
+
+// summarizeBody maps the top-level properties of an action body to their
+// types. A body that is not an object is summarized as a single "(body)" entry.
+func summarizeBody(body *schema.Property) map[string]string {
+	if body == nil {
+		return nil
+	}
+	if body.Type != schema.TypeObject || len(body.Children) == 0 {
+		return map[string]string{"(body)": describeType(body)}
+	}
+	properties := make(map[string]string, len(body.Children))
+	for name, prop := range body.Children {
+		properties[name] = describeType(prop)
+	}
+	return properties
+}
+
+// describeType returns a short description of the type of p, e.g. "array of
+// object" or "string, sensitive".
+func describeType(p *schema.Property) string {
+	description := p.Type.String()
+	switch {
+	case p.Type == schema.TypeArray && p.ItemType != nil:
+		description = "array of " + describeType(p.ItemType)
+	case p.Type == schema.TypeObject && len(p.Children) == 0 && p.AdditionalProperties != nil:
+		description = "map of " + describeType(p.AdditionalProperties)
+	case len(p.Enum) > 0:
+		description += " (" + strings.Join(p.Enum, " | ") + ")"
+	}
+	if p.Sensitive {
+		description += ", sensitive"
+	}
+	if p.Required {
+		description += ", required"
+	}
+	return description
+}
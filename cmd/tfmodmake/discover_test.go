@@ -0,0 +1,56 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/matt-FFFFFF/tfmodmake/schema"
+)
+
+func TestSummarizeActions(t *testing.T) {
+	rs := &schema.ResourceSchema{
+		Functions: []*schema.ResourceFunction{
+			{
+				Name:       "listKeys",
+				APIVersion: "2024-01-01",
+				Output: &schema.Property{Type: schema.TypeObject, Children: map[string]*schema.Property{
+					"keys":       {Type: schema.TypeArray, ItemType: &schema.Property{Type: schema.TypeObject}},
+					"primaryKey": {Type: schema.TypeString, Sensitive: true},
+				}},
+			},
+			{
+				Name:       "regenerateKey",
+				APIVersion: "2024-01-01",
+				Input: &schema.Property{Type: schema.TypeObject, Children: map[string]*schema.Property{
+					"keyName": {Type: schema.TypeString, Enum: []string{"key1", "key2"}, Required: true},
+					"tags":    {Type: schema.TypeObject, AdditionalProperties: &schema.Property{Type: schema.TypeString}},
+				}},
+				Output: &schema.Property{Type: schema.TypeString},
+			},
+		},
+	}
+
+	got := summarizeActions(rs)
+	want := []actionSummary{
+		{
+			Name:       "listKeys",
+			APIVersion: "2024-01-01",
+			Output: map[string]string{
+				"keys":       "array of object",
+				"primaryKey": "string, sensitive",
+			},
+		},
+		{
+			Name:       "regenerateKey",
+			APIVersion: "2024-01-01",
+			Input: map[string]string{
+				"keyName": "string (key1 | key2), required",
+				"tags":    "map of string",
+			},
+			Output: map[string]string{"(body)": "string"},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("summarizeActions = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/matt-FFFFFF/tfmodmake/bicepdata"
+	"github.com/matt-FFFFFF/tfmodmake/schema"
+	"github.com/urfave/cli/v3"
+)
+
+func DoctorCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "doctor",
+		Usage: "Report the constructs of a resource type that generation degrades on, before generating it",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:     "resource",
+				Usage:    "Resource type to check (e.g., Microsoft.App/containerApps)",
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:  "api-version",
+				Usage: "Specific API version to use",
+			},
+			&cli.BoolFlag{
+				Name:  "include-preview",
+				Usage: "Include latest preview API version",
+			},
+			typesPathFlag(),
+			typesRefFlag(),
+			&cli.BoolFlag{
+				Name:  "json",
+				Usage: "Output results as JSON",
+			},
+		},
+		Action: runDoctor,
+	}
+}
+
+// doctorReport is the JSON output of the doctor command.
+type doctorReport struct {
+	ResourceType string              `json:"resource_type"`
+	APIVersion   string              `json:"api_version"`
+	Diagnostics  []schema.Diagnostic `json:"diagnostics"`
+}
+
+func runDoctor(ctx context.Context, cmd *cli.Command) error {
+	resourceType := cmd.String("resource")
+	spec := specOptions(cmd, nil)
+	defer spec.Cache.Close()
+	loaded, err := bicepdata.LoadResource(ctx, resourceType, cmd.String("api-version"), cmd.Bool("include-preview"), spec)
+	if err != nil {
+		return fmt.Errorf("loading resource %s: %w", resourceType, err)
+	}
+	diagnostics, err := schema.Diagnose(loaded)
+	if err != nil {
+		return fmt.Errorf("checking resource %s: %w", resourceType, err)
+	}
+
+	if cmd.Bool("json") {
+		report := doctorReport{ResourceType: loaded.ResourceTypeName, APIVersion: loaded.APIVersion, Diagnostics: diagnostics}
+		if report.Diagnostics == nil {
+			report.Diagnostics = []schema.Diagnostic{}
+		}
+		if err := printJSON(os.Stdout, report); err != nil {
+			return err
+		}
+	} else {
+		fmt.Printf("%s@%s:\n", loaded.ResourceTypeName, loaded.APIVersion)
+		for _, d := range diagnostics {
+			path := d.Path
+			if path == "" {
+				path = "(resource)"
+			}
+			fmt.Printf("  %s: %s [%s] %s\n", path, d.Severity, d.Construct, d.Message)
+		}
+		if len(diagnostics) == 0 {
+			fmt.Println("  No constructs that generation degrades on")
+		}
+	}
+
+	failures := 0
+	for _, d := range diagnostics {
+		if d.Severity == schema.DiagnosticError {
+			failures++
+		}
+	}
+	if failures > 0 {
+		return fmt.Errorf("%d construct(s) of %s cannot be generated", failures, resourceType)
+	}
+	return nil
+}
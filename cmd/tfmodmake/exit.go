@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/matt-FFFFFF/tfmodmake/bicepdata"
+	"github.com/matt-FFFFFF/tfmodmake/schema"
+	"github.com/matt-FFFFFF/tfmodmake/terraform"
+)
+
+// Exit statuses of the CLI, by kind of failure, so scripts can tell them apart.
+const (
+	exitFailure              = 1
+	exitResourceNotFound     = 2
+	exitSpecFetch            = 3
+	exitUnsupportedConstruct = 4
+	exitNameCollision        = 5
+	exitBreakingChanges      = 6
+	exitInterrupted          = 130
+)
+
+// exitStatus returns the exit status for err and, for the failures the user can
+// usually fix, a hint printed after the error.
+func exitStatus(err error) (int, string) {
+	var fetchErr *bicepdata.FetchError
+	switch {
+	case errors.Is(err, context.Canceled):
+		return exitInterrupted, ""
+	case errors.Is(err, bicepdata.ErrResourceNotFound):
+		return exitResourceNotFound, "list the API versions of a resource type with `tfmodmake discover versions -resource <type>`"
+	case errors.As(err, &fetchErr):
+		switch {
+		case fetchErr.Local:
+			return exitSpecFetch, "check that -types-path points to a bicep-types-az checkout with a generated directory"
+		case fetchErr.StatusCode == http.StatusForbidden || fetchErr.StatusCode == http.StatusTooManyRequests:
+			return exitSpecFetch, "the download was rate limited; retry later, or use -types-path with a local bicep-types-az checkout"
+		default:
+			return exitSpecFetch, "check the network connection, or use -types-path with a local bicep-types-az checkout"
+		}
+	case errors.Is(err, schema.ErrUnsupportedConstruct):
+		return exitUnsupportedConstruct, ""
+	case errors.Is(err, terraform.ErrNameCollision):
+		return exitNameCollision, ""
+	case errors.Is(err, terraform.ErrBreakingChanges):
+		return exitBreakingChanges, "review the breaking changes listed and rerun with -allow-breaking to apply them"
+	}
+	return exitFailure, ""
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/matt-FFFFFF/tfmodmake/bicepdata"
+	"github.com/matt-FFFFFF/tfmodmake/schema"
+	"github.com/matt-FFFFFF/tfmodmake/terraform"
+)
+
+func TestExitStatus(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		hint   string
+	}{
+		{"other", errors.New("boom"), exitFailure, ""},
+		{"interrupted", fmt.Errorf("failed to load resource: %w", context.Canceled), exitInterrupted, ""},
+		{"not found", fmt.Errorf("failed to load resource: resource %w", bicepdata.ErrResourceNotFound), exitResourceNotFound, "discover versions"},
+		{"rate limited", fmt.Errorf("fetching index: %w", &bicepdata.FetchError{Path: "index.json", Source: "https://example.com/index.json", StatusCode: http.StatusTooManyRequests}), exitSpecFetch, "rate limited"},
+		{"local", &bicepdata.FetchError{Path: "index.json", Source: "/missing/generated/index.json", Local: true, Err: errors.New("no such file")}, exitSpecFetch, "-types-path"},
+		{"download", &bicepdata.FetchError{Path: "index.json", Source: "https://example.com/index.json", Err: errors.New("connection refused")}, exitSpecFetch, "network"},
+		{"unsupported", fmt.Errorf("%w: no PUT operation", schema.ErrUnsupportedConstruct), exitUnsupportedConstruct, ""},
+		{"collision", fmt.Errorf("building variables: %w", terraform.ErrNameCollision), exitNameCollision, ""},
+		{"breaking", fmt.Errorf("updating: %w", terraform.ErrBreakingChanges), exitBreakingChanges, "-allow-breaking"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, hint := exitStatus(tt.err)
+			if status != tt.status {
+				t.Fatalf("expected status %d, got %d", tt.status, status)
+			}
+			if (tt.hint == "") != (hint == "") || !strings.Contains(hint, tt.hint) {
+				t.Fatalf("expected a hint containing %q, got %q", tt.hint, hint)
+			}
+		})
+	}
+}
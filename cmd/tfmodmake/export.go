@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/matt-FFFFFF/tfmodmake/terraform"
+	"github.com/urfave/cli/v3"
+)
+
+func ExportCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "export",
+		Usage: "Export descriptions of existing modules",
+		Commands: []*cli.Command{
+			{
+				Name:      "schema",
+				Usage:     "Write a JSON Schema of the module variables, with the enums, bounds and patterns of their validations, for form-based frontends",
+				ArgsUsage: "[path]",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "output",
+						Usage: "Optional: file to write the schema to instead of stdout",
+					},
+				},
+				Action: runExportSchema,
+			},
+		},
+	}
+}
+
+func runExportSchema(ctx context.Context, cmd *cli.Command) error {
+	targetDir := "."
+	if cmd.NArg() > 0 {
+		targetDir = cmd.Args().First()
+	}
+
+	doc, err := terraform.ExportVariableSchema(targetDir)
+	if err != nil {
+		return fmt.Errorf("failed to export variable schema: %w", err)
+	}
+
+	output := cmd.String("output")
+	if output == "" {
+		return terraform.WriteVariableSchema(os.Stdout, doc)
+	}
+	f, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	if err := terraform.WriteVariableSchema(f, doc); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -2,16 +2,22 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 
 	"github.com/matt-FFFFFF/tfmodmake/bicepdata"
+	"github.com/matt-FFFFFF/tfmodmake/config"
+	"github.com/matt-FFFFFF/tfmodmake/hclgen"
+	"github.com/matt-FFFFFF/tfmodmake/profile"
 	"github.com/matt-FFFFFF/tfmodmake/schema"
 	"github.com/matt-FFFFFF/tfmodmake/submodule"
 	"github.com/matt-FFFFFF/tfmodmake/terraform"
 	"github.com/urfave/cli/v3"
+	"golang.org/x/sync/errgroup"
 )
 
 func GenCommand() *cli.Command {
@@ -32,10 +38,85 @@ func GenCommand() *cli.Command {
 				Name:  "api-version",
 				Usage: "Specific API version to use",
 			},
+			&cli.StringSliceFlag{
+				Name:  "api-versions",
+				Usage: "Generate one module for several API versions, selected by an api_version variable (repeatable; excludes -api-version)",
+			},
+			preferStableFlag(),
+			minAPIVersionFlag(),
+			&cli.StringFlag{
+				Name:  "api-version-properties",
+				Usage: "Properties of a module spanning -api-versions: union (any version; version-specific ones are noted in their description) or intersect (every version)",
+				Value: string(schema.VersionMergeUnion),
+			},
 			&cli.BoolFlag{
 				Name:  "include-preview",
 				Usage: "Include latest preview API version",
 			},
+			typesPathFlag(),
+			typesRefFlag(),
+			&cli.BoolFlag{
+				Name:  "schema-validation-variable",
+				Usage: "Generate a schema_validation_enabled variable wired to the azapi_resource",
+			},
+			&cli.BoolFlag{
+				Name:  "lock-resource-ids-variable",
+				Usage: "Generate a lock_resource_ids variable wired to the azapi_resource locks argument",
+			},
+			&cli.BoolFlag{
+				Name:  "inherited-tags-variable",
+				Usage: "Generate an inherited_tags variable merged beneath var.tags",
+			},
+			&cli.BoolFlag{
+				Name:  "update-resource",
+				Usage: "Generate an azapi_update_resource for resource types without a PUT operation",
+			},
+			&cli.BoolFlag{
+				Name:  "scope-resource",
+				Usage: "Generate a scope variable instead of parent_id for extension resources (detected automatically from the schema)",
+			},
+			&cli.BoolFlag{
+				Name:  "parent-id-components",
+				Usage: "Build parent_id from subscription_id, resource_group_name and parent resource name variables with azapi provider functions",
+			},
+			&cli.BoolFlag{
+				Name:  "naming-variable",
+				Usage: "Make var.name optional and assemble a compliant name from a naming object (prefix, suffix, random_length)",
+			},
+			&cli.BoolFlag{
+				Name:  "resource-output",
+				Usage: "Generate an AVM resource output with the ID, name, location and non-sensitive computed properties",
+			},
+			&cli.BoolFlag{
+				Name:  "module-interface",
+				Usage: "Write module-interface.json describing every variable and output with its JSON Schema type and source spec path",
+			},
+			&cli.BoolFlag{
+				Name:  "avm-strict",
+				Usage: "Enforce the AVM resource module interface and fail listing any deviation that cannot be reconciled",
+			},
+			&cli.BoolFlag{
+				Name:  "telemetry",
+				Usage: "Generate the AVM telemetry resources (main.telemetry.tf) gated by var.enable_telemetry",
+			},
+			&cli.BoolFlag{
+				Name:  "keys-output",
+				Usage: "Generate sensitive outputs for the listKeys/listConnectionStrings actions, gated by an enable_keys_output variable",
+			},
+			&cli.StringFlag{
+				Name:  "body-format",
+				Usage: "How request bodies are passed to azapi: hcl (object values) or json (jsonencode strings)",
+				Value: string(terraform.BodyFormatHCL),
+			},
+			&cli.StringFlag{
+				Name:  "backend",
+				Usage: "Provider the resource is managed with: azapi, azurerm (experimental; writes " + terraform.MappingReportFileName + " listing the variables left to map), or msgraph for Microsoft.Graph types",
+				Value: terraform.AzAPIBackend.Name(),
+			},
+			terraformVersionFlag(),
+			providerVersionFlag(),
+			noLockFlag(),
+			configFlag(),
 		},
 		Action: runGen,
 		Commands: []*cli.Command{
@@ -56,6 +137,8 @@ func GenCommand() *cli.Command {
 						Usage:    "Child resource type",
 						Required: true,
 					},
+					typesPathFlag(),
+					typesRefFlag(),
 					&cli.StringFlag{
 						Name:  "module-dir",
 						Value: "modules",
@@ -65,16 +148,21 @@ func GenCommand() *cli.Command {
 						Name:  "module-name",
 						Usage: "Override derived module folder name",
 					},
+					&cli.BoolFlag{
+						Name:  "inline",
+						Usage: "Generate the child as a for_each azapi_resource in the parent module instead of a submodule",
+					},
 					&cli.BoolFlag{
 						Name:  "dry-run",
 						Usage: "Print planned actions without writing files",
 					},
+					configFlag(),
 				},
 				Action: runAddChild,
 			},
 			{
 				Name:  "avm",
-				Usage: "Generate base module + child submodules + AVM interfaces",
+				Usage: "Generate base module + child submodules + AVM interfaces + examples",
 				Flags: []cli.Flag{
 					&cli.StringFlag{
 						Name:  "api-version",
@@ -84,6 +172,10 @@ func GenCommand() *cli.Command {
 						Name:  "include-preview",
 						Usage: "Include latest preview API version",
 					},
+					preferStableFlag(),
+					minAPIVersionFlag(),
+					typesPathFlag(),
+					typesRefFlag(),
 					&cli.StringFlag{
 						Name:     "resource",
 						Usage:    "Parent resource type",
@@ -98,13 +190,48 @@ func GenCommand() *cli.Command {
 						Value: "modules",
 						Usage: "Directory where child modules live",
 					},
+					&cli.StringSliceFlag{
+						Name:  "inline-child",
+						Usage: "Generate a child resource type as a for_each azapi_resource in its parent module instead of a submodule (repeatable)",
+					},
+					&cli.StringSliceFlag{
+						Name:  "children-include",
+						Usage: "Only generate children whose last type segment matches a glob pattern (repeatable; descendants must match too)",
+					},
+					&cli.StringSliceFlag{
+						Name:  "children-exclude",
+						Usage: "Skip children whose last type segment matches a glob pattern, along with their descendants (repeatable)",
+					},
+					&cli.StringSliceFlag{
+						Name:  "child-api-version",
+						Usage: "Pin the API version of a child as <resource type>@<api version> (repeatable; overrides the config file)",
+					},
+					&cli.IntFlag{
+						Name:      "depth",
+						Usage:     "Levels of child resources to generate; grandchildren are nested in their parent's submodule",
+						OnlyOnce:  true,
+						Value:     1,
+						Validator: validateDiscoveryDepth,
+					},
+					&cli.IntFlag{
+						Name:      "concurrency",
+						Value:     terraform.DefaultLoadConcurrency,
+						Usage:     "Maximum number of child resource schemas loaded, and of submodules generated, at once",
+						Validator: validateConcurrency,
+					},
 					&cli.BoolFlag{
 						Name:  "dry-run",
 						Usage: "Print planned actions without writing files",
 					},
+					terraformVersionFlag(),
+					providerVersionFlag(),
+					noLockFlag(),
+					configFlag(),
 				},
 				Action: runGenAVM,
 			},
+			genImportCommand(),
+			genPipelineCommand(),
 		},
 	}
 }
@@ -119,7 +246,98 @@ func runGen(ctx context.Context, cmd *cli.Command) error {
 		return cli.ShowSubcommandHelp(cmd)
 	}
 
-	return generateBaseModule(ctx, resourceType, apiVersion, includePreview, localName)
+	bodyFormat, err := terraform.ParseBodyFormat(cmd.String("body-format"))
+	if err != nil {
+		return err
+	}
+	backend, err := terraform.ParseBackend(cmd.String("backend"))
+	if err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return err
+	}
+	if err := applyVersionFlags(cfg, cmd.String("terraform-version"), cmd.StringSlice("provider-version")); err != nil {
+		return err
+	}
+
+	opts := configGeneratorOptions(cfg)
+	opts = append(opts,
+		terraform.WithBodyFormat(bodyFormat),
+		terraform.WithBackend(backend),
+		terraform.WithSchemaValidationVariable(cmd.Bool("schema-validation-variable")),
+		terraform.WithLockResourceIDsVariable(cmd.Bool("lock-resource-ids-variable")),
+		terraform.WithUpdateResource(cmd.Bool("update-resource")),
+		terraform.WithScopeResource(cmd.Bool("scope-resource")),
+		terraform.WithInheritedTagsVariable(cmd.Bool("inherited-tags-variable")),
+		terraform.WithParentIDComponents(cmd.Bool("parent-id-components")),
+		terraform.WithNamingVariable(cmd.Bool("naming-variable")),
+		terraform.WithResourceOutput(cmd.Bool("resource-output")),
+		terraform.WithAVMStrict(cmd.Bool("avm-strict")),
+		terraform.WithKeysOutput(cmd.Bool("keys-output")),
+		terraform.WithTelemetry(cmd.Bool("telemetry")),
+		terraform.WithModuleInterface(cmd.Bool("module-interface")),
+		terraform.WithTimings(profile.FromContext(ctx)),
+		terraform.WithContext(ctx),
+	)
+
+	policy := versionPolicy(cmd)
+	if err := policy.Validate(); err != nil {
+		return err
+	}
+	apiVersions := cmd.StringSlice("api-versions")
+	if len(apiVersions) > 0 {
+		if apiVersion != "" {
+			return fmt.Errorf("-api-version and -api-versions cannot be combined")
+		}
+		if len(apiVersions) < 2 {
+			return fmt.Errorf("-api-versions needs at least two versions; use -api-version for one")
+		}
+		for _, v := range apiVersions {
+			if err := policy.Check(v); err != nil {
+				return err
+			}
+		}
+	}
+	merge, err := schema.ParseVersionMerge(cmd.String("api-version-properties"))
+	if err != nil {
+		return err
+	}
+
+	journal := hclgen.NewJournal()
+	opts = append(opts, terraform.WithJournal(journal))
+	spec := specOptions(cmd, cfg)
+	defer spec.Cache.Close()
+	if len(apiVersions) > 0 {
+		err = generateMultiVersionModule(ctx, resourceType, apiVersions, merge, localName, spec, opts...)
+	} else {
+		apiVersion, err = selectAPIVersion(ctx, resourceType, apiVersion, policy, spec)
+		if err != nil {
+			return err
+		}
+		err = generateBaseModule(ctx, resourceType, apiVersion, includePreview, localName, spec, opts...)
+		apiVersions = []string{apiVersion}
+	}
+	if err == nil {
+		locks := newLockRecorder(ctx, cmd)
+		for _, v := range apiVersions {
+			if err = locks.record(ctx, ".", resourceType, v, *spec); err != nil {
+				break
+			}
+		}
+		if err == nil {
+			err = locks.write(journal)
+		}
+	}
+	if err == nil {
+		err = runHooks(ctx, cfg.Hooks, ".", resourceType, journal)
+	}
+	if err != nil {
+		return restoreOnCancel(ctx, ".", journal, err)
+	}
+	return printChangeSummary(os.Stdout, ".", journal)
 }
 
 func runAddChild(ctx context.Context, cmd *cli.Command) error {
@@ -137,6 +355,13 @@ func runAddChild(ctx context.Context, cmd *cli.Command) error {
 
 	modulePath := filepath.Join(moduleDir, finalModuleName)
 
+	if dryRun && cmd.Bool("inline") {
+		fmt.Printf("DRY RUN: Would generate %s as azapi_resource.%s in the root module with:\n", child, finalModuleName)
+		for _, prefix := range []string{"variables", "locals", "main", "outputs"} {
+			fmt.Printf("  - %s.%s.tf\n", prefix, finalModuleName)
+		}
+		return nil
+	}
 	if dryRun {
 		fmt.Printf("DRY RUN: Would create/update child module at: %s\n", modulePath)
 		fmt.Printf("DRY RUN: Would generate the following files in child module:\n")
@@ -149,89 +374,230 @@ func runAddChild(ctx context.Context, cmd *cli.Command) error {
 		moduleDirName := filepath.Base(modulePath)
 		fmt.Printf("  - variables.%s.tf\n", moduleDirName)
 		fmt.Printf("  - main.%s.tf\n", moduleDirName)
+		fmt.Printf("  - outputs.%s.tf\n", moduleDirName)
 		return nil
 	}
 
-	if err := generateChildModule(ctx, child, apiVersion, includePreview, modulePath); err != nil {
-		return fmt.Errorf("failed to generate child module: %w", err)
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	override, _ := cfg.ChildOverride(child)
+	if apiVersion == "" {
+		apiVersion = override.APIVersion
+	}
+	includePreview = includePreview || override.IncludePreview
+	parentSpec := specOptions(cmd, cfg)
+	defer parentSpec.Cache.Close()
+	spec := childSpec(parentSpec, override)
+
+	journal := hclgen.NewJournal()
+	childOpts := append(childGeneratorOptions(cfg), terraform.WithTimings(profile.FromContext(ctx)), terraform.WithContext(ctx), terraform.WithJournal(journal))
+	if cmd.Bool("inline") || override.Inline {
+		if err := generateInlineChild(ctx, child, apiVersion, includePreview, spec, ".", finalModuleName, childOpts...); err != nil {
+			return restoreOnCancel(ctx, ".", journal, fmt.Errorf("failed to generate inline child: %w", err))
+		}
+		fmt.Printf("Successfully generated %s as azapi_resource.%s\n", child, finalModuleName)
+		if err := runHooks(ctx, cfg.Hooks, ".", child, journal); err != nil {
+			return restoreOnCancel(ctx, ".", journal, err)
+		}
+		return printChangeSummary(os.Stdout, ".", journal)
 	}
 
-	if err := submodule.Generate(modulePath); err != nil {
-		return fmt.Errorf("failed to wire child module: %w", err)
+	if err := generateChildModule(ctx, child, apiVersion, includePreview, spec, modulePath, childOpts...); err != nil {
+		return restoreOnCancel(ctx, ".", journal, fmt.Errorf("failed to generate child module: %w", err))
+	}
+
+	if err := submodule.Generate(modulePath, journal); err != nil {
+		return restoreOnCancel(ctx, ".", journal, fmt.Errorf("failed to wire child module: %w", err))
+	}
+	if err := terraform.WriteTFVarsExample(".", journal); err != nil {
+		return restoreOnCancel(ctx, ".", journal, fmt.Errorf("failed to update %s: %w", terraform.TFVarsExampleFileName, err))
 	}
 
 	fmt.Printf("Successfully created child module at: %s\n", modulePath)
 	fmt.Println("Successfully generated submodule wrapper files")
-	return nil
+	if err := runHooks(ctx, cfg.Hooks, ".", child, journal); err != nil {
+		return restoreOnCancel(ctx, ".", journal, err)
+	}
+	return printChangeSummary(os.Stdout, ".", journal)
 }
 
 func runGenAVM(ctx context.Context, cmd *cli.Command) error {
 	resourceType := cmd.String("resource")
 	localName := cmd.String("local-name")
 	apiVersion := cmd.String("api-version")
-	includePreview := cmd.Bool("include-preview")
 	moduleDir := cmd.String("module-dir")
+	depth := cmd.Int("depth")
 	dryRun := cmd.Bool("dry-run")
 
 	if dryRun {
 		fmt.Println("DRY RUN: Would execute the following steps:")
 		fmt.Printf("1. Generate base module for resource: %s\n", resourceType)
-		fmt.Printf("2. Discover children under parent: %s\n", resourceType)
+		fmt.Printf("2. Discover children under parent: %s (depth %d)\n", resourceType, depth)
 		fmt.Printf("3. Generate submodule for each discovered child in: %s/\n", moduleDir)
-		fmt.Printf("4. Generate main.interfaces.tf\n")
+		if depth > 1 {
+			fmt.Printf("   Descendants are nested in %s/ of their parent's submodule\n", moduleDir)
+		}
+		fmt.Printf("4. Scaffold AVM interfaces into main.<interface>.tf and variables.<interface>.tf\n")
+		fmt.Printf("5. Scaffold tflint and terraform-docs configs, examples/default, examples/complete and tests/e2e\n")
 		return nil
 	}
 
-	if err := orchestrateAVMGeneration(ctx, resourceType, apiVersion, includePreview, localName, moduleDir); err != nil {
-		return fmt.Errorf("failed to generate AVM module: %w", err)
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return err
+	}
+	if err := applyChildAPIVersionFlags(cfg, cmd.StringSlice("child-api-version")); err != nil {
+		return err
+	}
+	if err := applyVersionFlags(cfg, cmd.String("terraform-version"), cmd.StringSlice("provider-version")); err != nil {
+		return err
+	}
+	applyInlineChildFlags(cfg, cmd.StringSlice("inline-child"))
+	if cmd.IsSet("children-include") {
+		cfg.ChildrenInclude = cmd.StringSlice("children-include")
+	}
+	if cmd.IsSet("children-exclude") {
+		cfg.ChildrenExclude = cmd.StringSlice("children-exclude")
+	}
+
+	journal := hclgen.NewJournal()
+	baseOpts := append(configGeneratorOptions(cfg), terraform.WithTelemetry(true), terraform.WithTimings(profile.FromContext(ctx)), terraform.WithContext(ctx), terraform.WithJournal(journal))
+	spec := specOptions(cmd, cfg)
+	defer spec.Cache.Close()
+	if err := orchestrateAVMGeneration(ctx, resourceType, apiVersion, versionPolicy(cmd), spec, localName, moduleDir, depth, cmd.Int("concurrency"), cfg, newLockRecorder(ctx, cmd), journal, baseOpts...); err != nil {
+		return restoreOnCancel(ctx, ".", journal, fmt.Errorf("failed to generate AVM module: %w", err))
 	}
 
 	fmt.Println("Successfully generated AVM module with child submodules and interfaces")
-	return nil
+	if err := runHooks(ctx, cfg.Hooks, ".", resourceType, journal); err != nil {
+		return restoreOnCancel(ctx, ".", journal, err)
+	}
+	return printChangeSummary(os.Stdout, ".", journal)
 }
 
 // generateChildModule generates a child module scaffold at the specified path.
-func generateChildModule(ctx context.Context, childType, apiVersion string, includePreview bool, modulePath string) error {
-	if err := os.MkdirAll(modulePath, 0o755); err != nil {
-		return fmt.Errorf("failed to create module directory: %w", err)
-	}
-
-	var loadOpts []terraform.LoadOption
-	if apiVersion != "" {
-		loadOpts = append(loadOpts, terraform.WithAPIVersionLoad(apiVersion))
+// A non-empty typesPath loads the child from a local bicep-types-az checkout.
+func generateChildModule(ctx context.Context, childType, apiVersion string, includePreview bool, spec bicepdata.FetchOptions, modulePath string, opts ...terraform.GeneratorOption) error {
+	result, err := loadChildResource(ctx, childType, apiVersion, includePreview, spec)
+	if err != nil {
+		return err
 	}
-	loadOpts = append(loadOpts, terraform.WithIncludePreview(includePreview))
+	return writeChildModule(childType, result, modulePath, opts...)
+}
 
-	result, err := terraform.LoadResource(ctx, childType, loadOpts...)
-	if err != nil {
-		return fmt.Errorf("failed to load child resource: %w", err)
+// writeChildModule generates a child module from its loaded schema at the specified path.
+func writeChildModule(childType string, result terraform.GeneratorOption, modulePath string, opts ...terraform.GeneratorOption) error {
+	if err := os.MkdirAll(modulePath, 0o755); err != nil {
+		return fmt.Errorf("failed to create module directory: %w", err)
 	}
 
 	moduleName := deriveModuleName(childType)
 	localName := "resource_body"
-	if err := terraform.Generate(childType,
+	opts = append([]terraform.GeneratorOption{
 		result,
 		terraform.WithLocalName(localName),
 		terraform.WithModuleNamePrefix(moduleName),
 		terraform.WithOutputDir(modulePath),
-	); err != nil {
+	}, opts...)
+	if err := terraform.Generate(childType, opts...); err != nil {
 		return fmt.Errorf("failed to generate terraform files: %w", err)
 	}
 
 	return nil
 }
 
-// orchestrateAVMGeneration performs the full AVM generation workflow
-func orchestrateAVMGeneration(ctx context.Context, resourceType, apiVersion string, includePreview bool, localName, moduleDir string) error {
+// generateInlineChild generates a child as azapi_resource.<name> in the module in
+// parentDir, driven by a map variable of the same name.
+func generateInlineChild(ctx context.Context, childType, apiVersion string, includePreview bool, spec bicepdata.FetchOptions, parentDir, name string, opts ...terraform.GeneratorOption) error {
+	result, err := loadChildResource(ctx, childType, apiVersion, includePreview, spec)
+	if err != nil {
+		return err
+	}
+	return writeInlineChild(childType, result, parentDir, name, opts...)
+}
+
+// writeInlineChild generates a child from its loaded schema as azapi_resource.<name>
+// in the module in parentDir.
+func writeInlineChild(childType string, result terraform.GeneratorOption, parentDir, name string, opts ...terraform.GeneratorOption) error {
+	opts = append([]terraform.GeneratorOption{
+		result,
+		terraform.WithLocalName("resource_body"),
+		terraform.WithOutputDir(parentDir),
+	}, opts...)
+	if err := terraform.GenerateInlineChild(childType, name, opts...); err != nil {
+		return fmt.Errorf("failed to generate terraform files: %w", err)
+	}
+	return nil
+}
+
+// loadChildResource loads the schema of a child resource type from spec.
+func loadChildResource(ctx context.Context, childType, apiVersion string, includePreview bool, spec bicepdata.FetchOptions) (terraform.GeneratorOption, error) {
+	result, err := terraform.LoadResource(ctx, childType, append(childLoadOptions(apiVersion, includePreview, ""), specLoadOptions(&spec)...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load child resource: %w", err)
+	}
+	return result, nil
+}
+
+// childLoadOptions are the options loading a child resource type.
+func childLoadOptions(apiVersion string, includePreview bool, typesPath string) []terraform.LoadOption {
+	var loadOpts []terraform.LoadOption
+	if apiVersion != "" {
+		loadOpts = append(loadOpts, terraform.WithAPIVersionLoad(apiVersion))
+	}
+	loadOpts = append(loadOpts, terraform.WithIncludePreview(includePreview))
+	if typesPath != "" {
+		loadOpts = append(loadOpts, terraform.WithTypesPath(typesPath))
+	}
+	return loadOpts
+}
+
+// specLoadOptions are the options loading from spec; nil loads the published
+// types from main. Both the path and the ref are set, so the options replace
+// those of a parent's spec.
+func specLoadOptions(spec *bicepdata.FetchOptions) []terraform.LoadOption {
+	if spec == nil {
+		return nil
+	}
+	loadOpts := []terraform.LoadOption{terraform.WithTypesPath(spec.LocalPath), terraform.WithTypesRef(spec.Ref)}
+	if spec.Cache != nil {
+		loadOpts = append(loadOpts, terraform.WithLoadCache(spec.Cache))
+	}
+	return loadOpts
+}
+
+// orchestrateAVMGeneration performs the full AVM generation workflow.
+// Base options are applied to the base module only; child options to every submodule.
+// Children up to depth levels below the resource are generated; each descendant is
+// nested in moduleDir of its parent's submodule and wired into it. The children
+// section of cfg pins the API version or types of individual children, and its
+// include and exclude patterns select the children that are generated. Child
+// schemas are loaded, sharing the fetched files, and submodules generated up to
+// concurrency at a time.
+func orchestrateAVMGeneration(ctx context.Context, resourceType, apiVersion string, policy bicepdata.VersionPolicy, spec *bicepdata.FetchOptions, localName, moduleDir string, depth, concurrency int, cfg *config.Config, locks *lockRecorder, journal *hclgen.Journal, baseOpts ...terraform.GeneratorOption) error {
+	childOpts := append(childGeneratorOptions(cfg), terraform.WithTimings(profile.FromContext(ctx)), terraform.WithContext(ctx), terraform.WithJournal(journal))
+	includePreview := policy.IncludePreview
+
+	apiVersion, err := selectAPIVersion(ctx, resourceType, apiVersion, policy, spec)
+	if err != nil {
+		return err
+	}
+
 	// Step 1: Generate base module
-	fmt.Println("Step 1/4: Generating base module...")
-	if err := generateBaseModule(ctx, resourceType, apiVersion, includePreview, localName); err != nil {
+	fmt.Println("Step 1/5: Generating base module...")
+	if err := generateBaseModule(ctx, resourceType, apiVersion, includePreview, localName, spec, baseOpts...); err != nil {
 		return fmt.Errorf("failed to generate base module: %w", err)
 	}
+	if err := locks.record(ctx, ".", resourceType, apiVersion, *spec); err != nil {
+		return err
+	}
 
 	// Step 2: Discover children from bicep-types index
-	fmt.Println("Step 2/4: Discovering child resources...")
-	indexData, err := bicepdata.FetchIndex(ctx, nil)
+	fmt.Println("Step 2/5: Discovering child resources...")
+	indexData, err := bicepdata.FetchIndex(ctx, spec)
 	if err != nil {
 		return fmt.Errorf("failed to fetch bicep-types index: %w", err)
 	}
@@ -239,48 +605,191 @@ func orchestrateAVMGeneration(ctx context.Context, resourceType, apiVersion stri
 	if err != nil {
 		return fmt.Errorf("failed to parse bicep-types index: %w", err)
 	}
-	children := schema.DiscoverChildren(idx, resourceType, 1)
+	discovered := schema.DiscoverChildren(idx, resourceType, depth)
+	filtered, err := schema.FilterChildren(discovered, cfg.ChildrenInclude, cfg.ChildrenExclude)
+	if err != nil {
+		return err
+	}
+	if skipped := len(discovered) - len(filtered); skipped > 0 {
+		fmt.Printf("Filtered out %d child resource type(s)\n", skipped)
+	}
+	discovered = filtered
+	pinChildVersions(discovered, cfg)
+	children, previewOnly := schema.SelectAPIVersions(discovered, includePreview)
+	schema.SortChildren(children)
 
 	fmt.Printf("Found %d child resource type(s)\n", len(children))
+	for _, child := range previewOnly {
+		fmt.Printf("  Skipping %s: only preview API versions exist (use -include-preview)\n", child.ResourceType)
+	}
 
 	// Step 3: Generate submodule for each child
 	if len(children) > 0 {
-		fmt.Println("Step 3/4: Generating child submodules...")
+		fmt.Println("Step 3/5: Generating child submodules...")
+
+		// Plan where each child goes, so their schemas can be loaded together.
+		type plannedChild struct {
+			child      schema.ChildResource
+			override   config.ChildOverride
+			parentDir  string
+			relPath    string
+			modulePath string
+		}
+		var planned []plannedChild
+		modulePaths := map[string]string{}
 		for i, child := range children {
 			if isInterfaceManagedChild(child.ResourceType) {
 				fmt.Printf("  [%d/%d] Skipping interface-managed child %s\n", i+1, len(children), child.ResourceType)
 				continue
 			}
 
-			fmt.Printf("  [%d/%d] Generating submodule for %s...\n", i+1, len(children), child.ResourceType)
+			parentDir := "."
+			if child.Depth > 1 {
+				parentType := child.ResourceType[:strings.LastIndex(child.ResourceType, "/")]
+				var ok bool
+				if parentDir, ok = modulePaths[strings.ToLower(parentType)]; !ok {
+					fmt.Printf("  [%d/%d] Skipping %s: its parent %s has no submodule\n", i+1, len(children), child.ResourceType, parentType)
+					continue
+				}
+			}
+
+			override, _ := cfg.ChildOverride(child.ResourceType)
+			p := plannedChild{child: child, override: override, parentDir: parentDir}
+			if !override.Inline {
+				p.relPath = filepath.Join(moduleDir, deriveModuleName(child.ResourceType))
+				p.modulePath = filepath.Join(parentDir, p.relPath)
+				modulePaths[strings.ToLower(child.ResourceType)] = p.modulePath
+			}
+			planned = append(planned, p)
+		}
+
+		// Children are loaded from their own API version, which need not match the parent's.
+		fmt.Printf("  Loading %d child resource schema(s)...\n", len(planned))
+		requests := make([]terraform.SchemaRequest, len(planned))
+		childSpecs := make([]bicepdata.FetchOptions, len(planned))
+		for i, p := range planned {
+			childSpecs[i] = childSpec(spec, p.override)
+			requests[i] = terraform.SchemaRequest{
+				ResourceType: p.child.ResourceType,
+				Options:      append(childLoadOptions(p.child.APIVersion, includePreview, ""), specLoadOptions(&childSpecs[i])...),
+			}
+		}
+		schemas, err := terraform.LoadResourceSchemas(ctx, requests, concurrency, specLoadOptions(spec)...)
+		if err != nil {
+			return fmt.Errorf("failed to load child resource: %w", err)
+		}
+		for i := range planned {
+			planned[i].child.ScopeResource = schemas[i].IsExtensionResource()
+		}
 
-			moduleName := deriveModuleName(child.ResourceType)
-			modulePath := filepath.Join(moduleDir, moduleName)
+		// Submodules have their own directories, so they are generated
+		// concurrently; a failure does not stop the others, and all failures are
+		// reported. Inline children edit their parent module, so they follow once
+		// every submodule exists.
+		type wiring struct {
+			resourceType, parentDir, modulePath string
+		}
+		var wirings []wiring
+		var mu sync.Mutex
+		var g errgroup.Group
+		g.SetLimit(concurrency)
+		errs := make([]error, len(planned))
+		for i, p := range planned {
+			if p.override.Inline {
+				continue
+			}
+			wirings = append(wirings, wiring{resourceType: p.child.ResourceType, parentDir: p.parentDir, modulePath: p.relPath})
+			g.Go(func() error {
+				if err := writeChildModule(p.child.ResourceType, terraform.WithLoadedSchema(schemas[i]), p.modulePath, childOpts...); err != nil {
+					errs[i] = fmt.Errorf("failed to generate child module for %s: %w", p.child.ResourceType, err)
+					return nil
+				}
+				if err := locks.record(ctx, p.modulePath, p.child.ResourceType, schemas[i].APIVersion, childSpecs[i]); err != nil {
+					errs[i] = fmt.Errorf("failed to record child module for %s: %w", p.child.ResourceType, err)
+					return nil
+				}
+				mu.Lock()
+				defer mu.Unlock()
+				fmt.Printf("  [%d/%d] Generated submodule for %s@%s%s\n", i+1, len(planned), p.child.ResourceType, p.child.APIVersion, scopeResourceMarker(p.child))
+				return nil
+			})
+		}
+		_ = g.Wait()
+		if err := errors.Join(errs...); err != nil {
+			return err
+		}
 
-			if err := generateChildModule(ctx, child.ResourceType, apiVersion, includePreview, modulePath); err != nil {
-				return fmt.Errorf("failed to generate child module for %s: %w", child.ResourceType, err)
+		for i, p := range planned {
+			if !p.override.Inline {
+				continue
+			}
+			fmt.Printf("  [%d/%d] Generating %s@%s inline%s...\n", i+1, len(planned), p.child.ResourceType, p.child.APIVersion, scopeResourceMarker(p.child))
+			if err := writeInlineChild(p.child.ResourceType, terraform.WithLoadedSchema(schemas[i]), p.parentDir, deriveModuleName(p.child.ResourceType), childOpts...); err != nil {
+				return fmt.Errorf("failed to generate inline child %s: %w", p.child.ResourceType, err)
+			}
+			if err := locks.record(ctx, p.parentDir, p.child.ResourceType, schemas[i].APIVersion, childSpecs[i]); err != nil {
+				return err
 			}
+		}
 
-			if err := submodule.Generate(modulePath); err != nil {
-				return fmt.Errorf("failed to wire child module for %s: %w", child.ResourceType, err)
+		// Wire the deepest submodules first, so every child module already exposes
+		// its own children when it is wired into its parent.
+		for i := len(wirings) - 1; i >= 0; i-- {
+			w := wirings[i]
+			if err := submodule.GenerateInto(w.parentDir, w.modulePath, journal); err != nil {
+				return fmt.Errorf("failed to wire child module for %s: %w", w.resourceType, err)
+			}
+			if err := terraform.WriteTFVarsExample(w.parentDir, journal); err != nil {
+				return fmt.Errorf("failed to update %s of %s: %w", terraform.TFVarsExampleFileName, w.parentDir, err)
 			}
 		}
 	} else {
-		fmt.Println("Step 3/4: No child resources found, skipping submodule generation")
+		fmt.Println("Step 3/5: No child resources found, skipping submodule generation")
 	}
 
 	// Step 4: Generate AVM interfaces
-	fmt.Println("Step 4/4: Generating AVM interfaces...")
-	var rs *schema.ResourceSchema
-	loaded, loadErr := bicepdata.LoadResourceFromIndex(ctx, idx, resourceType, apiVersion, includePreview, nil)
-	if loadErr == nil {
-		rs, _ = schema.ConvertResource(loaded)
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	fmt.Println("Step 4/5: Generating AVM interfaces...")
+	loaded, err := bicepdata.LoadResourceFromIndex(ctx, idx, resourceType, apiVersion, includePreview, spec)
+	if err != nil {
+		return fmt.Errorf("failed to load resource for AVM interfaces: %w", err)
+	}
+	rs, err := schema.ConvertResource(loaded)
+	if err != nil {
+		return fmt.Errorf("failed to convert resource for AVM interfaces: %w", err)
 	}
-	if err := terraform.GenerateInterfacesFile(resourceType, rs, "."); err != nil {
+	result, err := terraform.GenerateInterfaces(resourceType, rs, ".", nil, journal)
+	if err != nil {
 		return fmt.Errorf("failed to generate AVM interfaces: %w", err)
 	}
+	printInterfacesResult(result)
 
-	return nil
+	// Step 5: Scaffold the AVM repository layout
+	fmt.Println("Step 5/5: Scaffolding AVM repository layout...")
+	created, err := terraform.ScaffoldAVMLayout(".", journal)
+	if err != nil {
+		return fmt.Errorf("failed to scaffold AVM repository layout: %w", err)
+	}
+	for _, path := range created {
+		fmt.Printf("Created %s\n", path)
+	}
+
+	return locks.write(journal)
+}
+
+// childSpec is where a child is read from: the checkout or published ref its
+// override names, or the spec of its parent.
+func childSpec(spec *bicepdata.FetchOptions, override config.ChildOverride) bicepdata.FetchOptions {
+	child := *spec
+	switch {
+	case override.TypesPath != "":
+		child.LocalPath, child.Ref = override.TypesPath, ""
+	case override.TypesRef != "":
+		child.LocalPath, child.Ref = "", override.TypesRef
+	}
+	return child
 }
 
 func isInterfaceManagedChild(childResourceType string) bool {
@@ -293,13 +802,17 @@ func isInterfaceManagedChild(childResourceType string) bool {
 	return strings.EqualFold(last, "privateEndpointConnections")
 }
 
-// generateBaseModule generates the base module files in the current directory
-func generateBaseModule(ctx context.Context, resourceType, apiVersion string, includePreview bool, localName string) error {
+// generateBaseModule generates the base module files in the current directory.
+// The resource is read from spec: a local bicep-types-az checkout or the
+// published types at a ref, sharing the fetched files through its cache.
+// Extra generator options are applied after the loaded resource and local name.
+func generateBaseModule(ctx context.Context, resourceType, apiVersion string, includePreview bool, localName string, spec *bicepdata.FetchOptions, extraOpts ...terraform.GeneratorOption) error {
 	var loadOpts []terraform.LoadOption
 	if apiVersion != "" {
 		loadOpts = append(loadOpts, terraform.WithAPIVersionLoad(apiVersion))
 	}
 	loadOpts = append(loadOpts, terraform.WithIncludePreview(includePreview))
+	loadOpts = append(loadOpts, specLoadOptions(spec)...)
 
 	result, err := terraform.LoadResource(ctx, resourceType, loadOpts...)
 	if err != nil {
@@ -311,8 +824,38 @@ func generateBaseModule(ctx context.Context, resourceType, apiVersion string, in
 		finalLocalName = localName
 	}
 
-	return terraform.Generate(resourceType,
+	opts := []terraform.GeneratorOption{
 		result,
 		terraform.WithLocalName(finalLocalName),
-	)
+	}
+	opts = append(opts, extraOpts...)
+
+	return terraform.Generate(resourceType, opts...)
+}
+
+// generateMultiVersionModule generates the module for resourceType at every
+// one of apiVersions: the schemas are loaded and merged, and the resource type
+// takes its version from an api_version variable. The schemas are read from
+// spec, as by generateBaseModule.
+func generateMultiVersionModule(ctx context.Context, resourceType string, apiVersions []string, merge schema.VersionMerge, localName string, spec *bicepdata.FetchOptions, extraOpts ...terraform.GeneratorOption) error {
+	requests := make([]terraform.SchemaRequest, len(apiVersions))
+	for i, v := range apiVersions {
+		requests[i] = terraform.SchemaRequest{ResourceType: resourceType, Options: []terraform.LoadOption{terraform.WithAPIVersionLoad(v)}}
+	}
+	schemas, err := terraform.LoadResourceSchemas(ctx, requests, 0, specLoadOptions(spec)...)
+	if err != nil {
+		return fmt.Errorf("failed to load resource: %w", err)
+	}
+
+	if localName == "" {
+		localName = "resource_body"
+	}
+	opts := []terraform.GeneratorOption{
+		terraform.WithLoadedSchema(schema.MergeVersions(schemas, merge)),
+		terraform.WithLocalName(localName),
+		terraform.WithAPIVersionVariable(apiVersions...),
+	}
+	opts = append(opts, extraOpts...)
+
+	return terraform.Generate(resourceType, opts...)
 }
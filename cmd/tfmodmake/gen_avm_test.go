@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/matt-FFFFFF/tfmodmake/internal/typestest"
+	"github.com/matt-FFFFFF/tfmodmake/lockfile"
+)
+
+// writeChildTypes writes a checkout holding Microsoft.Test/widgets with three
+// children and a grandchild below gadgets.
+func writeChildTypes(t *testing.T) string {
+	t.Helper()
+	return typestest.Write(t, typestest.Widgets,
+		typestest.Resource{Type: "Microsoft.Test/widgets/gadgets"},
+		typestest.Resource{Type: "Microsoft.Test/widgets/gadgets/bolts"},
+		typestest.Resource{Type: "Microsoft.Test/widgets/gizmos"},
+		typestest.Resource{Type: "Microsoft.Test/widgets/sprockets"},
+	)
+}
+
+func genAVMArgs(typesPath string) []string {
+	return []string{"gen", "avm", "-resource", "Microsoft.Test/widgets", "-types-path", typesPath, "-depth", "2", "-concurrency", "4"}
+}
+
+func TestGenAVMConcurrentSubmodules(t *testing.T) {
+	typesPath := writeChildTypes(t)
+	chdirTemp(t)
+
+	if err := GenCommand().Run(context.Background(), genAVMArgs(typesPath)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Each submodule is wired into its parent, the grandchild into gadgets.
+	wired := map[string]string{
+		"main.gadgets.tf":                  `"./modules/gadgets"`,
+		"main.gizmos.tf":                   `"./modules/gizmos"`,
+		"main.sprockets.tf":                `"./modules/sprockets"`,
+		"modules/gadgets/main.bolts.tf":    `"./modules/bolts"`,
+		"modules/gadgets/outputs.bolts.tf": "module.bolts",
+	}
+	for name, want := range wired {
+		content, err := os.ReadFile(filepath.FromSlash(name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(content), want) {
+			t.Fatalf("%s does not contain %s:\n%s", name, want, content)
+		}
+	}
+
+	locks := map[string]struct {
+		root, resourceType string
+	}{
+		"modules/gadgets":               {"../..", "Microsoft.Test/widgets/gadgets"},
+		"modules/gadgets/modules/bolts": {"../../../..", "Microsoft.Test/widgets/gadgets/bolts"},
+		"modules/gizmos":                {"../..", "Microsoft.Test/widgets/gizmos"},
+		"modules/sprockets":             {"../..", "Microsoft.Test/widgets/sprockets"},
+	}
+	for dir, want := range locks {
+		lock, err := lockfile.Read(filepath.FromSlash(dir))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if lock.Root != want.root {
+			t.Fatalf("%s: Root = %q, want %q", dir, lock.Root, want.root)
+		}
+		var got []string
+		for _, r := range lock.Resources {
+			got = append(got, r.ResourceType+"@"+r.APIVersion)
+		}
+		if wantResources := []string{want.resourceType + "@2024-01-01"}; !reflect.DeepEqual(got, wantResources) {
+			t.Fatalf("%s: Resources = %q, want %q", dir, got, wantResources)
+		}
+	}
+	if _, err := lockfile.Read("."); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGenAVMReportsEverySubmoduleFailure(t *testing.T) {
+	typesPath := writeChildTypes(t)
+	chdirTemp(t)
+
+	// A file where a submodule directory goes makes its generation fail.
+	if err := os.MkdirAll("modules", 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"gizmos", "sprockets"} {
+		if err := os.WriteFile(filepath.Join("modules", name), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err := GenCommand().Run(context.Background(), genAVMArgs(typesPath))
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	for _, want := range []string{"Microsoft.Test/widgets/gizmos", "Microsoft.Test/widgets/sprockets"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("error does not report %s: %v", want, err)
+		}
+	}
+
+	// The other submodules are still generated, down to the grandchild.
+	for _, dir := range []string{"modules/gadgets", "modules/gadgets/modules/bolts"} {
+		if _, err := os.Stat(filepath.Join(filepath.FromSlash(dir), "main.tf")); err != nil {
+			t.Fatalf("expected %s to be generated: %v", dir, err)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/matt-FFFFFF/tfmodmake/config"
+	"github.com/matt-FFFFFF/tfmodmake/hclgen"
+)
+
+// hookManifest is the generation manifest pre_write command hooks receive on
+// stdin and print, with the transformed files, on stdout.
+type hookManifest struct {
+	ResourceType string     `json:"resource_type"`
+	Files        []hookFile `json:"files"`
+}
+
+type hookFile struct {
+	Name    string `json:"name"`
+	Content string `json:"content"`
+}
+
+// runHooks runs the configured hooks on the files below dir that generation
+// added or changed, as recorded in journal. The pre_write hooks transform the
+// files in turn, and their result is written once they all succeeded; the
+// post_write hooks then run on the written files.
+func runHooks(ctx context.Context, hooks config.Hooks, dir, resourceType string, journal *hclgen.Journal) error {
+	if len(hooks.PreWrite) == 0 && len(hooks.PostWrite) == 0 {
+		return nil
+	}
+	changes, err := moduleChanges(dir, journal)
+	if err != nil {
+		return err
+	}
+	files := map[string][]byte{}
+	for _, c := range changes {
+		if c.exists && (!c.existed || !bytes.Equal(c.before, c.after)) {
+			files[c.name] = c.after
+		}
+	}
+
+	for i, hook := range hooks.PreWrite {
+		var err error
+		if hook.Template != "" {
+			err = runTemplateHook(hook, dir, resourceType, files)
+		} else {
+			err = runManifestHook(ctx, hook, dir, resourceType, files)
+		}
+		if err != nil {
+			return fmt.Errorf("hooks.pre_write[%d]: %w", i, err)
+		}
+	}
+	names := sortedNames(files)
+	for _, name := range names {
+		if err := journal.WriteIfChanged(filepath.Join(dir, filepath.FromSlash(name)), files[name]); err != nil {
+			return err
+		}
+	}
+
+	for i, hook := range hooks.PostWrite {
+		var list strings.Builder
+		for _, name := range names {
+			if hook.Matches(name) {
+				list.WriteString(name + "\n")
+			}
+		}
+		if _, err := runHookCommand(ctx, hook.Command, dir, resourceType, strings.NewReader(list.String())); err != nil {
+			return fmt.Errorf("hooks.post_write[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// runTemplateHook renders every matching file through the template.
+func runTemplateHook(hook config.Hook, dir, resourceType string, files map[string][]byte) error {
+	src, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(hook.Template)))
+	if err != nil {
+		return err
+	}
+	tmpl, err := template.New(hook.Template).Parse(string(src))
+	if err != nil {
+		return err
+	}
+	for _, name := range sortedNames(files) {
+		if !hook.Matches(name) {
+			continue
+		}
+		var buf bytes.Buffer
+		data := map[string]string{"Name": name, "Content": string(files[name]), "ResourceType": resourceType}
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return fmt.Errorf("rendering %s: %w", name, err)
+		}
+		files[name] = buf.Bytes()
+	}
+	return nil
+}
+
+// runManifestHook passes the matching files to the command and takes back the
+// files it prints. Files it leaves out of its manifest are kept as they are; an
+// empty output changes nothing.
+func runManifestHook(ctx context.Context, hook config.Hook, dir, resourceType string, files map[string][]byte) error {
+	manifest := hookManifest{ResourceType: resourceType, Files: []hookFile{}}
+	for _, name := range sortedNames(files) {
+		if hook.Matches(name) {
+			manifest.Files = append(manifest.Files, hookFile{Name: name, Content: string(files[name])})
+		}
+	}
+	input, err := json.Marshal(manifest)
+	if err != nil {
+		return err
+	}
+	output, err := runHookCommand(ctx, hook.Command, dir, resourceType, bytes.NewReader(input))
+	if err != nil {
+		return err
+	}
+	if len(bytes.TrimSpace(output)) == 0 {
+		return nil
+	}
+
+	var result hookManifest
+	if err := json.Unmarshal(output, &result); err != nil {
+		return fmt.Errorf("parsing the manifest printed by %s: %w", hook.Command[0], err)
+	}
+	for _, f := range result.Files {
+		if _, ok := files[f.Name]; !ok || !hook.Matches(f.Name) {
+			return fmt.Errorf("%s returned %s, which it was not given", hook.Command[0], f.Name)
+		}
+		files[f.Name] = []byte(f.Content)
+	}
+	return nil
+}
+
+// runHookCommand runs command in dir with stdin and returns its stdout. Its
+// stderr is passed through.
+func runHookCommand(ctx context.Context, command []string, dir, resourceType string, stdin io.Reader) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "TFMODMAKE_RESOURCE_TYPE="+resourceType)
+	cmd.Stdin = stdin
+	cmd.Stderr = os.Stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("running %s: %w", strings.Join(command, " "), err)
+	}
+	return output, nil
+}
+
+func sortedNames(files map[string][]byte) []string {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/matt-FFFFFF/tfmodmake/config"
+	"github.com/matt-FFFFFF/tfmodmake/hclgen"
+)
+
+func TestRunHooks(t *testing.T) {
+	dir := t.TempDir()
+	writeFile := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile("header.tmpl", "# {{.ResourceType}}: {{.Name}}\n{{.Content}}")
+	writeFile("unchanged.tf", "kept\n")
+
+	journal := hclgen.NewJournal()
+	for name, content := range map[string]string{"main.tf": "resource\n", "README.md": "readme\n", "unchanged.tf": "kept\n"} {
+		if err := journal.WriteIfChanged(filepath.Join(dir, name), []byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	hooks := config.Hooks{
+		PreWrite: []config.Hook{
+			{Template: "header.tmpl", Files: []string{"*.tf"}},
+			{Command: []string{"sh", "-c", "sed 's/resource/RESOURCE/g'"}},
+		},
+		PostWrite: []config.Hook{
+			{Command: []string{"sh", "-c", "cat > written.txt"}},
+		},
+	}
+	if err := runHooks(context.Background(), hooks, dir, "Microsoft.Test/widgets", journal); err != nil {
+		t.Fatalf("runHooks returned error: %v", err)
+	}
+
+	for name, want := range map[string]string{
+		"main.tf":      "# Microsoft.Test/widgets: main.tf\nRESOURCE\n",
+		"README.md":    "readme\n",
+		"unchanged.tf": "kept\n",
+		"written.txt":  "README.md\nmain.tf\n",
+	} {
+		got, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestRunHooks_RejectsUnknownFiles(t *testing.T) {
+	dir := t.TempDir()
+	hooks := config.Hooks{PreWrite: []config.Hook{
+		{Command: []string{"sh", "-c", `echo '{"files": [{"name": "other.tf", "content": ""}]}'`}},
+	}}
+	journal := hclgen.NewJournal()
+	err := runHooks(context.Background(), hooks, dir, "Microsoft.Test/widgets", journal)
+	if err == nil || !strings.Contains(err.Error(), "returned other.tf, which it was not given") {
+		t.Fatalf("expected an error for the unknown file, got %v", err)
+	}
+}
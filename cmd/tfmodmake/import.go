@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/matt-FFFFFF/tfmodmake/bicepdata"
+	"github.com/matt-FFFFFF/tfmodmake/hclgen"
+	"github.com/matt-FFFFFF/tfmodmake/terraform"
+	"github.com/urfave/cli/v3"
+)
+
+// importTFVarsFileName holds the variable values read from an adopted resource.
+const importTFVarsFileName = "import.auto.tfvars.json"
+
+func genImportCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "import",
+		Usage: "Generate import blocks to adopt existing resources into the module in the current directory",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:     "resource-id",
+				Usage:    "ARM resource ID of the existing resource to import into azapi_resource.this",
+				Required: true,
+			},
+			&cli.StringSliceFlag{
+				Name:  "child-id",
+				Usage: "Import a child submodule instance, as <module_name>.<key>=<resource ID> (repeatable)",
+			},
+			&cli.BoolFlag{
+				Name:  "tfvars",
+				Usage: "Read the resource with the Azure CLI (az rest) and write its writable property values to " + importTFVarsFileName,
+			},
+			typesPathFlag(),
+			typesRefFlag(),
+		},
+		Action: runGenImport,
+	}
+}
+
+func runGenImport(ctx context.Context, cmd *cli.Command) error {
+	resourceID := cmd.String("resource-id")
+
+	targets := []terraform.ImportTarget{{Address: terraform.ResourceAddress, ID: resourceID}}
+	for _, spec := range cmd.StringSlice("child-id") {
+		target, err := parseChildImport(spec)
+		if err != nil {
+			return err
+		}
+		targets = append(targets, target)
+	}
+
+	journal := hclgen.NewJournal()
+	if err := terraform.GenerateImportFile(".", targets, journal); err != nil {
+		return fmt.Errorf("failed to write %s: %w", terraform.ImportFileName, err)
+	}
+	fmt.Printf("Wrote %d import block(s) to %s\n", len(targets), terraform.ImportFileName)
+
+	if !cmd.Bool("tfvars") {
+		return nil
+	}
+
+	spec := specOptions(cmd, nil)
+	defer spec.Cache.Close()
+	if err := writeImportTFVars(ctx, resourceID, spec, journal); err != nil {
+		return restoreOnCancel(ctx, ".", journal, err)
+	}
+	fmt.Printf("Wrote current property values to %s (secrets are not returned by Azure and must be set separately)\n", importTFVarsFileName)
+	return nil
+}
+
+// writeImportTFVars writes the writable property values of the resource at
+// resourceID, whose schema is read from spec, to importTFVarsFileName, recording
+// it in journal.
+func writeImportTFVars(ctx context.Context, resourceID string, spec *bicepdata.FetchOptions, journal *hclgen.Journal) error {
+	mainFile, err := terraform.ParseModuleFile(".", "main.tf")
+	if err != nil {
+		return err
+	}
+	resourceType, apiVersion, err := terraform.ExtractResourceTypeAndVersion(mainFile)
+	if err != nil {
+		return err
+	}
+	rs, err := terraform.LoadResourceSchema(ctx, resourceType, append(specLoadOptions(spec), terraform.WithAPIVersionLoad(apiVersion))...)
+	if err != nil {
+		return err
+	}
+
+	response, err := getResource(ctx, resourceID, apiVersion)
+	if err != nil {
+		return err
+	}
+	values, err := terraform.ImportVariableValues(rs, resourceType, resourceID, response)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(values, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := journal.WriteIfChanged(importTFVarsFileName, append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write %s: %w", importTFVarsFileName, err)
+	}
+	return nil
+}
+
+// parseChildImport parses <module_name>.<key>=<resource ID>.
+func parseChildImport(spec string) (terraform.ImportTarget, error) {
+	address, id, ok := strings.Cut(spec, "=")
+	moduleName, key, okAddress := strings.Cut(address, ".")
+	if !ok || !okAddress || moduleName == "" || key == "" || id == "" {
+		return terraform.ImportTarget{}, fmt.Errorf("invalid -child-id %q: expected <module_name>.<key>=<resource ID>", spec)
+	}
+	return terraform.ImportTarget{Address: terraform.SubmoduleResourceAddress(moduleName, key), ID: id}, nil
+}
+
+// getResource reads an ARM resource with the Azure CLI.
+func getResource(ctx context.Context, resourceID, apiVersion string) ([]byte, error) {
+	url := fmt.Sprintf("https://management.azure.com%s?api-version=%s", resourceID, apiVersion)
+	out, err := exec.CommandContext(ctx, "az", "rest", "--method", "get", "--url", url).Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return nil, fmt.Errorf("az rest failed: %s", strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, fmt.Errorf("running az rest: %w", err)
+	}
+	return out, nil
+}
@@ -0,0 +1,19 @@
+package main
+
+import "testing"
+
+func TestParseChildImport(t *testing.T) {
+	target, err := parseChildImport("storages.logs=/subscriptions/sub/resourceGroups/rg/providers/Microsoft.App/managedEnvironments/env/storages/logs")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if target.Address != `module.storages["logs"].azapi_resource.this` {
+		t.Fatalf("unexpected address %q", target.Address)
+	}
+
+	for _, spec := range []string{"storages=/id", "storages.logs", ".logs=/id"} {
+		if _, err := parseChildImport(spec); err == nil {
+			t.Fatalf("expected error for %q", spec)
+		}
+	}
+}
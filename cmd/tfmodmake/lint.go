@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/matt-FFFFFF/tfmodmake/terraform"
+	"github.com/urfave/cli/v3"
+)
+
+func LintCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "lint",
+		Usage: "Check existing modules against module requirements",
+		Commands: []*cli.Command{
+			{
+				Name:      "avm",
+				Usage:     "Report violations of the AVM resource module requirements that can be checked statically",
+				ArgsUsage: "[path]",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "format",
+						Usage: "Output format: text, json or sarif",
+						Value: "text",
+					},
+				},
+				Action: runLintAVM,
+			},
+		},
+	}
+}
+
+func runLintAVM(ctx context.Context, cmd *cli.Command) error {
+	targetDir := "."
+	if cmd.NArg() > 0 {
+		targetDir = cmd.Args().First()
+	}
+
+	findings, err := terraform.LintAVM(targetDir)
+	if err != nil {
+		return fmt.Errorf("failed to lint module: %w", err)
+	}
+
+	switch format := cmd.String("format"); format {
+	case "text":
+		for _, f := range findings {
+			location := f.File
+			if f.Line > 0 {
+				location = fmt.Sprintf("%s:%d", f.File, f.Line)
+			}
+			fmt.Printf("%s: %s [%s] %s\n", location, f.Level, f.Rule, f.Message)
+		}
+		if len(findings) == 0 {
+			fmt.Println("No AVM requirement violations found")
+		}
+	case "json":
+		err = terraform.WriteLintJSON(os.Stdout, findings)
+	case "sarif":
+		err = terraform.WriteLintSARIF(os.Stdout, findings)
+	default:
+		return fmt.Errorf("unknown format %q; valid formats are text, json, sarif", format)
+	}
+	if err != nil {
+		return err
+	}
+
+	violations := 0
+	for _, f := range findings {
+		if f.Level == terraform.LintError {
+			violations++
+		}
+	}
+	if violations > 0 {
+		return fmt.Errorf("%d AVM requirement violation(s)", violations)
+	}
+	return nil
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"sync"
+
+	"github.com/matt-FFFFFF/tfmodmake/bicepdata"
+	"github.com/matt-FFFFFF/tfmodmake/hclgen"
+	"github.com/matt-FFFFFF/tfmodmake/lockfile"
+	"github.com/urfave/cli/v3"
+)
+
+// noLockFlag turns off writing the lock files.
+func noLockFlag() cli.Flag {
+	return &cli.BoolFlag{
+		Name:  "no-lock",
+		Usage: "Do not write " + lockfile.FileName + " into the generated module and submodules",
+	}
+}
+
+// lockRecorder collects the resources generated into each module directory of
+// a run, and writes their lock files once generation succeeded. A nil recorder
+// records nothing.
+type lockRecorder struct {
+	command []string
+	args    []string
+
+	mu sync.Mutex
+	// locks are keyed by module directory, relative to the working directory.
+	locks map[string]*lockfile.Lock
+	// sources are keyed by types path and ref.
+	sources map[[2]string]lockfile.Source
+}
+
+// lockCommandKey is the context key of the command line recorded in the lock
+// instead of that of the run.
+type lockCommandKey struct{}
+
+type lockCommand struct {
+	command, args []string
+}
+
+// withLockCommand returns a copy of ctx in which the lock of a run records
+// command and args as its command line. Regen runs gen below itself with pinned
+// flags, but keeps the recorded command line.
+func withLockCommand(ctx context.Context, command, args []string) context.Context {
+	return context.WithValue(ctx, lockCommandKey{}, lockCommand{command: command, args: args})
+}
+
+// newLockRecorder returns a recorder for the run of cmd, or nil when -no-lock
+// is set.
+func newLockRecorder(ctx context.Context, cmd *cli.Command) *lockRecorder {
+	if cmd.Bool("no-lock") {
+		return nil
+	}
+	command, args := commandLine(cmd)
+	if recorded, ok := ctx.Value(lockCommandKey{}).(lockCommand); ok {
+		command, args = recorded.command, recorded.args
+	}
+	return &lockRecorder{
+		command: command,
+		args:    args,
+		locks:   map[string]*lockfile.Lock{},
+		sources: map[[2]string]lockfile.Source{},
+	}
+}
+
+// commandLine returns the names of cmd and its parent commands, e.g. ["gen",
+// "avm"], and the flags set on it.
+func commandLine(cmd *cli.Command) (command, args []string) {
+	for _, c := range cmd.Lineage() {
+		if c.Root() == c && c.Name == "tfmodmake" {
+			continue
+		}
+		command = append(command, c.Name)
+	}
+	slices.Reverse(command)
+
+	for _, f := range cmd.Flags {
+		name := f.Names()[0]
+		if name == "no-lock" || !cmd.IsSet(name) {
+			continue
+		}
+		switch v := cmd.Value(name).(type) {
+		case bool:
+			args = append(args, "-"+name+"="+strconv.FormatBool(v))
+		case []string:
+			for _, item := range v {
+				args = append(args, "-"+name, item)
+			}
+		default:
+			args = append(args, "-"+name, fmt.Sprint(v))
+		}
+	}
+	return command, args
+}
+
+// record adds resourceType at apiVersion, loaded from spec, to the lock of the
+// module in dir.
+func (r *lockRecorder) record(ctx context.Context, dir, resourceType, apiVersion string, spec bicepdata.FetchOptions) error {
+	if r == nil {
+		return nil
+	}
+	opts := &spec
+	idx, err := bicepdata.LoadIndex(ctx, opts)
+	if err != nil {
+		return err
+	}
+	lookupType, _ := bicepdata.ParseResourcePath(resourceType)
+	ref, err := bicepdata.LookupResource(idx, lookupType, apiVersion)
+	if err != nil {
+		return err
+	}
+	source := r.source(ctx, spec)
+	if !source.Local && source.Commit != "" {
+		opts.Ref = source.Commit
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	dir = filepath.Clean(dir)
+	lock, ok := r.locks[dir]
+	if !ok {
+		lock = &lockfile.Lock{TfmodmakeVersion: version}
+		r.locks[dir] = lock
+	}
+	lock.Resources = append(lock.Resources, lockfile.Resource{
+		ResourceType: resourceType,
+		APIVersion:   apiVersion,
+		Source:       source,
+		URL:          bicepdata.SourceURL(ref.RelativePath, opts),
+	})
+	return nil
+}
+
+// source returns the bicep-types-az revision spec reads, resolving its commit
+// once. A commit that cannot be resolved is left out with a warning.
+func (r *lockRecorder) source(ctx context.Context, spec bicepdata.FetchOptions) lockfile.Source {
+	key := [2]string{spec.LocalPath, spec.Ref}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if source, ok := r.sources[key]; ok {
+		return source
+	}
+
+	source := lockfile.Source{Repository: "https://github.com/Azure/bicep-types-az"}
+	if spec.LocalPath != "" {
+		abs, err := filepath.Abs(spec.LocalPath)
+		if err != nil {
+			abs = spec.LocalPath
+		}
+		source = lockfile.Source{Repository: abs, Local: true}
+	}
+	commit, err := bicepdata.ResolveCommit(ctx, &bicepdata.FetchOptions{LocalPath: spec.LocalPath, Ref: spec.Ref})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %s does not record the bicep-types-az commit: %v\n", lockfile.FileName, err)
+	}
+	source.Commit = commit
+	r.sources[key] = source
+	return source
+}
+
+// write writes the lock file of every module directory recorded, recording
+// them in journal. The lock of the working directory holds the command; the
+// others point back to it.
+func (r *lockRecorder) write(journal *hclgen.Journal) error {
+	if r == nil {
+		return nil
+	}
+	dirs := make([]string, 0, len(r.locks))
+	for dir := range r.locks {
+		dirs = append(dirs, dir)
+	}
+	slices.Sort(dirs)
+	for _, dir := range dirs {
+		lock := r.locks[dir]
+		if dir == "." {
+			lock.Command, lock.Args = r.command, r.args
+		} else {
+			root, err := filepath.Rel(dir, ".")
+			if err != nil {
+				return err
+			}
+			lock.Root = filepath.ToSlash(root)
+		}
+		if err := lockfile.Write(dir, lock, journal); err != nil {
+			return err
+		}
+	}
+	return nil
+}
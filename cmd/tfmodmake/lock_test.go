@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/matt-FFFFFF/tfmodmake/internal/typestest"
+	"github.com/matt-FFFFFF/tfmodmake/lockfile"
+)
+
+// chdirTemp changes into a new temporary directory for the rest of the test.
+func chdirTemp(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+	return dir
+}
+
+func TestGenWritesLockFile(t *testing.T) {
+	typesPath := typestest.Write(t, typestest.Widgets)
+	chdirTemp(t)
+
+	args := []string{"gen", "-resource", "Microsoft.Test/widgets", "-types-path", typesPath, "-telemetry"}
+	if err := GenCommand().Run(context.Background(), args); err != nil {
+		t.Fatal(err)
+	}
+
+	lock, err := lockfile.Read(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"gen"}; !reflect.DeepEqual(lock.Command, want) {
+		t.Fatalf("Command = %q, want %q", lock.Command, want)
+	}
+	wantArgs := []string{"-resource", "Microsoft.Test/widgets", "-types-path", typesPath, "-telemetry=true"}
+	if !reflect.DeepEqual(lock.Args, wantArgs) {
+		t.Fatalf("Args = %q, want %q", lock.Args, wantArgs)
+	}
+	want := []lockfile.Resource{{
+		ResourceType: "Microsoft.Test/widgets",
+		APIVersion:   "2024-01-01",
+		Source:       lockfile.Source{Repository: typesPath, Local: true},
+		URL:          filepath.Join(typesPath, "generated", "microsoft.test", "2024-01-01", "types.json"),
+	}}
+	if !reflect.DeepEqual(lock.Resources, want) {
+		t.Fatalf("Resources = %+v, want %+v", lock.Resources, want)
+	}
+}
+
+func TestGenNoLock(t *testing.T) {
+	typesPath := typestest.Write(t, typestest.Widgets)
+	chdirTemp(t)
+
+	args := []string{"gen", "-resource", "Microsoft.Test/widgets", "-types-path", typesPath, "-no-lock"}
+	if err := GenCommand().Run(context.Background(), args); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(lockfile.FileName); !os.IsNotExist(err) {
+		t.Fatalf("expected no %s, got %v", lockfile.FileName, err)
+	}
+}
+
+func TestGenAVMTypesPathFromEnvironment(t *testing.T) {
+	typesPath := typestest.Write(t, typestest.Widgets)
+	t.Setenv(typesPathEnv, typesPath)
+	chdirTemp(t)
+
+	if err := GenCommand().Run(context.Background(), []string{"gen", "avm", "-resource", "Microsoft.Test/widgets"}); err != nil {
+		t.Fatal(err)
+	}
+
+	lock, err := lockfile.Read(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"gen", "avm"}; !reflect.DeepEqual(lock.Command, want) {
+		t.Fatalf("Command = %q, want %q", lock.Command, want)
+	}
+	// The checkout is recorded, so regen reads it without the environment.
+	if wantArgs := []string{"-types-path", typesPath, "-resource", "Microsoft.Test/widgets"}; !reflect.DeepEqual(lock.Args, wantArgs) {
+		t.Fatalf("Args = %q, want %q", lock.Args, wantArgs)
+	}
+	if len(lock.Resources) != 1 || lock.Resources[0].Source != (lockfile.Source{Repository: typesPath, Local: true}) {
+		t.Fatalf("Resources = %+v, want one read from %s", lock.Resources, typesPath)
+	}
+}
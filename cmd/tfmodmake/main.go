@@ -4,6 +4,8 @@ import (
 	"context"
 	"log"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/urfave/cli/v3"
 )
@@ -11,19 +13,57 @@ import (
 var version = "dev"
 
 func main() {
+	var session profileSession
 	cmd := &cli.Command{
 		Version: version,
 		Name:    "tfmodmake",
 		Usage:   "Generate Terraform modules from Azure resource type definitions",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "profile",
+				Usage: "Print the time spent in each phase (fetch, parse, convert, variables, locals, main, outputs, write) to stderr",
+			},
+			&cli.StringFlag{
+				Name:  "profile-dir",
+				Usage: "Also write the cpu.pprof and heap.pprof profiles of the run to this directory",
+			},
+		},
+		Before: func(ctx context.Context, cmd *cli.Command) (context.Context, error) {
+			return session.start(ctx, cmd.Bool("profile"), cmd.String("profile-dir"))
+		},
+		After: func(ctx context.Context, cmd *cli.Command) error {
+			return session.stop(os.Stderr)
+		},
 		Commands: []*cli.Command{
 			GenCommand(),
+			RegenCommand(),
 			AddCommand(),
 			DiscoverCommand(),
 			UpdateCommand(),
+			LintCommand(),
+			DoctorCommand(),
+			DiffCommand(),
+			ExportCommand(),
+			SnapshotCommand(),
+			VerifyDeterministicCommand(),
 		},
 	}
 
-	if err := cmd.Run(context.Background(), os.Args); err != nil {
-		log.Fatal(err)
+	// The first interrupt cancels the run, which then stops loading and
+	// generating and restores the module; a second one exits at once.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	if err := cmd.Run(ctx, os.Args); err != nil {
+		status, hint := exitStatus(err)
+		log.Print(err)
+		if hint != "" {
+			log.Print("hint: " + hint)
+		}
+		os.Exit(status)
 	}
 }
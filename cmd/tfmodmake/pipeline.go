@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/matt-FFFFFF/tfmodmake/terraform"
+	"github.com/urfave/cli/v3"
+)
+
+func genPipelineCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "pipeline",
+		Usage: "Generate a CI workflow for the module repository in the current directory",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "azure-devops",
+				Usage: "Also generate " + terraform.AzurePipelinesPath + " for Azure DevOps",
+			},
+			&cli.BoolFlag{
+				Name:  "e2e",
+				Usage: "Add a job running the end-to-end test in tests/e2e on main and manual runs",
+			},
+		},
+		Action: runGenPipeline,
+	}
+}
+
+func runGenPipeline(ctx context.Context, cmd *cli.Command) error {
+	created, err := terraform.ScaffoldPipeline(".", terraform.PipelineOptions{
+		AzureDevOps: cmd.Bool("azure-devops"),
+		E2E:         cmd.Bool("e2e"),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to generate the CI workflow: %w", err)
+	}
+	if len(created) == 0 {
+		fmt.Println("CI workflow files already exist; nothing to do")
+	}
+	for _, path := range created {
+		fmt.Printf("Created %s\n", path)
+	}
+	return nil
+}
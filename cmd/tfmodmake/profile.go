@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"time"
+
+	"github.com/matt-FFFFFF/tfmodmake/profile"
+)
+
+// profileSession holds the state of the -profile and -profile-dir flags
+// between the start and the end of a run.
+type profileSession struct {
+	timings *profile.Timings
+	dir     string
+	cpu     *os.File
+	started time.Time
+}
+
+// start records phase timings in the returned context when profiling is
+// requested, and starts the CPU profile when dir is set.
+func (s *profileSession) start(ctx context.Context, enabled bool, dir string) (context.Context, error) {
+	if !enabled && dir == "" {
+		return ctx, nil
+	}
+	s.timings = &profile.Timings{}
+	s.started = time.Now()
+	if dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return ctx, err
+		}
+		cpu, err := os.Create(filepath.Join(dir, "cpu.pprof"))
+		if err != nil {
+			return ctx, err
+		}
+		if err := pprof.StartCPUProfile(cpu); err != nil {
+			cpu.Close()
+			return ctx, fmt.Errorf("starting CPU profile: %w", err)
+		}
+		s.dir, s.cpu = dir, cpu
+	}
+	return profile.NewContext(ctx, s.timings), nil
+}
+
+// stop writes the profiles and prints the phase timings to w.
+func (s *profileSession) stop(w io.Writer) error {
+	if s.timings == nil {
+		return nil
+	}
+	elapsed := time.Since(s.started)
+	var errs []error
+	if s.cpu != nil {
+		pprof.StopCPUProfile()
+		errs = append(errs, s.cpu.Close())
+		errs = append(errs, writeHeapProfile(filepath.Join(s.dir, "heap.pprof")))
+	}
+	errs = append(errs, s.timings.Write(w, elapsed))
+	if s.dir != "" {
+		fmt.Fprintf(w, "Profiles written to %s (inspect with go tool pprof)\n", s.dir)
+	}
+	return errors.Join(errs...)
+}
+
+func writeHeapProfile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	runtime.GC()
+	if err := pprof.WriteHeapProfile(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/matt-FFFFFF/tfmodmake/bicepdata"
+	"github.com/matt-FFFFFF/tfmodmake/lockfile"
+	"github.com/urfave/cli/v3"
+)
+
+func RegenCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "regen",
+		Usage: "Regenerate the module in the current directory from its " + lockfile.FileName,
+		Description: "Reruns the command recorded in " + lockfile.FileName + " with the same flags, pinned to the recorded " +
+			"bicep-types-az commit and API versions. With -bump, reruns it unpinned, so the latest commit and API versions " +
+			"the recorded flags allow are selected and recorded instead.",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "bump",
+				Usage: "Regenerate from the latest bicep-types-az commit and API versions, and update the lock file to them",
+			},
+		},
+		Action: runRegen,
+	}
+}
+
+func runRegen(ctx context.Context, cmd *cli.Command) error {
+	lock, err := lockfile.Read(".")
+	if err != nil {
+		return err
+	}
+	if lock.Root != "" {
+		return fmt.Errorf("this directory is a submodule; run regen in %s", lock.Root)
+	}
+	if len(lock.Command) == 0 || lock.Command[0] != "gen" {
+		return fmt.Errorf("%s records no gen command to regenerate with", lockfile.FileName)
+	}
+	if lock.TfmodmakeVersion != version {
+		fmt.Fprintf(os.Stderr, "Warning: the module was generated by tfmodmake %s, this is %s; the output may differ\n", lock.TfmodmakeVersion, version)
+	}
+
+	bump := cmd.Bool("bump")
+	var args []string
+	if bump {
+		args = removeFlag(removeFlag(lock.Args, "types-ref"), "api-version")
+	} else {
+		var pins []string
+		if slices.Equal(lock.Command, []string{"gen", "avm"}) {
+			if pins, err = childPins("."); err != nil {
+				return err
+			}
+		}
+		args = pinnedArgs(ctx, lock, pins)
+	}
+
+	command := append(slices.Clone(lock.Command), args...)
+	fmt.Printf("Regenerating with: tfmodmake %s\n", strings.Join(command, " "))
+	// The pins only reproduce what the lock already records; the lock keeps the
+	// flags the module was generated with, so a later -bump can drop them, and a
+	// regeneration that changes nothing leaves it as it is.
+	recorded := lock.Args
+	if bump {
+		recorded = args
+	}
+	return GenCommand().Run(withLockCommand(ctx, lock.Command, recorded), command)
+}
+
+// pinnedArgs returns the recorded flags of lock, pinned to its bicep-types-az
+// commit and the API version of its resource, and with the -child-api-version
+// pins given. A revision that cannot be pinned is warned about.
+func pinnedArgs(ctx context.Context, lock *lockfile.Lock, pins []string) []string {
+	args := slices.Clone(lock.Args)
+	if len(lock.Resources) == 0 {
+		return args
+	}
+	root := lock.Resources[0]
+
+	switch source := root.Source; {
+	case source.Local:
+		head, err := bicepdata.ResolveCommit(ctx, &bicepdata.FetchOptions{LocalPath: source.Repository})
+		switch {
+		case err != nil || source.Commit == "":
+			fmt.Fprintf(os.Stderr, "Warning: cannot check the commit of %s; regenerating from it as it is\n", source.Repository)
+		case head != source.Commit:
+			fmt.Fprintf(os.Stderr, "Warning: %s is at %s, the module was generated at %s\n", source.Repository, head, source.Commit)
+		}
+	case source.Commit == "":
+		fmt.Fprintf(os.Stderr, "Warning: %s records no bicep-types-az commit; regenerating from the latest\n", lockfile.FileName)
+	default:
+		args = append(removeFlag(args, "types-ref"), "-types-ref", source.Commit)
+	}
+
+	if !hasFlag(args, "api-version") && !hasFlag(args, "api-versions") {
+		args = append(args, "-api-version", root.APIVersion)
+	}
+
+	pinned := map[string]bool{strings.ToLower(root.ResourceType): true}
+	for _, pin := range flagValues(args, "child-api-version") {
+		if at := strings.LastIndex(pin, "@"); at > 0 {
+			pinned[strings.ToLower(pin[:at])] = true
+		}
+	}
+	for _, resource := range lock.Resources[1:] {
+		pins = append(pins, resource.ResourceType+"@"+resource.APIVersion)
+	}
+	for _, pin := range pins {
+		resourceType := strings.ToLower(pin[:strings.LastIndex(pin, "@")])
+		if pinned[resourceType] {
+			continue
+		}
+		pinned[resourceType] = true
+		args = append(args, "-child-api-version", pin)
+	}
+	return args
+}
+
+// childPins returns the resource types of the submodules below dir generated
+// by its lock, as <resource type>@<api version>, in directory order.
+func childPins(dir string) ([]string, error) {
+	var pins []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != dir && (strings.HasPrefix(d.Name(), ".") || d.Name() == "examples") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		moduleDir := filepath.Dir(path)
+		if d.Name() != lockfile.FileName || moduleDir == filepath.Clean(dir) {
+			return nil
+		}
+		lock, err := lockfile.Read(moduleDir)
+		if err != nil {
+			return err
+		}
+		if lock.Root == "" || filepath.Join(moduleDir, filepath.FromSlash(lock.Root)) != filepath.Clean(dir) {
+			return nil
+		}
+		for _, resource := range lock.Resources {
+			pins = append(pins, resource.ResourceType+"@"+resource.APIVersion)
+		}
+		return nil
+	})
+	return pins, err
+}
+
+// isFlag reports whether arg is the flag called name, given as -name, --name or
+// with an =value.
+func isFlag(arg, name string) bool {
+	if !strings.HasPrefix(arg, "-") {
+		return false
+	}
+	arg = strings.TrimPrefix(strings.TrimPrefix(arg, "-"), "-")
+	return arg == name || strings.HasPrefix(arg, name+"=")
+}
+
+// hasFlag reports whether args set the flag called name.
+func hasFlag(args []string, name string) bool {
+	return slices.ContainsFunc(args, func(arg string) bool { return isFlag(arg, name) })
+}
+
+// flagValues returns the values args give the flag called name, as -name value
+// or -name=value.
+func flagValues(args []string, name string) []string {
+	var values []string
+	for i := 0; i < len(args); i++ {
+		if !isFlag(args[i], name) {
+			continue
+		}
+		if _, value, ok := strings.Cut(args[i], "="); ok {
+			values = append(values, value)
+		} else if i+1 < len(args) {
+			i++
+			values = append(values, args[i])
+		}
+	}
+	return values
+}
+
+// removeFlag returns args without the flag called name and, unless given with
+// an =value, the value following it.
+func removeFlag(args []string, name string) []string {
+	var kept []string
+	for i := 0; i < len(args); i++ {
+		if !isFlag(args[i], name) {
+			kept = append(kept, args[i])
+			continue
+		}
+		if !strings.Contains(args[i], "=") {
+			i++
+		}
+	}
+	return kept
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/matt-FFFFFF/tfmodmake/internal/typestest"
+	"github.com/matt-FFFFFF/tfmodmake/lockfile"
+)
+
+func TestRegen(t *testing.T) {
+	typesPath := typestest.Write(t, typestest.Widgets)
+	chdirTemp(t)
+
+	args := []string{"gen", "-resource", "Microsoft.Test/widgets", "-types-path", typesPath}
+	if err := GenCommand().Run(context.Background(), args); err != nil {
+		t.Fatal(err)
+	}
+	want, err := os.ReadFile("main.tf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove("main.tf"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := RegenCommand().Run(context.Background(), []string{"regen"}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile("main.tf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) {
+		t.Fatalf("main.tf = %s, want %s", got, want)
+	}
+	lock, err := lockfile.Read(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wantArgs := args[1:]; !reflect.DeepEqual(lock.Args, wantArgs) {
+		t.Fatalf("Args = %q, want %q", lock.Args, wantArgs)
+	}
+}
+
+func TestRegenUnchanged(t *testing.T) {
+	typesPath := typestest.Write(t, typestest.Widgets)
+	chdirTemp(t)
+
+	if err := GenCommand().Run(context.Background(), []string{"gen", "-resource", "Microsoft.Test/widgets", "-types-path", typesPath}); err != nil {
+		t.Fatal(err)
+	}
+	want, err := os.ReadFile(lockfile.FileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-time.Hour).Truncate(time.Second)
+	if err := os.Chtimes(lockfile.FileName, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := RegenCommand().Run(context.Background(), []string{"regen"}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(lockfile.FileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) {
+		t.Fatalf("%s = %s, want %s", lockfile.FileName, got, want)
+	}
+	info, err := os.Stat(lockfile.FileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.ModTime().Equal(old) {
+		t.Fatalf("%s was rewritten by a regeneration that changed nothing", lockfile.FileName)
+	}
+}
+
+func TestRegenBump(t *testing.T) {
+	typesPath := typestest.Write(t, typestest.Widgets)
+	chdirTemp(t)
+
+	args := []string{"gen", "-resource", "Microsoft.Test/widgets", "-api-version", "2024-01-01", "-types-path", typesPath}
+	if err := GenCommand().Run(context.Background(), args); err != nil {
+		t.Fatal(err)
+	}
+	if err := RegenCommand().Run(context.Background(), []string{"regen", "-bump"}); err != nil {
+		t.Fatal(err)
+	}
+	lock, err := lockfile.Read(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"-resource", "Microsoft.Test/widgets", "-types-path", typesPath}; !reflect.DeepEqual(lock.Args, want) {
+		t.Fatalf("Args = %q, want %q", lock.Args, want)
+	}
+}
+
+func TestRegenRejectsSubmodule(t *testing.T) {
+	chdirTemp(t)
+	if err := lockfile.Write(".", &lockfile.Lock{Root: "../.."}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := RegenCommand().Run(context.Background(), []string{"regen"}); err == nil {
+		t.Fatal("expected an error regenerating a submodule")
+	}
+}
+
+func TestPinnedArgs(t *testing.T) {
+	published := lockfile.Source{Repository: "https://github.com/Azure/bicep-types-az", Commit: "abc123"}
+	lock := &lockfile.Lock{
+		Args: []string{"-resource", "Microsoft.Test/widgets", "-types-ref", "main", "-child-api-version", "Microsoft.Test/widgets/gadgets@2023-01-01"},
+		Resources: []lockfile.Resource{
+			{ResourceType: "Microsoft.Test/widgets", APIVersion: "2024-01-01", Source: published},
+			{ResourceType: "Microsoft.Test/widgets/parts", APIVersion: "2024-02-01", Source: published},
+		},
+	}
+	pins := []string{"Microsoft.Test/widgets/gadgets@2024-01-01", "Microsoft.Test/widgets/sprockets@2024-03-01"}
+
+	got := pinnedArgs(context.Background(), lock, pins)
+	want := []string{
+		"-resource", "Microsoft.Test/widgets",
+		"-child-api-version", "Microsoft.Test/widgets/gadgets@2023-01-01",
+		"-types-ref", "abc123",
+		"-api-version", "2024-01-01",
+		"-child-api-version", "Microsoft.Test/widgets/sprockets@2024-03-01",
+		"-child-api-version", "Microsoft.Test/widgets/parts@2024-02-01",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("pinnedArgs = %q, want %q", got, want)
+	}
+}
+
+func TestPinnedArgsWithEquals(t *testing.T) {
+	published := lockfile.Source{Repository: "https://github.com/Azure/bicep-types-az", Commit: "abc123"}
+	lock := &lockfile.Lock{
+		Args: []string{"-resource=Microsoft.Test/widgets", "--types-ref=main", "-api-version=2023-01-01", "-child-api-version=Microsoft.Test/widgets/parts@2023-01-01"},
+		Resources: []lockfile.Resource{
+			{ResourceType: "Microsoft.Test/widgets", APIVersion: "2024-01-01", Source: published},
+			{ResourceType: "Microsoft.Test/widgets/parts", APIVersion: "2024-02-01", Source: published},
+		},
+	}
+
+	got := pinnedArgs(context.Background(), lock, nil)
+	want := []string{
+		"-resource=Microsoft.Test/widgets",
+		"-api-version=2023-01-01",
+		"-child-api-version=Microsoft.Test/widgets/parts@2023-01-01",
+		"-types-ref", "abc123",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("pinnedArgs = %q, want %q", got, want)
+	}
+}
+
+func TestRemoveFlag(t *testing.T) {
+	args := []string{"-resource", "x", "--api-version", "2024-01-01", "-telemetry=true", "-api-versions", "a"}
+	got := removeFlag(removeFlag(args, "api-version"), "telemetry")
+	if want := []string{"-resource", "x", "-api-versions", "a"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("removeFlag = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/matt-FFFFFF/tfmodmake/snapshot"
+	"github.com/urfave/cli/v3"
+)
+
+func SnapshotCommand() *cli.Command {
+	dirFlag := &cli.StringFlag{
+		Name:  "dir",
+		Value: "snapshots",
+		Usage: "Snapshot directory holding " + snapshot.ManifestFileName + " and the recorded output of each case",
+	}
+	return &cli.Command{
+		Name:  "snapshot",
+		Usage: "Record generated modules and check that regeneration reproduces them",
+		Commands: []*cli.Command{
+			{
+				Name:      "record",
+				Usage:     "Generate the snapshot cases and record their output, replacing earlier recordings",
+				ArgsUsage: "[case...]",
+				Flags:     []cli.Flag{dirFlag},
+				Action:    runSnapshotRecord,
+			},
+			{
+				Name:      "verify",
+				Usage:     "Regenerate the snapshot cases and print a diff of every file that differs from the recording",
+				ArgsUsage: "[case...]",
+				Flags:     []cli.Flag{dirFlag},
+				Action:    runSnapshotVerify,
+			},
+		},
+	}
+}
+
+func runSnapshotRecord(ctx context.Context, cmd *cli.Command) error {
+	dir := cmd.String("dir")
+	cases, err := snapshotCases(dir, cmd.Args().Slice())
+	if err != nil {
+		return err
+	}
+	for _, c := range cases {
+		n, err := snapshot.Record(ctx, dir, c, generateSnapshotCase)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Recorded %s (%d file(s))\n", c.Name, n)
+	}
+	return nil
+}
+
+func runSnapshotVerify(ctx context.Context, cmd *cli.Command) error {
+	dir := cmd.String("dir")
+	cases, err := snapshotCases(dir, cmd.Args().Slice())
+	if err != nil {
+		return err
+	}
+	differing := 0
+	for _, c := range cases {
+		mismatches, err := snapshot.Verify(ctx, dir, c, generateSnapshotCase)
+		if err != nil {
+			return err
+		}
+		for _, m := range mismatches {
+			fmt.Print(m.Diff)
+		}
+		if len(mismatches) == 0 {
+			fmt.Printf("%s: matches the recording\n", c.Name)
+		}
+		differing += len(mismatches)
+	}
+	if differing > 0 {
+		return fmt.Errorf("%d file(s) differ from the recorded snapshots; run snapshot record to accept the changes", differing)
+	}
+	return nil
+}
+
+// snapshotCases loads the manifest of dir and selects the named cases.
+func snapshotCases(dir string, names []string) ([]snapshot.Case, error) {
+	m, err := snapshot.LoadManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+	return m.Select(names)
+}
+
+// generateSnapshotCase runs gen, or gen avm, for the case with outputDir as the
+// working directory, since both generate into the current directory.
+func generateSnapshotCase(ctx context.Context, c snapshot.Case, outputDir string) error {
+	args := []string{"gen"}
+	if c.AVM {
+		args = append(args, "avm")
+	}
+	// The lock records the tfmodmake version and spec commit, which change
+	// between runs without the module changing.
+	args = append(args, "-resource", c.Resource, "-no-lock")
+	if c.APIVersion != "" {
+		args = append(args, "-api-version", c.APIVersion)
+	}
+	if c.TypesPath != "" {
+		args = append(args, "-types-path", c.TypesPath)
+	}
+	if c.Config != "" {
+		args = append(args, "-config", c.Config)
+	}
+	args = append(args, c.Flags...)
+
+	wd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	if err := os.Chdir(outputDir); err != nil {
+		return err
+	}
+	defer os.Chdir(wd)
+	return GenCommand().Run(ctx, args)
+}
@@ -2,10 +2,14 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
+	"slices"
 	"sort"
 	"strings"
 
+	"github.com/matt-FFFFFF/tfmodmake/schema"
 	"github.com/matt-FFFFFF/tfmodmake/terraform"
 	"github.com/urfave/cli/v3"
 )
@@ -33,6 +37,16 @@ func UpdateCommand() *cli.Command {
 				Name:  "dry-run",
 				Usage: "Print planned changes without modifying files",
 			},
+			typesPathFlag(),
+			typesRefFlag(),
+			&cli.BoolFlag{
+				Name:  "allow-breaking",
+				Usage: "Update even when the new API version removes a variable or has breaking changes",
+			},
+			&cli.BoolFlag{
+				Name:  "json",
+				Usage: "Output the changes as JSON",
+			},
 		},
 		Action: runUpdate,
 	}
@@ -65,14 +79,61 @@ func runUpdate(ctx context.Context, cmd *cli.Command) error {
 		ResourceType:   resourceType,
 		IncludePreview: includePreview,
 		DryRun:         dryRun,
+		RejectBreaking: !cmd.Bool("allow-breaking"),
+		TypesPath:      cmd.String("types-path"),
+		TypesRef:       cmd.String("types-ref"),
 	})
-	if err != nil {
+	if err != nil && !errors.Is(err, terraform.ErrBreakingChanges) {
 		return err
 	}
 
-	// Print summary
-	printUpdateSummary(result, dryRun)
-	return nil
+	// A rejected update still reports the changes that stopped it.
+	switch {
+	case cmd.Bool("json"):
+		if jsonErr := printJSON(os.Stdout, newUpdateReport(resourceType, result, dryRun || err != nil)); jsonErr != nil {
+			return jsonErr
+		}
+	case err != nil:
+		fmt.Printf("API version: %s -> %s (not applied)\n\n", result.OldVersion, result.NewVersion)
+		printSortedItems("  removed variable", result.Variables.Removed)
+		printSpecChanges(result.Changes)
+	default:
+		printUpdateSummary(result, dryRun)
+		printSpecChanges(result.Changes)
+	}
+	return err
+}
+
+// updateReport is the JSON output of the update command.
+type updateReport struct {
+	ResourceType     string                 `json:"resource_type"`
+	OldVersion       string                 `json:"old_version"`
+	NewVersion       string                 `json:"new_version"`
+	Applied          bool                   `json:"applied"`
+	Breaking         bool                   `json:"breaking"`
+	RemovedVariables []string               `json:"removed_variables"`
+	Changes          []schema.VersionChange `json:"changes"`
+}
+
+// newUpdateReport reports result; notApplied is set when the files were left
+// unchanged.
+func newUpdateReport(resourceType string, result *terraform.UpdateResult, notApplied bool) updateReport {
+	report := updateReport{
+		ResourceType:     resourceType,
+		OldVersion:       result.OldVersion,
+		NewVersion:       result.NewVersion,
+		Applied:          !notApplied,
+		Breaking:         result.HasBreakingChanges(),
+		RemovedVariables: slices.Sorted(slices.Values(result.Variables.Removed)),
+		Changes:          result.Changes,
+	}
+	if report.RemovedVariables == nil {
+		report.RemovedVariables = []string{}
+	}
+	if report.Changes == nil {
+		report.Changes = []schema.VersionChange{}
+	}
+	return report
 }
 
 // extractOldVersionFromMainTf reads main.tf and extracts the old API version.
@@ -107,6 +168,27 @@ func printUpdateSummary(result *terraform.UpdateResult, dryRun bool) {
 	}
 }
 
+// printSpecChanges lists the changes to the writable properties between the
+// API versions, breaking ones first.
+func printSpecChanges(changes []schema.VersionChange) {
+	if len(changes) == 0 {
+		return
+	}
+	fmt.Println("Spec changes:")
+	for _, breaking := range []bool{true, false} {
+		label := "additive"
+		if breaking {
+			label = "breaking"
+		}
+		for _, c := range changes {
+			if c.Breaking == breaking {
+				fmt.Printf("  %s: %s %s%s\n", label, c.Kind, c.Path, describeVersionChange(c))
+			}
+		}
+	}
+	fmt.Println()
+}
+
 func printItemSummary(header string, summary terraform.UpdateSummary) {
 	hasChanges := len(summary.AutoUpdated) > 0 || len(summary.Added) > 0 ||
 		len(summary.Removed) > 0 || len(summary.NeedsReview) > 0
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/matt-FFFFFF/tfmodmake/terraform"
+)
+
+func TestNewUpdateReport(t *testing.T) {
+	result := &terraform.UpdateResult{
+		OldVersion: "2024-01-01",
+		NewVersion: "2025-01-01",
+		Variables:  terraform.UpdateSummary{Removed: []string{"zone", "legacy"}},
+	}
+	report := newUpdateReport("Microsoft.Test/widgets", result, true)
+	if report.Applied || !report.Breaking {
+		t.Fatalf("Applied = %v, Breaking = %v, want false, true", report.Applied, report.Breaking)
+	}
+	if want := []string{"legacy", "zone"}; !reflect.DeepEqual(report.RemovedVariables, want) {
+		t.Fatalf("RemovedVariables = %q, want %q", report.RemovedVariables, want)
+	}
+	if report.Changes == nil {
+		t.Fatal("Changes is nil, want an empty list")
+	}
+}
@@ -1,13 +1,280 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 	"strings"
 
+	"github.com/matt-FFFFFF/tfmodmake/bicepdata"
+	"github.com/matt-FFFFFF/tfmodmake/config"
 	"github.com/matt-FFFFFF/tfmodmake/naming"
+	"github.com/matt-FFFFFF/tfmodmake/schema"
+	"github.com/matt-FFFFFF/tfmodmake/terraform"
+	"github.com/urfave/cli/v3"
 )
 
+// configFlag is the shared -config flag for commands that honour tfmodmake.json.
+func configFlag() cli.Flag {
+	return &cli.StringFlag{
+		Name:  "config",
+		Usage: "Path to a tfmodmake.json config file (default: ./tfmodmake.json if present)",
+	}
+}
+
+// loadConfig resolves the config from the -config flag or the current directory.
+func loadConfig(cmd *cli.Command) (*config.Config, error) {
+	return config.Resolve(cmd.String("config"), ".")
+}
+
+// configGeneratorOptions maps config settings onto generator options.
+func configGeneratorOptions(cfg *config.Config) []terraform.GeneratorOption {
+	if cfg == nil {
+		return nil
+	}
+	preconditions := make([]terraform.PreconditionRule, 0, len(cfg.Preconditions))
+	for _, p := range cfg.Preconditions {
+		preconditions = append(preconditions, terraform.PreconditionRule{When: p.When, Equals: p.Equals, Require: p.Require})
+	}
+	exclusive := make([]terraform.ExclusiveRule, 0, len(cfg.MutuallyExclusive))
+	for _, paths := range cfg.MutuallyExclusive {
+		exclusive = append(exclusive, terraform.ExclusiveRule{Paths: paths})
+	}
+	opts := []terraform.GeneratorOption{
+		terraform.WithIgnoreChanges(cfg.IgnoreChanges...),
+		terraform.WithPreconditions(preconditions...),
+		terraform.WithExclusiveRules(exclusive...),
+		terraform.WithPostCreateProperties(cfg.PostCreateProperties...),
+		terraform.WithObjectOutputs(cfg.ObjectOutputs...),
+	}
+	if cfg.EndpointOutputSuffixes != nil {
+		opts = append(opts, terraform.WithEndpointOutputSuffixes(cfg.EndpointOutputSuffixes...))
+	}
+	return append(opts, childGeneratorOptions(cfg)...)
+}
+
+// childGeneratorOptions maps the config settings that apply to child submodules
+// as well as the base module; body paths only make sense for the base resource,
+// except for the blocklist, whose paths are common to every resource.
+func childGeneratorOptions(cfg *config.Config) []terraform.GeneratorOption {
+	if cfg == nil {
+		return nil
+	}
+	var opts []terraform.GeneratorOption
+	if cfg.PreconditionValidations {
+		opts = append(opts, terraform.WithPreconditionValidations(true))
+	}
+	if cfg.PropertyBlocklist != nil {
+		opts = append(opts, terraform.WithPropertyBlocklist(cfg.PropertyBlocklist...))
+	}
+	if cfg.OutputNaming != nil {
+		opts = append(opts, terraform.WithOutputNaming(terraform.OutputNaming{
+			Prefix:            cfg.OutputNaming.Prefix,
+			IncludeProperties: cfg.OutputNaming.IncludeProperties,
+			SegmentNames:      cfg.OutputNaming.SegmentNames,
+		}))
+	}
+	if cfg.Versions.Terraform != "" || len(cfg.Versions.RequiredProviders) > 0 {
+		versions := terraform.VersionConstraints{Terraform: cfg.Versions.Terraform}
+		if len(cfg.Versions.RequiredProviders) > 0 {
+			versions.Providers = map[string]terraform.ProviderRequirement{}
+			for name, p := range cfg.Versions.RequiredProviders {
+				versions.Providers[name] = terraform.ProviderRequirement{Source: p.Source, Version: p.Version}
+			}
+		}
+		opts = append(opts, terraform.WithVersionConstraints(versions))
+	}
+	if cfg.TemplatesDir != "" {
+		// Submodules are generated in their own directories, so the path is
+		// resolved against the root module here.
+		dir, err := filepath.Abs(cfg.TemplatesDir)
+		if err != nil {
+			dir = cfg.TemplatesDir
+		}
+		opts = append(opts, terraform.WithTemplatesDir(dir))
+	}
+	return opts
+}
+
+// applyChildAPIVersionFlags pins the API versions given as <type>@<version> on
+// the command line in cfg, taking precedence over the config file.
+func applyChildAPIVersionFlags(cfg *config.Config, entries []string) error {
+	for _, entry := range entries {
+		at := strings.LastIndex(entry, "@")
+		if at <= 0 || at == len(entry)-1 {
+			return fmt.Errorf("invalid -child-api-version %q: expected <resource type>@<api version>", entry)
+		}
+		updateChildOverride(cfg, entry[:at], func(o *config.ChildOverride) { o.APIVersion = entry[at+1:] })
+	}
+	return nil
+}
+
+// typesPathEnv names the environment variable -types-path defaults to, so
+// bulk and air-gapped runs can read a local checkout without passing it to
+// every command.
+const typesPathEnv = "TFMODMAKE_TYPES_PATH"
+
+// typesPathFlag reads the types from a local bicep-types-az checkout.
+func typesPathFlag() cli.Flag {
+	return &cli.StringFlag{
+		Name:    "types-path",
+		Usage:   "Optional: load the resource from a local bicep-types-az checkout instead of the published types",
+		Sources: cli.EnvVars(typesPathEnv),
+	}
+}
+
+// specOptions returns where cmd reads the types from: the checkout of
+// -types-path, or the published types at -types-ref. Without either flag, the
+// types_path or types_ref of cfg, which may be nil, is used. The caller closes
+// the cache of the returned options.
+func specOptions(cmd *cli.Command, cfg *config.Config) *bicepdata.FetchOptions {
+	spec := &bicepdata.FetchOptions{LocalPath: cmd.String("types-path"), Ref: cmd.String("types-ref"), Cache: bicepdata.NewCache()}
+	if spec.LocalPath == "" && spec.Ref == "" && cfg != nil {
+		spec.LocalPath, spec.Ref = cfg.TypesPath, cfg.TypesRef
+	}
+	return spec
+}
+
+// typesRefFlag pins the revision of the published types.
+func typesRefFlag() cli.Flag {
+	return &cli.StringFlag{
+		Name:  "types-ref",
+		Usage: "Read the published types at this bicep-types-az branch, tag or commit instead of main",
+	}
+}
+
+// preferStableFlag keeps -include-preview from selecting a preview newer than
+// the latest stable version.
+func preferStableFlag() cli.Flag {
+	return &cli.BoolFlag{
+		Name:  "prefer-stable",
+		Usage: "With -include-preview, select the latest stable API version when one exists, and a preview only otherwise",
+	}
+}
+
+// minAPIVersionFlag sets the oldest API version that may be selected.
+func minAPIVersionFlag() cli.Flag {
+	return &cli.StringFlag{
+		Name:  "min-api-version",
+		Usage: "Never use an API version older than this, e.g. 2024-01-01; also checked against -api-version",
+	}
+}
+
+// versionPolicy is the API version selection policy of the command's flags.
+func versionPolicy(cmd *cli.Command) bicepdata.VersionPolicy {
+	return bicepdata.VersionPolicy{
+		IncludePreview: cmd.Bool("include-preview"),
+		PreferStable:   cmd.Bool("prefer-stable"),
+		MinVersion:     cmd.String("min-api-version"),
+	}
+}
+
+// selectAPIVersion returns the API version to generate resourceType at: the
+// requested one, checked against the policy, or the one the policy selects. It
+// prints the version and why it was chosen.
+func selectAPIVersion(ctx context.Context, resourceType, apiVersion string, policy bicepdata.VersionPolicy, opts *bicepdata.FetchOptions) (string, error) {
+	if err := policy.Validate(); err != nil {
+		return "", err
+	}
+	if apiVersion != "" {
+		if err := policy.Check(apiVersion); err != nil {
+			return "", err
+		}
+		fmt.Printf("Using API version %s of %s: requested with -api-version\n", apiVersion, resourceType)
+		return apiVersion, nil
+	}
+	idx, err := bicepdata.LoadIndex(ctx, opts)
+	if err != nil {
+		return "", err
+	}
+	choice, err := bicepdata.ResolveVersion(idx, resourceType, policy)
+	if err != nil {
+		return "", err
+	}
+	fmt.Printf("Using API version %s of %s: %s\n", choice.Version, resourceType, choice.Reason)
+	return choice.Version, nil
+}
+
+// terraformVersionFlag overrides the required_version of the config.
+func terraformVersionFlag() cli.Flag {
+	return &cli.StringFlag{
+		Name:  "terraform-version",
+		Usage: "required_version constraint of terraform.tf (default: " + terraform.DefaultTerraformVersion + ")",
+	}
+}
+
+// providerVersionFlag overrides the provider versions of the config.
+func providerVersionFlag() cli.Flag {
+	return &cli.StringSliceFlag{
+		Name:  "provider-version",
+		Usage: "Version constraint of a required provider as <name>=<constraint>, e.g. 'azapi=>= 2.5, < 3.0' (repeatable); other providers are added",
+	}
+}
+
+// applyVersionFlags sets the version constraints given on the command line in
+// cfg, taking precedence over the config file.
+func applyVersionFlags(cfg *config.Config, terraformVersion string, providerVersions []string) error {
+	if terraformVersion != "" {
+		cfg.Versions.Terraform = terraformVersion
+	}
+	for _, entry := range providerVersions {
+		name, constraint, ok := strings.Cut(entry, "=")
+		name, constraint = strings.TrimSpace(name), strings.TrimSpace(constraint)
+		if !ok || name == "" || constraint == "" {
+			return fmt.Errorf("invalid -provider-version %q: expected <provider>=<constraint>", entry)
+		}
+		if cfg.Versions.RequiredProviders == nil {
+			cfg.Versions.RequiredProviders = map[string]config.ProviderVersion{}
+		}
+		p := cfg.Versions.RequiredProviders[name]
+		p.Version = constraint
+		cfg.Versions.RequiredProviders[name] = p
+	}
+	return nil
+}
+
+// applyInlineChildFlags marks the resource types given on the command line to be
+// generated inline in cfg.
+func applyInlineChildFlags(cfg *config.Config, resourceTypes []string) {
+	for _, resourceType := range resourceTypes {
+		updateChildOverride(cfg, resourceType, func(o *config.ChildOverride) { o.Inline = true })
+	}
+}
+
+// updateChildOverride applies update to the override of resourceType in cfg,
+// replacing an entry whose key only differs in case.
+func updateChildOverride(cfg *config.Config, resourceType string, update func(*config.ChildOverride)) {
+	override, _ := cfg.ChildOverride(resourceType)
+	for key := range cfg.Children {
+		if strings.EqualFold(key, resourceType) {
+			delete(cfg.Children, key)
+		}
+	}
+	update(&override)
+	if cfg.Children == nil {
+		cfg.Children = map[string]config.ChildOverride{}
+	}
+	cfg.Children[resourceType] = override
+}
+
+// pinChildVersions sets the API version of the children whose version is pinned
+// in cfg, or which may use preview versions, ahead of the default selection.
+func pinChildVersions(children []schema.ChildResource, cfg *config.Config) {
+	for i, child := range children {
+		override, ok := cfg.ChildOverride(child.ResourceType)
+		switch {
+		case !ok:
+		case override.APIVersion != "":
+			children[i].APIVersion = override.APIVersion
+		case override.IncludePreview:
+			children[i].APIVersion = bicepdata.PreferredVersion(child.APIVersions, true)
+		}
+	}
+}
+
 // deriveModuleName derives a module folder name from a child resource type.
 // Example: "Microsoft.App/managedEnvironments/storages" -> "storages"
 func deriveModuleName(childType string) string {
@@ -57,3 +324,15 @@ func inferResourceTypeFromMainTf() (string, error) {
 
 	return "", fmt.Errorf("could not find resource type in main.tf")
 }
+
+// printJSON writes v to w as indented JSON for automation. HTML is not escaped,
+// so conditions and version constraints keep their operators, e.g. >=.
+func printJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return fmt.Errorf("failed to format as JSON: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/matt-FFFFFF/tfmodmake/bicepdata"
+	"github.com/matt-FFFFFF/tfmodmake/hclgen"
+	"github.com/matt-FFFFFF/tfmodmake/terraform"
+	"github.com/urfave/cli/v3"
+)
+
+func VerifyDeterministicCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "verify-deterministic",
+		Usage: "Generate a module several times in memory and fail on any difference between the runs",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:     "resource",
+				Usage:    "Resource type to generate (e.g., Microsoft.App/containerApps)",
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:  "api-version",
+				Usage: "Specific API version to use",
+			},
+			&cli.BoolFlag{
+				Name:  "include-preview",
+				Usage: "Include latest preview API version",
+			},
+			typesPathFlag(),
+			&cli.IntFlag{
+				Name:  "runs",
+				Usage: "Number of generations to compare (at least 2)",
+				Value: 2,
+			},
+			configFlag(),
+		},
+		Action: runVerifyDeterministic,
+	}
+}
+
+func runVerifyDeterministic(ctx context.Context, cmd *cli.Command) error {
+	resourceType := cmd.String("resource")
+	runs := cmd.Int("runs")
+	if runs < 2 {
+		return fmt.Errorf("-runs must be at least 2, got %d", runs)
+	}
+
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return err
+	}
+	// The optional outputs and the module interface are turned on so that they
+	// are checked too; they iterate over the same schema as the rest.
+	opts := append(configGeneratorOptions(cfg),
+		terraform.WithTelemetry(true),
+		terraform.WithResourceOutput(true),
+		terraform.WithModuleInterface(true),
+	)
+
+	// The types files are fetched once; the schema is converted on every run,
+	// since conversion is where nondeterminism is most likely.
+	cache := bicepdata.NewCache()
+	defer cache.Close()
+	loadOpts := append(childLoadOptions(cmd.String("api-version"), cmd.Bool("include-preview"), cmd.String("types-path")), terraform.WithLoadCache(cache))
+
+	var first hclgen.MemoryWriter
+	var differences []string
+	for run := 1; run <= runs; run++ {
+		result, err := terraform.LoadResource(ctx, resourceType, loadOpts...)
+		if err != nil {
+			return fmt.Errorf("failed to load resource: %w", err)
+		}
+		files := hclgen.MemoryWriter{}
+		genOpts := append([]terraform.GeneratorOption{result, terraform.WithWriter(files)}, opts...)
+		if err := terraform.Generate(resourceType, genOpts...); err != nil {
+			return fmt.Errorf("run %d: %w", run, err)
+		}
+		if first == nil {
+			first = files
+			continue
+		}
+		for _, d := range diffRuns(first, files) {
+			differences = append(differences, fmt.Sprintf("run %d: %s", run, d))
+		}
+	}
+
+	if len(differences) > 0 {
+		for _, d := range differences {
+			fmt.Println(d)
+		}
+		return fmt.Errorf("generation of %s is not deterministic: %d difference(s) across %d runs", resourceType, len(differences), runs)
+	}
+	fmt.Printf("Generated %d file(s) identically in %d runs\n", len(first), runs)
+	return nil
+}
+
+// diffRuns describes how the files of a run differ from those of the first
+// run: missing and extra files, and the first differing line of each file.
+func diffRuns(first, other map[string][]byte) []string {
+	names := map[string]bool{}
+	for name := range first {
+		names[name] = true
+	}
+	for name := range other {
+		names[name] = true
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	var differences []string
+	for _, name := range sorted {
+		want, inFirst := first[name]
+		got, inOther := other[name]
+		switch {
+		case !inOther:
+			differences = append(differences, name+" is missing")
+		case !inFirst:
+			differences = append(differences, name+" was not generated by run 1")
+		case !bytes.Equal(want, got):
+			differences = append(differences, fmt.Sprintf("%s differs at line %d", name, firstDifferingLine(want, got)))
+		}
+	}
+	return differences
+}
+
+// firstDifferingLine returns the 1-based number of the first line of a and b
+// that differs.
+func firstDifferingLine(a, b []byte) int {
+	aLines := strings.Split(string(a), "\n")
+	bLines := strings.Split(string(b), "\n")
+	for i := range aLines {
+		if i >= len(bLines) || aLines[i] != bLines[i] {
+			return i + 1
+		}
+	}
+	return len(aLines) + 1
+}
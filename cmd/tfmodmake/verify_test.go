@@ -0,0 +1,38 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffRuns(t *testing.T) {
+	first := map[string][]byte{
+		"main.tf":      []byte("a\nb\nc\n"),
+		"outputs.tf":   []byte("same\n"),
+		"variables.tf": []byte("v\n"),
+	}
+	other := map[string][]byte{
+		"main.tf":    []byte("a\nc\nb\n"),
+		"outputs.tf": []byte("same\n"),
+		"locals.tf":  []byte("l\n"),
+	}
+
+	got := diffRuns(first, other)
+	want := []string{
+		"locals.tf was not generated by run 1",
+		"main.tf differs at line 2",
+		"variables.tf is missing",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("diffRuns = %q, want %q", got, want)
+	}
+	if got := diffRuns(first, first); len(got) != 0 {
+		t.Fatalf("expected identical runs not to differ, got %q", got)
+	}
+}
+
+func TestFirstDifferingLine(t *testing.T) {
+	if got := firstDifferingLine([]byte("a\nb"), []byte("a\nb\nc")); got != 3 {
+		t.Fatalf("expected the extra line to differ, got %d", got)
+	}
+}
@@ -0,0 +1,309 @@
+// Package config loads the optional tfmodmake.json file that carries generation
+// settings which must survive regeneration of a module.
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// FileName is the name of the configuration file looked up in a module directory.
+const FileName = "tfmodmake.json"
+
+// Config holds user-declared generation settings.
+type Config struct {
+	// IgnoreChanges lists body paths (e.g. "properties.agentPoolProfiles") that are
+	// rendered into lifecycle ignore_changes on the azapi_resource, in addition to the
+	// built-in defaults for fields the service is known to rewrite.
+	IgnoreChanges []string `json:"ignore_changes,omitempty"`
+
+	// Preconditions declares cross-property rules rendered as lifecycle preconditions
+	// on the azapi_resource, in addition to those inferred from the schema.
+	Preconditions []Precondition `json:"preconditions,omitempty"`
+
+	// MutuallyExclusive declares sets of body paths of which at most one may be
+	// set, rendered like the preconditions, in addition to the pairs inferred
+	// from the schema.
+	MutuallyExclusive [][]string `json:"mutually_exclusive,omitempty"`
+
+	// PreconditionValidations renders the cross-property rules, configured and
+	// inferred, as validation blocks on the variable holding the required
+	// property instead of lifecycle preconditions, in the base module and in
+	// child submodules.
+	PreconditionValidations bool `json:"precondition_validations,omitempty"`
+
+	// PostCreateProperties lists body paths (children of "properties" or root
+	// properties) that the service only accepts once the resource exists. They are
+	// applied by a companion azapi_update_resource instead of the creation body.
+	PostCreateProperties []string `json:"post_create_properties,omitempty"`
+
+	// EndpointOutputSuffixes replaces the name suffixes (Endpoint, Url, Uri, Fqdn,
+	// HostName) of read-only strings that are always exported and output. An empty
+	// list disables the heuristic; omitting the key keeps the defaults.
+	EndpointOutputSuffixes []string `json:"endpoint_output_suffixes,omitempty"`
+
+	// PropertyBlocklist replaces the body paths (systemData, etag,
+	// properties.provisioningState) that never become variables, body entries or
+	// outputs, even where the spec marks them writable. An empty list disables it;
+	// omitting the key keeps the defaults.
+	PropertyBlocklist []string `json:"property_blocklist,omitempty"`
+
+	// ObjectOutputs lists response paths of read-only objects (e.g.
+	// "properties.networkProfile") that are exported and output as a single object
+	// instead of one output per nested attribute.
+	ObjectOutputs []string `json:"object_outputs,omitempty"`
+
+	// OutputNaming sets the naming convention of outputs generated for response
+	// paths, in the base module and in child submodules.
+	OutputNaming *OutputNaming `json:"output_naming,omitempty"`
+
+	// TypesPath is a local bicep-types-az checkout, relative to the module, and
+	// TypesRef a branch, tag or commit of the published types, that the module
+	// and its children are loaded from when -types-path and -types-ref are not
+	// given. Children override them in Children. At most one may be set.
+	TypesPath string `json:"types_path,omitempty"`
+	TypesRef  string `json:"types_ref,omitempty"`
+
+	// Children pins how individual child resource types are loaded when they are
+	// generated as submodules, keyed by resource type (case-insensitive).
+	Children map[string]ChildOverride `json:"children,omitempty"`
+
+	// ChildrenInclude and ChildrenExclude are glob patterns on the last segment
+	// of discovered child resource types (e.g. "diagnostic*"), selecting the
+	// children gen avm generates.
+	ChildrenInclude []string `json:"children_include,omitempty"`
+	ChildrenExclude []string `json:"children_exclude,omitempty"`
+
+	// SpecExamples is the directory of x-ms-examples files of the module's
+	// resource type and API version in an azure-rest-api-specs checkout, relative
+	// to the module. The request bodies provide realistic values for the example
+	// modules and terraform.tfvars.example.
+	SpecExamples string `json:"spec_examples,omitempty"`
+
+	// Versions replaces the default version constraints of terraform.tf, in the
+	// base module and in child submodules.
+	Versions Versions `json:"versions,omitempty"`
+
+	// TemplatesDir is a directory of override templates for the fixed-content
+	// files, relative to the module: terraform.tf.tmpl and main.telemetry.tf.tmpl
+	// replace those files, and <file>.header.tmpl is written above a .tf file.
+	TemplatesDir string `json:"templates_dir,omitempty"`
+
+	// Hooks customize the generated files, e.g. to add a company header or run
+	// a formatter, without forking tfmodmake.
+	Hooks Hooks `json:"hooks,omitempty"`
+}
+
+// Versions are the version constraints written to terraform.tf.
+type Versions struct {
+	// Terraform is the required_version constraint.
+	Terraform string `json:"terraform,omitempty"`
+	// RequiredProviders replaces the source or version of required providers,
+	// keyed by local name (e.g. "azapi", "modtm", "random"), and adds others.
+	RequiredProviders map[string]ProviderVersion `json:"required_providers,omitempty"`
+}
+
+// ProviderVersion is an entry of required_providers.
+type ProviderVersion struct {
+	Source  string `json:"source,omitempty"`
+	Version string `json:"version,omitempty"`
+}
+
+// Hooks are run, in order, on the files a generation command added or changed.
+type Hooks struct {
+	// PreWrite hooks transform the generated files before the command completes.
+	// A command hook receives the generation manifest as JSON on stdin and
+	// prints the manifest with the transformed files; a template hook renders
+	// each file through a Go template.
+	PreWrite []Hook `json:"pre_write,omitempty"`
+	// PostWrite hooks are commands run once the files are written. They receive
+	// the list of written files on stdin, one per line.
+	PostWrite []Hook `json:"post_write,omitempty"`
+}
+
+// Hook is either a command or a template.
+type Hook struct {
+	// Command is the program and its arguments, run in the module directory.
+	Command []string `json:"command,omitempty"`
+	// Template is the path, relative to the module directory, of a Go template
+	// rendered with the file's Name, Content and the command's ResourceType.
+	Template string `json:"template,omitempty"`
+	// Files are glob patterns on file names (e.g. "*.tf") limiting the files the
+	// hook receives. Empty means every file.
+	Files []string `json:"files,omitempty"`
+}
+
+// Matches reports whether the hook receives the file name, a slash-separated
+// path whose base name is matched against Files.
+func (h Hook) Matches(name string) bool {
+	if len(h.Files) == 0 {
+		return true
+	}
+	base := path.Base(name)
+	for _, pattern := range h.Files {
+		if ok, _ := path.Match(pattern, base); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (h Hooks) validate() error {
+	for i, hook := range h.PreWrite {
+		if (len(hook.Command) == 0) == (hook.Template == "") {
+			return fmt.Errorf("hooks.pre_write[%d]: set exactly one of command and template", i)
+		}
+		if err := validateFilePatterns(hook.Files); err != nil {
+			return fmt.Errorf("hooks.pre_write[%d]: %w", i, err)
+		}
+	}
+	for i, hook := range h.PostWrite {
+		if len(hook.Command) == 0 || hook.Template != "" {
+			return fmt.Errorf("hooks.post_write[%d]: set command; templates are only run before writing", i)
+		}
+		if err := validateFilePatterns(hook.Files); err != nil {
+			return fmt.Errorf("hooks.post_write[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func validateFilePatterns(patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid files pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// ChildOverride pins the schema of one child resource type.
+type ChildOverride struct {
+	// APIVersion is the API version the child is generated from, instead of its
+	// latest stable version.
+	APIVersion string `json:"api_version,omitempty"`
+	// IncludePreview selects the latest preview version of the child when it is
+	// newer, without putting the rest of the module on preview versions.
+	IncludePreview bool `json:"include_preview,omitempty"`
+	// TypesPath is a local bicep-types-az checkout the child is loaded from instead
+	// of the types of its parent, and TypesRef a branch, tag or commit of the
+	// published types it is loaded from instead. At most one may be set.
+	TypesPath string `json:"types_path,omitempty"`
+	TypesRef  string `json:"types_ref,omitempty"`
+	// Inline generates the child as a for_each azapi_resource in its parent module
+	// instead of a submodule.
+	Inline bool `json:"inline,omitempty"`
+}
+
+// ChildOverride returns the override declared for resourceType, if any.
+func (c *Config) ChildOverride(resourceType string) (ChildOverride, bool) {
+	if c == nil {
+		return ChildOverride{}, false
+	}
+	for key, override := range c.Children {
+		if strings.EqualFold(key, resourceType) {
+			return override, true
+		}
+	}
+	return ChildOverride{}, false
+}
+
+// OutputNaming customizes the names of generated outputs.
+type OutputNaming struct {
+	// Prefix is prepended to every generated output name.
+	Prefix string `json:"prefix,omitempty"`
+	// IncludeProperties keeps the leading "properties" path segment in names.
+	IncludeProperties bool `json:"include_properties,omitempty"`
+	// SegmentNames maps API path segments to the name used instead of their
+	// snake_cased form, e.g. {"defaultHostName": "hostname"}.
+	SegmentNames map[string]string `json:"segment_names,omitempty"`
+}
+
+// Precondition requires the body path Require to be set whenever the body path
+// When equals Equals.
+type Precondition struct {
+	When    string `json:"when"`
+	Equals  string `json:"equals"`
+	Require string `json:"require"`
+}
+
+// Load reads the configuration file at path. Unknown fields are rejected so that
+// typos surface instead of being silently ignored.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading config %s: %w", path, err)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+
+	var cfg Config
+	if err := dec.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("parsing config %s: %w", path, err)
+	}
+	if err := cfg.Hooks.validate(); err != nil {
+		return nil, fmt.Errorf("config %s: %w", path, err)
+	}
+	if err := cfg.validateTypes(); err != nil {
+		return nil, fmt.Errorf("config %s: %w", path, err)
+	}
+	if err := cfg.validateMutuallyExclusive(); err != nil {
+		return nil, fmt.Errorf("config %s: %w", path, err)
+	}
+	return &cfg, nil
+}
+
+// validateTypes rejects a types_path and a types_ref set together, at the top
+// level or for a child.
+func (c *Config) validateTypes() error {
+	if c.TypesPath != "" && c.TypesRef != "" {
+		return errors.New("set at most one of types_path and types_ref")
+	}
+	keys := make([]string, 0, len(c.Children))
+	for key := range c.Children {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if override := c.Children[key]; override.TypesPath != "" && override.TypesRef != "" {
+			return fmt.Errorf("children[%q]: set at most one of types_path and types_ref", key)
+		}
+	}
+	return nil
+}
+
+// validateMutuallyExclusive requires every mutually_exclusive entry to name at
+// least two paths.
+func (c *Config) validateMutuallyExclusive() error {
+	for i, paths := range c.MutuallyExclusive {
+		if len(paths) < 2 {
+			return fmt.Errorf("mutually_exclusive[%d]: name at least two paths", i)
+		}
+	}
+	return nil
+}
+
+// LoadFromDir reads tfmodmake.json from dir. A missing file yields an empty config.
+func LoadFromDir(dir string) (*Config, error) {
+	cfg, err := Load(filepath.Join(dir, FileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return &Config{}, nil
+	}
+	return cfg, err
+}
+
+// Resolve loads the config from an explicit path when given, otherwise from dir.
+func Resolve(path, dir string) (*Config, error) {
+	if path != "" {
+		return Load(path)
+	}
+	return LoadFromDir(dir)
+}
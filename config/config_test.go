@@ -0,0 +1,154 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadFromDir_MissingFileReturnsEmptyConfig(t *testing.T) {
+	cfg, err := LoadFromDir(t.TempDir())
+	require.NoError(t, err)
+	require.NotNil(t, cfg)
+	assert.Empty(t, cfg.IgnoreChanges)
+}
+
+func TestLoadFromDir_ReadsFile(t *testing.T) {
+	dir := t.TempDir()
+	content := `{"ignore_changes": ["properties.count"]}`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, FileName), []byte(content), 0o644))
+
+	cfg, err := LoadFromDir(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"properties.count"}, cfg.IgnoreChanges)
+}
+
+func TestLoad_RejectsUnknownFields(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	require.NoError(t, os.WriteFile(path, []byte(`{"ignore_chnages": []}`), 0o644))
+
+	_, err := Load(path)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "ignore_chnages")
+}
+
+func TestResolve_ExplicitPathMustExist(t *testing.T) {
+	_, err := Resolve(filepath.Join(t.TempDir(), "missing.json"), t.TempDir())
+	require.Error(t, err)
+}
+
+func TestLoad_EmptyEndpointOutputSuffixesDisablesHeuristic(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	require.NoError(t, os.WriteFile(path, []byte(`{"endpoint_output_suffixes": []}`), 0o644))
+
+	cfg, err := Load(path)
+	require.NoError(t, err)
+	assert.NotNil(t, cfg.EndpointOutputSuffixes)
+	assert.Empty(t, cfg.EndpointOutputSuffixes)
+
+	cfg, err = LoadFromDir(t.TempDir())
+	require.NoError(t, err)
+	assert.Nil(t, cfg.EndpointOutputSuffixes)
+}
+
+func TestLoad_PropertyBlocklist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	require.NoError(t, os.WriteFile(path, []byte(`{"property_blocklist": ["etag", "properties.status"]}`), 0o644))
+
+	cfg, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"etag", "properties.status"}, cfg.PropertyBlocklist)
+
+	cfg, err = LoadFromDir(t.TempDir())
+	require.NoError(t, err)
+	assert.Nil(t, cfg.PropertyBlocklist)
+}
+
+func TestLoad_OutputNaming(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	require.NoError(t, os.WriteFile(path, []byte(`{"output_naming": {"prefix": "res_", "include_properties": true, "segment_names": {"defaultHostName": "hostname"}}}`), 0o644))
+
+	cfg, err := Load(path)
+	require.NoError(t, err)
+	require.NotNil(t, cfg.OutputNaming)
+	assert.Equal(t, OutputNaming{Prefix: "res_", IncludeProperties: true, SegmentNames: map[string]string{"defaultHostName": "hostname"}}, *cfg.OutputNaming)
+}
+
+func TestConfig_ChildOverride(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	require.NoError(t, os.WriteFile(path, []byte(`{"children": {"Microsoft.App/managedEnvironments/certificates": {"api_version": "2024-10-02-preview", "types_path": "../bicep-types-az"}}}`), 0o644))
+
+	cfg, err := Load(path)
+	require.NoError(t, err)
+
+	override, ok := cfg.ChildOverride("microsoft.app/managedenvironments/certificates")
+	require.True(t, ok)
+	assert.Equal(t, ChildOverride{APIVersion: "2024-10-02-preview", TypesPath: "../bicep-types-az"}, override)
+
+	_, ok = cfg.ChildOverride("Microsoft.App/managedEnvironments/storages")
+	assert.False(t, ok)
+
+	var empty *Config
+	_, ok = empty.ChildOverride("Microsoft.App/managedEnvironments/certificates")
+	assert.False(t, ok)
+}
+
+func TestLoad_Hooks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	require.NoError(t, os.WriteFile(path, []byte(`{"hooks": {"pre_write": [{"template": "header.tmpl", "files": ["*.tf"]}], "post_write": [{"command": ["avmfix", "-folder", "."]}]}}`), 0o644))
+
+	cfg, err := Load(path)
+	require.NoError(t, err)
+	require.Len(t, cfg.Hooks.PreWrite, 1)
+	assert.True(t, cfg.Hooks.PreWrite[0].Matches("modules/child/main.tf"))
+	assert.False(t, cfg.Hooks.PreWrite[0].Matches("README.md"))
+	assert.True(t, cfg.Hooks.PostWrite[0].Matches("README.md"))
+
+	for content, want := range map[string]string{
+		`{"hooks": {"pre_write": [{}]}}`:                                  "hooks.pre_write[0]: set exactly one of command and template",
+		`{"hooks": {"pre_write": [{"command": ["x"], "template": "t"}]}}`: "hooks.pre_write[0]: set exactly one of command and template",
+		`{"hooks": {"post_write": [{"template": "t"}]}}`:                  "hooks.post_write[0]: set command",
+		`{"hooks": {"post_write": [{"command": ["x"], "files": ["["]}]}}`: `invalid files pattern "["`,
+	} {
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+		_, err := Load(path)
+		assert.ErrorContains(t, err, want, content)
+	}
+}
+
+func TestLoad_Types(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	require.NoError(t, os.WriteFile(path, []byte(`{"types_path": "../bicep-types-az", "children": {"Microsoft.Insights/diagnosticSettings": {"types_ref": "v0.5"}}}`), 0o644))
+
+	cfg, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, "../bicep-types-az", cfg.TypesPath)
+	override, ok := cfg.ChildOverride("microsoft.insights/diagnosticsettings")
+	require.True(t, ok)
+	assert.Equal(t, ChildOverride{TypesRef: "v0.5"}, override)
+
+	for content, want := range map[string]string{
+		`{"types_path": "a", "types_ref": "b"}`:                                    "set at most one of types_path and types_ref",
+		`{"children": {"Microsoft.A/b/c": {"types_path": "a", "types_ref": "b"}}}`: `children["Microsoft.A/b/c"]: set at most one`,
+	} {
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+		_, err := Load(path)
+		assert.ErrorContains(t, err, want, content)
+	}
+}
+
+func TestLoad_MutuallyExclusive(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	require.NoError(t, os.WriteFile(path, []byte(`{"mutually_exclusive": [["properties.subnetId", "properties.vnetId"]]}`), 0o644))
+
+	cfg, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"properties.subnetId", "properties.vnetId"}}, cfg.MutuallyExclusive)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"mutually_exclusive": [["properties.subnetId"]]}`), 0o644))
+	_, err = Load(path)
+	assert.ErrorContains(t, err, "mutually_exclusive[0]: name at least two paths")
+}
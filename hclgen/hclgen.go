@@ -2,7 +2,6 @@
 package hclgen
 
 import (
-	"os"
 	"path/filepath"
 	"unicode"
 
@@ -93,10 +92,34 @@ func TokensForTraversalOrIndex(parts ...string) hclwrite.Tokens {
 	return tokens
 }
 
+// TokensForInterpolatedString returns tokens for a quoted string template whose
+// literal prefix is followed by an interpolated traversal, e.g.:
+//
+//	"/subscriptions/${data.azapi_client_config.current.subscription_id}"
+func TokensForInterpolatedString(prefix string, traversal ...string) hclwrite.Tokens {
+	tokens := hclwrite.Tokens{
+		{Type: hclsyntax.TokenOQuote, Bytes: []byte(`"`)},
+		{Type: hclsyntax.TokenQuotedLit, Bytes: []byte(prefix)},
+		{Type: hclsyntax.TokenTemplateInterp, Bytes: []byte("${")},
+	}
+	tokens = append(tokens, TokensForTraversal(traversal...)...)
+	return append(tokens,
+		&hclwrite.Token{Type: hclsyntax.TokenTemplateSeqEnd, Bytes: []byte("}")},
+		&hclwrite.Token{Type: hclsyntax.TokenCQuote, Bytes: []byte(`"`)},
+	)
+}
+
 // NullEqualityTernary returns tokens for a ternary expression: condition == null ? null : trueExpr
+//
+// The condition tokens are copied, so trueExpr may reuse them, e.g. in sort(var.zones):
+// formatting sets the spacing of each token, and a token shared by both positions
+// would take the spacing of the last.
 func NullEqualityTernary(conditionExpr hclwrite.Tokens, trueExpr hclwrite.Tokens) hclwrite.Tokens {
 	var t hclwrite.Tokens
-	t = append(t, conditionExpr...)
+	for _, token := range conditionExpr {
+		copied := *token
+		t = append(t, &copied)
+	}
 	t = append(t, &hclwrite.Token{Type: hclsyntax.TokenEqualOp, Bytes: []byte("==")})
 	t = append(t, hclwrite.TokensForIdentifier("null")...)
 	t = append(t, &hclwrite.Token{Type: hclsyntax.TokenQuestion, Bytes: []byte("?")})
@@ -170,13 +193,19 @@ func TokensForMultilineStringListWithValueComments(values []string) hclwrite.Tok
 	return tokens
 }
 
-// WriteFile writes an HCL file to disk.
+// WriteFile writes an HCL file to disk, unless it already holds the same content.
 func WriteFile(path string, file *hclwrite.File) error {
-	return os.WriteFile(path, file.Bytes(), 0o644)
+	return WriteIfChanged(path, file.Bytes())
 }
 
-// WriteFileToDir writes an HCL file to a specified directory.
+// WriteFileToDir writes an HCL file to a specified directory, unless it already
+// holds the same content.
 func WriteFileToDir(outputDir string, filename string, file *hclwrite.File) error {
-	path := filepath.Join(outputDir, filename)
-	return os.WriteFile(path, file.Bytes(), 0o644)
+	return WriteIfChanged(filepath.Join(outputDir, filename), file.Bytes())
+}
+
+// WriteIfChanged writes content to path unless the file already holds exactly
+// that content, without recording it in a Journal.
+func WriteIfChanged(path string, content []byte) error {
+	return (*Journal)(nil).WriteIfChanged(path, content)
 }
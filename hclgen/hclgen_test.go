@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/hclwrite"
@@ -122,6 +123,11 @@ func TestTokensForMultilineStringListWithValueComments(t *testing.T) {
 	})
 }
 
+func TestTokensForInterpolatedString(t *testing.T) {
+	tokens := TokensForInterpolatedString("/subscriptions/", "data", "azapi_client_config", "current", "subscription_id")
+	assert.Equal(t, `"/subscriptions/${data.azapi_client_config.current.subscription_id}"`, string(tokens.Bytes()))
+}
+
 func TestTernary(t *testing.T) {
 	condition := hclwrite.TokensForIdentifier("var.enabled")
 	trueExpr := hclwrite.TokensForIdentifier("var.value")
@@ -140,6 +146,13 @@ func TestTernary(t *testing.T) {
 	assert.Equal(t, expected, string(resultTokens.Bytes()))
 }
 
+func TestTernary_SharedTokens(t *testing.T) {
+	access := TokensForTraversal("var", "zones")
+	f := hclwrite.NewEmptyFile()
+	f.Body().SetAttributeRaw("zones", NullEqualityTernary(access, hclwrite.TokensForFunctionCall("sort", access)))
+	assert.Equal(t, "zones = var.zones == null ? null : sort(var.zones)\n", string(f.Bytes()))
+}
+
 func TestSetDescriptionAttribute(t *testing.T) {
 	f := hclwrite.NewEmptyFile()
 	body := f.Body()
@@ -171,3 +184,25 @@ func TestWriteFile(t *testing.T) {
 
 	assert.Contains(t, string(content), "foo = bar")
 }
+
+func TestWriteIfChanged(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "main.tf")
+	require.NoError(t, WriteIfChanged(path, []byte("foo = bar\n")))
+
+	old := time.Now().Add(-time.Hour).Truncate(time.Second)
+	require.NoError(t, os.Chtimes(path, old, old))
+
+	// Identical content leaves the file, and its modification time, as it was.
+	require.NoError(t, WriteIfChanged(path, []byte("foo = bar\n")))
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.True(t, info.ModTime().Equal(old))
+
+	require.NoError(t, WriteIfChanged(path, []byte("foo = baz\n")))
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "foo = baz\n", string(content))
+	info, err = os.Stat(path)
+	require.NoError(t, err)
+	assert.True(t, info.ModTime().After(old))
+}
@@ -0,0 +1,116 @@
+package hclgen
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+// Journal records the files written and removed through it, with their state
+// before the first touch, so a command can report or undo the changes of a
+// generation without looking at any other file. A nil Journal writes and
+// removes files without recording them. A Journal is safe for concurrent use.
+type Journal struct {
+	mu    sync.Mutex
+	files map[string]journalEntry
+}
+
+type journalEntry struct {
+	content []byte
+	existed bool
+}
+
+// NewJournal returns an empty journal.
+func NewJournal() *Journal {
+	return &Journal{files: map[string]journalEntry{}}
+}
+
+// Touched returns the slash-separated paths, relative to dir, of the files below
+// dir the journal recorded, sorted.
+func (j *Journal) Touched(dir string) []string {
+	if j == nil {
+		return nil
+	}
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		return nil
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	var names []string
+	for path := range j.files {
+		rel, err := filepath.Rel(root, path)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		names = append(names, filepath.ToSlash(rel))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Before returns the content the file at the slash-separated path name below dir
+// held before it was first touched, and whether it existed then.
+func (j *Journal) Before(dir, name string) ([]byte, bool) {
+	if j == nil {
+		return nil, false
+	}
+	path, err := filepath.Abs(filepath.Join(dir, filepath.FromSlash(name)))
+	if err != nil {
+		return nil, false
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	entry := j.files[path]
+	return entry.content, entry.existed
+}
+
+// touch records the state of the file at path, the first time it is touched.
+func (j *Journal) touch(path string) {
+	if j == nil {
+		return
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if _, ok := j.files[abs]; ok {
+		return
+	}
+	content, err := os.ReadFile(abs)
+	j.files[abs] = journalEntry{content: content, existed: err == nil}
+}
+
+// WriteIfChanged writes content to path unless the file already holds exactly
+// that content. Skipping identical writes keeps the modification time of files
+// a regeneration leaves as they were, so make and file watchers see no change.
+// The file is recorded in the journal either way.
+func (j *Journal) WriteIfChanged(path string, content []byte) error {
+	j.touch(path)
+	if existing, err := os.ReadFile(path); err == nil && bytes.Equal(existing, content) {
+		return nil
+	}
+	return os.WriteFile(path, content, 0o644)
+}
+
+// WriteFile writes an HCL file like WriteIfChanged.
+func (j *Journal) WriteFile(path string, file *hclwrite.File) error {
+	return j.WriteIfChanged(path, file.Bytes())
+}
+
+// Remove removes the file at path, recording it in the journal. A missing file
+// is not an error.
+func (j *Journal) Remove(path string) error {
+	j.touch(path)
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
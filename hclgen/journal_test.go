@@ -0,0 +1,42 @@
+package hclgen
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJournal(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.tf"), []byte("old"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "stale.tf"), []byte("stale"), 0o644))
+
+	j := NewJournal()
+	require.NoError(t, j.WriteIfChanged(filepath.Join(dir, "main.tf"), []byte("first")))
+	require.NoError(t, j.WriteIfChanged(filepath.Join(dir, "main.tf"), []byte("second")))
+	require.NoError(t, JournalWriter{Dir: dir, Journal: j}.WriteFile("outputs.tf", []byte("new")))
+	require.NoError(t, j.Remove(filepath.Join(dir, "stale.tf")))
+	require.NoError(t, j.Remove(filepath.Join(dir, "missing.tf")))
+
+	// Writes through another journal, or none, are not recorded.
+	other := NewJournal()
+	require.NoError(t, other.WriteIfChanged(filepath.Join(dir, "other.tf"), []byte("other")))
+	require.NoError(t, WriteIfChanged(filepath.Join(dir, "untracked.tf"), []byte("untracked")))
+	require.NoError(t, DirWriter(dir).WriteFile("dir.tf", []byte("untracked")))
+
+	assert.Equal(t, []string{"main.tf", "missing.tf", "outputs.tf", "stale.tf"}, j.Touched(dir))
+	assert.Equal(t, []string{"other.tf"}, other.Touched(dir))
+	assert.Empty(t, j.Touched(filepath.Join(dir, "modules")))
+
+	content, existed := j.Before(dir, "main.tf")
+	assert.True(t, existed)
+	assert.Equal(t, "old", string(content))
+	content, existed = j.Before(dir, "stale.tf")
+	assert.True(t, existed)
+	assert.Equal(t, "stale", string(content))
+	_, existed = j.Before(dir, "outputs.tf")
+	assert.False(t, existed)
+}
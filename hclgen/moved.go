@@ -0,0 +1,75 @@
+package hclgen
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+// MovedFileName is the file moved blocks are written to.
+const MovedFileName = "moved.tf"
+
+// Move records that the object at address From is now at address To.
+type Move struct {
+	From string
+	To   string
+}
+
+// AppendMovedBlocks adds a moved block per move to moved.tf in dir, creating the
+// file when needed, and records it in journal. Moves already recorded in the
+// file are skipped.
+func AppendMovedBlocks(dir string, moves []Move, journal *Journal) error {
+	if len(moves) == 0 {
+		return nil
+	}
+
+	path := filepath.Join(dir, MovedFileName)
+	file := hclwrite.NewEmptyFile()
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		var diags hcl.Diagnostics
+		file, diags = hclwrite.ParseConfig(data, path, hcl.Pos{Line: 1, Column: 1})
+		if diags.HasErrors() {
+			return fmt.Errorf("parsing %s: %s", path, diags.Error())
+		}
+	case !errors.Is(err, os.ErrNotExist):
+		return fmt.Errorf("reading %s: %w", path, err)
+	}
+
+	existing := make(map[Move]struct{})
+	for _, block := range file.Body().Blocks() {
+		if block.Type() != "moved" {
+			continue
+		}
+		existing[Move{From: attributeText(block.Body(), "from"), To: attributeText(block.Body(), "to")}] = struct{}{}
+	}
+
+	for _, move := range moves {
+		if _, ok := existing[move]; ok {
+			continue
+		}
+		existing[move] = struct{}{}
+		if len(file.Body().Blocks()) > 0 {
+			file.Body().AppendNewline()
+		}
+		body := file.Body().AppendNewBlock("moved", nil).Body()
+		body.SetAttributeRaw("from", TokensForTraversal(strings.Split(move.From, ".")...))
+		body.SetAttributeRaw("to", TokensForTraversal(strings.Split(move.To, ".")...))
+	}
+
+	return journal.WriteFile(path, file)
+}
+
+func attributeText(body *hclwrite.Body, name string) string {
+	attr := body.GetAttribute(name)
+	if attr == nil {
+		return ""
+	}
+	return strings.TrimSpace(string(attr.Expr().BuildTokens(nil).Bytes()))
+}
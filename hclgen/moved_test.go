@@ -0,0 +1,40 @@
+package hclgen
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppendMovedBlocks(t *testing.T) {
+	dir := t.TempDir()
+
+	require.NoError(t, AppendMovedBlocks(dir, []Move{{From: "azapi_resource.main", To: "azapi_resource.this"}}, nil))
+	require.NoError(t, AppendMovedBlocks(dir, []Move{
+		{From: "azapi_resource.main", To: "azapi_resource.this"},
+		{From: "module.old", To: "module.new"},
+	}, nil))
+
+	data, err := os.ReadFile(filepath.Join(dir, MovedFileName))
+	require.NoError(t, err)
+	assert.Equal(t, `moved {
+  from = azapi_resource.main
+  to   = azapi_resource.this
+}
+
+moved {
+  from = module.old
+  to   = module.new
+}
+`, string(data))
+}
+
+func TestAppendMovedBlocks_NoMoves(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, AppendMovedBlocks(dir, nil, nil))
+	_, err := os.Stat(filepath.Join(dir, MovedFileName))
+	assert.True(t, os.IsNotExist(err))
+}
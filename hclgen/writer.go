@@ -0,0 +1,39 @@
+package hclgen
+
+import "path/filepath"
+
+// Writer receives the files of a generated module. Names are slash-separated
+// and relative to the module root.
+type Writer interface {
+	WriteFile(name string, content []byte) error
+}
+
+// DirWriter writes files into the directory it names, leaving files that
+// already hold the same content untouched.
+type DirWriter string
+
+// WriteFile writes content to name below the directory.
+func (d DirWriter) WriteFile(name string, content []byte) error {
+	return JournalWriter{Dir: string(d)}.WriteFile(name, content)
+}
+
+// JournalWriter writes files into Dir like DirWriter, recording them in
+// Journal, which may be nil.
+type JournalWriter struct {
+	Dir     string
+	Journal *Journal
+}
+
+// WriteFile writes content to name below Dir.
+func (w JournalWriter) WriteFile(name string, content []byte) error {
+	return w.Journal.WriteIfChanged(filepath.Join(w.Dir, filepath.FromSlash(name)), content)
+}
+
+// MemoryWriter collects files in memory, keyed by name.
+type MemoryWriter map[string][]byte
+
+// WriteFile stores a copy of content under name.
+func (m MemoryWriter) WriteFile(name string, content []byte) error {
+	m[name] = append([]byte(nil), content...)
+	return nil
+}
@@ -0,0 +1,102 @@
+// Package typestest writes small bicep-types-az checkouts for tests, so
+// generation can run against local types without the network.
+package typestest
+
+import (
+	"encoding/json"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/Azure/bicep-types/src/bicep-types-go/types"
+)
+
+// DefaultAPIVersion is the API version of resources that do not set one.
+const DefaultAPIVersion = "2024-01-01"
+
+// Resource is a resource type in a checkout. Its body has a required name and,
+// when Properties is not empty, an optional properties object holding those
+// string properties.
+type Resource struct {
+	Type       string
+	APIVersion string
+	Properties []string
+}
+
+// Widgets is Microsoft.Test/widgets at DefaultAPIVersion with a color property.
+var Widgets = Resource{Type: "Microsoft.Test/widgets", Properties: []string{"color"}}
+
+// Write writes a checkout holding resources to a new temporary directory and
+// returns its path. Resources of one provider and API version share a
+// types.json, as in bicep-types-az.
+func Write(t testing.TB, resources ...Resource) string {
+	t.Helper()
+	dir := t.TempDir()
+	generated := filepath.Join(dir, "generated")
+
+	files := map[string][]types.Type{}
+	index := map[string]map[string]string{}
+	for _, r := range resources {
+		apiVersion := r.APIVersion
+		if apiVersion == "" {
+			apiVersion = DefaultAPIVersion
+		}
+		namespace := strings.ToLower(r.Type[:strings.Index(r.Type, "/")])
+		file := namespace + "/" + apiVersion + "/types.json"
+
+		entries := files[file]
+		if entries == nil {
+			entries = []types.Type{&types.StringType{}}
+		}
+		body := &types.ObjectType{Name: r.Type, Properties: map[string]types.ObjectTypeProperty{
+			"name": {Type: types.TypeReference{Ref: 0}, Flags: types.TypePropertyFlagsRequired},
+		}}
+		if len(r.Properties) > 0 {
+			props := &types.ObjectType{Name: path.Base(r.Type) + "Properties", Properties: map[string]types.ObjectTypeProperty{}}
+			for _, name := range r.Properties {
+				props.Properties[name] = types.ObjectTypeProperty{Type: types.TypeReference{Ref: 0}}
+			}
+			entries = append(entries, props)
+			body.Properties["properties"] = types.ObjectTypeProperty{Type: types.TypeReference{Ref: len(entries) - 1}}
+		}
+		entries = append(entries, body, &types.ResourceType{
+			Name:           r.Type + "@" + apiVersion,
+			Body:           types.TypeReference{Ref: len(entries)},
+			WritableScopes: types.ScopeTypeResourceGroup,
+			ReadableScopes: types.ScopeTypeResourceGroup,
+		})
+		files[file] = entries
+		index[r.Type+"@"+apiVersion] = map[string]string{"$ref": file + "#/" + strconv.Itoa(len(entries)-1)}
+	}
+
+	for file, entries := range files {
+		parts := make([]json.RawMessage, len(entries))
+		for i, entry := range entries {
+			data, err := entry.MarshalJSON()
+			if err != nil {
+				t.Fatal(err)
+			}
+			parts[i] = data
+		}
+		writeJSON(t, filepath.Join(generated, filepath.FromSlash(file)), parts)
+	}
+	writeJSON(t, filepath.Join(generated, "index.json"), map[string]any{"resources": index})
+	return dir
+}
+
+func writeJSON(t testing.TB, name string, v any) {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(name, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
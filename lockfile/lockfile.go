@@ -0,0 +1,98 @@
+// Package lockfile reads and writes tfmodmake.lock.json, which records where a
+// generated module's schemas came from and how it was generated, so it can be
+// regenerated reproducibly and checked for drift.
+package lockfile
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/matt-FFFFFF/tfmodmake/hclgen"
+)
+
+// FileName is the name of the lock file written into each generated module.
+const FileName = "tfmodmake.lock.json"
+
+// Lock records the generation of one module directory.
+type Lock struct {
+	// TfmodmakeVersion is the version of tfmodmake that generated the module.
+	TfmodmakeVersion string `json:"tfmodmake_version"`
+	// Command is the tfmodmake command the module was generated with, e.g.
+	// ["gen", "avm"], and Args its flags. Submodules have neither; they are
+	// regenerated with the module in Root.
+	Command []string `json:"command,omitempty"`
+	Args    []string `json:"args,omitempty"`
+	// Root is the directory, relative to this one, of the module whose lock
+	// holds the command that generated this submodule.
+	Root string `json:"root,omitempty"`
+	// Resources are the resource types generated into the module, in the order
+	// they were recorded.
+	Resources []Resource `json:"resources"`
+}
+
+// Resource is a resource type generated into a module, at one API version.
+type Resource struct {
+	ResourceType string `json:"resource_type"`
+	APIVersion   string `json:"api_version"`
+	// Source is the bicep-types-az the schema was read from.
+	Source Source `json:"source"`
+	// URL is where the types file of the resource was read from: its URL, or
+	// its path in a local checkout.
+	URL string `json:"url"`
+}
+
+// Source is a revision of bicep-types-az.
+type Source struct {
+	// Repository is the URL the types were downloaded from, or the path of the
+	// local checkout they were read from.
+	Repository string `json:"repository"`
+	// Commit is the bicep-types-az commit the types were read at, empty when
+	// it could not be resolved.
+	Commit string `json:"commit,omitempty"`
+	// Local reports whether Repository is a local checkout.
+	Local bool `json:"local,omitempty"`
+}
+
+// Read reads the lock file of dir. A missing lock file is reported with an
+// error matching os.ErrNotExist.
+func Read(dir string) (*Lock, error) {
+	path := filepath.Join(dir, FileName)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("%s has no %s: %w", dir, FileName, err)
+		}
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	var l Lock
+	if err := dec.Decode(&l); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return &l, nil
+}
+
+// Marshal renders the lock as indented JSON ending in a newline.
+func (l *Lock) Marshal() ([]byte, error) {
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// Write writes the lock file of dir, leaving it untouched when unchanged, and
+// records it in journal, which may be nil.
+func Write(dir string, l *Lock, journal *hclgen.Journal) error {
+	data, err := l.Marshal()
+	if err != nil {
+		return err
+	}
+	return journal.WriteIfChanged(filepath.Join(dir, FileName), data)
+}
@@ -0,0 +1,49 @@
+package lockfile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteRead(t *testing.T) {
+	dir := t.TempDir()
+	lock := &Lock{
+		TfmodmakeVersion: "1.2.3",
+		Command:          []string{"gen"},
+		Args:             []string{"-resource", "Microsoft.App/containerApps"},
+		Resources: []Resource{{
+			ResourceType: "Microsoft.App/containerApps",
+			APIVersion:   "2025-01-01",
+			Source:       Source{Repository: "https://github.com/Azure/bicep-types-az", Commit: "abc123"},
+			URL:          "https://raw.githubusercontent.com/Azure/bicep-types-az/abc123/generated/app/microsoft.app/2025-01-01/types.json",
+		}},
+	}
+	require.NoError(t, Write(dir, lock, nil))
+
+	got, err := Read(dir)
+	require.NoError(t, err)
+	assert.Equal(t, lock, got)
+
+	data, err := os.ReadFile(filepath.Join(dir, FileName))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "\n  \"tfmodmake_version\": \"1.2.3\",\n")
+	assert.NotContains(t, string(data), "root", "empty fields are omitted")
+}
+
+func TestRead_Missing(t *testing.T) {
+	_, err := Read(t.TempDir())
+	require.ErrorIs(t, err, os.ErrNotExist)
+	assert.Contains(t, err.Error(), FileName)
+}
+
+func TestRead_UnknownField(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, FileName), []byte(`{"tfmodmake_version": "1", "resources": [], "extra": true}`), 0o644))
+
+	_, err := Read(dir)
+	assert.ErrorContains(t, err, "extra")
+}
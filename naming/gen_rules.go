@@ -0,0 +1,108 @@
+//go:build ignore
+
+// gen_rules refreshes rules.json from the resource type catalogue of the Azure
+// Naming Tool. Upstream entries replace existing ones; types only known locally
+// are kept.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/matt-FFFFFF/tfmodmake/naming"
+)
+
+const defaultSource = "https://raw.githubusercontent.com/mspnp/AzureNamingTool/main/src/repository/resourcetypes.json"
+
+// upstreamResourceType is the subset of an Azure Naming Tool resource type used here.
+type upstreamResourceType struct {
+	Resource  string    `json:"resource"`
+	Property  string    `json:"property"`
+	Scope     string    `json:"scope"`
+	LengthMin flexInt64 `json:"lengthMin"`
+	LengthMax flexInt64 `json:"lengthMax"`
+	Regx      string    `json:"regx"`
+}
+
+// flexInt64 accepts numbers encoded as JSON numbers or strings.
+type flexInt64 int64
+
+func (f *flexInt64) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	if s == "" || s == "null" {
+		return nil
+	}
+	v, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return err
+	}
+	*f = flexInt64(v)
+	return nil
+}
+
+func main() {
+	source := flag.String("source", defaultSource, "URL of the Azure Naming Tool resourcetypes.json")
+	out := flag.String("out", "rules.json", "rules file to update")
+	flag.Parse()
+
+	if err := run(*source, *out); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(source, out string) error {
+	resp, err := http.Get(source)
+	if err != nil {
+		return fmt.Errorf("fetching %s: %w", source, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("fetching %s: %s", source, resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	var upstream []upstreamResourceType
+	if err := json.Unmarshal(data, &upstream); err != nil {
+		return fmt.Errorf("parsing %s: %w", source, err)
+	}
+
+	rules := map[string]naming.Rule{}
+	if existing, err := os.ReadFile(out); err == nil {
+		if err := json.Unmarshal(existing, &rules); err != nil {
+			return fmt.Errorf("parsing %s: %w", out, err)
+		}
+	}
+
+	for _, rt := range upstream {
+		// Entries with a property describe a sub-name of the resource, not its name.
+		if rt.Resource == "" || rt.Property != "" {
+			continue
+		}
+		resourceType := strings.ToLower(rt.Resource)
+		if !strings.HasPrefix(resourceType, "microsoft.") {
+			resourceType = "microsoft." + resourceType
+		}
+		rules[resourceType] = naming.Rule{
+			MinLength: int64(rt.LengthMin),
+			MaxLength: int64(rt.LengthMax),
+			Pattern:   rt.Regx,
+			Scope:     strings.ToLower(rt.Scope),
+		}
+	}
+
+	encoded, err := json.MarshalIndent(rules, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(out, append(encoded, '\n'), 0o644)
+}
@@ -0,0 +1,53 @@
+package naming
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+//go:generate go run gen_rules.go
+
+// rulesJSON holds Azure resource naming restrictions keyed by lower-case resource
+// type. It is refreshed from upstream by gen_rules.go.
+//
+//go:embed rules.json
+var rulesJSON []byte
+
+// Rule describes the naming restrictions of a resource type.
+type Rule struct {
+	MinLength int64  `json:"min_length,omitempty"`
+	MaxLength int64  `json:"max_length,omitempty"`
+	Pattern   string `json:"pattern,omitempty"`
+	// Scope is the scope within which names must be unique: "global", "tenant",
+	// "subscription", "resource group" or "parent resource".
+	Scope string `json:"scope,omitempty"`
+}
+
+var rules = func() map[string]Rule {
+	var m map[string]Rule
+	if err := json.Unmarshal(rulesJSON, &m); err != nil {
+		panic(fmt.Sprintf("parsing embedded naming rules: %v", err))
+	}
+	return m
+}()
+
+// RuleFor returns the naming restrictions of resourceType, matched case-insensitively.
+func RuleFor(resourceType string) (Rule, bool) {
+	rule, ok := rules[strings.ToLower(strings.TrimSpace(resourceType))]
+	return rule, ok
+}
+
+// UniquenessDescription returns a sentence describing the uniqueness scope of the
+// rule, or "" when the scope is unknown.
+func (r Rule) UniquenessDescription() string {
+	switch r.Scope {
+	case "":
+		return ""
+	case "global":
+		return "The name must be globally unique."
+	default:
+		return fmt.Sprintf("The name must be unique within the %s.", r.Scope)
+	}
+}
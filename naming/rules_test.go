@@ -0,0 +1,104 @@
+package naming
+
+import (
+	"math"
+	"regexp"
+	"regexp/syntax"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRuleFor(t *testing.T) {
+	rule, ok := RuleFor("Microsoft.Storage/storageAccounts")
+	require.True(t, ok)
+	assert.Equal(t, Rule{MinLength: 3, MaxLength: 24, Pattern: "^[a-z0-9]{3,24}$", Scope: "global"}, rule)
+	assert.Equal(t, "The name must be globally unique.", rule.UniquenessDescription())
+
+	rule, ok = RuleFor("microsoft.network/virtualnetworks/subnets")
+	require.True(t, ok)
+	assert.Equal(t, "The name must be unique within the parent resource.", rule.UniquenessDescription())
+
+	_, ok = RuleFor("Microsoft.Test/unknown")
+	assert.False(t, ok)
+}
+
+// TestRulesMatchPatterns checks the length limits of each rule against the
+// lengths its pattern accepts, so no rule admits a name its pattern rejects.
+func TestRulesMatchPatterns(t *testing.T) {
+	for resourceType, rule := range rules {
+		if rule.Pattern == "" {
+			continue
+		}
+		_, err := regexp.Compile(rule.Pattern)
+		require.NoError(t, err, resourceType)
+		re, err := syntax.Parse(rule.Pattern, syntax.Perl)
+		require.NoError(t, err, resourceType)
+		minLength, maxLength := patternLengths(re.Simplify())
+
+		if rule.MinLength != 0 {
+			assert.GreaterOrEqual(t, rule.MinLength, minLength, "min_length of %s is below what %s accepts", resourceType, rule.Pattern)
+		}
+		if rule.MaxLength != 0 {
+			assert.LessOrEqual(t, rule.MaxLength, maxLength, "max_length of %s is above what %s accepts", resourceType, rule.Pattern)
+		}
+		if rule.MinLength != 0 && rule.MaxLength != 0 {
+			assert.LessOrEqual(t, rule.MinLength, rule.MaxLength, resourceType)
+		}
+	}
+}
+
+// patternLengths returns the shortest and longest match of re in characters,
+// the longest being math.MaxInt64 when it is unbounded.
+func patternLengths(re *syntax.Regexp) (minLength, maxLength int64) {
+	add := func(a, b int64) int64 {
+		if a == math.MaxInt64 || b == math.MaxInt64 {
+			return math.MaxInt64
+		}
+		return a + b
+	}
+	times := func(a int64, n int) int64 {
+		if a == math.MaxInt64 && n > 0 {
+			return math.MaxInt64
+		}
+		return a * int64(n)
+	}
+	switch re.Op {
+	case syntax.OpLiteral:
+		return int64(len(re.Rune)), int64(len(re.Rune))
+	case syntax.OpCharClass, syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		return 1, 1
+	case syntax.OpCapture:
+		return patternLengths(re.Sub[0])
+	case syntax.OpStar:
+		return 0, math.MaxInt64
+	case syntax.OpPlus:
+		subMin, _ := patternLengths(re.Sub[0])
+		return subMin, math.MaxInt64
+	case syntax.OpQuest:
+		_, subMax := patternLengths(re.Sub[0])
+		return 0, subMax
+	case syntax.OpRepeat:
+		subMin, subMax := patternLengths(re.Sub[0])
+		if re.Max < 0 {
+			return times(subMin, re.Min), math.MaxInt64
+		}
+		return times(subMin, re.Min), times(subMax, re.Max)
+	case syntax.OpConcat:
+		for _, sub := range re.Sub {
+			subMin, subMax := patternLengths(sub)
+			minLength, maxLength = add(minLength, subMin), add(maxLength, subMax)
+		}
+		return minLength, maxLength
+	case syntax.OpAlternate:
+		minLength = math.MaxInt64
+		for _, sub := range re.Sub {
+			subMin, subMax := patternLengths(sub)
+			minLength, maxLength = min(minLength, subMin), max(maxLength, subMax)
+		}
+		return minLength, maxLength
+	}
+	// Anchors, word boundaries and empty matches take no characters.
+	return 0, 0
+}
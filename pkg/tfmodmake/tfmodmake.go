@@ -0,0 +1,248 @@
+// Package tfmodmake is the stable API for generating Terraform modules from
+// Azure resource types, for tools that embed module generation instead of
+// running the CLI. It wraps the bicepdata, schema and terraform packages, whose
+// APIs follow the needs of the CLI and may change between releases.
+package tfmodmake
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/matt-FFFFFF/tfmodmake/bicepdata"
+	"github.com/matt-FFFFFF/tfmodmake/hclgen"
+	"github.com/matt-FFFFFF/tfmodmake/schema"
+	"github.com/matt-FFFFFF/tfmodmake/terraform"
+)
+
+// Errors returned by Generate match these with errors.Is, so callers can tell
+// the kinds of failure apart without inspecting messages.
+var (
+	// ErrResourceNotFound: the resource type or API version is not in the index.
+	ErrResourceNotFound = bicepdata.ErrResourceNotFound
+	// ErrSpecFetch: an index or types file could not be downloaded or read. The
+	// error is a *bicepdata.FetchError carrying the URL and HTTP status.
+	ErrSpecFetch = bicepdata.ErrSpecFetch
+	// ErrUnsupportedConstruct: the resource type cannot be generated, e.g. it
+	// has no PUT operation.
+	ErrUnsupportedConstruct = schema.ErrUnsupportedConstruct
+	// ErrNameCollision: two properties map to the same variable name.
+	ErrNameCollision = terraform.ErrNameCollision
+)
+
+// Flavor selects the kind of module Generate produces.
+type Flavor int
+
+const (
+	// FlavorBase is the azapi_resource with its variables, locals and outputs,
+	// as generated by `tfmodmake gen`.
+	FlavorBase Flavor = iota
+	// FlavorAVM adds the AVM telemetry, the AVM interfaces and the AVM
+	// repository layout to the base module. Child submodules are not generated.
+	FlavorAVM
+)
+
+// SpecSource is where resource schemas are loaded from.
+type SpecSource struct {
+	// TypesPath is a local bicep-types-az checkout. When empty, the published
+	// types are downloaded.
+	TypesPath string
+	// Cache shares the fetched types files between Generate calls using the
+	// same cache. When nil, every call fetches its files. The caller closes
+	// the cache once the calls are done.
+	Cache *bicepdata.Cache
+}
+
+// Options configures Generate.
+type Options struct {
+	SpecSource SpecSource
+	// ResourceType is the Azure resource type, e.g. "Microsoft.App/containerApps",
+	// or an instance path, which may end in the fixed name of a singleton.
+	ResourceType string
+	// APIVersion is the API version to generate. When empty, the latest stable
+	// version is used, or the latest version when IncludePreview is set.
+	APIVersion     string
+	IncludePreview bool
+	Flavor         Flavor
+	// FS receives the generated files. When nil, the files are only returned.
+	FS FS
+}
+
+// Result is the module Generate produced.
+type Result struct {
+	ResourceType string
+	APIVersion   string
+	// Files are the generated files, sorted by name.
+	Files []File
+}
+
+// File is a generated file. Name is slash-separated and relative to the module root.
+type File struct {
+	Name    string
+	Content []byte
+}
+
+// FS is the filesystem Generate writes the module files to.
+type FS interface {
+	// WriteFile writes data to the file name, a slash-separated path relative
+	// to the module root, creating or replacing it.
+	WriteFile(name string, data []byte) error
+}
+
+// DirFS returns an FS writing into dir, creating directories as needed. Files
+// already holding the generated content are not rewritten.
+func DirFS(dir string) FS {
+	return dirFS(dir)
+}
+
+type dirFS string
+
+func (d dirFS) WriteFile(name string, data []byte) error {
+	if !fs.ValidPath(name) {
+		return fmt.Errorf("invalid file name %q", name)
+	}
+	path := filepath.Join(string(d), filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return hclgen.WriteIfChanged(path, data)
+}
+
+// MemFS is an FS holding the files in memory, keyed by name.
+type MemFS map[string][]byte
+
+// WriteFile stores a copy of data under name.
+func (m MemFS) WriteFile(name string, data []byte) error {
+	m[name] = append([]byte(nil), data...)
+	return nil
+}
+
+// Generate loads the schema of opts.ResourceType and generates a module for it,
+// writing the files to opts.FS. The module is always generated from scratch;
+// files of an earlier generation in the FS are replaced, not merged.
+func Generate(ctx context.Context, opts Options) (*Result, error) {
+	if opts.ResourceType == "" {
+		return nil, errors.New("resource type is required")
+	}
+	if opts.Flavor != FlavorBase && opts.Flavor != FlavorAVM {
+		return nil, fmt.Errorf("unknown flavor %d", opts.Flavor)
+	}
+
+	loadOpts := []terraform.LoadOption{terraform.WithIncludePreview(opts.IncludePreview)}
+	if opts.APIVersion != "" {
+		loadOpts = append(loadOpts, terraform.WithAPIVersionLoad(opts.APIVersion))
+	}
+	if opts.SpecSource.TypesPath != "" {
+		loadOpts = append(loadOpts, terraform.WithTypesPath(opts.SpecSource.TypesPath))
+	}
+	if opts.SpecSource.Cache != nil {
+		loadOpts = append(loadOpts, terraform.WithLoadCache(opts.SpecSource.Cache))
+	}
+	rs, err := terraform.LoadResourceSchema(ctx, opts.ResourceType, loadOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("loading resource %s: %w", opts.ResourceType, err)
+	}
+
+	var files []File
+	if opts.Flavor == FlavorBase {
+		files, err = generateBase(opts.ResourceType, rs)
+	} else {
+		files, err = generateAVM(opts.ResourceType, rs)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if opts.FS != nil {
+		for _, f := range files {
+			if err := opts.FS.WriteFile(f.Name, f.Content); err != nil {
+				return nil, fmt.Errorf("writing %s: %w", f.Name, err)
+			}
+		}
+	}
+	return &Result{ResourceType: rs.ResourceType, APIVersion: rs.APIVersion, Files: files}, nil
+}
+
+// ResourceMatch is an index entry of a resource type, as returned by
+// FindResources.
+type ResourceMatch = bicepdata.ResourceMatch
+
+// FindResources returns every API version of resourceType in the index of src,
+// with the types file each is defined in. The type is matched case-insensitively;
+// the index may hold it under several casings, which the matches report, so
+// callers can pick an entry or report the ambiguity themselves.
+func FindResources(ctx context.Context, src SpecSource, resourceType string) ([]ResourceMatch, error) {
+	if resourceType == "" {
+		return nil, errors.New("resource type is required")
+	}
+	idx, err := bicepdata.LoadIndex(ctx, &bicepdata.FetchOptions{LocalPath: src.TypesPath, Cache: src.Cache})
+	if err != nil {
+		return nil, err
+	}
+	matches := bicepdata.FindResources(idx, resourceType)
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("resource type %s %w in index", resourceType, ErrResourceNotFound)
+	}
+	return matches, nil
+}
+
+// generateBase generates the base module in memory.
+func generateBase(resourceType string, rs *schema.ResourceSchema) ([]File, error) {
+	generated := hclgen.MemoryWriter{}
+	if err := terraform.Generate(resourceType, terraform.WithLoadedSchema(rs), terraform.WithWriter(generated)); err != nil {
+		return nil, fmt.Errorf("generating module: %w", err)
+	}
+	files := make([]File, 0, len(generated))
+	for name, content := range generated {
+		files = append(files, File{Name: name, Content: content})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+	return files, nil
+}
+
+// generateAVM generates the AVM module. The interface and layout generators
+// build on the module files on disk, so it is generated in a temporary
+// directory and read back.
+func generateAVM(resourceType string, rs *schema.ResourceSchema) ([]File, error) {
+	dir, err := os.MkdirTemp("", "tfmodmake-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	if err := terraform.Generate(resourceType, terraform.WithLoadedSchema(rs), terraform.WithOutputDir(dir), terraform.WithTelemetry(true)); err != nil {
+		return nil, fmt.Errorf("generating module: %w", err)
+	}
+	if _, err := terraform.GenerateInterfaces(resourceType, rs, dir, nil, nil); err != nil {
+		return nil, fmt.Errorf("generating AVM interfaces: %w", err)
+	}
+	if _, err := terraform.ScaffoldAVMLayout(dir, nil); err != nil {
+		return nil, fmt.Errorf("scaffolding AVM layout: %w", err)
+	}
+	return readFiles(dir)
+}
+
+// readFiles returns every file below root, sorted by name.
+func readFiles(root string) ([]File, error) {
+	var files []File
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, File{Name: filepath.ToSlash(rel), Content: content})
+		return nil
+	})
+	sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+	return files, err
+}
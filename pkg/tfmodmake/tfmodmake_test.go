@@ -0,0 +1,100 @@
+package tfmodmake
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/matt-FFFFFF/tfmodmake/internal/typestest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func fileNames(files []File) []string {
+	names := make([]string, len(files))
+	for i, f := range files {
+		names[i] = f.Name
+	}
+	return names
+}
+
+func TestGenerate_Base(t *testing.T) {
+	fs := MemFS{}
+	result, err := Generate(context.Background(), Options{
+		SpecSource:   SpecSource{TypesPath: typestest.Write(t, typestest.Widgets)},
+		ResourceType: "Microsoft.Test/widgets",
+		FS:           fs,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "Microsoft.Test/widgets", result.ResourceType)
+	assert.Equal(t, "2024-01-01", result.APIVersion)
+	names := fileNames(result.Files)
+	assert.IsIncreasing(t, names)
+	assert.Subset(t, names, []string{"locals.tf", "main.tf", "outputs.tf", "terraform.tf", "variables.tf"})
+	assert.NotContains(t, names, "examples/default/main.tf")
+
+	require.Len(t, fs, len(result.Files))
+	for _, f := range result.Files {
+		assert.Equal(t, f.Content, fs[f.Name])
+	}
+	assert.Contains(t, string(fs["main.tf"]), "Microsoft.Test/widgets@2024-01-01")
+	assert.Contains(t, string(fs["variables.tf"]), `variable "color"`)
+}
+
+func TestGenerate_AVM(t *testing.T) {
+	dir := t.TempDir()
+	result, err := Generate(context.Background(), Options{
+		SpecSource:   SpecSource{TypesPath: typestest.Write(t, typestest.Widgets)},
+		ResourceType: "Microsoft.Test/widgets",
+		APIVersion:   "2024-01-01",
+		Flavor:       FlavorAVM,
+		FS:           DirFS(dir),
+	})
+	require.NoError(t, err)
+
+	names := fileNames(result.Files)
+	assert.Subset(t, names, []string{"main.tf", "main.telemetry.tf", "main.lock.tf", "examples/default/main.tf"})
+	for _, name := range names {
+		assert.FileExists(t, filepath.Join(dir, filepath.FromSlash(name)))
+	}
+}
+
+func TestGenerate_Errors(t *testing.T) {
+	_, err := Generate(context.Background(), Options{})
+	assert.ErrorContains(t, err, "resource type is required")
+
+	_, err = Generate(context.Background(), Options{ResourceType: "Microsoft.Test/widgets", Flavor: Flavor(7)})
+	assert.ErrorContains(t, err, "unknown flavor 7")
+
+	_, err = Generate(context.Background(), Options{
+		SpecSource:   SpecSource{TypesPath: typestest.Write(t, typestest.Widgets)},
+		ResourceType: "Microsoft.Test/missing",
+	})
+	assert.ErrorContains(t, err, "loading resource Microsoft.Test/missing")
+	assert.ErrorIs(t, err, ErrResourceNotFound)
+
+	_, err = Generate(context.Background(), Options{
+		SpecSource:   SpecSource{TypesPath: t.TempDir()},
+		ResourceType: "Microsoft.Test/widgets",
+	})
+	assert.ErrorIs(t, err, ErrSpecFetch)
+
+	assert.ErrorContains(t, DirFS(t.TempDir()).WriteFile("../escape.tf", nil), "invalid file name")
+}
+
+func TestFindResources(t *testing.T) {
+	src := SpecSource{TypesPath: typestest.Write(t, typestest.Widgets)}
+
+	matches, err := FindResources(context.Background(), src, "microsoft.test/widgets")
+	require.NoError(t, err)
+	assert.Equal(t, []ResourceMatch{{
+		ResourceType: "Microsoft.Test/widgets",
+		APIVersion:   "2024-01-01",
+		RelativePath: "microsoft.test/2024-01-01/types.json",
+		Ref:          3,
+	}}, matches)
+
+	_, err = FindResources(context.Background(), src, "Microsoft.Test/gadgets")
+	assert.ErrorIs(t, err, ErrResourceNotFound)
+}
@@ -0,0 +1,100 @@
+// Package profile records how long the phases of a generation take, for the
+// -profile flag. Phases are recorded on a Timings carried by the context or
+// passed to the generator; a nil Timings records nothing.
+package profile
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// Phases of a generation.
+const (
+	PhaseFetch     = "fetch"
+	PhaseParse     = "parse"
+	PhaseConvert   = "convert"
+	PhaseVariables = "variables"
+	PhaseLocals    = "locals"
+	PhaseMain      = "main"
+	PhaseOutputs   = "outputs"
+	PhaseWrite     = "write"
+)
+
+// Phase is the time spent in a phase, summed over every time it ran.
+type Phase struct {
+	Name     string
+	Duration time.Duration
+	Count    int
+}
+
+// Timings accumulates the duration of each phase. It is safe for concurrent
+// use; phases run concurrently, e.g. child schemas loaded in parallel, add up,
+// so their sum can exceed the elapsed time.
+type Timings struct {
+	mu     sync.Mutex
+	phases []Phase
+}
+
+// Track starts timing phase and returns the function that stops it.
+func (t *Timings) Track(phase string) func() {
+	if t == nil {
+		return func() {}
+	}
+	start := time.Now()
+	return func() { t.Add(phase, time.Since(start)) }
+}
+
+// Add records d spent in phase.
+func (t *Timings) Add(phase string, d time.Duration) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for i := range t.phases {
+		if t.phases[i].Name == phase {
+			t.phases[i].Duration += d
+			t.phases[i].Count++
+			return
+		}
+	}
+	t.phases = append(t.phases, Phase{Name: phase, Duration: d, Count: 1})
+}
+
+// Phases returns the recorded phases in the order they first ran.
+func (t *Timings) Phases() []Phase {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]Phase(nil), t.phases...)
+}
+
+// Write prints the phases as a table, followed by the elapsed time.
+func (t *Timings) Write(w io.Writer, elapsed time.Duration) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "phase\ttime\truns\t")
+	for _, p := range t.Phases() {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t\n", p.Name, p.Duration.Round(time.Microsecond), p.Count)
+	}
+	fmt.Fprintf(tw, "elapsed\t%s\t\t\n", elapsed.Round(time.Microsecond))
+	return tw.Flush()
+}
+
+type contextKey struct{}
+
+// NewContext returns a context carrying t.
+func NewContext(ctx context.Context, t *Timings) context.Context {
+	return context.WithValue(ctx, contextKey{}, t)
+}
+
+// FromContext returns the Timings carried by ctx, or nil.
+func FromContext(ctx context.Context) *Timings {
+	t, _ := ctx.Value(contextKey{}).(*Timings)
+	return t
+}
@@ -0,0 +1,56 @@
+package profile
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimings_Nil(t *testing.T) {
+	var timings *Timings
+	timings.Track(PhaseFetch)()
+	timings.Add(PhaseParse, time.Second)
+	assert.Nil(t, timings.Phases())
+	assert.Nil(t, FromContext(context.Background()))
+}
+
+func TestTimings_Add(t *testing.T) {
+	timings := &Timings{}
+	timings.Add(PhaseParse, time.Millisecond)
+	timings.Add(PhaseFetch, 2*time.Millisecond)
+	timings.Add(PhaseParse, 3*time.Millisecond)
+	timings.Track(PhaseWrite)()
+
+	phases := timings.Phases()
+	require.Len(t, phases, 3)
+	assert.Equal(t, Phase{Name: PhaseParse, Duration: 4 * time.Millisecond, Count: 2}, phases[0])
+	assert.Equal(t, Phase{Name: PhaseFetch, Duration: 2 * time.Millisecond, Count: 1}, phases[1])
+	assert.Equal(t, PhaseWrite, phases[2].Name)
+	assert.Equal(t, 1, phases[2].Count)
+}
+
+func TestTimings_Context(t *testing.T) {
+	timings := &Timings{}
+	ctx := NewContext(context.Background(), timings)
+	assert.Same(t, timings, FromContext(ctx))
+}
+
+func TestTimings_Write(t *testing.T) {
+	timings := &Timings{}
+	timings.Add(PhaseFetch, 1500*time.Microsecond)
+	timings.Add(PhaseVariables, 250*time.Microsecond)
+	timings.Add(PhaseVariables, 250*time.Microsecond)
+
+	var buf bytes.Buffer
+	require.NoError(t, timings.Write(&buf, 3*time.Millisecond))
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	require.Len(t, lines, 4)
+	assert.Regexp(t, `^\s*phase\s+time\s+runs\s*$`, string(lines[0]))
+	assert.Regexp(t, `^\s*fetch\s+1\.5ms\s+1\s*$`, string(lines[1]))
+	assert.Regexp(t, `^\s*variables\s+500µs\s+2\s*$`, string(lines[2]))
+	assert.Regexp(t, `^\s*elapsed\s+3ms\s*$`, string(lines[3]))
+}
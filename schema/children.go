@@ -1,7 +1,14 @@
 package schema
 
 import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
 	"github.com/Azure/bicep-types/src/bicep-types-go/index"
+	"github.com/Azure/bicep-types/src/bicep-types-go/types"
 	"github.com/matt-FFFFFF/tfmodmake/bicepdata"
 )
 
@@ -12,20 +19,127 @@ type ChildResource struct {
 
 	// APIVersions is the list of available API versions for this child resource.
 	APIVersions []string
+
+	// APIVersion is the API version selected for the child by SelectAPIVersions.
+	APIVersion string
+
+	// Depth is the number of levels below the parent, 1 for direct children.
+	Depth int
+
+	// ScopeResource reports whether the child is an extension resource, whose
+	// PUT path is rooted at the scope of another resource rather than nested in
+	// its parent. It is set by MarkScopeResources.
+	ScopeResource bool
 }
 
 // DiscoverChildren finds child resources of a given parent resource type from the index.
 // The depth parameter controls how many levels of nesting to include (1 = direct children only, 0 = unlimited).
 func DiscoverChildren(idx *index.TypeIndex, parentType string, depth int) []ChildResource {
 	entries := bicepdata.ListChildren(idx, parentType, depth)
+	parentSegments := strings.Count(parentType, "/")
 
 	result := make([]ChildResource, len(entries))
 	for i, entry := range entries {
 		result[i] = ChildResource{
 			ResourceType: entry.ResourceType,
 			APIVersions:  entry.APIVersions,
+			Depth:        strings.Count(entry.ResourceType, "/") - parentSegments,
 		}
 	}
 
 	return result
 }
+
+// SortChildren orders children as a tree: every resource type is followed by its
+// own descendants, and siblings are sorted case-insensitively.
+func SortChildren(children []ChildResource) {
+	sort.Slice(children, func(i, j int) bool {
+		a := strings.Split(strings.ToLower(children[i].ResourceType), "/")
+		b := strings.Split(strings.ToLower(children[j].ResourceType), "/")
+		for k := 0; k < len(a) && k < len(b); k++ {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+		return len(a) < len(b)
+	})
+}
+
+// SelectAPIVersions sets the API version of each child to its latest stable
+// version or, when includePreview is true, to its latest version overall. The
+// child's own versions are used, so a child defined in a different or newer
+// spec than its parent is still found. A child whose API version is already set
+// keeps it. Children without a stable version are returned separately unless
+// includePreview is true.
+func SelectAPIVersions(children []ChildResource, includePreview bool) (selected, previewOnly []ChildResource) {
+	for _, child := range children {
+		if child.APIVersion == "" {
+			child.APIVersion = bicepdata.PreferredVersion(child.APIVersions, includePreview)
+		}
+		if child.APIVersion == "" {
+			previewOnly = append(previewOnly, child)
+			continue
+		}
+		selected = append(selected, child)
+	}
+	return selected, previewOnly
+}
+
+// FilterChildren keeps the children whose last type segment matches one of the
+// include glob patterns, or every child when include is empty, and drops those
+// matching one of the exclude patterns. Patterns use path.Match syntax and match
+// case-insensitively. A descendant is dropped along with its parent, so include
+// patterns must also match the segments of the levels above it.
+func FilterChildren(children []ChildResource, include, exclude []string) ([]ChildResource, error) {
+	for _, pattern := range append(append([]string{}, include...), exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid child filter %q: %w", pattern, err)
+		}
+	}
+	matches := func(patterns []string, segment string) bool {
+		for _, pattern := range patterns {
+			if ok, _ := path.Match(strings.ToLower(pattern), segment); ok {
+				return true
+			}
+		}
+		return false
+	}
+
+	dropped := map[string]bool{}
+	for _, child := range children {
+		resourceType := strings.ToLower(child.ResourceType)
+		segment := resourceType[strings.LastIndex(resourceType, "/")+1:]
+		if (len(include) > 0 && !matches(include, segment)) || matches(exclude, segment) {
+			dropped[resourceType] = true
+		}
+	}
+
+	var kept []ChildResource
+	for _, child := range children {
+		keep := true
+		for resourceType := strings.ToLower(child.ResourceType); keep && strings.Contains(resourceType, "/"); resourceType = resourceType[:strings.LastIndex(resourceType, "/")] {
+			keep = !dropped[resourceType]
+		}
+		if keep {
+			kept = append(kept, child)
+		}
+	}
+	return kept, nil
+}
+
+// MarkScopeResources sets ScopeResource on the children whose selected API
+// version is deployable at extension scope. It loads the resource type of every
+// child with an API version, so opts should carry a cache.
+func MarkScopeResources(ctx context.Context, idx *index.TypeIndex, children []ChildResource, opts *bicepdata.FetchOptions) error {
+	for i := range children {
+		if children[i].APIVersion == "" {
+			continue
+		}
+		loaded, err := bicepdata.LoadResourceFromIndex(ctx, idx, children[i].ResourceType, children[i].APIVersion, false, opts)
+		if err != nil {
+			return fmt.Errorf("loading resource %s@%s: %w", children[i].ResourceType, children[i].APIVersion, err)
+		}
+		children[i].ScopeResource = loaded.ResourceType.WritableScopes&types.ScopeTypeExtension != 0
+	}
+	return nil
+}
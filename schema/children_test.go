@@ -1,10 +1,15 @@
 package schema
 
 import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/Azure/bicep-types/src/bicep-types-go/index"
 	"github.com/Azure/bicep-types/src/bicep-types-go/types"
+	"github.com/matt-FFFFFF/tfmodmake/bicepdata"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -102,4 +107,171 @@ func TestDiscoverChildren(t *testing.T) {
 		assert.False(t, childTypes["Microsoft.Test/resources/child/grandchild/greatgrandchild"])
 		assert.Len(t, children, 2)
 	})
+
+	t.Run("reports the depth of each descendant", func(t *testing.T) {
+		idx := index.NewTypeIndex()
+		idx.AddResource("Microsoft.Test/resources", "2023-01-01", types.CrossFileTypeReference{Ref: 0, RelativePath: "types.json"})
+		idx.AddResource("Microsoft.Test/resources/child", "2023-01-01", types.CrossFileTypeReference{Ref: 1, RelativePath: "types.json"})
+		idx.AddResource("Microsoft.Test/resources/child/grandchild", "2023-01-01", types.CrossFileTypeReference{Ref: 2, RelativePath: "types.json"})
+
+		children := DiscoverChildren(idx, "Microsoft.Test/resources", 0)
+		SortChildren(children)
+
+		require.Len(t, children, 2)
+		assert.Equal(t, 1, children[0].Depth)
+		assert.Equal(t, 2, children[1].Depth)
+	})
+}
+
+func TestSortChildren(t *testing.T) {
+	children := []ChildResource{
+		{ResourceType: "Microsoft.Test/resources/child-b"},
+		{ResourceType: "Microsoft.Test/resources/child/grandchild"},
+		{ResourceType: "Microsoft.Test/resources/Child"},
+		{ResourceType: "Microsoft.Test/resources/child/grandchild/greatgrandchild"},
+		{ResourceType: "Microsoft.Test/resources/child/another"},
+	}
+
+	SortChildren(children)
+
+	var got []string
+	for _, c := range children {
+		got = append(got, c.ResourceType)
+	}
+	assert.Equal(t, []string{
+		"Microsoft.Test/resources/Child",
+		"Microsoft.Test/resources/child/another",
+		"Microsoft.Test/resources/child/grandchild",
+		"Microsoft.Test/resources/child/grandchild/greatgrandchild",
+		"Microsoft.Test/resources/child-b",
+	}, got)
+}
+
+func TestSelectAPIVersions(t *testing.T) {
+	children := []ChildResource{
+		{ResourceType: "Microsoft.Test/resources/stable", APIVersions: []string{"2023-01-01", "2024-01-01", "2025-01-01-preview"}},
+		{ResourceType: "Microsoft.Test/resources/previewOnly", APIVersions: []string{"2024-01-01-preview"}},
+	}
+
+	t.Run("prefers stable and sets preview-only children aside", func(t *testing.T) {
+		selected, previewOnly := SelectAPIVersions(children, false)
+
+		require.Len(t, selected, 1)
+		assert.Equal(t, "Microsoft.Test/resources/stable", selected[0].ResourceType)
+		assert.Equal(t, "2024-01-01", selected[0].APIVersion)
+		require.Len(t, previewOnly, 1)
+		assert.Equal(t, "Microsoft.Test/resources/previewOnly", previewOnly[0].ResourceType)
+	})
+
+	t.Run("include preview selects the latest version of every child", func(t *testing.T) {
+		selected, previewOnly := SelectAPIVersions(children, true)
+
+		require.Len(t, selected, 2)
+		assert.Equal(t, "2025-01-01-preview", selected[0].APIVersion)
+		assert.Equal(t, "2024-01-01-preview", selected[1].APIVersion)
+		assert.Empty(t, previewOnly)
+	})
+
+	t.Run("keeps pinned versions", func(t *testing.T) {
+		pinned := []ChildResource{
+			{ResourceType: "Microsoft.Test/resources/previewOnly", APIVersions: []string{"2024-01-01-preview"}, APIVersion: "2024-01-01-preview"},
+		}
+		selected, previewOnly := SelectAPIVersions(pinned, false)
+
+		require.Len(t, selected, 1)
+		assert.Equal(t, "2024-01-01-preview", selected[0].APIVersion)
+		assert.Empty(t, previewOnly)
+	})
+}
+
+func TestFilterChildren(t *testing.T) {
+	children := []ChildResource{
+		{ResourceType: "Microsoft.Test/resources/diagnosticSettings/rules"},
+		{ResourceType: "Microsoft.Test/resources/diagnosticSettings"},
+		{ResourceType: "Microsoft.Test/resources/storages"},
+		{ResourceType: "Microsoft.Test/resources/storages/shares"},
+		{ResourceType: "Microsoft.Test/resources/certificates"},
+	}
+	resourceTypes := func(children []ChildResource) []string {
+		var got []string
+		for _, c := range children {
+			got = append(got, c.ResourceType)
+		}
+		return got
+	}
+
+	t.Run("no filters keeps every child", func(t *testing.T) {
+		kept, err := FilterChildren(children, nil, nil)
+		require.NoError(t, err)
+		assert.Equal(t, children, kept)
+	})
+
+	t.Run("exclude drops matching children and their descendants", func(t *testing.T) {
+		kept, err := FilterChildren(children, nil, []string{"Diagnostic*"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			"Microsoft.Test/resources/storages",
+			"Microsoft.Test/resources/storages/shares",
+			"Microsoft.Test/resources/certificates",
+		}, resourceTypes(kept))
+	})
+
+	t.Run("include keeps only matching segments at every level", func(t *testing.T) {
+		kept, err := FilterChildren(children, []string{"storages", "shares", "rules"}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			"Microsoft.Test/resources/storages",
+			"Microsoft.Test/resources/storages/shares",
+		}, resourceTypes(kept))
+	})
+
+	t.Run("exclude wins over include", func(t *testing.T) {
+		kept, err := FilterChildren(children, []string{"*"}, []string{"shares"})
+		require.NoError(t, err)
+		assert.NotContains(t, resourceTypes(kept), "Microsoft.Test/resources/storages/shares")
+		assert.Len(t, kept, 4)
+	})
+
+	t.Run("rejects malformed patterns", func(t *testing.T) {
+		_, err := FilterChildren(children, []string{"[storages"}, nil)
+		assert.ErrorContains(t, err, `invalid child filter "[storages"`)
+	})
+}
+
+func TestMarkScopeResources(t *testing.T) {
+	dir := t.TempDir()
+	entries := []types.Type{
+		&types.StringType{},
+		&types.ObjectType{Name: "body", Properties: map[string]types.ObjectTypeProperty{
+			"name": {Type: types.TypeReference{Ref: 0}, Flags: types.TypePropertyFlagsRequired},
+		}},
+		&types.ResourceType{Name: "Microsoft.Test/resources/settings@2023-01-01", Body: types.TypeReference{Ref: 1}, WritableScopes: types.ScopeTypeResourceGroup},
+		&types.ResourceType{Name: "Microsoft.Test/resources/tags@2023-01-01", Body: types.TypeReference{Ref: 1}, WritableScopes: types.ScopeTypeExtension},
+	}
+	parts := make([]json.RawMessage, len(entries))
+	for i, entry := range entries {
+		data, err := entry.MarshalJSON()
+		require.NoError(t, err)
+		parts[i] = data
+	}
+	typesData, err := json.Marshal(parts)
+	require.NoError(t, err)
+	versionDir := filepath.Join(dir, "generated", "microsoft.test", "2023-01-01")
+	require.NoError(t, os.MkdirAll(versionDir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(versionDir, "types.json"), typesData, 0o644))
+
+	idx := index.NewTypeIndex()
+	idx.AddResource("Microsoft.Test/resources/settings", "2023-01-01", types.CrossFileTypeReference{Ref: 2, RelativePath: "microsoft.test/2023-01-01/types.json"})
+	idx.AddResource("Microsoft.Test/resources/tags", "2023-01-01", types.CrossFileTypeReference{Ref: 3, RelativePath: "microsoft.test/2023-01-01/types.json"})
+
+	children := []ChildResource{
+		{ResourceType: "Microsoft.Test/resources/settings", APIVersion: "2023-01-01"},
+		{ResourceType: "Microsoft.Test/resources/tags", APIVersion: "2023-01-01"},
+		{ResourceType: "Microsoft.Test/resources/previews"},
+	}
+	opts := &bicepdata.FetchOptions{LocalPath: dir, Cache: bicepdata.NewCache()}
+	require.NoError(t, MarkScopeResources(context.Background(), idx, children, opts))
+	assert.False(t, children[0].ScopeResource)
+	assert.True(t, children[1].ScopeResource)
+	assert.False(t, children[2].ScopeResource, "children without an API version are not loaded")
 }
@@ -1,12 +1,19 @@
 package schema
 
 import (
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/Azure/bicep-types/src/bicep-types-go/types"
 	"github.com/matt-FFFFFF/tfmodmake/bicepdata"
 )
 
+// ErrUnsupportedConstruct is matched by the errors reporting a resource type or
+// type definition that tfmodmake cannot generate a module for.
+var ErrUnsupportedConstruct = errors.New("unsupported construct")
+
 // ConvertResource converts a loaded bicep-types resource into the internal ResourceSchema.
 func ConvertResource(loaded *bicepdata.LoadedResource) (*ResourceSchema, error) {
 	if loaded.ResourceType.Body == nil {
@@ -21,6 +28,7 @@ func ConvertResource(loaded *bicepdata.LoadedResource) (*ResourceSchema, error)
 	c := &converter{
 		loaded:  loaded,
 		visited: make(map[int]bool),
+		objects: make(map[int]convertedObject),
 	}
 
 	properties, err := c.convertBodyType(bodyType)
@@ -29,9 +37,16 @@ func ConvertResource(loaded *bicepdata.LoadedResource) (*ResourceSchema, error)
 	}
 
 	rs := &ResourceSchema{
-		Properties:   properties,
-		ResourceType: loaded.ResourceTypeName,
-		APIVersion:   loaded.APIVersion,
+		Properties:     properties,
+		ResourceType:   loaded.ResourceTypeName,
+		APIVersion:     loaded.APIVersion,
+		ReadableScopes: loaded.ResourceType.ReadableScopes,
+		WritableScopes: loaded.ResourceType.WritableScopes,
+	}
+
+	rs.Functions, err = c.convertFunctions()
+	if err != nil {
+		return nil, fmt.Errorf("converting actions for %s: %w", loaded.ResourceTypeName, err)
 	}
 
 	// Detect capabilities
@@ -39,6 +54,18 @@ func ConvertResource(loaded *bicepdata.LoadedResource) (*ResourceSchema, error)
 	rs.SupportsLocation = detectSupportsLocation(rs)
 	rs.SupportsIdentity = detectSupportsIdentity(rs)
 
+	// A resource requested by a path ending in a static name must be the
+	// singleton of that name.
+	if loaded.SingletonName != "" {
+		name, ok := rs.SingletonName()
+		if !ok {
+			return nil, fmt.Errorf("resource %s@%s named %q %w: the resource type is not a singleton", rs.ResourceType, rs.APIVersion, loaded.SingletonName, bicepdata.ErrResourceNotFound)
+		}
+		if !strings.EqualFold(name, loaded.SingletonName) {
+			return nil, fmt.Errorf("resource %s@%s named %q %w: the only instance is named %q", rs.ResourceType, rs.APIVersion, loaded.SingletonName, bicepdata.ErrResourceNotFound, name)
+		}
+	}
+
 	return rs, nil
 }
 
@@ -46,6 +73,50 @@ func ConvertResource(loaded *bicepdata.LoadedResource) (*ResourceSchema, error)
 type converter struct {
 	loaded  *bicepdata.LoadedResource
 	visited map[int]bool // cycle detection: type index -> visited
+	// objects memoizes the converted object types by type index, so shared
+	// definitions such as SKUs, identities and system data are converted once
+	// and their properties shared by every property of that type.
+	objects map[int]convertedObject
+	// cycles counts the cycles broken so far. An object whose conversion broke
+	// a cycle is incomplete in a way that depends on where it was reached, so
+	// it is not memoized.
+	cycles int
+}
+
+// convertedObject is the part of a converted object type that depends only on
+// the type, not on the property holding it.
+type convertedObject struct {
+	children             map[string]*Property
+	additionalProperties *Property
+}
+
+// convertFunctions converts the resource functions of the loaded resource type and
+// API version found in its types.json.
+func (c *converter) convertFunctions() ([]*ResourceFunction, error) {
+	var functions []*ResourceFunction
+	for _, t := range c.loaded.Types {
+		rf, ok := t.(*types.ResourceFunctionType)
+		if !ok || !strings.EqualFold(rf.ResourceType, c.loaded.ResourceTypeName) || !strings.EqualFold(rf.ApiVersion, c.loaded.APIVersion) {
+			continue
+		}
+		fn := &ResourceFunction{Name: rf.Name, APIVersion: rf.ApiVersion, Output: &Property{Name: rf.Name}}
+		if rf.Output != nil {
+			if err := c.resolvePropertyType(fn.Output, rf.Output); err != nil {
+				return nil, fmt.Errorf("converting output of %s: %w", rf.Name, err)
+			}
+		} else {
+			fn.Output.Type = TypeAny
+		}
+		if rf.Input != nil {
+			fn.Input = &Property{Name: rf.Name}
+			if err := c.resolvePropertyType(fn.Input, rf.Input); err != nil {
+				return nil, fmt.Errorf("converting input of %s: %w", rf.Name, err)
+			}
+		}
+		functions = append(functions, fn)
+	}
+	sort.Slice(functions, func(i, j int) bool { return functions[i].Name < functions[j].Name })
+	return functions, nil
 }
 
 // convertBodyType converts the resource body (ObjectType or DiscriminatedObjectType) into a property map.
@@ -56,7 +127,7 @@ func (c *converter) convertBodyType(bodyType types.Type) (map[string]*Property,
 	case *types.DiscriminatedObjectType:
 		return c.convertDiscriminatedObject(bt)
 	default:
-		return nil, fmt.Errorf("unexpected body type %T (expected ObjectType or DiscriminatedObjectType)", bodyType)
+		return nil, fmt.Errorf("%w: unexpected body type %T (expected ObjectType or DiscriminatedObjectType)", ErrUnsupportedConstruct, bodyType)
 	}
 }
 
@@ -155,16 +226,27 @@ func (c *converter) applyResolvedType(prop *Property, resolved types.Type, ref t
 
 	case *types.ObjectType:
 		prop.Type = TypeObject
-		// Cycle detection
+		// Check object-level sensitivity
+		if t.Sensitive != nil && *t.Sensitive {
+			prop.Sensitive = true
+		}
 		idx := typeRefIndex(ref)
+		if cached, ok := c.objects[idx]; ok {
+			prop.Children = cached.children
+			prop.AdditionalProperties = cached.additionalProperties
+			return nil
+		}
+		// Cycle detection
 		if idx >= 0 {
 			if c.visited[idx] {
 				// Cycle detected, leave children empty
+				c.cycles++
 				return nil
 			}
 			c.visited[idx] = true
 			defer delete(c.visited, idx)
 		}
+		cycles := c.cycles
 		children, err := c.convertObjectProperties(t.Properties)
 		if err != nil {
 			return fmt.Errorf("converting nested object properties: %w", err)
@@ -179,29 +261,36 @@ func (c *converter) applyResolvedType(prop *Property, resolved types.Type, ref t
 			}
 			prop.AdditionalProperties = addProp
 		}
-
-		// Check object-level sensitivity
-		if t.Sensitive != nil && *t.Sensitive {
-			prop.Sensitive = true
+		if idx >= 0 && c.cycles == cycles {
+			c.objects[idx] = convertedObject{children: prop.Children, additionalProperties: prop.AdditionalProperties}
 		}
 
 	case *types.DiscriminatedObjectType:
 		prop.Type = TypeObject
 		prop.Discriminator = t.Discriminator
-		// Cycle detection
 		idx := typeRefIndex(ref)
+		if cached, ok := c.objects[idx]; ok {
+			prop.Children = cached.children
+			return nil
+		}
+		// Cycle detection
 		if idx >= 0 {
 			if c.visited[idx] {
+				c.cycles++
 				return nil
 			}
 			c.visited[idx] = true
 			defer delete(c.visited, idx)
 		}
+		cycles := c.cycles
 		children, err := c.convertDiscriminatedObject(t)
 		if err != nil {
 			return fmt.Errorf("converting discriminated object: %w", err)
 		}
 		prop.Children = children
+		if idx >= 0 && c.cycles == cycles {
+			c.objects[idx] = convertedObject{children: children}
+		}
 
 	case *types.UnionType:
 		// Check if it's a string enum (union of StringLiteralType)
@@ -253,11 +342,14 @@ func (c *converter) convertDiscriminatedObject(dot *types.DiscriminatedObjectTyp
 		result[name] = prop
 	}
 
-	// Add discriminator as a required string enum property
-	var discriminatorValues []string
+	// Add discriminator as a required string enum property. Variants are
+	// visited in the order of their values, so a property declared by several
+	// variants is always taken from the same one.
+	discriminatorValues := make([]string, 0, len(dot.Elements))
 	for value := range dot.Elements {
 		discriminatorValues = append(discriminatorValues, value)
 	}
+	sort.Strings(discriminatorValues)
 	result[dot.Discriminator] = &Property{
 		Name:     dot.Discriminator,
 		Type:     TypeString,
@@ -266,8 +358,8 @@ func (c *converter) convertDiscriminatedObject(dot *types.DiscriminatedObjectTyp
 	}
 
 	// Merge properties from all variants
-	for _, elementRef := range dot.Elements {
-		resolved, err := c.loaded.ResolveType(elementRef)
+	for _, value := range discriminatorValues {
+		resolved, err := c.loaded.ResolveType(dot.Elements[value])
 		if err != nil {
 			continue // Skip unresolvable variants
 		}
@@ -275,8 +367,12 @@ func (c *converter) convertDiscriminatedObject(dot *types.DiscriminatedObjectTyp
 		switch variant := resolved.(type) {
 		case *types.ObjectType:
 			for name, objProp := range variant.Properties {
-				if _, exists := result[name]; exists {
-					continue // Don't override existing properties (base or discriminator)
+				if existing, exists := result[name]; exists {
+					// Don't override existing properties (base or discriminator)
+					if existing.Variants != nil {
+						existing.Variants = append(existing.Variants, value)
+					}
+					continue
 				}
 				prop, err := c.convertObjectProperty(name, objProp)
 				if err != nil {
@@ -284,6 +380,7 @@ func (c *converter) convertDiscriminatedObject(dot *types.DiscriminatedObjectTyp
 				}
 				// Variant properties are not required (they're conditional on the discriminator value)
 				prop.Required = false
+				prop.Variants = []string{value}
 				result[name] = prop
 			}
 		}
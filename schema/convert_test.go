@@ -327,6 +327,12 @@ func TestConvertResource_DiscriminatedObject(t *testing.T) {
 	require.NotNil(t, bProp)
 	assert.Equal(t, TypeInteger, bProp.Type)
 	assert.False(t, bProp.Required)
+
+	// Variant properties record the variants declaring them
+	assert.Equal(t, []string{"A"}, aProp.Variants)
+	assert.Equal(t, []string{"B"}, bProp.Variants)
+	assert.Nil(t, nameProp.Variants)
+	assert.Nil(t, kindProp.Variants)
 }
 
 func TestConvertResource_UnionTypeStringEnum(t *testing.T) {
@@ -1236,6 +1242,45 @@ func TestConvertResource_StringLiteralType(t *testing.T) {
 	assert.Equal(t, []string{"fixedValue"}, constProp.Enum)
 }
 
+func TestConvertResource_SingletonName(t *testing.T) {
+	newLoaded := func(singletonName string, name types.Type) *bicepdata.LoadedResource {
+		return &bicepdata.LoadedResource{
+			ResourceType: &types.ResourceType{
+				Name: "Microsoft.Test/servers/settings@2023-01-01",
+				Body: &types.TypeReference{Ref: 1},
+			},
+			Types: []types.Type{
+				name, // 0
+				&types.ObjectType{ // 1
+					Name: "Microsoft.Test/servers/settings",
+					Properties: map[string]types.ObjectTypeProperty{
+						"name": {Type: &types.TypeReference{Ref: 0}, Flags: types.TypePropertyFlagsRequired},
+					},
+				},
+			},
+			APIVersion:       "2023-01-01",
+			ResourceTypeName: "Microsoft.Test/servers/settings",
+			SingletonName:    singletonName,
+		}
+	}
+
+	rs, err := ConvertResource(newLoaded("Default", &types.StringLiteralType{Value: "default"}))
+	require.NoError(t, err)
+	name, ok := rs.SingletonName()
+	assert.True(t, ok)
+	assert.Equal(t, "default", name)
+
+	_, err = ConvertResource(newLoaded("current", &types.StringLiteralType{Value: "default"}))
+	require.Error(t, err)
+	assert.ErrorIs(t, err, bicepdata.ErrResourceNotFound)
+	assert.Contains(t, err.Error(), `the only instance is named "default"`)
+
+	_, err = ConvertResource(newLoaded("default", &types.StringType{}))
+	require.Error(t, err)
+	assert.ErrorIs(t, err, bicepdata.ErrResourceNotFound)
+	assert.Contains(t, err.Error(), "not a singleton")
+}
+
 func TestConvertResource_NestedDiscriminatedObject(t *testing.T) {
 	// A property (not body) that is a DiscriminatedObjectType
 	// Types array:
@@ -1496,3 +1541,186 @@ func TestProperty_IsContainer(t *testing.T) {
 		})
 	}
 }
+
+func TestConvertResource_Functions(t *testing.T) {
+	// Types array:
+	// 0: StringType
+	// 1: ObjectType (body)
+	// 2: ObjectType (listKeys response)
+	// 3: ResourceFunctionType listKeys
+	// 4: ResourceFunctionType listKeys of another API version
+	// 5: ObjectType (regenerateKey request)
+	// 6: ResourceFunctionType regenerateKey
+	loaded := &bicepdata.LoadedResource{
+		ResourceType: &types.ResourceType{
+			Name: "Microsoft.Test/vaults@2023-01-01",
+			Body: &types.TypeReference{Ref: 1},
+		},
+		Types: []types.Type{
+			&types.StringType{Sensitive: true}, // 0
+			&types.ObjectType{ // 1
+				Properties: map[string]types.ObjectTypeProperty{
+					"name": {Type: &types.TypeReference{Ref: 0}, Flags: types.TypePropertyFlagsRequired},
+				},
+			},
+			&types.ObjectType{ // 2
+				Properties: map[string]types.ObjectTypeProperty{
+					"primaryKey": {Type: &types.TypeReference{Ref: 0}, Flags: types.TypePropertyFlagsReadOnly, Description: "The primary key"},
+				},
+			},
+			&types.ResourceFunctionType{Name: "listKeys", ResourceType: "Microsoft.Test/vaults", ApiVersion: "2023-01-01", Output: &types.TypeReference{Ref: 2}},                                           // 3
+			&types.ResourceFunctionType{Name: "listKeys", ResourceType: "Microsoft.Test/vaults", ApiVersion: "2022-01-01", Output: &types.TypeReference{Ref: 2}},                                           // 4
+			&types.ObjectType{Properties: map[string]types.ObjectTypeProperty{"keyType": {Type: &types.TypeReference{Ref: 0}, Flags: types.TypePropertyFlagsRequired}}},                                    // 5
+			&types.ResourceFunctionType{Name: "regenerateKey", ResourceType: "Microsoft.Test/vaults", ApiVersion: "2023-01-01", Output: &types.TypeReference{Ref: 2}, Input: &types.TypeReference{Ref: 5}}, // 6
+		},
+		APIVersion:       "2023-01-01",
+		ResourceTypeName: "Microsoft.Test/vaults",
+	}
+
+	rs, err := ConvertResource(loaded)
+	require.NoError(t, err)
+	require.Len(t, rs.Functions, 2)
+
+	listKeys := rs.Function("ListKeys")
+	require.NotNil(t, listKeys)
+	assert.Nil(t, listKeys.Input)
+	assert.Equal(t, TypeObject, listKeys.Output.Type)
+	require.Contains(t, listKeys.Output.Children, "primaryKey")
+	assert.True(t, listKeys.Output.Children["primaryKey"].Sensitive)
+
+	regenerate := rs.Function("regenerateKey")
+	require.NotNil(t, regenerate)
+	require.NotNil(t, regenerate.Input)
+	assert.True(t, regenerate.Input.Children["keyType"].Required)
+	assert.Nil(t, rs.Function("listConnectionStrings"))
+}
+
+func TestConvertResource_DiscriminatedObjectIsDeterministic(t *testing.T) {
+	// Variants B and A both declare "shared", with different types; the variant
+	// with the lowest discriminator value wins, whatever the map order.
+	loaded := &bicepdata.LoadedResource{
+		ResourceType: &types.ResourceType{
+			Name: "Microsoft.Test/discriminated@2023-01-01",
+			Body: &types.TypeReference{Ref: 4},
+		},
+		Types: []types.Type{
+			&types.StringType{},  // 0
+			&types.IntegerType{}, // 1
+			&types.ObjectType{Name: "VariantA", Properties: map[string]types.ObjectTypeProperty{ // 2
+				"shared": {Type: &types.TypeReference{Ref: 0}},
+			}},
+			&types.ObjectType{Name: "VariantB", Properties: map[string]types.ObjectTypeProperty{ // 3
+				"shared": {Type: &types.TypeReference{Ref: 1}},
+			}},
+			&types.DiscriminatedObjectType{ // 4
+				Name:          "Microsoft.Test/discriminated",
+				Discriminator: "kind",
+				Elements: map[string]types.ITypeReference{
+					"C": &types.TypeReference{Ref: 3},
+					"B": &types.TypeReference{Ref: 3},
+					"A": &types.TypeReference{Ref: 2},
+				},
+			},
+		},
+		APIVersion:       "2023-01-01",
+		ResourceTypeName: "Microsoft.Test/discriminated",
+	}
+
+	for range 20 {
+		rs, err := ConvertResource(loaded)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"A", "B", "C"}, rs.Properties["kind"].Enum)
+		assert.Equal(t, TypeString, rs.Properties["shared"].Type)
+	}
+}
+
+func TestConvertResource_SharedObjectTypesAreConvertedOnce(t *testing.T) {
+	// Types array:
+	// 0: StringType
+	// 1: ObjectType (sku), referenced by two properties
+	// 2: ObjectType (body)
+	loaded := &bicepdata.LoadedResource{
+		ResourceType: &types.ResourceType{
+			Name: "Microsoft.Test/shared@2023-01-01",
+			Body: &types.TypeReference{Ref: 2},
+		},
+		Types: []types.Type{
+			&types.StringType{}, // 0
+			&types.ObjectType{ // 1
+				Name: "Sku",
+				Properties: map[string]types.ObjectTypeProperty{
+					"name": {Type: &types.TypeReference{Ref: 0}, Flags: types.TypePropertyFlagsRequired},
+				},
+			},
+			&types.ObjectType{ // 2
+				Name: "Microsoft.Test/shared",
+				Properties: map[string]types.ObjectTypeProperty{
+					"sku":       {Type: &types.TypeReference{Ref: 1}, Flags: types.TypePropertyFlagsRequired, Description: "The SKU"},
+					"backupSku": {Type: &types.TypeReference{Ref: 1}, Flags: types.TypePropertyFlagsNone, Description: "The backup SKU"},
+				},
+			},
+		},
+		APIVersion:       "2023-01-01",
+		ResourceTypeName: "Microsoft.Test/shared",
+	}
+
+	rs, err := ConvertResource(loaded)
+	require.NoError(t, err)
+
+	sku, backup := rs.Properties["sku"], rs.Properties["backupSku"]
+	require.NotNil(t, sku)
+	require.NotNil(t, backup)
+	// The properties keep their own flags and descriptions but share the
+	// converted definition.
+	assert.True(t, sku.Required)
+	assert.False(t, backup.Required)
+	assert.Equal(t, "The SKU", sku.Description)
+	assert.Equal(t, "The backup SKU", backup.Description)
+	require.Contains(t, sku.Children, "name")
+	assert.Same(t, sku.Children["name"], backup.Children["name"])
+}
+
+func TestConvertResource_CyclicObjectsAreNotMemoized(t *testing.T) {
+	// Types array:
+	// 0: StringType
+	// 1: ObjectType (node), referencing itself
+	// 2: ObjectType (body), with node reached directly and through node
+	loaded := &bicepdata.LoadedResource{
+		ResourceType: &types.ResourceType{
+			Name: "Microsoft.Test/cyclic@2023-01-01",
+			Body: &types.TypeReference{Ref: 2},
+		},
+		Types: []types.Type{
+			&types.StringType{}, // 0
+			&types.ObjectType{ // 1
+				Name: "Node",
+				Properties: map[string]types.ObjectTypeProperty{
+					"value": {Type: &types.TypeReference{Ref: 0}},
+					"child": {Type: &types.TypeReference{Ref: 1}},
+				},
+			},
+			&types.ObjectType{ // 2
+				Name: "Microsoft.Test/cyclic",
+				Properties: map[string]types.ObjectTypeProperty{
+					"first":  {Type: &types.TypeReference{Ref: 1}},
+					"second": {Type: &types.TypeReference{Ref: 1}},
+				},
+			},
+		},
+		APIVersion:       "2023-01-01",
+		ResourceTypeName: "Microsoft.Test/cyclic",
+	}
+
+	rs, err := ConvertResource(loaded)
+	require.NoError(t, err)
+
+	// Both properties get the node with one level of children, as when each
+	// is converted on its own; the truncated inner node is not reused.
+	for _, name := range []string{"first", "second"} {
+		prop := rs.Properties[name]
+		require.NotNil(t, prop, name)
+		require.Contains(t, prop.Children, "child", name)
+		assert.Contains(t, prop.Children, "value", name)
+		assert.Nil(t, prop.Children["child"].Children, name)
+	}
+}
@@ -0,0 +1,222 @@
+package schema
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Azure/bicep-types/src/bicep-types-go/types"
+	"github.com/matt-FFFFFF/tfmodmake/bicepdata"
+)
+
+// DiagnosticSeverity is how much a construct degrades the generated module.
+type DiagnosticSeverity string
+
+// Diagnostic severities. Errors stop generation or lose part of the schema;
+// warnings are generated in a looser form than the spec describes, and notes
+// are generated as the spec describes them but may need manual attention.
+const (
+	DiagnosticError   DiagnosticSeverity = "error"
+	DiagnosticWarning DiagnosticSeverity = "warning"
+	DiagnosticNote    DiagnosticSeverity = "note"
+)
+
+// LargeSchemaProperties is the number of writable properties from which a
+// schema is reported as large.
+const LargeSchemaProperties = 500
+
+// Diagnostic is a construct of a resource type that generation degrades on.
+// Path is the body path of the property holding it, with [] for array items and
+// {} for map values; it is empty for the resource as a whole.
+type Diagnostic struct {
+	Construct string             `json:"construct"`
+	Severity  DiagnosticSeverity `json:"severity"`
+	Path      string             `json:"path,omitempty"`
+	Message   string             `json:"message"`
+}
+
+// Diagnose reports the constructs of a loaded resource type that generation
+// degrades on: a missing PUT, discriminated objects, unions, recursive and
+// unresolvable types, untyped properties and very large schemas. A type shared
+// by several properties is reported at the first path it is reached by. The
+// diagnostics are sorted by path.
+func Diagnose(loaded *bicepdata.LoadedResource) ([]Diagnostic, error) {
+	rs, err := ConvertResource(loaded)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &diagnoser{loaded: loaded, onPath: map[int]bool{}, seen: map[int]bool{}}
+	if rs.IsReadOnlyResource() {
+		d.add("read-only-resource", DiagnosticError, "", "the resource type has no PUT operation; only azapi_update_resource can be generated for it")
+	}
+	body, err := loaded.ResolveType(loaded.ResourceType.Body)
+	if err != nil {
+		return nil, err
+	}
+	d.walk("", body, loaded.ResourceType.Body)
+
+	if count := countWritable(rs.Properties); count >= LargeSchemaProperties {
+		d.add("large-schema", DiagnosticWarning, "", fmt.Sprintf("the body has %d writable properties; the variables and their descriptions will be large", count))
+	}
+
+	sort.SliceStable(d.diagnostics, func(i, j int) bool { return d.diagnostics[i].Path < d.diagnostics[j].Path })
+	return d.diagnostics, nil
+}
+
+type diagnoser struct {
+	loaded *bicepdata.LoadedResource
+	// onPath holds the object types being walked, to recognize recursion, and
+	// seen those already walked, which are not walked again.
+	onPath      map[int]bool
+	seen        map[int]bool
+	diagnostics []Diagnostic
+}
+
+func (d *diagnoser) add(construct string, severity DiagnosticSeverity, path, message string) {
+	d.diagnostics = append(d.diagnostics, Diagnostic{Construct: construct, Severity: severity, Path: path, Message: message})
+}
+
+// walk reports the constructs of t, reached through ref at path.
+func (d *diagnoser) walk(path string, t types.Type, ref types.ITypeReference) {
+	idx := typeRefIndex(ref)
+	switch t := t.(type) {
+	case *types.ObjectType:
+		if !d.enter(path, idx, t.Name) {
+			return
+		}
+		defer delete(d.onPath, idx)
+		d.walkProperties(path, t.Properties)
+		if t.AdditionalProperties != nil {
+			d.walkRef(path+"{}", t.AdditionalProperties)
+		}
+
+	case *types.DiscriminatedObjectType:
+		if !d.enter(path, idx, t.Name) {
+			return
+		}
+		defer delete(d.onPath, idx)
+		values := make([]string, 0, len(t.Elements))
+		for value := range t.Elements {
+			values = append(values, value)
+		}
+		sort.Strings(values)
+		d.add("discriminator", DiagnosticWarning, path, fmt.Sprintf("objects discriminated by %q (%s) are merged into one object; the properties of every variant are optional and accepted whatever the %s", t.Discriminator, strings.Join(values, ", "), t.Discriminator))
+		d.walkProperties(path, t.BaseProperties)
+		// A property several variants declare with different types is taken
+		// from the first variant.
+		type declaration struct {
+			variant string
+			ref     int
+		}
+		declared := map[string]declaration{}
+		for _, value := range values {
+			variant, err := d.loaded.ResolveType(t.Elements[value])
+			if err != nil {
+				d.add("unresolved-type", DiagnosticError, path, fmt.Sprintf("the %s variant cannot be resolved and is left out: %v", value, err))
+				continue
+			}
+			object, ok := variant.(*types.ObjectType)
+			if !ok {
+				continue
+			}
+			d.walkProperties(path, object.Properties)
+			for _, name := range sortedTypePropertyNames(object.Properties) {
+				if _, base := t.BaseProperties[name]; base {
+					continue
+				}
+				ref := typeRefIndex(object.Properties[name].Type)
+				if first, ok := declared[name]; ok {
+					if first.ref != ref {
+						d.add("discriminator", DiagnosticNote, joinPath(path, name), fmt.Sprintf("declared with different types by the %s and %s variants; the definition of %s is used", first.variant, value, first.variant))
+					}
+					continue
+				}
+				declared[name] = declaration{variant: value, ref: ref}
+			}
+		}
+
+	case *types.ArrayType:
+		if t.ItemType != nil {
+			d.walkRef(path+"[]", t.ItemType)
+		}
+
+	case *types.UnionType:
+		if _, isEnum := (&converter{loaded: d.loaded}).extractStringEnum(t); !isEnum {
+			d.add("union", DiagnosticWarning, path, fmt.Sprintf("a union of %d types is generated as any", len(t.Elements)))
+		}
+
+	case *types.AnyType:
+		d.add("any", DiagnosticNote, path, "untyped in the spec; generated as any")
+	}
+}
+
+// enter reports whether the object type idx is to be walked, reporting the
+// recursion when it is already being walked.
+func (d *diagnoser) enter(path string, idx int, name string) bool {
+	if idx < 0 {
+		return true
+	}
+	if d.onPath[idx] {
+		d.add("recursion", DiagnosticWarning, path, fmt.Sprintf("%s contains itself; the nested levels are generated as an empty object", name))
+		return false
+	}
+	if d.seen[idx] {
+		return false
+	}
+	d.seen[idx] = true
+	d.onPath[idx] = true
+	return true
+}
+
+func (d *diagnoser) walkProperties(path string, properties map[string]types.ObjectTypeProperty) {
+	for _, name := range sortedTypePropertyNames(properties) {
+		if ref := properties[name].Type; ref != nil {
+			d.walkRef(joinPath(path, name), ref)
+		}
+	}
+}
+
+func (d *diagnoser) walkRef(path string, ref types.ITypeReference) {
+	t, err := d.loaded.ResolveType(ref)
+	if err != nil {
+		d.add("unresolved-type", DiagnosticError, path, fmt.Sprintf("the type cannot be resolved and is generated as any: %v", err))
+		return
+	}
+	d.walk(path, t, ref)
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func sortedTypePropertyNames(properties map[string]types.ObjectTypeProperty) []string {
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// countWritable counts the writable properties below props.
+func countWritable(props map[string]*Property) int {
+	count := 0
+	for _, prop := range props {
+		if prop == nil || prop.ReadOnly {
+			continue
+		}
+		count++
+		count += countWritable(prop.Children)
+		if prop.ItemType != nil {
+			count += countWritable(prop.ItemType.Children)
+		}
+		if prop.AdditionalProperties != nil {
+			count += countWritable(prop.AdditionalProperties.Children)
+		}
+	}
+	return count
+}
@@ -0,0 +1,111 @@
+package schema
+
+import (
+	"testing"
+
+	"github.com/Azure/bicep-types/src/bicep-types-go/types"
+	"github.com/matt-FFFFFF/tfmodmake/bicepdata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiagnose(t *testing.T) {
+	// Types array:
+	// 0: StringType
+	// 1: IntegerType
+	// 2: UnionType (string | integer)
+	// 3: AnyType
+	// 4: ObjectType Node (recursive through children)
+	// 5: ArrayType of Node
+	// 6: ObjectType variant "a"
+	// 7: ObjectType variant "b"
+	// 8: DiscriminatedObjectType
+	// 9: ObjectType (body)
+	loaded := &bicepdata.LoadedResource{
+		ResourceType: &types.ResourceType{
+			Name:           "Microsoft.Test/widgets@2024-01-01",
+			Body:           &types.TypeReference{Ref: 9},
+			ReadableScopes: types.ScopeTypeResourceGroup,
+			WritableScopes: types.ScopeTypeResourceGroup,
+		},
+		Types: []types.Type{
+			&types.StringType{},  // 0
+			&types.IntegerType{}, // 1
+			&types.UnionType{Elements: []types.ITypeReference{&types.TypeReference{Ref: 0}, &types.TypeReference{Ref: 1}}}, // 2
+			&types.AnyType{}, // 3
+			&types.ObjectType{Name: "Node", Properties: map[string]types.ObjectTypeProperty{ // 4
+				"label":    {Type: &types.TypeReference{Ref: 0}},
+				"children": {Type: &types.TypeReference{Ref: 5}},
+			}},
+			&types.ArrayType{ItemType: &types.TypeReference{Ref: 4}}, // 5
+			&types.ObjectType{Name: "A", Properties: map[string]types.ObjectTypeProperty{ // 6
+				"size": {Type: &types.TypeReference{Ref: 0}},
+			}},
+			&types.ObjectType{Name: "B", Properties: map[string]types.ObjectTypeProperty{ // 7
+				"size": {Type: &types.TypeReference{Ref: 1}},
+			}},
+			&types.DiscriminatedObjectType{Name: "Shape", Discriminator: "kind", Elements: map[string]types.ITypeReference{ // 8
+				"a": &types.TypeReference{Ref: 6},
+				"b": &types.TypeReference{Ref: 7},
+			}},
+			&types.ObjectType{Name: "Microsoft.Test/widgets", Properties: map[string]types.ObjectTypeProperty{ // 9
+				"name":    {Type: &types.TypeReference{Ref: 0}, Flags: types.TypePropertyFlagsRequired},
+				"value":   {Type: &types.TypeReference{Ref: 2}},
+				"extra":   {Type: &types.TypeReference{Ref: 3}},
+				"tree":    {Type: &types.TypeReference{Ref: 4}},
+				"shape":   {Type: &types.TypeReference{Ref: 8}},
+				"missing": {Type: &types.TypeReference{Ref: 42}},
+			}},
+		},
+		APIVersion:       "2024-01-01",
+		ResourceTypeName: "Microsoft.Test/widgets",
+	}
+
+	diagnostics, err := Diagnose(loaded)
+	require.NoError(t, err)
+
+	type summary struct {
+		construct string
+		severity  DiagnosticSeverity
+		path      string
+	}
+	var got []summary
+	for _, d := range diagnostics {
+		got = append(got, summary{d.Construct, d.Severity, d.Path})
+	}
+	assert.Equal(t, []summary{
+		{"any", DiagnosticNote, "extra"},
+		{"unresolved-type", DiagnosticError, "missing"},
+		{"discriminator", DiagnosticWarning, "shape"},
+		{"discriminator", DiagnosticNote, "shape.size"},
+		{"recursion", DiagnosticWarning, "tree.children[]"},
+		{"union", DiagnosticWarning, "value"},
+	}, got)
+	assert.Contains(t, diagnostics[2].Message, `discriminated by "kind" (a, b)`)
+	assert.Contains(t, diagnostics[3].Message, "the definition of a is used")
+}
+
+func TestDiagnose_ReadOnlyResource(t *testing.T) {
+	loaded := &bicepdata.LoadedResource{
+		ResourceType: &types.ResourceType{
+			Name:           "Microsoft.Test/reports@2024-01-01",
+			Body:           &types.TypeReference{Ref: 1},
+			ReadableScopes: types.ScopeTypeResourceGroup,
+		},
+		Types: []types.Type{
+			&types.StringType{}, // 0
+			&types.ObjectType{Name: "Microsoft.Test/reports", Properties: map[string]types.ObjectTypeProperty{ // 1
+				"name": {Type: &types.TypeReference{Ref: 0}, Flags: types.TypePropertyFlagsRequired},
+			}},
+		},
+		APIVersion:       "2024-01-01",
+		ResourceTypeName: "Microsoft.Test/reports",
+	}
+
+	diagnostics, err := Diagnose(loaded)
+	require.NoError(t, err)
+	require.Len(t, diagnostics, 1)
+	assert.Equal(t, "read-only-resource", diagnostics[0].Construct)
+	assert.Equal(t, DiagnosticError, diagnostics[0].Severity)
+	assert.Empty(t, diagnostics[0].Path)
+}
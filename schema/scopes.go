@@ -0,0 +1,68 @@
+package schema
+
+import "github.com/Azure/bicep-types/src/bicep-types-go/types"
+
+// scopeNames lists the ARM deployment scopes in the order they are reported.
+var scopeNames = []struct {
+	scope types.ScopeType
+	name  string
+}{
+	{types.ScopeTypeTenant, "tenant"},
+	{types.ScopeTypeManagementGroup, "managementGroup"},
+	{types.ScopeTypeSubscription, "subscription"},
+	{types.ScopeTypeResourceGroup, "resourceGroup"},
+	{types.ScopeTypeExtension, "extension"},
+}
+
+// ScopeNames returns the names of the deployment scopes set in s.
+func ScopeNames(s types.ScopeType) []string {
+	var names []string
+	for _, sn := range scopeNames {
+		if s&sn.scope != 0 {
+			names = append(names, sn.name)
+		}
+	}
+	return names
+}
+
+// IsExtensionResource reports whether the resource can be deployed onto another
+// resource (an ARM extension resource such as a lock, role assignment or diagnostic
+// setting), i.e. its PUT path is rooted at an arbitrary {scope}.
+func (rs *ResourceSchema) IsExtensionResource() bool {
+	return rs != nil && rs.WritableScopes&types.ScopeTypeExtension != 0
+}
+
+// IsReadOnlyResource reports whether the resource can be read but not written,
+// i.e. the service exposes GET but no PUT for it.
+func (rs *ResourceSchema) IsReadOnlyResource() bool {
+	return rs != nil && rs.WritableScopes == types.ScopeTypeNone && rs.ReadableScopes != types.ScopeTypeNone
+}
+
+// ResourceKind classifies a resource type the way the ARM resource provider
+// contract does.
+type ResourceKind string
+
+const (
+	// ResourceKindTracked resources live in a region: they have a writable
+	// location, and usually tags.
+	ResourceKindTracked ResourceKind = "tracked"
+	// ResourceKindProxy resources have no location of their own; they are
+	// managed through a parent resource or are global.
+	ResourceKindProxy ResourceKind = "proxy"
+	// ResourceKindExtension resources are deployed onto another resource.
+	ResourceKindExtension ResourceKind = "extension"
+)
+
+// Kind returns the kind of the resource. The bicep types flatten the common
+// TrackedResource and ProxyResource definitions the swagger bodies inherit
+// from, so a tracked resource is recognised by the location property it
+// inherited, however deeply the inheritance was nested.
+func (rs *ResourceSchema) Kind() ResourceKind {
+	switch {
+	case rs.IsExtensionResource():
+		return ResourceKindExtension
+	case rs != nil && rs.SupportsLocation:
+		return ResourceKindTracked
+	}
+	return ResourceKindProxy
+}
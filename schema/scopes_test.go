@@ -0,0 +1,44 @@
+package schema
+
+import (
+	"testing"
+
+	"github.com/Azure/bicep-types/src/bicep-types-go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScopeNames(t *testing.T) {
+	assert.Equal(t, []string{"subscription", "resourceGroup"}, ScopeNames(types.ScopeTypeSubscription|types.ScopeTypeResourceGroup))
+	assert.Empty(t, ScopeNames(types.ScopeTypeNone))
+}
+
+func TestIsReadOnlyResource(t *testing.T) {
+	tests := []struct {
+		name string
+		rs   *ResourceSchema
+		want bool
+	}{
+		{name: "nil schema", rs: nil, want: false},
+		{name: "no scope data", rs: &ResourceSchema{}, want: false},
+		{name: "writable", rs: &ResourceSchema{ReadableScopes: types.ScopeTypeResourceGroup, WritableScopes: types.ScopeTypeResourceGroup}, want: false},
+		{name: "read only", rs: &ResourceSchema{ReadableScopes: types.ScopeTypeResourceGroup}, want: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, tc.rs.IsReadOnlyResource())
+		})
+	}
+}
+
+func TestIsExtensionResource(t *testing.T) {
+	assert.True(t, (&ResourceSchema{WritableScopes: types.ScopeTypeExtension}).IsExtensionResource())
+	assert.True(t, (&ResourceSchema{WritableScopes: types.AllExceptExtension | types.ScopeTypeExtension}).IsExtensionResource())
+	assert.False(t, (&ResourceSchema{WritableScopes: types.ScopeTypeResourceGroup}).IsExtensionResource())
+}
+
+func TestKind(t *testing.T) {
+	assert.Equal(t, ResourceKindTracked, (&ResourceSchema{SupportsLocation: true, WritableScopes: types.ScopeTypeResourceGroup}).Kind())
+	assert.Equal(t, ResourceKindProxy, (&ResourceSchema{WritableScopes: types.ScopeTypeResourceGroup}).Kind())
+	assert.Equal(t, ResourceKindExtension, (&ResourceSchema{SupportsLocation: true, WritableScopes: types.ScopeTypeExtension}).Kind())
+	assert.Equal(t, ResourceKindProxy, (*ResourceSchema)(nil).Kind())
+}
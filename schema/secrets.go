@@ -1,5 +1,7 @@
 package schema
 
+import "sort"
+
 // SecretField represents a property path that contains sensitive data.
 type SecretField struct {
 	// Path is the dot-separated path to the secret property (e.g. "properties.adminPassword").
@@ -21,8 +23,8 @@ func CollectSecretFields(schema *ResourceSchema) []SecretField {
 	}
 
 	var secrets []SecretField
-	for name, prop := range schema.Properties {
-		secrets = collectSecretsRecursive(prop, name, secrets)
+	for _, name := range sortedPropertyNames(schema.Properties) {
+		secrets = collectSecretsRecursive(schema.Properties[name], name, secrets)
 	}
 	return secrets
 }
@@ -41,9 +43,8 @@ func collectSecretsRecursive(prop *Property, path string, secrets []SecretField)
 	}
 
 	// Recurse into children
-	for name, child := range prop.Children {
-		childPath := path + "." + name
-		secrets = collectSecretsRecursive(child, childPath, secrets)
+	for _, name := range sortedPropertyNames(prop.Children) {
+		secrets = collectSecretsRecursive(prop.Children[name], path+"."+name, secrets)
 	}
 
 	// Recurse into array item type
@@ -53,3 +54,14 @@ func collectSecretsRecursive(prop *Property, path string, secrets []SecretField)
 
 	return secrets
 }
+
+// sortedPropertyNames returns the names of props in order, so walks of the
+// property tree are deterministic.
+func sortedPropertyNames(props map[string]*Property) []string {
+	names := make([]string, 0, len(props))
+	for name := range props {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -193,3 +193,22 @@ func TestCollectSecretFields(t *testing.T) {
 		assert.True(t, paths["nested.innerSecret"])
 	})
 }
+
+func TestCollectSecretFields_SortedByPath(t *testing.T) {
+	rs := &ResourceSchema{Properties: map[string]*Property{
+		"properties": {Name: "properties", Type: TypeObject, Children: map[string]*Property{
+			"zPassword": {Name: "zPassword", Type: TypeString, Sensitive: true},
+			"aKey":      {Name: "aKey", Type: TypeString, Sensitive: true},
+			"mToken":    {Name: "mToken", Type: TypeString, Sensitive: true},
+		}},
+		"adminSecret": {Name: "adminSecret", Type: TypeString, Sensitive: true},
+	}}
+
+	for range 10 {
+		var paths []string
+		for _, secret := range CollectSecretFields(rs) {
+			paths = append(paths, secret.Path)
+		}
+		assert.Equal(t, []string{"adminSecret", "properties.aKey", "properties.mToken", "properties.zPassword"}, paths)
+	}
+}
@@ -2,6 +2,12 @@
 // that serves as an adapter between bicep-types and tfmodmake's generation logic.
 package schema
 
+import (
+	"strings"
+
+	"github.com/Azure/bicep-types/src/bicep-types-go/types"
+)
+
 // TypeKind represents the kind of a property's type.
 type TypeKind int
 
@@ -107,6 +113,11 @@ type Property struct {
 	// Discriminator is the property name used to discriminate between object variants.
 	// Only set on properties that represent discriminated objects.
 	Discriminator string
+
+	// Variants lists, for a child of a discriminated object, the discriminator
+	// values of the variants declaring it, sorted. It is nil for base
+	// properties, the discriminator itself and children of other objects.
+	Variants []string
 }
 
 // HasDiscriminator reports whether the resource schema contains any
@@ -176,4 +187,52 @@ type ResourceSchema struct {
 
 	// SupportsIdentity indicates whether the resource supports managed identity configuration.
 	SupportsIdentity bool
+
+	// ReadableScopes and WritableScopes are the deployment scopes at which the resource
+	// can be read (GET) and written (PUT). Both are zero when the source data does not
+	// record scopes.
+	ReadableScopes types.ScopeType
+	WritableScopes types.ScopeType
+
+	// Functions lists the POST actions invoked on an instance of the resource
+	// (e.g. listKeys), sorted by name.
+	Functions []*ResourceFunction
+}
+
+// ResourceFunction represents a POST action invoked on an instance of a resource.
+type ResourceFunction struct {
+	// Name is the action name (e.g. "listKeys").
+	Name string
+
+	// APIVersion is the API version the action is invoked with.
+	APIVersion string
+
+	// Input is the request body, or nil when the action takes none.
+	Input *Property
+
+	// Output is the response body.
+	Output *Property
+}
+
+// SingletonName returns the fixed name of a resource that only ever has one
+// instance per parent (e.g. "default" or "current"), recognised by a name
+// property restricted to a single value.
+func (rs *ResourceSchema) SingletonName() (string, bool) {
+	if rs == nil || rs.Properties["name"] == nil || len(rs.Properties["name"].Enum) != 1 {
+		return "", false
+	}
+	return rs.Properties["name"].Enum[0], true
+}
+
+// Function returns the action named name (case-insensitive), or nil.
+func (rs *ResourceSchema) Function(name string) *ResourceFunction {
+	if rs == nil {
+		return nil
+	}
+	for _, fn := range rs.Functions {
+		if strings.EqualFold(fn.Name, name) {
+			return fn
+		}
+	}
+	return nil
 }
@@ -0,0 +1,173 @@
+package schema
+
+import (
+	"slices"
+	"sort"
+)
+
+// VersionChangeKind is how a writable property changed between two API
+// versions.
+type VersionChangeKind string
+
+const (
+	// VersionChangeAdded is a property only the new version has.
+	VersionChangeAdded VersionChangeKind = "added"
+	// VersionChangeRemoved is a property only the old version has, or that
+	// became read-only.
+	VersionChangeRemoved VersionChangeKind = "removed"
+	// VersionChangeType is a property whose type changed. Its nested
+	// properties are not compared.
+	VersionChangeType VersionChangeKind = "type-changed"
+	// VersionChangeEnum is a property whose allowed values changed.
+	VersionChangeEnum VersionChangeKind = "enum-changed"
+	// VersionChangeRestricted is a property that accepted any value and now
+	// only accepts those of an enum.
+	VersionChangeRestricted VersionChangeKind = "restricted"
+	// VersionChangeUnrestricted is a property that only accepted the values of
+	// an enum and now accepts any value.
+	VersionChangeUnrestricted VersionChangeKind = "unrestricted"
+	// VersionChangeRequired is an optional property that became required.
+	VersionChangeRequired VersionChangeKind = "required"
+	// VersionChangeOptional is a required property that became optional.
+	VersionChangeOptional VersionChangeKind = "optional"
+)
+
+// VersionChange is a change to a writable property between two API versions of
+// a resource type. Path is the body path of the property, with [] for array
+// items and {} for map values.
+type VersionChange struct {
+	Path string            `json:"path"`
+	Kind VersionChangeKind `json:"kind"`
+	// Breaking reports whether configurations the old version accepts may be
+	// rejected by the new one: a property was removed, changed type or became
+	// required, a required property was added, or allowed values were removed
+	// or restricted. Other changes are additive.
+	Breaking bool `json:"breaking"`
+	// Required reports, for an added property, whether it is required.
+	Required bool `json:"required,omitempty"`
+	// OldType and NewType are the types of a property whose type changed.
+	OldType string `json:"old_type,omitempty"`
+	NewType string `json:"new_type,omitempty"`
+	// AddedValues and RemovedValues are the allowed values an enum gained and
+	// lost, sorted. A restricted property lists the values of its new enum as
+	// added, an unrestricted one those of its old enum as removed.
+	AddedValues   []string `json:"added_values,omitempty"`
+	RemovedValues []string `json:"removed_values,omitempty"`
+}
+
+// DiffVersions compares the writable properties of two schemas of a resource
+// type, usually at two API versions. Read-only properties are left out, so a
+// property that became read-only is removed and one that became writable is
+// added. The changes are sorted by path.
+func DiffVersions(from, to *ResourceSchema) []VersionChange {
+	var changes []VersionChange
+	diffProperties(&changes, "", from.Properties, to.Properties)
+	for i := range changes {
+		changes[i].Breaking = changes[i].isBreaking()
+	}
+	sort.SliceStable(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes
+}
+
+// isBreaking classifies the change; see Breaking.
+func (c VersionChange) isBreaking() bool {
+	switch c.Kind {
+	case VersionChangeRemoved, VersionChangeType, VersionChangeRequired, VersionChangeRestricted:
+		return true
+	case VersionChangeAdded:
+		return c.Required
+	case VersionChangeEnum:
+		return len(c.RemovedValues) > 0
+	}
+	return false
+}
+
+func diffProperties(changes *[]VersionChange, path string, from, to map[string]*Property) {
+	names := map[string]bool{}
+	for name, prop := range from {
+		if prop != nil && !prop.ReadOnly {
+			names[name] = true
+		}
+	}
+	for name, prop := range to {
+		if prop != nil && !prop.ReadOnly {
+			names[name] = true
+		}
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	for _, name := range sorted {
+		before, after := writableProperty(from[name]), writableProperty(to[name])
+		diffProperty(changes, joinPath(path, name), before, after)
+	}
+}
+
+// writableProperty returns prop, or nil when it is missing or read-only.
+func writableProperty(prop *Property) *Property {
+	if prop == nil || prop.ReadOnly {
+		return nil
+	}
+	return prop
+}
+
+// diffProperty compares a property at path, nil in a version that lacks it.
+func diffProperty(changes *[]VersionChange, path string, from, to *Property) {
+	switch {
+	case from == nil && to == nil:
+		return
+	case from == nil:
+		*changes = append(*changes, VersionChange{Path: path, Kind: VersionChangeAdded, Required: to.Required})
+		return
+	case to == nil:
+		*changes = append(*changes, VersionChange{Path: path, Kind: VersionChangeRemoved})
+		return
+	case from.Type != to.Type:
+		*changes = append(*changes, VersionChange{Path: path, Kind: VersionChangeType, OldType: from.Type.String(), NewType: to.Type.String()})
+		return
+	}
+
+	switch {
+	case !from.Required && to.Required:
+		*changes = append(*changes, VersionChange{Path: path, Kind: VersionChangeRequired})
+	case from.Required && !to.Required:
+		*changes = append(*changes, VersionChange{Path: path, Kind: VersionChangeOptional})
+	}
+	switch added, removed := enumDifference(from.Enum, to.Enum); {
+	case len(from.Enum) == 0 && len(to.Enum) > 0:
+		*changes = append(*changes, VersionChange{Path: path, Kind: VersionChangeRestricted, AddedValues: added})
+	case len(from.Enum) > 0 && len(to.Enum) == 0:
+		*changes = append(*changes, VersionChange{Path: path, Kind: VersionChangeUnrestricted, RemovedValues: removed})
+	case len(added) > 0 || len(removed) > 0:
+		*changes = append(*changes, VersionChange{Path: path, Kind: VersionChangeEnum, AddedValues: added, RemovedValues: removed})
+	}
+
+	diffProperties(changes, path, from.Children, to.Children)
+	if from.ItemType != nil && to.ItemType != nil {
+		diffProperty(changes, path+"[]", writableProperty(from.ItemType), writableProperty(to.ItemType))
+	}
+	if from.AdditionalProperties != nil && to.AdditionalProperties != nil {
+		diffProperty(changes, path+"{}", writableProperty(from.AdditionalProperties), writableProperty(to.AdditionalProperties))
+	}
+}
+
+// enumDifference returns the values to allows and from does not, and those from
+// allows and to does not, sorted.
+func enumDifference(from, to []string) (added, removed []string) {
+	for _, v := range to {
+		if !slices.Contains(from, v) {
+			added = append(added, v)
+		}
+	}
+	for _, v := range from {
+		if !slices.Contains(to, v) {
+			removed = append(removed, v)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	return added, removed
+}
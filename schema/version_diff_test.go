@@ -0,0 +1,82 @@
+package schema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffVersions(t *testing.T) {
+	from := &ResourceSchema{APIVersion: "2024-01-01", Properties: map[string]*Property{
+		"name": {Name: "name", Type: TypeString, Required: true},
+		"id":   {Name: "id", Type: TypeString, ReadOnly: true},
+		"properties": {Name: "properties", Type: TypeObject, Children: map[string]*Property{
+			"sku":      {Name: "sku", Type: TypeString, Enum: []string{"Basic", "Standard", "Premium"}},
+			"mode":     {Name: "mode", Type: TypeString},
+			"legacy":   {Name: "legacy", Type: TypeBoolean},
+			"size":     {Name: "size", Type: TypeString},
+			"owner":    {Name: "owner", Type: TypeString},
+			"endpoint": {Name: "endpoint", Type: TypeString, Required: true},
+			"rules": {Name: "rules", Type: TypeArray, ItemType: &Property{Type: TypeObject, Children: map[string]*Property{
+				"port": {Name: "port", Type: TypeInteger},
+			}}},
+		}},
+	}}
+	to := &ResourceSchema{APIVersion: "2025-01-01", Properties: map[string]*Property{
+		"name": {Name: "name", Type: TypeString, Required: true},
+		"id":   {Name: "id", Type: TypeString, ReadOnly: true},
+		"properties": {Name: "properties", Type: TypeObject, Children: map[string]*Property{
+			"sku":      {Name: "sku", Type: TypeString, Enum: []string{"Standard", "Premium", "Ultra"}},
+			"mode":     {Name: "mode", Type: TypeString, Enum: []string{"Auto", "Manual"}},
+			"size":     {Name: "size", Type: TypeInteger},
+			"owner":    {Name: "owner", Type: TypeString, Required: true},
+			"endpoint": {Name: "endpoint", Type: TypeString, ReadOnly: true},
+			"region":   {Name: "region", Type: TypeString, Required: true},
+			"tier":     {Name: "tier", Type: TypeString},
+			"rules": {Name: "rules", Type: TypeArray, ItemType: &Property{Type: TypeObject, Children: map[string]*Property{
+				"port":     {Name: "port", Type: TypeInteger},
+				"protocol": {Name: "protocol", Type: TypeString},
+			}}},
+		}},
+	}}
+
+	assert.Equal(t, []VersionChange{
+		{Path: "properties.endpoint", Kind: VersionChangeRemoved, Breaking: true},
+		{Path: "properties.legacy", Kind: VersionChangeRemoved, Breaking: true},
+		{Path: "properties.mode", Kind: VersionChangeRestricted, AddedValues: []string{"Auto", "Manual"}, Breaking: true},
+		{Path: "properties.owner", Kind: VersionChangeRequired, Breaking: true},
+		{Path: "properties.region", Kind: VersionChangeAdded, Required: true, Breaking: true},
+		{Path: "properties.rules[].protocol", Kind: VersionChangeAdded},
+		{Path: "properties.size", Kind: VersionChangeType, OldType: "string", NewType: "integer", Breaking: true},
+		{Path: "properties.sku", Kind: VersionChangeEnum, AddedValues: []string{"Ultra"}, RemovedValues: []string{"Basic"}, Breaking: true},
+		{Path: "properties.tier", Kind: VersionChangeAdded},
+	}, DiffVersions(from, to))
+}
+
+func TestDiffVersions_Identical(t *testing.T) {
+	rs := &ResourceSchema{Properties: map[string]*Property{
+		"name": {Name: "name", Type: TypeString, Required: true},
+	}}
+	assert.Empty(t, DiffVersions(rs, rs))
+}
+
+func TestDiffVersions_Additive(t *testing.T) {
+	from := &ResourceSchema{Properties: map[string]*Property{
+		"sku":  {Name: "sku", Type: TypeString, Enum: []string{"Basic"}},
+		"mode": {Name: "mode", Type: TypeString, Enum: []string{"Auto"}},
+		"name": {Name: "name", Type: TypeString, Required: true},
+	}}
+	to := &ResourceSchema{Properties: map[string]*Property{
+		"sku":  {Name: "sku", Type: TypeString, Enum: []string{"Basic", "Standard"}},
+		"mode": {Name: "mode", Type: TypeString},
+		"name": {Name: "name", Type: TypeString},
+		"tier": {Name: "tier", Type: TypeString},
+	}}
+
+	assert.Equal(t, []VersionChange{
+		{Path: "mode", Kind: VersionChangeUnrestricted, RemovedValues: []string{"Auto"}},
+		{Path: "name", Kind: VersionChangeOptional},
+		{Path: "sku", Kind: VersionChangeEnum, AddedValues: []string{"Standard"}},
+		{Path: "tier", Kind: VersionChangeAdded},
+	}, DiffVersions(from, to))
+}
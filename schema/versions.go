@@ -0,0 +1,137 @@
+package schema
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// VersionMerge selects the properties MergeVersions keeps.
+type VersionMerge string
+
+const (
+	// VersionMergeUnion keeps the properties of any version; those missing
+	// from some versions are optional and say in their description which
+	// versions have them.
+	VersionMergeUnion VersionMerge = "union"
+	// VersionMergeIntersect keeps only the properties of every version.
+	VersionMergeIntersect VersionMerge = "intersect"
+)
+
+// ParseVersionMerge parses a VersionMerge, "union" or "intersect".
+func ParseVersionMerge(s string) (VersionMerge, error) {
+	switch m := VersionMerge(s); m {
+	case VersionMergeUnion, VersionMergeIntersect:
+		return m, nil
+	}
+	return "", fmt.Errorf("unknown API version merge %q; valid values are %s, %s", s, VersionMergeUnion, VersionMergeIntersect)
+}
+
+// MergeVersions merges the schemas of one resource type at several API
+// versions into a schema accepted by all of them. A property is taken from the
+// latest version defining it, and is required only if every version requires
+// it. The result has the API version, scopes and actions of the latest
+// version. The schemas are not modified.
+func MergeVersions(schemas []*ResourceSchema, merge VersionMerge) *ResourceSchema {
+	if len(schemas) == 0 {
+		return nil
+	}
+	sorted := append([]*ResourceSchema(nil), schemas...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].APIVersion < sorted[j].APIVersion })
+	latest := sorted[len(sorted)-1]
+
+	versions := make([]string, len(sorted))
+	props := make([]map[string]*Property, len(sorted))
+	for i, rs := range sorted {
+		versions[i] = rs.APIVersion
+		props[i] = rs.Properties
+	}
+
+	merged := *latest
+	merged.Properties = mergeProperties(versions, props, merge)
+	merged.SupportsTags = detectSupportsTags(&merged)
+	merged.SupportsLocation = detectSupportsLocation(&merged)
+	merged.SupportsIdentity = detectSupportsIdentity(&merged)
+	return &merged
+}
+
+// mergeProperties merges the property maps of the versions, given in the same
+// order as versions; a nil map is a version without the parent property.
+func mergeProperties(versions []string, props []map[string]*Property, merge VersionMerge) map[string]*Property {
+	names := map[string]bool{}
+	for _, p := range props {
+		for name := range p {
+			names[name] = true
+		}
+	}
+
+	result := make(map[string]*Property, len(names))
+	for name := range names {
+		defined := make([]*Property, len(versions))
+		var in []string
+		var latest *Property
+		for i, p := range props {
+			if prop := p[name]; prop != nil {
+				defined[i] = prop
+				in = append(in, versions[i])
+				latest = prop
+			}
+		}
+		if len(in) < len(versions) && merge == VersionMergeIntersect {
+			continue
+		}
+		result[name] = mergeProperty(versions, defined, latest, in, merge)
+	}
+	return result
+}
+
+// mergeProperty merges the definitions of a property in each version (nil
+// where the version lacks it) into a copy of latest, the latest definition.
+// Nested properties are merged across the versions defining the property with
+// the same type as latest.
+func mergeProperty(versions []string, defined []*Property, latest *Property, in []string, merge VersionMerge) *Property {
+	merged := *latest
+	merged.Required = len(in) == len(versions)
+	for _, prop := range defined {
+		if prop != nil && !prop.Required {
+			merged.Required = false
+		}
+	}
+	if len(in) < len(versions) {
+		note := fmt.Sprintf("Only available in API versions %s.", strings.Join(in, ", "))
+		merged.Description = strings.TrimSpace(strings.TrimSpace(merged.Description) + " " + note)
+	}
+
+	var sameVersions []string
+	var same []*Property
+	for i, prop := range defined {
+		if prop != nil && prop.Type == latest.Type {
+			sameVersions = append(sameVersions, versions[i])
+			same = append(same, prop)
+		}
+	}
+	nestedChildren := func(get func(*Property) *Property) []map[string]*Property {
+		children := make([]map[string]*Property, len(same))
+		for i, prop := range same {
+			if nested := get(prop); nested != nil {
+				children[i] = nested.Children
+			}
+		}
+		return children
+	}
+
+	if len(latest.Children) > 0 {
+		merged.Children = mergeProperties(sameVersions, nestedChildren(func(p *Property) *Property { return p }), merge)
+	}
+	if latest.ItemType != nil && len(latest.ItemType.Children) > 0 {
+		item := *latest.ItemType
+		item.Children = mergeProperties(sameVersions, nestedChildren(func(p *Property) *Property { return p.ItemType }), merge)
+		merged.ItemType = &item
+	}
+	if latest.AdditionalProperties != nil && len(latest.AdditionalProperties.Children) > 0 {
+		value := *latest.AdditionalProperties
+		value.Children = mergeProperties(sameVersions, nestedChildren(func(p *Property) *Property { return p.AdditionalProperties }), merge)
+		merged.AdditionalProperties = &value
+	}
+	return &merged
+}
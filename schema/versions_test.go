@@ -0,0 +1,71 @@
+package schema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func versionSchemas() []*ResourceSchema {
+	return []*ResourceSchema{
+		{
+			APIVersion: "2025-01-01",
+			Properties: map[string]*Property{
+				"location": {Name: "location", Type: TypeString, Required: true},
+				"properties": {Name: "properties", Type: TypeObject, Children: map[string]*Property{
+					"sku":      {Name: "sku", Type: TypeString, Required: true, Description: "The SKU."},
+					"newField": {Name: "newField", Type: TypeBoolean, Description: "A new field."},
+				}},
+			},
+		},
+		{
+			APIVersion: "2024-01-01",
+			Properties: map[string]*Property{
+				"location": {Name: "location", Type: TypeString, Required: true},
+				"properties": {Name: "properties", Type: TypeObject, Children: map[string]*Property{
+					"sku":      {Name: "sku", Type: TypeString},
+					"oldField": {Name: "oldField", Type: TypeString, Required: true},
+				}},
+			},
+		},
+	}
+}
+
+func TestMergeVersions_Union(t *testing.T) {
+	schemas := versionSchemas()
+	merged := MergeVersions(schemas, VersionMergeUnion)
+	require.NotNil(t, merged)
+
+	assert.Equal(t, "2025-01-01", merged.APIVersion)
+	assert.True(t, merged.SupportsLocation)
+	assert.True(t, merged.Properties["location"].Required)
+
+	props := merged.Properties["properties"].Children
+	require.Len(t, props, 3)
+	assert.False(t, props["sku"].Required, "sku is optional in 2024-01-01")
+	assert.Equal(t, "The SKU.", props["sku"].Description)
+	assert.Equal(t, "A new field. Only available in API versions 2025-01-01.", props["newField"].Description)
+	assert.False(t, props["oldField"].Required)
+	assert.Equal(t, "Only available in API versions 2024-01-01.", props["oldField"].Description)
+
+	assert.True(t, schemas[0].Properties["properties"].Children["sku"].Required, "the inputs are not modified")
+}
+
+func TestMergeVersions_Intersect(t *testing.T) {
+	merged := MergeVersions(versionSchemas(), VersionMergeIntersect)
+	require.NotNil(t, merged)
+
+	props := merged.Properties["properties"].Children
+	require.Len(t, props, 1)
+	assert.Contains(t, props, "sku")
+}
+
+func TestParseVersionMerge(t *testing.T) {
+	m, err := ParseVersionMerge("intersect")
+	require.NoError(t, err)
+	assert.Equal(t, VersionMergeIntersect, m)
+
+	_, err = ParseVersionMerge("both")
+	assert.Error(t, err)
+}
@@ -0,0 +1,248 @@
+// Package snapshot records the modules generated for a set of cases into a
+// snapshot directory and verifies that regenerating them reproduces the recorded
+// files, to catch unintended changes of the generator or of the specs.
+package snapshot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// ManifestFileName is the name of the manifest listing the cases of a snapshot directory.
+const ManifestFileName = "snapshot.json"
+
+// Manifest lists the cases recorded in a snapshot directory.
+type Manifest struct {
+	Cases []Case `json:"cases"`
+}
+
+// Case is one generation run: a resource type, the spec it is loaded from and
+// the flags passed to the generator. Its output is recorded in the directory of
+// the same name next to the manifest.
+type Case struct {
+	Name       string `json:"name"`
+	Resource   string `json:"resource"`
+	APIVersion string `json:"api_version,omitempty"`
+	// TypesPath is a local bicep-types-az checkout to load the resource from
+	// instead of the published types, relative to the snapshot directory.
+	TypesPath string `json:"types_path,omitempty"`
+	// Config is a tfmodmake.json to generate with, relative to the snapshot directory.
+	Config string `json:"config,omitempty"`
+	// AVM runs the full AVM generation instead of the base module generation.
+	AVM bool `json:"avm,omitempty"`
+	// Flags are further command-line flags of the generation, e.g. "-telemetry".
+	Flags []string `json:"flags,omitempty"`
+}
+
+// Generator writes the module of a case into outputDir.
+type Generator func(ctx context.Context, c Case, outputDir string) error
+
+// Mismatch is a file whose regenerated content differs from the recorded one.
+// Diff is a unified diff from the recorded to the regenerated file.
+type Mismatch struct {
+	Case string
+	File string
+	Diff string
+}
+
+// LoadManifest reads the manifest of the snapshot directory dir. Relative types
+// and config paths are resolved against dir.
+func LoadManifest(dir string) (*Manifest, error) {
+	path := filepath.Join(dir, ManifestFileName)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading snapshot manifest %s: %w", path, err)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	var m Manifest
+	if err := dec.Decode(&m); err != nil {
+		return nil, fmt.Errorf("parsing snapshot manifest %s: %w", path, err)
+	}
+
+	// Generation runs in another working directory, so paths must not be relative.
+	base, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	for i := range m.Cases {
+		c := &m.Cases[i]
+		switch {
+		case c.Name == "" || c.Name != filepath.Base(c.Name) || strings.HasPrefix(c.Name, "."):
+			return nil, fmt.Errorf("snapshot case %d: invalid name %q; use a plain directory name", i+1, c.Name)
+		case seen[c.Name]:
+			return nil, fmt.Errorf("snapshot case %q: duplicate name", c.Name)
+		case c.Resource == "":
+			return nil, fmt.Errorf("snapshot case %q: resource is required", c.Name)
+		}
+		seen[c.Name] = true
+		if c.TypesPath != "" && !filepath.IsAbs(c.TypesPath) {
+			c.TypesPath = filepath.Join(base, c.TypesPath)
+		}
+		if c.Config != "" && !filepath.IsAbs(c.Config) {
+			c.Config = filepath.Join(base, c.Config)
+		}
+	}
+	return &m, nil
+}
+
+// Select returns the cases with the given names, or every case when names is empty.
+func (m *Manifest) Select(names []string) ([]Case, error) {
+	if len(names) == 0 {
+		return m.Cases, nil
+	}
+	byName := make(map[string]Case, len(m.Cases))
+	for _, c := range m.Cases {
+		byName[c.Name] = c
+	}
+	cases := make([]Case, 0, len(names))
+	for _, name := range names {
+		c, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown snapshot case %q", name)
+		}
+		cases = append(cases, c)
+	}
+	return cases, nil
+}
+
+// Record generates the case and replaces its recorded files in dir with the
+// output. It returns the number of files recorded.
+func Record(ctx context.Context, dir string, c Case, generate Generator) (int, error) {
+	files, err := generateCase(ctx, c, generate)
+	if err != nil {
+		return 0, err
+	}
+
+	caseDir := filepath.Join(dir, c.Name)
+	if err := os.RemoveAll(caseDir); err != nil {
+		return 0, err
+	}
+	for name, content := range files {
+		path := filepath.Join(caseDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return 0, err
+		}
+		if err := os.WriteFile(path, content, 0o644); err != nil {
+			return 0, err
+		}
+	}
+	return len(files), nil
+}
+
+// Verify regenerates the case and compares the output with the files recorded
+// in dir, returning a mismatch for every file that was added, removed or changed.
+func Verify(ctx context.Context, dir string, c Case, generate Generator) ([]Mismatch, error) {
+	caseDir := filepath.Join(dir, c.Name)
+	if _, err := os.Stat(caseDir); err != nil {
+		return nil, fmt.Errorf("snapshot case %q has not been recorded: %w", c.Name, err)
+	}
+	recorded, err := readTree(caseDir)
+	if err != nil {
+		return nil, err
+	}
+	generated, err := generateCase(ctx, c, generate)
+	if err != nil {
+		return nil, err
+	}
+
+	names := map[string]struct{}{}
+	for name := range recorded {
+		names[name] = struct{}{}
+	}
+	for name := range generated {
+		names[name] = struct{}{}
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	var mismatches []Mismatch
+	for _, name := range sorted {
+		want, hasWant := recorded[name]
+		got, hasGot := generated[name]
+		if hasWant && hasGot && bytes.Equal(want, got) {
+			continue
+		}
+		fromFile, toFile := "recorded/"+c.Name+"/"+name, "generated/"+c.Name+"/"+name
+		if !hasWant {
+			fromFile = "/dev/null"
+		}
+		if !hasGot {
+			toFile = "/dev/null"
+		}
+		diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:        splitLines(want),
+			B:        splitLines(got),
+			FromFile: fromFile,
+			ToFile:   toFile,
+			Context:  3,
+		})
+		if err != nil {
+			return nil, err
+		}
+		mismatches = append(mismatches, Mismatch{Case: c.Name, File: name, Diff: diff})
+	}
+	return mismatches, nil
+}
+
+// splitLines splits content into lines for diffing, each ending in a newline.
+func splitLines(content []byte) []string {
+	lines := strings.SplitAfter(string(content), "\n")
+	if last := len(lines) - 1; lines[last] == "" {
+		lines = lines[:last]
+	} else {
+		lines[last] += "\n"
+	}
+	return lines
+}
+
+// generateCase runs the generator in a temporary directory and returns the
+// files it wrote.
+func generateCase(ctx context.Context, c Case, generate Generator) (map[string][]byte, error) {
+	outputDir, err := os.MkdirTemp("", "tfmodmake-snapshot-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(outputDir)
+
+	if err := generate(ctx, c, outputDir); err != nil {
+		return nil, fmt.Errorf("generating snapshot case %q: %w", c.Name, err)
+	}
+	return readTree(outputDir)
+}
+
+// readTree returns the content of every file below root, keyed by its
+// slash-separated path relative to root.
+func readTree(root string) (map[string][]byte, error) {
+	files := map[string][]byte{}
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = content
+		return nil
+	})
+	return files, err
+}
@@ -0,0 +1,139 @@
+package snapshot
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeGenerator writes the given files for every case.
+func fakeGenerator(files map[string]string) Generator {
+	return func(ctx context.Context, c Case, outputDir string) error {
+		for name, content := range files {
+			path := filepath.Join(outputDir, filepath.FromSlash(name))
+			if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+				return err
+			}
+			if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func writeManifest(t *testing.T, dir, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ManifestFileName), []byte(content), 0o644))
+}
+
+func TestLoadManifest_ResolvesPathsAgainstDir(t *testing.T) {
+	dir := t.TempDir()
+	writeManifest(t, dir, `{"cases": [{"name": "vault", "resource": "Microsoft.KeyVault/vaults", "types_path": "types", "config": "vault.json", "flags": ["-telemetry"]}]}`)
+
+	m, err := LoadManifest(dir)
+	require.NoError(t, err)
+	require.Len(t, m.Cases, 1)
+	assert.Equal(t, Case{
+		Name:      "vault",
+		Resource:  "Microsoft.KeyVault/vaults",
+		TypesPath: filepath.Join(dir, "types"),
+		Config:    filepath.Join(dir, "vault.json"),
+		Flags:     []string{"-telemetry"},
+	}, m.Cases[0])
+}
+
+func TestLoadManifest_RejectsInvalidCases(t *testing.T) {
+	for name, manifest := range map[string]string{
+		"missing name":     `{"cases": [{"resource": "Microsoft.KeyVault/vaults"}]}`,
+		"path as name":     `{"cases": [{"name": "../vault", "resource": "Microsoft.KeyVault/vaults"}]}`,
+		"duplicate name":   `{"cases": [{"name": "vault", "resource": "Microsoft.KeyVault/vaults"}, {"name": "vault", "resource": "Microsoft.KeyVault/vaults"}]}`,
+		"missing resource": `{"cases": [{"name": "vault"}]}`,
+		"unknown field":    `{"cases": [{"name": "vault", "resource": "Microsoft.KeyVault/vaults", "api": "2024-01-01"}]}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeManifest(t, dir, manifest)
+			_, err := LoadManifest(dir)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestManifestSelect(t *testing.T) {
+	m := &Manifest{Cases: []Case{{Name: "a"}, {Name: "b"}}}
+
+	all, err := m.Select(nil)
+	require.NoError(t, err)
+	assert.Len(t, all, 2)
+
+	selected, err := m.Select([]string{"b"})
+	require.NoError(t, err)
+	assert.Equal(t, []Case{{Name: "b"}}, selected)
+
+	_, err = m.Select([]string{"c"})
+	assert.EqualError(t, err, `unknown snapshot case "c"`)
+}
+
+func TestRecordAndVerify(t *testing.T) {
+	dir := t.TempDir()
+	c := Case{Name: "widget", Resource: "Microsoft.Test/widgets"}
+	original := map[string]string{
+		"main.tf":                "resource \"azapi_resource\" \"this\" {}\n",
+		"variables.tf":           "variable \"name\" {}\n",
+		"modules/child/main.tf":  "# child\n",
+		"examples/default/x.txt": "x\n",
+	}
+
+	n, err := Record(context.Background(), dir, c, fakeGenerator(original))
+	require.NoError(t, err)
+	assert.Equal(t, 4, n)
+	recorded, err := os.ReadFile(filepath.Join(dir, "widget", "modules", "child", "main.tf"))
+	require.NoError(t, err)
+	assert.Equal(t, "# child\n", string(recorded))
+
+	mismatches, err := Verify(context.Background(), dir, c, fakeGenerator(original))
+	require.NoError(t, err)
+	assert.Empty(t, mismatches)
+
+	changed := map[string]string{
+		"main.tf":               "resource \"azapi_resource\" \"this\" {}\n",
+		"variables.tf":          "variable \"name\" {\n  type = string\n}\n",
+		"modules/child/main.tf": "# child\n",
+		"outputs.tf":            "output \"name\" {}\n",
+	}
+	mismatches, err = Verify(context.Background(), dir, c, fakeGenerator(changed))
+	require.NoError(t, err)
+	require.Len(t, mismatches, 3)
+
+	assert.Equal(t, "examples/default/x.txt", mismatches[0].File)
+	assert.Contains(t, mismatches[0].Diff, "+++ /dev/null")
+	assert.Equal(t, "outputs.tf", mismatches[1].File)
+	assert.Contains(t, mismatches[1].Diff, "--- /dev/null")
+	assert.Equal(t, "variables.tf", mismatches[2].File)
+	assert.Equal(t, `--- recorded/widget/variables.tf
++++ generated/widget/variables.tf
+@@ -1 +1,3 @@
+-variable "name" {}
++variable "name" {
++  type = string
++}
+`, mismatches[2].Diff)
+
+	// Recording again replaces the earlier output, dropping removed files.
+	_, err = Record(context.Background(), dir, c, fakeGenerator(changed))
+	require.NoError(t, err)
+	assert.NoFileExists(t, filepath.Join(dir, "widget", "examples", "default", "x.txt"))
+	mismatches, err = Verify(context.Background(), dir, c, fakeGenerator(changed))
+	require.NoError(t, err)
+	assert.Empty(t, mismatches)
+}
+
+func TestVerify_UnrecordedCase(t *testing.T) {
+	_, err := Verify(context.Background(), t.TempDir(), Case{Name: "widget"}, fakeGenerator(nil))
+	assert.ErrorContains(t, err, `snapshot case "widget" has not been recorded`)
+}
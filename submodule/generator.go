@@ -5,10 +5,12 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"sort"
 	"strings"
 
 	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
 	"github.com/hashicorp/hcl/v2/hclwrite"
 	"github.com/hashicorp/terraform-config-inspect/tfconfig"
 	"github.com/matt-FFFFFF/tfmodmake/hclgen"
@@ -17,9 +19,17 @@ import (
 
 // Generate reads a Terraform submodule at modulePath and writes variables.submodule.tf and main.submodule.tf
 // in the current working directory to expose the submodule as a map-based module block.
-func Generate(modulePath string) error {
+// The files written and removed are recorded in journal, which may be nil.
+func Generate(modulePath string, journal *hclgen.Journal) error {
+	return GenerateInto(".", modulePath, journal)
+}
+
+// GenerateInto is like Generate but wires the submodule into the module in
+// parentDir. modulePath is relative to parentDir, so nested submodules can be
+// wired into a child module.
+func GenerateInto(parentDir, modulePath string, journal *hclgen.Journal) error {
 	cleanPath := filepath.Clean(modulePath)
-	info, err := os.Stat(cleanPath)
+	info, err := os.Stat(filepath.Join(parentDir, cleanPath))
 	if err != nil {
 		return fmt.Errorf("failed to stat module path: %w", err)
 	}
@@ -27,7 +37,7 @@ func Generate(modulePath string) error {
 		return fmt.Errorf("module path is not a directory: %s", cleanPath)
 	}
 
-	module, diags := tfconfig.LoadModule(cleanPath)
+	module, diags := tfconfig.LoadModule(filepath.Join(parentDir, cleanPath))
 	if diags.HasErrors() {
 		return diags.Err()
 	}
@@ -37,37 +47,144 @@ func Generate(modulePath string) error {
 		moduleName = "module"
 	}
 
-	typeTokens, err := buildTypeTokens(module)
+	parent, diags := tfconfig.LoadModule(parentDir)
+	if diags.HasErrors() {
+		return fmt.Errorf("failed to load parent module: %w", diags.Err())
+	}
+	defaults := instanceDefaults(module, parent)
+
+	typeTokens, err := buildTypeTokens(module, defaults)
 	if err != nil {
 		return fmt.Errorf("failed to build variable type: %w", err)
 	}
 
-	desc := buildDescription(module)
+	desc := buildDescription(module, defaults)
+
+	moves, err := renameWrappers(parentDir, moduleName, cleanPath, journal)
+	if err != nil {
+		return err
+	}
 
-	if err := writeVariablesFile(moduleName, typeTokens, desc); err != nil {
+	if err := writeVariablesFile(parentDir, moduleName, typeTokens, desc, journal); err != nil {
 		return fmt.Errorf("failed to write variables.submodule.tf: %w", err)
 	}
 
-	if err := writeMainFile(moduleName, cleanPath, module); err != nil {
+	if err := writeMainFile(parentDir, moduleName, cleanPath, module, defaults, journal); err != nil {
 		return fmt.Errorf("failed to write main.submodule.tf: %w", err)
 	}
 
-	return nil
+	if err := writeOutputsFile(parentDir, moduleName, module, journal); err != nil {
+		return fmt.Errorf("failed to write outputs.submodule.tf: %w", err)
+	}
+
+	return hclgen.AppendMovedBlocks(parentDir, moves, journal)
+}
+
+// renameWrappers finds wrapper files in parentDir whose module block sources
+// sourcePath under a name other than moduleName, removes them so the module is
+// only called once, and returns the moves that carry the state over.
+func renameWrappers(parentDir, moduleName, sourcePath string, journal *hclgen.Journal) ([]hclgen.Move, error) {
+	paths, err := filepath.Glob(filepath.Join(parentDir, "main.*.tf"))
+	if err != nil {
+		return nil, err
+	}
+	source := fmt.Sprintf("%q", "./"+sourcePath)
+
+	var moves []hclgen.Move
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		file, diags := hclwrite.ParseConfig(data, path, hcl.Pos{Line: 1, Column: 1})
+		if diags.HasErrors() {
+			return nil, diags
+		}
+		for _, block := range file.Body().Blocks() {
+			if block.Type() != "module" || len(block.Labels()) != 1 {
+				continue
+			}
+			oldName := block.Labels()[0]
+			sourceAttr := block.Body().GetAttribute("source")
+			if oldName == moduleName || sourceAttr == nil || strings.TrimSpace(string(sourceAttr.Expr().BuildTokens(nil).Bytes())) != source {
+				continue
+			}
+			// Only wrappers written by Generate are replaced.
+			if filepath.Base(path) != fmt.Sprintf("main.%s.tf", oldName) {
+				continue
+			}
+			for _, stale := range []string{path, filepath.Join(parentDir, fmt.Sprintf("variables.%s.tf", oldName)), filepath.Join(parentDir, fmt.Sprintf("outputs.%s.tf", oldName))} {
+				if err := journal.Remove(stale); err != nil {
+					return nil, fmt.Errorf("failed to remove %s: %w", stale, err)
+				}
+			}
+			moves = append(moves, hclgen.Move{From: "module." + oldName, To: "module." + moduleName})
+		}
+	}
+	return moves, nil
+}
+
+// instanceDefault is the value a submodule variable takes when an instance does
+// not set it.
+type instanceDefault struct {
+	// expr is the HCL expression passed to the submodule, preferring the
+	// attribute of the instance.
+	expr string
+	// description explains the fallback to module consumers.
+	description string
+}
+
+// instanceDefaults returns the submodule variables that fall back to a value known
+// to the parent: name to the instance key, the scope of an extension resource to
+// the parent resource and, when the parent has the same variable, location and
+// tags to those of the parent.
+func instanceDefaults(module, parent *tfconfig.Module) map[string]instanceDefault {
+	defaults := map[string]instanceDefault{}
+	if v, ok := module.Variables["name"]; ok && isStringVariable(v) {
+		defaults["name"] = instanceDefault{expr: "coalesce(each.value.name, each.key)", description: "Defaults to the map key of the instance."}
+	}
+	if v, ok := module.Variables["scope"]; ok && isStringVariable(v) {
+		defaults["scope"] = instanceDefault{expr: "coalesce(each.value.scope, azapi_resource.this.id)", description: "Defaults to the ID of the parent resource."}
+	}
+	if parent == nil {
+		return defaults
+	}
+	if v, ok := module.Variables["location"]; ok && isStringVariable(v) && parent.Variables["location"] != nil {
+		defaults["location"] = instanceDefault{expr: "coalesce(each.value.location, var.location)", description: "Defaults to the location of the parent resource."}
+	}
+	if _, ok := module.Variables["tags"]; ok && parent.Variables["tags"] != nil {
+		// tags may be null on both sides, which coalesce rejects.
+		defaults["tags"] = instanceDefault{expr: "each.value.tags != null ? each.value.tags : var.tags", description: "Defaults to the tags of the parent resource."}
+	}
+	return defaults
+}
+
+func isStringVariable(v *tfconfig.Variable) bool {
+	return strings.TrimSpace(v.Type) == "string"
 }
 
-func buildDescription(module *tfconfig.Module) string {
+func buildDescription(module *tfconfig.Module, defaults map[string]instanceDefault) string {
+	var names []string
+	for name := range module.Variables {
+		if name != "parent_id" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
 	sb := strings.Builder{}
 	sb.WriteString("Map of instances for the submodule with the following attributes:\n\n")
-	for k, v := range module.Variables {
-		if k == "parent_id" {
-			continue
+	for _, name := range names {
+		description := module.Variables[name].Description
+		if d, ok := defaults[name]; ok {
+			description = strings.TrimSpace(description + " " + d.description)
 		}
-		sb.WriteString(fmt.Sprintf("**%s**\n%s\n", k, v.Description))
+		sb.WriteString(fmt.Sprintf("**%s**\n%s\n", name, description))
 	}
 	return sb.String()
 }
 
-func buildTypeTokens(module *tfconfig.Module) (hclwrite.Tokens, error) {
+func buildTypeTokens(module *tfconfig.Module, defaults map[string]instanceDefault) (hclwrite.Tokens, error) {
 	var variableNames []string
 	for name := range module.Variables {
 		if name == "parent_id" {
@@ -90,7 +207,7 @@ func buildTypeTokens(module *tfconfig.Module) (hclwrite.Tokens, error) {
 			return nil, fmt.Errorf("failed to parse type expression for %s: %w", name, err)
 		}
 
-		if !variable.Required {
+		if _, ok := defaults[name]; !variable.Required || ok {
 			typeTokens = hclwrite.TokensForFunctionCall("optional", typeTokens)
 		}
 
@@ -103,7 +220,7 @@ func buildTypeTokens(module *tfconfig.Module) (hclwrite.Tokens, error) {
 	return hclwrite.TokensForFunctionCall("map", hclwrite.TokensForFunctionCall("object", hclwrite.TokensForObject(attrs))), nil
 }
 
-func writeVariablesFile(moduleName string, typeTokens hclwrite.Tokens, description string) error {
+func writeVariablesFile(parentDir, moduleName string, typeTokens hclwrite.Tokens, description string, journal *hclgen.Journal) error {
 	file := hclwrite.NewEmptyFile()
 	body := file.Body()
 
@@ -113,11 +230,11 @@ func writeVariablesFile(moduleName string, typeTokens hclwrite.Tokens, descripti
 	blockBody.SetAttributeRaw("type", typeTokens)
 	blockBody.SetAttributeValue("default", cty.MapValEmpty(cty.DynamicPseudoType))
 
-	filename := fmt.Sprintf("variables.%s.tf", moduleName)
-	return os.WriteFile(filename, file.Bytes(), 0o644)
+	filename := filepath.Join(parentDir, fmt.Sprintf("variables.%s.tf", moduleName))
+	return journal.WriteFile(filename, file)
 }
 
-func writeMainFile(moduleName, sourcePath string, module *tfconfig.Module) error {
+func writeMainFile(parentDir, moduleName, sourcePath string, module *tfconfig.Module, defaults map[string]instanceDefault, journal *hclgen.Journal) error {
 	file := hclwrite.NewEmptyFile()
 	body := file.Body()
 
@@ -146,11 +263,88 @@ func writeMainFile(moduleName, sourcePath string, module *tfconfig.Module) error
 			argName = fmt.Sprintf("%s_version", moduleName)
 		}
 
+		if d, ok := defaults[name]; ok {
+			value, err := parseExpressionTokens(d.expr)
+			if err != nil {
+				return err
+			}
+			blockBody.SetAttributeRaw(argName, value)
+			continue
+		}
 		blockBody.SetAttributeRaw(argName, hclgen.TokensForTraversal("each", "value", name))
 	}
 
-	filename := fmt.Sprintf("main.%s.tf", moduleName)
-	return os.WriteFile(filename, file.Bytes(), 0o644)
+	filename := filepath.Join(parentDir, fmt.Sprintf("main.%s.tf", moduleName))
+	return journal.WriteFile(filename, file)
+}
+
+// leadingOutputs are the child module outputs listed first in the parent's output
+// for the submodule.
+var leadingOutputs = []string{"resource_id", "name"}
+
+// writeOutputsFile writes an output mapping each instance key of the submodule to
+// its outputs, resource_id and name first, so the parent module exposes its
+// children and their computed values. The output is sensitive when any child
+// output is. No file is written when the submodule has no outputs.
+func writeOutputsFile(parentDir, moduleName string, module *tfconfig.Module, journal *hclgen.Journal) error {
+	filename := filepath.Join(parentDir, fmt.Sprintf("outputs.%s.tf", moduleName))
+
+	var names []string
+	for _, name := range leadingOutputs {
+		if _, ok := module.Outputs[name]; ok {
+			names = append(names, name)
+		}
+	}
+	var others []string
+	for name := range module.Outputs {
+		if !slices.Contains(leadingOutputs, name) {
+			others = append(others, name)
+		}
+	}
+	sort.Strings(others)
+	names = append(names, others...)
+
+	var attrs []hclwrite.ObjectAttrTokens
+	sensitive := false
+	for _, name := range names {
+		sensitive = sensitive || module.Outputs[name].Sensitive
+		attrs = append(attrs, hclwrite.ObjectAttrTokens{
+			Name:  hclwrite.TokensForIdentifier(name),
+			Value: hclgen.TokensForTraversal("instance", name),
+		})
+	}
+	if len(attrs) == 0 {
+		if err := journal.Remove(filename); err != nil {
+			return err
+		}
+		return nil
+	}
+
+	var value hclwrite.Tokens
+	value = append(value, &hclwrite.Token{Type: hclsyntax.TokenOBrace, Bytes: []byte("{")})
+	value = append(value, hclwrite.TokensForIdentifier("for")...)
+	value = append(value, hclwrite.TokensForIdentifier("key")...)
+	value = append(value, &hclwrite.Token{Type: hclsyntax.TokenComma, Bytes: []byte(",")})
+	value = append(value, hclwrite.TokensForIdentifier("instance")...)
+	value = append(value, hclwrite.TokensForIdentifier("in")...)
+	value = append(value, hclgen.TokensForTraversal("module", moduleName)...)
+	value = append(value, &hclwrite.Token{Type: hclsyntax.TokenColon, Bytes: []byte(":")})
+	value = append(value, hclwrite.TokensForIdentifier("key")...)
+	value = append(value, &hclwrite.Token{Type: hclsyntax.TokenFatArrow, Bytes: []byte("=>")})
+	value = append(value, hclwrite.TokensForObject(attrs)...)
+	value = append(value, &hclwrite.Token{Type: hclsyntax.TokenCBrace, Bytes: []byte("}")})
+
+	file := hclwrite.NewEmptyFile()
+	body := file.Body()
+	block := body.AppendNewBlock("output", []string{moduleName})
+	blockBody := block.Body()
+	blockBody.SetAttributeValue("description", cty.StringVal(fmt.Sprintf("Map of %s instance keys to the outputs of each instance, including its resource ID and name.", moduleName)))
+	blockBody.SetAttributeRaw("value", value)
+	if sensitive {
+		blockBody.SetAttributeValue("sensitive", cty.True)
+	}
+
+	return journal.WriteFile(filename, file)
 }
 
 func parseExpressionTokens(expr string) (hclwrite.Tokens, error) {
@@ -34,7 +34,7 @@ variable "replicas" {
 		t.Fatalf("failed to chdir: %v", err)
 	}
 
-	if err := Generate("my-module"); err != nil {
+	if err := Generate("my-module", nil); err != nil {
 		t.Fatalf("generate failed: %v", err)
 	}
 
@@ -81,7 +81,7 @@ func TestBuildTypeTokensMarksNonRequiredAsOptional(t *testing.T) {
 		},
 	}
 
-	tokens, err := buildTypeTokens(module)
+	tokens, err := buildTypeTokens(module, nil)
 	if err != nil {
 		t.Fatalf("buildTypeTokens returned error: %v", err)
 	}
@@ -108,3 +108,330 @@ func TestBuildTypeTokensMarksNonRequiredAsOptional(t *testing.T) {
 		t.Fatalf("claims_matching_expression should not be optional, got: %s", content)
 	}
 }
+
+func TestGenerateRenamedWrapperWritesMovedBlock(t *testing.T) {
+	tempDir := t.TempDir()
+	moduleDir := filepath.Join(tempDir, "my-module")
+	if err := os.MkdirAll(moduleDir, 0o755); err != nil {
+		t.Fatalf("failed to create module dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(moduleDir, "variables.tf"), []byte("variable \"region\" {\n  type = string\n}\n"), 0o644); err != nil {
+		t.Fatalf("failed to write module variables: %v", err)
+	}
+
+	if err := os.Chdir(tempDir); err != nil {
+		t.Fatalf("failed to chdir: %v", err)
+	}
+
+	// A wrapper generated under a previous module name.
+	oldMain := "module \"mymodule\" {\n  source   = \"./my-module\"\n  for_each = var.mymodule\n}\n"
+	if err := os.WriteFile("main.mymodule.tf", []byte(oldMain), 0o644); err != nil {
+		t.Fatalf("failed to write old wrapper: %v", err)
+	}
+	if err := os.WriteFile("variables.mymodule.tf", []byte("variable \"mymodule\" {}\n"), 0o644); err != nil {
+		t.Fatalf("failed to write old wrapper variables: %v", err)
+	}
+
+	if err := Generate("my-module", nil); err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+
+	for _, stale := range []string{"main.mymodule.tf", "variables.mymodule.tf"} {
+		if _, err := os.Stat(stale); !os.IsNotExist(err) {
+			t.Fatalf("expected %s to be removed", stale)
+		}
+	}
+
+	moved, err := os.ReadFile("moved.tf")
+	if err != nil {
+		t.Fatalf("failed to read moved.tf: %v", err)
+	}
+	if !strings.Contains(string(moved), "from = module.mymodule") || !strings.Contains(string(moved), "to   = module.my_module") {
+		t.Fatalf("moved.tf missing module rename:\n%s", moved)
+	}
+}
+
+func TestGenerateWritesAggregatedOutputs(t *testing.T) {
+	tempDir := t.TempDir()
+	moduleDir := filepath.Join(tempDir, "certificate")
+	if err := os.MkdirAll(moduleDir, 0o755); err != nil {
+		t.Fatalf("failed to create module dir: %v", err)
+	}
+
+	moduleHCL := `
+variable "name" {
+  type = string
+}
+
+output "resource_id" {
+  value = "id"
+}
+
+output "name" {
+  value = var.name
+}
+`
+	if err := os.WriteFile(filepath.Join(moduleDir, "main.tf"), []byte(moduleHCL), 0o644); err != nil {
+		t.Fatalf("failed to write module: %v", err)
+	}
+
+	if err := os.Chdir(tempDir); err != nil {
+		t.Fatalf("failed to chdir: %v", err)
+	}
+
+	if err := Generate("certificate", nil); err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+
+	outputsContent, err := os.ReadFile(filepath.Join(tempDir, "outputs.certificate.tf"))
+	if err != nil {
+		t.Fatalf("failed to read outputs.certificate.tf: %v", err)
+	}
+	for _, want := range []string{
+		`output "certificate"`,
+		"for key, instance in module.certificate : key => {",
+		"resource_id = instance.resource_id",
+		"name        = instance.name",
+	} {
+		if !strings.Contains(string(outputsContent), want) {
+			t.Fatalf("outputs file missing %q:\n%s", want, outputsContent)
+		}
+	}
+}
+
+func TestGenerateDefaultsNameLocationAndTagsFromParent(t *testing.T) {
+	tempDir := t.TempDir()
+	moduleDir := filepath.Join(tempDir, "certificate")
+	if err := os.MkdirAll(moduleDir, 0o755); err != nil {
+		t.Fatalf("failed to create module dir: %v", err)
+	}
+
+	moduleHCL := `
+variable "name" {
+  type = string
+}
+
+variable "location" {
+  type = string
+}
+
+variable "parent_id" {
+  type = string
+}
+
+variable "tags" {
+  type    = map(string)
+  default = null
+}
+
+variable "value" {
+  type = string
+}
+`
+	if err := os.WriteFile(filepath.Join(moduleDir, "variables.tf"), []byte(moduleHCL), 0o644); err != nil {
+		t.Fatalf("failed to write module: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "variables.tf"), []byte("variable \"location\" {\n  type = string\n}\n\nvariable \"tags\" {\n  type    = map(string)\n  default = null\n}\n"), 0o644); err != nil {
+		t.Fatalf("failed to write parent variables: %v", err)
+	}
+
+	if err := os.Chdir(tempDir); err != nil {
+		t.Fatalf("failed to chdir: %v", err)
+	}
+
+	if err := Generate("certificate", nil); err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+
+	varsContent, err := os.ReadFile(filepath.Join(tempDir, "variables.certificate.tf"))
+	if err != nil {
+		t.Fatalf("failed to read variables.certificate.tf: %v", err)
+	}
+	for _, want := range []string{
+		"location = optional(string)",
+		"name     = optional(string)",
+		"tags     = optional(map(string))",
+		"value    = string",
+		"Defaults to the map key of the instance.",
+		"Defaults to the location of the parent resource.",
+		"Defaults to the tags of the parent resource.",
+	} {
+		if !strings.Contains(string(varsContent), want) {
+			t.Fatalf("variables file missing %q:\n%s", want, varsContent)
+		}
+	}
+	if strings.Contains(string(varsContent), "parent_id") {
+		t.Fatalf("variables file should not expose parent_id:\n%s", varsContent)
+	}
+
+	mainContent, err := os.ReadFile(filepath.Join(tempDir, "main.certificate.tf"))
+	if err != nil {
+		t.Fatalf("failed to read main.certificate.tf: %v", err)
+	}
+	for _, want := range []string{
+		"location  = coalesce(each.value.location, var.location)",
+		"name      = coalesce(each.value.name, each.key)",
+		"parent_id = azapi_resource.this.id",
+		"tags      = each.value.tags != null ? each.value.tags : var.tags",
+		"value     = each.value.value",
+	} {
+		if !strings.Contains(string(mainContent), want) {
+			t.Fatalf("main file missing %q:\n%s", want, mainContent)
+		}
+	}
+}
+
+func TestGenerateKeepsLocationRequiredWithoutParentLocation(t *testing.T) {
+	tempDir := t.TempDir()
+	moduleDir := filepath.Join(tempDir, "child")
+	if err := os.MkdirAll(moduleDir, 0o755); err != nil {
+		t.Fatalf("failed to create module dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(moduleDir, "variables.tf"), []byte("variable \"location\" {\n  type = string\n}\n"), 0o644); err != nil {
+		t.Fatalf("failed to write module variables: %v", err)
+	}
+
+	if err := os.Chdir(tempDir); err != nil {
+		t.Fatalf("failed to chdir: %v", err)
+	}
+
+	if err := Generate("child", nil); err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+
+	mainContent, err := os.ReadFile(filepath.Join(tempDir, "main.child.tf"))
+	if err != nil {
+		t.Fatalf("failed to read main.child.tf: %v", err)
+	}
+	if !strings.Contains(string(mainContent), "location = each.value.location") {
+		t.Fatalf("expected location to be passed through unchanged:\n%s", mainContent)
+	}
+}
+
+func TestGenerateIntoWiresNestedSubmodule(t *testing.T) {
+	tempDir := t.TempDir()
+	parentDir := filepath.Join(tempDir, "modules", "child")
+	moduleDir := filepath.Join(parentDir, "modules", "grandchild")
+	if err := os.MkdirAll(moduleDir, 0o755); err != nil {
+		t.Fatalf("failed to create module dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(moduleDir, "variables.tf"), []byte("variable \"name\" {\n  type = string\n}\n\nvariable \"parent_id\" {\n  type = string\n}\n"), 0o644); err != nil {
+		t.Fatalf("failed to write module variables: %v", err)
+	}
+
+	if err := os.Chdir(tempDir); err != nil {
+		t.Fatalf("failed to chdir: %v", err)
+	}
+
+	if err := GenerateInto(filepath.Join("modules", "child"), filepath.Join("modules", "grandchild"), nil); err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+
+	mainContent, err := os.ReadFile(filepath.Join(parentDir, "main.grandchild.tf"))
+	if err != nil {
+		t.Fatalf("failed to read main.grandchild.tf: %v", err)
+	}
+	for _, want := range []string{
+		`source    = "./modules/grandchild"`,
+		"parent_id = azapi_resource.this.id",
+	} {
+		if !strings.Contains(string(mainContent), want) {
+			t.Fatalf("main file missing %q:\n%s", want, mainContent)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(parentDir, "variables.grandchild.tf")); err != nil {
+		t.Fatalf("expected variables.grandchild.tf in the child module: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "main.grandchild.tf")); !os.IsNotExist(err) {
+		t.Fatalf("expected no wrapper in the working directory")
+	}
+}
+
+func TestGenerateWritesComputedChildOutputs(t *testing.T) {
+	tempDir := t.TempDir()
+	moduleDir := filepath.Join(tempDir, "certificate")
+	if err := os.MkdirAll(moduleDir, 0o755); err != nil {
+		t.Fatalf("failed to create module dir: %v", err)
+	}
+
+	moduleHCL := `
+output "resource_id" {
+  value = "id"
+}
+
+output "thumbprint" {
+  value = "thumbprint"
+}
+
+output "primary_key" {
+  value     = "key"
+  sensitive = true
+}
+`
+	if err := os.WriteFile(filepath.Join(moduleDir, "outputs.tf"), []byte(moduleHCL), 0o644); err != nil {
+		t.Fatalf("failed to write module: %v", err)
+	}
+
+	if err := os.Chdir(tempDir); err != nil {
+		t.Fatalf("failed to chdir: %v", err)
+	}
+
+	if err := Generate("certificate", nil); err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+
+	outputsContent, err := os.ReadFile(filepath.Join(tempDir, "outputs.certificate.tf"))
+	if err != nil {
+		t.Fatalf("failed to read outputs.certificate.tf: %v", err)
+	}
+	content := string(outputsContent)
+	for _, want := range []string{
+		"resource_id = instance.resource_id",
+		"primary_key = instance.primary_key",
+		"thumbprint  = instance.thumbprint",
+		"sensitive = true",
+	} {
+		if !strings.Contains(content, want) {
+			t.Fatalf("outputs file missing %q:\n%s", want, content)
+		}
+	}
+	if strings.Index(content, "resource_id =") > strings.Index(content, "primary_key =") {
+		t.Fatalf("expected resource_id to be listed first:\n%s", content)
+	}
+}
+
+func TestGenerateDefaultsScopeToParent(t *testing.T) {
+	tempDir := t.TempDir()
+	moduleDir := filepath.Join(tempDir, "tag")
+	if err := os.MkdirAll(moduleDir, 0o755); err != nil {
+		t.Fatalf("failed to create module dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(moduleDir, "variables.tf"), []byte("variable \"scope\" {\n  type = string\n}\n"), 0o644); err != nil {
+		t.Fatalf("failed to write module variables: %v", err)
+	}
+
+	if err := os.Chdir(tempDir); err != nil {
+		t.Fatalf("failed to chdir: %v", err)
+	}
+
+	if err := Generate("tag", nil); err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+
+	mainContent, err := os.ReadFile(filepath.Join(tempDir, "main.tag.tf"))
+	if err != nil {
+		t.Fatalf("failed to read main.tag.tf: %v", err)
+	}
+	if !strings.Contains(string(mainContent), "scope    = coalesce(each.value.scope, azapi_resource.this.id)") {
+		t.Fatalf("expected scope to default to the parent resource:\n%s", mainContent)
+	}
+	varsContent, err := os.ReadFile(filepath.Join(tempDir, "variables.tag.tf"))
+	if err != nil {
+		t.Fatalf("failed to read variables.tag.tf: %v", err)
+	}
+	for _, want := range []string{"scope = optional(string)", "Defaults to the ID of the parent resource."} {
+		if !strings.Contains(string(varsContent), want) {
+			t.Fatalf("variables file missing %q:\n%s", want, varsContent)
+		}
+	}
+}
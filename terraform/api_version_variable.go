@@ -0,0 +1,42 @@
+package terraform
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/matt-FFFFFF/tfmodmake/hclgen"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// apiVersionVariable selects the API version of a module generated for several.
+const apiVersionVariable = "api_version"
+
+// appendAPIVersionVariable appends the api_version variable, defaulting to the
+// latest of versions and rejecting any other value.
+func appendAPIVersionVariable(body *hclwrite.Body, versions []string) {
+	varBody := body.AppendNewBlock("variable", []string{apiVersionVariable}).Body()
+	hclgen.SetDescriptionAttribute(varBody, fmt.Sprintf("The API version the resource is deployed with. Properties only available in some API versions say so in their description. Supported versions: %s.", strings.Join(versions, ", ")))
+	varBody.SetAttributeRaw("type", hclwrite.TokensForIdentifier("string"))
+	varBody.SetAttributeValue("default", cty.StringVal(versions[len(versions)-1]))
+	varBody.SetAttributeValue("nullable", cty.False)
+
+	values := make([]cty.Value, len(versions))
+	for i, v := range versions {
+		values[i] = cty.StringVal(v)
+	}
+	appendValidation(varBody,
+		hclwrite.TokensForFunctionCall("contains", hclwrite.TokensForValue(cty.ListVal(values)), hclgen.TokensForTraversal("var", apiVersionVariable)),
+		fmt.Sprintf("api_version must be one of: %s.", strings.Join(versions, ", ")),
+	)
+	body.AppendNewline()
+}
+
+// tokensForResourceType returns the type argument of the resource: the type at
+// apiVersion, or at var.api_version when the module spans several versions.
+func tokensForResourceType(resourceType, apiVersion string, features optionalFeatures) hclwrite.Tokens {
+	if len(features.apiVersions) > 0 {
+		return interfaceExpression(fmt.Sprintf(`"%s@${var.%s}"`, cleanTypeString(resourceType), apiVersionVariable))
+	}
+	return hclwrite.TokensForValue(cty.StringVal(fmt.Sprintf("%s@%s", cleanTypeString(resourceType), apiVersion)))
+}
@@ -0,0 +1,105 @@
+package terraform
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/matt-FFFFFF/tfmodmake/hclgen"
+	"github.com/matt-FFFFFF/tfmodmake/naming"
+	"github.com/matt-FFFFFF/tfmodmake/schema"
+)
+
+// associationReference is a required property of an association resource that
+// holds the ID of the second resource it links its parent to.
+type associationReference struct {
+	// property is the name of the property under properties.
+	property string
+	// variable is the string variable carrying the resource ID.
+	variable string
+	// subResource is true when the property is an object whose only attribute is
+	// the id (the ARM SubResource pattern) rather than an ID string.
+	subResource bool
+	prop        *schema.Property
+}
+
+// associationReferences returns the references of a child or extension resource
+// that associates its parent with another resource: required properties that are
+// either a SubResource or an ID string (named *ResourceId or described as a
+// resource ID). Top-level resources are never treated as associations.
+func associationReferences(rs *schema.ResourceSchema, resourceType string) []associationReference {
+	if rs == nil || rs.Properties["properties"] == nil {
+		return nil
+	}
+	if strings.Count(cleanTypeString(resourceType), "/") < 2 && !rs.IsExtensionResource() {
+		return nil
+	}
+
+	var refs []associationReference
+	for name, prop := range rs.Properties["properties"].Children {
+		if prop == nil || !prop.Required || !isWritableProperty(prop) {
+			continue
+		}
+		tfName := naming.ToSnakeCase(name)
+		switch {
+		case isSubResource(prop):
+			refs = append(refs, associationReference{property: name, variable: tfName + "_id", subResource: true, prop: prop})
+		case isResourceIDString(name, prop):
+			refs = append(refs, associationReference{property: name, variable: tfName, prop: prop})
+		}
+	}
+	sort.Slice(refs, func(i, j int) bool { return refs[i].property < refs[j].property })
+	return refs
+}
+
+// isSubResource reports whether prop is an object holding nothing but a resource id.
+func isSubResource(prop *schema.Property) bool {
+	if prop.Type != schema.TypeObject || len(prop.Children) != 1 {
+		return false
+	}
+	id := prop.Children["id"]
+	return id != nil && id.Type == schema.TypeString && isWritableProperty(id)
+}
+
+// isResourceIDString reports whether prop is a string holding the ID of a resource.
+func isResourceIDString(name string, prop *schema.Property) bool {
+	if prop.Type != schema.TypeString || !strings.HasSuffix(name, "Id") {
+		return false
+	}
+	return strings.HasSuffix(name, "ResourceId") || strings.Contains(strings.ToLower(prop.Description), "resource id")
+}
+
+// associationDescription documents the ID variable of an association reference,
+// including how to order the association after the resources it links.
+func associationDescription(ref associationReference) string {
+	desc := strings.TrimSpace(ref.prop.Description)
+	if ref.subResource && ref.prop.Children["id"] != nil && desc == "" {
+		desc = strings.TrimSpace(ref.prop.Children["id"].Description)
+	}
+	if desc == "" {
+		desc = fmt.Sprintf("The resource ID referenced by %s.", ref.property)
+	}
+	return desc + " This resource associates the parent resource with the resource of this ID, so both must exist before it is created. Pass an attribute of the other resource (e.g. `module.example.resource_id`) for Terraform to order the deployments; when the ID is built from strings, add the resource to `depends_on` of the module call instead."
+}
+
+// appendAssociationComment notes above the resource which resources it links.
+func appendAssociationComment(body *hclwrite.Body, refs []associationReference) {
+	ids := make([]string, len(refs))
+	for i, ref := range refs {
+		ids[i] = "var." + ref.variable
+	}
+	comment := fmt.Sprintf("# Associates the parent resource with %s; both must exist before this resource is created.", strings.Join(ids, " and "))
+	body.AppendUnstructuredTokens(hclwrite.Tokens{
+		&hclwrite.Token{Type: hclsyntax.TokenComment, Bytes: []byte(comment)},
+		&hclwrite.Token{Type: hclsyntax.TokenNewline, Bytes: []byte("\n")},
+	})
+}
+
+// tokensForSubResourceValue returns the body value of a SubResource reference.
+func tokensForSubResourceValue(variable string) hclwrite.Tokens {
+	return hclwrite.TokensForObject([]hclwrite.ObjectAttrTokens{
+		{Name: hclwrite.TokensForIdentifier("id"), Value: hclgen.TokensForTraversal("var", variable)},
+	})
+}
@@ -0,0 +1,73 @@
+package terraform
+
+import (
+	"os"
+	"testing"
+
+	"github.com/matt-FFFFFF/tfmodmake/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func associationSchema() *schema.ResourceSchema {
+	return &schema.ResourceSchema{
+		Properties: map[string]*schema.Property{
+			"name": {Name: "name", Type: schema.TypeString, Required: true},
+			"properties": {Name: "properties", Type: schema.TypeObject, Required: true, Children: map[string]*schema.Property{
+				"remoteVirtualNetwork": {Name: "remoteVirtualNetwork", Type: schema.TypeObject, Required: true, Description: "Reference to the remote virtual network.", Children: map[string]*schema.Property{
+					"id": {Name: "id", Type: schema.TypeString, Description: "Resource ID."},
+				}},
+				"dataCollectionRuleId": {Name: "dataCollectionRuleId", Type: schema.TypeString, Required: true, Description: "The resource ID of the data collection rule."},
+				"tenantId":             {Name: "tenantId", Type: schema.TypeString, Required: true, Description: "The tenant ID."},
+				"routeTable": {Name: "routeTable", Type: schema.TypeObject, Children: map[string]*schema.Property{
+					"id": {Name: "id", Type: schema.TypeString},
+				}},
+			}},
+		},
+	}
+}
+
+func TestAssociationReferences(t *testing.T) {
+	refs := associationReferences(associationSchema(), "Microsoft.Test/hubs/connections")
+	require.Len(t, refs, 2)
+	assert.Equal(t, "dataCollectionRuleId", refs[0].property)
+	assert.Equal(t, "data_collection_rule_id", refs[0].variable)
+	assert.False(t, refs[0].subResource)
+	assert.Equal(t, "remoteVirtualNetwork", refs[1].property)
+	assert.Equal(t, "remote_virtual_network_id", refs[1].variable)
+	assert.True(t, refs[1].subResource)
+
+	assert.Empty(t, associationReferences(associationSchema(), "Microsoft.Test/hubs"), "top-level resources are not associations")
+}
+
+func TestGenerate_AssociationResource(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+
+	require.NoError(t, os.Chdir(tmpDir))
+
+	require.NoError(t, Generate("Microsoft.Test/hubs/connections", WithResourceSchema(associationSchema()), WithAPIVersion("2024-01-01")))
+
+	varsBody := parseHCLBody(t, "variables.tf")
+	remote := requireBlock(t, varsBody, "variable", "remote_virtual_network_id")
+	assert.Equal(t, "string", expressionString(t, remote.Body.Attributes["type"].Expr))
+	assert.Contains(t, expressionString(t, remote.Body.Attributes["description"].Expr), "depends_on")
+	requireBlock(t, varsBody, "variable", "data_collection_rule_id")
+	requireBlock(t, varsBody, "variable", "route_table")
+	for _, block := range varsBody.Blocks {
+		assert.False(t, block.Type == "variable" && block.Labels[0] == "remote_virtual_network", "the SubResource object is replaced by its ID")
+	}
+
+	localsBody := parseHCLBody(t, "locals.tf")
+	locals := requireBlock(t, localsBody, "locals")
+	body := expressionString(t, locals.Body.Attributes["resource_body"].Expr)
+	assert.Contains(t, body, "remoteVirtualNetwork = {\n      id = var.remote_virtual_network_id\n    }")
+	assert.Contains(t, body, "dataCollectionRuleId = var.data_collection_rule_id")
+
+	main, err := os.ReadFile("main.tf")
+	require.NoError(t, err)
+	assert.Contains(t, string(main), "# Associates the parent resource with var.data_collection_rule_id and var.remote_virtual_network_id;")
+}
@@ -0,0 +1,274 @@
+package terraform
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/ext/typeexpr"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/matt-FFFFFF/tfmodmake/hclgen"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// moduleVariable is a variable declared by a module on disk.
+type moduleVariable struct {
+	name     string
+	ty       cty.Type
+	required bool
+	// typeSource and defaultSource are the expressions of the type and default
+	// attributes as written, empty when the attribute is absent.
+	typeSource    string
+	defaultSource string
+	// constraints are parsed from the validations of the variable.
+	constraints map[string]*exampleConstraint
+	// block is the variable block, parsed from src.
+	block *hclsyntax.Block
+	src   []byte
+}
+
+// ScaffoldAVMLayout creates the AVM repository skeleton around the module in dir:
+// tflint and terraform-docs configurations with the README header and footer
+// they inject, an examples/default root module calling it with its required
+// variables, an examples/complete root module setting every variable, and a
+// terratest module in tests/e2e deploying examples/default. Existing files are left untouched. It
+// returns the paths it created, relative to dir, and records them in journal,
+// which may be nil.
+func ScaffoldAVMLayout(dir string, journal *hclgen.Journal) ([]string, error) {
+	variables, err := readModuleVariables(dir)
+	if err != nil {
+		return nil, err
+	}
+	terraformBlock, err := moduleTerraformBlock(dir)
+	if err != nil {
+		return nil, err
+	}
+	declared, err := declaredNames(dir, "output")
+	if err != nil {
+		return nil, err
+	}
+	var outputs []string
+	for _, name := range e2eOutputs {
+		if _, ok := declared[name]; ok {
+			outputs = append(outputs, name)
+		}
+	}
+	e2eTest, err := renderE2ETemplate("e2e_test.go", outputs)
+	if err != nil {
+		return nil, err
+	}
+	e2eHelpers, err := renderE2ETemplate("helpers_test.go", outputs)
+	if err != nil {
+		return nil, err
+	}
+	e2eGoMod, err := renderE2ETemplate("go.mod", outputs)
+	if err != nil {
+		return nil, err
+	}
+	tooling := map[string][]byte{}
+	for _, name := range []string{"tflint.hcl", "terraform-docs.yml", "example-terraform-docs.yml", "footer.md"} {
+		if tooling[name], err = toolingFile(name); err != nil {
+			return nil, err
+		}
+	}
+
+	// The bootstrap of examples needs the type of the module's resource; modules
+	// without an azapi_resource.this only get a resource group.
+	resourceType, apiVersion := moduleResourceType(dir)
+	spec, err := moduleSpecExampleValues(dir, resourceType)
+	if err != nil {
+		return nil, err
+	}
+
+	files := []scaffoldFile{
+		{".tflint.hcl", tooling["tflint.hcl"]},
+		{".terraform-docs.yml", tooling["terraform-docs.yml"]},
+		{"_header.md", buildModuleHeader(dir, resourceType)},
+		{"_footer.md", tooling["footer.md"]},
+		{filepath.Join("examples", "default", ".terraform-docs.yml"), tooling["example-terraform-docs.yml"]},
+		{filepath.Join("examples", "default", "_header.md"), []byte("# Default example\n\nThis deploys the module in its simplest form, setting only the required variables.\n")},
+		{filepath.Join("examples", "default", "main.tf"), buildExampleMain(terraformBlock, variables, newExampleBootstrap(resourceType, apiVersion, variables, false), spec, false)},
+		{filepath.Join("examples", "default", "variables.tf"), buildExampleVariables()},
+		{filepath.Join("examples", "complete", ".terraform-docs.yml"), tooling["example-terraform-docs.yml"]},
+		{filepath.Join("examples", "complete", "_header.md"), []byte("# Complete example\n\nThis sets every variable of the module to a value satisfying its type and validations, as a starting point for full-coverage examples and end-to-end tests. Replace the placeholders before deploying it.\n")},
+		{filepath.Join("examples", "complete", "main.tf"), buildExampleMain(terraformBlock, variables, newExampleBootstrap(resourceType, apiVersion, variables, true), spec, true)},
+		{filepath.Join("examples", "complete", "variables.tf"), buildExampleVariables()},
+		{filepath.Join("tests", "e2e", "go.mod"), e2eGoMod},
+		{filepath.Join("tests", "e2e", "helpers_test.go"), e2eHelpers},
+		{filepath.Join("tests", "e2e", "e2e_test.go"), e2eTest},
+	}
+
+	if len(outputs) > 0 {
+		files = append(files, scaffoldFile{filepath.Join("examples", "default", "outputs.tf"), buildExampleOutputs(outputs)})
+	}
+
+	return writeScaffoldFiles(dir, files, journal)
+}
+
+// scaffoldFile is a file written by a scaffold, at path relative to the module.
+type scaffoldFile struct {
+	path    string
+	content []byte
+}
+
+// writeScaffoldFiles writes the files below dir, skipping those that already
+// exist, and returns the paths it created.
+func writeScaffoldFiles(dir string, files []scaffoldFile, journal *hclgen.Journal) ([]string, error) {
+	var created []string
+	for _, f := range files {
+		path := filepath.Join(dir, f.path)
+		if _, err := os.Stat(path); err == nil {
+			continue
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return nil, err
+		}
+		if err := journal.WriteIfChanged(path, f.content); err != nil {
+			return nil, err
+		}
+		created = append(created, f.path)
+	}
+	return created, nil
+}
+
+// buildExampleMain calls the module from an example, with the Terraform and
+// provider constraints of the module, the prerequisite resources of bootstrap
+// and a value for every required variable, or for every variable and optional
+// attribute when complete is true. Values come from the spec examples where
+// they fit.
+func buildExampleMain(terraformBlock *hclwrite.Block, variables []moduleVariable, bootstrap *exampleBootstrap, spec specExampleValues, complete bool) []byte {
+	file := hclwrite.NewEmptyFile()
+	body := file.Body()
+	if terraformBlock != nil {
+		body.AppendBlock(terraformBlock)
+		body.AppendNewline()
+	}
+	bootstrap.appendTo(body)
+
+	moduleBody := body.AppendNewBlock("module", []string{"test"}).Body()
+	moduleBody.SetAttributeValue("source", cty.StringVal("../../"))
+	moduleBody.AppendNewline()
+	for _, v := range variables {
+		if v.name == "enable_telemetry" || !(v.required || complete) {
+			continue
+		}
+		b := &exampleBuilder{constraints: v.constraints, complete: complete, overrides: bootstrap.overrides, spec: spec}
+		moduleBody.SetAttributeRaw(v.name, b.tokens(v.name, v.name, v.ty))
+	}
+	moduleBody.SetAttributeRaw("enable_telemetry", hclgen.TokensForTraversal("var", "enable_telemetry"))
+
+	src := strings.TrimRight(string(hclwrite.Format(file.Bytes())), "\n")
+	return []byte(src + "\n")
+}
+
+// buildExampleVariables declares the enable_telemetry variable every AVM example passes on.
+func buildExampleVariables() []byte {
+	file := hclwrite.NewEmptyFile()
+	varBody := file.Body().AppendNewBlock("variable", []string{"enable_telemetry"}).Body()
+	hclgen.SetDescriptionAttribute(varBody, "This variable controls whether or not telemetry is enabled for the module.\nFor more information see <https://aka.ms/avm/telemetryinfo>.\nIf it is set to false, then no telemetry will be collected.")
+	varBody.SetAttributeRaw("type", hclwrite.TokensForIdentifier("bool"))
+	varBody.SetAttributeValue("default", cty.True)
+
+	src := strings.TrimRight(string(hclwrite.Format(file.Bytes())), "\n")
+	return []byte(src + "\n")
+}
+
+// readModuleVariables returns the variables declared in the .tf files of dir, in
+// declaration order with variables.tf first.
+func readModuleVariables(dir string) ([]moduleVariable, error) {
+	var first, rest []moduleVariable
+	err := parseTFFiles(dir, func(name string, src []byte, body *hclsyntax.Body) error {
+		declared, err := moduleVariables(filepath.Join(dir, name), src, body)
+		if err != nil {
+			return err
+		}
+		if name == "variables.tf" {
+			first = append(first, declared...)
+		} else {
+			rest = append(rest, declared...)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return append(first, rest...), nil
+}
+
+// parseModuleVariables returns the variables declared in the .tf source src,
+// read from path, in declaration order.
+func parseModuleVariables(path string, src []byte) ([]moduleVariable, error) {
+	body, err := parseSyntaxBody(src, path)
+	if err != nil {
+		return nil, err
+	}
+	return moduleVariables(path, src, body)
+}
+
+// moduleVariables returns the variables declared in body, parsed from the .tf
+// source src read from path, in declaration order.
+func moduleVariables(path string, src []byte, body *hclsyntax.Body) ([]moduleVariable, error) {
+	var variables []moduleVariable
+	for _, block := range body.Blocks {
+		if block.Type != "variable" || len(block.Labels) != 1 {
+			continue
+		}
+		v := moduleVariable{name: block.Labels[0], ty: cty.DynamicPseudoType, block: block, src: src}
+		defaultAttr, hasDefault := block.Body.Attributes["default"]
+		v.required = !hasDefault
+		if hasDefault {
+			v.defaultSource = attributeSource(src, defaultAttr)
+		}
+		if typeAttr, ok := block.Body.Attributes["type"]; ok {
+			v.typeSource = attributeSource(src, typeAttr)
+			ty, _, diags := typeexpr.TypeConstraintWithDefaults(typeAttr.Expr)
+			if diags.HasErrors() {
+				return nil, fmt.Errorf("invalid type of variable %s in %s: %s", v.name, path, diags.Error())
+			}
+			v.ty = ty
+		}
+		var conditions []hclsyntax.Expression
+		for _, validation := range block.Body.Blocks {
+			if condition, ok := validation.Body.Attributes["condition"]; validation.Type == "validation" && ok {
+				conditions = append(conditions, condition.Expr)
+			}
+		}
+		v.constraints = validationConstraints(conditions)
+		variables = append(variables, v)
+	}
+	return variables, nil
+}
+
+// attributeSource returns the source of the expression of attr, with the
+// indentation of the attribute removed from its continuation lines.
+func attributeSource(src []byte, attr *hclsyntax.Attribute) string {
+	indent := strings.Repeat(" ", attr.SrcRange.Start.Column-1)
+	lines := strings.Split(exprSource(src, attr.Expr), "\n")
+	for i := 1; i < len(lines); i++ {
+		lines[i] = strings.TrimPrefix(lines[i], indent)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// moduleTerraformBlock returns the terraform block of the module's terraform.tf, or
+// nil when there is none.
+func moduleTerraformBlock(dir string) (*hclwrite.Block, error) {
+	file, err := ParseHCLFile(filepath.Join(dir, "terraform.tf"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, block := range file.Body().Blocks() {
+		if block.Type() == "terraform" {
+			return block, nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,188 @@
+package terraform
+
+import (
+	"go/format"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/ext/typeexpr"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/matt-FFFFFF/tfmodmake/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestScaffoldAVMLayout(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, Generate("Microsoft.Test/widgets", WithResourceSchema(avmStrictSchema()), WithAPIVersion("2024-01-01"), WithAVMStrict(true), WithOutputDir(dir)))
+
+	created, err := ScaffoldAVMLayout(dir, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		".tflint.hcl",
+		".terraform-docs.yml",
+		"_header.md",
+		"_footer.md",
+		filepath.Join("examples", "default", ".terraform-docs.yml"),
+		filepath.Join("examples", "default", "_header.md"),
+		filepath.Join("examples", "default", "main.tf"),
+		filepath.Join("examples", "default", "variables.tf"),
+		filepath.Join("examples", "complete", ".terraform-docs.yml"),
+		filepath.Join("examples", "complete", "_header.md"),
+		filepath.Join("examples", "complete", "main.tf"),
+		filepath.Join("examples", "complete", "variables.tf"),
+		filepath.Join("tests", "e2e", "go.mod"),
+		filepath.Join("tests", "e2e", "helpers_test.go"),
+		filepath.Join("tests", "e2e", "e2e_test.go"),
+		filepath.Join("examples", "default", "outputs.tf"),
+	}, created)
+
+	body := parseHCLBody(t, filepath.Join(dir, "examples", "default", "main.tf"))
+
+	tf := requireBlock(t, body, "terraform")
+	assert.Equal(t, `"~> 1.12"`, expressionString(t, tf.Body.Attributes["required_version"].Expr))
+	providers := requireBlock(t, tf.Body, "required_providers")
+	assert.Contains(t, providers.Body.Attributes, "modtm")
+
+	module := requireBlock(t, body, "module", "test")
+	assert.Equal(t, `"../../"`, expressionString(t, module.Body.Attributes["source"].Expr))
+	assert.Equal(t, "azapi_resource.resource_group.location", expressionString(t, module.Body.Attributes["location"].Expr))
+	assert.Contains(t, module.Body.Attributes, "name")
+	assert.Equal(t, "azapi_resource.resource_group.id", expressionString(t, module.Body.Attributes["parent_id"].Expr))
+	rg := requireBlock(t, body, "resource", "azapi_resource", "resource_group")
+	assert.Equal(t, `"eastus"`, expressionString(t, rg.Body.Attributes["location"].Expr))
+	requireBlock(t, body, "data", "azapi_client_config", "current")
+	assert.NotContains(t, module.Body.Attributes, "tags", "optional variables are left to the module defaults")
+	assert.Equal(t, "var.enable_telemetry", expressionString(t, module.Body.Attributes["enable_telemetry"].Expr))
+
+	complete := requireBlock(t, parseHCLBody(t, filepath.Join(dir, "examples", "complete", "main.tf")), "module", "test")
+	assert.Contains(t, complete.Body.Attributes, "tags", "the complete example sets optional variables")
+	assert.Equal(t, "false", expressionString(t, complete.Body.Attributes["enabled"].Expr))
+	assert.Equal(t, `{
+  example = "example"
+}`, expressionString(t, complete.Body.Attributes["tags"].Expr))
+	assert.Equal(t, "var.enable_telemetry", expressionString(t, complete.Body.Attributes["enable_telemetry"].Expr))
+
+	// Hand edits survive a second run.
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "examples", "default", "main.tf"), []byte("# customised\n"), 0o644))
+	created, err = ScaffoldAVMLayout(dir, nil)
+	require.NoError(t, err)
+	assert.Empty(t, created)
+	src, err := os.ReadFile(filepath.Join(dir, "examples", "default", "main.tf"))
+	require.NoError(t, err)
+	assert.Equal(t, "# customised\n", string(src))
+}
+
+func TestExampleValue_RequiredObjectAttributes(t *testing.T) {
+	expr, diags := hclsyntax.ParseExpression([]byte(`object({ kind = string, name = optional(string), count = number })`), "type", hcl.Pos{Line: 1, Column: 1})
+	require.False(t, diags.HasErrors())
+	ty, _, diags := typeexpr.TypeConstraintWithDefaults(expr)
+	require.False(t, diags.HasErrors())
+	value := exampleValue("lock", ty)
+	assert.True(t, value.Type().HasAttribute("kind"))
+	assert.True(t, value.Type().HasAttribute("count"))
+	assert.False(t, value.Type().HasAttribute("name"))
+}
+
+func TestExampleBuilder_SatisfiesValidations(t *testing.T) {
+	var conditions []hclsyntax.Expression
+	for _, src := range []string{
+		`var.sku == null || contains(["Premium", "Standard"], var.sku.tier)`,
+		`var.capacity >= 2 && var.capacity <= 10`,
+		`can(regex("^[a-z][0-9]{3}$", var.code))`,
+		`length(var.prefix) <= 3`,
+	} {
+		expr, diags := hclsyntax.ParseExpression([]byte(src), "condition", hcl.Pos{Line: 1, Column: 1})
+		require.False(t, diags.HasErrors())
+		conditions = append(conditions, expr)
+	}
+	b := &exampleBuilder{constraints: validationConstraints(conditions), complete: true}
+
+	sku := b.value("sku", "sku", cty.Object(map[string]cty.Type{"tier": cty.String}))
+	assert.Equal(t, "Premium", sku.GetAttr("tier").AsString())
+	capacity, _ := b.value("capacity", "capacity", cty.Number).AsBigFloat().Int64()
+	assert.Equal(t, int64(6), capacity)
+	assert.Equal(t, "a000", b.value("code", "code", cty.String).AsString())
+	assert.Equal(t, "exa", b.value("prefix", "prefix", cty.String).AsString())
+	assert.Equal(t, 1, b.value("tags", "tags", cty.Map(cty.String)).LengthInt())
+}
+
+func TestScaffoldAVMLayout_BootstrapsPrerequisites(t *testing.T) {
+	dir := t.TempDir()
+	rs := avmStrictSchema()
+	rs.SupportsIdentity = true
+	rs.Properties["identity"] = &schema.Property{Name: "identity", Type: schema.TypeObject, Children: map[string]*schema.Property{
+		"type": {Name: "type", Type: schema.TypeString},
+	}}
+	require.NoError(t, Generate("Microsoft.Test/widgets/gadgets/parts", WithResourceSchema(rs), WithAPIVersion("2024-01-01"), WithAVMStrict(true), WithOutputDir(dir)))
+
+	_, err := ScaffoldAVMLayout(dir, nil)
+	require.NoError(t, err)
+
+	body := parseHCLBody(t, filepath.Join(dir, "examples", "complete", "main.tf"))
+	widget := requireBlock(t, body, "resource", "azapi_resource", "widget")
+	assert.Equal(t, `"Microsoft.Test/widgets@2024-01-01"`, expressionString(t, widget.Body.Attributes["type"].Expr))
+	assert.Equal(t, "azapi_resource.resource_group.id", expressionString(t, widget.Body.Attributes["parent_id"].Expr))
+	gadget := requireBlock(t, body, "resource", "azapi_resource", "gadget")
+	assert.Equal(t, `"Microsoft.Test/widgets/gadgets@2024-01-01"`, expressionString(t, gadget.Body.Attributes["type"].Expr))
+	assert.Equal(t, "azapi_resource.widget.id", expressionString(t, gadget.Body.Attributes["parent_id"].Expr))
+	requireBlock(t, body, "resource", "azapi_resource", "user_assigned_identity")
+
+	module := requireBlock(t, body, "module", "test")
+	assert.Equal(t, "azapi_resource.gadget.id", expressionString(t, module.Body.Attributes["parent_id"].Expr))
+	assert.Contains(t, expressionString(t, module.Body.Attributes["managed_identities"].Expr), "user_assigned_resource_ids = [azapi_resource.user_assigned_identity.id]")
+}
+
+func TestScaffoldAVMLayout_E2ETest(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, Generate("Microsoft.Test/widgets", WithResourceSchema(avmStrictSchema()), WithAPIVersion("2024-01-01"), WithAVMStrict(true), WithOutputDir(dir)))
+
+	_, err := ScaffoldAVMLayout(dir, nil)
+	require.NoError(t, err)
+
+	outputs := parseHCLBody(t, filepath.Join(dir, "examples", "default", "outputs.tf"))
+	resourceID := requireBlock(t, outputs, "output", "resource_id")
+	assert.Equal(t, "module.test.resource_id", expressionString(t, resourceID.Body.Attributes["value"].Expr))
+	requireBlock(t, outputs, "output", "name")
+
+	for _, name := range []string{"e2e_test.go", "helpers_test.go"} {
+		src, err := os.ReadFile(filepath.Join(dir, "tests", "e2e", name))
+		require.NoError(t, err)
+		formatted, err := format.Source(src)
+		require.NoError(t, err, name)
+		assert.Equal(t, string(formatted), string(src), "%s is gofmt-formatted", name)
+	}
+	src, err := os.ReadFile(filepath.Join(dir, "tests", "e2e", "e2e_test.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(src), `assert.NotEmpty(t, terraform.Output(t, options, "resource_id"), "output resource_id")`)
+	assert.Contains(t, string(src), `terraform.InitAndApply(t, options)`)
+}
+
+func TestScaffoldAVMLayout_ToolingConfigs(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, Generate("Microsoft.Test/widgets", WithResourceSchema(avmStrictSchema()), WithAPIVersion("2024-01-01"), WithAVMStrict(true), WithOutputDir(dir)))
+
+	_, err := ScaffoldAVMLayout(dir, nil)
+	require.NoError(t, err)
+
+	tflint := parseHCLBody(t, filepath.Join(dir, ".tflint.hcl"))
+	avm := requireBlock(t, tflint, "plugin", "avm")
+	assert.Equal(t, `"github.com/Azure/tflint-ruleset-avm"`, expressionString(t, avm.Body.Attributes["source"].Expr))
+	structure := requireBlock(t, tflint, "rule", "terraform_standard_module_structure")
+	assert.Equal(t, "false", expressionString(t, structure.Body.Attributes["enabled"].Expr))
+
+	docs, err := os.ReadFile(filepath.Join(dir, ".terraform-docs.yml"))
+	require.NoError(t, err)
+	assert.Contains(t, string(docs), `header-from: "_header.md"`)
+	assert.Contains(t, string(docs), `footer-from: "_footer.md"`)
+	exampleDocs, err := os.ReadFile(filepath.Join(dir, "examples", "default", ".terraform-docs.yml"))
+	require.NoError(t, err)
+	assert.Contains(t, string(exampleDocs), `{{ include "main.tf" }}`)
+
+	header, err := os.ReadFile(filepath.Join(dir, "_header.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "# "+filepath.Base(dir)+"\n\nThis is a Terraform module deploying `Microsoft.Test/widgets` with the azapi provider.\n", string(header))
+}
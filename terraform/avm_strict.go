@@ -0,0 +1,116 @@
+package terraform
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// avmRequiredOutputs are the outputs every AVM resource module exposes.
+var avmRequiredOutputs = []string{"resource_id", "resource", "name"}
+
+// avmNamePattern is the snake_case naming rule for AVM variables and outputs.
+var avmNamePattern = regexp.MustCompile(`^[a-z][a-z0-9]*(_[a-z0-9]+)*$`)
+
+// hasFixedName reports whether azapi_resource.this in main.tf is given a literal
+// name, as singleton resources are, rather than var.name.
+func hasFixedName(mod *GeneratedModule) bool {
+	if mod.Main == nil {
+		return false
+	}
+	body, err := parseSyntaxBody(mod.Main.Bytes(), "main.tf")
+	if err != nil {
+		return false
+	}
+	for _, block := range body.Blocks {
+		if block.Type == "resource" && len(block.Labels) == 2 && block.Labels[1] == "this" {
+			return literalString(block.Body.Attributes["name"]) != ""
+		}
+	}
+	return false
+}
+
+// avmDeviations lists how the generated module departs from the AVM resource
+// module interface: required outputs and the enable_telemetry variable, snake_case
+// names, and a type and description on every variable and output.
+func avmDeviations(mod *GeneratedModule, features optionalFeatures, supportsLocation, supportsTags bool) ([]string, error) {
+	var deviations []string
+	if features.updateResource {
+		deviations = append(deviations, "the module manages an existing resource with azapi_update_resource; AVM resource modules create their resource")
+	}
+
+	variables := map[string]*hclsyntax.Block{}
+	if mod.Variables != nil {
+		body, err := parseSyntaxBody(mod.Variables.Bytes(), "variables.tf")
+		if err != nil {
+			return nil, err
+		}
+		for _, block := range body.Blocks {
+			if block.Type != "variable" || len(block.Labels) != 1 {
+				continue
+			}
+			name := block.Labels[0]
+			variables[name] = block
+			if !avmNamePattern.MatchString(name) {
+				deviations = append(deviations, fmt.Sprintf("variable %q is not snake_case", name))
+			}
+			if _, ok := block.Body.Attributes["type"]; !ok {
+				deviations = append(deviations, fmt.Sprintf("variable %q has no type", name))
+			}
+			if strings.TrimSpace(literalString(block.Body.Attributes["description"])) == "" {
+				deviations = append(deviations, fmt.Sprintf("variable %q has no description", name))
+			}
+		}
+	}
+
+	required := []string{"enable_telemetry"}
+	if !hasFixedName(mod) {
+		required = append(required, "name")
+	}
+	if supportsLocation {
+		required = append(required, "location")
+	}
+	if supportsTags {
+		required = append(required, "tags")
+	}
+	for _, name := range required {
+		if _, ok := variables[name]; !ok {
+			deviations = append(deviations, fmt.Sprintf("required variable %q is missing", name))
+		}
+	}
+
+	outputs := map[string]struct{}{}
+	if mod.Outputs != nil {
+		body, err := parseSyntaxBody(mod.Outputs.Bytes(), "outputs.tf")
+		if err != nil {
+			return nil, err
+		}
+		for _, block := range body.Blocks {
+			if block.Type != "output" || len(block.Labels) != 1 {
+				continue
+			}
+			name := block.Labels[0]
+			outputs[name] = struct{}{}
+			if !avmNamePattern.MatchString(name) {
+				deviations = append(deviations, fmt.Sprintf("output %q is not snake_case", name))
+			}
+			if strings.TrimSpace(literalString(block.Body.Attributes["description"])) == "" {
+				deviations = append(deviations, fmt.Sprintf("output %q has no description", name))
+			}
+		}
+	}
+	for _, name := range avmRequiredOutputs {
+		if _, ok := outputs[name]; !ok {
+			deviations = append(deviations, fmt.Sprintf("required output %q is missing", name))
+		}
+	}
+
+	return deviations, nil
+}
+
+// avmStrictError reports the AVM deviations generation could not reconcile.
+func avmStrictError(deviations []string) error {
+	return fmt.Errorf("generated module does not conform to the AVM resource module interface:\n  - %s", strings.Join(deviations, "\n  - "))
+}
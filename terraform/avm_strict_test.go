@@ -0,0 +1,83 @@
+package terraform
+
+import (
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/matt-FFFFFF/tfmodmake/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func avmStrictSchema() *schema.ResourceSchema {
+	return &schema.ResourceSchema{
+		SupportsTags:     true,
+		SupportsLocation: true,
+		Properties: map[string]*schema.Property{
+			"name":     {Name: "name", Type: schema.TypeString, Required: true},
+			"location": {Name: "location", Type: schema.TypeString, Required: true},
+			"tags":     {Name: "tags", Type: schema.TypeObject, AdditionalProperties: &schema.Property{Type: schema.TypeString}},
+			"properties": {Name: "properties", Type: schema.TypeObject, Children: map[string]*schema.Property{
+				"enabled": {Name: "enabled", Type: schema.TypeBoolean},
+			}},
+		},
+	}
+}
+
+func TestGenerate_AVMStrictAddsResourceOutput(t *testing.T) {
+	mod, err := GenerateInMemory("Microsoft.Test/widgets", WithResourceSchema(avmStrictSchema()), WithAPIVersion("2024-01-01"), WithAVMStrict(true))
+	require.NoError(t, err)
+
+	body, err := parseSyntaxBody(mod.Outputs.Bytes(), "outputs.tf")
+	require.NoError(t, err)
+	var names []string
+	for _, block := range body.Blocks {
+		names = append(names, block.Labels[0])
+	}
+	assert.Contains(t, names, "resource")
+}
+
+func TestGenerate_AVMStrictRejectsUpdateResource(t *testing.T) {
+	_, err := GenerateInMemory("Microsoft.Test/widgets", WithResourceSchema(avmStrictSchema()), WithAPIVersion("2024-01-01"), WithAVMStrict(true), WithUpdateResource(true))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "azapi_update_resource")
+
+	_, err = GenerateInMemory("Microsoft.Test/widgets", WithResourceSchema(avmStrictSchema()), WithAPIVersion("2024-01-01"), WithUpdateResource(true))
+	assert.NoError(t, err)
+}
+
+func TestAVMDeviations(t *testing.T) {
+	variables, diags := hclwrite.ParseConfig([]byte(`variable "name" {
+  type        = string
+  description = "The name."
+}
+
+variable "SkuName" {
+  type        = string
+  description = "The SKU."
+}
+
+variable "untyped" {
+  description = "Untyped."
+}
+`), "variables.tf", hcl.Pos{Line: 1, Column: 1})
+	require.False(t, diags.HasErrors())
+	outputs, diags := hclwrite.ParseConfig([]byte(`output "resource_id" {
+  value = azapi_resource.this.id
+}
+`), "outputs.tf", hcl.Pos{Line: 1, Column: 1})
+	require.False(t, diags.HasErrors())
+
+	deviations, err := avmDeviations(&GeneratedModule{Variables: variables, Outputs: outputs}, optionalFeatures{}, true, false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		`variable "SkuName" is not snake_case`,
+		`variable "untyped" has no type`,
+		`required variable "enable_telemetry" is missing`,
+		`required variable "location" is missing`,
+		`output "resource_id" has no description`,
+		`required output "resource" is missing`,
+		`required output "name" is missing`,
+	}, deviations)
+}
@@ -0,0 +1,97 @@
+package terraform
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Backend emits the provider-specific files of a module: the provider
+// requirements, the locals shaping the request, the resource and its outputs.
+// Every backend declares the variables, with their types and validations, the
+// schema describes.
+type Backend interface {
+	// Name selects the backend with the -backend flag.
+	Name() string
+	build(o *generatorOptions) (*GeneratedModule, error)
+}
+
+var (
+	// AzAPIBackend manages the resource with azapi_resource (the default).
+	AzAPIBackend Backend = azapiBackend{}
+	// AzureRMBackend is experimental: it manages the resource with the closest
+	// azurerm resource, mapping the common arguments and reporting the variables
+	// that still have to be mapped by hand.
+	AzureRMBackend Backend = azurermBackend{}
+	// MSGraphBackend manages Microsoft Graph objects, loaded from Graph bicep
+	// types (Microsoft.Graph/<collection>), with msgraph_resource.
+	MSGraphBackend Backend = msgraphBackend{}
+)
+
+// Backends lists the available backends, the default first.
+var Backends = []Backend{AzAPIBackend, AzureRMBackend, MSGraphBackend}
+
+type azapiBackend struct{}
+
+func (azapiBackend) Name() string { return "azapi" }
+
+func (azapiBackend) build(o *generatorOptions) (*GeneratedModule, error) {
+	return buildAzAPIModule(o)
+}
+
+// ParseBackend parses a -backend flag value. An empty value selects azapi.
+func ParseBackend(s string) (Backend, error) {
+	if s == "" {
+		return AzAPIBackend, nil
+	}
+	names := make([]string, len(Backends))
+	for i, b := range Backends {
+		if b.Name() == s {
+			return b, nil
+		}
+		names[i] = fmt.Sprintf("%q", b.Name())
+	}
+	return nil, fmt.Errorf("invalid backend %q: must be one of %s", s, strings.Join(names, ", "))
+}
+
+// WithBackend selects the backend emitting the resource, AzAPIBackend by default.
+func WithBackend(b Backend) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.backend = b
+	}
+}
+
+// azapiOnlyOptions returns the options set in o that only the azapi backend
+// implements, by their flag or config name.
+func azapiOnlyOptions(o *generatorOptions) []string {
+	var unsupported []string
+	for _, option := range []struct {
+		name string
+		set  bool
+	}{
+		{"-schema-validation-variable", o.features.schemaValidationVariable},
+		{"-lock-resource-ids-variable", o.features.lockResourceIDsVariable},
+		{"-update-resource", o.features.updateResource},
+		{"-scope-resource", o.features.scopeResource},
+		{"-inherited-tags-variable", o.features.inheritedTagsVariable},
+		{"-parent-id-components", o.features.parentIDComponents},
+		{"-naming-variable", o.features.namingVariable},
+		{"-resource-output", o.features.resourceOutput},
+		{"-keys-output", o.features.keysOutput},
+		{"-module-interface", o.features.moduleInterface},
+		{"-avm-strict", o.features.avmStrict},
+		{"-telemetry", o.features.telemetry},
+		{"-body-format json", o.features.bodyFormat == BodyFormatJSON},
+		{"-api-versions", len(o.features.apiVersions) > 0},
+		{"ignore_changes", len(o.ignoreChanges) > 0},
+		{"preconditions", len(o.preconditions) > 0},
+		{"precondition_validations", o.features.preconditionValidations},
+		{"mutually_exclusive", len(o.exclusive) > 0},
+		{"post_create_properties", len(o.postCreate) > 0},
+		{"object_outputs", len(o.objectOutputs) > 0},
+	} {
+		if option.set {
+			unsupported = append(unsupported, option.name)
+		}
+	}
+	return unsupported
+}
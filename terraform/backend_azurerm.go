@@ -0,0 +1,227 @@
+package terraform
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/matt-FFFFFF/tfmodmake/hclgen"
+	"github.com/matt-FFFFFF/tfmodmake/naming"
+	"github.com/matt-FFFFFF/tfmodmake/schema"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// MappingReportFileName is the file listing the variables a backend left for
+// the module author to map onto the resource.
+const MappingReportFileName = "MAPPING.md"
+
+// azurermResourceNames maps common resource types, in lower case, to the
+// azurerm resource managing them. Other names are guessed from the type.
+var azurermResourceNames = map[string]string{
+	"microsoft.app/containerapps":                      "azurerm_container_app",
+	"microsoft.app/managedenvironments":                "azurerm_container_app_environment",
+	"microsoft.cache/redis":                            "azurerm_redis_cache",
+	"microsoft.containerregistry/registries":           "azurerm_container_registry",
+	"microsoft.containerservice/managedclusters":       "azurerm_kubernetes_cluster",
+	"microsoft.documentdb/databaseaccounts":            "azurerm_cosmosdb_account",
+	"microsoft.eventhub/namespaces":                    "azurerm_eventhub_namespace",
+	"microsoft.insights/components":                    "azurerm_application_insights",
+	"microsoft.keyvault/vaults":                        "azurerm_key_vault",
+	"microsoft.managedidentity/userassignedidentities": "azurerm_user_assigned_identity",
+	"microsoft.network/publicipaddresses":              "azurerm_public_ip",
+	"microsoft.operationalinsights/workspaces":         "azurerm_log_analytics_workspace",
+	"microsoft.servicebus/namespaces":                  "azurerm_servicebus_namespace",
+	"microsoft.sql/servers":                            "azurerm_mssql_server",
+	"microsoft.storage/storageaccounts":                "azurerm_storage_account",
+	"microsoft.web/serverfarms":                        "azurerm_service_plan",
+}
+
+type azurermBackend struct{}
+
+func (azurermBackend) Name() string { return "azurerm" }
+
+// build generates the closest azurerm resource. The name, resource group,
+// location and tags are mapped; every other variable is listed in the resource
+// as a TODO comment and in the mapping report.
+func (azurermBackend) build(o *generatorOptions) (*GeneratedModule, error) {
+	if o.schema.IsReadOnlyResource() {
+		return nil, fmt.Errorf("%w: resource type %s@%s has no PUT operation; the azurerm backend cannot generate it", schema.ErrUnsupportedConstruct, o.resourceType, o.apiVersion)
+	}
+	if unsupported := azapiOnlyOptions(o); len(unsupported) > 0 {
+		return nil, fmt.Errorf("the azurerm backend does not support %s", strings.Join(unsupported, ", "))
+	}
+
+	o.schema = withNamingRule(o.schema, o.resourceType)
+	supportsIdentity := SupportsIdentity(o.schema)
+	supportsTags := SupportsTags(o.schema)
+	supportsLocation := SupportsLocation(o.schema)
+	parent := resolveParentScope(o.schema, o.resourceType, false)
+	var secrets []secretField
+	if o.schema != nil {
+		secrets = collectSecretFields(o.schema)
+	}
+
+	variables, err := buildVariables(o.schema, o.resourceType, supportsTags, supportsLocation, supportsIdentity, schema.HasDiscriminator(o.schema), o.features, parent, secrets, InterfaceCapabilities{SupportsManagedIdentity: supportsIdentity}, o.moduleNamePrefix)
+	if err != nil {
+		return nil, fmt.Errorf("building variables: %w", err)
+	}
+
+	resourceName, known := azurermResourceName(o.resourceType)
+	// Only resources deployed directly into a resource group take its name;
+	// the parent of other resources is an argument specific to the resource.
+	inResourceGroup := parent.kind == parentScopeResource && strings.Count(cleanTypeString(o.resourceType), "/") == 1
+
+	mapped := [][2]string{{"name", "name"}}
+	if inResourceGroup {
+		mapped = append(mapped, [2]string{"parent_id", "resource_group_name"})
+	}
+	if supportsLocation {
+		mapped = append(mapped, [2]string{"location", "location"})
+	}
+	if supportsTags {
+		mapped = append(mapped, [2]string{"tags", "tags"})
+	}
+
+	// sources describes the unmapped variables for the report: every variable
+	// not in mapped.
+	sources := map[string]string{}
+	for name, path := range variableSourcePaths(o.schema, o.resourceType, supportsIdentity, secrets, o.moduleNamePrefix) {
+		sources[name] = "body path `" + path + "`"
+	}
+	sources["parent_id"] = "the ID of the parent resource or scope"
+	for _, m := range mapped {
+		delete(sources, m[0])
+	}
+	unmapped := make([]string, 0, len(sources))
+	for name := range sources {
+		unmapped = append(unmapped, name)
+	}
+	sort.Strings(unmapped)
+
+	mod := &GeneratedModule{
+		Terraform:     buildAzureRMTerraform(o.versions),
+		Variables:     variables,
+		Main:          buildAzureRMMain(resourceName, inResourceGroup, supportsLocation, supportsTags, unmapped),
+		Outputs:       buildAzureRMOutputs(resourceName),
+		MappingReport: buildMappingReport(o.resourceType, o.apiVersion, resourceName, known, mapped, unmapped, sources),
+	}
+	if inResourceGroup {
+		mod.Locals = buildAzureRMLocals()
+	}
+	return mod, nil
+}
+
+// azurermResourceName returns the azurerm resource managing resourceType. It
+// reports false when the name is guessed from the last segment of the type.
+func azurermResourceName(resourceType string) (string, bool) {
+	cleaned := cleanTypeString(resourceType)
+	if name, ok := azurermResourceNames[strings.ToLower(cleaned)]; ok {
+		return name, true
+	}
+	last := cleaned[strings.LastIndex(cleaned, "/")+1:]
+	return "azurerm_" + naming.ToSnakeCase(singular(last)), false
+}
+
+// singular returns the singular of an English plural resource type segment.
+func singular(s string) string {
+	switch {
+	case strings.HasSuffix(s, "ies"):
+		return strings.TrimSuffix(s, "ies") + "y"
+	case strings.HasSuffix(s, "sses"), strings.HasSuffix(s, "xes"):
+		return s[:len(s)-2]
+	case strings.HasSuffix(s, "s") && !strings.HasSuffix(s, "ss"):
+		return s[:len(s)-1]
+	}
+	return s
+}
+
+// buildAzureRMTerraform requires the azurerm provider.
+func buildAzureRMTerraform(versions VersionConstraints) *hclwrite.File {
+	return buildRequiredProviders([]providerRequirement{{"azurerm", "hashicorp/azurerm", "~> 4.0"}}, versions)
+}
+
+// buildAzureRMLocals derives the resource group name azurerm resources take
+// from the parent ID.
+func buildAzureRMLocals() *hclwrite.File {
+	file := hclwrite.NewEmptyFile()
+	localsBody := file.Body().AppendNewBlock("locals", nil).Body()
+	localsBody.SetAttributeRaw(resourceGroupNameVariable, hclwrite.TokensForFunctionCall("element",
+		hclwrite.TokensForFunctionCall("split", hclwrite.TokensForValue(cty.StringVal("/")), hclgen.TokensForTraversal("var", "parent_id")),
+		hclwrite.TokensForValue(cty.NumberIntVal(4)),
+	))
+	return file
+}
+
+// buildAzureRMMain generates the resource with the mapped arguments, followed by
+// the unmapped variables as commented-out arguments of the same name.
+func buildAzureRMMain(resourceName string, inResourceGroup, supportsLocation, supportsTags bool, unmapped []string) *hclwrite.File {
+	file := hclwrite.NewEmptyFile()
+	resourceBody := file.Body().AppendNewBlock("resource", []string{resourceName, "this"}).Body()
+	resourceBody.SetAttributeRaw("name", hclgen.TokensForTraversal("var", "name"))
+	if inResourceGroup {
+		resourceBody.SetAttributeRaw(resourceGroupNameVariable, hclgen.TokensForTraversal("local", resourceGroupNameVariable))
+	}
+	if supportsLocation {
+		resourceBody.SetAttributeRaw("location", hclgen.TokensForTraversal("var", "location"))
+	}
+	if supportsTags {
+		resourceBody.SetAttributeRaw("tags", hclgen.TokensForTraversal("var", "tags"))
+	}
+	if len(unmapped) == 0 {
+		return file
+	}
+
+	resourceBody.AppendNewline()
+	tokens := hclwrite.Tokens{
+		&hclwrite.Token{Type: hclsyntax.TokenComment, Bytes: []byte(fmt.Sprintf("# TODO: map these variables onto the arguments of %s; see %s.", resourceName, MappingReportFileName))},
+		&hclwrite.Token{Type: hclsyntax.TokenNewline, Bytes: []byte("\n")},
+	}
+	for _, name := range unmapped {
+		tokens = append(tokens,
+			&hclwrite.Token{Type: hclsyntax.TokenComment, Bytes: []byte(fmt.Sprintf("# %s = var.%s", name, name))},
+			&hclwrite.Token{Type: hclsyntax.TokenNewline, Bytes: []byte("\n")},
+		)
+	}
+	resourceBody.AppendUnstructuredTokens(tokens)
+	return file
+}
+
+// buildAzureRMOutputs generates the resource_id and name outputs.
+func buildAzureRMOutputs(resourceName string) *hclwrite.File {
+	file := hclwrite.NewEmptyFile()
+	body := file.Body()
+	resourceIDBody := body.AppendNewBlock("output", []string{"resource_id"}).Body()
+	resourceIDBody.SetAttributeValue("description", cty.StringVal("The ID of the created resource."))
+	resourceIDBody.SetAttributeRaw("value", hclgen.TokensForTraversal(resourceName, "this", "id"))
+	body.AppendNewline()
+	nameBody := body.AppendNewBlock("output", []string{"name"}).Body()
+	nameBody.SetAttributeValue("description", cty.StringVal("The name of the created resource."))
+	nameBody.SetAttributeRaw("value", hclgen.TokensForTraversal(resourceName, "this", "name"))
+	return file
+}
+
+// buildMappingReport renders the Markdown report of the mapped variables and
+// of those left to map, with the body paths they stand for.
+func buildMappingReport(resourceType, apiVersion, resourceName string, known bool, mapped [][2]string, unmapped []string, sources map[string]string) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# azurerm mapping\n\n")
+	fmt.Fprintf(&buf, "`%s@%s` is generated as `%s`", resourceType, apiVersion, resourceName)
+	if !known {
+		buf.WriteString(", a name guessed from the resource type; check that the azurerm provider has it")
+	}
+	buf.WriteString(".\n\n## Mapped\n\n| Variable | Argument |\n| --- | --- |\n")
+	for _, m := range mapped {
+		fmt.Fprintf(&buf, "| `%s` | `%s` |\n", m[0], m[1])
+	}
+	if len(unmapped) == 0 {
+		return buf.Bytes()
+	}
+	buf.WriteString("\n## To map\n\nThese variables are declared but not yet passed to the resource.\n\n| Variable | Source |\n| --- | --- |\n")
+	for _, name := range unmapped {
+		fmt.Fprintf(&buf, "| `%s` | %s |\n", name, sources[name])
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,151 @@
+package terraform
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/matt-FFFFFF/tfmodmake/hclgen"
+	"github.com/matt-FFFFFF/tfmodmake/schema"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// msgraphTypePrefix starts the resource types of Microsoft Graph objects in the
+// Graph bicep types, e.g. Microsoft.Graph/applications@v1.0.
+const msgraphTypePrefix = "Microsoft.Graph/"
+
+type msgraphBackend struct{}
+
+func (msgraphBackend) Name() string { return "msgraph" }
+
+// build generates a msgraph_resource for a Microsoft Graph object. Graph objects
+// have no name, location or tags, and are addressed by URL rather than by ARM
+// ID: top-level objects are created in their collection, e.g. "applications",
+// and child objects in the collection below their parent's ID, which the
+// parent_id variable then holds.
+func (msgraphBackend) build(o *generatorOptions) (*GeneratedModule, error) {
+	segments, err := msgraphSegments(o.resourceType)
+	if err != nil {
+		return nil, err
+	}
+	if o.schema.IsReadOnlyResource() {
+		return nil, fmt.Errorf("%w: resource type %s@%s cannot be created; the msgraph backend cannot generate it", schema.ErrUnsupportedConstruct, o.resourceType, o.apiVersion)
+	}
+	if unsupported := azapiOnlyOptions(o); len(unsupported) > 0 {
+		return nil, fmt.Errorf("the msgraph backend does not support %s", strings.Join(unsupported, ", "))
+	}
+
+	// Graph bodies carry secrets like any other property; the variables are
+	// sensitive rather than ephemeral, which the body of msgraph_resource rejects.
+	var secrets []secretField
+	if o.schema != nil {
+		secrets = collectSecretFields(o.schema)
+	}
+	isChild := len(segments) > 1
+	variables, err := buildVariables(o.schema, o.resourceType, false, false, false, false, o.features, parentScope{kind: parentScopeResource}, nil, InterfaceCapabilities{}, o.moduleNamePrefix)
+	if err != nil {
+		return nil, fmt.Errorf("building variables: %w", err)
+	}
+	unused := []string{"name", "location", "enable_telemetry"}
+	if isChild {
+		setVariableDescription(variables, "parent_id", fmt.Sprintf("The ID of the parent %s object.", segments[len(segments)-2]))
+	} else {
+		unused = append(unused, "parent_id")
+	}
+	if variables, err = removeVariables(variables, unused...); err != nil {
+		return nil, err
+	}
+	for _, secret := range secrets {
+		if block := variables.Body().FirstMatchingBlock("variable", []string{secret.varName}); block != nil {
+			block.Body().SetAttributeValue("sensitive", cty.True)
+		}
+	}
+
+	locals, err := buildLocals(o.schema, o.localName, false, o.features, nil, nil, o.resourceType, InterfaceCapabilities{}, o.moduleNamePrefix)
+	if err != nil {
+		return nil, fmt.Errorf("building locals: %w", err)
+	}
+
+	return &GeneratedModule{
+		Terraform: buildMSGraphTerraform(o.versions),
+		Variables: variables,
+		Locals:    locals,
+		Main:      buildMSGraphMain(segments, o.apiVersion, o.localName, locals != nil),
+		Outputs:   buildMSGraphOutputs(),
+	}, nil
+}
+
+// msgraphSegments returns the collection segments of a Graph resource type,
+// e.g. ["applications", "federatedIdentityCredentials"].
+func msgraphSegments(resourceType string) ([]string, error) {
+	if len(resourceType) < len(msgraphTypePrefix) || !strings.EqualFold(resourceType[:len(msgraphTypePrefix)], msgraphTypePrefix) {
+		return nil, fmt.Errorf("resource type %s is not a Microsoft Graph type (%s...)", resourceType, msgraphTypePrefix)
+	}
+	segments := strings.Split(cleanTypeString(resourceType[len(msgraphTypePrefix):]), "/")
+	if len(segments) > 2 {
+		return nil, fmt.Errorf("%w: resource type %s is nested more than one level; the msgraph backend cannot build its URL", schema.ErrUnsupportedConstruct, resourceType)
+	}
+	return segments, nil
+}
+
+// buildMSGraphTerraform requires the msgraph provider.
+func buildMSGraphTerraform(versions VersionConstraints) *hclwrite.File {
+	return buildRequiredProviders([]providerRequirement{{"msgraph", "microsoft/msgraph", "~> 0.2"}}, versions)
+}
+
+// buildMSGraphMain generates the msgraph_resource creating the object in its
+// collection.
+func buildMSGraphMain(segments []string, apiVersion, localName string, hasBody bool) *hclwrite.File {
+	file := hclwrite.NewEmptyFile()
+	resourceBody := file.Body().AppendNewBlock("resource", []string{"msgraph_resource", "this"}).Body()
+	if len(segments) == 1 {
+		resourceBody.SetAttributeValue("url", cty.StringVal(segments[0]))
+	} else {
+		resourceBody.SetAttributeRaw("url", interfaceExpression(fmt.Sprintf(`"%s/${var.parent_id}/%s"`, segments[0], segments[1])))
+	}
+	if apiVersion != "" {
+		resourceBody.SetAttributeValue("api_version", cty.StringVal(apiVersion))
+	}
+	if hasBody {
+		resourceBody.SetAttributeRaw("body", hclgen.TokensForTraversal("local", localName))
+	}
+	return file
+}
+
+// buildMSGraphOutputs generates the resource_id output.
+func buildMSGraphOutputs() *hclwrite.File {
+	file := hclwrite.NewEmptyFile()
+	outBody := file.Body().AppendNewBlock("output", []string{"resource_id"}).Body()
+	outBody.SetAttributeValue("description", cty.StringVal("The ID of the created object."))
+	outBody.SetAttributeRaw("value", hclgen.TokensForTraversal("msgraph_resource", "this", "id"))
+	return file
+}
+
+// removeVariables returns file without the variable blocks of the given names.
+// The blank lines that followed them are removed too.
+func removeVariables(file *hclwrite.File, names ...string) (*hclwrite.File, error) {
+	for _, name := range names {
+		if block := file.Body().FirstMatchingBlock("variable", []string{name}); block != nil {
+			file.Body().RemoveBlock(block)
+		}
+	}
+	src := bytes.TrimLeft(blankLines.ReplaceAll(file.Bytes(), []byte("\n\n")), "\n")
+	cleaned, diags := hclwrite.ParseConfig(src, "variables.tf", hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("parsing variables: %s", diags.Error())
+	}
+	return cleaned, nil
+}
+
+// blankLines matches runs of more than one blank line.
+var blankLines = regexp.MustCompile(`\n{3,}`)
+
+// setVariableDescription replaces the description of the variable called name.
+func setVariableDescription(file *hclwrite.File, name, description string) {
+	if block := file.Body().FirstMatchingBlock("variable", []string{name}); block != nil {
+		hclgen.SetDescriptionAttribute(block.Body(), description)
+	}
+}
@@ -0,0 +1,206 @@
+package terraform
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/matt-FFFFFF/tfmodmake/hclgen"
+	"github.com/matt-FFFFFF/tfmodmake/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBackend(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		want Backend
+	}{
+		{"", AzAPIBackend},
+		{"azapi", AzAPIBackend},
+		{"azurerm", AzureRMBackend},
+		{"msgraph", MSGraphBackend},
+	} {
+		got, err := ParseBackend(tc.in)
+		require.NoError(t, err)
+		assert.Equal(t, tc.want, got)
+	}
+
+	_, err := ParseBackend("aws")
+	assert.EqualError(t, err, `invalid backend "aws": must be one of "azapi", "azurerm", "msgraph"`)
+}
+
+func backendTestSchema() *schema.ResourceSchema {
+	return &schema.ResourceSchema{
+		SupportsLocation: true,
+		SupportsTags:     true,
+		Properties: map[string]*schema.Property{
+			"location": {Name: "location", Type: schema.TypeString},
+			"tags":     {Name: "tags", Type: schema.TypeObject},
+			"properties": {Name: "properties", Type: schema.TypeObject, Children: map[string]*schema.Property{
+				"enableHttpsTrafficOnly": {Name: "enableHttpsTrafficOnly", Type: schema.TypeBoolean},
+				"minimumTlsVersion":      {Name: "minimumTlsVersion", Type: schema.TypeString, Enum: []string{"TLS1_2"}},
+				"primaryEndpoints":       {Name: "primaryEndpoints", Type: schema.TypeString, ReadOnly: true},
+			}},
+		},
+	}
+}
+
+func TestGenerate_AzureRMBackend(t *testing.T) {
+	files := hclgen.MemoryWriter{}
+	require.NoError(t, Generate("Microsoft.Storage/storageAccounts",
+		WithResourceSchema(backendTestSchema()), WithAPIVersion("2023-05-01"), WithBackend(AzureRMBackend), WithWriter(files)))
+
+	assert.Contains(t, string(files["terraform.tf"]), `source  = "hashicorp/azurerm"`)
+	assert.NotContains(t, string(files["terraform.tf"]), "azapi")
+	assert.Contains(t, string(files["locals.tf"]), `resource_group_name = element(split("/", var.parent_id), 4)`)
+
+	main := string(files["main.tf"])
+	assert.Contains(t, main, `resource "azurerm_storage_account" "this" {`)
+	assert.Contains(t, main, "resource_group_name = local.resource_group_name")
+	assert.Contains(t, main, "location            = var.location")
+	assert.Contains(t, main, "# TODO: map these variables onto the arguments of azurerm_storage_account; see MAPPING.md.")
+	assert.Contains(t, main, "# enable_https_traffic_only = var.enable_https_traffic_only")
+	assert.Contains(t, main, "# minimum_tls_version = var.minimum_tls_version")
+	assert.NotContains(t, main, "primary_endpoints")
+
+	// The variables and their validations are those of the azapi backend.
+	variables := parseHCLSource(t, "variables.tf", files["variables.tf"])
+	tls := requireBlock(t, variables, "variable", "minimum_tls_version")
+	assert.NotEmpty(t, findAllBlocks(tls.Body, "validation"))
+
+	assert.Contains(t, string(files["outputs.tf"]), "azurerm_storage_account.this.id")
+
+	report := string(files[MappingReportFileName])
+	assert.Contains(t, report, "`Microsoft.Storage/storageAccounts@2023-05-01` is generated as `azurerm_storage_account`.")
+	assert.Contains(t, report, "| `parent_id` | `resource_group_name` |")
+	assert.Contains(t, report, "| `minimum_tls_version` | body path `properties.minimumTlsVersion` |")
+}
+
+func TestGenerate_AzureRMBackend_MappedVariablesAreNotToMap(t *testing.T) {
+	// Resource schemas declare the name as a property of the body.
+	rs := backendTestSchema()
+	rs.Properties["name"] = &schema.Property{Name: "name", Type: schema.TypeString, Required: true}
+	for _, resourceType := range []string{"Microsoft.Storage/storageAccounts", "Microsoft.Test/widgets/gadgets"} {
+		files := hclgen.MemoryWriter{}
+		require.NoError(t, Generate(resourceType,
+			WithResourceSchema(rs), WithAPIVersion("2024-01-01"), WithBackend(AzureRMBackend), WithWriter(files)))
+
+		mappedSection, toMapSection, ok := strings.Cut(string(files[MappingReportFileName]), "## To map")
+		require.True(t, ok, resourceType)
+		mapped, toMap := reportVariables(mappedSection), reportVariables(toMapSection)
+		assert.Contains(t, mapped, "name", resourceType)
+		assert.NotEmpty(t, toMap, resourceType)
+		for _, name := range toMap {
+			assert.NotContains(t, mapped, name, resourceType)
+		}
+		main := string(files["main.tf"])
+		for _, name := range mapped {
+			assert.NotContains(t, main, "# "+name+" = var."+name, resourceType)
+		}
+	}
+}
+
+// reportVariables returns the variables in the first column of the tables of a
+// MAPPING.md section.
+func reportVariables(section string) []string {
+	var names []string
+	for _, line := range strings.Split(section, "\n") {
+		if rest, ok := strings.CutPrefix(line, "| `"); ok {
+			names = append(names, rest[:strings.Index(rest, "`")])
+		}
+	}
+	return names
+}
+
+func TestGenerate_AzureRMBackend_ChildResource(t *testing.T) {
+	files := hclgen.MemoryWriter{}
+	require.NoError(t, Generate("Microsoft.Test/widgets/gadgets",
+		WithResourceSchema(&schema.ResourceSchema{}), WithAPIVersion("2024-01-01"), WithBackend(AzureRMBackend), WithWriter(files)))
+
+	assert.NotContains(t, files, "locals.tf")
+	main := string(files["main.tf"])
+	assert.Contains(t, main, `resource "azurerm_gadget" "this" {`)
+	assert.Contains(t, main, "# parent_id = var.parent_id")
+
+	report := string(files[MappingReportFileName])
+	assert.Contains(t, report, "a name guessed from the resource type")
+	assert.Contains(t, report, "| `parent_id` | the ID of the parent resource or scope |")
+}
+
+func TestGenerate_AzureRMBackend_RejectsAzAPIOptions(t *testing.T) {
+	err := Generate("Microsoft.Storage/storageAccounts",
+		WithResourceSchema(backendTestSchema()), WithBackend(AzureRMBackend), WithWriter(hclgen.MemoryWriter{}),
+		WithTelemetry(true), WithIgnoreChanges("properties.minimumTlsVersion"))
+	assert.EqualError(t, err, "the azurerm backend does not support -telemetry, ignore_changes")
+}
+
+func TestAzureRMResourceName(t *testing.T) {
+	for _, tc := range []struct {
+		resourceType string
+		want         string
+		known        bool
+	}{
+		{"Microsoft.KeyVault/vaults", "azurerm_key_vault", true},
+		{"microsoft.app/containerApps", "azurerm_container_app", true},
+		{"Microsoft.Network/virtualNetworks", "azurerm_virtual_network", false},
+		{"Microsoft.Network/dnsZones/A", "azurerm_a", false},
+		{"Microsoft.Web/sites/slots/policies", "azurerm_policy", false},
+		{"Microsoft.Test/addresses", "azurerm_address", false},
+	} {
+		got, known := azurermResourceName(tc.resourceType)
+		assert.Equal(t, tc.want, got, tc.resourceType)
+		assert.Equal(t, tc.known, known, tc.resourceType)
+	}
+}
+
+func msgraphTestSchema() *schema.ResourceSchema {
+	return &schema.ResourceSchema{Properties: map[string]*schema.Property{
+		"id":           {Name: "id", Type: schema.TypeString, ReadOnly: true},
+		"displayName":  {Name: "displayName", Type: schema.TypeString, Required: true},
+		"clientSecret": {Name: "clientSecret", Type: schema.TypeString, Sensitive: true},
+	}}
+}
+
+func TestGenerate_MSGraphBackend(t *testing.T) {
+	files := hclgen.MemoryWriter{}
+	require.NoError(t, Generate("Microsoft.Graph/applications",
+		WithResourceSchema(msgraphTestSchema()), WithAPIVersion("v1.0"), WithBackend(MSGraphBackend), WithWriter(files)))
+
+	assert.Contains(t, string(files["terraform.tf"]), `source  = "microsoft/msgraph"`)
+	main := string(files["main.tf"])
+	assert.Contains(t, main, `resource "msgraph_resource" "this" {`)
+	assert.Contains(t, main, `url         = "applications"`)
+	assert.Contains(t, main, `api_version = "v1.0"`)
+	assert.Contains(t, main, "body        = local.resource_body")
+	assert.Contains(t, string(files["locals.tf"]), "displayName  = var.display_name")
+	assert.Contains(t, string(files["outputs.tf"]), "msgraph_resource.this.id")
+
+	variables := parseHCLSource(t, "variables.tf", files["variables.tf"])
+	for _, name := range []string{"name", "parent_id", "location", "enable_telemetry"} {
+		assert.Nil(t, findBlock(variables, "variable", name), name)
+	}
+	requireBlock(t, variables, "variable", "display_name")
+	secret := requireBlock(t, variables, "variable", "client_secret")
+	assert.Contains(t, secret.Body.Attributes, "sensitive")
+	assert.NotContains(t, secret.Body.Attributes, "ephemeral")
+}
+
+func TestGenerate_MSGraphBackend_ChildResource(t *testing.T) {
+	files := hclgen.MemoryWriter{}
+	require.NoError(t, Generate("Microsoft.Graph/applications/federatedIdentityCredentials",
+		WithResourceSchema(msgraphTestSchema()), WithAPIVersion("beta"), WithBackend(MSGraphBackend), WithWriter(files)))
+
+	assert.Contains(t, string(files["main.tf"]), `url         = "applications/${var.parent_id}/federatedIdentityCredentials"`)
+	assert.Contains(t, string(files["variables.tf"]), "The ID of the parent applications object.")
+}
+
+func TestGenerate_MSGraphBackend_Errors(t *testing.T) {
+	err := Generate("Microsoft.Storage/storageAccounts",
+		WithResourceSchema(msgraphTestSchema()), WithBackend(MSGraphBackend), WithWriter(hclgen.MemoryWriter{}))
+	assert.ErrorContains(t, err, "is not a Microsoft Graph type")
+
+	err = Generate("Microsoft.Graph/applications/owners/items",
+		WithResourceSchema(msgraphTestSchema()), WithBackend(MSGraphBackend), WithWriter(hclgen.MemoryWriter{}))
+	assert.ErrorContains(t, err, "nested more than one level")
+	assert.ErrorIs(t, err, schema.ErrUnsupportedConstruct)
+}
@@ -0,0 +1,49 @@
+package terraform
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/matt-FFFFFF/tfmodmake/hclgen"
+)
+
+// BodyFormat selects how request bodies are passed to the azapi provider.
+type BodyFormat string
+
+const (
+	// BodyFormatHCL passes bodies as HCL object values (the default).
+	BodyFormatHCL BodyFormat = "hcl"
+	// BodyFormatJSON passes bodies as jsonencode()d strings, for policy tooling that
+	// inspects JSON. The provider then also returns the output as a JSON string.
+	BodyFormatJSON BodyFormat = "json"
+)
+
+// ParseBodyFormat parses a -body-format flag value. An empty value selects HCL.
+func ParseBodyFormat(s string) (BodyFormat, error) {
+	switch BodyFormat(s) {
+	case "", BodyFormatHCL:
+		return BodyFormatHCL, nil
+	case BodyFormatJSON:
+		return BodyFormatJSON, nil
+	}
+	return "", fmt.Errorf("invalid body format %q: must be %q or %q", s, BodyFormatHCL, BodyFormatJSON)
+}
+
+// tokensForBody returns value in the requested body format.
+func tokensForBody(format BodyFormat, value hclwrite.Tokens) hclwrite.Tokens {
+	if format == BodyFormatJSON {
+		return hclwrite.TokensForFunctionCall("jsonencode", value)
+	}
+	return value
+}
+
+// tokensForOutputPath returns the expression reading path from the output of the
+// module's resource, decoding it first when bodies are sent as JSON.
+func tokensForOutputPath(blockType string, format BodyFormat, path []string) hclwrite.Tokens {
+	if format != BodyFormatJSON {
+		return hclgen.TokensForTraversalOrIndex(append([]string{blockType, "this", "output"}, path...)...)
+	}
+	tokens := hclwrite.TokensForFunctionCall("jsondecode", hclgen.TokensForTraversal(blockType, "this", "output"))
+	// Drop the placeholder root identifier, keeping the attribute and index accessors.
+	return append(tokens, hclgen.TokensForTraversalOrIndex(append([]string{"_"}, path...)...)[1:]...)
+}
@@ -0,0 +1,63 @@
+package terraform
+
+import (
+	"os"
+	"testing"
+
+	"github.com/matt-FFFFFF/tfmodmake/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBodyFormat(t *testing.T) {
+	format, err := ParseBodyFormat("")
+	require.NoError(t, err)
+	assert.Equal(t, BodyFormatHCL, format)
+
+	format, err = ParseBodyFormat("json")
+	require.NoError(t, err)
+	assert.Equal(t, BodyFormatJSON, format)
+
+	_, err = ParseBodyFormat("yaml")
+	assert.Error(t, err)
+}
+
+func TestGenerate_JSONBodyFormat(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+
+	require.NoError(t, os.Chdir(tmpDir))
+
+	rs := &schema.ResourceSchema{
+		Properties: map[string]*schema.Property{
+			"properties": {Name: "properties", Type: schema.TypeObject, Children: map[string]*schema.Property{
+				"password": {Name: "password", Type: schema.TypeString, Sensitive: true},
+				"state":    {Name: "state", Type: schema.TypeString, ReadOnly: true},
+			}},
+		},
+	}
+
+	require.NoError(t, Generate("Microsoft.Test/widgets", WithResourceSchema(rs), WithAPIVersion("2025-01-01"), WithBodyFormat(BodyFormatJSON)))
+
+	mainBody := parseHCLBody(t, "main.tf")
+	resource := requireBlock(t, mainBody, "resource", "azapi_resource", "this")
+	assert.Equal(t, "jsonencode(local.resource_body)", expressionString(t, resource.Body.Attributes["body"].Expr))
+	assert.Contains(t, expressionString(t, resource.Body.Attributes["sensitive_body"].Expr), "jsonencode({")
+
+	outputsBody := parseHCLBody(t, "outputs.tf")
+	state := requireBlock(t, outputsBody, "output", "state")
+	assert.Equal(t, "try(jsondecode(azapi_resource.this.output).properties.state, null)", expressionString(t, state.Body.Attributes["value"].Expr))
+}
+
+func TestGenerate_JSONBodyFormatRejectsIgnoreChanges(t *testing.T) {
+	_, err := GenerateInMemory("Microsoft.Test/widgets",
+		WithResourceSchema(authSchema()),
+		WithBodyFormat(BodyFormatJSON),
+		WithIgnoreChanges("properties.notes"),
+	)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "ignore_changes")
+}
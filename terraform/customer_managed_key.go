@@ -0,0 +1,274 @@
+package terraform
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/matt-FFFFFF/tfmodmake/hclgen"
+	"github.com/matt-FFFFFF/tfmodmake/schema"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// cmkShape is how the body of a resource references its customer-managed key.
+type cmkShape struct {
+	// property is the child of properties carrying the key, e.g. encryption.
+	property string
+	// value is the HCL expression of the property.
+	value string
+	// merge reports whether value is merged into the object the module already sets.
+	merge bool
+	// needsClientID reports whether the body takes the client ID of the user-assigned
+	// identity rather than its resource ID.
+	needsClientID bool
+	// needsIdentity reports whether the body names the user-assigned identity
+	// accessing the key, which must also be assigned to the resource.
+	needsIdentity bool
+}
+
+// cmkKeyFields maps the lower-cased names of key reference properties to the
+// locals and variable attributes they are set from.
+var cmkKeyFields = map[string]string{
+	"keyname":        "var.customer_managed_key.key_name",
+	"keyversion":     "var.customer_managed_key.key_version",
+	"keyvaulturi":    "local.customer_managed_key_vault_uri",
+	"keyvaulturl":    "local.customer_managed_key_vault_uri",
+	"keyidentifier":  "local.customer_managed_key_uri",
+	"keyuri":         "local.customer_managed_key_uri",
+	"keyurl":         "local.customer_managed_key_uri",
+	"keyid":          "local.customer_managed_key_uri",
+	"keyvaultkeyuri": "local.customer_managed_key_uri",
+}
+
+// cmkIdentityFields maps the lower-cased names of properties naming the identity
+// that accesses the key.
+var cmkIdentityFields = map[string]string{
+	"userassignedidentity": "try(var.customer_managed_key.user_assigned_identity.resource_id, null)",
+	"useridentity":         "try(var.customer_managed_key.user_assigned_identity.resource_id, null)",
+	"identityresourceid":   "try(var.customer_managed_key.user_assigned_identity.resource_id, null)",
+	"identityclientid":     "local.customer_managed_key_identity_client_id",
+	"clientid":             "local.customer_managed_key_identity_client_id",
+}
+
+// detectCustomerManagedKey finds where the body of rs references a Key Vault key:
+// either a key URI property such as keyVaultKeyUri, or an encryption object with
+// key vault properties. It returns nil when the schema has neither.
+func detectCustomerManagedKey(rs *schema.ResourceSchema) *cmkShape {
+	if rs == nil || rs.Properties["properties"] == nil {
+		return nil
+	}
+	properties := rs.Properties["properties"].Children
+
+	for _, name := range sortedPropertyNames(properties) {
+		prop := properties[name]
+		if prop.Type != schema.TypeObject || !strings.EqualFold(name, "encryption") || !isWritableProperty(prop) {
+			continue
+		}
+		shape := &cmkShape{property: name, merge: true}
+		if value, ok := cmkEncryptionValue(prop, shape); ok {
+			shape.value = value
+			return shape
+		}
+	}
+
+	for _, name := range sortedPropertyNames(properties) {
+		prop := properties[name]
+		if prop.Type == schema.TypeString && isWritableProperty(prop) &&
+			(strings.EqualFold(name, "keyVaultKeyUri") || strings.EqualFold(name, "keyVaultKeyId")) {
+			return &cmkShape{property: name, value: "local.customer_managed_key_uri"}
+		}
+	}
+	return nil
+}
+
+// cmkEncryptionValue renders an encryption object referencing the key. It only
+// succeeds when the object has key vault properties with a key reference.
+func cmkEncryptionValue(encryption *schema.Property, shape *cmkShape) (string, bool) {
+	var attrs []string
+	hasKey := false
+	for _, name := range sortedPropertyNames(encryption.Children) {
+		child := encryption.Children[name]
+		if !isWritableProperty(child) {
+			continue
+		}
+		lower := strings.ToLower(name)
+		switch {
+		case lower == "keyvaultproperties" || lower == "keyvault":
+			item, isArray := child, false
+			if child.Type == schema.TypeArray && child.ItemType != nil {
+				item, isArray = child.ItemType, true
+			}
+			value, ok := cmkObjectValue(item, shape)
+			if !ok {
+				continue
+			}
+			hasKey = true
+			if isArray {
+				value = "[" + value + "]"
+			}
+			attrs = append(attrs, fmt.Sprintf("%s = %s", name, value))
+		case lower == "keysource":
+			attrs = append(attrs, fmt.Sprintf("%s = %q", name, enumValueContaining(child, "keyvault", "Microsoft.KeyVault")))
+		case lower == "status" && enumValueContaining(child, "enabled", "") != "":
+			attrs = append(attrs, fmt.Sprintf("%s = %q", name, enumValueContaining(child, "enabled", "")))
+		case lower == "identity" && child.Type == schema.TypeObject:
+			if value, ok := cmkIdentityValue(child, shape); ok {
+				attrs = append(attrs, fmt.Sprintf("%s = %s", name, value))
+			}
+		}
+	}
+	if !hasKey {
+		return "", false
+	}
+	return "{\n" + strings.Join(attrs, "\n") + "\n}", true
+}
+
+// cmkObjectValue renders the key vault properties object. It only succeeds when
+// the object has a key reference.
+func cmkObjectValue(prop *schema.Property, shape *cmkShape) (string, bool) {
+	if prop == nil || prop.Type != schema.TypeObject {
+		return "", false
+	}
+	var attrs []string
+	hasKey := false
+	for _, name := range sortedPropertyNames(prop.Children) {
+		child := prop.Children[name]
+		if !isWritableProperty(child) {
+			continue
+		}
+		lower := strings.ToLower(name)
+		if expr, ok := cmkKeyFields[lower]; ok && child.Type == schema.TypeString {
+			hasKey = hasKey || lower != "keyversion"
+			attrs = append(attrs, fmt.Sprintf("%s = %s", name, expr))
+			continue
+		}
+		if _, ok := cmkIdentityFields[lower]; ok && child.Type == schema.TypeString {
+			attrs = append(attrs, fmt.Sprintf("%s = %s", name, cmkIdentityField(lower, shape)))
+			continue
+		}
+		if lower == "identity" && child.Type == schema.TypeObject {
+			if value, ok := cmkIdentityValue(child, shape); ok {
+				attrs = append(attrs, fmt.Sprintf("%s = %s", name, value))
+			}
+		}
+	}
+	if !hasKey {
+		return "", false
+	}
+	return "{\n" + strings.Join(attrs, "\n") + "\n}", true
+}
+
+// cmkIdentityValue renders an identity object naming the user-assigned identity.
+func cmkIdentityValue(prop *schema.Property, shape *cmkShape) (string, bool) {
+	var attrs []string
+	for _, name := range sortedPropertyNames(prop.Children) {
+		child := prop.Children[name]
+		lower := strings.ToLower(name)
+		if _, ok := cmkIdentityFields[lower]; ok && child.Type == schema.TypeString && isWritableProperty(child) {
+			attrs = append(attrs, fmt.Sprintf("%s = %s", name, cmkIdentityField(lower, shape)))
+		}
+	}
+	if len(attrs) == 0 {
+		return "", false
+	}
+	return "{\n" + strings.Join(attrs, "\n") + "\n}", true
+}
+
+func cmkIdentityField(lower string, shape *cmkShape) string {
+	shape.needsIdentity = true
+	expr := cmkIdentityFields[lower]
+	if strings.HasPrefix(expr, "local.customer_managed_key_identity_client_id") {
+		shape.needsClientID = true
+	}
+	return expr
+}
+
+// enumValueContaining returns the enum value of prop containing substr, ignoring
+// case, or fallback.
+func enumValueContaining(prop *schema.Property, substr, fallback string) string {
+	for _, value := range prop.Enum {
+		if strings.Contains(strings.ToLower(value), substr) {
+			return value
+		}
+	}
+	return fallback
+}
+
+func sortedPropertyNames(props map[string]*schema.Property) []string {
+	names := make([]string, 0, len(props))
+	for name, prop := range props {
+		if prop != nil {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// appendCustomerManagedKeyResources resolves var.customer_managed_key into the key
+// URI and, when the schema shows where the key goes, into
+// local.customer_managed_key_properties, which is merged into the resource body.
+func appendCustomerManagedKeyResources(body *hclwrite.Body, target interfaceTarget) {
+	shape := detectCustomerManagedKey(target.rs)
+
+	vaultBody := body.AppendNewBlock("data", []string{"azapi_resource", "customer_managed_key_vault"}).Body()
+	vaultBody.SetAttributeRaw("count", interfaceExpression(`var.customer_managed_key != null ? 1 : 0`))
+	vaultBody.AppendNewline()
+	vaultBody.SetAttributeValue("type", cty.StringVal("Microsoft.KeyVault/vaults@2023-07-01"))
+	vaultBody.SetAttributeRaw("resource_id", hclgen.TokensForTraversal("var", "customer_managed_key", "key_vault_resource_id"))
+	vaultBody.SetAttributeRaw("response_export_values", hclgen.TokensForMultilineStringList([]string{"properties.vaultUri"}))
+	body.AppendNewline()
+
+	if shape != nil && shape.needsClientID {
+		identityBody := body.AppendNewBlock("data", []string{"azapi_resource", "customer_managed_key_identity"}).Body()
+		identityBody.SetAttributeRaw("count", interfaceExpression(`try(var.customer_managed_key.user_assigned_identity.resource_id, null) != null ? 1 : 0`))
+		identityBody.AppendNewline()
+		identityBody.SetAttributeValue("type", cty.StringVal("Microsoft.ManagedIdentity/userAssignedIdentities@2023-01-31"))
+		identityBody.SetAttributeRaw("resource_id", hclgen.TokensForTraversal("var", "customer_managed_key", "user_assigned_identity", "resource_id"))
+		identityBody.SetAttributeRaw("response_export_values", hclgen.TokensForMultilineStringList([]string{"properties.clientId"}))
+		body.AppendNewline()
+	}
+
+	localsBody := body.AppendNewBlock("locals", nil).Body()
+	localsBody.SetAttributeRaw("customer_managed_key_vault_uri", interfaceExpression(`var.customer_managed_key != null ? trimsuffix(data.azapi_resource.customer_managed_key_vault[0].output.properties.vaultUri, "/") : null`))
+	localsBody.SetAttributeRaw("customer_managed_key_uri", interfaceExpression(`var.customer_managed_key != null ? join("/", compact([
+  local.customer_managed_key_vault_uri,
+  "keys",
+  var.customer_managed_key.key_name,
+  var.customer_managed_key.key_version,
+])) : null`))
+	if shape == nil {
+		return
+	}
+	if shape.needsClientID {
+		localsBody.SetAttributeRaw("customer_managed_key_identity_client_id", interfaceExpression(`try(data.azapi_resource.customer_managed_key_identity[0].output.properties.clientId, null)`))
+	}
+
+	value := shape.value
+	if shape.merge && target.bodyLocal != "" {
+		value = fmt.Sprintf("merge(try(local.%s.properties.%s, null), %s)", target.bodyLocal, shape.property, value)
+	}
+	localsBody.SetAttributeRaw("customer_managed_key_properties", interfaceExpression(fmt.Sprintf("var.customer_managed_key == null ? {} : {\n%s = %s\n}", shape.property, value)))
+}
+
+// wireCustomerManagedKey merges local.customer_managed_key_properties into the
+// body of the resource, or explains why the key could not be wired.
+func wireCustomerManagedKey(outputDir string, target interfaceTarget, journal *hclgen.Journal) (string, error) {
+	shape := detectCustomerManagedKey(target.rs)
+	if shape == nil {
+		return "customer_managed_key: the resource schema has no encryption key reference; set local.customer_managed_key_uri in the resource body by hand", nil
+	}
+	if target.bodyLocal == "" {
+		return "customer_managed_key: the resource body is not built from a local; merge local.customer_managed_key_properties into its properties by hand", nil
+	}
+	overlay := fmt.Sprintf("{\nproperties = merge(local.%s.properties, local.customer_managed_key_properties)\n}", target.bodyLocal)
+	if err := mergeIntoResourceBody(outputDir, target.bodyLocal, overlay, journal); err != nil {
+		return "", err
+	}
+	note := fmt.Sprintf("customer_managed_key: wired into properties.%s", shape.property)
+	if shape.needsIdentity {
+		note += "; the user-assigned identity must also be assigned to the resource, e.g. with the identity interface"
+	}
+	return note, nil
+}
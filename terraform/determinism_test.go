@@ -0,0 +1,66 @@
+package terraform
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/matt-FFFFFF/tfmodmake/hclgen"
+	"github.com/matt-FFFFFF/tfmodmake/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// determinismTestSchema has enough properties at every level that map
+// iteration order would show in the output if it leaked.
+func determinismTestSchema() *schema.ResourceSchema {
+	props := map[string]*schema.Property{}
+	for i := range 12 {
+		name := fmt.Sprintf("setting%02d", i)
+		props[name] = &schema.Property{Name: name, Type: schema.TypeString, Enum: []string{"A", "B"}}
+		nested := fmt.Sprintf("nested%02d", i)
+		children := map[string]*schema.Property{}
+		for j := range 6 {
+			child := fmt.Sprintf("child%02d", j)
+			children[child] = &schema.Property{Name: child, Type: schema.TypeInteger}
+		}
+		secret := fmt.Sprintf("secret%02d", i)
+		children[secret] = &schema.Property{Name: secret, Type: schema.TypeString, Sensitive: true}
+		props[nested] = &schema.Property{Name: nested, Type: schema.TypeObject, Children: children}
+		computed := fmt.Sprintf("endpoint%02d", i)
+		props[computed] = &schema.Property{Name: computed, Type: schema.TypeString, ReadOnly: true}
+	}
+	props["rules"] = &schema.Property{Name: "rules", Type: schema.TypeArray, ItemType: &schema.Property{Type: schema.TypeObject, Children: map[string]*schema.Property{
+		"kind":     {Name: "kind", Type: schema.TypeString, Required: true, Enum: []string{"Allow", "Deny"}},
+		"priority": {Name: "priority", Type: schema.TypeInteger},
+		"source":   {Name: "source", Type: schema.TypeString},
+	}}}
+	return &schema.ResourceSchema{
+		SupportsLocation: true,
+		SupportsTags:     true,
+		SupportsIdentity: true,
+		Properties: map[string]*schema.Property{
+			"location":   {Name: "location", Type: schema.TypeString, Required: true},
+			"tags":       {Name: "tags", Type: schema.TypeObject},
+			"identity":   {Name: "identity", Type: schema.TypeObject},
+			"properties": {Name: "properties", Type: schema.TypeObject, Children: props},
+		},
+	}
+}
+
+func TestGenerate_Deterministic(t *testing.T) {
+	generate := func() hclgen.MemoryWriter {
+		files := hclgen.MemoryWriter{}
+		require.NoError(t, Generate("Microsoft.Test/widgets", WithResourceSchema(determinismTestSchema()), WithAPIVersion("2024-01-01"),
+			WithTelemetry(true), WithResourceOutput(true), WithModuleInterface(true), WithWriter(files)))
+		return files
+	}
+
+	first := generate()
+	for range 10 {
+		next := generate()
+		require.Equal(t, len(first), len(next))
+		for name, content := range first {
+			assert.Equal(t, string(content), string(next[name]), name)
+		}
+	}
+}
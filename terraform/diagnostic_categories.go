@@ -0,0 +1,225 @@
+package terraform
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/matt-FFFFFF/tfmodmake/hclgen"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// diagnosticCategories are the diagnostic setting categories a resource type emits.
+type diagnosticCategories struct {
+	logs      []string
+	logGroups []string
+	metrics   []string
+}
+
+// diagnosticCategoriesByResourceType provides the diagnostic log categories, log
+// category groups and metric categories of ARM resource types. The REST specs do
+// not describe them; they are published per resource type by Azure Monitor.
+//
+// Source: https://learn.microsoft.com/en-us/azure/azure-monitor/reference/supported-logs/logs-index
+// and https://learn.microsoft.com/en-us/azure/azure-monitor/reference/supported-metrics/metrics-index.
+// NOTE: Keys are normalized to strings.ToLower(resourceType).
+var diagnosticCategoriesByResourceType = map[string]diagnosticCategories{
+	strings.ToLower("Microsoft.ApiManagement/service"): {
+		logs:      []string{"DeveloperPortalAuditLogs", "GatewayLogs", "WebSocketConnectionLogs"},
+		logGroups: []string{"allLogs", "audit"},
+		metrics:   []string{"AllMetrics"},
+	},
+	strings.ToLower("Microsoft.App/managedEnvironments"): {
+		logs:      []string{"AppEnvSpringAppConsoleLogs", "ContainerAppConsoleLogs", "ContainerAppSystemLogs"},
+		logGroups: []string{"allLogs"},
+		metrics:   []string{"AllMetrics"},
+	},
+	strings.ToLower("Microsoft.Cache/redis"): {
+		logs:      []string{"ConnectedClientList"},
+		logGroups: []string{"allLogs", "audit"},
+		metrics:   []string{"AllMetrics"},
+	},
+	strings.ToLower("Microsoft.CognitiveServices/accounts"): {
+		logs:      []string{"Audit", "RequestResponse", "Trace"},
+		logGroups: []string{"allLogs", "audit"},
+		metrics:   []string{"AllMetrics"},
+	},
+	strings.ToLower("Microsoft.ContainerRegistry/registries"): {
+		logs:      []string{"ContainerRegistryLoginEvents", "ContainerRegistryRepositoryEvents"},
+		logGroups: []string{"allLogs", "audit"},
+		metrics:   []string{"AllMetrics"},
+	},
+	strings.ToLower("Microsoft.ContainerService/managedClusters"): {
+		logs: []string{
+			"cloud-controller-manager", "cluster-autoscaler", "csi-azuredisk-controller", "csi-azurefile-controller",
+			"csi-snapshot-controller", "guard", "kube-apiserver", "kube-audit", "kube-audit-admin",
+			"kube-controller-manager", "kube-scheduler",
+		},
+		logGroups: []string{"allLogs", "audit"},
+		metrics:   []string{"AllMetrics"},
+	},
+	strings.ToLower("Microsoft.DocumentDB/databaseAccounts"): {
+		logs: []string{
+			"CassandraRequests", "ControlPlaneRequests", "DataPlaneRequests", "GremlinRequests", "MongoRequests",
+			"PartitionKeyRUConsumption", "PartitionKeyStatistics", "QueryRuntimeStatistics", "TableApiRequests",
+		},
+		logGroups: []string{"allLogs", "audit"},
+		metrics:   []string{"Requests"},
+	},
+	strings.ToLower("Microsoft.EventHub/namespaces"): {
+		logs: []string{
+			"ApplicationMetricsLogs", "ArchiveLogs", "AutoScaleLogs", "CustomerManagedKeyUserLogs", "EventHubVNetConnectionEvent",
+			"KafkaCoordinatorLogs", "KafkaUserErrorLogs", "OperationalLogs", "RuntimeAuditLogs",
+		},
+		logGroups: []string{"allLogs", "audit"},
+		metrics:   []string{"AllMetrics"},
+	},
+	strings.ToLower("Microsoft.KeyVault/vaults"): {
+		logs:      []string{"AuditEvent", "AzurePolicyEvaluationDetails"},
+		logGroups: []string{"allLogs", "audit"},
+		metrics:   []string{"AllMetrics"},
+	},
+	strings.ToLower("Microsoft.Network/applicationGateways"): {
+		logs:      []string{"ApplicationGatewayAccessLog", "ApplicationGatewayFirewallLog", "ApplicationGatewayPerformanceLog"},
+		logGroups: []string{"allLogs"},
+		metrics:   []string{"AllMetrics"},
+	},
+	strings.ToLower("Microsoft.Network/networkSecurityGroups"): {
+		logs:      []string{"NetworkSecurityGroupEvent", "NetworkSecurityGroupRuleCounter"},
+		logGroups: []string{"allLogs"},
+	},
+	strings.ToLower("Microsoft.Network/publicIPAddresses"): {
+		logs:      []string{"DDoSMitigationFlowLogs", "DDoSMitigationReports", "DDoSProtectionNotifications"},
+		logGroups: []string{"allLogs", "audit"},
+		metrics:   []string{"AllMetrics"},
+	},
+	strings.ToLower("Microsoft.Network/virtualNetworks"): {
+		logs:      []string{"VMProtectionAlerts"},
+		logGroups: []string{"allLogs"},
+		metrics:   []string{"AllMetrics"},
+	},
+	strings.ToLower("Microsoft.OperationalInsights/workspaces"): {
+		logs:      []string{"Audit", "SummaryLogs"},
+		logGroups: []string{"allLogs", "audit"},
+		metrics:   []string{"AllMetrics"},
+	},
+	strings.ToLower("Microsoft.ServiceBus/namespaces"): {
+		logs:      []string{"ApplicationMetricsLogs", "OperationalLogs", "RuntimeAuditLogs", "VNetAndIPFilteringLogs"},
+		logGroups: []string{"allLogs", "audit"},
+		metrics:   []string{"AllMetrics"},
+	},
+	strings.ToLower("Microsoft.Sql/servers/databases"): {
+		logs: []string{
+			"AutomaticTuning", "Blocks", "DatabaseWaitStatistics", "Deadlocks", "DevOpsOperationsAudit", "Errors",
+			"QueryStoreRuntimeStatistics", "QueryStoreWaitStatistics", "SQLInsights", "SQLSecurityAuditEvents", "Timeouts",
+		},
+		logGroups: []string{"allLogs", "audit"},
+		metrics:   []string{"Basic", "InstanceAndAppAdvanced", "WorkloadManagement"},
+	},
+	// Storage accounts only emit metrics; their logs are emitted by the blob, file,
+	// queue and table services.
+	strings.ToLower("Microsoft.Storage/storageAccounts"): {
+		metrics: []string{"Transaction"},
+	},
+	strings.ToLower("Microsoft.Web/sites"): {
+		logs: []string{
+			"AppServiceAppLogs", "AppServiceAuditLogs", "AppServiceConsoleLogs", "AppServiceHTTPLogs",
+			"AppServiceIPSecAuditLogs", "AppServicePlatformLogs",
+		},
+		logGroups: []string{"allLogs"},
+		metrics:   []string{"AllMetrics"},
+	},
+}
+
+// diagnosticCategoriesFor returns the diagnostic categories of the resource type, if known.
+func diagnosticCategoriesFor(resourceType string) (diagnosticCategories, bool) {
+	categories, ok := diagnosticCategoriesByResourceType[strings.ToLower(resourceType)]
+	return categories, ok
+}
+
+// defaultLogGroups returns the log category groups enabled when none are given.
+func (c diagnosticCategories) defaultLogGroups() []string {
+	for _, group := range c.logGroups {
+		if group == "allLogs" {
+			return []string{group}
+		}
+	}
+	return nil
+}
+
+// defaultMetrics returns the metric categories enabled when none are given.
+func (c diagnosticCategories) defaultMetrics() []string {
+	for _, metric := range c.metrics {
+		if metric == "AllMetrics" {
+			return []string{metric}
+		}
+	}
+	return c.metrics
+}
+
+// description documents the categories and an example setting.
+func (c diagnosticCategories) description() string {
+	var sb strings.Builder
+	sb.WriteString("A map of diagnostic settings to create on the resource.\n")
+	fmt.Fprintf(&sb, "\n- log_categories: Log categories to enable. %s\n", describeCategories(c.logs))
+	fmt.Fprintf(&sb, "- log_groups: Log category groups to enable. %s\n", describeCategories(c.logGroups))
+	fmt.Fprintf(&sb, "- metric_categories: Metric categories to enable. %s\n", describeCategories(c.metrics))
+
+	sb.WriteString("\nExample:\n\n```hcl\n{\n  to_law = {\n    workspace_resource_id = \"<log analytics workspace resource id>\"\n")
+	switch {
+	case len(c.logs) > 0:
+		fmt.Fprintf(&sb, "    log_categories        = [%q]\n", c.logs[0])
+		sb.WriteString("    log_groups            = []\n")
+	case len(c.logGroups) > 0:
+		fmt.Fprintf(&sb, "    log_groups            = [%q]\n", c.logGroups[0])
+	}
+	sb.WriteString("  }\n}\n```")
+	return sb.String()
+}
+
+func describeCategories(categories []string) string {
+	if len(categories) == 0 {
+		return "The resource type has none."
+	}
+	return fmt.Sprintf("One or more of %s.", formatQuotedList(categories))
+}
+
+// addDiagnosticCategoryValidation restricts a set attribute of every diagnostic
+// setting to the allowed values.
+func addDiagnosticCategoryValidation(diagBody *hclwrite.Body, attribute string, allowed []string) {
+	// alltrue([for _, v in var.diagnostic_settings : alltrue([for c in v.<attribute> : contains([...], c)])])
+	allowedTokens := tokensForStringList(allowed)
+
+	inner := hclwrite.Tokens{
+		&hclwrite.Token{Type: hclsyntax.TokenOBrack, Bytes: []byte("[")},
+		&hclwrite.Token{Type: hclsyntax.TokenIdent, Bytes: []byte("for")},
+		&hclwrite.Token{Type: hclsyntax.TokenIdent, Bytes: []byte("c")},
+		&hclwrite.Token{Type: hclsyntax.TokenIdent, Bytes: []byte("in")},
+	}
+	inner = append(inner, hclgen.TokensForTraversal("v", attribute)...)
+	inner = append(inner, &hclwrite.Token{Type: hclsyntax.TokenColon, Bytes: []byte(":")})
+	inner = append(inner, hclwrite.TokensForFunctionCall("contains", allowedTokens, hclwrite.TokensForIdentifier("c"))...)
+	inner = append(inner, &hclwrite.Token{Type: hclsyntax.TokenCBrack, Bytes: []byte("]")})
+
+	outer := hclwrite.Tokens{
+		&hclwrite.Token{Type: hclsyntax.TokenOBrack, Bytes: []byte("[")},
+		&hclwrite.Token{Type: hclsyntax.TokenIdent, Bytes: []byte("for")},
+		&hclwrite.Token{Type: hclsyntax.TokenIdent, Bytes: []byte("_")},
+		&hclwrite.Token{Type: hclsyntax.TokenComma, Bytes: []byte(",")},
+		&hclwrite.Token{Type: hclsyntax.TokenIdent, Bytes: []byte("v")},
+		&hclwrite.Token{Type: hclsyntax.TokenIdent, Bytes: []byte("in")},
+	}
+	outer = append(outer, hclgen.TokensForTraversal("var", "diagnostic_settings")...)
+	outer = append(outer, &hclwrite.Token{Type: hclsyntax.TokenColon, Bytes: []byte(":")})
+	outer = append(outer, hclwrite.TokensForFunctionCall("alltrue", inner)...)
+	outer = append(outer, &hclwrite.Token{Type: hclsyntax.TokenCBrack, Bytes: []byte("]")})
+
+	message := fmt.Sprintf("The resource type has no %s.", strings.ReplaceAll(attribute, "_", " "))
+	if len(allowed) > 0 {
+		message = fmt.Sprintf("%s must only contain: %s.", attribute, formatQuotedList(allowed))
+	}
+	validationBody := diagBody.AppendNewBlock("validation", nil).Body()
+	validationBody.SetAttributeRaw("condition", hclwrite.TokensForFunctionCall("alltrue", outer))
+	validationBody.SetAttributeValue("error_message", cty.StringVal(message))
+}
@@ -0,0 +1,66 @@
+package terraform
+
+import (
+	"regexp"
+	"strings"
+)
+
+// docLinkPattern matches Microsoft documentation links embedded in spec
+// descriptions, bare or in Markdown links.
+var docLinkPattern = regexp.MustCompile(`(?i)https?://(?:(?:learn|docs)\.microsoft\.com|aka\.ms)/[^\s()<>\[\]"'` + "`" + `]*`)
+
+// docLocalePattern matches the locale segment of a documentation URL path.
+var docLocalePattern = regexp.MustCompile(`^/[a-z]{2}-[a-z]{2}(/|$)`)
+
+// withDocLinks appends a "See: <url>" line to description for each
+// documentation link it embeds, so the link stands out from the prose. Links
+// are normalized (https, learn.microsoft.com, no locale) and listed once, in
+// the order they appear.
+func withDocLinks(description string) string {
+	var links []string
+	seen := map[string]bool{}
+	for _, match := range docLinkPattern.FindAllString(description, -1) {
+		link := normalizeDocLink(match)
+		if link == "" || seen[link] || strings.Contains(description, "See: "+link) {
+			continue
+		}
+		seen[link] = true
+		links = append(links, link)
+	}
+	if len(links) == 0 {
+		return description
+	}
+	var sb strings.Builder
+	sb.WriteString(strings.TrimRight(description, "\n"))
+	sb.WriteString("\n")
+	for _, link := range links {
+		sb.WriteString("\nSee: " + link)
+	}
+	if strings.HasSuffix(description, "\n") {
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// normalizeDocLink returns link with the https scheme, docs.microsoft.com
+// replaced by learn.microsoft.com, and without a locale or trailing punctuation.
+// It returns an empty string for a link without a path.
+func normalizeDocLink(link string) string {
+	link = strings.TrimRight(link, ".,;:!?")
+	schemeEnd := strings.Index(link, "://") + len("://")
+	rest := link[schemeEnd:]
+	slash := strings.Index(rest, "/")
+	host, path := strings.ToLower(rest[:slash]), rest[slash:]
+	if host == "docs.microsoft.com" {
+		host = "learn.microsoft.com"
+	}
+	if host == "learn.microsoft.com" {
+		if loc := docLocalePattern.FindStringIndex(strings.ToLower(path)); loc != nil {
+			path = "/" + path[loc[1]:]
+		}
+	}
+	if strings.Trim(path, "/") == "" {
+		return ""
+	}
+	return "https://" + host + path
+}
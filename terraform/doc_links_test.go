@@ -0,0 +1,36 @@
+package terraform
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeDocLink(t *testing.T) {
+	tests := map[string]string{
+		"https://learn.microsoft.com/azure/storage/":                      "https://learn.microsoft.com/azure/storage/",
+		"http://docs.microsoft.com/en-us/azure/aks/availability-zones.":   "https://learn.microsoft.com/azure/aks/availability-zones",
+		"https://Learn.Microsoft.com/en-GB/azure/key-vault#soft-delete,":  "https://learn.microsoft.com/azure/key-vault#soft-delete",
+		"https://aka.ms/storagenetworkrules":                              "https://aka.ms/storagenetworkrules",
+		"https://docs.microsoft.com/en-us/":                               "",
+		"https://learn.microsoft.com/rest/api/storage/?view=rest-storage": "https://learn.microsoft.com/rest/api/storage/?view=rest-storage",
+	}
+	for link, want := range tests {
+		assert.Equal(t, want, normalizeDocLink(link), link)
+	}
+}
+
+func TestWithDocLinks(t *testing.T) {
+	assert.Equal(t, "No links here.", withDocLinks("No links here."))
+
+	description := "The SKU. See [the docs](https://docs.microsoft.com/en-us/azure/aks/skus) or https://learn.microsoft.com/azure/aks/skus."
+	assert.Equal(t, description+"\n\nSee: https://learn.microsoft.com/azure/aks/skus", withDocLinks(description))
+
+	// Nested descriptions end in a newline, which is kept.
+	nested := "The profile.\n\n- `sku` - Described at https://aka.ms/sku.\n"
+	assert.Equal(t, "The profile.\n\n- `sku` - Described at https://aka.ms/sku.\n\nSee: https://aka.ms/sku\n", withDocLinks(nested))
+
+	// A link already listed is not repeated.
+	listed := "The name.\n\nSee: https://aka.ms/name"
+	assert.Equal(t, listed, withDocLinks(listed))
+}
@@ -0,0 +1,49 @@
+package terraform
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/matt-FFFFFF/tfmodmake/hclgen"
+)
+
+// e2eTemplates are the files of the terratest module scaffolded into tests/e2e.
+//
+//go:embed templates/e2e/*.tmpl
+var e2eTemplates embed.FS
+
+// e2eOutputs are the module outputs the end-to-end test expects to be non-empty.
+var e2eOutputs = []string{"resource_id", "name"}
+
+// renderE2ETemplate renders templates/e2e/<name>.tmpl with the outputs the test checks.
+func renderE2ETemplate(name string, outputs []string) ([]byte, error) {
+	tmpl, err := template.ParseFS(e2eTemplates, "templates/e2e/"+name+".tmpl")
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, outputs); err != nil {
+		return nil, fmt.Errorf("rendering %s: %w", name, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// buildExampleOutputs passes the module outputs the end-to-end test checks on as
+// outputs of the example.
+func buildExampleOutputs(outputs []string) []byte {
+	file := hclwrite.NewEmptyFile()
+	for i, name := range outputs {
+		if i > 0 {
+			file.Body().AppendNewline()
+		}
+		outputBody := file.Body().AppendNewBlock("output", []string{name}).Body()
+		hclgen.SetDescriptionAttribute(outputBody, fmt.Sprintf("The %s output of the module.", name))
+		outputBody.SetAttributeRaw("value", hclgen.TokensForTraversal("module", "test", name))
+	}
+	src := strings.TrimRight(string(hclwrite.Format(file.Bytes())), "\n")
+	return []byte(src + "\n")
+}
@@ -0,0 +1,11 @@
+package terraform
+
+import "errors"
+
+// ErrNameCollision is matched by the errors reporting two schema properties
+// that map to the same Terraform variable name.
+var ErrNameCollision = errors.New("terraform variable name collision")
+
+// ErrBreakingChanges is matched by the error Update returns when the new API
+// version has breaking changes and UpdateOptions.RejectBreaking is set.
+var ErrBreakingChanges = errors.New("breaking changes")
@@ -0,0 +1,159 @@
+package terraform
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/matt-FFFFFF/tfmodmake/hclgen"
+	"github.com/matt-FFFFFF/tfmodmake/naming"
+	"github.com/zclconf/go-cty/cty"
+)
+
+const (
+	exampleResourceGroupType    = "Microsoft.Resources/resourceGroups@2024-03-01"
+	exampleUserAssignedIdentity = "Microsoft.ManagedIdentity/userAssignedIdentities@2023-01-31"
+	exampleLocation             = "eastus"
+)
+
+// exampleBootstrap holds the prerequisite resources an example creates before
+// calling the module, and the module arguments that reference them.
+type exampleBootstrap struct {
+	body *hclwrite.Body
+	// overrides replace the placeholder of the value at an attribute path, e.g.
+	// "parent_id" or "managed_identities.user_assigned_resource_ids.*".
+	overrides map[string]hclwrite.Tokens
+
+	clientConfig  bool
+	resourceGroup bool
+}
+
+// newExampleBootstrap infers the prerequisites of an example from the variables it
+// sets: the resource group and parent resources behind parent_id, scope and
+// resource_group_name, a user-assigned identity for managed_identities, and the
+// caller's identity for principal, tenant and subscription IDs. resourceType and
+// apiVersion are those of the module's azapi_resource.
+func newExampleBootstrap(resourceType, apiVersion string, variables []moduleVariable, complete bool) *exampleBootstrap {
+	b := &exampleBootstrap{body: hclwrite.NewEmptyFile().Body(), overrides: map[string]hclwrite.Tokens{}}
+	set := map[string]moduleVariable{}
+	for _, v := range variables {
+		if v.required || complete {
+			set[v.name] = v
+		}
+	}
+
+	if v, ok := set["parent_id"]; ok && v.ty == cty.String && b.acceptsResourceGroupID(v) {
+		b.overrides["parent_id"] = b.parentChain(resourceType, apiVersion)
+	}
+	if v, ok := set["scope"]; ok && v.ty == cty.String {
+		b.overrides["scope"] = hclgen.TokensForTraversal("azapi_resource", "resource_group", "id")
+		b.requireResourceGroup()
+	}
+	if v, ok := set["resource_group_name"]; ok && v.ty == cty.String {
+		b.overrides["resource_group_name"] = hclgen.TokensForTraversal("azapi_resource", "resource_group", "name")
+		b.requireResourceGroup()
+	}
+	for _, name := range []string{"subscription_id", "tenant_id"} {
+		if v, ok := set[name]; ok && v.ty == cty.String {
+			b.overrides[name] = b.clientConfigAttribute(name)
+		}
+	}
+	if v, ok := set["managed_identities"]; ok && complete && v.ty.IsObjectType() && v.ty.HasAttribute("user_assigned_resource_ids") {
+		b.overrides["managed_identities.user_assigned_resource_ids.*"] = b.userAssignedIdentity()
+	}
+	if v, ok := set["role_assignments"]; ok && complete && v.ty.IsMapType() && v.ty.ElementType().IsObjectType() {
+		b.overrides["role_assignments.*.principal_id"] = b.clientConfigAttribute("object_id")
+		b.overrides["role_assignments.*.role_definition_id_or_name"] = hclwrite.TokensForValue(cty.StringVal("Reader"))
+	}
+	if _, ok := set["location"]; ok && b.resourceGroup {
+		b.overrides["location"] = hclgen.TokensForTraversal("azapi_resource", "resource_group", "location")
+	}
+	return b
+}
+
+// acceptsResourceGroupID reports whether the validations of the parent ID allow a
+// resource group or resource below one, rather than e.g. a management group.
+func (b *exampleBootstrap) acceptsResourceGroupID(v moduleVariable) bool {
+	c := v.constraints["parent_id"]
+	if c == nil || c.pattern == "" {
+		return true
+	}
+	re, err := regexp.Compile(c.pattern)
+	return err == nil && re.MatchString("/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/rg-example")
+}
+
+// parentChain creates the resource group and every ancestor of a child resource
+// type, each below the previous one, and returns the ID of the direct parent.
+func (b *exampleBootstrap) parentChain(resourceType, apiVersion string) hclwrite.Tokens {
+	b.requireResourceGroup()
+	parentID := hclgen.TokensForTraversal("azapi_resource", "resource_group", "id")
+
+	segments := strings.Split(resourceType, "/")
+	if len(segments) < 3 || apiVersion == "" {
+		return parentID
+	}
+	for i := 2; i < len(segments); i++ {
+		ancestorType := strings.Join(segments[:i], "/")
+		label := singularize(naming.ToSnakeCase(segments[i-1]))
+		b.body.AppendUnstructuredTokens(hclwrite.Tokens{
+			&hclwrite.Token{Type: hclsyntax.TokenComment, Bytes: []byte(fmt.Sprintf("# Parent %s; set the properties it requires before applying the example.", ancestorType))},
+			&hclwrite.Token{Type: hclsyntax.TokenNewline, Bytes: []byte("\n")},
+		})
+		ancestor := b.body.AppendNewBlock("resource", []string{"azapi_resource", label}).Body()
+		ancestor.SetAttributeValue("type", cty.StringVal(ancestorType+"@"+apiVersion))
+		ancestor.SetAttributeValue("name", cty.StringVal(strings.ReplaceAll(label, "_", "-")+"-example"))
+		ancestor.SetAttributeRaw("parent_id", parentID)
+		if i == 2 {
+			ancestor.SetAttributeRaw("location", hclgen.TokensForTraversal("azapi_resource", "resource_group", "location"))
+		}
+		ancestor.SetAttributeRaw("body", hclwrite.TokensForObject(nil))
+		b.body.AppendNewline()
+		parentID = hclgen.TokensForTraversal("azapi_resource", label, "id")
+	}
+	return parentID
+}
+
+// requireResourceGroup creates the resource group of the example once.
+func (b *exampleBootstrap) requireResourceGroup() {
+	if b.resourceGroup {
+		return
+	}
+	b.resourceGroup = true
+	rg := b.body.AppendNewBlock("resource", []string{"azapi_resource", "resource_group"}).Body()
+	rg.SetAttributeValue("type", cty.StringVal(exampleResourceGroupType))
+	rg.SetAttributeValue("name", cty.StringVal("rg-example"))
+	rg.SetAttributeValue("location", cty.StringVal(exampleLocation))
+	rg.SetAttributeRaw("parent_id", hclgen.TokensForInterpolatedString("/subscriptions/", "data", "azapi_client_config", "current", "subscription_id"))
+	b.clientConfig = true
+	b.body.AppendNewline()
+}
+
+// userAssignedIdentity creates a user-assigned identity in the resource group and
+// returns its ID.
+func (b *exampleBootstrap) userAssignedIdentity() hclwrite.Tokens {
+	b.requireResourceGroup()
+	identity := b.body.AppendNewBlock("resource", []string{"azapi_resource", "user_assigned_identity"}).Body()
+	identity.SetAttributeValue("type", cty.StringVal(exampleUserAssignedIdentity))
+	identity.SetAttributeValue("name", cty.StringVal("uai-example"))
+	identity.SetAttributeRaw("location", hclgen.TokensForTraversal("azapi_resource", "resource_group", "location"))
+	identity.SetAttributeRaw("parent_id", hclgen.TokensForTraversal("azapi_resource", "resource_group", "id"))
+	b.body.AppendNewline()
+	return hclgen.TokensForTraversal("azapi_resource", "user_assigned_identity", "id")
+}
+
+// clientConfigAttribute reads an attribute of the identity running the example.
+func (b *exampleBootstrap) clientConfigAttribute(name string) hclwrite.Tokens {
+	b.clientConfig = true
+	return hclgen.TokensForTraversal("data", "azapi_client_config", "current", name)
+}
+
+// appendTo writes the data sources and resources of the bootstrap to body.
+func (b *exampleBootstrap) appendTo(body *hclwrite.Body) {
+	if b.clientConfig {
+		body.AppendNewBlock("data", []string{"azapi_client_config", "current"})
+		body.AppendNewline()
+	}
+	body.AppendUnstructuredTokens(b.body.BuildTokens(nil))
+}
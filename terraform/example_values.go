@@ -0,0 +1,330 @@
+package terraform
+
+import (
+	"math/big"
+	"regexp"
+	"regexp/syntax"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// exampleConstraint is what the validations of a variable require of one value.
+type exampleConstraint struct {
+	enum      []cty.Value
+	min, max  *big.Float
+	minLength *int
+	maxLength *int
+	pattern   string
+}
+
+// exampleBuilder produces placeholder values for example module calls.
+type exampleBuilder struct {
+	// constraints are keyed by the attribute path below var, e.g. "lock.kind".
+	constraints map[string]*exampleConstraint
+	// complete sets optional object attributes and fills collections, for
+	// examples exercising every variable.
+	complete bool
+	// overrides are expressions used instead of placeholders, keyed by attribute
+	// path with "*" for collection elements.
+	overrides map[string]hclwrite.Tokens
+	// spec holds values harvested from the spec examples, used instead of
+	// placeholders for the variables they fit.
+	spec specExampleValues
+}
+
+// exampleValue returns a placeholder of the type for the named variable. Optional
+// object attributes are left out.
+func exampleValue(name string, ty cty.Type) cty.Value {
+	return (&exampleBuilder{}).value(name, name, ty)
+}
+
+// value returns a placeholder of ty for the value at path, named name, that
+// satisfies the validations parsed for the path.
+func (b *exampleBuilder) value(path, name string, ty cty.Type) cty.Value {
+	if raw, ok := b.spec[path]; ok {
+		if v, ok := b.specValue(path, raw, ty); ok {
+			return v
+		}
+	}
+	c := b.constraints[path]
+	if c == nil {
+		c = &exampleConstraint{}
+	}
+	for _, v := range c.enum {
+		if v.Type().Equals(ty) || ty == cty.DynamicPseudoType {
+			return v
+		}
+	}
+
+	switch {
+	case ty == cty.String:
+		return cty.StringVal(c.sampleString(name))
+	case ty == cty.Number:
+		return cty.NumberVal(c.sampleNumber())
+	case ty == cty.Bool:
+		return cty.False
+	case ty.IsListType():
+		if b.complete {
+			return cty.ListVal([]cty.Value{b.value(path+".*", singularize(name), ty.ElementType())})
+		}
+		return cty.ListValEmpty(ty.ElementType())
+	case ty.IsSetType():
+		if b.complete {
+			return cty.SetVal([]cty.Value{b.value(path+".*", singularize(name), ty.ElementType())})
+		}
+		return cty.SetValEmpty(ty.ElementType())
+	case ty.IsMapType():
+		if b.complete {
+			return cty.MapVal(map[string]cty.Value{"example": b.value(path+".*", singularize(name), ty.ElementType())})
+		}
+		return cty.MapValEmpty(ty.ElementType())
+	case ty.IsObjectType():
+		attrs := map[string]cty.Value{}
+		for attrName, attrType := range ty.AttributeTypes() {
+			if b.complete || !ty.AttributeOptional(attrName) {
+				attrs[attrName] = b.value(path+"."+attrName, attrName, attrType)
+			}
+		}
+		return cty.ObjectVal(attrs)
+	}
+	return cty.EmptyObjectVal
+}
+
+// tokens is like value, but uses the override expression of a path, and of the
+// paths below it, in place of the placeholder.
+func (b *exampleBuilder) tokens(path, name string, ty cty.Type) hclwrite.Tokens {
+	if override, ok := b.overrides[path]; ok {
+		return override
+	}
+	overridden := false
+	for p := range b.overrides {
+		overridden = overridden || strings.HasPrefix(p, path+".")
+	}
+	if !overridden {
+		return hclwrite.TokensForValue(b.value(path, name, ty))
+	}
+
+	switch {
+	case b.complete && (ty.IsListType() || ty.IsSetType()):
+		return hclwrite.TokensForTuple([]hclwrite.Tokens{b.tokens(path+".*", singularize(name), ty.ElementType())})
+	case b.complete && ty.IsMapType():
+		return hclwrite.TokensForObject([]hclwrite.ObjectAttrTokens{
+			{Name: hclwrite.TokensForIdentifier("example"), Value: b.tokens(path+".*", singularize(name), ty.ElementType())},
+		})
+	case ty.IsObjectType():
+		var names []string
+		for attrName := range ty.AttributeTypes() {
+			if b.complete || !ty.AttributeOptional(attrName) {
+				names = append(names, attrName)
+			}
+		}
+		sort.Strings(names)
+		attrs := make([]hclwrite.ObjectAttrTokens, len(names))
+		for i, attrName := range names {
+			attrs[i] = hclwrite.ObjectAttrTokens{
+				Name:  tokensForObjectKey(attrName),
+				Value: b.tokens(path+"."+attrName, attrName, ty.AttributeType(attrName)),
+			}
+		}
+		return hclwrite.TokensForObject(attrs)
+	}
+	return hclwrite.TokensForValue(b.value(path, name, ty))
+}
+
+// sampleString returns a string for the named value within the length limits
+// and matching the pattern, falling back to the shortest match of the pattern.
+func (c *exampleConstraint) sampleString(name string) string {
+	var s string
+	switch {
+	case name == "location":
+		s = "eastus"
+	case name == "parent_id":
+		s = "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/rg-example"
+	case strings.HasSuffix(name, "_id"):
+		s = "<" + name + ">"
+	default:
+		s = "example"
+	}
+	if c.maxLength != nil && len(s) > *c.maxLength {
+		s = s[:*c.maxLength]
+	}
+	if c.minLength != nil && len(s) < *c.minLength {
+		s += strings.Repeat("x", *c.minLength-len(s))
+	}
+	if c.pattern == "" {
+		return s
+	}
+	re, err := regexp.Compile(c.pattern)
+	if err != nil || re.MatchString(s) {
+		return s
+	}
+	if match, ok := shortestMatch(c.pattern); ok {
+		return match
+	}
+	return s
+}
+
+// sampleNumber returns the middle of the allowed range, rounded down to an integer.
+func (c *exampleConstraint) sampleNumber() *big.Float {
+	switch {
+	case c.min != nil && c.max != nil:
+		mid := new(big.Float).Quo(new(big.Float).Add(c.min, c.max), big.NewFloat(2))
+		i, _ := mid.Int(nil)
+		return new(big.Float).SetInt(i)
+	case c.min != nil:
+		return c.min
+	case c.max != nil && c.max.Cmp(big.NewFloat(1)) < 0:
+		return c.max
+	}
+	return big.NewFloat(1)
+}
+
+// shortestMatch builds a short string matching pattern: the first alternative of
+// every choice, the minimum number of repetitions, and a lowercase letter or
+// digit from character classes where possible.
+func shortestMatch(pattern string) (string, bool) {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return "", false
+	}
+	var sb strings.Builder
+	var walk func(re *syntax.Regexp)
+	walk = func(re *syntax.Regexp) {
+		switch re.Op {
+		case syntax.OpLiteral:
+			sb.WriteString(string(re.Rune))
+		case syntax.OpCharClass:
+			sb.WriteRune(pickClassRune(re.Rune))
+		case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+			sb.WriteRune('a')
+		case syntax.OpCapture:
+			walk(re.Sub[0])
+		case syntax.OpPlus:
+			walk(re.Sub[0])
+		case syntax.OpRepeat:
+			for i := 0; i < re.Min; i++ {
+				walk(re.Sub[0])
+			}
+		case syntax.OpConcat:
+			for _, sub := range re.Sub {
+				walk(sub)
+			}
+		case syntax.OpAlternate:
+			walk(re.Sub[0])
+		}
+	}
+	walk(re)
+
+	match := sb.String()
+	ok, err := regexp.MatchString(pattern, match)
+	return match, err == nil && ok
+}
+
+// pickClassRune picks a rune from a character class given as pairs of range bounds.
+func pickClassRune(ranges []rune) rune {
+	for _, preferred := range []rune{'a', '0', 'A'} {
+		for i := 0; i+1 < len(ranges); i += 2 {
+			if ranges[i] <= preferred && preferred <= ranges[i+1] {
+				return preferred
+			}
+		}
+	}
+	if len(ranges) == 0 {
+		return 'a'
+	}
+	return ranges[0]
+}
+
+// validationConstraints collects what the validation conditions of a variable
+// require of its value and attributes: contains() enums, numeric bounds,
+// length() bounds and regex() patterns on var.<path>.
+func validationConstraints(conditions []hclsyntax.Expression) map[string]*exampleConstraint {
+	constraints := map[string]*exampleConstraint{}
+	get := func(path string) *exampleConstraint {
+		if constraints[path] == nil {
+			constraints[path] = &exampleConstraint{}
+		}
+		return constraints[path]
+	}
+	for _, condition := range conditions {
+		hclsyntax.VisitAll(condition, func(node hclsyntax.Node) hcl.Diagnostics {
+			switch expr := node.(type) {
+			case *hclsyntax.FunctionCallExpr:
+				if len(expr.Args) != 2 {
+					return nil
+				}
+				path, ok := variablePath(expr.Args[1])
+				if !ok {
+					return nil
+				}
+				value, diags := expr.Args[0].Value(nil)
+				if diags.HasErrors() || !value.IsWhollyKnown() || value.IsNull() {
+					return nil
+				}
+				switch {
+				case expr.Name == "contains" && (value.Type().IsTupleType() || value.Type().IsListType()):
+					get(path).enum = value.AsValueSlice()
+				case expr.Name == "regex" && value.Type() == cty.String:
+					get(path).pattern = value.AsString()
+				}
+			case *hclsyntax.BinaryOpExpr:
+				bound, diags := expr.RHS.Value(nil)
+				if diags.HasErrors() || bound.IsNull() || bound.Type() != cty.Number {
+					return nil
+				}
+				lhs := expr.LHS
+				length := false
+				if call, ok := lhs.(*hclsyntax.FunctionCallExpr); ok && call.Name == "length" && len(call.Args) == 1 {
+					lhs, length = call.Args[0], true
+				}
+				path, ok := variablePath(lhs)
+				if !ok {
+					return nil
+				}
+				c := get(path)
+				n := bound.AsBigFloat()
+				limit, _ := n.Int64()
+				switch expr.Op {
+				case hclsyntax.OpGreaterThanOrEqual:
+					if length {
+						l := int(limit)
+						c.minLength = &l
+					} else {
+						c.min = n
+					}
+				case hclsyntax.OpLessThanOrEqual:
+					if length {
+						l := int(limit)
+						c.maxLength = &l
+					} else {
+						c.max = n
+					}
+				}
+			}
+			return nil
+		})
+	}
+	return constraints
+}
+
+// variablePath returns "a.b" for the traversal var.a.b.
+func variablePath(expr hclsyntax.Expression) (string, bool) {
+	traversal, ok := expr.(*hclsyntax.ScopeTraversalExpr)
+	if !ok || traversal.Traversal.RootName() != "var" {
+		return "", false
+	}
+	var parts []string
+	for _, step := range traversal.Traversal[1:] {
+		attr, ok := step.(hcl.TraverseAttr)
+		if !ok {
+			return "", false
+		}
+		parts = append(parts, attr.Name)
+	}
+	return strings.Join(parts, "."), len(parts) > 0
+}
@@ -0,0 +1,361 @@
+package terraform
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/matt-FFFFFF/tfmodmake/hclgen"
+	"github.com/matt-FFFFFF/tfmodmake/schema"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// ExclusiveRule declares that at most one of the properties at Paths may be set.
+// Paths are relative to the resource body, like those of PreconditionRule.
+type ExclusiveRule struct {
+	Paths []string
+}
+
+// exclusiveWithPattern matches description phrases naming one sibling, such as
+// "Mutually exclusive with subnetId" or "Cannot be used together with 'vnetId'".
+var exclusiveWithPattern = regexp.MustCompile(
+	"(?i)\\b(?:mutually\\s+exclusive\\s+with|cannot\\s+be\\s+(?:used|set|specified|combined)\\s+(?:together\\s+)?with)\\s+(?:the\\s+)?[`'\"]?([A-Za-z][A-Za-z0-9]*)",
+)
+
+// exclusivePairPatterns match description phrases naming two siblings, such as
+// "Only one of keyVaultUri and value can be set" or "Either subnetId or vnetId,
+// but not both". "Either X or Y must be set" alone does not rule out both.
+var exclusivePairPatterns = []*regexp.Regexp{
+	regexp.MustCompile("(?i)\\bonly\\s+one\\s+of\\s+[`'\"]?([A-Za-z][A-Za-z0-9]*)[`'\"]?,?\\s+(?:and|or)\\s+[`'\"]?([A-Za-z][A-Za-z0-9]*)"),
+	regexp.MustCompile("(?i)\\beither\\s+[`'\"]?([A-Za-z][A-Za-z0-9]*)[`'\"]?\\s+or\\s+[`'\"]?([A-Za-z][A-Za-z0-9]*)[`'\"]?[^.]*\\bnot\\s+both\\b"),
+}
+
+// inferExclusiveRules derives pairs of mutually exclusive properties from
+// sibling descriptions that rule each other out. Only writable siblings are
+// paired. Properties of discriminated object variants are covered by
+// inferVariantRules instead.
+func inferExclusiveRules(rs *schema.ResourceSchema) []ExclusiveRule {
+	if rs == nil {
+		return nil
+	}
+	pairs := map[[2]string]struct{}{}
+	inferExclusiveRulesRecursive(rs.Properties, "", pairs)
+
+	rules := make([]ExclusiveRule, 0, len(pairs))
+	for pair := range pairs {
+		rules = append(rules, ExclusiveRule{Paths: []string{pair[0], pair[1]}})
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		if rules[i].Paths[0] != rules[j].Paths[0] {
+			return rules[i].Paths[0] < rules[j].Paths[0]
+		}
+		return rules[i].Paths[1] < rules[j].Paths[1]
+	})
+	return rules
+}
+
+func inferExclusiveRulesRecursive(props map[string]*schema.Property, prefix string, pairs map[[2]string]struct{}) {
+	add := func(a, b string) {
+		if a == b {
+			return
+		}
+		if a > b {
+			a, b = b, a
+		}
+		pairs[[2]string{prefix + a, prefix + b}] = struct{}{}
+	}
+	sibling := func(name string) (string, bool) {
+		found, prop := findSiblingFold(props, name)
+		return found, prop != nil && isWritableProperty(prop)
+	}
+
+	for name, prop := range props {
+		if prop == nil || !isWritableProperty(prop) {
+			continue
+		}
+		if match := exclusiveWithPattern.FindStringSubmatch(prop.Description); match != nil {
+			if other, ok := sibling(match[1]); ok {
+				add(name, other)
+			}
+		}
+		for _, pattern := range exclusivePairPatterns {
+			if match := pattern.FindStringSubmatch(prop.Description); match != nil {
+				a, okA := sibling(match[1])
+				b, okB := sibling(match[2])
+				if okA && okB {
+					add(a, b)
+				}
+			}
+		}
+
+		if prop.Type == schema.TypeObject && len(prop.Children) > 0 {
+			inferExclusiveRulesRecursive(prop.Children, prefix+name+".", pairs)
+		}
+	}
+}
+
+// variantRule declares that the properties at Paths, which only one variant of
+// a discriminated object declares, must be unset unless the discriminator at
+// Discriminator equals Value.
+type variantRule struct {
+	Discriminator string
+	Value         string
+	Paths         []string
+}
+
+// inferVariantRules derives one rule per variant of each discriminated object
+// from the properties only that variant declares.
+func inferVariantRules(rs *schema.ResourceSchema) []variantRule {
+	if rs == nil {
+		return nil
+	}
+	var rules []variantRule
+	inferVariantRulesRecursive(rs.Properties, "", &rules)
+	sort.Slice(rules, func(i, j int) bool {
+		if rules[i].Discriminator != rules[j].Discriminator {
+			return rules[i].Discriminator < rules[j].Discriminator
+		}
+		return rules[i].Value < rules[j].Value
+	})
+	return rules
+}
+
+func inferVariantRulesRecursive(props map[string]*schema.Property, prefix string, rules *[]variantRule) {
+	byVariant := map[string][]string{}
+	for name, prop := range props {
+		if prop != nil && isWritableProperty(prop) && len(prop.Variants) == 1 {
+			byVariant[prop.Variants[0]] = append(byVariant[prop.Variants[0]], prefix+name)
+		}
+	}
+	if discriminator, ok := discriminatorProperty(props, byVariant); ok {
+		for value, paths := range byVariant {
+			sort.Strings(paths)
+			*rules = append(*rules, variantRule{Discriminator: prefix + discriminator, Value: value, Paths: paths})
+		}
+	}
+
+	for name, prop := range props {
+		if prop != nil && isWritableProperty(prop) && prop.Type == schema.TypeObject && len(prop.Children) > 0 {
+			inferVariantRulesRecursive(prop.Children, prefix+name+".", rules)
+		}
+	}
+}
+
+// discriminatorProperty returns the name of the required string enum sibling
+// that accepts every variant value, which is how discriminators are flattened.
+// It returns false when there is no such sibling or more than one.
+func discriminatorProperty(props map[string]*schema.Property, byVariant map[string][]string) (string, bool) {
+	if len(byVariant) == 0 {
+		return "", false
+	}
+	var found []string
+	for name, prop := range props {
+		if prop == nil || !prop.Required || prop.Type != schema.TypeString || len(prop.Variants) > 0 || !isWritableProperty(prop) {
+			continue
+		}
+		accepts := true
+		for value := range byVariant {
+			if !slices.Contains(prop.Enum, value) {
+				accepts = false
+				break
+			}
+		}
+		if accepts {
+			found = append(found, name)
+		}
+	}
+	if len(found) != 1 {
+		return "", false
+	}
+	return found[0], true
+}
+
+// resolveExclusiveRules binds inferred and configured rules to variable
+// references. Inferred rules that cannot be expressed over module variables are
+// dropped; configured rules that cannot be expressed are an error.
+func resolveExclusiveRules(rs *schema.ResourceSchema, configured []ExclusiveRule, secrets []secretField, moduleNamePrefix string) ([]resolvedPrecondition, error) {
+	if rs == nil {
+		return nil, nil
+	}
+
+	secretPaths := make(map[string]struct{}, len(secrets))
+	for _, s := range secrets {
+		secretPaths[s.path] = struct{}{}
+	}
+
+	seen := map[string]struct{}{}
+	var resolved []resolvedPrecondition
+	add := func(rule ExclusiveRule, strict bool) error {
+		key := strings.Join(rule.Paths, "\x00")
+		if _, ok := seen[key]; ok {
+			return nil
+		}
+		seen[key] = struct{}{}
+		p, err := resolveExclusiveRule(rs, rule, secretPaths, moduleNamePrefix)
+		if err != nil {
+			if strict {
+				return err
+			}
+			return nil
+		}
+		resolved = append(resolved, p)
+		return nil
+	}
+
+	for _, rule := range configured {
+		paths := make([]string, len(rule.Paths))
+		for i, path := range rule.Paths {
+			paths[i] = strings.TrimPrefix(strings.TrimSpace(path), "body.")
+		}
+		if err := add(ExclusiveRule{Paths: paths}, true); err != nil {
+			return nil, err
+		}
+	}
+	for _, rule := range inferExclusiveRules(rs) {
+		_ = add(rule, false)
+	}
+	for _, rule := range inferVariantRules(rs) {
+		if p, err := resolveVariantRule(rs, rule, secretPaths, moduleNamePrefix); err == nil {
+			resolved = append(resolved, p)
+		}
+	}
+
+	return resolved, nil
+}
+
+func resolveExclusiveRule(rs *schema.ResourceSchema, rule ExclusiveRule, secretPaths map[string]struct{}, moduleNamePrefix string) (resolvedPrecondition, error) {
+	if len(rule.Paths) < 2 {
+		return resolvedPrecondition{}, fmt.Errorf("mutually exclusive paths %q must name at least two properties", rule.Paths)
+	}
+
+	var variable string
+	var refs []hclwrite.Tokens
+	var names []string
+	for _, path := range rule.Paths {
+		prop := propertyForExportPath(rs, path)
+		if prop == nil || !isWritableProperty(prop) {
+			return resolvedPrecondition{}, fmt.Errorf("mutually exclusive path %q does not exist in the resource schema or is read-only", path)
+		}
+		if _, ok := secretPaths[path]; ok {
+			return resolvedPrecondition{}, fmt.Errorf("mutually exclusive path %q is a secret and cannot be referenced", path)
+		}
+		parts, guards, err := variablePartsForBodyPath(path, moduleNamePrefix)
+		if err != nil {
+			return resolvedPrecondition{}, err
+		}
+		if variable == "" {
+			variable = parts[1]
+		}
+		refs = append(refs, wrapAncestorGuards(guards, nil, hclgen.TokensForTraversal(parts...)))
+		names = append(names, strings.Join(parts, "."))
+	}
+
+	return resolvedPrecondition{
+		variable:     variable,
+		condition:    atMostOneSetTokens(refs),
+		errorMessage: fmt.Sprintf("Only one of %s may be set.", joinNames(names)),
+	}, nil
+}
+
+// resolveVariantRule renders a variant rule as
+// "kind == \"Git\" || (branch == null && commit == null)", guarded like a
+// precondition gate so a null parent object passes.
+func resolveVariantRule(rs *schema.ResourceSchema, rule variantRule, secretPaths map[string]struct{}, moduleNamePrefix string) (resolvedPrecondition, error) {
+	gateParts, gateGuards, err := variablePartsForBodyPath(rule.Discriminator, moduleNamePrefix)
+	if err != nil {
+		return resolvedPrecondition{}, err
+	}
+
+	var unset hclwrite.Tokens
+	var variable string
+	var names []string
+	for i, path := range rule.Paths {
+		if _, ok := secretPaths[path]; ok {
+			return resolvedPrecondition{}, fmt.Errorf("variant path %q is a secret and cannot be referenced", path)
+		}
+		parts, guards, err := variablePartsForBodyPath(path, moduleNamePrefix)
+		if err != nil {
+			return resolvedPrecondition{}, err
+		}
+		if variable == "" {
+			variable = parts[1]
+		}
+		if i > 0 {
+			unset = append(unset, &hclwrite.Token{Type: hclsyntax.TokenAnd, Bytes: []byte(" && ")})
+		}
+		unset = append(unset, wrapAncestorGuards(guards, gateGuards, hclgen.TokensForTraversal(parts...))...)
+		unset = append(unset, &hclwrite.Token{Type: hclsyntax.TokenEqualOp, Bytes: []byte(" == ")})
+		unset = append(unset, hclwrite.TokensForIdentifier("null")...)
+		names = append(names, strings.Join(parts, "."))
+	}
+	if len(rule.Paths) > 1 {
+		unset = append(hclwrite.Tokens{{Type: hclsyntax.TokenOParen, Bytes: []byte("(")}}, unset...)
+		unset = append(unset, &hclwrite.Token{Type: hclsyntax.TokenCParen, Bytes: []byte(")")})
+	}
+
+	gateValue := hclwrite.TokensForValue(cty.StringVal(rule.Value))
+	var condition hclwrite.Tokens
+	condition = append(condition, hclgen.TokensForTraversal(gateParts...)...)
+	condition = append(condition, &hclwrite.Token{Type: hclsyntax.TokenEqualOp, Bytes: []byte(" == ")})
+	condition = append(condition, gateValue...)
+	condition = append(condition, &hclwrite.Token{Type: hclsyntax.TokenOr, Bytes: []byte(" || ")})
+	condition = append(condition, unset...)
+	for i := len(gateGuards) - 1; i >= 0; i-- {
+		condition = wrapWithNullGuard(hclgen.TokensForTraversal(gateGuards[i]...), condition)
+	}
+
+	return resolvedPrecondition{
+		variable:  variable,
+		condition: condition,
+		errorMessage: fmt.Sprintf("%s may only be set when %s is %s.",
+			joinNames(names), strings.Join(gateParts, "."), string(gateValue.Bytes())),
+	}, nil
+}
+
+// atMostOneSetTokens returns a condition that holds when at most one of refs is
+// non-null: "a == null || b == null" for a pair, and otherwise
+// "length([for v in [a, b, c] : v if v != null]) <= 1".
+func atMostOneSetTokens(refs []hclwrite.Tokens) hclwrite.Tokens {
+	isNull := func(ref hclwrite.Tokens) hclwrite.Tokens {
+		var out hclwrite.Tokens
+		out = append(out, ref...)
+		out = append(out, &hclwrite.Token{Type: hclsyntax.TokenEqualOp, Bytes: []byte(" == ")})
+		return append(out, hclwrite.TokensForIdentifier("null")...)
+	}
+	if len(refs) == 2 {
+		out := isNull(refs[0])
+		out = append(out, &hclwrite.Token{Type: hclsyntax.TokenOr, Bytes: []byte(" || ")})
+		return append(out, isNull(refs[1])...)
+	}
+
+	ident := func(name string) *hclwrite.Token {
+		return &hclwrite.Token{Type: hclsyntax.TokenIdent, Bytes: []byte(name)}
+	}
+	set := hclwrite.Tokens{
+		{Type: hclsyntax.TokenOBrack, Bytes: []byte("[")},
+		ident("for"), ident("v"), ident("in"),
+	}
+	set = append(set, hclwrite.TokensForTuple(refs)...)
+	set = append(set,
+		&hclwrite.Token{Type: hclsyntax.TokenColon, Bytes: []byte(":")},
+		ident("v"), ident("if"), ident("v"),
+		&hclwrite.Token{Type: hclsyntax.TokenNotEqual, Bytes: []byte("!=")},
+		ident("null"),
+		&hclwrite.Token{Type: hclsyntax.TokenCBrack, Bytes: []byte("]")},
+	)
+	out := hclwrite.TokensForFunctionCall("length", set)
+	out = append(out, &hclwrite.Token{Type: hclsyntax.TokenLessThanEq, Bytes: []byte(" <= ")})
+	return append(out, &hclwrite.Token{Type: hclsyntax.TokenNumberLit, Bytes: []byte("1")})
+}
+
+// joinNames lists names as "a and b" or "a, b and c".
+func joinNames(names []string) string {
+	if len(names) < 2 {
+		return strings.Join(names, "")
+	}
+	return strings.Join(names[:len(names)-1], ", ") + " and " + names[len(names)-1]
+}
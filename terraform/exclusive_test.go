@@ -0,0 +1,125 @@
+package terraform
+
+import (
+	"os"
+	"testing"
+
+	"github.com/matt-FFFFFF/tfmodmake/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func exclusiveSchema() *schema.ResourceSchema {
+	return &schema.ResourceSchema{
+		Properties: map[string]*schema.Property{
+			"properties": {Name: "properties", Type: schema.TypeObject, Children: map[string]*schema.Property{
+				"subnetId": {Name: "subnetId", Type: schema.TypeString, Description: "The subnet. Mutually exclusive with vnetId."},
+				"vnetId":   {Name: "vnetId", Type: schema.TypeString},
+				"keyVaultUri": {Name: "keyVaultUri", Type: schema.TypeString,
+					Description: "Only one of keyVaultUri and value can be set."},
+				"value":  {Name: "value", Type: schema.TypeString},
+				"status": {Name: "status", Type: schema.TypeString, ReadOnly: true, Description: "Cannot be used with value."},
+				"source": {Name: "source", Type: schema.TypeObject, Children: map[string]*schema.Property{
+					"kind":      {Name: "kind", Type: schema.TypeString, Required: true, Enum: []string{"Git", "Registry"}},
+					"branch":    {Name: "branch", Type: schema.TypeString, Variants: []string{"Git"}},
+					"commit":    {Name: "commit", Type: schema.TypeString, Variants: []string{"Git"}},
+					"image":     {Name: "image", Type: schema.TypeString, Variants: []string{"Registry"}},
+					"tag":       {Name: "tag", Type: schema.TypeString, Variants: []string{"Registry"}},
+					"shared":    {Name: "shared", Type: schema.TypeString, Variants: []string{"Git", "Registry"}},
+					"unrelated": {Name: "unrelated", Type: schema.TypeString, Description: "Either true or false, but not both."},
+				}},
+			}},
+		},
+	}
+}
+
+func TestInferExclusiveRules(t *testing.T) {
+	assert.Equal(t, []ExclusiveRule{
+		{Paths: []string{"properties.keyVaultUri", "properties.value"}},
+		{Paths: []string{"properties.subnetId", "properties.vnetId"}},
+	}, inferExclusiveRules(exclusiveSchema()))
+}
+
+func TestInferVariantRules(t *testing.T) {
+	assert.Equal(t, []variantRule{
+		{Discriminator: "properties.source.kind", Value: "Git", Paths: []string{"properties.source.branch", "properties.source.commit"}},
+		{Discriminator: "properties.source.kind", Value: "Registry", Paths: []string{"properties.source.image", "properties.source.tag"}},
+	}, inferVariantRules(exclusiveSchema()))
+}
+
+func TestInferVariantRules_OneRulePerVariant(t *testing.T) {
+	rs := &schema.ResourceSchema{
+		Properties: map[string]*schema.Property{
+			"type": {Name: "type", Type: schema.TypeString, Required: true, Enum: []string{"A", "B", "C"}},
+			"a1":   {Name: "a1", Type: schema.TypeString, Variants: []string{"A"}},
+			"a2":   {Name: "a2", Type: schema.TypeString, Variants: []string{"A"}},
+			"a3":   {Name: "a3", Type: schema.TypeString, Variants: []string{"A"}},
+			"b1":   {Name: "b1", Type: schema.TypeString, Variants: []string{"B"}},
+			"b2":   {Name: "b2", Type: schema.TypeString, Variants: []string{"B"}},
+			"c1":   {Name: "c1", Type: schema.TypeString, Variants: []string{"C"}},
+			"bc":   {Name: "bc", Type: schema.TypeString, Variants: []string{"B", "C"}},
+		},
+	}
+	assert.Equal(t, []variantRule{
+		{Discriminator: "type", Value: "A", Paths: []string{"a1", "a2", "a3"}},
+		{Discriminator: "type", Value: "B", Paths: []string{"b1", "b2"}},
+		{Discriminator: "type", Value: "C", Paths: []string{"c1"}},
+	}, inferVariantRules(rs))
+
+	resolved, err := resolveExclusiveRules(rs, nil, nil, "")
+	require.NoError(t, err)
+	assert.Len(t, resolved, 3)
+}
+
+func TestInferVariantRules_RequiresDiscriminator(t *testing.T) {
+	rs := &schema.ResourceSchema{
+		Properties: map[string]*schema.Property{
+			"a": {Name: "a", Type: schema.TypeString, Variants: []string{"A"}},
+			"b": {Name: "b", Type: schema.TypeString, Variants: []string{"B"}},
+		},
+	}
+	assert.Empty(t, inferVariantRules(rs))
+}
+
+func TestResolveExclusiveRules_ConfiguredRuleMustExist(t *testing.T) {
+	_, err := resolveExclusiveRules(exclusiveSchema(), []ExclusiveRule{{Paths: []string{"properties.subnetId", "properties.missing"}}}, nil, "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "properties.missing")
+
+	_, err = resolveExclusiveRules(exclusiveSchema(), []ExclusiveRule{{Paths: []string{"properties.subnetId"}}}, nil, "")
+	assert.ErrorContains(t, err, "at least two properties")
+}
+
+func TestGenerate_ExclusiveRules(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+
+	require.NoError(t, os.Chdir(tmpDir))
+
+	err = Generate("Microsoft.Test/widgets",
+		WithResourceSchema(exclusiveSchema()),
+		WithAPIVersion("2025-01-01"),
+		WithExclusiveRules(ExclusiveRule{Paths: []string{"body.properties.subnetId", "properties.value", "properties.source.branch"}}),
+	)
+	require.NoError(t, err)
+
+	resource := requireBlock(t, parseHCLBody(t, "main.tf"), "resource", "azapi_resource", "this")
+	lifecycle := requireBlock(t, resource.Body, "lifecycle")
+
+	blocks := findAllBlocks(lifecycle.Body, "precondition")
+	assert.Len(t, blocks, 5)
+	messages := map[string]string{}
+	for _, block := range blocks {
+		messages[expressionString(t, block.Body.Attributes["condition"].Expr)] = expressionString(t, block.Body.Attributes["error_message"].Expr)
+	}
+	assert.Equal(t, map[string]string{
+		`length([for v in [var.subnet_id, var.value, try(var.source.branch, null)] : v if v != null]) <= 1`:           `"Only one of var.subnet_id, var.value and var.source.branch may be set."`,
+		`var.key_vault_uri == null || var.value == null`:                                                              `"Only one of var.key_vault_uri and var.value may be set."`,
+		`var.source == null || var.source.kind == "Git" || (var.source.branch == null && var.source.commit == null)`:  `"var.source.branch and var.source.commit may only be set when var.source.kind is \"Git\"."`,
+		`var.source == null || var.source.kind == "Registry" || (var.source.image == null && var.source.tag == null)`: `"var.source.image and var.source.tag may only be set when var.source.kind is \"Registry\"."`,
+		`var.subnet_id == null || var.vnet_id == null`:                                                                `"Only one of var.subnet_id and var.vnet_id may be set."`,
+	}, messages)
+}
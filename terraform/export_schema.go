@@ -0,0 +1,190 @@
+package terraform
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// jsonSchemaDialect is the JSON Schema version of exported variable schemas.
+const jsonSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// ExportVariableSchema describes the variables of the module in dir as one JSON
+// Schema object, for service catalogs and no-code frontends that render input
+// forms. Each variable becomes a property with the schema of its type, its
+// description and default; the enums, numeric bounds, lengths and patterns of its
+// validations, which the generator carries over from the spec, become the
+// matching keywords on the variable or the attribute they check. Sensitive and
+// ephemeral variables are marked writeOnly. When the module has a module-interface.json,
+// each property also records the resource body path it maps to as
+// x-source-path.
+func ExportVariableSchema(dir string) (map[string]any, error) {
+	variables, err := readModuleVariables(dir)
+	if err != nil {
+		return nil, err
+	}
+	sources, err := interfaceSourcePaths(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	properties := make(map[string]any, len(variables))
+	required := []string{}
+	for _, v := range variables {
+		iv, err := interfaceVariable(v.block, v.src)
+		if err != nil {
+			return nil, fmt.Errorf("describing variable %s: %w", v.name, err)
+		}
+		prop := iv.Schema
+		for path, c := range v.constraints {
+			if path != v.name && !strings.HasPrefix(path, v.name+".") {
+				continue
+			}
+			if target := schemaAtPath(prop, strings.TrimPrefix(path, v.name)); target != nil {
+				applySchemaConstraint(target, c)
+			}
+		}
+		if iv.Description != "" {
+			prop["description"] = iv.Description
+		}
+		if iv.Default != nil {
+			prop["default"] = iv.Default
+		}
+		if iv.Sensitive || iv.Ephemeral {
+			prop["writeOnly"] = true
+		}
+		if source := sources[v.name]; source != "" {
+			prop["x-source-path"] = source
+		}
+		properties[v.name] = prop
+		if iv.Required {
+			required = append(required, v.name)
+		}
+	}
+	sort.Strings(required)
+
+	doc := map[string]any{
+		"$schema":              jsonSchemaDialect,
+		"type":                 "object",
+		"properties":           properties,
+		"required":             required,
+		"additionalProperties": false,
+	}
+	if main, err := ParseHCLFile(filepath.Join(dir, "main.tf")); err == nil {
+		if resourceType, apiVersion, err := ExtractResourceTypeAndVersion(main); err == nil {
+			doc["title"] = resourceType
+			doc["x-api-version"] = apiVersion
+		}
+	}
+	return doc, nil
+}
+
+// WriteVariableSchema writes the schema from ExportVariableSchema as indented JSON.
+func WriteVariableSchema(w io.Writer, doc map[string]any) error {
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// schemaAtPath returns the schema of the attribute at path, given as ".a.b"
+// below the variable, following object properties and "*" for the elements
+// of collections. It returns nil when the path leaves the schema.
+func schemaAtPath(s map[string]any, path string) map[string]any {
+	for _, step := range strings.Split(strings.TrimPrefix(path, "."), ".") {
+		if step == "" {
+			continue
+		}
+		var next any
+		if step == "*" {
+			next = s["items"]
+			if next == nil {
+				next = s["additionalProperties"]
+			}
+		} else if properties, ok := s["properties"].(map[string]any); ok {
+			next = properties[step]
+		}
+		child, ok := next.(map[string]any)
+		if !ok {
+			return nil
+		}
+		s = child
+	}
+	return s
+}
+
+// applySchemaConstraint sets the JSON Schema keywords matching a constraint. The
+// length() bounds of validations apply to strings, collections or maps depending
+// on the type of the schema.
+func applySchemaConstraint(s map[string]any, c *exampleConstraint) {
+	if len(c.enum) > 0 {
+		var values []any
+		for _, v := range c.enum {
+			if raw, err := ctyjson.Marshal(v, v.Type()); err == nil {
+				values = append(values, json.RawMessage(raw))
+			}
+		}
+		if len(values) > 0 {
+			s["enum"] = values
+		}
+	}
+	if c.min != nil {
+		s["minimum"] = jsonNumber(c.min)
+	}
+	if c.max != nil {
+		s["maximum"] = jsonNumber(c.max)
+	}
+	if c.pattern != "" {
+		s["pattern"] = c.pattern
+	}
+
+	minKey, maxKey := "minLength", "maxLength"
+	switch s["type"] {
+	case "array":
+		minKey, maxKey = "minItems", "maxItems"
+	case "object":
+		minKey, maxKey = "minProperties", "maxProperties"
+	}
+	if c.minLength != nil {
+		s[minKey] = *c.minLength
+	}
+	if c.maxLength != nil {
+		s[maxKey] = *c.maxLength
+	}
+}
+
+// jsonNumber renders a bound without the exponent notation of big.Float.
+func jsonNumber(f *big.Float) json.Number {
+	return json.Number(f.Text('f', -1))
+}
+
+// interfaceSourcePaths reads the source path of each variable from the module
+// interface manifest in dir, if there is one.
+func interfaceSourcePaths(dir string) (map[string]string, error) {
+	data, err := os.ReadFile(filepath.Join(dir, ModuleInterfaceFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var mi ModuleInterface
+	if err := json.Unmarshal(data, &mi); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", ModuleInterfaceFileName, err)
+	}
+	sources := make(map[string]string, len(mi.Variables))
+	for _, v := range mi.Variables {
+		sources[v.Name] = v.SourcePath
+	}
+	return sources, nil
+}
@@ -0,0 +1,87 @@
+package terraform
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/matt-FFFFFF/tfmodmake/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportVariableSchema(t *testing.T) {
+	dir := t.TempDir()
+
+	rs := &schema.ResourceSchema{
+		Properties: map[string]*schema.Property{
+			"name": {Name: "name", Type: schema.TypeString, Required: true, Constraints: schema.Constraints{Pattern: "^[a-z0-9-]+$", MinLength: int64Ptr(3), MaxLength: int64Ptr(24)}},
+			"properties": {Name: "properties", Type: schema.TypeObject, Children: map[string]*schema.Property{
+				"replicaCount": {Name: "replicaCount", Type: schema.TypeInteger, Description: "Number of replicas.", Constraints: schema.Constraints{MinValue: int64Ptr(1), MaxValue: int64Ptr(10)}},
+				"tier":         {Name: "tier", Type: schema.TypeString, Enum: []string{"Basic", "Premium"}},
+				"network": {Name: "network", Type: schema.TypeObject, Children: map[string]*schema.Property{
+					"mode": {Name: "mode", Type: schema.TypeString, Enum: []string{"Private", "Public"}},
+				}},
+				"adminPassword": {Name: "adminPassword", Type: schema.TypeString, Sensitive: true},
+			}},
+		},
+	}
+	require.NoError(t, Generate("Microsoft.Test/widgets", WithResourceSchema(rs), WithAPIVersion("2024-01-01"), WithModuleInterface(true), WithOutputDir(dir)))
+
+	doc, err := ExportVariableSchema(dir)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteVariableSchema(&buf, doc))
+	var exported struct {
+		Schema     string                     `json:"$schema"`
+		Title      string                     `json:"title"`
+		Required   []string                   `json:"required"`
+		Properties map[string]json.RawMessage `json:"properties"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &exported))
+
+	assert.Equal(t, jsonSchemaDialect, exported.Schema)
+	assert.Equal(t, "Microsoft.Test/widgets", exported.Title)
+	assert.Equal(t, []string{"location", "name", "parent_id"}, exported.Required)
+
+	assert.JSONEq(t, `{"type": "string", "pattern": "^[a-z0-9-]+$", "minLength": 3, "maxLength": 24, "description": "The name of the resource. Must be between 3 and 24 characters.", "x-source-path": "name"}`, string(exported.Properties["name"]))
+
+	var replicas map[string]any
+	require.NoError(t, json.Unmarshal(exported.Properties["replica_count"], &replicas))
+	assert.Equal(t, map[string]any{
+		"type":          "number",
+		"minimum":       float64(1),
+		"maximum":       float64(10),
+		"description":   "Number of replicas.",
+		"default":       nil,
+		"x-source-path": "properties.replicaCount",
+	}, replicas)
+
+	var tier map[string]any
+	require.NoError(t, json.Unmarshal(exported.Properties["tier"], &tier))
+	assert.Equal(t, []any{"Basic", "Premium"}, tier["enum"])
+
+	var network struct {
+		Properties map[string]map[string]any `json:"properties"`
+	}
+	require.NoError(t, json.Unmarshal(exported.Properties["network"], &network))
+	assert.Equal(t, []any{"Private", "Public"}, network.Properties["mode"]["enum"])
+
+	var password map[string]any
+	require.NoError(t, json.Unmarshal(exported.Properties["admin_password"], &password))
+	assert.Equal(t, true, password["writeOnly"])
+}
+
+func TestApplySchemaConstraint_LengthKeywords(t *testing.T) {
+	minimum, maximum := 1, 5
+	c := &exampleConstraint{minLength: &minimum, maxLength: &maximum}
+
+	list := map[string]any{"type": "array"}
+	applySchemaConstraint(list, c)
+	assert.Equal(t, map[string]any{"type": "array", "minItems": 1, "maxItems": 5}, list)
+
+	object := map[string]any{"type": "object"}
+	applySchemaConstraint(object, c)
+	assert.Equal(t, map[string]any{"type": "object", "minProperties": 1, "maxProperties": 5}, object)
+}
@@ -0,0 +1,22 @@
+package terraform
+
+import (
+	"strings"
+
+	"github.com/matt-FFFFFF/tfmodmake/schema"
+)
+
+// extendedLocationProperty returns the writable top-level extendedLocation object of
+// the schema, or nil when the resource cannot be deployed into an edge zone.
+func extendedLocationProperty(rs *schema.ResourceSchema) *schema.Property {
+	return rootObjectProperty(rs, "extendedLocation")
+}
+
+// extendedLocationDescription documents the extended_location variable.
+func extendedLocationDescription(prop *schema.Property) string {
+	desc := strings.TrimSpace(prop.Description)
+	if desc == "" {
+		desc = "The extended location of the resource."
+	}
+	return desc + " Set to deploy into an edge zone; leave null to deploy into the region given by location.\n\n" + buildNestedDescription(prop, "")
+}
@@ -0,0 +1,51 @@
+package terraform
+
+import (
+	"os"
+	"testing"
+
+	"github.com/matt-FFFFFF/tfmodmake/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate_ExtendedLocationVariable(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+
+	require.NoError(t, os.Chdir(tmpDir))
+
+	rs := &schema.ResourceSchema{
+		Properties: map[string]*schema.Property{
+			"extendedLocation": {Name: "extendedLocation", Type: schema.TypeObject, Children: map[string]*schema.Property{
+				"name": {Name: "name", Type: schema.TypeString},
+				"type": {Name: "type", Type: schema.TypeString, Enum: []string{"EdgeZone"}},
+			}},
+			"properties": {Name: "properties", Type: schema.TypeObject, Children: map[string]*schema.Property{
+				"enabled": {Name: "enabled", Type: schema.TypeBoolean},
+			}},
+		},
+	}
+
+	require.NoError(t, Generate("Microsoft.Test/widgets", WithResourceSchema(rs), WithAPIVersion("2025-01-01")))
+
+	varsBody := parseHCLBody(t, "variables.tf")
+	el := requireBlock(t, varsBody, "variable", "extended_location")
+
+	var conditions []string
+	for _, block := range findAllBlocks(el.Body, "validation") {
+		conditions = append(conditions, expressionString(t, block.Body.Attributes["condition"].Expr))
+	}
+	assert.Equal(t, []string{
+		`var.extended_location == null || var.extended_location.type == null || contains(["EdgeZone"], var.extended_location.type)`,
+		`var.extended_location == null || var.extended_location.name != null`,
+		`var.extended_location == null || var.extended_location.type != null`,
+	}, conditions)
+
+	localsBody := parseHCLBody(t, "locals.tf")
+	locals := requireBlock(t, localsBody, "locals")
+	assert.Contains(t, expressionString(t, locals.Body.Attributes["resource_body"].Expr), "extendedLocation = var.extended_location == null ? null : {")
+}
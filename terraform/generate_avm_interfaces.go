@@ -1,10 +1,20 @@
 package terraform
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
 
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
 	"github.com/hashicorp/hcl/v2/hclwrite"
 	"github.com/matt-FFFFFF/tfmodmake/hclgen"
+	"github.com/matt-FFFFFF/tfmodmake/schema"
 	"github.com/zclconf/go-cty/cty"
 )
 
@@ -99,52 +109,770 @@ var privateEndpointSubresourcesByResourceType = map[string][]string{
 }
 
 func privateEndpointDefaultSubresource(resourceType string) (string, bool) {
-	subresources, ok := privateEndpointSubresourcesByResourceType[strings.ToLower(resourceType)]
-	if !ok || len(subresources) != 1 {
+	subresources := privateEndpointSubresources(resourceType)
+	if len(subresources) != 1 {
 		return "", false
 	}
 	return subresources[0], true
 }
 
-// generateInterfaces creates main.interfaces.tf with the AVM interfaces module wiring.
-// Only includes interface wiring for capabilities with swagger evidence.
-func generateInterfaces(caps InterfaceCapabilities, outputDir string) error {
-	file := hclwrite.NewEmptyFile()
-	body := file.Body()
+// The AVM interfaces GenerateInterfaces can scaffold.
+const (
+	InterfaceLock               = "lock"
+	InterfaceRoleAssignments    = "role_assignments"
+	InterfaceDiagnosticSettings = "diagnostic_settings"
+	InterfacePrivateEndpoints   = "private_endpoints"
+	InterfaceIdentity           = "identity"
+	InterfaceCustomerManagedKey = "customer_managed_key"
+)
+
+// interfaceTarget is the resource an AVM interface is scaffolded for.
+type interfaceTarget struct {
+	resourceType string
+	rs           *schema.ResourceSchema
+	// bodyLocal is the local the body of azapi_resource.this is built from, if any.
+	bodyLocal string
+}
+
+// avmInterface scaffolds one AVM interface into main.<name>.tf and variables.<name>.tf.
+type avmInterface struct {
+	name string
+	// variable is the variable declaring the interface, when it is not name.
+	variable string
+	// byDefault reports whether the interface is scaffolded when none are selected.
+	byDefault func(target interfaceTarget) bool
+	variables func(body *hclwrite.Body, target interfaceTarget)
+	main      func(body *hclwrite.Body, target interfaceTarget)
+	// wire, when set, connects the interface to the resource in main.tf and returns
+	// a note for the user.
+	wire func(outputDir string, target interfaceTarget, journal *hclgen.Journal) (string, error)
+	// existing, when set, returns a note on how the interface the module already has
+	// is connected to the resource.
+	existing func(outputDir string) (string, error)
+}
+
+var avmInterfaces = []avmInterface{
+	{
+		name:      InterfaceLock,
+		byDefault: always,
+		variables: func(body *hclwrite.Body, _ interfaceTarget) {
+			emitLockVar(body, interfaceVariableAppender(body))
+		},
+		main: appendLockResources,
+	},
+	{
+		name:      InterfaceRoleAssignments,
+		byDefault: always,
+		variables: func(body *hclwrite.Body, _ interfaceTarget) {
+			emitRoleAssignmentsVar(body, interfaceVariableAppender(body))
+		},
+		main: appendRoleAssignmentResources,
+	},
+	{
+		name:      InterfaceDiagnosticSettings,
+		byDefault: always,
+		variables: func(body *hclwrite.Body, target interfaceTarget) {
+			var categories *diagnosticCategories
+			if known, ok := diagnosticCategoriesFor(target.resourceType); ok {
+				categories = &known
+			}
+			emitDiagnosticSettingsVar(body, interfaceVariableAppender(body), categories)
+		},
+		main: appendDiagnosticSettingResources,
+	},
+	{
+		name:      InterfacePrivateEndpoints,
+		byDefault: supportsPrivateEndpoints,
+		variables: func(body *hclwrite.Body, target interfaceTarget) {
+			emitPrivateEndpointsVars(body, interfaceVariableAppender(body), privateEndpointSubresources(target.resourceType))
+		},
+		main: appendPrivateEndpointResources,
+	},
+	{
+		name:      InterfaceIdentity,
+		variable:  "managed_identities",
+		byDefault: supportsManagedIdentity,
+		variables: func(body *hclwrite.Body, _ interfaceTarget) {
+			emitManagedIdentitiesVar(body, interfaceVariableAppender(body))
+		},
+		main:     appendIdentityLocals,
+		wire:     wireIdentity,
+		existing: existingIdentity,
+	},
+	// Where the key goes in the body differs per resource type, so it is never assumed.
+	{
+		name:      InterfaceCustomerManagedKey,
+		byDefault: never,
+		variables: func(body *hclwrite.Body, _ interfaceTarget) {
+			emitCustomerManagedKeyVar(body, interfaceVariableAppender(body))
+		},
+		main: appendCustomerManagedKeyResources,
+		wire: wireCustomerManagedKey,
+	},
+}
+
+func always(interfaceTarget) bool { return true }
+
+func never(interfaceTarget) bool { return false }
+
+func supportsManagedIdentity(target interfaceTarget) bool { return SupportsIdentity(target.rs) }
+
+// supportsPrivateEndpoints reports whether the resource type has documented Private
+// Link subresources, or its schema returns private endpoint connections.
+func supportsPrivateEndpoints(target interfaceTarget) bool {
+	if len(privateEndpointSubresources(target.resourceType)) > 0 {
+		return true
+	}
+	if target.rs == nil {
+		return false
+	}
+	properties := target.rs.Properties["properties"]
+	return properties != nil && properties.Children["privateEndpointConnections"] != nil
+}
+
+// privateEndpointSubresources returns the Private Link subresource names (group
+// IDs) of the resource type. The specs do not enumerate group IDs, so they come
+// from privateEndpointSubresourcesByResourceType.
+func privateEndpointSubresources(resourceType string) []string {
+	return privateEndpointSubresourcesByResourceType[strings.ToLower(resourceType)]
+}
+
+// interfaceVariableAppender returns a function appending a described, typed variable to body.
+func interfaceVariableAppender(body *hclwrite.Body) func(string, string, hclwrite.Tokens) *hclwrite.Body {
+	return func(name, description string, typeTokens hclwrite.Tokens) *hclwrite.Body {
+		varBody := body.AppendNewBlock("variable", []string{name}).Body()
+		hclgen.SetDescriptionAttribute(varBody, description)
+		varBody.SetAttributeRaw("type", typeTokens)
+		return varBody
+	}
+}
+
+// InterfaceNames returns the names of the AVM interfaces GenerateInterfaces can scaffold.
+func InterfaceNames() []string {
+	names := make([]string, 0, len(avmInterfaces))
+	for _, iface := range avmInterfaces {
+		names = append(names, iface.name)
+	}
+	return names
+}
+
+// InterfacesResult reports which interfaces GenerateInterfaces added and which it
+// skipped because the module already has them. Notes describe how added interfaces
+// were wired into the resource, or what is left to do by hand.
+type InterfacesResult struct {
+	Added   []string
+	Skipped []string
+	Notes   []string
+}
+
+// GenerateInterfaces scaffolds the selected AVM interfaces for the azapi_resource.this
+// of the module in outputDir, each into its own main.<interface>.tf and
+// variables.<interface>.tf. With no selection, lock, role_assignments and
+// diagnostic_settings are scaffolded, plus private_endpoints for resource types
+// with Private Link support and identity for schemas with a managed identity.
+//
+// It is idempotent: an interface whose files exist, or whose variable the module
+// already declares, is skipped. The files written are recorded in journal, which
+// may be nil.
+func GenerateInterfaces(resourceType string, rs *schema.ResourceSchema, outputDir string, only []string, journal *hclgen.Journal) (*InterfacesResult, error) {
+	if rs != nil && rs.ResourceType != "" {
+		resourceType = rs.ResourceType
+	}
+	if idx := strings.LastIndex(resourceType, "@"); idx > 0 {
+		resourceType = resourceType[:idx]
+	}
+	resourceType = cleanTypeString(resourceType)
+
+	bodyLocal, err := resourceBodyLocal(outputDir)
+	if err != nil {
+		return nil, err
+	}
+	target := interfaceTarget{resourceType: resourceType, rs: rs, bodyLocal: bodyLocal}
+	selected, err := selectInterfaces(target, only)
+	if err != nil {
+		return nil, err
+	}
+
+	declared, err := declaredNames(outputDir, "variable")
+	if err != nil {
+		return nil, err
+	}
 
-	// Add module block for avm_interfaces
-	moduleBlock := body.AppendNewBlock("module", []string{"avm_interfaces"})
-	moduleBody := moduleBlock.Body()
+	result := &InterfacesResult{}
+	for _, iface := range selected {
+		variable := iface.variable
+		if variable == "" {
+			variable = iface.name
+		}
+		present, err := interfacePresent(outputDir, iface.name, variable, declared)
+		if err != nil {
+			return nil, err
+		}
+		if present {
+			result.Skipped = append(result.Skipped, iface.name)
+			if iface.existing != nil {
+				note, err := iface.existing(outputDir)
+				if err != nil {
+					return nil, fmt.Errorf("checking the %s interface: %w", iface.name, err)
+				}
+				if note != "" {
+					result.Notes = append(result.Notes, note)
+				}
+			}
+			continue
+		}
+		if err := writeInterface(outputDir, target, iface, journal); err != nil {
+			return nil, err
+		}
+		result.Added = append(result.Added, iface.name)
+		if iface.wire != nil {
+			note, err := iface.wire(outputDir, target, journal)
+			if err != nil {
+				return nil, fmt.Errorf("wiring the %s interface: %w", iface.name, err)
+			}
+			if note != "" {
+				result.Notes = append(result.Notes, note)
+			}
+		}
+	}
 
-	// Source the interfaces module from Git + branch
-	moduleBody.SetAttributeValue("source", cty.StringVal("git::https://github.com/Azure/terraform-azure-avm-utl-interfaces.git?ref=feat/prepv1"))
+	if len(result.Added) > 0 {
+		note, err := orderLock(outputDir, journal)
+		if err != nil {
+			return nil, fmt.Errorf("ordering the lock: %w", err)
+		}
+		if note != "" {
+			result.Notes = append(result.Notes, note)
+		}
+		if err := WriteTFVarsExample(outputDir, journal); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
 
-	// Wire mandatory IDs
-	moduleBody.SetAttributeRaw("parent_id", hclgen.TokensForTraversal("var", "parent_id"))
-	moduleBody.SetAttributeRaw("this_resource_id", hclgen.TokensForTraversal("azapi_resource", "this", "id"))
+// resourceBodyLocal returns the local the body of azapi_resource.this in main.tf
+// is built from, or "" when there is no main.tf or the body is not a local.
+func resourceBodyLocal(outputDir string) (string, error) {
+	file, err := ParseHCLFile(filepath.Join(outputDir, "main.tf"))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	attr := resourceBodyAttribute(file)
+	if attr == nil {
+		return "", nil
+	}
+	tokens := attr.Expr().BuildTokens(nil)
+	for i := 0; i+2 < len(tokens); i++ {
+		if tokens[i].Type == hclsyntax.TokenIdent && string(tokens[i].Bytes) == "local" &&
+			tokens[i+1].Type == hclsyntax.TokenDot && tokens[i+2].Type == hclsyntax.TokenIdent {
+			return string(tokens[i+2].Bytes), nil
+		}
+	}
+	return "", nil
+}
 
-	// Always include telemetry and location (basic AVM requirements)
-	moduleBody.SetAttributeRaw("enable_telemetry", hclgen.TokensForTraversal("var", "enable_telemetry"))
-	moduleBody.SetAttributeRaw("location", hclgen.TokensForTraversal("var", "location"))
+// mergeIntoResourceBody wraps the reference to local.<bodyLocal> in the body of
+// azapi_resource.this in main.tf with merge(local.<bodyLocal>, overlay).
+func mergeIntoResourceBody(outputDir, bodyLocal, overlay string, journal *hclgen.Journal) error {
+	path := filepath.Join(outputDir, "main.tf")
+	file, err := ParseHCLFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("no body in azapi_resource \"this\" in %s", path)
+	}
+	if err != nil {
+		return err
+	}
+	attr := resourceBodyAttribute(file)
+	if attr == nil {
+		return fmt.Errorf("no body in azapi_resource \"this\" in %s", path)
+	}
+
+	ref := "local." + bodyLocal
+	expr := string(attr.Expr().BuildTokens(nil).Bytes())
+	idx := -1
+	for i := strings.Index(expr, ref); i >= 0; {
+		end := i + len(ref)
+		if end == len(expr) || !isIdentifierOrDot(expr[end]) {
+			idx = i
+			break
+		}
+		next := strings.Index(expr[end:], ref)
+		if next < 0 {
+			break
+		}
+		i = end + next
+	}
+	if idx < 0 {
+		return fmt.Errorf("the body in %s does not reference %s", path, ref)
+	}
 
-	// Only wire private endpoints if swagger indicates support
-	if caps.SupportsPrivateEndpoints {
-		moduleBody.SetAttributeRaw("private_endpoints", hclgen.TokensForTraversal("local", "private_endpoints"))
-		moduleBody.SetAttributeRaw("private_endpoints_manage_dns_zone_group", hclgen.TokensForTraversal("var", "private_endpoints_manage_dns_zone_group"))
+	merged := expr[:idx] + "merge(" + ref + ", " + overlay + ")" + expr[idx+len(ref):]
+	tokens, diags := hclwrite.ParseConfig([]byte("body = "+merged+"\n"), path, hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		return fmt.Errorf("merging into the body in %s: %s", path, diags.Error())
 	}
+	resourceBodyBlock(file).Body().SetAttributeRaw("body", tokens.Body().GetAttribute("body").Expr().BuildTokens(nil))
+	return writeFormattedFile(journal, outputDir, "main.tf", file)
+}
+
+func isIdentifierOrDot(c byte) bool {
+	return c == '.' || c == '_' || c == '-' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
 
-	// Only wire diagnostic settings if swagger indicates support
-	if caps.SupportsDiagnostics {
-		moduleBody.SetAttributeRaw("diagnostic_settings", hclgen.TokensForTraversal("var", "diagnostic_settings"))
+func resourceBodyBlock(file *hclwrite.File) *hclwrite.Block {
+	for _, block := range file.Body().Blocks() {
+		labels := block.Labels()
+		if block.Type() == "resource" && len(labels) == 2 && labels[0] == "azapi_resource" && labels[1] == "this" {
+			return block
+		}
 	}
+	return nil
+}
+
+func resourceBodyAttribute(file *hclwrite.File) *hclwrite.Attribute {
+	block := resourceBodyBlock(file)
+	if block == nil {
+		return nil
+	}
+	return block.Body().GetAttribute("body")
+}
+
+// selectInterfaces resolves the requested interface names, in scaffolding order.
+func selectInterfaces(target interfaceTarget, only []string) ([]avmInterface, error) {
+	if len(only) == 0 {
+		var selected []avmInterface
+		for _, iface := range avmInterfaces {
+			if iface.byDefault(target) {
+				selected = append(selected, iface)
+			}
+		}
+		return selected, nil
+	}
+
+	requested := make(map[string]struct{}, len(only))
+	for _, name := range only {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		requested[name] = struct{}{}
+	}
+
+	var selected []avmInterface
+	for _, iface := range avmInterfaces {
+		if _, ok := requested[iface.name]; ok {
+			selected = append(selected, iface)
+			delete(requested, iface.name)
+		}
+	}
+	if len(requested) > 0 {
+		unknown := make([]string, 0, len(requested))
+		for name := range requested {
+			unknown = append(unknown, name)
+		}
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("unknown AVM interface(s) %s; valid interfaces are %s", strings.Join(unknown, ", "), strings.Join(InterfaceNames(), ", "))
+	}
+	return selected, nil
+}
+
+// interfacePresent reports whether the module already has the interface.
+func interfacePresent(outputDir, name, variable string, declared map[string]struct{}) (bool, error) {
+	if _, ok := declared[variable]; ok {
+		return true, nil
+	}
+	for _, file := range []string{"main." + name + ".tf", "variables." + name + ".tf"} {
+		_, err := os.Stat(filepath.Join(outputDir, file))
+		if err == nil {
+			return true, nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return false, err
+		}
+	}
+	return false, nil
+}
+
+// declaredNames returns the addresses of the blocks of blockType declared in the .tf
+// files of dir: the name of a variable, or the type and name of a resource.
+func declaredNames(dir, blockType string) (map[string]struct{}, error) {
+	declared := make(map[string]struct{})
+	err := parseTFFiles(dir, func(_ string, _ []byte, body *hclsyntax.Body) error {
+		for _, block := range body.Blocks {
+			if block.Type == blockType && len(block.Labels) > 0 {
+				declared[strings.Join(block.Labels, ".")] = struct{}{}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return declared, nil
+}
+
+func writeInterface(outputDir string, target interfaceTarget, iface avmInterface, journal *hclgen.Journal) error {
+	variables := hclwrite.NewEmptyFile()
+	iface.variables(variables.Body(), target)
+
+	main := hclwrite.NewEmptyFile()
+	iface.main(main.Body(), target)
+
+	if err := writeFormattedFile(journal, outputDir, "variables."+iface.name+".tf", variables); err != nil {
+		return err
+	}
+	return writeFormattedFile(journal, outputDir, "main."+iface.name+".tf", main)
+}
+
+func writeFormattedFile(journal *hclgen.Journal, outputDir, filename string, file *hclwrite.File) error {
+	src := bytes.TrimRight(hclwrite.Format(file.Bytes()), "\n")
+	return journal.WriteIfChanged(filepath.Join(outputDir, filename), append(src, '\n'))
+}
+
+// interfaceExpression parses one of the fixed expressions the interface resources
+// are built from.
+func interfaceExpression(src string) hclwrite.Tokens {
+	file, diags := hclwrite.ParseConfig([]byte("value = "+src+"\n"), "interface.tf", hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		panic(fmt.Sprintf("invalid interface expression %q: %s", src, diags.Error()))
+	}
+	return file.Body().GetAttribute("value").Expr().BuildTokens(nil)
+}
+
+// appendIdentityLocals renders var.managed_identities as the type and identity_ids
+// of the azapi identity block, or null when no identity is enabled.
+func appendIdentityLocals(body *hclwrite.Body, _ interfaceTarget) {
+	localsBody := body.AppendNewBlock("locals", nil).Body()
+	localsBody.SetAttributeRaw("identity", interfaceExpression(`var.managed_identities.system_assigned || length(var.managed_identities.user_assigned_resource_ids) > 0 ? {
+  type         = var.managed_identities.system_assigned && length(var.managed_identities.user_assigned_resource_ids) > 0 ? "SystemAssigned, UserAssigned" : length(var.managed_identities.user_assigned_resource_ids) > 0 ? "UserAssigned" : "SystemAssigned"
+  identity_ids = var.managed_identities.user_assigned_resource_ids
+} : null`))
+}
+
+// wireIdentity adds a dynamic identity block set from local.identity to
+// azapi_resource.this when the schema supports a managed identity. The identity
+// is never put in the body: azapi manages it through the identity block.
+func wireIdentity(outputDir string, target interfaceTarget, journal *hclgen.Journal) (string, error) {
+	if !SupportsIdentity(target.rs) {
+		return "identity: the resource schema has no managed identity; local.identity is not wired into the resource", nil
+	}
+	file, err := ParseHCLFile(filepath.Join(outputDir, "main.tf"))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+	var resource *hclwrite.Block
+	if file != nil {
+		resource = resourceBodyBlock(file)
+	}
+	if resource == nil {
+		return `identity: main.tf has no azapi_resource "this"; add an identity block set from local.identity by hand`, nil
+	}
+	if hasIdentityBlock(resource) {
+		return `identity: azapi_resource "this" already has an identity block; set it from local.identity by hand`, nil
+	}
+
+	dynBody := resource.Body().AppendNewBlock("dynamic", []string{"identity"}).Body()
+	dynBody.SetAttributeRaw("for_each", interfaceExpression(`local.identity == null ? [] : [local.identity]`))
+	contentBody := dynBody.AppendNewBlock("content", nil).Body()
+	contentBody.SetAttributeRaw("type", hclgen.TokensForTraversal("identity", "value", "type"))
+	contentBody.SetAttributeRaw("identity_ids", hclgen.TokensForTraversal("identity", "value", "identity_ids"))
+	if err := writeFormattedFile(journal, outputDir, "main.tf", file); err != nil {
+		return "", err
+	}
+	return "identity: wired into the identity block of the resource", nil
+}
+
+// existingIdentity reports whether the managed_identities the module already
+// declares reach the identity block of azapi_resource.this, as in generated modules.
+func existingIdentity(outputDir string) (string, error) {
+	file, err := ParseHCLFile(filepath.Join(outputDir, "main.tf"))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	resource := resourceBodyBlock(file)
+	if resource == nil || hasIdentityBlock(resource) {
+		return "", nil
+	}
+	return `identity: managed_identities is declared but azapi_resource "this" has no identity block; add one by hand`, nil
+}
+
+// hasIdentityBlock reports whether the resource has an identity block, static or dynamic.
+func hasIdentityBlock(resource *hclwrite.Block) bool {
+	for _, block := range resource.Body().Blocks() {
+		if block.Type() == "identity" || block.Type() == "dynamic" && slices.Equal(block.Labels(), []string{"identity"}) {
+			return true
+		}
+	}
+	return false
+}
+
+// appendLockResources appends the management lock on the resource.
+func appendLockResources(body *hclwrite.Body, _ interfaceTarget) {
+	lockBody := body.AppendNewBlock("resource", []string{"azapi_resource", "lock"}).Body()
+	lockBody.SetAttributeRaw("count", interfaceExpression(`var.lock != null ? 1 : 0`))
+	lockBody.AppendNewline()
+	lockBody.SetAttributeValue("type", cty.StringVal("Microsoft.Authorization/locks@2020-05-01"))
+	lockBody.SetAttributeRaw("name", interfaceExpression(`coalesce(var.lock.name, "lock-${var.lock.kind}")`))
+	lockBody.SetAttributeRaw("parent_id", hclgen.TokensForTraversal("azapi_resource", "this", "id"))
+	lockBody.SetAttributeRaw("body", interfaceExpression(`{
+  properties = {
+    level = var.lock.kind
+    notes = var.lock.kind == "CanNotDelete" ? "Cannot delete the resource or its child resources." : "Cannot delete or modify the resource or its child resources."
+  }
+}`))
+}
+
+// lockDependencies are the interface resources the lock is created after and
+// destroyed before, because a ReadOnly lock blocks writing them.
+var lockDependencies = []string{
+	"azapi_resource.role_assignment",
+	"azapi_resource.diagnostic_setting",
+	"azapi_resource.private_endpoint",
+	"azapi_resource.private_dns_zone_group",
+}
+
+// orderLock adds the lockDependencies the module declares to the depends_on of
+// azapi_resource.lock in main.lock.tf, keeping any other dependencies. It returns a
+// note naming the added dependencies.
+func orderLock(outputDir string, journal *hclgen.Journal) (string, error) {
+	path := filepath.Join(outputDir, "main."+InterfaceLock+".tf")
+	file, err := ParseHCLFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	var lock *hclwrite.Block
+	for _, block := range file.Body().Blocks() {
+		labels := block.Labels()
+		if block.Type() == "resource" && len(labels) == 2 && labels[0] == "azapi_resource" && labels[1] == "lock" {
+			lock = block
+		}
+	}
+	if lock == nil {
+		return "", nil
+	}
+
+	var dependsOn []string
+	if attr := lock.Body().GetAttribute("depends_on"); attr != nil {
+		src := attr.Expr().BuildTokens(nil).Bytes()
+		expr, diags := hclsyntax.ParseExpression(src, path, hcl.Pos{Line: 1, Column: 1})
+		tuple, ok := expr.(*hclsyntax.TupleConsExpr)
+		if diags.HasErrors() || !ok {
+			return "", fmt.Errorf("depends_on in %s is not a list of references", path)
+		}
+		for _, item := range tuple.Exprs {
+			dependsOn = append(dependsOn, strings.TrimSpace(string(item.Range().SliceBytes(src))))
+		}
+	}
+
+	resources, err := declaredNames(outputDir, "resource")
+	if err != nil {
+		return "", err
+	}
+	var added []string
+	for _, dep := range lockDependencies {
+		if _, ok := resources[dep]; ok && !slices.Contains(dependsOn, dep) {
+			added = append(added, dep)
+		}
+	}
+	if len(added) == 0 {
+		return "", nil
+	}
+
+	dependsOn = append(dependsOn, added...)
+	lock.Body().SetAttributeRaw("depends_on", interfaceExpression("[\n"+strings.Join(dependsOn, ",\n")+",\n]"))
+	if err := writeFormattedFile(journal, outputDir, "main."+InterfaceLock+".tf", file); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("lock: created after and destroyed before %s", strings.Join(added, ", ")), nil
+}
+
+// appendRoleAssignmentResources appends the role assignments on the resource. Role
+// names are resolved to role definition IDs by listing the role definitions
+// assignable at the resource.
+func appendRoleAssignmentResources(body *hclwrite.Body, _ interfaceTarget) {
+	listBody := body.AppendNewBlock("data", []string{"azapi_resource_list", "role_definitions"}).Body()
+	listBody.SetAttributeRaw("count", interfaceExpression(`length(var.role_assignments) > 0 ? 1 : 0`))
+	listBody.AppendNewline()
+	listBody.SetAttributeValue("type", cty.StringVal("Microsoft.Authorization/roleDefinitions@2022-04-01"))
+	listBody.SetAttributeRaw("parent_id", hclgen.TokensForTraversal("azapi_resource", "this", "id"))
+	listBody.SetAttributeRaw("response_export_values", interfaceExpression(`{
+  role_definitions = "value[].{id: id, role_name: properties.roleName}"
+}`))
+	body.AppendNewline()
+
+	localsBody := body.AppendNewBlock("locals", nil).Body()
+	localsBody.SetAttributeRaw("role_definition_ids", interfaceExpression(`length(var.role_assignments) > 0 ? {
+  for role in data.azapi_resource_list.role_definitions[0].output.role_definitions : role.role_name => role.id
+} : {}`))
+	body.AppendNewline()
+
+	raBody := body.AppendNewBlock("resource", []string{"azapi_resource", "role_assignment"}).Body()
+	raBody.SetAttributeRaw("for_each", hclgen.TokensForTraversal("var", "role_assignments"))
+	raBody.AppendNewline()
+	raBody.SetAttributeValue("type", cty.StringVal("Microsoft.Authorization/roleAssignments@2022-04-01"))
+	// The name is derived from the scope, principal and role rather than the map key:
+	// ARM cannot move an existing assignment to another principal or role, so either
+	// change replaces it, while renaming a key keeps the same assignment name.
+	raBody.SetAttributeRaw("name", interfaceExpression(`uuidv5("url", "${azapi_resource.this.id}/${each.value.principal_id}/${each.value.role_definition_id_or_name}")`))
+	raBody.SetAttributeRaw("parent_id", hclgen.TokensForTraversal("azapi_resource", "this", "id"))
+	raBody.SetAttributeRaw("body", interfaceExpression(`{
+  properties = {
+    principalId                        = each.value.principal_id
+    roleDefinitionId                   = strcontains(lower(each.value.role_definition_id_or_name), "/providers/microsoft.authorization/roledefinitions/") ? each.value.role_definition_id_or_name : local.role_definition_ids[each.value.role_definition_id_or_name]
+    principalType                      = each.value.skip_service_principal_aad_check ? "ServicePrincipal" : each.value.principal_type
+    description                        = each.value.description
+    condition                          = each.value.condition
+    conditionVersion                   = each.value.condition != null ? coalesce(each.value.condition_version, "2.0") : null
+    delegatedManagedIdentityResourceId = each.value.delegated_managed_identity_resource_id
+  }
+}`))
+}
+
+// appendDiagnosticSettingResources appends the diagnostic settings of the resource.
+func appendDiagnosticSettingResources(body *hclwrite.Body, _ interfaceTarget) {
+	diagBody := body.AppendNewBlock("resource", []string{"azapi_resource", "diagnostic_setting"}).Body()
+	diagBody.SetAttributeRaw("for_each", hclgen.TokensForTraversal("var", "diagnostic_settings"))
+	diagBody.AppendNewline()
+	diagBody.SetAttributeValue("type", cty.StringVal("Microsoft.Insights/diagnosticSettings@2021-05-01-preview"))
+	diagBody.SetAttributeRaw("name", interfaceExpression(`coalesce(each.value.name, "diag-${each.key}")`))
+	diagBody.SetAttributeRaw("parent_id", hclgen.TokensForTraversal("azapi_resource", "this", "id"))
+	diagBody.SetAttributeRaw("body", interfaceExpression(`{
+  properties = {
+    eventHubAuthorizationRuleId = each.value.event_hub_authorization_rule_resource_id
+    eventHubName                = each.value.event_hub_name
+    logAnalyticsDestinationType = each.value.log_analytics_destination_type
+    logs = concat(
+      [for category in each.value.log_categories : { category = category, enabled = true }],
+      [for group in each.value.log_groups : { categoryGroup = group, enabled = true }],
+    )
+    marketplacePartnerId = each.value.marketplace_partner_resource_id
+    metrics              = [for category in each.value.metric_categories : { category = category, enabled = true }]
+    storageAccountId     = each.value.storage_account_resource_id
+    workspaceId          = each.value.workspace_resource_id
+  }
+}`))
+}
+
+// appendPrivateEndpointResources appends the private endpoints of the resource and
+// their private DNS zone groups. Private endpoints are created in the resource
+// group of the resource unless another is given.
+func appendPrivateEndpointResources(body *hclwrite.Body, target interfaceTarget) {
+	localsBody := body.AppendNewBlock("locals", nil).Body()
+	localsBody.SetAttributeRaw("private_endpoints", tokensForPrivateEndpointsLocal(target.resourceType))
+	body.AppendNewline()
+
+	peBody := body.AppendNewBlock("resource", []string{"azapi_resource", "private_endpoint"}).Body()
+	peBody.SetAttributeRaw("for_each", hclgen.TokensForTraversal("local", "private_endpoints"))
+	peBody.AppendNewline()
+	peBody.SetAttributeValue("type", cty.StringVal("Microsoft.Network/privateEndpoints@2024-05-01"))
+	peBody.SetAttributeRaw("name", interfaceExpression(`coalesce(each.value.name, "pep-${azapi_resource.this.name}-${each.key}")`))
+	peBody.SetAttributeRaw("location", interfaceExpression(`coalesce(each.value.location, azapi_resource.this.location)`))
+	peBody.SetAttributeRaw("parent_id", interfaceExpression(`each.value.resource_group_name != null ? "${regex("^/subscriptions/[^/]+", azapi_resource.this.id)}/resourceGroups/${each.value.resource_group_name}" : regex("^/subscriptions/[^/]+/resourceGroups/[^/]+", azapi_resource.this.id)`))
+	peBody.SetAttributeRaw("tags", hclgen.TokensForTraversal("each", "value", "tags"))
+	peBody.SetAttributeRaw("body", interfaceExpression(`{
+  properties = {
+    applicationSecurityGroups  = [for id in values(each.value.application_security_group_associations) : { id = id }]
+    customNetworkInterfaceName = each.value.network_interface_name
+    ipConfigurations = [for ip in each.value.ip_configurations : {
+      name = ip.name
+      properties = {
+        groupId          = each.value.subresource_name
+        memberName       = each.value.subresource_name
+        privateIPAddress = ip.private_ip_address
+      }
+    }]
+    privateLinkServiceConnections = [{
+      name = coalesce(each.value.private_service_connection_name, "pse-${each.key}")
+      properties = {
+        groupIds             = [each.value.subresource_name]
+        privateLinkServiceId = azapi_resource.this.id
+      }
+    }]
+    subnet = {
+      id = each.value.subnet_resource_id
+    }
+  }
+}`))
+	body.AppendNewline()
+
+	zoneGroupBody := body.AppendNewBlock("resource", []string{"azapi_resource", "private_dns_zone_group"}).Body()
+	zoneGroupBody.SetAttributeRaw("for_each", interfaceExpression(`var.private_endpoints_manage_dns_zone_group ? {
+  for k, v in local.private_endpoints : k => v if length(v.private_dns_zone_resource_ids) > 0
+} : {}`))
+	zoneGroupBody.AppendNewline()
+	zoneGroupBody.SetAttributeValue("type", cty.StringVal("Microsoft.Network/privateEndpoints/privateDnsZoneGroups@2024-05-01"))
+	zoneGroupBody.SetAttributeRaw("name", hclgen.TokensForTraversal("each", "value", "private_dns_zone_group_name"))
+	zoneGroupBody.SetAttributeRaw("parent_id", interfaceExpression(`azapi_resource.private_endpoint[each.key].id`))
+	zoneGroupBody.SetAttributeRaw("body", interfaceExpression(`{
+  properties = {
+    privateDnsZoneConfigs = [for id in each.value.private_dns_zone_resource_ids : {
+      name = replace(basename(id), ".", "-")
+      properties = {
+        privateDnsZoneId = id
+      }
+    }]
+  }
+}`))
+}
+
+// tokensForPrivateEndpointsLocal generates the local.private_endpoints expression that provides
+// opinionated defaults for subresource_name based on the resource type.
+func tokensForPrivateEndpointsLocal(resourceType string) hclwrite.Tokens {
+	// Build the for expression: {
+	//   for k, v in var.private_endpoints : k => merge(
+	//     v,
+	//     {
+	//       subresource_name = coalesce(v.subresource_name, "<default>")
+	//     }
+	//   )
+	// }
+	//
+	// If there's no default mapping for this resource type, use:
+	// {
+	//   for k, v in var.private_endpoints : k => v
+	// }
+
+	// Look up a default subresource name for this resource type.
+	// Only default when the docs list exactly one possible subresource.
+	defaultSubresource, hasDefault := privateEndpointDefaultSubresource(resourceType)
+
+	varPE := hclgen.TokensForTraversal("var", "private_endpoints")
+
+	valueTokens := hclwrite.TokensForIdentifier("v")
+
+	if hasDefault {
+		vSubresource := hclgen.TokensForTraversal("v", "subresource_name")
+		coalesceCall := hclwrite.TokensForFunctionCall("coalesce", vSubresource, hclwrite.TokensForValue(cty.StringVal(defaultSubresource)))
 
-	// Only wire customer managed key if swagger indicates support
-	if caps.SupportsCustomerManagedKey {
-		moduleBody.SetAttributeRaw("customer_managed_key", hclgen.TokensForTraversal("var", "customer_managed_key"))
+		mergeArg := hclwrite.TokensForObject([]hclwrite.ObjectAttrTokens{
+			{Name: hclwrite.TokensForIdentifier("subresource_name"), Value: coalesceCall},
+		})
+		valueTokens = hclwrite.TokensForFunctionCall("merge", hclwrite.TokensForIdentifier("v"), mergeArg)
 	}
 
-	// Note: Lock and role_assignments are ARM-level capabilities not reliably detectable from specs.
-	// They are intentionally omitted. Use a separate helper command to add them if needed.
+	var tokens hclwrite.Tokens
+	tokens = append(tokens, &hclwrite.Token{Type: hclsyntax.TokenOBrace, Bytes: []byte("{")})
+	tokens = append(tokens, &hclwrite.Token{Type: hclsyntax.TokenIdent, Bytes: []byte("for")})
+	tokens = append(tokens, &hclwrite.Token{Type: hclsyntax.TokenIdent, Bytes: []byte("k")})
+	tokens = append(tokens, &hclwrite.Token{Type: hclsyntax.TokenComma, Bytes: []byte(",")})
+	tokens = append(tokens, &hclwrite.Token{Type: hclsyntax.TokenIdent, Bytes: []byte("v")})
+	tokens = append(tokens, &hclwrite.Token{Type: hclsyntax.TokenIdent, Bytes: []byte("in")})
+	tokens = append(tokens, varPE...)
+	tokens = append(tokens, &hclwrite.Token{Type: hclsyntax.TokenColon, Bytes: []byte(":")})
+	tokens = append(tokens, &hclwrite.Token{Type: hclsyntax.TokenIdent, Bytes: []byte("k")})
+	tokens = append(tokens, &hclwrite.Token{Type: hclsyntax.TokenFatArrow, Bytes: []byte("=>")})
+	tokens = append(tokens, valueTokens...)
+	tokens = append(tokens, &hclwrite.Token{Type: hclsyntax.TokenCBrace, Bytes: []byte("}")})
 
-	return hclgen.WriteFileToDir(outputDir, "main.interfaces.tf", file)
+	return tokens
 }
@@ -0,0 +1,382 @@
+package terraform
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/matt-FFFFFF/tfmodmake/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readInterfaceFile(t *testing.T, dir, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	require.NoError(t, err)
+	_, diags := hclsyntax.ParseConfig(data, name, hcl.Pos{Line: 1, Column: 1})
+	require.False(t, diags.HasErrors(), diags.Error())
+	return string(data)
+}
+
+func TestGenerateInterfaces_DefaultSelection(t *testing.T) {
+	dir := t.TempDir()
+
+	result, err := GenerateInterfaces("Microsoft.KeyVault/vaults@2023-07-01", nil, dir, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{InterfaceLock, InterfaceRoleAssignments, InterfaceDiagnosticSettings, InterfacePrivateEndpoints}, result.Added)
+	assert.Empty(t, result.Skipped)
+
+	pe := readInterfaceFile(t, dir, "main.private_endpoints.tf")
+	assert.Contains(t, pe, `subresource_name = coalesce(v.subresource_name, "vault")`)
+	assert.Contains(t, pe, `resource "azapi_resource" "private_dns_zone_group"`)
+	assert.Contains(t, readInterfaceFile(t, dir, "variables.private_endpoints.tf"), `variable "private_endpoints_manage_dns_zone_group"`)
+	assert.NoFileExists(t, filepath.Join(dir, "main.customer_managed_key.tf"))
+
+	result, err = GenerateInterfaces("Microsoft.Example/widgets", nil, t.TempDir(), nil, nil)
+	require.NoError(t, err)
+	assert.NotContains(t, result.Added, InterfacePrivateEndpoints)
+}
+
+func TestGenerateInterfaces_PrivateEndpointSubresources(t *testing.T) {
+	t.Run("single subresource defaults", func(t *testing.T) {
+		dir := t.TempDir()
+		_, err := GenerateInterfaces("Microsoft.KeyVault/vaults", nil, dir, []string{InterfacePrivateEndpoints}, nil)
+		require.NoError(t, err)
+		vars := readInterfaceFile(t, dir, "variables.private_endpoints.tf")
+		assert.Contains(t, vars, "Defaults to 'vault'")
+		assert.Contains(t, vars, `v.subresource_name == null ? true : contains(["vault"], v.subresource_name)`)
+	})
+
+	t.Run("several subresources must be chosen", func(t *testing.T) {
+		dir := t.TempDir()
+		_, err := GenerateInterfaces("Microsoft.DataFactory/factories", nil, dir, []string{InterfacePrivateEndpoints}, nil)
+		require.NoError(t, err)
+		vars := readInterfaceFile(t, dir, "variables.private_endpoints.tf")
+		assert.Contains(t, vars, "One of 'dataFactory', 'portal'.")
+		assert.Contains(t, vars, `v.subresource_name == null ? false : contains(["dataFactory", "portal"], v.subresource_name)`)
+		assert.Contains(t, vars, "subresource_name must be set to one of: 'dataFactory', 'portal'.")
+		assert.NotContains(t, readInterfaceFile(t, dir, "main.private_endpoints.tf"), "coalesce(v.subresource_name")
+	})
+
+	t.Run("spec-detected support without known subresources", func(t *testing.T) {
+		rs := &schema.ResourceSchema{
+			ResourceType: "Microsoft.Example/widgets",
+			Properties: map[string]*schema.Property{
+				"properties": {Name: "properties", Type: schema.TypeObject, Children: map[string]*schema.Property{
+					"privateEndpointConnections": {Name: "privateEndpointConnections", Type: schema.TypeArray, ReadOnly: true},
+				}},
+			},
+		}
+		dir := t.TempDir()
+		result, err := GenerateInterfaces("Microsoft.Example/widgets", rs, dir, nil, nil)
+		require.NoError(t, err)
+		assert.Contains(t, result.Added, InterfacePrivateEndpoints)
+		assert.NotContains(t, readInterfaceFile(t, dir, "variables.private_endpoints.tf"), "validation")
+	})
+}
+
+func TestGenerateInterfaces_DiagnosticCategories(t *testing.T) {
+	t.Run("known categories", func(t *testing.T) {
+		dir := t.TempDir()
+		_, err := GenerateInterfaces("Microsoft.Network/networkSecurityGroups", nil, dir, []string{InterfaceDiagnosticSettings}, nil)
+		require.NoError(t, err)
+		vars := readInterfaceFile(t, dir, "variables.diagnostic_settings.tf")
+		assert.Contains(t, vars, "One or more of 'NetworkSecurityGroupEvent', 'NetworkSecurityGroupRuleCounter'.")
+		assert.Contains(t, vars, `log_categories        = ["NetworkSecurityGroupEvent"]`)
+		assert.Contains(t, vars, `metric_categories                        = optional(set(string), [])`)
+		assert.Contains(t, vars, `alltrue([for c in v.log_categories : contains(["NetworkSecurityGroupEvent", "NetworkSecurityGroupRuleCounter"], c)])`)
+		assert.Contains(t, vars, "The resource type has no metric categories.")
+	})
+
+	t.Run("metrics only", func(t *testing.T) {
+		dir := t.TempDir()
+		_, err := GenerateInterfaces("Microsoft.Storage/storageAccounts", nil, dir, []string{InterfaceDiagnosticSettings}, nil)
+		require.NoError(t, err)
+		vars := readInterfaceFile(t, dir, "variables.diagnostic_settings.tf")
+		assert.Contains(t, vars, `log_groups                               = optional(set(string), [])`)
+		assert.Contains(t, vars, `metric_categories                        = optional(set(string), ["Transaction"])`)
+	})
+
+	t.Run("unknown resource type stays generic", func(t *testing.T) {
+		dir := t.TempDir()
+		_, err := GenerateInterfaces("Microsoft.Example/widgets", nil, dir, []string{InterfaceDiagnosticSettings}, nil)
+		require.NoError(t, err)
+		vars := readInterfaceFile(t, dir, "variables.diagnostic_settings.tf")
+		assert.Contains(t, vars, `log_groups                               = optional(set(string), ["allLogs"])`)
+		assert.NotContains(t, vars, "v.log_categories")
+	})
+}
+
+func TestGenerateInterfaces_Only(t *testing.T) {
+	dir := t.TempDir()
+
+	result, err := GenerateInterfaces("Microsoft.Example/widgets", nil, dir, []string{"role_assignments", " lock"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{InterfaceLock, InterfaceRoleAssignments}, result.Added)
+
+	lock := readInterfaceFile(t, dir, "main.lock.tf")
+	assert.Contains(t, lock, `type      = "Microsoft.Authorization/locks@2020-05-01"`)
+	assert.Contains(t, lock, "parent_id = azapi_resource.this.id")
+	assert.Contains(t, readInterfaceFile(t, dir, "variables.lock.tf"), `variable "lock"`)
+
+	roles := readInterfaceFile(t, dir, "main.role_assignments.tf")
+	assert.Contains(t, roles, `data "azapi_resource_list" "role_definitions"`)
+	assert.Contains(t, roles, "local.role_definition_ids[each.value.role_definition_id_or_name]")
+	assert.Contains(t, readInterfaceFile(t, dir, "variables.role_assignments.tf"), `variable "role_assignments"`)
+
+	assert.NoFileExists(t, filepath.Join(dir, "main.diagnostic_settings.tf"))
+}
+
+func TestGenerateInterfaces_LockOrdering(t *testing.T) {
+	dir := t.TempDir()
+
+	result, err := GenerateInterfaces("Microsoft.Example/widgets", nil, dir, []string{InterfaceLock, InterfaceRoleAssignments}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"lock: created after and destroyed before azapi_resource.role_assignment"}, result.Notes)
+
+	lock := readInterfaceFile(t, dir, "main.lock.tf")
+	assert.Contains(t, readInterfaceFile(t, dir, "variables.lock.tf"), `contains(["CanNotDelete", "ReadOnly"], var.lock.kind)`)
+	assert.Contains(t, lock, "depends_on = [\n    azapi_resource.role_assignment,\n  ]")
+
+	// Interfaces added later are appended, keeping hand-added dependencies.
+	lock = strings.Replace(lock, "azapi_resource.role_assignment,", "azapi_resource.role_assignment,\n    azapi_resource.custom,", 1)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.lock.tf"), []byte(lock), 0o644))
+
+	result, err = GenerateInterfaces("Microsoft.Example/widgets", nil, dir, []string{InterfaceDiagnosticSettings}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"lock: created after and destroyed before azapi_resource.diagnostic_setting"}, result.Notes)
+	assert.Contains(t, readInterfaceFile(t, dir, "main.lock.tf"), "depends_on = [\n    azapi_resource.role_assignment,\n    azapi_resource.custom,\n    azapi_resource.diagnostic_setting,\n  ]")
+}
+
+func TestGenerateInterfaces_RoleAssignments(t *testing.T) {
+	dir := t.TempDir()
+
+	_, err := GenerateInterfaces("Microsoft.Example/widgets", nil, dir, []string{InterfaceRoleAssignments}, nil)
+	require.NoError(t, err)
+
+	vars := readInterfaceFile(t, dir, "variables.role_assignments.tf")
+	assert.Contains(t, vars, "- principal_type: (Optional) The type of the principal, one of 'User', 'Group', 'ServicePrincipal', 'ForeignGroup', 'Device'.")
+	assert.Contains(t, vars, `v.principal_type == null ? true : contains(["User", "Group", "ServicePrincipal", "ForeignGroup", "Device"], v.principal_type)`)
+	assert.Contains(t, vars, "condition_version must be '2.0' and is only valid together with condition.")
+	assert.Contains(t, vars, "Each principal_id may only be assigned a role definition once.")
+
+	roles := readInterfaceFile(t, dir, "main.role_assignments.tf")
+	assert.Contains(t, roles, "for_each = var.role_assignments")
+	assert.Contains(t, roles, "parent_id = azapi_resource.this.id")
+	assert.Contains(t, roles, `conditionVersion                   = each.value.condition != null ? coalesce(each.value.condition_version, "2.0") : null`)
+	assert.Contains(t, roles, `principalType                      = each.value.skip_service_principal_aad_check ? "ServicePrincipal" : each.value.principal_type`)
+}
+
+func TestGenerateInterfaces_Identity(t *testing.T) {
+	identitySchema := func(supportsIdentity bool) *schema.ResourceSchema {
+		return &schema.ResourceSchema{
+			ResourceType:     "Microsoft.Example/widgets",
+			SupportsIdentity: supportsIdentity,
+			Properties: map[string]*schema.Property{
+				"name": {Name: "name", Type: schema.TypeString, Required: true},
+				"properties": {Name: "properties", Type: schema.TypeObject, Children: map[string]*schema.Property{
+					"color": {Name: "color", Type: schema.TypeString},
+				}},
+			},
+		}
+	}
+	// identityBlocks returns the identity blocks of azapi_resource.this, static or dynamic.
+	identityBlocks := func(t *testing.T, dir string) []*hclsyntax.Block {
+		t.Helper()
+		resource := requireBlock(t, parseHCLBody(t, filepath.Join(dir, "main.tf")), "resource", "azapi_resource", "this")
+		blocks := findAllBlocks(resource.Body, "identity")
+		for _, block := range findAllBlocks(resource.Body, "dynamic") {
+			if len(block.Labels) == 1 && block.Labels[0] == "identity" {
+				blocks = append(blocks, block)
+			}
+		}
+		return blocks
+	}
+
+	t.Run("skipped in a generated module", func(t *testing.T) {
+		dir := t.TempDir()
+		rs := identitySchema(true)
+		require.NoError(t, Generate("Microsoft.Example/widgets", WithResourceSchema(rs), WithAPIVersion("2024-01-01"), WithOutputDir(dir)))
+		mainTF := readInterfaceFile(t, dir, "main.tf")
+		require.Len(t, identityBlocks(t, dir), 1)
+
+		result, err := GenerateInterfaces("Microsoft.Example/widgets", rs, dir, nil, nil)
+		require.NoError(t, err)
+		assert.Contains(t, result.Skipped, InterfaceIdentity)
+		assert.NotContains(t, result.Added, InterfaceIdentity)
+		for _, note := range result.Notes {
+			assert.NotContains(t, note, "identity:")
+		}
+		assert.NoFileExists(t, filepath.Join(dir, "main.identity.tf"))
+		assert.Equal(t, mainTF, readInterfaceFile(t, dir, "main.tf"))
+		assert.Len(t, identityBlocks(t, dir), 1)
+	})
+
+	t.Run("wired into the identity block of a generated module", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, Generate("Microsoft.Example/widgets", WithResourceSchema(identitySchema(false)), WithAPIVersion("2024-01-01"), WithOutputDir(dir)))
+		require.Empty(t, identityBlocks(t, dir))
+
+		result, err := GenerateInterfaces("Microsoft.Example/widgets", identitySchema(true), dir, []string{InterfaceIdentity}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, []string{InterfaceIdentity}, result.Added)
+		assert.Equal(t, []string{"identity: wired into the identity block of the resource"}, result.Notes)
+
+		assert.Contains(t, readInterfaceFile(t, dir, "variables.identity.tf"), `variable "managed_identities"`)
+		locals := readInterfaceFile(t, dir, "main.identity.tf")
+		assert.Contains(t, locals, `"SystemAssigned, UserAssigned"`)
+		assert.Contains(t, locals, "identity_ids = var.managed_identities.user_assigned_resource_ids")
+
+		blocks := identityBlocks(t, dir)
+		require.Len(t, blocks, 1)
+		assert.Equal(t, "local.identity == null ? [] : [local.identity]", expressionString(t, blocks[0].Body.Attributes["for_each"].Expr))
+		content := requireBlock(t, blocks[0].Body, "content")
+		assert.Equal(t, "identity.value.type", expressionString(t, content.Body.Attributes["type"].Expr))
+		assert.Equal(t, "identity.value.identity_ids", expressionString(t, content.Body.Attributes["identity_ids"].Expr))
+
+		resource := requireBlock(t, parseHCLBody(t, filepath.Join(dir, "main.tf")), "resource", "azapi_resource", "this")
+		assert.Equal(t, "local.resource_body", expressionString(t, resource.Body.Attributes["body"].Expr))
+		assert.NotContains(t, readInterfaceFile(t, dir, "locals.tf"), "identity")
+	})
+
+	mainTF := "resource \"azapi_resource\" \"this\" {\n  type = \"Microsoft.Example/widgets@2024-01-01\"\n  body = local.resource_body\n}\n"
+
+	t.Run("noted when managed_identities is declared without an identity block", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "main.tf"), []byte(mainTF), 0o644))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "variables.tf"), []byte("variable \"managed_identities\" {\n  type = any\n}\n"), 0o644))
+
+		result, err := GenerateInterfaces("Microsoft.Example/widgets", identitySchema(true), dir, []string{InterfaceIdentity}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, []string{InterfaceIdentity}, result.Skipped)
+		require.Len(t, result.Notes, 1)
+		assert.Contains(t, result.Notes[0], "has no identity block")
+		assert.Equal(t, mainTF, readInterfaceFile(t, dir, "main.tf"))
+	})
+
+	t.Run("not wired without identity support", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "main.tf"), []byte(mainTF), 0o644))
+
+		result, err := GenerateInterfaces("Microsoft.Example/widgets", nil, dir, []string{InterfaceIdentity}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, []string{InterfaceIdentity}, result.Added)
+		require.Len(t, result.Notes, 1)
+		assert.Contains(t, result.Notes[0], "no managed identity")
+		assert.Equal(t, mainTF, readInterfaceFile(t, dir, "main.tf"))
+	})
+}
+
+func TestGenerateInterfaces_CustomerManagedKey(t *testing.T) {
+	dir := t.TempDir()
+
+	_, err := GenerateInterfaces("Microsoft.Example/widgets", nil, dir, []string{InterfaceCustomerManagedKey}, nil)
+	require.NoError(t, err)
+	assert.Contains(t, readInterfaceFile(t, dir, "main.customer_managed_key.tf"), "customer_managed_key_uri")
+	assert.Contains(t, readInterfaceFile(t, dir, "variables.customer_managed_key.tf"), `variable "customer_managed_key"`)
+}
+
+func TestGenerateInterfaces_CustomerManagedKeyWiring(t *testing.T) {
+	str := func(name string, enum ...string) *schema.Property {
+		return &schema.Property{Name: name, Type: schema.TypeString, Enum: enum}
+	}
+	obj := func(name string, children ...*schema.Property) *schema.Property {
+		p := &schema.Property{Name: name, Type: schema.TypeObject, Children: map[string]*schema.Property{}}
+		for _, child := range children {
+			p.Children[child.Name] = child
+		}
+		return p
+	}
+	mainTF := "resource \"azapi_resource\" \"this\" {\n  type = \"Microsoft.Example/widgets@2024-01-01\"\n  body = local.resource_body\n}\n"
+
+	t.Run("encryption object", func(t *testing.T) {
+		rs := &schema.ResourceSchema{
+			ResourceType: "Microsoft.Storage/storageAccounts",
+			Properties: map[string]*schema.Property{
+				"properties": obj("properties", obj("encryption",
+					str("keySource", "Microsoft.Storage", "Microsoft.Keyvault"),
+					obj("keyvaultproperties", str("keyname"), str("keyversion"), str("keyvaulturi")),
+					obj("identity", str("userAssignedIdentity")),
+				)),
+			},
+		}
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "main.tf"), []byte(mainTF), 0o644))
+
+		result, err := GenerateInterfaces("Microsoft.Storage/storageAccounts", rs, dir, []string{InterfaceCustomerManagedKey}, nil)
+		require.NoError(t, err)
+		require.Len(t, result.Notes, 1)
+		assert.Contains(t, result.Notes[0], "wired into properties.encryption")
+		assert.Contains(t, result.Notes[0], "user-assigned identity")
+
+		cmk := readInterfaceFile(t, dir, "main.customer_managed_key.tf")
+		assert.Contains(t, cmk, `keySource = "Microsoft.Keyvault"`)
+		assert.Contains(t, cmk, "keyvaulturi = local.customer_managed_key_vault_uri")
+		assert.Contains(t, cmk, "userAssignedIdentity = try(var.customer_managed_key.user_assigned_identity.resource_id, null)")
+		assert.Contains(t, cmk, "merge(try(local.resource_body.properties.encryption, null)")
+
+		main := readInterfaceFile(t, dir, "main.tf")
+		assert.Contains(t, main, "merge(local.resource_body, {")
+		assert.Contains(t, main, "properties = merge(local.resource_body.properties, local.customer_managed_key_properties)")
+	})
+
+	t.Run("key uri property", func(t *testing.T) {
+		rs := &schema.ResourceSchema{
+			ResourceType: "Microsoft.DocumentDB/databaseAccounts",
+			Properties: map[string]*schema.Property{
+				"properties": obj("properties", str("keyVaultKeyUri")),
+			},
+		}
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "main.tf"), []byte(mainTF), 0o644))
+
+		result, err := GenerateInterfaces("Microsoft.DocumentDB/databaseAccounts", rs, dir, []string{InterfaceCustomerManagedKey}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"customer_managed_key: wired into properties.keyVaultKeyUri"}, result.Notes)
+		assert.Contains(t, readInterfaceFile(t, dir, "main.customer_managed_key.tf"), "keyVaultKeyUri = local.customer_managed_key_uri")
+		assert.NotContains(t, readInterfaceFile(t, dir, "main.customer_managed_key.tf"), "customer_managed_key_identity")
+	})
+
+	t.Run("no key reference", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "main.tf"), []byte(mainTF), 0o644))
+
+		result, err := GenerateInterfaces("Microsoft.Example/widgets", nil, dir, []string{InterfaceCustomerManagedKey}, nil)
+		require.NoError(t, err)
+		require.Len(t, result.Notes, 1)
+		assert.Contains(t, result.Notes[0], "no encryption key reference")
+		assert.Equal(t, mainTF, readInterfaceFile(t, dir, "main.tf"))
+	})
+}
+
+func TestGenerateInterfaces_Idempotent(t *testing.T) {
+	dir := t.TempDir()
+	// A module that already declares its own lock variable keeps it.
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "variables.tf"), []byte("variable \"lock\" {\n  type = any\n}\n"), 0o644))
+
+	result, err := GenerateInterfaces("Microsoft.Example/widgets", nil, dir, []string{InterfaceLock, InterfaceRoleAssignments}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{InterfaceRoleAssignments}, result.Added)
+	assert.Equal(t, []string{InterfaceLock}, result.Skipped)
+	assert.NoFileExists(t, filepath.Join(dir, "main.lock.tf"))
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.role_assignments.tf"), []byte("# customised\n"), 0o644))
+	result, err = GenerateInterfaces("Microsoft.Example/widgets", nil, dir, []string{InterfaceRoleAssignments, InterfaceDiagnosticSettings}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{InterfaceDiagnosticSettings}, result.Added)
+	assert.Equal(t, []string{InterfaceRoleAssignments}, result.Skipped)
+
+	data, err := os.ReadFile(filepath.Join(dir, "main.role_assignments.tf"))
+	require.NoError(t, err)
+	assert.Equal(t, "# customised\n", string(data))
+}
+
+func TestGenerateInterfaces_UnknownInterface(t *testing.T) {
+	_, err := GenerateInterfaces("Microsoft.Example/widgets", nil, t.TempDir(), []string{"lock", "locks"}, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown AVM interface(s) locks`)
+}
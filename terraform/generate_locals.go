@@ -11,7 +11,33 @@ import (
 	"github.com/zclconf/go-cty/cty"
 )
 
-func buildLocals(rs *schema.ResourceSchema, localName string, supportsIdentity bool, secrets []secretField, resourceType string, caps InterfaceCapabilities, moduleNamePrefix string) (*hclwrite.File, error) {
+// bodyValueOverrides returns the body paths whose values are not read straight from
+// their variable.
+func bodyValueOverrides(rs *schema.ResourceSchema, resourceType string, features optionalFeatures) map[string]hclwrite.Tokens {
+	overrides := make(map[string]hclwrite.Tokens)
+	// The zones variable is a set; sort it into a stable list for the body.
+	if zonesProperty(rs) != nil {
+		overrides["zones"] = tokensForZonesValue(hclgen.TokensForTraversal("var", "zones"))
+	}
+	// Association references are passed as plain resource IDs.
+	for _, ref := range associationReferences(rs, resourceType) {
+		if ref.subResource {
+			overrides["properties."+ref.property] = tokensForSubResourceValue(ref.variable)
+		}
+	}
+	// An assembled name is only known to local.name; var.name is null then.
+	if features.namingVariable && rs.Properties["name"] != nil {
+		overrides["name"] = hclgen.TokensForTraversal("local", "name")
+	}
+	if features.inheritedTagsVariable {
+		if path := tagsBodyPath(rs); path != "" {
+			overrides[path] = tokensForMergedTags()
+		}
+	}
+	return overrides
+}
+
+func buildLocals(rs *schema.ResourceSchema, localName string, supportsIdentity bool, features optionalFeatures, secrets []secretField, postCreate []string, resourceType string, caps InterfaceCapabilities, moduleNamePrefix string) (*hclwrite.File, error) {
 	if rs == nil {
 		return nil, nil
 	}
@@ -24,43 +50,49 @@ func buildLocals(rs *schema.ResourceSchema, localName string, supportsIdentity b
 
 	secretPaths := newSecretPathSet(secrets)
 
+	// Post-create properties are applied by azapi_update_resource, so they are
+	// skipped in the creation body just like secrets.
+	skipPaths := make(map[string]struct{}, len(secretPaths)+len(postCreate))
+	for p := range secretPaths {
+		skipPaths[p] = struct{}{}
+	}
+	for _, p := range postCreate {
+		skipPaths[p] = struct{}{}
+	}
+
 	// Build a synthetic root property from the ResourceSchema
 	rootProp := &schema.Property{
 		Type:     schema.TypeObject,
 		Children: rs.Properties,
 	}
-	valueExpression, err := constructValue(rootProp, hclwrite.TokensForIdentifier("var"), true, secretPaths, "", supportsIdentity, moduleNamePrefix)
+	overrides := bodyValueOverrides(rs, resourceType, features)
+	valueExpression, err := constructValue(rootProp, hclwrite.TokensForIdentifier("var"), true, skipPaths, overrides, "", supportsIdentity, moduleNamePrefix)
 	if err != nil {
 		return nil, err
 	}
 	localBody.SetAttributeRaw(localName, valueExpression)
 
+	if features.namingVariable {
+		localBody.SetAttributeRaw("name", tokensForNameLocal(rs.Properties["name"]))
+	}
+
+	if len(postCreate) > 0 {
+		postCreateExpression, err := constructValue(postCreateRootProperty(rs, postCreate), hclwrite.TokensForIdentifier("var"), true, secretPaths, overrides, "", supportsIdentity, moduleNamePrefix)
+		if err != nil {
+			return nil, err
+		}
+		localBody.SetAttributeRaw(localName+postCreateLocalSuffix, postCreateExpression)
+	}
+
 	// Managed identity scaffolding (only when the resource schema supports configuring identity).
 	if supportsIdentity {
 		localBody.SetAttributeRaw("managed_identities", tokensForManagedIdentitiesLocal())
 	}
 
-	// Private endpoints local with opinionated defaults for subresource_name
-	// Only generate when schema indicates private endpoint support
-	if caps.SupportsPrivateEndpoints {
-		localBody.SetAttributeRaw("private_endpoints", tokensForPrivateEndpointsLocal(resourceType))
-	}
-
 	return file, nil
 }
 
-func generateLocals(rs *schema.ResourceSchema, localName string, supportsIdentity bool, secrets []secretField, resourceType string, caps InterfaceCapabilities, moduleNamePrefix string, outputDir string) error {
-	file, err := buildLocals(rs, localName, supportsIdentity, secrets, resourceType, caps, moduleNamePrefix)
-	if err != nil {
-		return err
-	}
-	if file == nil {
-		return nil
-	}
-	return hclgen.WriteFileToDir(outputDir, "locals.tf", file)
-}
-
-func constructFlattenedRootPropertiesValue(prop *schema.Property, accessPath hclwrite.Tokens, secretPaths map[string]struct{}, moduleNamePrefix string) (hclwrite.Tokens, error) {
+func constructFlattenedRootPropertiesValue(prop *schema.Property, accessPath hclwrite.Tokens, secretPaths map[string]struct{}, overrides map[string]hclwrite.Tokens, moduleNamePrefix string) (hclwrite.Tokens, error) {
 	// prop represents the schema property at root.properties.
 	// The Terraform variables are flattened to var.<child> rather than var.properties.<child>.
 
@@ -102,7 +134,15 @@ func constructFlattenedRootPropertiesValue(prop *schema.Property, accessPath hcl
 		childAccess = append(childAccess, &hclwrite.Token{Type: hclsyntax.TokenDot, Bytes: []byte(".")})
 		childAccess = append(childAccess, hclwrite.TokensForIdentifier(snakeName)...)
 
-		childValue, err := constructValue(child, childAccess, false, secretPaths, "properties."+k, false, moduleNamePrefix)
+		if value, ok := overrides["properties."+k]; ok {
+			attrs = append(attrs, hclwrite.ObjectAttrTokens{
+				Name:  tokensForObjectKey(k),
+				Value: value,
+			})
+			continue
+		}
+
+		childValue, err := constructValue(child, childAccess, false, secretPaths, nil, "properties."+k, false, moduleNamePrefix)
 		if err != nil {
 			return nil, err
 		}
@@ -115,11 +155,14 @@ func constructFlattenedRootPropertiesValue(prop *schema.Property, accessPath hcl
 	return hclwrite.TokensForObject(attrs), nil
 }
 
-func constructValue(prop *schema.Property, accessPath hclwrite.Tokens, isRoot bool, secretPaths map[string]struct{}, pathPrefix string, omitRootIdentity bool, moduleNamePrefix string) (hclwrite.Tokens, error) {
+// constructValue builds the body expression for prop read from accessPath. At the
+// root and within the flattened properties bag, overrides replace the value of the
+// given body paths (e.g. to sort zones or merge inherited tags).
+func constructValue(prop *schema.Property, accessPath hclwrite.Tokens, isRoot bool, secretPaths map[string]struct{}, overrides map[string]hclwrite.Tokens, pathPrefix string, omitRootIdentity bool, moduleNamePrefix string) (hclwrite.Tokens, error) {
 	if prop.Type == schema.TypeObject {
 		if len(prop.Children) == 0 {
 			if prop.AdditionalProperties != nil {
-				mappedValue, err := constructValue(prop.AdditionalProperties, hclwrite.TokensForIdentifier("value"), false, secretPaths, pathPrefix, false, moduleNamePrefix)
+				mappedValue, err := constructValue(prop.AdditionalProperties, hclwrite.TokensForIdentifier("value"), false, secretPaths, nil, pathPrefix, false, moduleNamePrefix)
 				if err != nil {
 					return nil, err
 				}
@@ -187,7 +230,7 @@ func constructValue(prop *schema.Property, accessPath hclwrite.Tokens, isRoot bo
 
 			// Flatten the top-level "properties" bag into separate variables.
 			if isRoot && k == "properties" && child.Type == schema.TypeObject && len(child.Children) > 0 {
-				childValue, err := constructFlattenedRootPropertiesValue(child, accessPath, secretPaths, moduleNamePrefix)
+				childValue, err := constructFlattenedRootPropertiesValue(child, accessPath, secretPaths, overrides, moduleNamePrefix)
 				if err != nil {
 					return nil, err
 				}
@@ -204,7 +247,15 @@ func constructValue(prop *schema.Property, accessPath hclwrite.Tokens, isRoot bo
 			childAccess = append(childAccess, &hclwrite.Token{Type: hclsyntax.TokenDot, Bytes: []byte(".")})
 			childAccess = append(childAccess, hclwrite.TokensForIdentifier(snakeName)...)
 
-			childValue, err := constructValue(child, childAccess, false, secretPaths, childPath, false, moduleNamePrefix)
+			if value, ok := overrides[childPath]; ok && isRoot {
+				attrs = append(attrs, hclwrite.ObjectAttrTokens{
+					Name:  tokensForObjectKey(k),
+					Value: value,
+				})
+				continue
+			}
+
+			childValue, err := constructValue(child, childAccess, false, secretPaths, nil, childPath, false, moduleNamePrefix)
 			if err != nil {
 				return nil, err
 			}
@@ -223,7 +274,7 @@ func constructValue(prop *schema.Property, accessPath hclwrite.Tokens, isRoot bo
 
 	if prop.Type == schema.TypeArray {
 		if prop.ItemType != nil {
-			childValue, err := constructValue(prop.ItemType, hclwrite.TokensForIdentifier("item"), false, secretPaths, pathPrefix+"[]", false, moduleNamePrefix)
+			childValue, err := constructValue(prop.ItemType, hclwrite.TokensForIdentifier("item"), false, secretPaths, nil, pathPrefix+"[]", false, moduleNamePrefix)
 			if err != nil {
 				return nil, err
 			}
@@ -326,55 +377,3 @@ func tokensForManagedIdentitiesLocal() hclwrite.Tokens {
 		{Name: hclwrite.TokensForIdentifier("user_assigned"), Value: userAssignedOnly},
 	})
 }
-
-// tokensForPrivateEndpointsLocal generates the local.private_endpoints expression that provides
-// opinionated defaults for subresource_name based on the resource type.
-func tokensForPrivateEndpointsLocal(resourceType string) hclwrite.Tokens {
-	// Build the for expression: {
-	//   for k, v in var.private_endpoints : k => merge(
-	//     v,
-	//     {
-	//       subresource_name = coalesce(v.subresource_name, "<default>")
-	//     }
-	//   )
-	// }
-	//
-	// If there's no default mapping for this resource type, use:
-	// {
-	//   for k, v in var.private_endpoints : k => v
-	// }
-
-	// Look up a default subresource name for this resource type.
-	// Only default when the docs list exactly one possible subresource.
-	defaultSubresource, hasDefault := privateEndpointDefaultSubresource(resourceType)
-
-	varPE := hclgen.TokensForTraversal("var", "private_endpoints")
-
-	valueTokens := hclwrite.TokensForIdentifier("v")
-
-	if hasDefault {
-		vSubresource := hclgen.TokensForTraversal("v", "subresource_name")
-		coalesceCall := hclwrite.TokensForFunctionCall("coalesce", vSubresource, hclwrite.TokensForValue(cty.StringVal(defaultSubresource)))
-
-		mergeArg := hclwrite.TokensForObject([]hclwrite.ObjectAttrTokens{
-			{Name: hclwrite.TokensForIdentifier("subresource_name"), Value: coalesceCall},
-		})
-		valueTokens = hclwrite.TokensForFunctionCall("merge", hclwrite.TokensForIdentifier("v"), mergeArg)
-	}
-
-	var tokens hclwrite.Tokens
-	tokens = append(tokens, &hclwrite.Token{Type: hclsyntax.TokenOBrace, Bytes: []byte("{")})
-	tokens = append(tokens, &hclwrite.Token{Type: hclsyntax.TokenIdent, Bytes: []byte("for")})
-	tokens = append(tokens, &hclwrite.Token{Type: hclsyntax.TokenIdent, Bytes: []byte("k")})
-	tokens = append(tokens, &hclwrite.Token{Type: hclsyntax.TokenComma, Bytes: []byte(",")})
-	tokens = append(tokens, &hclwrite.Token{Type: hclsyntax.TokenIdent, Bytes: []byte("v")})
-	tokens = append(tokens, &hclwrite.Token{Type: hclsyntax.TokenIdent, Bytes: []byte("in")})
-	tokens = append(tokens, varPE...)
-	tokens = append(tokens, &hclwrite.Token{Type: hclsyntax.TokenColon, Bytes: []byte(":")})
-	tokens = append(tokens, &hclwrite.Token{Type: hclsyntax.TokenIdent, Bytes: []byte("k")})
-	tokens = append(tokens, &hclwrite.Token{Type: hclsyntax.TokenFatArrow, Bytes: []byte("=>")})
-	tokens = append(tokens, valueTokens...)
-	tokens = append(tokens, &hclwrite.Token{Type: hclsyntax.TokenCBrace, Bytes: []byte("}")})
-
-	return tokens
-}
@@ -1,30 +1,34 @@
 package terraform
 
 import (
-	"fmt"
 	"strings"
 
 	"github.com/hashicorp/hcl/v2/hclsyntax"
 	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/matt-FFFFFF/tfmodmake/bicepdata"
 	"github.com/matt-FFFFFF/tfmodmake/hclgen"
 	"github.com/matt-FFFFFF/tfmodmake/naming"
 	"github.com/matt-FFFFFF/tfmodmake/schema"
 	"github.com/zclconf/go-cty/cty"
 )
 
+// cleanTypeString returns the resource type of typeStr, which may be an
+// instance path with parameters and a singleton name.
 func cleanTypeString(typeStr string) string {
-	segments := strings.Split(typeStr, "/")
-	cleaned := make([]string, 0, len(segments))
-	for _, segment := range segments {
-		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
-			continue
-		}
-		cleaned = append(cleaned, segment)
+	resourceType, _ := bicepdata.ParseResourcePath(typeStr)
+	return resourceType
+}
+
+// resourceBlockType returns the azapi resource type managing the module's resource.
+// Resources without a PUT operation are modified in place with azapi_update_resource.
+func resourceBlockType(features optionalFeatures) string {
+	if features.updateResource {
+		return "azapi_update_resource"
 	}
-	return strings.Join(cleaned, "/")
+	return "azapi_resource"
 }
 
-func buildMain(rs *schema.ResourceSchema, resourceType, apiVersion, localName string, supportsTags, supportsLocation, supportsIdentity, hasSchema, hasDiscriminator bool, secrets []secretField) *hclwrite.File {
+func buildMain(rs *schema.ResourceSchema, resourceType, apiVersion, localName string, supportsTags, supportsLocation, supportsIdentity, hasSchema, hasDiscriminator bool, features optionalFeatures, parent parentScope, secrets []secretField, exportPaths []string, ignoreChanges []string, preconditions []resolvedPrecondition, postCreateVars []string) *hclwrite.File {
 	file := hclwrite.NewEmptyFile()
 	body := file.Body()
 
@@ -32,22 +36,39 @@ func buildMain(rs *schema.ResourceSchema, resourceType, apiVersion, localName st
 	if apiVersion == "" {
 		apiVersion = "apiVersion"
 	}
-	resourceTypeWithAPIVersion := fmt.Sprintf("%s@%s", cleanTypeString(resourceType), apiVersion)
 
-	resourceBlock := body.AppendNewBlock("resource", []string{"azapi_resource", "this"})
+	if parent.needsClientConfig() {
+		body.AppendNewBlock("data", []string{"azapi_client_config", "current"})
+		body.AppendNewline()
+	}
+
+	nameRef := hclgen.TokensForTraversal("var", "name")
+	if features.namingVariable {
+		appendRandomNameResource(body)
+		nameRef = hclgen.TokensForTraversal("local", "name")
+	}
+	if singleton, ok := rs.SingletonName(); ok {
+		nameRef = hclwrite.TokensForValue(cty.StringVal(singleton))
+	}
+
+	if refs := associationReferences(rs, resourceType); len(refs) > 0 {
+		appendAssociationComment(body, refs)
+	}
+	resourceBlock := body.AppendNewBlock("resource", []string{resourceBlockType(features), "this"})
 	resourceBody := resourceBlock.Body()
-	resourceBody.SetAttributeValue("type", cty.StringVal(resourceTypeWithAPIVersion))
-	resourceBody.SetAttributeRaw("name", hclgen.TokensForTraversal("var", "name"))
-	resourceBody.SetAttributeRaw("parent_id", hclgen.TokensForTraversal("var", "parent_id"))
+	resourceBody.SetAttributeRaw("type", tokensForResourceType(resourceType, apiVersion, features))
+	resourceBody.SetAttributeRaw("name", nameRef)
+	resourceBody.SetAttributeRaw("parent_id", parent.tokensForParentID())
 
 	if supportsLocation {
 		resourceBody.SetAttributeRaw("location", hclgen.TokensForTraversal("var", "location"))
 	}
 
-	resourceBody.SetAttributeValue("body", cty.EmptyObjectVal)
+	bodyTokens := hclwrite.TokensForValue(cty.EmptyObjectVal)
 	if hasSchema {
-		resourceBody.SetAttributeRaw("body", hclgen.TokensForTraversal("local", localName))
+		bodyTokens = hclgen.TokensForTraversal("local", localName)
 	}
+	resourceBody.SetAttributeRaw("body", tokensForBody(features.bodyFormat, bodyTokens))
 
 	// Disable embedded schema validation for resources whose body contains a
 	// discriminated object type (e.g. javaComponents with componentType).
@@ -55,7 +76,13 @@ func buildMain(rs *schema.ResourceSchema, resourceType, apiVersion, localName st
 	// plan/validate time, but Terraform passes "unknown" for unset variables
 	// which the provider rejects as an invalid discriminator value.
 	// TODO: re-enable once the azapi provider handles unknown discriminator values gracefully.
-	if hasDiscriminator {
+	// When the toggle variable is generated, its default carries this decision instead.
+	// azapi_update_resource has no schema_validation_enabled argument.
+	switch {
+	case features.updateResource:
+	case features.schemaValidationVariable:
+		resourceBody.SetAttributeRaw("schema_validation_enabled", hclgen.TokensForTraversal("var", "schema_validation_enabled"))
+	case hasDiscriminator:
 		resourceBody.AppendUnstructuredTokens(hclwrite.Tokens{
 			&hclwrite.Token{Type: hclsyntax.TokenComment, Bytes: []byte("# Disabled because the body contains a discriminated object type whose")},
 			&hclwrite.Token{Type: hclsyntax.TokenNewline, Bytes: []byte("\n")},
@@ -82,7 +109,7 @@ func buildMain(rs *schema.ResourceSchema, resourceType, apiVersion, localName st
 		sensitiveBodyTokens := tokensForSensitiveBody(secrets, func(secret secretField) hclwrite.Tokens {
 			return hclgen.TokensForTraversal("var", secret.varName)
 		}, sensitiveNullCheck)
-		resourceBody.SetAttributeRaw("sensitive_body", sensitiveBodyTokens)
+		resourceBody.SetAttributeRaw("sensitive_body", tokensForBody(features.bodyFormat, sensitiveBodyTokens))
 
 		// Add sensitive_body_version map
 		var versionAttrs []hclwrite.ObjectAttrTokens
@@ -98,7 +125,15 @@ func buildMain(rs *schema.ResourceSchema, resourceType, apiVersion, localName st
 	}
 
 	if supportsTags {
-		resourceBody.SetAttributeRaw("tags", hclgen.TokensForTraversal("var", "tags"))
+		if features.inheritedTagsVariable {
+			resourceBody.SetAttributeRaw("tags", tokensForMergedTags())
+		} else {
+			resourceBody.SetAttributeRaw("tags", hclgen.TokensForTraversal("var", "tags"))
+		}
+	}
+
+	if features.lockResourceIDsVariable {
+		resourceBody.SetAttributeRaw("locks", hclgen.TokensForTraversal("var", "lock_resource_ids"))
 	}
 
 	if supportsIdentity {
@@ -112,13 +147,21 @@ func buildMain(rs *schema.ResourceSchema, resourceType, apiVersion, localName st
 		contentBody.SetAttributeRaw("identity_ids", hclgen.TokensForTraversal("identity", "value", "user_assigned_resource_ids"))
 	}
 
-	// Generate response_export_values from computed (non-writable) fields in the schema
-	exportPaths := extractComputedPaths(rs)
+	// Export the computed (non-writable) fields chosen for the module's outputs.
 	resourceBody.SetAttributeRaw("response_export_values", hclgen.TokensForMultilineStringList(exportPaths))
 
-	return file
-}
+	if features.namingVariable {
+		preconditions = append(append([]resolvedPrecondition(nil), preconditions...), assembledNamePreconditions(rs.Properties["name"])...)
+	}
+	appendLifecycleBlock(resourceBody, ignoreChanges, preconditions)
 
-func generateMain(rs *schema.ResourceSchema, resourceType, apiVersion, localName string, supportsTags, supportsLocation, supportsIdentity, hasSchema, hasDiscriminator bool, secrets []secretField, outputDir string) error {
-	return hclgen.WriteFileToDir(outputDir, "main.tf", buildMain(rs, resourceType, apiVersion, localName, supportsTags, supportsLocation, supportsIdentity, hasSchema, hasDiscriminator, secrets))
+	if len(postCreateVars) > 0 {
+		appendPostCreateUpdateResource(body, tokensForResourceType(resourceType, apiVersion, features), localName, features.bodyFormat, postCreateVars)
+	}
+
+	if features.keysOutput {
+		appendKeysActionDataSources(body, resourceBlockType(features), resourceType, keysActions(rs))
+	}
+
+	return file
 }
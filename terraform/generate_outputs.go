@@ -11,10 +11,14 @@ import (
 	"github.com/zclconf/go-cty/cty"
 )
 
-// generateOutputs creates the outputs.tf file with AVM-compliant outputs.
+// buildOutputs creates the outputs.tf file with AVM-compliant outputs.
 // Always includes the mandatory AVM outputs: resource_id and name.
-// Also includes outputs for computed/readOnly exported attributes when schema is available.
-func buildOutputs(rs *schema.ResourceSchema) *hclwrite.File {
+// Also includes an output per path in exportPaths (the response_export_values of
+// the resource) when the schema is available. Identity paths are replaced by the
+// AVM managed identity outputs when the resource supports identity.
+func buildOutputs(rs *schema.ResourceSchema, supportsIdentity bool, features optionalFeatures, exportPaths []string, outputNaming OutputNaming) *hclwrite.File {
+	blockType := resourceBlockType(features)
+
 	file := hclwrite.NewEmptyFile()
 	body := file.Body()
 
@@ -22,58 +26,123 @@ func buildOutputs(rs *schema.ResourceSchema) *hclwrite.File {
 	resourceID := body.AppendNewBlock("output", []string{"resource_id"})
 	resourceIDBody := resourceID.Body()
 	resourceIDBody.SetAttributeValue("description", cty.StringVal("The ID of the created resource."))
-	resourceIDBody.SetAttributeRaw("value", hclgen.TokensForTraversal("azapi_resource", "this", "id"))
+	resourceIDBody.SetAttributeRaw("value", hclgen.TokensForTraversal(blockType, "this", "id"))
 	body.AppendNewline()
 
 	// AVM mandatory output: name
 	name := body.AppendNewBlock("output", []string{"name"})
 	nameBody := name.Body()
 	nameBody.SetAttributeValue("description", cty.StringVal("The name of the created resource."))
-	nameBody.SetAttributeRaw("value", hclgen.TokensForTraversal("azapi_resource", "this", "name"))
+	nameBody.SetAttributeRaw("value", hclgen.TokensForTraversal(blockType, "this", "name"))
 	body.AppendNewline()
 
-	if rs != nil {
-		exportPaths := extractComputedPaths(rs)
-		usedNames := make(map[string]int)
-		for _, exportPath := range exportPaths {
-			outputName := outputNameForExportPath(exportPath)
-			if outputName == "" {
-				continue
-			}
-			if count, ok := usedNames[outputName]; ok {
-				count++
-				usedNames[outputName] = count
-				outputName = fmt.Sprintf("%s_%d", outputName, count)
-			} else {
-				usedNames[outputName] = 1
-			}
-
-			out := body.AppendNewBlock("output", []string{outputName})
-			outBody := out.Body()
-			desc := "Computed value exported from the Azure API response."
-			propForPath := propertyForExportPath(rs, exportPath)
-			if propForPath != nil {
-				if strings.TrimSpace(propForPath.Description) != "" {
-					desc = strings.TrimSpace(propForPath.Description)
-				}
-			}
-			outBody.SetAttributeValue("description", cty.StringVal(desc))
+	exports := exportedOutputs(rs, supportsIdentity, exportPaths, outputNaming)
+
+	if features.resourceOutput {
+		appendResourceOutput(body, blockType, SupportsLocation(rs) && !features.updateResource, features.bodyFormat, exports)
+	}
+
+	if supportsIdentity {
+		appendIdentityOutputs(body, blockType, features.bodyFormat)
+	}
 
-			segments := strings.Split(exportPath, ".")
-			valueParts := make([]string, 0, 3+len(segments))
-			valueParts = append(valueParts, "azapi_resource", "this", "output")
-			valueParts = append(valueParts, segments...)
-			expr := hclgen.TokensForTraversalOrIndex(valueParts...)
-			outBody.SetAttributeRaw("value", hclwrite.TokensForFunctionCall("try", expr, defaultTokensForProperty(propForPath)))
-			body.AppendNewline()
+	usedNames := map[string]struct{}{"resource_id": {}, "name": {}, "resource": {}}
+	for _, export := range exports {
+		usedNames[export.name] = struct{}{}
+		outBody := body.AppendNewBlock("output", []string{export.name}).Body()
+		desc := "Computed value exported from the Azure API response."
+		if export.prop != nil && strings.TrimSpace(export.prop.Description) != "" {
+			desc = strings.TrimSpace(export.prop.Description)
+		}
+		if export.prop != nil && export.prop.Type == schema.TypeObject && len(export.prop.Children) > 0 {
+			hclgen.SetDescriptionAttribute(outBody, objectOutputDescription(export.prop, desc))
+		} else {
+			outBody.SetAttributeValue("description", cty.StringVal(desc))
 		}
+		outBody.SetAttributeRaw("value", export.tokens(blockType, features.bodyFormat))
+		if export.sensitive {
+			outBody.SetAttributeValue("sensitive", cty.True)
+		}
+		body.AppendNewline()
+	}
+
+	if features.keysOutput {
+		appendKeysOutputs(body, features.bodyFormat, keysActions(rs), outputNaming, usedNames)
 	}
 
 	return file
 }
 
-func generateOutputs(rs *schema.ResourceSchema, outputDir string) error {
-	return hclgen.WriteFileToDir(outputDir, "outputs.tf", buildOutputs(rs))
+// exportedOutput is an output generated for a response_export_values path.
+type exportedOutput struct {
+	name      string
+	path      string
+	prop      *schema.Property
+	sensitive bool
+}
+
+// tokens returns the expression reading the exported path, falling back to an
+// empty value of the property's type.
+func (e exportedOutput) tokens(blockType string, format BodyFormat) hclwrite.Tokens {
+	expr := tokensForOutputPath(blockType, format, strings.Split(e.path, "."))
+	return hclwrite.TokensForFunctionCall("try", expr, defaultTokensForProperty(e.prop))
+}
+
+// exportedOutputs names an output for each export path, de-duplicating clashing
+// names. Identity paths are skipped when the identity outputs cover them.
+func exportedOutputs(rs *schema.ResourceSchema, supportsIdentity bool, exportPaths []string, outputNaming OutputNaming) []exportedOutput {
+	if rs == nil {
+		return nil
+	}
+	var exports []exportedOutput
+	usedNames := make(map[string]int)
+	for _, exportPath := range exportPaths {
+		if supportsIdentity && isIdentityExportPath(exportPath) {
+			continue
+		}
+		outputName := outputNameForExportPath(exportPath, outputNaming)
+		if outputName == "" {
+			continue
+		}
+		if count, ok := usedNames[outputName]; ok {
+			count++
+			usedNames[outputName] = count
+			outputName = fmt.Sprintf("%s_%d", outputName, count)
+		} else {
+			usedNames[outputName] = 1
+		}
+		exports = append(exports, exportedOutput{
+			name:      outputName,
+			path:      exportPath,
+			prop:      propertyForExportPath(rs, exportPath),
+			sensitive: isSensitiveExportPath(rs, exportPath),
+		})
+	}
+	return exports
+}
+
+// appendResourceOutput appends the AVM "resource" output: a curated object with the
+// ID, name, location and the non-sensitive exported scalars, instead of the whole
+// resource whose output may carry secrets.
+func appendResourceOutput(body *hclwrite.Body, blockType string, supportsLocation bool, format BodyFormat, exports []exportedOutput) {
+	attrs := []hclwrite.ObjectAttrTokens{
+		{Name: hclwrite.TokensForIdentifier("id"), Value: hclgen.TokensForTraversal(blockType, "this", "id")},
+		{Name: hclwrite.TokensForIdentifier("name"), Value: hclgen.TokensForTraversal(blockType, "this", "name")},
+	}
+	if supportsLocation {
+		attrs = append(attrs, hclwrite.ObjectAttrTokens{Name: hclwrite.TokensForIdentifier("location"), Value: hclgen.TokensForTraversal(blockType, "this", "location")})
+	}
+	for _, export := range exports {
+		if export.sensitive || export.prop == nil || !export.prop.IsScalar() {
+			continue
+		}
+		attrs = append(attrs, hclwrite.ObjectAttrTokens{Name: hclwrite.TokensForIdentifier(export.name), Value: export.tokens(blockType, format)})
+	}
+
+	outBody := body.AppendNewBlock("output", []string{"resource"}).Body()
+	outBody.SetAttributeValue("description", cty.StringVal("A curated view of the created resource: its ID, name, location and non-sensitive computed properties."))
+	outBody.SetAttributeRaw("value", hclwrite.TokensForObject(attrs))
+	body.AppendNewline()
 }
 
 // propertyForExportPath navigates the resource schema's property tree
@@ -132,7 +201,31 @@ func defaultTokensForProperty(prop *schema.Property) hclwrite.Tokens {
 	}
 }
 
-func outputNameForExportPath(path string) string {
+// OutputNaming customizes the names of the outputs generated for response paths,
+// so they can follow an existing module convention. The zero value drops the
+// leading "properties" segment and snake-cases and joins the remaining segments.
+type OutputNaming struct {
+	// Prefix is prepended to every generated name, e.g. "resource_".
+	Prefix string
+	// IncludeProperties keeps the leading "properties" segment, e.g.
+	// properties_default_domain instead of default_domain.
+	IncludeProperties bool
+	// SegmentNames maps API path segments (e.g. "defaultHostName") to the name used
+	// in their place instead of the snake_cased segment (e.g. "hostname").
+	SegmentNames map[string]string
+}
+
+// segmentName returns the output name fragment for one API path segment.
+func (n OutputNaming) segmentName(segment string) string {
+	if name, ok := n.SegmentNames[segment]; ok {
+		return name
+	}
+	return naming.ToSnakeCase(segment)
+}
+
+// outputNameForExportPath names the output of a response path, or returns "" when
+// the path would duplicate the AVM mandatory outputs.
+func outputNameForExportPath(path string, n OutputNaming) string {
 	path = strings.TrimSpace(path)
 	if path == "" {
 		return ""
@@ -141,7 +234,7 @@ func outputNameForExportPath(path string) string {
 	if len(segments) == 0 {
 		return ""
 	}
-	if segments[0] == "properties" {
+	if segments[0] == "properties" && !n.IncludeProperties {
 		segments = segments[1:]
 	}
 	nameSegments := make([]string, 0, len(segments))
@@ -150,7 +243,9 @@ func outputNameForExportPath(path string) string {
 		if seg == "" {
 			continue
 		}
-		nameSegments = append(nameSegments, naming.ToSnakeCase(seg))
+		if name := n.segmentName(seg); name != "" {
+			nameSegments = append(nameSegments, name)
+		}
 	}
 	if len(nameSegments) == 0 {
 		return ""
@@ -161,5 +256,5 @@ func outputNameForExportPath(path string) string {
 	if outName == "name" || outName == "resource_id" || outName == "id" {
 		return ""
 	}
-	return outName
+	return n.Prefix + outName
 }
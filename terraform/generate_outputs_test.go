@@ -1,10 +1,13 @@
 package terraform
 
 import (
+	"os"
+	"strings"
 	"testing"
 
 	"github.com/matt-FFFFFF/tfmodmake/schema"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestOutputNameForExportPath(t *testing.T) {
@@ -25,11 +28,24 @@ func TestOutputNameForExportPath(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			assert.Equal(t, tt.expected, outputNameForExportPath(tt.path))
+			assert.Equal(t, tt.expected, outputNameForExportPath(tt.path, OutputNaming{}))
 		})
 	}
 }
 
+func TestOutputNameForExportPath_Naming(t *testing.T) {
+	n := OutputNaming{
+		Prefix:            "res_",
+		IncludeProperties: true,
+		SegmentNames:      map[string]string{"defaultHostName": "hostname", "properties": "props"},
+	}
+	assert.Equal(t, "res_props_hostname", outputNameForExportPath("properties.defaultHostName", n))
+	assert.Equal(t, "res_props_static_ip", outputNameForExportPath("properties.staticIp", n))
+	assert.Equal(t, "", outputNameForExportPath("name", n))
+
+	assert.Equal(t, "properties_static_ip", outputNameForExportPath("properties.staticIp", OutputNaming{IncludeProperties: true}))
+}
+
 func TestDefaultTokensForProperty(t *testing.T) {
 	assert.Equal(t, "null", string(defaultTokensForProperty(nil).Bytes()))
 
@@ -70,3 +86,142 @@ func TestPropertyForExportPath_NestedProperties(t *testing.T) {
 		assert.Equal(t, "Bar description", got.Description)
 	}
 }
+
+func TestGenerate_OutputsFollowResponseExportValues(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+
+	require.NoError(t, os.Chdir(tmpDir))
+
+	rs := &schema.ResourceSchema{
+		Properties: map[string]*schema.Property{
+			"properties": {Name: "properties", Type: schema.TypeObject, Children: map[string]*schema.Property{
+				"defaultDomain": {Name: "defaultDomain", Type: schema.TypeString, ReadOnly: true, Description: "Default domain"},
+				"staticIp":      {Name: "staticIp", Type: schema.TypeString, ReadOnly: true, Description: "Static IP"},
+			}},
+		},
+	}
+
+	require.NoError(t, Generate("Microsoft.App/managedEnvironments", WithResourceSchema(rs), WithAPIVersion("2024-01-01"),
+		WithResponseExportValues("properties.staticIp")))
+
+	mainBody := parseHCLBody(t, "main.tf")
+	resource := requireBlock(t, mainBody, "resource", "azapi_resource", "this")
+	assert.Equal(t, `["properties.staticIp"]`, strings.Join(strings.Fields(expressionString(t, resource.Body.Attributes["response_export_values"].Expr)), ""))
+
+	outputsBody := parseHCLBody(t, "outputs.tf")
+	staticIP := requireBlock(t, outputsBody, "output", "static_ip")
+	assert.Equal(t, "Static IP", attributeStringValue(t, staticIP.Body.Attributes["description"]))
+	assert.Equal(t, "try(azapi_resource.this.output.properties.staticIp, null)", expressionString(t, staticIP.Body.Attributes["value"].Expr))
+	assert.Nil(t, findBlock(outputsBody, "output", "default_domain"))
+}
+
+func TestIsSensitiveExportPath(t *testing.T) {
+	rs := &schema.ResourceSchema{
+		Properties: map[string]*schema.Property{
+			"properties": {Name: "properties", Type: schema.TypeObject, Children: map[string]*schema.Property{
+				"defaultDomain":    {Name: "defaultDomain", Type: schema.TypeString, ReadOnly: true},
+				"primaryKey":       {Name: "primaryKey", Type: schema.TypeString, ReadOnly: true},
+				"connectionString": {Name: "connectionString", Type: schema.TypeString, ReadOnly: true},
+				"sshPublicKey":     {Name: "sshPublicKey", Type: schema.TypeString, ReadOnly: true},
+				"flaggedValue":     {Name: "flaggedValue", Type: schema.TypeString, ReadOnly: true, Sensitive: true},
+				"credentials":      {Name: "credentials", Type: schema.TypeObject, ReadOnly: true, Children: map[string]*schema.Property{"user": {Name: "user", Type: schema.TypeString}, "password": {Name: "password", Type: schema.TypeString}}},
+			}},
+		},
+	}
+
+	tests := map[string]bool{
+		"properties.defaultDomain":        false,
+		"properties.primaryKey":           true,
+		"properties.connectionString":     true,
+		"properties.sshPublicKey":         false,
+		"properties.flaggedValue":         true,
+		"properties.credentials":          true,
+		"properties.credentials.user":     false,
+		"properties.credentials.password": true,
+	}
+	for path, want := range tests {
+		assert.Equal(t, want, isSensitiveExportPath(rs, path), path)
+	}
+}
+
+func TestGenerate_IdentityOutputs(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+
+	require.NoError(t, os.Chdir(tmpDir))
+
+	rs := &schema.ResourceSchema{
+		SupportsIdentity: true,
+		Properties: map[string]*schema.Property{
+			"identity": {Name: "identity", Type: schema.TypeObject, Children: map[string]*schema.Property{
+				"type":        {Name: "type", Type: schema.TypeString},
+				"principalId": {Name: "principalId", Type: schema.TypeString, ReadOnly: true},
+				"tenantId":    {Name: "tenantId", Type: schema.TypeString, ReadOnly: true},
+			}},
+			"properties": {Name: "properties", Type: schema.TypeObject, Children: map[string]*schema.Property{
+				"enabled": {Name: "enabled", Type: schema.TypeBoolean},
+			}},
+		},
+	}
+
+	require.NoError(t, Generate("Microsoft.Test/widgets", WithResourceSchema(rs), WithAPIVersion("2024-01-01")))
+
+	mainBody := parseHCLBody(t, "main.tf")
+	resource := requireBlock(t, mainBody, "resource", "azapi_resource", "this")
+	exports := expressionString(t, resource.Body.Attributes["response_export_values"].Expr)
+	for _, path := range []string{"identity.principalId", "identity.tenantId", "identity.userAssignedIdentities"} {
+		assert.Contains(t, exports, path)
+	}
+
+	outputsBody := parseHCLBody(t, "outputs.tf")
+	principal := requireBlock(t, outputsBody, "output", "system_assigned_mi_principal_id")
+	assert.Equal(t, "try(azapi_resource.this.output.identity.principalId, null)", expressionString(t, principal.Body.Attributes["value"].Expr))
+	requireBlock(t, outputsBody, "output", "system_assigned_mi_tenant_id")
+	userAssigned := requireBlock(t, outputsBody, "output", "user_assigned_identities")
+	assert.Equal(t, "try(azapi_resource.this.output.identity.userAssignedIdentities, {})", expressionString(t, userAssigned.Body.Attributes["value"].Expr))
+	assert.Nil(t, findBlock(outputsBody, "output", "identity_principal_id"))
+}
+
+func TestGenerate_ResourceOutput(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+
+	require.NoError(t, os.Chdir(tmpDir))
+
+	rs := &schema.ResourceSchema{
+		SupportsLocation: true,
+		Properties: map[string]*schema.Property{
+			"location": {Name: "location", Type: schema.TypeString},
+			"properties": {Name: "properties", Type: schema.TypeObject, Children: map[string]*schema.Property{
+				"defaultDomain": {Name: "defaultDomain", Type: schema.TypeString, ReadOnly: true},
+				"primaryKey":    {Name: "primaryKey", Type: schema.TypeString, ReadOnly: true},
+				"endpoints":     {Name: "endpoints", Type: schema.TypeObject, ReadOnly: true, Children: map[string]*schema.Property{"web": {Name: "web", Type: schema.TypeString, ReadOnly: true}}},
+			}},
+		},
+	}
+
+	require.NoError(t, Generate("Microsoft.Test/widgets", WithResourceSchema(rs), WithAPIVersion("2024-01-01"), WithResourceOutput(true)))
+
+	outputsBody := parseHCLBody(t, "outputs.tf")
+	resource := requireBlock(t, outputsBody, "output", "resource")
+	value := strings.Join(strings.Fields(expressionString(t, resource.Body.Attributes["value"].Expr)), " ")
+	assert.Contains(t, value, "id = azapi_resource.this.id")
+	assert.Contains(t, value, "location = azapi_resource.this.location")
+	assert.Contains(t, value, "default_domain = try(azapi_resource.this.output.properties.defaultDomain, null)")
+	assert.Contains(t, value, "endpoints_web = try(azapi_resource.this.output.properties.endpoints.web, null)")
+	assert.NotContains(t, value, "primary_key")
+	assert.NotContains(t, value, "endpoints =")
+
+	primaryKey := requireBlock(t, outputsBody, "output", "primary_key")
+	assert.Contains(t, primaryKey.Body.Attributes, "sensitive")
+}
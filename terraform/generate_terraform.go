@@ -1,28 +1,95 @@
 package terraform
 
 import (
+	"sort"
+
 	"github.com/hashicorp/hcl/v2/hclwrite"
-	"github.com/matt-FFFFFF/tfmodmake/hclgen"
 	"github.com/zclconf/go-cty/cty"
 )
 
-func buildTerraform() *hclwrite.File {
-	file := hclwrite.NewEmptyFile()
-	body := file.Body()
+// DefaultTerraformVersion is the required_version of generated modules.
+const DefaultTerraformVersion = "~> 1.12"
 
-	tfBlock := body.AppendNewBlock("terraform", nil)
-	tfBody := tfBlock.Body()
-	tfBody.SetAttributeValue("required_version", cty.StringVal("~> 1.12"))
+// VersionConstraints replaces the default version constraints of terraform.tf.
+type VersionConstraints struct {
+	// Terraform replaces DefaultTerraformVersion as the required_version.
+	Terraform string
+	// Providers replaces the source or version of the providers the module
+	// requires, keyed by local name, and adds the providers it does not require.
+	Providers map[string]ProviderRequirement
+}
 
-	providers := tfBody.AppendNewBlock("required_providers", nil)
-	providers.Body().SetAttributeValue("azapi", cty.ObjectVal(map[string]cty.Value{
-		"source":  cty.StringVal("azure/azapi"),
-		"version": cty.StringVal("~> 2.7"),
-	}))
+// ProviderRequirement is an entry of required_providers. An empty field keeps
+// the default of a required provider; a provider added without a source is
+// looked up as hashicorp/<name> by Terraform.
+type ProviderRequirement struct {
+	Source  string
+	Version string
+}
 
-	return file
+// providerRequirement is a provider a backend requires, in declaration order.
+type providerRequirement struct {
+	name, source, version string
 }
 
-func generateTerraform(outputDir string) error {
-	return hclgen.WriteFileToDir(outputDir, "terraform.tf", buildTerraform())
+// buildTerraform pins Terraform and azapi to versions that support provider-defined
+// functions (Terraform 1.8, azapi 2.0), which parent ID components rely on. The
+// random provider is required by the naming variable and, with modtm, by telemetry.
+func buildTerraform(features optionalFeatures, versions VersionConstraints) *hclwrite.File {
+	providers := []providerRequirement{{"azapi", "azure/azapi", "~> 2.7"}}
+	if features.telemetry {
+		providers = append(providers, providerRequirement{"modtm", "azure/modtm", "~> 0.3"})
+	}
+	if features.namingVariable || features.telemetry {
+		providers = append(providers, providerRequirement{"random", "hashicorp/random", "~> 3.6"})
+	}
+	return buildRequiredProviders(providers, versions)
+}
+
+// buildRequiredProviders generates the terraform block requiring providers, with
+// the versions constraints applied. Added providers follow, sorted by name.
+func buildRequiredProviders(providers []providerRequirement, versions VersionConstraints) *hclwrite.File {
+	file := hclwrite.NewEmptyFile()
+	tfBody := file.Body().AppendNewBlock("terraform", nil).Body()
+	required := DefaultTerraformVersion
+	if versions.Terraform != "" {
+		required = versions.Terraform
+	}
+	tfBody.SetAttributeValue("required_version", cty.StringVal(required))
+
+	declared := map[string]bool{}
+	for _, p := range providers {
+		declared[p.name] = true
+	}
+	var added []string
+	for name := range versions.Providers {
+		if !declared[name] {
+			added = append(added, name)
+		}
+	}
+	sort.Strings(added)
+	for _, name := range added {
+		providers = append(providers, providerRequirement{name: name})
+	}
+
+	providersBody := tfBody.AppendNewBlock("required_providers", nil).Body()
+	for _, p := range providers {
+		if override, ok := versions.Providers[p.name]; ok {
+			if override.Source != "" {
+				p.source = override.Source
+			}
+			if override.Version != "" {
+				p.version = override.Version
+			}
+		}
+		attrs := map[string]cty.Value{}
+		if p.source != "" {
+			attrs["source"] = cty.StringVal(p.source)
+		}
+		if p.version != "" {
+			attrs["version"] = cty.StringVal(p.version)
+		}
+		providersBody.SetAttributeValue(p.name, cty.ObjectVal(attrs))
+	}
+	return file
 }
@@ -0,0 +1,53 @@
+package terraform
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildTerraform_VersionConstraints(t *testing.T) {
+	got := string(buildTerraform(optionalFeatures{telemetry: true}, VersionConstraints{
+		Terraform: ">= 1.9, < 2.0",
+		Providers: map[string]ProviderRequirement{
+			"azapi": {Version: ">= 2.5, < 3.0"},
+			"modtm": {Source: "contoso/modtm"},
+			"time":  {Source: "hashicorp/time", Version: "~> 0.12"},
+			"null":  {Version: "~> 3.2"},
+		},
+	}).Bytes())
+
+	want := `terraform {
+  required_version = ">= 1.9, < 2.0"
+  required_providers {
+    azapi = {
+      source  = "azure/azapi"
+      version = ">= 2.5, < 3.0"
+    }
+    modtm = {
+      source  = "contoso/modtm"
+      version = "~> 0.3"
+    }
+    random = {
+      source  = "hashicorp/random"
+      version = "~> 3.6"
+    }
+    null = {
+      version = "~> 3.2"
+    }
+    time = {
+      source  = "hashicorp/time"
+      version = "~> 0.12"
+    }
+  }
+}
+`
+	assert.Equal(t, want, got)
+}
+
+func TestBuildTerraform_Defaults(t *testing.T) {
+	got := string(buildTerraform(optionalFeatures{}, VersionConstraints{}).Bytes())
+	assert.Contains(t, got, `required_version = "`+DefaultTerraformVersion+`"`)
+	assert.Contains(t, got, `version = "~> 2.7"`)
+	assert.NotContains(t, got, "random")
+}
@@ -13,7 +13,7 @@ import (
 	"github.com/zclconf/go-cty/cty"
 )
 
-func buildVariables(rs *schema.ResourceSchema, supportsTags, supportsLocation, supportsIdentity bool, secrets []secretField, caps InterfaceCapabilities, moduleNamePrefix string) (*hclwrite.File, error) {
+func buildVariables(rs *schema.ResourceSchema, resourceType string, supportsTags, supportsLocation, supportsIdentity, hasDiscriminator bool, features optionalFeatures, parent parentScope, secrets []secretField, caps InterfaceCapabilities, moduleNamePrefix string) (*hclwrite.File, error) {
 	file := hclwrite.NewEmptyFile()
 	body := file.Body()
 
@@ -111,7 +111,7 @@ func buildVariables(rs *schema.ResourceSchema, supportsTags, supportsLocation, s
 
 			nested := buildNestedDescription(nestedDocProp, "")
 			sb.WriteString(nested)
-			hclgen.SetDescriptionAttribute(varBody, sb.String())
+			hclgen.SetDescriptionAttribute(varBody, withDocLinks(sb.String()))
 		} else {
 			description := prop.Description
 			if description == "" {
@@ -121,7 +121,7 @@ func buildVariables(rs *schema.ResourceSchema, supportsTags, supportsLocation, s
 					description = fmt.Sprintf("The %s of the resource.", tfName)
 				}
 			}
-			hclgen.SetDescriptionAttribute(varBody, description)
+			hclgen.SetDescriptionAttribute(varBody, withDocLinks(description))
 		}
 
 		if !prop.Required {
@@ -151,17 +151,78 @@ func buildVariables(rs *schema.ResourceSchema, supportsTags, supportsLocation, s
 		return varBody, nil
 	}
 
-	appendVariable("name", "The name of the resource.", hclwrite.TokensForIdentifier("string"))
-	body.AppendNewline()
+	// Singletons have a fixed name, set in main.tf instead of a variable.
+	if _, singleton := rs.SingletonName(); !singleton {
+		nameBody := appendVariable("name", nameVariableDescription(rs, resourceType, features.namingVariable), hclwrite.TokensForIdentifier("string"))
+		if features.namingVariable {
+			nameBody.SetAttributeRaw("default", hclwrite.TokensForIdentifier("null"))
+		}
+		// The body's name property carries the constraints of the resource name path segment.
+		if rs != nil {
+			generateValidations(nameBody, "name", rs.Properties["name"], !features.namingVariable)
+		}
+		body.AppendNewline()
+	}
 
-	appendVariable("parent_id", "The parent resource ID for this resource.", hclwrite.TokensForIdentifier("string"))
-	body.AppendNewline()
+	if features.namingVariable {
+		appendNamingVariable(body)
+	}
+
+	parent.appendVariable(body)
 
 	// AVM standard variables (declared up-front; may be unused depending on resource capabilities)
 	// location
 	appendVariable("location", "The location of the resource.", hclwrite.TokensForIdentifier("string"))
 	body.AppendNewline()
 
+	// sku (declared with the standard variables; the most commonly set block after name and location)
+	sku := skuProperty(rs)
+	if sku != nil {
+		skuBody, err := appendSchemaVariable("sku", "sku", sku)
+		if err != nil {
+			return nil, err
+		}
+		hclgen.SetDescriptionAttribute(skuBody, skuDescription(sku))
+		body.AppendNewline()
+	}
+
+	// zones (a set, so reordering never changes the sorted request body)
+	zones := zonesProperty(rs)
+	if zones != nil {
+		zonesBody, err := appendSchemaVariable("zones", "zones", zones)
+		if err != nil {
+			return nil, err
+		}
+		hclgen.SetDescriptionAttribute(zonesBody, zonesDescription(zones))
+		zonesBody.SetAttributeRaw("type", hclwrite.TokensForFunctionCall("set", hclwrite.TokensForIdentifier("string")))
+		appendZonesValidation(zonesBody, "zones")
+		body.AppendNewline()
+	}
+
+	// extended_location (edge zones)
+	extendedLocation := extendedLocationProperty(rs)
+	if extendedLocation != nil {
+		elBody, err := appendSchemaVariable("extended_location", "extendedLocation", extendedLocation)
+		if err != nil {
+			return nil, err
+		}
+		hclgen.SetDescriptionAttribute(elBody, extendedLocationDescription(extendedLocation))
+		appendRequiredIfSetValidations(elBody, "extended_location", extendedLocation, "name", "type")
+		body.AppendNewline()
+	}
+
+	// plan (marketplace offers)
+	plan := planProperty(rs)
+	if plan != nil {
+		planBody, err := appendSchemaVariable("plan", "plan", plan)
+		if err != nil {
+			return nil, err
+		}
+		hclgen.SetDescriptionAttribute(planBody, planDescription(plan))
+		appendRequiredIfSetValidations(planBody, "plan", plan, planRequiredAttributes...)
+		body.AppendNewline()
+	}
+
 	// tags (only when the resource supports tags)
 	if supportsTags {
 		appendTFLintIgnoreUnused()
@@ -170,28 +231,60 @@ func buildVariables(rs *schema.ResourceSchema, supportsTags, supportsLocation, s
 		body.AppendNewline()
 	}
 
+	// inherited_tags (opt-in, merged beneath tags)
+	inheritedTags := features.inheritedTagsVariable && tagsBodyPath(rs) != ""
+	if inheritedTags {
+		inheritedBody := appendVariable(
+			"inherited_tags",
+			"Tags applied to the resource beneath var.tags, e.g. mandatory tags supplied by a platform team. Keys also present in var.tags are overridden by var.tags.",
+			hclwrite.TokensForFunctionCall("map", hclwrite.TokensForIdentifier("string")),
+		)
+		inheritedBody.SetAttributeRaw("default", hclwrite.TokensForObject(nil))
+		inheritedBody.SetAttributeValue("nullable", cty.False)
+		body.AppendNewline()
+	}
+
 	// managed_identities (only when the resource supports configuring identity)
 	if supportsIdentity {
 		appendTFLintIgnoreUnused()
-		miBody := appendVariable(
-			"managed_identities",
-			"Controls the Managed Identity configuration on this resource.",
-			hclwrite.TokensForFunctionCall(
-				"object",
-				hclwrite.TokensForObject([]hclwrite.ObjectAttrTokens{
-					{Name: hclwrite.TokensForIdentifier("system_assigned"), Value: hclwrite.TokensForFunctionCall("optional", hclwrite.TokensForIdentifier("bool"), hclwrite.TokensForIdentifier("false"))},
-					{Name: hclwrite.TokensForIdentifier("user_assigned_resource_ids"), Value: hclwrite.TokensForFunctionCall("optional", hclwrite.TokensForFunctionCall("set", hclwrite.TokensForIdentifier("string")), hclwrite.TokensForValue(cty.ListValEmpty(cty.String)))},
-				}),
-			),
+		emitManagedIdentitiesVar(body, appendVariable)
+	}
+
+	// schema_validation_enabled (opt-in toggle for the azapi_resource argument)
+	if features.schemaValidationVariable {
+		description := "Whether the azapi provider validates the resource body against its embedded schema. Set to false when deploying an API version the provider does not know yet."
+		if hasDiscriminator {
+			description += " Defaults to false because the body contains a discriminated object type whose discriminator value is unknown at validate time."
+		}
+		svBody := appendVariable("schema_validation_enabled", description, hclwrite.TokensForIdentifier("bool"))
+		svBody.SetAttributeValue("default", cty.BoolVal(!hasDiscriminator))
+		svBody.SetAttributeValue("nullable", cty.False)
+		body.AppendNewline()
+	}
+
+	// lock_resource_ids (opt-in, wired to the azapi_resource locks argument)
+	if features.lockResourceIDsVariable {
+		locksBody := appendVariable(
+			"lock_resource_ids",
+			"A list of resource IDs the azapi provider locks while this resource is created, updated or deleted, serializing operations on resources that share them (e.g. subnets of the same virtual network).",
+			hclwrite.TokensForFunctionCall("list", hclwrite.TokensForIdentifier("string")),
 		)
-		miBody.SetAttributeRaw("default", hclwrite.TokensForObject(nil))
-		miBody.SetAttributeValue("nullable", cty.False)
+		locksBody.SetAttributeValue("default", cty.ListValEmpty(cty.String))
+		locksBody.SetAttributeValue("nullable", cty.False)
 		body.AppendNewline()
 	}
 
+	// api_version (selects among the versions the module was generated for)
+	if len(features.apiVersions) > 0 {
+		appendAPIVersionVariable(body, features.apiVersions)
+	}
+
+	if features.keysOutput {
+		appendEnableKeysOutputVariable(body)
+	}
+
 	reservedNames := map[string]struct{}{
 		"name":                 {},
-		"parent_id":            {},
 		"location":             {},
 		"customer_managed_key": {},
 		"diagnostic_settings":  {},
@@ -201,12 +294,45 @@ func buildVariables(rs *schema.ResourceSchema, supportsTags, supportsLocation, s
 		"private_endpoints":    {},
 		"private_endpoints_manage_dns_zone_group": {},
 	}
+	for _, name := range parent.variableNames() {
+		reservedNames[name] = struct{}{}
+	}
+	if inheritedTags {
+		reservedNames["inherited_tags"] = struct{}{}
+	}
+	if features.namingVariable {
+		reservedNames[namingVariableName] = struct{}{}
+	}
+	if sku != nil {
+		reservedNames["sku"] = struct{}{}
+	}
+	if zones != nil {
+		reservedNames["zones"] = struct{}{}
+	}
+	if extendedLocation != nil {
+		reservedNames["extended_location"] = struct{}{}
+	}
+	if plan != nil {
+		reservedNames["plan"] = struct{}{}
+	}
 	if supportsTags {
 		reservedNames["tags"] = struct{}{}
 	}
 	if supportsIdentity {
 		reservedNames["managed_identities"] = struct{}{}
 	}
+	if features.schemaValidationVariable {
+		reservedNames["schema_validation_enabled"] = struct{}{}
+	}
+	if features.lockResourceIDsVariable {
+		reservedNames["lock_resource_ids"] = struct{}{}
+	}
+	if features.keysOutput {
+		reservedNames[enableKeysOutputVariable] = struct{}{}
+	}
+	if len(features.apiVersions) > 0 {
+		reservedNames[apiVersionVariable] = struct{}{}
+	}
 
 	seenNames := map[string]struct{}{}
 	for k := range reservedNames {
@@ -254,6 +380,11 @@ func buildVariables(rs *schema.ResourceSchema, supportsTags, supportsLocation, s
 			}
 			sort.Strings(childKeys)
 
+			associations := map[string]associationReference{}
+			for _, ref := range associationReferences(rs, resourceType) {
+				associations[ref.property] = ref
+			}
+
 			for _, childName := range childKeys {
 				child := prop.Children[childName]
 				if child == nil {
@@ -275,13 +406,25 @@ func buildVariables(rs *schema.ResourceSchema, supportsTags, supportsLocation, s
 				// A collision under flattened root properties is a hard error: users would have no way
 				// to configure that field.
 				if _, reserved := reservedNames[tfName]; reserved {
-					return nil, fmt.Errorf("terraform variable name collision: %q (from properties.%s)", tfName, childName)
+					return nil, fmt.Errorf("%w: %q (from properties.%s)", ErrNameCollision, tfName, childName)
 				}
 				if _, exists := seenNames[tfName]; exists {
-					return nil, fmt.Errorf("terraform variable name collision: %q (from properties.%s)", tfName, childName)
+					return nil, fmt.Errorf("%w: %q (from properties.%s)", ErrNameCollision, tfName, childName)
 				}
 				seenNames[tfName] = struct{}{}
 
+				// Association references take the ID of the linked resource as a string.
+				if ref, ok := associations[childName]; ok {
+					if _, exists := seenNames[ref.variable]; exists && ref.variable != tfName {
+						return nil, fmt.Errorf("%w: %q (from properties.%s)", ErrNameCollision, ref.variable, childName)
+					}
+					seenNames[ref.variable] = struct{}{}
+					idBody := appendVariable(ref.variable, associationDescription(ref), hclwrite.TokensForIdentifier("string"))
+					idBody.SetAttributeValue("nullable", cty.False)
+					body.AppendNewline()
+					continue
+				}
+
 				if _, err := appendSchemaVariable(tfName, childName, child); err != nil {
 					return nil, err
 				}
@@ -308,7 +451,7 @@ func buildVariables(rs *schema.ResourceSchema, supportsTags, supportsLocation, s
 			tfName = moduleNamePrefix + "_version"
 		}
 		if _, exists := seenNames[tfName]; exists {
-			return nil, fmt.Errorf("terraform variable name collision: %q (from %s)", tfName, name)
+			return nil, fmt.Errorf("%w: %q (from %s)", ErrNameCollision, tfName, name)
 		}
 		seenNames[tfName] = struct{}{}
 		if _, err := appendSchemaVariable(tfName, name, prop); err != nil {
@@ -361,7 +504,7 @@ func buildVariables(rs *schema.ResourceSchema, supportsTags, supportsLocation, s
 		}
 		versionVarName := secret.varName + "_version"
 		if _, exists := seenNames[versionVarName]; exists {
-			return nil, fmt.Errorf("terraform variable name collision: %q (from secret version var)", versionVarName)
+			return nil, fmt.Errorf("%w: %q (from secret version var)", ErrNameCollision, versionVarName)
 		}
 		versionBody := appendVariable(
 			versionVarName,
@@ -400,44 +543,17 @@ func buildVariables(rs *schema.ResourceSchema, supportsTags, supportsLocation, s
 		}
 	}
 
-	// Add AVM interface variables
-	// Only generate these when capabilities indicate support from REST spec
 	if len(secrets) > 0 || len(keys) > 0 {
 		body.AppendNewline()
 	}
 
-	// customer_managed_key (only if supported based on encryption properties in schema)
-	emitCustomerManagedKeyVar(body, caps, appendVariable, appendTFLintIgnoreUnused)
-
-	// enable_telemetry (always included for AVM compliance)
+	// enable_telemetry (always included for AVM compliance). The other AVM interfaces
+	// are scaffolded into their own files by GenerateInterfaces.
 	emitEnableTelemetryVar(body, appendVariable)
 
-	// diagnostic_settings (only if swagger indicates support)
-	emitDiagnosticSettingsVar(body, caps, appendVariable)
-
-	// role_assignments (ARM-level capability, not detectable from specs - omitted for child modules)
-	// Note: For root modules, this could be included by default, but for consistency we omit unless detected
-	// Users can add this manually or via a future helper command
-	_ = caps // Explicitly show we're aware of capabilities but choosing not to generate role_assignments
-
-	// lock (ARM-level capability, not detectable from specs - omitted for child modules)
-	// Note: For root modules, this could be included by default, but for consistency we omit unless detected
-	// Users can add this manually or via a future helper command
-
-	// private_endpoints (only if swagger indicates Private Link/Private Endpoint support)
-	emitPrivateEndpointsVars(body, caps, appendVariable)
-
 	return file, nil
 }
 
-func generateVariables(rs *schema.ResourceSchema, supportsTags, supportsLocation, supportsIdentity bool, secrets []secretField, caps InterfaceCapabilities, moduleNamePrefix string, outputDir string) error {
-	file, err := buildVariables(rs, supportsTags, supportsLocation, supportsIdentity, secrets, caps, moduleNamePrefix)
-	if err != nil {
-		return err
-	}
-	return hclgen.WriteFileToDir(outputDir, "variables.tf", file)
-}
-
 func mapType(prop *schema.Property) (hclwrite.Tokens, error) {
 	if prop == nil {
 		return hclwrite.TokensForIdentifier("any"), nil
@@ -1,6 +1,9 @@
 package terraform
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/hashicorp/hcl/v2/hclsyntax"
 	"github.com/hashicorp/hcl/v2/hclwrite"
 	"github.com/matt-FFFFFF/tfmodmake/hclgen"
@@ -10,15 +13,10 @@ import (
 
 // This file contains AVM (Azure Verified Modules) interface variable generation.
 // These are standard variables that follow AVM patterns for customer_managed_key,
-// diagnostic_settings, private_endpoints, etc.
-
-// emitCustomerManagedKeyVar generates the customer_managed_key variable if supported.
-func emitCustomerManagedKeyVar(body *hclwrite.Body, caps InterfaceCapabilities, appendVariable func(string, string, hclwrite.Tokens) *hclwrite.Body, appendTFLintIgnoreUnused func()) {
-	if !caps.SupportsCustomerManagedKey {
-		return
-	}
+// diagnostic_settings, lock, managed_identities, private_endpoints and role_assignments.
 
-	appendTFLintIgnoreUnused()
+// emitCustomerManagedKeyVar generates the customer_managed_key variable.
+func emitCustomerManagedKeyVar(body *hclwrite.Body, appendVariable func(string, string, hclwrite.Tokens) *hclwrite.Body) {
 	cmkBody := appendVariable(
 		"customer_managed_key",
 		"A map describing customer-managed keys to associate with the resource.",
@@ -57,20 +55,27 @@ func emitEnableTelemetryVar(body *hclwrite.Body, appendVariable func(string, str
 	body.AppendNewline()
 }
 
-// emitDiagnosticSettingsVar generates the diagnostic_settings variable with validations if supported.
-func emitDiagnosticSettingsVar(body *hclwrite.Body, caps InterfaceCapabilities, appendVariable func(string, string, hclwrite.Tokens) *hclwrite.Body) {
-	if !caps.SupportsDiagnostics {
-		return
+// emitDiagnosticSettingsVar generates the diagnostic_settings variable with validations.
+// When the categories of the resource type are known, they are documented, validated
+// and used for the default log groups and metric categories.
+func emitDiagnosticSettingsVar(body *hclwrite.Body, appendVariable func(string, string, hclwrite.Tokens) *hclwrite.Body, categories *diagnosticCategories) {
+	description := "A map of diagnostic settings to create on the resource."
+	logGroups := []string{"allLogs"}
+	metrics := []string{"AllMetrics"}
+	if categories != nil {
+		description = categories.description()
+		logGroups = categories.defaultLogGroups()
+		metrics = categories.defaultMetrics()
 	}
 
 	diagBody := appendVariable(
 		"diagnostic_settings",
-		"A map of diagnostic settings to create on the resource.",
+		description,
 		hclwrite.TokensForFunctionCall("map", hclwrite.TokensForFunctionCall("object", hclwrite.TokensForObject([]hclwrite.ObjectAttrTokens{
 			{Name: hclwrite.TokensForIdentifier("name"), Value: hclwrite.TokensForFunctionCall("optional", hclwrite.TokensForIdentifier("string"), hclwrite.TokensForIdentifier("null"))},
 			{Name: hclwrite.TokensForIdentifier("log_categories"), Value: hclwrite.TokensForFunctionCall("optional", hclwrite.TokensForFunctionCall("set", hclwrite.TokensForIdentifier("string")), hclwrite.TokensForValue(cty.ListValEmpty(cty.String)))},
-			{Name: hclwrite.TokensForIdentifier("log_groups"), Value: hclwrite.TokensForFunctionCall("optional", hclwrite.TokensForFunctionCall("set", hclwrite.TokensForIdentifier("string")), hclwrite.TokensForValue(cty.ListVal([]cty.Value{cty.StringVal("allLogs")})))},
-			{Name: hclwrite.TokensForIdentifier("metric_categories"), Value: hclwrite.TokensForFunctionCall("optional", hclwrite.TokensForFunctionCall("set", hclwrite.TokensForIdentifier("string")), hclwrite.TokensForValue(cty.ListVal([]cty.Value{cty.StringVal("AllMetrics")})))},
+			{Name: hclwrite.TokensForIdentifier("log_groups"), Value: hclwrite.TokensForFunctionCall("optional", hclwrite.TokensForFunctionCall("set", hclwrite.TokensForIdentifier("string")), tokensForStringList(logGroups))},
+			{Name: hclwrite.TokensForIdentifier("metric_categories"), Value: hclwrite.TokensForFunctionCall("optional", hclwrite.TokensForFunctionCall("set", hclwrite.TokensForIdentifier("string")), tokensForStringList(metrics))},
 			{Name: hclwrite.TokensForIdentifier("log_analytics_destination_type"), Value: hclwrite.TokensForFunctionCall("optional", hclwrite.TokensForIdentifier("string"), hclwrite.TokensForValue(cty.StringVal("Dedicated")))},
 			{Name: hclwrite.TokensForIdentifier("workspace_resource_id"), Value: hclwrite.TokensForFunctionCall("optional", hclwrite.TokensForIdentifier("string"), hclwrite.TokensForIdentifier("null"))},
 			{Name: hclwrite.TokensForIdentifier("storage_account_resource_id"), Value: hclwrite.TokensForFunctionCall("optional", hclwrite.TokensForIdentifier("string"), hclwrite.TokensForIdentifier("null"))},
@@ -84,6 +89,11 @@ func emitDiagnosticSettingsVar(body *hclwrite.Body, caps InterfaceCapabilities,
 
 	// Add validations
 	addDiagnosticSettingsValidations(diagBody)
+	if categories != nil {
+		addDiagnosticCategoryValidation(diagBody, "log_categories", categories.logs)
+		addDiagnosticCategoryValidation(diagBody, "log_groups", categories.logGroups)
+		addDiagnosticCategoryValidation(diagBody, "metric_categories", categories.metrics)
+	}
 	body.AppendNewline()
 }
 
@@ -160,31 +170,23 @@ func addDiagnosticSettingsValidations(diagBody *hclwrite.Body) {
 	}
 }
 
-// emitPrivateEndpointsVars generates both private_endpoints and private_endpoints_manage_dns_zone_group variables if supported.
-func emitPrivateEndpointsVars(body *hclwrite.Body, caps InterfaceCapabilities, appendVariable func(string, string, hclwrite.Tokens) *hclwrite.Body) {
-	if !caps.SupportsPrivateEndpoints {
-		return
+// emitPrivateEndpointsVars generates both private_endpoints and private_endpoints_manage_dns_zone_group variables.
+// When the Private Link subresources of the resource are known, subresource_name is
+// documented and validated against them; it may be omitted when there is only one.
+func emitPrivateEndpointsVars(body *hclwrite.Body, appendVariable func(string, string, hclwrite.Tokens) *hclwrite.Body, subresources []string) {
+	description := "A map of private endpoints to create on this resource."
+	switch len(subresources) {
+	case 0:
+	case 1:
+		description += fmt.Sprintf("\n\n- subresource_name: The Private Link subresource (group ID) to connect to. Defaults to '%s', the only subresource of this resource type.", subresources[0])
+	default:
+		description += fmt.Sprintf("\n\n- subresource_name: The Private Link subresource (group ID) to connect to. One of %s.", formatQuotedList(subresources))
 	}
-
 	peBody := appendVariable(
 		"private_endpoints",
-		"A map of private endpoints to create on this resource.",
+		description,
 		hclwrite.TokensForFunctionCall("map", hclwrite.TokensForFunctionCall("object", hclwrite.TokensForObject([]hclwrite.ObjectAttrTokens{
 			{Name: hclwrite.TokensForIdentifier("name"), Value: hclwrite.TokensForFunctionCall("optional", hclwrite.TokensForIdentifier("string"), hclwrite.TokensForIdentifier("null"))},
-			{Name: hclwrite.TokensForIdentifier("role_assignments"), Value: hclwrite.TokensForFunctionCall("optional", hclwrite.TokensForFunctionCall("map", hclwrite.TokensForFunctionCall("object", hclwrite.TokensForObject([]hclwrite.ObjectAttrTokens{
-				{Name: hclwrite.TokensForIdentifier("role_definition_id_or_name"), Value: hclwrite.TokensForIdentifier("string")},
-				{Name: hclwrite.TokensForIdentifier("principal_id"), Value: hclwrite.TokensForIdentifier("string")},
-				{Name: hclwrite.TokensForIdentifier("description"), Value: hclwrite.TokensForFunctionCall("optional", hclwrite.TokensForIdentifier("string"), hclwrite.TokensForIdentifier("null"))},
-				{Name: hclwrite.TokensForIdentifier("skip_service_principal_aad_check"), Value: hclwrite.TokensForFunctionCall("optional", hclwrite.TokensForIdentifier("bool"), hclwrite.TokensForIdentifier("false"))},
-				{Name: hclwrite.TokensForIdentifier("condition"), Value: hclwrite.TokensForFunctionCall("optional", hclwrite.TokensForIdentifier("string"), hclwrite.TokensForIdentifier("null"))},
-				{Name: hclwrite.TokensForIdentifier("condition_version"), Value: hclwrite.TokensForFunctionCall("optional", hclwrite.TokensForIdentifier("string"), hclwrite.TokensForIdentifier("null"))},
-				{Name: hclwrite.TokensForIdentifier("delegated_managed_identity_resource_id"), Value: hclwrite.TokensForFunctionCall("optional", hclwrite.TokensForIdentifier("string"), hclwrite.TokensForIdentifier("null"))},
-				{Name: hclwrite.TokensForIdentifier("principal_type"), Value: hclwrite.TokensForFunctionCall("optional", hclwrite.TokensForIdentifier("string"), hclwrite.TokensForIdentifier("null"))},
-			}))), hclwrite.TokensForObject(nil))},
-			{Name: hclwrite.TokensForIdentifier("lock"), Value: hclwrite.TokensForFunctionCall("optional", hclwrite.TokensForFunctionCall("object", hclwrite.TokensForObject([]hclwrite.ObjectAttrTokens{
-				{Name: hclwrite.TokensForIdentifier("kind"), Value: hclwrite.TokensForIdentifier("string")},
-				{Name: hclwrite.TokensForIdentifier("name"), Value: hclwrite.TokensForFunctionCall("optional", hclwrite.TokensForIdentifier("string"), hclwrite.TokensForIdentifier("null"))},
-			})), hclwrite.TokensForIdentifier("null"))},
 			{Name: hclwrite.TokensForIdentifier("tags"), Value: hclwrite.TokensForFunctionCall("optional", hclwrite.TokensForFunctionCall("map", hclwrite.TokensForIdentifier("string")), hclwrite.TokensForIdentifier("null"))},
 			{Name: hclwrite.TokensForIdentifier("subnet_resource_id"), Value: hclwrite.TokensForIdentifier("string")},
 			{Name: hclwrite.TokensForIdentifier("subresource_name"), Value: hclwrite.TokensForFunctionCall("optional", hclwrite.TokensForIdentifier("string"), hclwrite.TokensForIdentifier("null"))},
@@ -203,6 +205,9 @@ func emitPrivateEndpointsVars(body *hclwrite.Body, caps InterfaceCapabilities, a
 	)
 	peBody.SetAttributeValue("default", cty.MapValEmpty(cty.DynamicPseudoType))
 	peBody.SetAttributeValue("nullable", cty.False)
+	if len(subresources) > 0 {
+		addPrivateEndpointSubresourceValidation(peBody, subresources)
+	}
 	body.AppendNewline()
 
 	// private_endpoints_manage_dns_zone_group
@@ -214,3 +219,162 @@ func emitPrivateEndpointsVars(body *hclwrite.Body, caps InterfaceCapabilities, a
 	peMgmtBody.SetAttributeValue("default", cty.True)
 	peMgmtBody.SetAttributeValue("nullable", cty.False)
 }
+
+// emitManagedIdentitiesVar generates the managed_identities variable.
+func emitManagedIdentitiesVar(body *hclwrite.Body, appendVariable func(string, string, hclwrite.Tokens) *hclwrite.Body) {
+	miBody := appendVariable(
+		"managed_identities",
+		"Controls the Managed Identity configuration on this resource.",
+		hclwrite.TokensForFunctionCall(
+			"object",
+			hclwrite.TokensForObject([]hclwrite.ObjectAttrTokens{
+				{Name: hclwrite.TokensForIdentifier("system_assigned"), Value: hclwrite.TokensForFunctionCall("optional", hclwrite.TokensForIdentifier("bool"), hclwrite.TokensForIdentifier("false"))},
+				{Name: hclwrite.TokensForIdentifier("user_assigned_resource_ids"), Value: hclwrite.TokensForFunctionCall("optional", hclwrite.TokensForFunctionCall("set", hclwrite.TokensForIdentifier("string")), hclwrite.TokensForValue(cty.ListValEmpty(cty.String)))},
+			}),
+		),
+	)
+	miBody.SetAttributeRaw("default", hclwrite.TokensForObject(nil))
+	miBody.SetAttributeValue("nullable", cty.False)
+	body.AppendNewline()
+}
+
+// emitLockVar generates the lock variable with a validation of the lock kind.
+func emitLockVar(body *hclwrite.Body, appendVariable func(string, string, hclwrite.Tokens) *hclwrite.Body) {
+	lockBody := appendVariable(
+		"lock",
+		"Controls the resource lock configuration for this resource. The kind is either `CanNotDelete` or `ReadOnly`.",
+		hclwrite.TokensForFunctionCall("object", hclwrite.TokensForObject([]hclwrite.ObjectAttrTokens{
+			{Name: hclwrite.TokensForIdentifier("kind"), Value: hclwrite.TokensForIdentifier("string")},
+			{Name: hclwrite.TokensForIdentifier("name"), Value: hclwrite.TokensForFunctionCall("optional", hclwrite.TokensForIdentifier("string"), hclwrite.TokensForIdentifier("null"))},
+		})),
+	)
+	lockBody.SetAttributeRaw("default", hclwrite.TokensForIdentifier("null"))
+
+	// var.lock != null ? contains(["CanNotDelete", "ReadOnly"], var.lock.kind) : true
+	var condition hclwrite.Tokens
+	condition = append(condition, hclgen.TokensForTraversal("var", "lock")...)
+	condition = append(condition, &hclwrite.Token{Type: hclsyntax.TokenNotEqual, Bytes: []byte(" != ")})
+	condition = append(condition, hclwrite.TokensForIdentifier("null")...)
+	condition = append(condition, &hclwrite.Token{Type: hclsyntax.TokenQuestion, Bytes: []byte(" ? ")})
+	condition = append(condition, hclwrite.TokensForFunctionCall(
+		"contains",
+		hclwrite.TokensForValue(cty.ListVal([]cty.Value{cty.StringVal("CanNotDelete"), cty.StringVal("ReadOnly")})),
+		hclgen.TokensForTraversal("var", "lock", "kind"),
+	)...)
+	condition = append(condition, &hclwrite.Token{Type: hclsyntax.TokenColon, Bytes: []byte(" : ")})
+	condition = append(condition, hclwrite.TokensForIdentifier("true")...)
+
+	validationBody := lockBody.AppendNewBlock("validation", nil).Body()
+	validationBody.SetAttributeRaw("condition", condition)
+	validationBody.SetAttributeValue("error_message", cty.StringVal("The lock level must be one of: 'CanNotDelete', or 'ReadOnly'."))
+	body.AppendNewline()
+}
+
+// roleAssignmentPrincipalTypes are the principal types a role assignment accepts.
+var roleAssignmentPrincipalTypes = []string{"User", "Group", "ServicePrincipal", "ForeignGroup", "Device"}
+
+// emitRoleAssignmentsVar generates the role_assignments variable with validations of
+// the principal type, the condition version and duplicate assignments.
+func emitRoleAssignmentsVar(body *hclwrite.Body, appendVariable func(string, string, hclwrite.Tokens) *hclwrite.Body) {
+	description := strings.Join([]string{
+		"A map of role assignments to create on this resource. The map key is arbitrary and only identifies the assignment in Terraform.",
+		"",
+		"- role_definition_id_or_name: The ID or name of the role definition to assign, e.g. `Reader`.",
+		"- principal_id: The object ID of the principal to assign the role to.",
+		"- description: (Optional) The description of the role assignment.",
+		"- skip_service_principal_aad_check: (Optional) Set to true when the principal is a newly created service principal, to skip the Entra ID replication check.",
+		"- condition: (Optional) The ABAC condition limiting the role assignment.",
+		"- condition_version: (Optional) The version of the condition. Defaults to `2.0` when a condition is set.",
+		"- delegated_managed_identity_resource_id: (Optional) The resource ID of the delegated managed identity, for group assignments across tenants.",
+		fmt.Sprintf("- principal_type: (Optional) The type of the principal, one of %s.", formatQuotedList(roleAssignmentPrincipalTypes)),
+	}, "\n")
+	raBody := appendVariable(
+		"role_assignments",
+		description,
+		hclwrite.TokensForFunctionCall("map", hclwrite.TokensForFunctionCall("object", hclwrite.TokensForObject([]hclwrite.ObjectAttrTokens{
+			{Name: hclwrite.TokensForIdentifier("role_definition_id_or_name"), Value: hclwrite.TokensForIdentifier("string")},
+			{Name: hclwrite.TokensForIdentifier("principal_id"), Value: hclwrite.TokensForIdentifier("string")},
+			{Name: hclwrite.TokensForIdentifier("description"), Value: hclwrite.TokensForFunctionCall("optional", hclwrite.TokensForIdentifier("string"), hclwrite.TokensForIdentifier("null"))},
+			{Name: hclwrite.TokensForIdentifier("skip_service_principal_aad_check"), Value: hclwrite.TokensForFunctionCall("optional", hclwrite.TokensForIdentifier("bool"), hclwrite.TokensForIdentifier("false"))},
+			{Name: hclwrite.TokensForIdentifier("condition"), Value: hclwrite.TokensForFunctionCall("optional", hclwrite.TokensForIdentifier("string"), hclwrite.TokensForIdentifier("null"))},
+			{Name: hclwrite.TokensForIdentifier("condition_version"), Value: hclwrite.TokensForFunctionCall("optional", hclwrite.TokensForIdentifier("string"), hclwrite.TokensForIdentifier("null"))},
+			{Name: hclwrite.TokensForIdentifier("delegated_managed_identity_resource_id"), Value: hclwrite.TokensForFunctionCall("optional", hclwrite.TokensForIdentifier("string"), hclwrite.TokensForIdentifier("null"))},
+			{Name: hclwrite.TokensForIdentifier("principal_type"), Value: hclwrite.TokensForFunctionCall("optional", hclwrite.TokensForIdentifier("string"), hclwrite.TokensForIdentifier("null"))},
+		}))),
+	)
+	raBody.SetAttributeRaw("default", hclwrite.TokensForObject(nil))
+	raBody.SetAttributeValue("nullable", cty.False)
+
+	principalTypes := string(tokensForStringList(roleAssignmentPrincipalTypes).Bytes())
+	validations := []struct{ condition, message string }{
+		{
+			condition: fmt.Sprintf("alltrue([for _, v in var.role_assignments : v.principal_type == null ? true : contains(%s, v.principal_type)])", principalTypes),
+			message:   fmt.Sprintf("principal_type must be one of: %s.", formatQuotedList(roleAssignmentPrincipalTypes)),
+		},
+		{
+			condition: `alltrue([for _, v in var.role_assignments : v.condition_version == null ? true : v.condition != null && v.condition_version == "2.0"])`,
+			message:   "condition_version must be '2.0' and is only valid together with condition.",
+		},
+		{
+			condition: `length(distinct([for _, v in var.role_assignments : "${v.principal_id}/${lower(v.role_definition_id_or_name)}"])) == length(var.role_assignments)`,
+			message:   "Each principal_id may only be assigned a role definition once.",
+		},
+	}
+	for _, v := range validations {
+		validationBody := raBody.AppendNewBlock("validation", nil).Body()
+		validationBody.SetAttributeRaw("condition", interfaceExpression(v.condition))
+		validationBody.SetAttributeValue("error_message", cty.StringVal(v.message))
+	}
+	body.AppendNewline()
+}
+
+// addPrivateEndpointSubresourceValidation restricts subresource_name to the known
+// subresources. A null name is only accepted when there is a single subresource to
+// default to.
+func addPrivateEndpointSubresourceValidation(peBody *hclwrite.Body, subresources []string) {
+	values := make([]cty.Value, 0, len(subresources))
+	for _, name := range subresources {
+		values = append(values, cty.StringVal(name))
+	}
+	nullAccepted := "false"
+	if len(subresources) == 1 {
+		nullAccepted = "true"
+	}
+
+	// alltrue([for _, v in var.private_endpoints : v.subresource_name == null ? <nullAccepted> : contains([...], v.subresource_name)])
+	listComp := hclwrite.Tokens{
+		&hclwrite.Token{Type: hclsyntax.TokenOBrack, Bytes: []byte("[")},
+		&hclwrite.Token{Type: hclsyntax.TokenIdent, Bytes: []byte("for")},
+		&hclwrite.Token{Type: hclsyntax.TokenIdent, Bytes: []byte("_")},
+		&hclwrite.Token{Type: hclsyntax.TokenComma, Bytes: []byte(",")},
+		&hclwrite.Token{Type: hclsyntax.TokenIdent, Bytes: []byte("v")},
+		&hclwrite.Token{Type: hclsyntax.TokenIdent, Bytes: []byte("in")},
+	}
+	listComp = append(listComp, hclgen.TokensForTraversal("var", "private_endpoints")...)
+	listComp = append(listComp, &hclwrite.Token{Type: hclsyntax.TokenColon, Bytes: []byte(":")})
+	listComp = append(listComp, hclgen.TokensForTraversal("v", "subresource_name")...)
+	listComp = append(listComp, &hclwrite.Token{Type: hclsyntax.TokenEqualOp, Bytes: []byte(" == ")})
+	listComp = append(listComp, hclwrite.TokensForIdentifier("null")...)
+	listComp = append(listComp, &hclwrite.Token{Type: hclsyntax.TokenQuestion, Bytes: []byte(" ? ")})
+	listComp = append(listComp, hclwrite.TokensForIdentifier(nullAccepted)...)
+	listComp = append(listComp, &hclwrite.Token{Type: hclsyntax.TokenColon, Bytes: []byte(" : ")})
+	listComp = append(listComp, hclwrite.TokensForFunctionCall("contains", hclwrite.TokensForValue(cty.ListVal(values)), hclgen.TokensForTraversal("v", "subresource_name"))...)
+	listComp = append(listComp, &hclwrite.Token{Type: hclsyntax.TokenCBrack, Bytes: []byte("]")})
+
+	message := fmt.Sprintf("subresource_name must be one of: %s.", formatQuotedList(subresources))
+	if len(subresources) > 1 {
+		message = fmt.Sprintf("subresource_name must be set to one of: %s.", formatQuotedList(subresources))
+	}
+	validationBody := peBody.AppendNewBlock("validation", nil).Body()
+	validationBody.SetAttributeRaw("condition", hclwrite.TokensForFunctionCall("alltrue", listComp))
+	validationBody.SetAttributeValue("error_message", cty.StringVal(message))
+}
+
+// formatQuotedList quotes names for descriptions and validation messages.
+func formatQuotedList(subresources []string) string {
+	quoted := make([]string, 0, len(subresources))
+	for _, name := range subresources {
+		quoted = append(quoted, "'"+name+"'")
+	}
+	return strings.Join(quoted, ", ")
+}
@@ -2,9 +2,15 @@
 package terraform
 
 import (
+	"context"
 	"fmt"
+	"slices"
+	"sort"
+	"strings"
 
 	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/matt-FFFFFF/tfmodmake/hclgen"
+	"github.com/matt-FFFFFF/tfmodmake/profile"
 	"github.com/matt-FFFFFF/tfmodmake/schema"
 )
 
@@ -18,6 +24,57 @@ type generatorOptions struct {
 	apiVersion       string
 	moduleNamePrefix string
 	outputDir        string
+	// writer, when set, receives the generated files in place of outputDir.
+	writer hclgen.Writer
+	// journal, when set, records the files written to outputDir.
+	journal *hclgen.Journal
+	// backend emits the provider-specific files; nil selects azapi.
+	backend Backend
+	// templatesDir holds the override templates of fixed-content files.
+	templatesDir string
+	// versions replaces the default version constraints of terraform.tf.
+	versions VersionConstraints
+	// timings, when set, records how long building and writing each file takes.
+	timings *profile.Timings
+	// ctx, when set, stops generation before the next file is written once it
+	// is cancelled.
+	ctx context.Context
+
+	features      optionalFeatures
+	ignoreChanges []string
+	preconditions []PreconditionRule
+	exclusive     []ExclusiveRule
+	postCreate    []string
+	// exportPaths, when non-nil, replaces the computed paths derived from the schema
+	// as response_export_values (and the outputs wired to them).
+	exportPaths []string
+	// endpointSuffixes overrides DefaultEndpointSuffixes when non-nil.
+	endpointSuffixes []string
+	objectOutputs    []string
+	outputNaming     OutputNaming
+	// propertyBlocklist overrides DefaultPropertyBlocklist when non-nil.
+	propertyBlocklist []string
+}
+
+// optionalFeatures carries the opt-in toggles that shape the generated module.
+type optionalFeatures struct {
+	schemaValidationVariable bool
+	lockResourceIDsVariable  bool
+	updateResource           bool
+	scopeResource            bool
+	inheritedTagsVariable    bool
+	parentIDComponents       bool
+	namingVariable           bool
+	resourceOutput           bool
+	keysOutput               bool
+	moduleInterface          bool
+	avmStrict                bool
+	telemetry                bool
+	preconditionValidations  bool
+	bodyFormat               BodyFormat
+	// apiVersions, when set, are the API versions the api_version variable
+	// selects from, oldest first.
+	apiVersions []string
 }
 
 // WithResourceSchema sets the resource schema for generation.
@@ -55,6 +112,266 @@ func WithOutputDir(dir string) GeneratorOption {
 	}
 }
 
+// WithWriter sends the generated files to w instead of writing them to the
+// output directory. The existing module in the output directory is then not
+// consulted: no moved blocks are derived from it, and the tfvars example only
+// holds the generated variables.
+func WithWriter(w hclgen.Writer) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.writer = w
+	}
+}
+
+// WithJournal records the files written to the output directory in journal, so
+// the caller can report or undo the changes of the generation.
+func WithJournal(journal *hclgen.Journal) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.journal = journal
+	}
+}
+
+// WithTemplatesDir renders the override templates in dir onto the generated
+// files: terraform.tf.tmpl and main.telemetry.tf.tmpl replace those files, and
+// <file>.header.tmpl is written above a generated .tf file. Templates are Go
+// templates rendered with TemplateData.
+func WithTemplatesDir(dir string) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.templatesDir = dir
+	}
+}
+
+// WithVersionConstraints replaces the default required_version and provider
+// version constraints of terraform.tf, and requires further providers.
+func WithVersionConstraints(v VersionConstraints) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.versions = v
+	}
+}
+
+// WithTimings records the time spent building the variables, locals, main and
+// outputs files and writing the module in t.
+func WithTimings(t *profile.Timings) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.timings = t
+	}
+}
+
+// WithContext stops generation once ctx is cancelled: the module is not built,
+// or no further file is written, and the context's error is returned.
+func WithContext(ctx context.Context) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.ctx = ctx
+	}
+}
+
+// WithSchemaValidationVariable generates a schema_validation_enabled variable
+// wired to the azapi_resource argument, letting consumers opt out of the
+// provider's embedded schema validation (e.g. for API versions it does not yet know).
+func WithSchemaValidationVariable(enabled bool) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.features.schemaValidationVariable = enabled
+	}
+}
+
+// WithLockResourceIDsVariable generates a lock_resource_ids variable wired to the
+// azapi_resource locks argument, so mutations to resources sharing a lock ID
+// (e.g. subnets of one VNet) are serialized by the provider.
+func WithLockResourceIDsVariable(enabled bool) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.features.lockResourceIDsVariable = enabled
+	}
+}
+
+// WithAPIVersionVariable generates an api_version variable validated against
+// versions and interpolates it into the resource type, so one module can be
+// deployed at any of them. The variable defaults to the latest version.
+func WithAPIVersionVariable(versions ...string) GeneratorOption {
+	return func(o *generatorOptions) {
+		sorted := append([]string(nil), versions...)
+		sort.Strings(sorted)
+		o.features.apiVersions = slices.Compact(sorted)
+	}
+}
+
+// WithUpdateResource generates an azapi_update_resource instead of an azapi_resource,
+// for resource types that can be read and modified but not created with PUT.
+func WithUpdateResource(enabled bool) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.features.updateResource = enabled
+	}
+}
+
+// WithScopeResource generates a scope variable instead of parent_id, for extension
+// resources (locks, role assignments, diagnostic settings) applied to an arbitrary
+// resource. It is enabled automatically when the schema reports extension scope.
+func WithScopeResource(enabled bool) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.features.scopeResource = enabled
+	}
+}
+
+// WithInheritedTagsVariable generates an inherited_tags variable merged beneath the
+// module's tags, letting platform teams layer mandatory tags onto every instance.
+func WithInheritedTagsVariable(enabled bool) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.features.inheritedTagsVariable = enabled
+	}
+}
+
+// WithParentIDComponents replaces the parent_id variable with subscription_id,
+// resource_group_name and parent resource name variables, from which the parent ID
+// is built with azapi provider functions.
+func WithParentIDComponents(enabled bool) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.features.parentIDComponents = enabled
+	}
+}
+
+// WithNamingVariable makes var.name optional and generates a naming variable from
+// which a name compliant with the resource type's length and character rules is
+// assembled when no name is given.
+func WithNamingVariable(enabled bool) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.features.namingVariable = enabled
+	}
+}
+
+// WithResourceOutput generates the AVM "resource" output exposing a curated object
+// (ID, name, location and non-sensitive computed properties).
+func WithResourceOutput(enabled bool) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.features.resourceOutput = enabled
+	}
+}
+
+// WithKeysOutput enables sensitive outputs for the responses of the listKeys and
+// listConnectionStrings actions, read by azapi_resource_action data sources gated
+// by an enable_keys_output variable.
+func WithKeysOutput(enabled bool) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.features.keysOutput = enabled
+	}
+}
+
+// WithModuleInterface writes module-interface.json, describing every variable and
+// output with its type as JSON Schema and the body path it maps to.
+func WithModuleInterface(enabled bool) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.features.moduleInterface = enabled
+	}
+}
+
+// WithAVMStrict enforces the AVM resource module interface: the resource output and
+// telemetry are generated, and generation fails listing every deviation from the required
+// outputs, variables and naming rules that could not be reconciled.
+func WithAVMStrict(enabled bool) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.features.avmStrict = enabled
+	}
+}
+
+// WithTelemetry generates main.telemetry.tf with the AVM telemetry resources gated
+// by var.enable_telemetry, and requires the modtm and random providers.
+func WithTelemetry(enabled bool) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.features.telemetry = enabled
+	}
+}
+
+// WithBodyFormat selects whether request bodies are HCL objects or jsonencode()d strings.
+func WithBodyFormat(format BodyFormat) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.features.bodyFormat = format
+	}
+}
+
+// WithIgnoreChanges adds body paths (e.g. "properties.count") to the lifecycle
+// ignore_changes list of the azapi_resource, on top of the built-in defaults.
+func WithIgnoreChanges(paths ...string) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.ignoreChanges = append(o.ignoreChanges, paths...)
+	}
+}
+
+// WithPreconditions adds cross-property rules rendered as lifecycle preconditions
+// on the azapi_resource, on top of those inferred from property descriptions.
+func WithPreconditions(rules ...PreconditionRule) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.preconditions = append(o.preconditions, rules...)
+	}
+}
+
+// WithExclusiveRules adds sets of properties of which at most one may be set,
+// rendered like the preconditions, on top of those inferred from the schema.
+func WithExclusiveRules(rules ...ExclusiveRule) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.exclusive = append(o.exclusive, rules...)
+	}
+}
+
+// WithPreconditionValidations renders the cross-property rules as validation
+// blocks on the variable holding the required property instead of lifecycle
+// preconditions, so they fail at plan before anything is read. A rule whose
+// gate is another variable needs Terraform 1.9, which allows validations to
+// reference other variables.
+func WithPreconditionValidations(enabled bool) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.features.preconditionValidations = enabled
+	}
+}
+
+// WithPostCreateProperties moves the given body paths (e.g. "properties.networkAcls")
+// out of the creation body into a companion azapi_update_resource, for properties
+// the service only accepts once the resource exists.
+func WithPostCreateProperties(paths ...string) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.postCreate = append(o.postCreate, paths...)
+	}
+}
+
+// WithResponseExportValues exports exactly the given response paths instead of every
+// computed path of the schema, and generates outputs only for them. The update
+// command uses it to keep the paths a module author trimmed by hand.
+func WithResponseExportValues(paths ...string) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.exportPaths = append([]string{}, paths...)
+	}
+}
+
+// WithEndpointOutputSuffixes replaces DefaultEndpointSuffixes, the name suffixes of
+// read-only strings that are always exported and output. An empty list disables
+// the heuristic.
+func WithEndpointOutputSuffixes(suffixes ...string) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.endpointSuffixes = append([]string{}, suffixes...)
+	}
+}
+
+// WithPropertyBlocklist replaces DefaultPropertyBlocklist, the body paths (e.g.
+// "properties.provisioningState") left out of the schema whatever the spec says.
+// An empty list disables it.
+func WithPropertyBlocklist(paths ...string) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.propertyBlocklist = append([]string{}, paths...)
+	}
+}
+
+// WithObjectOutputs exports and outputs the given read-only objects (e.g.
+// "properties.networkProfile") whole, in place of the paths nested beneath them.
+func WithObjectOutputs(paths ...string) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.objectOutputs = append(o.objectOutputs, paths...)
+	}
+}
+
+// WithOutputNaming sets the naming convention of the outputs generated for
+// response paths.
+func WithOutputNaming(n OutputNaming) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.outputNaming = n
+	}
+}
+
 // WithLoadResult sets multiple options from a ResourceLoadResult.
 func WithLoadResult(result *ResourceLoadResult) GeneratorOption {
 	return func(o *generatorOptions) {
@@ -67,10 +384,7 @@ func WithLoadResult(result *ResourceLoadResult) GeneratorOption {
 
 // InterfaceCapabilities represents which AVM interface scaffolding should be generated.
 type InterfaceCapabilities struct {
-	SupportsPrivateEndpoints   bool
-	SupportsDiagnostics        bool
-	SupportsCustomerManagedKey bool
-	SupportsManagedIdentity    bool
+	SupportsManagedIdentity bool
 }
 
 // Generate generates variables.tf, locals.tf, main.tf, and outputs.tf based on the schema.
@@ -88,50 +402,70 @@ func Generate(resourceType string, opts ...GeneratorOption) error {
 }
 
 func generateWithOpts(o *generatorOptions) error {
-	hasSchema := o.schema != nil
-	supportsIdentity := SupportsIdentity(o.schema)
-	supportsTags := SupportsTags(o.schema)
-	supportsLocation := SupportsLocation(o.schema)
-	hasDiscriminator := schema.HasDiscriminator(o.schema)
-
-	// Build interface capabilities from schema
-	caps := InterfaceCapabilities{
-		SupportsManagedIdentity: supportsIdentity,
+	mod, err := buildModule(o)
+	if err != nil {
+		return err
 	}
 
-	// Collect secret fields from schema
-	var secrets []secretField
-	if hasSchema {
-		secrets = collectSecretFields(o.schema)
+	defer o.timings.Track(profile.PhaseWrite)()
+	w := o.writer
+	toDir := w == nil
+	var moves []hclgen.Move
+	if toDir {
+		w = hclgen.JournalWriter{Dir: o.outputDir, Journal: o.journal}
+		moves, err = resourceMovesForRegeneration(o.outputDir, mod.Main)
+		if err != nil {
+			return err
+		}
 	}
 
-	if err := generateTerraform(o.outputDir); err != nil {
-		return err
+	files := []struct {
+		name string
+		file *hclwrite.File
+	}{
+		{"terraform.tf", mod.Terraform},
+		{"variables.tf", mod.Variables},
+		{"locals.tf", mod.Locals},
+		{"main.tf", mod.Main},
+		{"outputs.tf", mod.Outputs},
+		{telemetryFileName, mod.Telemetry},
 	}
-	if err := generateVariables(o.schema, supportsTags, supportsLocation, supportsIdentity, secrets, caps, o.moduleNamePrefix, o.outputDir); err != nil {
-		return err
+	var variables []moduleVariable
+	for _, f := range files {
+		if f.file == nil {
+			continue
+		}
+		if err := o.cancelled(); err != nil {
+			return err
+		}
+		if err := w.WriteFile(f.name, f.file.Bytes()); err != nil {
+			return err
+		}
+		if !toDir {
+			declared, err := parseModuleVariables(f.name, f.file.Bytes())
+			if err != nil {
+				return err
+			}
+			variables = append(variables, declared...)
+		}
 	}
-	if hasSchema {
-		if err := generateLocals(o.schema, o.localName, supportsIdentity, secrets, o.resourceType, caps, o.moduleNamePrefix, o.outputDir); err != nil {
+	if mod.Interface != nil {
+		if err := writeModuleInterface(w, mod.Interface); err != nil {
 			return err
 		}
 	}
-	if err := generateMain(o.schema, o.resourceType, o.apiVersion, o.localName, supportsTags, supportsLocation, supportsIdentity, hasSchema, hasDiscriminator, secrets, o.outputDir); err != nil {
-		return err
+	if mod.MappingReport != nil {
+		if err := w.WriteFile(MappingReportFileName, mod.MappingReport); err != nil {
+			return err
+		}
 	}
-	if err := generateOutputs(o.schema, o.outputDir); err != nil {
-		return err
+	if !toDir {
+		return w.WriteFile(TFVarsExampleFileName, buildTFVarsExample(variables, nil))
 	}
-	return nil
-}
-
-// GenerateInterfacesFile generates main.interfaces.tf with AVM interfaces module wiring.
-// This function can be called separately to opt-in to AVM interfaces scaffolding.
-func GenerateInterfacesFile(resourceType string, rs *schema.ResourceSchema, outputDir string) error {
-	caps := InterfaceCapabilities{
-		SupportsManagedIdentity: rs != nil && rs.SupportsIdentity,
+	if err := hclgen.AppendMovedBlocks(o.outputDir, moves, o.journal); err != nil {
+		return err
 	}
-	return generateInterfaces(caps, outputDir)
+	return WriteTFVarsExample(o.outputDir, o.journal)
 }
 
 // SupportsIdentity reports whether the schema supports configuring managed identity.
@@ -157,6 +491,13 @@ type GeneratedModule struct {
 	Locals    *hclwrite.File
 	Main      *hclwrite.File
 	Outputs   *hclwrite.File
+	// Telemetry is set when the AVM telemetry resources are requested.
+	Telemetry *hclwrite.File
+	// Interface is set when the module interface manifest is requested.
+	Interface *ModuleInterface
+	// MappingReport is set by backends that cannot map every variable onto the
+	// resource, listing what is left to map by hand.
+	MappingReport []byte
 }
 
 // GenerateInMemory runs the generation pipeline and returns all files in memory
@@ -172,12 +513,91 @@ func GenerateInMemory(resourceType string, opts ...GeneratorOption) (*GeneratedM
 		opt(o)
 	}
 
+	return buildModule(o)
+}
+
+// buildModule runs the generation pipeline of the selected backend.
+func buildModule(o *generatorOptions) (*GeneratedModule, error) {
+	if err := o.cancelled(); err != nil {
+		return nil, err
+	}
+	blocklist := o.propertyBlocklist
+	if blocklist == nil {
+		blocklist = DefaultPropertyBlocklist
+	}
+	o.schema = withPropertyBlocklist(o.schema, blocklist)
+
+	backend := o.backend
+	if backend == nil {
+		backend = AzAPIBackend
+	}
+	mod, err := backend.build(o)
+	if err != nil || o.templatesDir == "" {
+		return mod, err
+	}
+	data := TemplateData{ResourceType: o.resourceType, APIVersion: o.apiVersion, Telemetry: mod.Telemetry != nil}
+	if err := applyTemplates(o.templatesDir, mod, data); err != nil {
+		return nil, err
+	}
+	return mod, nil
+}
+
+// cancelled returns the error of the generation context once it is cancelled.
+func (o *generatorOptions) cancelled() error {
+	if o.ctx == nil {
+		return nil
+	}
+	return o.ctx.Err()
+}
+
+// buildAzAPIModule runs the generation pipeline of the azapi backend.
+func buildAzAPIModule(o *generatorOptions) (*GeneratedModule, error) {
+	if o.schema.IsReadOnlyResource() && !o.features.updateResource {
+		return nil, fmt.Errorf("%w: resource type %s@%s has no PUT operation (readable at scope(s): %s); use update-resource mode to generate an azapi_update_resource that modifies existing instances",
+			schema.ErrUnsupportedConstruct, o.resourceType, o.apiVersion, strings.Join(schema.ScopeNames(o.schema.ReadableScopes), ", "))
+	}
+	if o.features.updateResource && len(o.postCreate) > 0 {
+		return nil, fmt.Errorf("post-create properties cannot be combined with azapi_update_resource generation")
+	}
+
+	// Fill in name constraints the spec lacks from the embedded naming rules.
+	o.schema = withNamingRule(o.schema, o.resourceType)
+
 	hasSchema := o.schema != nil
+	if o.features.namingVariable && !hasSchema {
+		return nil, fmt.Errorf("the naming variable requires a resource schema")
+	}
+	if singleton, ok := o.schema.SingletonName(); ok && o.features.namingVariable {
+		return nil, fmt.Errorf("resource type %s always has the name %q; it cannot be generated with the naming variable", o.resourceType, singleton)
+	}
+	if o.features.keysOutput && len(keysActions(o.schema)) == 0 {
+		return nil, fmt.Errorf("keys output requires a listKeys or listConnectionStrings action; %s has none", o.resourceType)
+	}
 	supportsIdentity := SupportsIdentity(o.schema)
 	supportsTags := SupportsTags(o.schema)
 	supportsLocation := SupportsLocation(o.schema)
 	hasDiscriminator := schema.HasDiscriminator(o.schema)
 
+	// azapi_update_resource has no location, tags or identity arguments, and no
+	// schema_validation_enabled; such properties travel in the body instead.
+	if o.features.updateResource {
+		supportsIdentity, supportsTags, supportsLocation = false, false, false
+		o.features.schemaValidationVariable = false
+	}
+
+	if o.features.avmStrict {
+		o.features.resourceOutput = true
+		o.features.telemetry = true
+	}
+
+	parent := resolveParentScope(o.schema, o.resourceType, o.features.scopeResource)
+	if o.features.parentIDComponents {
+		var err error
+		if parent, err = parent.withComponents(o.schema, o.resourceType); err != nil {
+			return nil, err
+		}
+	}
+
 	caps := InterfaceCapabilities{
 		SupportsManagedIdentity: supportsIdentity,
 	}
@@ -187,25 +607,109 @@ func GenerateInMemory(resourceType string, opts ...GeneratorOption) (*GeneratedM
 		secrets = collectSecretFields(o.schema)
 	}
 
+	// ignore_changes cannot address paths inside a JSON string body, so the
+	// built-in defaults are skipped and configured paths are rejected.
+	var ignoreChanges []string
+	var err error
+	if o.features.bodyFormat == BodyFormatJSON {
+		if len(o.ignoreChanges) > 0 {
+			return nil, fmt.Errorf("ignore_changes paths require the %q body format", BodyFormatHCL)
+		}
+	} else {
+		ignoreChanges, err = resolveIgnoreChanges(o.schema, o.ignoreChanges)
+		if err != nil {
+			return nil, err
+		}
+	}
+	preconditions, err := resolvePreconditions(o.schema, o.preconditions, secrets, o.moduleNamePrefix)
+	if err != nil {
+		return nil, err
+	}
+	exclusive, err := resolveExclusiveRules(o.schema, o.exclusive, secrets, o.moduleNamePrefix)
+	if err != nil {
+		return nil, err
+	}
+	preconditions = append(preconditions, exclusive...)
+	postCreate, err := resolvePostCreateProperties(o.schema, o.postCreate, secrets)
+	if err != nil {
+		return nil, err
+	}
+	postCreateVars := postCreateVariableNames(postCreate, o.moduleNamePrefix)
+	objectOutputs, err := resolveObjectOutputs(o.schema, o.objectOutputs)
+	if err != nil {
+		return nil, err
+	}
+
+	exportPaths := o.exportPaths
+	if exportPaths == nil {
+		exportPaths = extractComputedPaths(o.schema)
+		if supportsIdentity {
+			exportPaths = withIdentityExportPaths(exportPaths)
+		}
+	}
+	endpointSuffixes := o.endpointSuffixes
+	if endpointSuffixes == nil {
+		endpointSuffixes = DefaultEndpointSuffixes
+	}
+	exportPaths = mergeExportPaths(exportPaths, extractEndpointPaths(o.schema, endpointSuffixes))
+	exportPaths = withObjectOutputs(exportPaths, objectOutputs)
+
+	done := o.timings.Track(profile.PhaseOutputs)
 	mod := &GeneratedModule{
-		Terraform: buildTerraform(),
-		Outputs:   buildOutputs(o.schema),
+		Terraform: buildTerraform(o.features, o.versions),
+		Outputs:   buildOutputs(o.schema, supportsIdentity, o.features, exportPaths, o.outputNaming),
 	}
+	done()
 
-	var err error
-	mod.Variables, err = buildVariables(o.schema, supportsTags, supportsLocation, supportsIdentity, secrets, caps, o.moduleNamePrefix)
+	done = o.timings.Track(profile.PhaseVariables)
+	mod.Variables, err = buildVariables(o.schema, o.resourceType, supportsTags, supportsLocation, supportsIdentity, hasDiscriminator, o.features, parent, secrets, caps, o.moduleNamePrefix)
+	done()
 	if err != nil {
 		return nil, fmt.Errorf("building variables: %w", err)
 	}
+	if o.features.preconditionValidations {
+		preconditions = appendPreconditionValidations(mod.Variables, preconditions)
+	}
 
 	if hasSchema {
-		mod.Locals, err = buildLocals(o.schema, o.localName, supportsIdentity, secrets, o.resourceType, caps, o.moduleNamePrefix)
+		done = o.timings.Track(profile.PhaseLocals)
+		mod.Locals, err = buildLocals(o.schema, o.localName, supportsIdentity, o.features, secrets, postCreate, o.resourceType, caps, o.moduleNamePrefix)
+		done()
 		if err != nil {
 			return nil, fmt.Errorf("building locals: %w", err)
 		}
 	}
 
-	mod.Main = buildMain(o.schema, o.resourceType, o.apiVersion, o.localName, supportsTags, supportsLocation, supportsIdentity, hasSchema, hasDiscriminator, secrets)
+	if o.features.telemetry {
+		mod.Telemetry = buildTelemetry(supportsLocation)
+	}
+
+	done = o.timings.Track(profile.PhaseMain)
+	mod.Main = buildMain(o.schema, o.resourceType, o.apiVersion, o.localName, supportsTags, supportsLocation, supportsIdentity, hasSchema, hasDiscriminator, o.features, parent, secrets, exportPaths, ignoreChanges, preconditions, postCreateVars)
+	done()
+
+	if o.features.avmStrict {
+		deviations, err := avmDeviations(mod, o.features, supportsLocation, supportsTags)
+		if err != nil {
+			return nil, err
+		}
+		if len(deviations) > 0 {
+			return nil, avmStrictError(deviations)
+		}
+	}
+
+	if o.features.moduleInterface {
+		exports := exportedOutputs(o.schema, supportsIdentity, exportPaths, o.outputNaming)
+		var kind schema.ResourceKind
+		if o.schema != nil {
+			kind = o.schema.Kind()
+		}
+		mod.Interface, err = buildModuleInterface(o.resourceType, o.apiVersion, kind, mod.Variables, mod.Outputs,
+			variableSourcePaths(o.schema, o.resourceType, supportsIdentity, secrets, o.moduleNamePrefix), outputSourcePaths(exports, supportsIdentity))
+		if err != nil {
+			return nil, fmt.Errorf("building module interface: %w", err)
+		}
+	}
 
 	return mod, nil
 }
@@ -2,18 +2,24 @@ package terraform
 
 import (
 	"bytes"
+	"context"
+	"maps"
 	"os"
+	"slices"
 	"strings"
 	"testing"
 
+	"github.com/Azure/bicep-types/src/bicep-types-go/types"
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/hclsyntax"
 	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/matt-FFFFFF/tfmodmake/hclgen"
 	"github.com/matt-FFFFFF/tfmodmake/naming"
 	"github.com/matt-FFFFFF/tfmodmake/schema"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/zclconf/go-cty/cty"
+	"golang.org/x/sync/errgroup"
 )
 
 func TestToSnakeCase(t *testing.T) {
@@ -125,6 +131,95 @@ func TestGenerate(t *testing.T) {
 	assert.Equal(t, "try(azapi_resource.this.output.properties.readOnlyProp, null)", expressionString(t, readOnlyOutput.Body.Attributes["value"].Expr))
 }
 
+func TestGenerate_WithWriter(t *testing.T) {
+	outputDir := t.TempDir()
+	rs := &schema.ResourceSchema{
+		Properties: map[string]*schema.Property{
+			"properties": {Name: "properties", Type: schema.TypeObject, Children: map[string]*schema.Property{
+				"sku": {Name: "sku", Type: schema.TypeString, Required: true},
+			}},
+		},
+	}
+
+	files := hclgen.MemoryWriter{}
+	require.NoError(t, Generate("Microsoft.Test/widgets", WithResourceSchema(rs), WithAPIVersion("2024-01-01"), WithOutputDir(outputDir), WithWriter(files), WithModuleInterface(true)))
+
+	assert.ElementsMatch(t, []string{"terraform.tf", "variables.tf", "locals.tf", "main.tf", "outputs.tf", ModuleInterfaceFileName, TFVarsExampleFileName}, slices.Collect(maps.Keys(files)))
+	mainBody := parseHCLSource(t, "main.tf", files["main.tf"])
+	requireBlock(t, mainBody, "resource", "azapi_resource", "this")
+	assert.Contains(t, string(files[TFVarsExampleFileName]), "sku =")
+
+	entries, err := os.ReadDir(outputDir)
+	require.NoError(t, err)
+	assert.Empty(t, entries, "nothing is written to the output directory")
+}
+
+func TestGenerate_WithJournal(t *testing.T) {
+	rs := &schema.ResourceSchema{
+		Properties: map[string]*schema.Property{
+			"properties": {Name: "properties", Type: schema.TypeObject, Children: map[string]*schema.Property{
+				"sku": {Name: "sku", Type: schema.TypeString},
+			}},
+		},
+	}
+
+	// Concurrent generations each record only their own files.
+	dirs := []string{t.TempDir(), t.TempDir()}
+	journals := []*hclgen.Journal{hclgen.NewJournal(), hclgen.NewJournal()}
+	var g errgroup.Group
+	for i := range dirs {
+		g.Go(func() error {
+			return Generate("Microsoft.Test/widgets", WithResourceSchema(rs), WithAPIVersion("2024-01-01"), WithOutputDir(dirs[i]), WithJournal(journals[i]))
+		})
+	}
+	require.NoError(t, g.Wait())
+
+	want := []string{"locals.tf", "main.tf", "outputs.tf", "terraform.tf", TFVarsExampleFileName, "variables.tf"}
+	for i, dir := range dirs {
+		assert.Equal(t, want, journals[i].Touched(dir))
+		assert.Empty(t, journals[i].Touched(dirs[1-i]))
+	}
+}
+
+// cancellingWriter cancels its context once it has received a file.
+type cancellingWriter struct {
+	hclgen.MemoryWriter
+	cancel context.CancelFunc
+}
+
+func (w cancellingWriter) WriteFile(name string, content []byte) error {
+	w.cancel()
+	return w.MemoryWriter.WriteFile(name, content)
+}
+
+func TestGenerate_WithContext(t *testing.T) {
+	rs := &schema.ResourceSchema{
+		Properties: map[string]*schema.Property{
+			"properties": {Name: "properties", Type: schema.TypeObject, Children: map[string]*schema.Property{
+				"sku": {Name: "sku", Type: schema.TypeString},
+			}},
+		},
+	}
+
+	t.Run("cancelled before generation", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		files := hclgen.MemoryWriter{}
+		err := Generate("Microsoft.Test/widgets", WithResourceSchema(rs), WithWriter(files), WithContext(ctx))
+		require.ErrorIs(t, err, context.Canceled)
+		assert.Empty(t, files)
+	})
+
+	t.Run("cancelled while writing", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		w := cancellingWriter{MemoryWriter: hclgen.MemoryWriter{}, cancel: cancel}
+		err := Generate("Microsoft.Test/widgets", WithResourceSchema(rs), WithWriter(w), WithContext(ctx))
+		require.ErrorIs(t, err, context.Canceled)
+		assert.Equal(t, []string{"terraform.tf"}, slices.Collect(maps.Keys(w.MemoryWriter)), "no file is written after the cancellation")
+	})
+}
+
 func TestGenerate_NestedObjectValidations(t *testing.T) {
 	tmpDir := t.TempDir()
 
@@ -315,6 +410,7 @@ func TestGenerate_FailsOnFlattenedPropertiesNameCollision(t *testing.T) {
 	err = Generate("testResource", WithResourceSchema(rs), WithLocalName("resource_body"), WithAPIVersion("2025-01-01"))
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "collision")
+	assert.ErrorIs(t, err, ErrNameCollision)
 }
 
 func TestGenerate_DoesNotDuplicateSecretVarsFromFlattenedProperties(t *testing.T) {
@@ -615,7 +711,7 @@ func TestConstructValue_MapAdditionalPropertiesObject(t *testing.T) {
 		{Type: hclsyntax.TokenDot, Bytes: []byte(".")},
 		{Type: hclsyntax.TokenIdent, Bytes: []byte("kube_dns_overrides")},
 	}
-	tokens, err := constructValue(prop, accessPath, false, nil, "", false, "")
+	tokens, err := constructValue(prop, accessPath, false, nil, nil, "", false, "")
 	require.NoError(t, err)
 
 	f := hclwrite.NewEmptyFile()
@@ -890,7 +986,7 @@ func TestGenerate_ResponseExportValues(t *testing.T) {
 	// Should contain the readOnly fields
 	assert.Contains(t, exprStr, "properties.defaultDomain")
 	assert.Contains(t, exprStr, "properties.staticIp")
-	assert.Contains(t, exprStr, "properties.provisioningState")
+	assert.NotContains(t, exprStr, "provisioningState", "provisioningState is in DefaultPropertyBlocklist")
 	assert.Contains(t, exprStr, "identity.principalId")
 	assert.Contains(t, exprStr, "identity.tenantId")
 
@@ -1024,6 +1120,222 @@ func TestGenerate_ResponseExportValuesEmptyWhenNoReadOnly(t *testing.T) {
 	assert.NotContains(t, mainContent, "Trim response_export_values")
 }
 
+func TestGenerate_SchemaValidationVariable(t *testing.T) {
+	tests := []struct {
+		name          string
+		discriminator string
+		wantDefault   bool
+	}{
+		{name: "plain body defaults to true", wantDefault: true},
+		{name: "discriminated body defaults to false", discriminator: "kind", wantDefault: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+
+			originalWd, err := os.Getwd()
+			require.NoError(t, err)
+			defer os.Chdir(originalWd)
+
+			require.NoError(t, os.Chdir(tmpDir))
+
+			rs := &schema.ResourceSchema{
+				Properties: map[string]*schema.Property{
+					"properties": {Name: "properties", Type: schema.TypeObject, Children: map[string]*schema.Property{
+						"settings": {Name: "settings", Type: schema.TypeObject, Discriminator: tc.discriminator, Children: map[string]*schema.Property{
+							"kind": {Name: "kind", Type: schema.TypeString},
+						}},
+					}},
+				},
+			}
+
+			err = Generate("Microsoft.Test/widgets", WithResourceSchema(rs), WithAPIVersion("2025-01-01"), WithSchemaValidationVariable(true))
+			require.NoError(t, err)
+
+			mainBody := parseHCLBody(t, "main.tf")
+			resource := requireBlock(t, mainBody, "resource", "azapi_resource", "this")
+			attr := resource.Body.Attributes["schema_validation_enabled"]
+			require.NotNil(t, attr)
+			assert.Equal(t, "var.schema_validation_enabled", expressionString(t, attr.Expr))
+
+			varsBody := parseHCLBody(t, "variables.tf")
+			variable := requireBlock(t, varsBody, "variable", "schema_validation_enabled")
+			defaultAttr := variable.Body.Attributes["default"]
+			require.NotNil(t, defaultAttr)
+			val, diags := defaultAttr.Expr.Value(nil)
+			require.False(t, diags.HasErrors())
+			assert.Equal(t, tc.wantDefault, val.True())
+		})
+	}
+}
+
+func TestGenerate_NoSchemaValidationVariableByDefault(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+
+	require.NoError(t, os.Chdir(tmpDir))
+
+	rs := &schema.ResourceSchema{
+		Properties: map[string]*schema.Property{
+			"properties": {Name: "properties", Type: schema.TypeObject, Children: map[string]*schema.Property{
+				"sku": {Name: "sku", Type: schema.TypeString},
+			}},
+		},
+	}
+
+	require.NoError(t, Generate("Microsoft.Test/widgets", WithResourceSchema(rs), WithAPIVersion("2025-01-01")))
+
+	varsBody := parseHCLBody(t, "variables.tf")
+	assert.Nil(t, findBlock(varsBody, "variable", "schema_validation_enabled"))
+
+	mainBody := parseHCLBody(t, "main.tf")
+	resource := requireBlock(t, mainBody, "resource", "azapi_resource", "this")
+	assert.Nil(t, resource.Body.Attributes["schema_validation_enabled"])
+}
+
+func TestGenerate_LockResourceIDsVariable(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+
+	require.NoError(t, os.Chdir(tmpDir))
+
+	rs := &schema.ResourceSchema{
+		Properties: map[string]*schema.Property{
+			"properties": {Name: "properties", Type: schema.TypeObject, Children: map[string]*schema.Property{
+				"addressPrefix": {Name: "addressPrefix", Type: schema.TypeString},
+			}},
+		},
+	}
+
+	require.NoError(t, Generate("Microsoft.Network/virtualNetworks/subnets", WithResourceSchema(rs), WithAPIVersion("2025-01-01"), WithLockResourceIDsVariable(true)))
+
+	mainBody := parseHCLBody(t, "main.tf")
+	resource := requireBlock(t, mainBody, "resource", "azapi_resource", "this")
+	attr := resource.Body.Attributes["locks"]
+	require.NotNil(t, attr)
+	assert.Equal(t, "var.lock_resource_ids", expressionString(t, attr.Expr))
+
+	varsBody := parseHCLBody(t, "variables.tf")
+	variable := requireBlock(t, varsBody, "variable", "lock_resource_ids")
+	assert.Equal(t, "list(string)", expressionString(t, variable.Body.Attributes["type"].Expr))
+	assert.Equal(t, "[]", expressionString(t, variable.Body.Attributes["default"].Expr))
+}
+
+func TestGenerate_APIVersionVariable(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+
+	require.NoError(t, os.Chdir(tmpDir))
+
+	rs := &schema.ResourceSchema{
+		Properties: map[string]*schema.Property{
+			"properties": {Name: "properties", Type: schema.TypeObject, Children: map[string]*schema.Property{
+				"addressPrefix": {Name: "addressPrefix", Type: schema.TypeString},
+			}},
+		},
+	}
+
+	require.NoError(t, Generate("Microsoft.Network/virtualNetworks/subnets", WithResourceSchema(rs), WithAPIVersion("2025-01-01"), WithAPIVersionVariable("2025-01-01", "2024-05-01", "2025-01-01")))
+
+	mainBody := parseHCLBody(t, "main.tf")
+	resource := requireBlock(t, mainBody, "resource", "azapi_resource", "this")
+	assert.Equal(t, `"Microsoft.Network/virtualNetworks/subnets@${var.api_version}"`, expressionString(t, resource.Body.Attributes["type"].Expr))
+
+	varsBody := parseHCLBody(t, "variables.tf")
+	variable := requireBlock(t, varsBody, "variable", "api_version")
+	assert.Equal(t, "string", expressionString(t, variable.Body.Attributes["type"].Expr))
+	assert.Equal(t, `"2025-01-01"`, expressionString(t, variable.Body.Attributes["default"].Expr))
+	validation := requireBlock(t, variable.Body, "validation")
+	assert.Equal(t, `contains(["2024-05-01", "2025-01-01"], var.api_version)`, expressionString(t, validation.Body.Attributes["condition"].Expr))
+}
+
+func TestGenerate_APIVersionVariableRejectedByOtherBackends(t *testing.T) {
+	rs := &schema.ResourceSchema{Properties: map[string]*schema.Property{}}
+	err := Generate("Microsoft.Storage/storageAccounts", WithResourceSchema(rs), WithAPIVersion("2025-01-01"), WithAPIVersionVariable("2024-01-01", "2025-01-01"), WithBackend(AzureRMBackend), WithWriter(hclgen.MemoryWriter{}))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "-api-versions")
+}
+
+func TestGenerate_ReadOnlyResourceRequiresUpdateResource(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+
+	require.NoError(t, os.Chdir(tmpDir))
+
+	rs := &schema.ResourceSchema{
+		ReadableScopes: types.ScopeTypeResourceGroup,
+		Properties: map[string]*schema.Property{
+			"properties": {Name: "properties", Type: schema.TypeObject, Children: map[string]*schema.Property{
+				"enabled": {Name: "enabled", Type: schema.TypeBoolean},
+				"state":   {Name: "state", Type: schema.TypeString, ReadOnly: true},
+			}},
+		},
+	}
+
+	err = Generate("Microsoft.Test/settings", WithResourceSchema(rs), WithAPIVersion("2025-01-01"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "has no PUT operation")
+	assert.Contains(t, err.Error(), "resourceGroup")
+	assert.ErrorIs(t, err, schema.ErrUnsupportedConstruct)
+
+	require.NoError(t, Generate("Microsoft.Test/settings", WithResourceSchema(rs), WithAPIVersion("2025-01-01"), WithUpdateResource(true)))
+
+	mainBody := parseHCLBody(t, "main.tf")
+	assert.Nil(t, findBlock(mainBody, "resource", "azapi_resource", "this"))
+	update := requireBlock(t, mainBody, "resource", "azapi_update_resource", "this")
+	assert.Equal(t, "local.resource_body", expressionString(t, update.Body.Attributes["body"].Expr))
+	assert.Nil(t, update.Body.Attributes["location"])
+
+	outputsBody := parseHCLBody(t, "outputs.tf")
+	idOutput := requireBlock(t, outputsBody, "output", "resource_id")
+	assert.Equal(t, "azapi_update_resource.this.id", expressionString(t, idOutput.Body.Attributes["value"].Expr))
+	stateOutput := requireBlock(t, outputsBody, "output", "state")
+	assert.Equal(t, "try(azapi_update_resource.this.output.properties.state, null)", expressionString(t, stateOutput.Body.Attributes["value"].Expr))
+}
+
+func TestGenerate_ExtensionResourceUsesScope(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+
+	require.NoError(t, os.Chdir(tmpDir))
+
+	rs := &schema.ResourceSchema{
+		ReadableScopes: types.ScopeTypeExtension,
+		WritableScopes: types.ScopeTypeExtension,
+		Properties: map[string]*schema.Property{
+			"properties": {Name: "properties", Type: schema.TypeObject, Children: map[string]*schema.Property{
+				"level": {Name: "level", Type: schema.TypeString, Required: true},
+			}},
+		},
+	}
+
+	require.NoError(t, Generate("Microsoft.Authorization/locks", WithResourceSchema(rs), WithAPIVersion("2020-05-01")))
+
+	varsBody := parseHCLBody(t, "variables.tf")
+	requireBlock(t, varsBody, "variable", "scope")
+	assert.Nil(t, findBlock(varsBody, "variable", "parent_id"))
+
+	mainBody := parseHCLBody(t, "main.tf")
+	resource := requireBlock(t, mainBody, "resource", "azapi_resource", "this")
+	assert.Equal(t, "var.scope", expressionString(t, resource.Body.Attributes["parent_id"].Expr))
+}
+
 // Helper functions
 
 func parseHCLBody(t *testing.T, path string) *hclsyntax.Body {
@@ -1031,6 +1343,13 @@ func parseHCLBody(t *testing.T, path string) *hclsyntax.Body {
 
 	data, err := os.ReadFile(path)
 	require.NoError(t, err)
+	return parseHCLSource(t, path, data)
+}
+
+// parseHCLSource parses generated HCL held in memory, e.g. by an hclgen.MemoryWriter.
+func parseHCLSource(t *testing.T, path string, data []byte) *hclsyntax.Body {
+	t.Helper()
+	require.NotNil(t, data, "%s was not generated", path)
 
 	file, diags := hclsyntax.ParseConfig(data, path, hcl.InitialPos)
 	require.False(t, diags.HasErrors(), diags.Error())
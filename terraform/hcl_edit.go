@@ -139,6 +139,9 @@ func UpdateResponseExportValues(file *hclwrite.File, paths []string) error {
 
 // tokensForStringList creates HCL tokens for a list of string literals: ["a", "b", "c"]
 func tokensForStringList(values []string) hclwrite.Tokens {
+	if len(values) == 0 {
+		return hclwrite.TokensForValue(cty.ListValEmpty(cty.String))
+	}
 	ctyVals := make([]cty.Value, len(values))
 	for i, v := range values {
 		ctyVals[i] = cty.StringVal(v)
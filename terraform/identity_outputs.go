@@ -0,0 +1,59 @@
+package terraform
+
+import (
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// identityExportPaths are the response paths backing the managed identity outputs.
+var identityExportPaths = []string{
+	"identity.principalId",
+	"identity.tenantId",
+	"identity.userAssignedIdentities",
+}
+
+// withIdentityExportPaths adds the identity response paths to exportPaths.
+func withIdentityExportPaths(exportPaths []string) []string {
+	return mergeExportPaths(exportPaths, identityExportPaths)
+}
+
+// isIdentityExportPath reports whether exportPath is covered by the identity outputs.
+func isIdentityExportPath(exportPath string) bool {
+	return strings.HasPrefix(exportPath, "identity.")
+}
+
+// appendIdentityOutputs appends the AVM managed identity outputs.
+func appendIdentityOutputs(body *hclwrite.Body, blockType string, format BodyFormat) {
+	outputs := []struct {
+		name, description string
+		path              []string
+		fallback          hclwrite.Tokens
+	}{
+		{
+			name:        "system_assigned_mi_principal_id",
+			description: "The principal ID of the system-assigned managed identity, or null when it is not enabled.",
+			path:        []string{"identity", "principalId"},
+			fallback:    hclwrite.TokensForIdentifier("null"),
+		},
+		{
+			name:        "system_assigned_mi_tenant_id",
+			description: "The tenant ID of the system-assigned managed identity, or null when it is not enabled.",
+			path:        []string{"identity", "tenantId"},
+			fallback:    hclwrite.TokensForIdentifier("null"),
+		},
+		{
+			name:        "user_assigned_identities",
+			description: "The user-assigned managed identities, keyed by resource ID, each with its principalId and clientId.",
+			path:        []string{"identity", "userAssignedIdentities"},
+			fallback:    hclwrite.TokensForValue(cty.EmptyObjectVal),
+		},
+	}
+	for _, o := range outputs {
+		outBody := body.AppendNewBlock("output", []string{o.name}).Body()
+		outBody.SetAttributeValue("description", cty.StringVal(o.description))
+		outBody.SetAttributeRaw("value", hclwrite.TokensForFunctionCall("try", tokensForOutputPath(blockType, format, o.path), o.fallback))
+		body.AppendNewline()
+	}
+}
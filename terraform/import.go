@@ -0,0 +1,203 @@
+package terraform
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/matt-FFFFFF/tfmodmake/hclgen"
+	"github.com/matt-FFFFFF/tfmodmake/naming"
+	"github.com/matt-FFFFFF/tfmodmake/schema"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// ImportFileName is the file import blocks are written to.
+const ImportFileName = "imports.tf"
+
+// ImportTarget adopts the existing Azure resource ID into the Terraform address Address.
+type ImportTarget struct {
+	Address string
+	ID      string
+}
+
+// ResourceAddress is the address of the module's own resource.
+const ResourceAddress = "azapi_resource.this"
+
+// SubmoduleResourceAddress returns the address of the resource of one instance of a
+// child submodule wired with for_each, e.g. module.storages["logs"].azapi_resource.this.
+func SubmoduleResourceAddress(moduleName, key string) string {
+	return fmt.Sprintf("module.%s[%q].%s", moduleName, key, ResourceAddress)
+}
+
+// GenerateImportFile writes imports.tf with an import block per target, and
+// records it in journal, which may be nil.
+func GenerateImportFile(outputDir string, targets []ImportTarget, journal *hclgen.Journal) error {
+	file := hclwrite.NewEmptyFile()
+	body := file.Body()
+	for i, target := range targets {
+		if strings.TrimSpace(target.ID) == "" {
+			return fmt.Errorf("import target %s has no resource ID", target.Address)
+		}
+		to, err := tokensForAddress(target.Address)
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			body.AppendNewline()
+		}
+		importBody := body.AppendNewBlock("import", nil).Body()
+		importBody.SetAttributeRaw("to", to)
+		importBody.SetAttributeValue("id", cty.StringVal(target.ID))
+	}
+	return journal.WriteFile(filepath.Join(outputDir, ImportFileName), file)
+}
+
+// tokensForAddress parses a resource address into expression tokens.
+func tokensForAddress(address string) (hclwrite.Tokens, error) {
+	file, diags := hclwrite.ParseConfig([]byte("to = "+address+"\n"), "address", hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("invalid resource address %q: %s", address, diags.Error())
+	}
+	return file.Body().GetAttribute("to").Expr().BuildTokens(nil), nil
+}
+
+// ImportVariableValues maps a GET response of an existing resource onto the input
+// variables of the module generated for rs, so the adopted resource plans without
+// changes. Read-only properties, secrets (which GET does not return) and unknown
+// fields are omitted.
+func ImportVariableValues(rs *schema.ResourceSchema, resourceType, resourceID string, response []byte) (map[string]any, error) {
+	if rs == nil {
+		return nil, fmt.Errorf("importing variable values requires a resource schema")
+	}
+
+	var resource map[string]any
+	if err := json.Unmarshal(response, &resource); err != nil {
+		return nil, fmt.Errorf("parsing resource response: %w", err)
+	}
+
+	values := make(map[string]any)
+	if name, ok := resource["name"]; ok {
+		values["name"] = name
+	}
+	if parentVar := resolveParentScope(rs, resourceType, false).variableName(); parentVar != "" {
+		parentID, err := parentIDFromResourceID(resourceID)
+		if err != nil {
+			return nil, err
+		}
+		values[parentVar] = parentID
+	}
+	if location, ok := resource["location"]; ok {
+		values["location"] = location
+	}
+	if SupportsIdentity(rs) {
+		if identity, ok := resource["identity"].(map[string]any); ok {
+			values["managed_identities"] = managedIdentitiesFromResponse(identity)
+		}
+	}
+
+	secretPaths := newSecretPathSet(collectSecretFields(rs))
+	for name, prop := range rs.Properties {
+		if prop == nil || !isWritableProperty(prop) {
+			continue
+		}
+		switch name {
+		case "name", "location", "identity":
+			continue
+		case "properties":
+			bag, _ := resource["properties"].(map[string]any)
+			for childName, child := range prop.Children {
+				value, ok := bag[childName]
+				if !ok || child == nil || !isWritableProperty(child) {
+					continue
+				}
+				if _, secret := secretPaths["properties."+childName]; secret {
+					continue
+				}
+				values[naming.ToSnakeCase(childName)] = variableValue(child, value)
+			}
+		default:
+			if value, ok := resource[name]; ok {
+				values[naming.ToSnakeCase(name)] = variableValue(prop, value)
+			}
+		}
+	}
+	return values, nil
+}
+
+// variableValue converts an API value into the shape of the variable generated for
+// prop: object attributes become snake_case and read-only attributes are dropped.
+func variableValue(prop *schema.Property, value any) any {
+	if prop == nil || value == nil {
+		return value
+	}
+	switch prop.Type {
+	case schema.TypeObject:
+		obj, ok := value.(map[string]any)
+		if !ok {
+			return value
+		}
+		if len(prop.Children) == 0 {
+			if prop.AdditionalProperties == nil {
+				return value
+			}
+			out := make(map[string]any, len(obj))
+			for k, v := range obj {
+				out[k] = variableValue(prop.AdditionalProperties, v)
+			}
+			return out
+		}
+		out := make(map[string]any)
+		for k, child := range prop.Children {
+			v, ok := obj[k]
+			if !ok || child == nil || !isWritableProperty(child) {
+				continue
+			}
+			out[naming.ToSnakeCase(k)] = variableValue(child, v)
+		}
+		return out
+	case schema.TypeArray:
+		items, ok := value.([]any)
+		if !ok || prop.ItemType == nil {
+			return value
+		}
+		out := make([]any, len(items))
+		for i, item := range items {
+			out[i] = variableValue(prop.ItemType, item)
+		}
+		return out
+	}
+	return value
+}
+
+// managedIdentitiesFromResponse converts an ARM identity object to the managed_identities variable.
+func managedIdentitiesFromResponse(identity map[string]any) map[string]any {
+	identityType, _ := identity["type"].(string)
+	userAssigned, _ := identity["userAssignedIdentities"].(map[string]any)
+	ids := make([]string, 0, len(userAssigned))
+	for id := range userAssigned {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return map[string]any{
+		"system_assigned":            strings.Contains(strings.ToLower(identityType), "systemassigned"),
+		"user_assigned_resource_ids": ids,
+	}
+}
+
+// parentIDFromResourceID strips the last type/name pair (and a then-trailing
+// providers/namespace segment) from an ARM resource ID.
+func parentIDFromResourceID(resourceID string) (string, error) {
+	segments := strings.Split(strings.Trim(resourceID, "/"), "/")
+	if len(segments) < 2 {
+		return "", fmt.Errorf("invalid resource ID %q", resourceID)
+	}
+	segments = segments[:len(segments)-2]
+	if n := len(segments); n >= 2 && strings.EqualFold(segments[n-2], "providers") {
+		segments = segments[:n-2]
+	}
+	return "/" + strings.Join(segments, "/"), nil
+}
@@ -0,0 +1,100 @@
+package terraform
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/bicep-types/src/bicep-types-go/types"
+	"github.com/matt-FFFFFF/tfmodmake/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateImportFile(t *testing.T) {
+	dir := t.TempDir()
+
+	require.NoError(t, GenerateImportFile(dir, []ImportTarget{
+		{Address: ResourceAddress, ID: "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Test/widgets/w1"},
+		{Address: SubmoduleResourceAddress("parts", "a"), ID: "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Test/widgets/w1/parts/a"},
+	}, nil))
+
+	data, err := os.ReadFile(filepath.Join(dir, ImportFileName))
+	require.NoError(t, err)
+	assert.Equal(t, `import {
+  to = azapi_resource.this
+  id = "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Test/widgets/w1"
+}
+
+import {
+  to = module.parts["a"].azapi_resource.this
+  id = "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Test/widgets/w1/parts/a"
+}
+`, string(data))
+}
+
+func TestParentIDFromResourceID(t *testing.T) {
+	tests := map[string]string{
+		"/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Test/widgets/w1":         "/subscriptions/sub/resourceGroups/rg",
+		"/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Test/widgets/w1/parts/a": "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Test/widgets/w1",
+		"/subscriptions/sub/resourceGroups/rg":                                             "/subscriptions/sub",
+	}
+	for id, want := range tests {
+		got, err := parentIDFromResourceID(id)
+		require.NoError(t, err)
+		assert.Equal(t, want, got, id)
+	}
+}
+
+func TestImportVariableValues(t *testing.T) {
+	rs := &schema.ResourceSchema{
+		WritableScopes:   types.ScopeTypeResourceGroup,
+		SupportsIdentity: true,
+		Properties: map[string]*schema.Property{
+			"name":     {Name: "name", Type: schema.TypeString},
+			"location": {Name: "location", Type: schema.TypeString},
+			"identity": {Name: "identity", Type: schema.TypeObject},
+			"sku": {Name: "sku", Type: schema.TypeObject, Children: map[string]*schema.Property{
+				"name": {Name: "name", Type: schema.TypeString},
+			}},
+			"properties": {Name: "properties", Type: schema.TypeObject, Children: map[string]*schema.Property{
+				"networkAcls": {Name: "networkAcls", Type: schema.TypeObject, Children: map[string]*schema.Property{
+					"defaultAction": {Name: "defaultAction", Type: schema.TypeString},
+					"ipRules": {Name: "ipRules", Type: schema.TypeArray, ItemType: &schema.Property{Type: schema.TypeObject, Children: map[string]*schema.Property{
+						"value": {Name: "value", Type: schema.TypeString},
+					}}},
+				}},
+				"provisioningState": {Name: "provisioningState", Type: schema.TypeString, ReadOnly: true},
+				"adminPassword":     {Name: "adminPassword", Type: schema.TypeString, Sensitive: true},
+			}},
+		},
+	}
+	response := []byte(`{
+  "id": "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Test/widgets/w1",
+  "name": "w1",
+  "location": "westeurope",
+  "identity": {"type": "SystemAssigned, UserAssigned", "userAssignedIdentities": {"/id/b": {}, "/id/a": {}}},
+  "sku": {"name": "Standard", "tier": "Standard"},
+  "properties": {
+    "networkAcls": {"defaultAction": "Deny", "ipRules": [{"value": "10.0.0.0/8", "action": "Allow"}]},
+    "provisioningState": "Succeeded"
+  }
+}`)
+
+	values, err := ImportVariableValues(rs, "Microsoft.Test/widgets", "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Test/widgets/w1", response)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"name":      "w1",
+		"parent_id": "/subscriptions/sub/resourceGroups/rg",
+		"location":  "westeurope",
+		"managed_identities": map[string]any{
+			"system_assigned":            true,
+			"user_assigned_resource_ids": []string{"/id/a", "/id/b"},
+		},
+		"sku": map[string]any{"name": "Standard"},
+		"network_acls": map[string]any{
+			"default_action": "Deny",
+			"ip_rules":       []any{map[string]any{"value": "10.0.0.0/8"}},
+		},
+	}, values)
+}
@@ -0,0 +1,497 @@
+package terraform
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/matt-FFFFFF/tfmodmake/hclgen"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Iteration variables of the for expressions that evaluate child expressions per
+// instance. They are unlikely to be shadowed by the generated expressions, which
+// iterate with k, value and item.
+const (
+	inlineInstanceVar = "instance"
+	inlineKeyVar      = "instance_key"
+)
+
+// inlineSkippedVariables are child variables the parent module sets itself.
+var inlineSkippedVariables = map[string]bool{
+	"parent_id":        true,
+	"enable_telemetry": true,
+}
+
+// GenerateInlineChild generates a child resource type as an azapi_resource
+// "<name>" in the module of the output directory, instead of a submodule. The
+// instances come from a map(object) variable named name, whose attributes,
+// defaults and validations are those the child module would have. name defaults
+// to the map key and, when the module has the same variables, location and tags
+// default to those of the module. Write-only inputs move to ephemeral maps keyed
+// like the instances, since for_each cannot iterate over them.
+//
+// It writes variables.<name>.tf, locals.<name>.tf, main.<name>.tf and
+// outputs.<name>.tf.
+func GenerateInlineChild(resourceType, name string, opts ...GeneratorOption) error {
+	o := &generatorOptions{
+		resourceType: resourceType,
+		outputDir:    ".",
+		localName:    "resource_body",
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	o.moduleNamePrefix = name
+	// The blocks are rewritten into the parent module's files, which took the
+	// template headers when they were generated.
+	o.templatesDir = ""
+
+	mod, err := buildModule(o)
+	if err != nil {
+		return err
+	}
+	declared, err := declaredNames(o.outputDir, "variable")
+	if err != nil {
+		return err
+	}
+
+	inline, err := newInlineChild(name, mod, declared)
+	if err != nil {
+		return fmt.Errorf("inlining %s: %w", resourceType, err)
+	}
+
+	files := []struct {
+		name string
+		src  []byte
+	}{
+		{"variables." + name + ".tf", inline.variables()},
+		{"locals." + name + ".tf", inline.locals},
+		{"main." + name + ".tf", inline.main},
+		{"outputs." + name + ".tf", inline.outputs()},
+	}
+	for _, f := range files {
+		if f.src == nil {
+			continue
+		}
+		if err := o.cancelled(); err != nil {
+			return err
+		}
+		file, diags := hclwrite.ParseConfig(f.src, f.name, hcl.Pos{Line: 1, Column: 1})
+		if diags.HasErrors() {
+			return fmt.Errorf("inlining %s: generated invalid %s: %s", resourceType, f.name, diags.Error())
+		}
+		if err := writeFormattedFile(o.journal, o.outputDir, f.name, file); err != nil {
+			return err
+		}
+	}
+	return WriteTFVarsExample(o.outputDir, o.journal)
+}
+
+// inlineVariable is a child variable that becomes an attribute of the instances.
+type inlineVariable struct {
+	name        string
+	description string
+	ty          string
+	// defaultValue is the source of the default, empty when the variable is required.
+	defaultValue string
+	// fallback is the expression used when the instance does not set the attribute,
+	// with %[1]s for the instance and %[2]s for its key.
+	fallback    string
+	ephemeral   bool
+	validations []inlineValidation
+}
+
+type inlineValidation struct {
+	condition    hclsyntax.Expression
+	errorMessage hclsyntax.Expression
+}
+
+// inlineChild holds a child module rewritten to run once per instance.
+type inlineChild struct {
+	name   string
+	inputs []*inlineVariable
+	byName map[string]*inlineVariable
+	// src maps file names to the source of the child file, which the parsed
+	// expressions point into.
+	src    map[string][]byte
+	locals []byte
+	main   []byte
+	// exports are the child outputs, rewritten to read from one instance.
+	exports []inlineExport
+}
+
+type inlineExport struct {
+	name      string
+	value     string
+	sensitive bool
+}
+
+func newInlineChild(name string, mod *GeneratedModule, parentVariables map[string]struct{}) (*inlineChild, error) {
+	c := &inlineChild{
+		name:   name,
+		byName: map[string]*inlineVariable{},
+		src:    map[string][]byte{},
+	}
+	if err := c.readVariables(mod.Variables, parentVariables); err != nil {
+		return nil, err
+	}
+
+	var err error
+	if mod.Locals != nil {
+		if c.locals, err = c.rewriteLocals(mod.Locals.Bytes()); err != nil {
+			return nil, err
+		}
+	}
+	if c.main, err = c.rewriteMain(mod.Main.Bytes()); err != nil {
+		return nil, err
+	}
+	if c.exports, err = c.rewriteOutputs(mod.Outputs.Bytes()); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// rewriteOutputs reads the child outputs as attributes of one instance of the
+// resource, resource_id and name first.
+func (c *inlineChild) rewriteOutputs(src []byte) ([]inlineExport, error) {
+	parsed, diags := hclsyntax.ParseConfig(src, "outputs.tf", hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	var exports []inlineExport
+	for _, block := range parsed.Body.(*hclsyntax.Body).Blocks {
+		value, ok := block.Body.Attributes["value"]
+		if block.Type != "output" || len(block.Labels) != 1 || !ok {
+			continue
+		}
+		export := inlineExport{name: block.Labels[0], value: c.rewriteExpr(src, value.Expr, inlineInstanceVar, inlineKeyVar)}
+		if attr, ok := block.Body.Attributes["sensitive"]; ok {
+			sensitive, _ := attr.Expr.Value(nil)
+			export.sensitive = sensitive.Type() == cty.Bool && sensitive.True()
+		}
+		exports = append(exports, export)
+	}
+	rank := func(name string) int {
+		switch name {
+		case "resource_id":
+			return 0
+		case "name":
+			return 1
+		}
+		return 2
+	}
+	sort.SliceStable(exports, func(i, j int) bool { return rank(exports[i].name) < rank(exports[j].name) })
+	return exports, nil
+}
+
+// readVariables collects the child variables, with the fallback of those the
+// parent can supply.
+func (c *inlineChild) readVariables(file *hclwrite.File, parentVariables map[string]struct{}) error {
+	src := file.Bytes()
+	c.src["variables.tf"] = src
+	parsed, diags := hclsyntax.ParseConfig(src, "variables.tf", hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		return diags
+	}
+	for _, block := range parsed.Body.(*hclsyntax.Body).Blocks {
+		if block.Type != "variable" || len(block.Labels) != 1 || inlineSkippedVariables[block.Labels[0]] {
+			continue
+		}
+		v := &inlineVariable{name: block.Labels[0], ty: "any"}
+		if attr, ok := block.Body.Attributes["description"]; ok {
+			if value, diags := attr.Expr.Value(nil); !diags.HasErrors() && value.Type() == cty.String {
+				v.description = strings.TrimSpace(value.AsString())
+			}
+		}
+		if attr, ok := block.Body.Attributes["type"]; ok {
+			v.ty = exprSource(src, attr.Expr)
+		}
+		if attr, ok := block.Body.Attributes["default"]; ok {
+			v.defaultValue = exprSource(src, attr.Expr)
+		}
+		if attr, ok := block.Body.Attributes["ephemeral"]; ok {
+			value, _ := attr.Expr.Value(nil)
+			v.ephemeral = value.Type() == cty.Bool && value.True()
+		}
+		for _, validation := range block.Body.Blocks {
+			if validation.Type != "validation" {
+				continue
+			}
+			condition, ok := validation.Body.Attributes["condition"]
+			if !ok {
+				continue
+			}
+			iv := inlineValidation{condition: condition.Expr}
+			if msg, ok := validation.Body.Attributes["error_message"]; ok {
+				iv.errorMessage = msg.Expr
+			}
+			v.validations = append(v.validations, iv)
+		}
+
+		_, parentHas := parentVariables[v.name]
+		switch {
+		case v.name == "name" && v.ty == "string":
+			v.fallback = "coalesce(%[1]s.name, %[2]s)"
+			v.description = withDefaultNote(v.description, "Defaults to the map key of the instance.")
+		case v.name == "scope" && v.ty == "string":
+			v.fallback = "coalesce(%[1]s.scope, azapi_resource.this.id)"
+			v.description = withDefaultNote(v.description, "Defaults to the ID of the parent resource.")
+		case v.name == "location" && v.ty == "string" && parentHas:
+			v.fallback = "coalesce(%[1]s.location, var.location)"
+			v.description = withDefaultNote(v.description, "Defaults to the location of the parent resource.")
+		case v.name == "tags" && parentHas:
+			// coalesce fails when both are null, which try turns back into null.
+			v.fallback = "try(coalesce(%[1]s.tags, var.tags), null)"
+			v.description = withDefaultNote(v.description, "Defaults to the tags of the parent resource.")
+		}
+
+		c.inputs = append(c.inputs, v)
+		c.byName[v.name] = v
+	}
+	return nil
+}
+
+// withDefaultNote appends note, which names the default of an input, to its
+// description. The note follows a single-line description in the same sentence
+// flow, and a multi-line one, such as a description ending in a list, as its own
+// paragraph.
+func withDefaultNote(description, note string) string {
+	description = strings.TrimSpace(description)
+	switch {
+	case description == "":
+		return note
+	case strings.Contains(description, "\n"):
+		return description + "\n\n" + note
+	}
+	return description + " " + note
+}
+
+// reference returns the per-instance expression replacing var.<name>.
+func (c *inlineChild) reference(name, instance, key string) string {
+	switch v := c.byName[name]; {
+	case name == "parent_id":
+		return "azapi_resource.this.id"
+	case v == nil:
+		return "var." + name
+	case v.ephemeral:
+		return fmt.Sprintf("try(var.%s_%s[%s], null)", c.name, name, key)
+	case v.fallback != "":
+		return fmt.Sprintf(v.fallback, instance, key)
+	}
+	return instance + "." + name
+}
+
+// rewriteExpr returns the source of expr with var and local references replaced
+// by their per-instance form. References to azapi_resource.this, which only
+// outputs make, become instance as well, the outputs iterating over the resources.
+func (c *inlineChild) rewriteExpr(src []byte, expr hclsyntax.Expression, instance, key string) string {
+	rng := expr.Range()
+	type edit struct {
+		start, end  int
+		replacement string
+	}
+	var edits []edit
+	for _, traversal := range expr.Variables() {
+		if len(traversal) < 2 {
+			continue
+		}
+		attr, ok := traversal[1].(hcl.TraverseAttr)
+		if !ok {
+			continue
+		}
+		var replacement string
+		switch traversal.RootName() {
+		case "var":
+			replacement = c.reference(attr.Name, instance, key)
+		case "local":
+			replacement = fmt.Sprintf("local.%s_%s[%s]", c.name, attr.Name, key)
+		case "azapi_resource":
+			if attr.Name != "this" {
+				continue
+			}
+			replacement = instance
+		default:
+			continue
+		}
+		edits = append(edits, edit{traversal[0].SourceRange().Start.Byte, traversal[1].SourceRange().End.Byte, replacement})
+	}
+	sort.Slice(edits, func(i, j int) bool { return edits[i].start > edits[j].start })
+
+	out := append([]byte(nil), src[rng.Start.Byte:rng.End.Byte]...)
+	for _, e := range edits {
+		start, end := e.start-rng.Start.Byte, e.end-rng.Start.Byte
+		out = append(out[:start], append([]byte(e.replacement), out[end:]...)...)
+	}
+	return string(out)
+}
+
+// rewriteLocals turns every child local into a map of its value per instance key.
+func (c *inlineChild) rewriteLocals(src []byte) ([]byte, error) {
+	parsed, diags := hclsyntax.ParseConfig(src, "locals.tf", hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	var buf bytes.Buffer
+	buf.WriteString("locals {\n")
+	for _, block := range parsed.Body.(*hclsyntax.Body).Blocks {
+		if block.Type != "locals" {
+			continue
+		}
+		attrs := make([]*hclsyntax.Attribute, 0, len(block.Body.Attributes))
+		for _, attr := range block.Body.Attributes {
+			attrs = append(attrs, attr)
+		}
+		sort.Slice(attrs, func(i, j int) bool { return attrs[i].SrcRange.Start.Byte < attrs[j].SrcRange.Start.Byte })
+		for _, attr := range attrs {
+			fmt.Fprintf(&buf, "%s_%s = { for %s, %s in var.%s : %s => %s }\n",
+				c.name, attr.Name, inlineKeyVar, inlineInstanceVar, c.name, inlineKeyVar,
+				c.rewriteExpr(src, attr.Expr, inlineInstanceVar, inlineKeyVar))
+		}
+	}
+	buf.WriteString("}\n")
+	return buf.Bytes(), nil
+}
+
+// rewriteMain renames azapi_resource.this to azapi_resource.<name> and iterates
+// it over the instances.
+func (c *inlineChild) rewriteMain(src []byte) ([]byte, error) {
+	parsed, diags := hclsyntax.ParseConfig(src, "main.tf", hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	blocks := parsed.Body.(*hclsyntax.Body).Blocks
+	if len(blocks) != 1 || blocks[0].Type != "resource" || len(blocks[0].Labels) != 2 || blocks[0].Labels[0] != "azapi_resource" {
+		return nil, fmt.Errorf("only children generated as a single azapi_resource can be inlined; generate it as a submodule")
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "resource \"azapi_resource\" %q {\nfor_each = var.%s\n\n", c.name, c.name)
+	c.writeBody(&buf, src, blocks[0].Body)
+	buf.WriteString("}\n")
+	return buf.Bytes(), nil
+}
+
+func (c *inlineChild) writeBody(buf *bytes.Buffer, src []byte, body *hclsyntax.Body) {
+	attrs := make([]*hclsyntax.Attribute, 0, len(body.Attributes))
+	for _, attr := range body.Attributes {
+		attrs = append(attrs, attr)
+	}
+	sort.Slice(attrs, func(i, j int) bool { return attrs[i].SrcRange.Start.Byte < attrs[j].SrcRange.Start.Byte })
+	for _, attr := range attrs {
+		fmt.Fprintf(buf, "%s = %s\n", attr.Name, c.rewriteExpr(src, attr.Expr, "each.value", "each.key"))
+	}
+	for _, block := range body.Blocks {
+		buf.WriteString(block.Type)
+		for _, label := range block.Labels {
+			fmt.Fprintf(buf, " %q", label)
+		}
+		buf.WriteString(" {\n")
+		c.writeBody(buf, src, block.Body)
+		buf.WriteString("}\n")
+	}
+}
+
+// variables renders the map variable of the instances and an ephemeral map per
+// write-only input.
+func (c *inlineChild) variables() []byte {
+	file := hclwrite.NewEmptyFile()
+	body := file.Body()
+	src := c.src["variables.tf"]
+
+	var description strings.Builder
+	description.WriteString("Map of instances with the following attributes:\n")
+	var attrs []string
+	var conditions []string
+	for _, v := range c.inputs {
+		if v.ephemeral {
+			continue
+		}
+		// Continuation lines are indented to stay in the list item of the input.
+		lines := strings.Split(v.description, "\n")
+		for i := 1; i < len(lines); i++ {
+			if lines[i] != "" {
+				lines[i] = "  " + lines[i]
+			}
+		}
+		fmt.Fprintf(&description, "\n- `%s` - %s", v.name, strings.Join(lines, "\n"))
+		ty := v.ty
+		switch {
+		case v.defaultValue != "" && v.defaultValue != "null":
+			ty = fmt.Sprintf("optional(%s, %s)", v.ty, v.defaultValue)
+		case v.defaultValue != "" || v.fallback != "":
+			ty = fmt.Sprintf("optional(%s)", v.ty)
+		}
+		attrs = append(attrs, fmt.Sprintf("%s = %s", v.name, ty))
+	}
+	for _, v := range c.inputs {
+		for _, validation := range v.validations {
+			conditions = append(conditions, c.rewriteExpr(src, validation.condition, inlineInstanceVar, inlineKeyVar))
+		}
+	}
+
+	varBody := body.AppendNewBlock("variable", []string{c.name}).Body()
+	hclgen.SetDescriptionAttribute(varBody, description.String())
+	varBody.SetAttributeRaw("type", interfaceExpression(fmt.Sprintf("map(object({\n%s\n}))", strings.Join(attrs, "\n"))))
+	varBody.SetAttributeRaw("default", interfaceExpression("{}"))
+	varBody.SetAttributeValue("nullable", cty.False)
+	i := 0
+	for _, v := range c.inputs {
+		for _, validation := range v.validations {
+			validationBody := varBody.AppendNewBlock("validation", nil).Body()
+			validationBody.SetAttributeRaw("condition", interfaceExpression(fmt.Sprintf("alltrue([for %s, %s in var.%s : %s])", inlineKeyVar, inlineInstanceVar, c.name, conditions[i])))
+			message := fmt.Sprintf("%s is invalid in one or more instances.", v.name)
+			if validation.errorMessage != nil && len(validation.errorMessage.Variables()) == 0 {
+				if value, diags := validation.errorMessage.Value(nil); !diags.HasErrors() && value.Type() == cty.String {
+					message = value.AsString()
+				}
+			}
+			validationBody.SetAttributeValue("error_message", cty.StringVal(message))
+			i++
+		}
+	}
+
+	for _, v := range c.inputs {
+		if !v.ephemeral {
+			continue
+		}
+		body.AppendNewline()
+		ephemeralBody := body.AppendNewBlock("variable", []string{c.name + "_" + v.name}).Body()
+		hclgen.SetDescriptionAttribute(ephemeralBody, fmt.Sprintf("Map of instance keys of var.%s to their write-only `%s`. %s", c.name, v.name, v.description))
+		ephemeralBody.SetAttributeRaw("type", interfaceExpression(fmt.Sprintf("map(%s)", v.ty)))
+		ephemeralBody.SetAttributeRaw("default", interfaceExpression("{}"))
+		ephemeralBody.SetAttributeValue("ephemeral", cty.True)
+		ephemeralBody.SetAttributeValue("nullable", cty.False)
+	}
+	return file.Bytes()
+}
+
+// outputs maps each instance key to the outputs the child module would have,
+// like the output of a submodule wrapper.
+func (c *inlineChild) outputs() []byte {
+	var attrs []string
+	sensitive := false
+	for _, export := range c.exports {
+		attrs = append(attrs, fmt.Sprintf("%s = %s", export.name, export.value))
+		sensitive = sensitive || export.sensitive
+	}
+
+	file := hclwrite.NewEmptyFile()
+	outputBody := file.Body().AppendNewBlock("output", []string{c.name}).Body()
+	outputBody.SetAttributeValue("description", cty.StringVal(fmt.Sprintf("Map of %s instance keys to the outputs of each instance, including its resource ID and name.", c.name)))
+	outputBody.SetAttributeRaw("value", interfaceExpression(fmt.Sprintf("{ for %s, %s in azapi_resource.%s : %s => {\n%s\n} }",
+		inlineKeyVar, inlineInstanceVar, c.name, inlineKeyVar, strings.Join(attrs, "\n"))))
+	if sensitive {
+		outputBody.SetAttributeValue("sensitive", cty.True)
+	}
+	return file.Bytes()
+}
+
+func exprSource(src []byte, expr hclsyntax.Expression) string {
+	rng := expr.Range()
+	return string(src[rng.Start.Byte:rng.End.Byte])
+}
@@ -0,0 +1,140 @@
+package terraform
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/bicep-types/src/bicep-types-go/types"
+	"github.com/matt-FFFFFF/tfmodmake/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func inlineChildSchema() *schema.ResourceSchema {
+	return &schema.ResourceSchema{
+		ResourceType:     "Microsoft.App/managedEnvironments/certificates",
+		APIVersion:       "2024-03-01",
+		SupportsTags:     true,
+		SupportsLocation: true,
+		Properties: map[string]*schema.Property{
+			"name":     {Type: schema.TypeString},
+			"location": {Type: schema.TypeString},
+			"tags":     {Type: schema.TypeObject, AdditionalProperties: &schema.Property{Type: schema.TypeString}},
+			"properties": {Type: schema.TypeObject, Children: map[string]*schema.Property{
+				"password":   {Type: schema.TypeString, Sensitive: true},
+				"kind":       {Type: schema.TypeString, Enum: []string{"A", "B"}},
+				"thumbprint": {Type: schema.TypeString, ReadOnly: true},
+			}},
+		},
+	}
+}
+
+func TestGenerateInlineChild(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "variables.tf"), []byte("variable \"location\" {\n  type = string\n}\n"), 0o644))
+
+	require.NoError(t, GenerateInlineChild("Microsoft.App/managedEnvironments/certificates", "certificates",
+		WithResourceSchema(inlineChildSchema()), WithAPIVersion("2024-03-01"), WithOutputDir(dir)))
+
+	variables := parseHCLBody(t, filepath.Join(dir, "variables.certificates.tf"))
+	instances := requireBlock(t, variables, "variable", "certificates")
+	typeExpr := expressionString(t, instances.Body.Attributes["type"].Expr)
+	assert.Contains(t, typeExpr, "name             = optional(string)")
+	assert.Contains(t, typeExpr, "location         = optional(string)")
+	assert.Contains(t, typeExpr, "kind             = optional(string)")
+	assert.NotContains(t, typeExpr, "parent_id")
+	assert.NotContains(t, typeExpr, "password ", "write-only inputs cannot be part of the for_each map")
+	assert.Equal(t, "{}", expressionString(t, instances.Body.Attributes["default"].Expr))
+
+	var conditions []string
+	for _, block := range instances.Body.Blocks {
+		if block.Type == "validation" {
+			conditions = append(conditions, expressionString(t, block.Body.Attributes["condition"].Expr))
+		}
+	}
+	assert.Contains(t, conditions, `alltrue([for instance_key, instance in var.certificates : instance.kind == null || contains(["A", "B"], instance.kind)])`)
+	assert.Contains(t, conditions, `alltrue([for instance_key, instance in var.certificates : try(var.certificates_password[instance_key], null) == null || instance.password_version != null])`)
+
+	password := requireBlock(t, variables, "variable", "certificates_password")
+	assert.Equal(t, "map(string)", expressionString(t, password.Body.Attributes["type"].Expr))
+	assert.Equal(t, "true", expressionString(t, password.Body.Attributes["ephemeral"].Expr))
+
+	locals := requireBlock(t, parseHCLBody(t, filepath.Join(dir, "locals.certificates.tf")), "locals")
+	require.Contains(t, locals.Body.Attributes, "certificates_resource_body")
+	body := expressionString(t, locals.Body.Attributes["certificates_resource_body"].Expr)
+	assert.Contains(t, body, "for instance_key, instance in var.certificates : instance_key =>")
+	assert.Contains(t, body, "kind = instance.kind")
+	assert.Contains(t, body, "tags = instance.tags == null ? null", "tags only fall back to var.tags when the parent declares it")
+
+	resource := requireBlock(t, parseHCLBody(t, filepath.Join(dir, "main.certificates.tf")), "resource", "azapi_resource", "certificates")
+	for name, want := range map[string]string{
+		"for_each":  "var.certificates",
+		"name":      "coalesce(each.value.name, each.key)",
+		"parent_id": "azapi_resource.this.id",
+		"location":  "coalesce(each.value.location, var.location)",
+		"body":      "local.certificates_resource_body[each.key]",
+		"type":      `"Microsoft.App/managedEnvironments/certificates@2024-03-01"`,
+	} {
+		require.Contains(t, resource.Body.Attributes, name)
+		assert.Equal(t, want, expressionString(t, resource.Body.Attributes[name].Expr), name)
+	}
+	assert.Contains(t, expressionString(t, resource.Body.Attributes["sensitive_body"].Expr), "password = try(var.certificates_password[each.key], null)")
+
+	output := requireBlock(t, parseHCLBody(t, filepath.Join(dir, "outputs.certificates.tf")), "output", "certificates")
+	value := expressionString(t, output.Body.Attributes["value"].Expr)
+	assert.Contains(t, value, "for instance_key, instance in azapi_resource.certificates : instance_key =>")
+	assert.Contains(t, value, "resource_id = instance.id")
+	assert.Contains(t, value, "name        = instance.name")
+	assert.Contains(t, value, "thumbprint  = try(instance.output.properties.thumbprint, null)")
+}
+
+func TestGenerateInlineChild_ScopeResource(t *testing.T) {
+	dir := t.TempDir()
+	rs := &schema.ResourceSchema{
+		ResourceType:   "Microsoft.App/managedEnvironments/tags",
+		APIVersion:     "2024-03-01",
+		WritableScopes: types.ScopeTypeExtension,
+		Properties: map[string]*schema.Property{
+			"name": {Type: schema.TypeString},
+			"properties": {Type: schema.TypeObject, Children: map[string]*schema.Property{
+				"kind": {Type: schema.TypeString},
+			}},
+		},
+	}
+	require.NoError(t, GenerateInlineChild("Microsoft.App/managedEnvironments/tags", "tags",
+		WithResourceSchema(rs), WithAPIVersion("2024-03-01"), WithOutputDir(dir)))
+
+	instances := requireBlock(t, parseHCLBody(t, filepath.Join(dir, "variables.tags.tf")), "variable", "tags")
+	assert.Contains(t, expressionString(t, instances.Body.Attributes["type"].Expr), "scope    = optional(string)")
+
+	resource := requireBlock(t, parseHCLBody(t, filepath.Join(dir, "main.tags.tf")), "resource", "azapi_resource", "tags")
+	assert.Equal(t, "coalesce(each.value.scope, azapi_resource.this.id)", expressionString(t, resource.Body.Attributes["parent_id"].Expr))
+}
+
+func TestGenerateInlineChild_RejectsExtraResources(t *testing.T) {
+	rs := inlineChildSchema()
+	err := GenerateInlineChild("Microsoft.App/managedEnvironments/certificates", "certificates",
+		WithResourceSchema(rs), WithAPIVersion("2024-03-01"), WithOutputDir(t.TempDir()), WithPostCreateProperties("properties.kind"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "generate it as a submodule")
+}
+
+func TestInlineChildDescriptions(t *testing.T) {
+	assert.Equal(t, "The name. Defaults to the key.", withDefaultNote("The name.\n", "Defaults to the key."))
+	assert.Equal(t, "Defaults to the key.", withDefaultNote("", "Defaults to the key."))
+	assert.Equal(t, "The tags:\n- a\n- b\n\nDefaults to the parent tags.", withDefaultNote("The tags:\n- a\n- b\n", "Defaults to the parent tags."))
+
+	c := &inlineChild{name: "certificates", inputs: []*inlineVariable{
+		{name: "name", ty: "string", description: withDefaultNote("The name.\n", "Defaults to the map key of the instance."), fallback: "coalesce(%[1]s.name, %[2]s)"},
+		{name: "tags", ty: "map(string)", description: withDefaultNote("The tags:\n\n- `env` - The environment.\n", "Defaults to the tags of the parent resource."), fallback: "try(coalesce(%[1]s.tags, var.tags), null)"},
+	}}
+	path := filepath.Join(t.TempDir(), "variables.certificates.tf")
+	require.NoError(t, os.WriteFile(path, c.variables(), 0o644))
+	instances := requireBlock(t, parseHCLBody(t, path), "variable", "certificates")
+	value, diags := instances.Body.Attributes["description"].Expr.Value(nil)
+	require.False(t, diags.HasErrors(), diags.Error())
+	assert.Equal(t, "Map of instances with the following attributes:\n\n"+
+		"- `name` - The name. Defaults to the map key of the instance.\n"+
+		"- `tags` - The tags:\n\n  - `env` - The environment.\n\n  Defaults to the tags of the parent resource.\n", value.AsString())
+}
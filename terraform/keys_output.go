@@ -0,0 +1,122 @@
+package terraform
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/matt-FFFFFF/tfmodmake/hclgen"
+	"github.com/matt-FFFFFF/tfmodmake/naming"
+	"github.com/matt-FFFFFF/tfmodmake/schema"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// enableKeysOutputVariable gates the key actions, which need permission to read secrets.
+const enableKeysOutputVariable = "enable_keys_output"
+
+// keysActionNames are the POST actions whose responses carry access keys or
+// connection strings.
+var keysActionNames = []string{"listKeys", "listConnectionStrings"}
+
+// keysActions returns the key actions the resource exposes, in keysActionNames order.
+func keysActions(rs *schema.ResourceSchema) []*schema.ResourceFunction {
+	var actions []*schema.ResourceFunction
+	for _, name := range keysActionNames {
+		if fn := rs.Function(name); fn != nil && fn.Output != nil && len(fn.Output.Children) > 0 {
+			actions = append(actions, fn)
+		}
+	}
+	return actions
+}
+
+// keysActionFields returns the sorted response fields of a key action.
+func keysActionFields(fn *schema.ResourceFunction) []string {
+	fields := make([]string, 0, len(fn.Output.Children))
+	for name, prop := range fn.Output.Children {
+		if prop != nil {
+			fields = append(fields, name)
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// appendEnableKeysOutputVariable appends the variable gating the key actions.
+func appendEnableKeysOutputVariable(body *hclwrite.Body) {
+	varBody := body.AppendNewBlock("variable", []string{enableKeysOutputVariable}).Body()
+	hclgen.SetDescriptionAttribute(varBody, "Whether to read the access keys and connection strings of the resource and expose them as sensitive outputs. Requires permission to invoke the list actions on the resource.")
+	varBody.SetAttributeRaw("type", hclwrite.TokensForIdentifier("bool"))
+	varBody.SetAttributeValue("default", cty.False)
+	varBody.SetAttributeValue("nullable", cty.False)
+	body.AppendNewline()
+}
+
+// appendKeysActionDataSources appends an azapi_resource_action data source per key
+// action, invoked against the module's resource when var.enable_keys_output is true.
+func appendKeysActionDataSources(body *hclwrite.Body, blockType, resourceType string, actions []*schema.ResourceFunction) {
+	for _, fn := range actions {
+		body.AppendNewline()
+		dataBody := body.AppendNewBlock("data", []string{"azapi_resource_action", naming.ToSnakeCase(fn.Name)}).Body()
+
+		var count hclwrite.Tokens
+		count = append(count, hclgen.TokensForTraversal("var", enableKeysOutputVariable)...)
+		count = append(count, &hclwrite.Token{Type: hclsyntax.TokenQuestion, Bytes: []byte(" ? ")})
+		count = append(count, hclwrite.TokensForIdentifier("1")...)
+		count = append(count, &hclwrite.Token{Type: hclsyntax.TokenColon, Bytes: []byte(" : ")})
+		count = append(count, hclwrite.TokensForIdentifier("0")...)
+
+		dataBody.SetAttributeRaw("count", count)
+		dataBody.SetAttributeValue("type", cty.StringVal(fmt.Sprintf("%s@%s", cleanTypeString(resourceType), fn.APIVersion)))
+		dataBody.SetAttributeRaw("resource_id", hclgen.TokensForTraversal(blockType, "this", "id"))
+		dataBody.SetAttributeValue("action", cty.StringVal(fn.Name))
+		dataBody.SetAttributeValue("method", cty.StringVal("POST"))
+		dataBody.SetAttributeRaw("response_export_values", hclgen.TokensForMultilineStringList(keysActionFields(fn)))
+	}
+}
+
+// appendKeysOutputs appends a sensitive output per response field of the key
+// actions, null unless var.enable_keys_output is true. Fields are named after the
+// field, prefixed with the action when the name is already taken.
+func appendKeysOutputs(body *hclwrite.Body, format BodyFormat, actions []*schema.ResourceFunction, outputNaming OutputNaming, usedNames map[string]struct{}) {
+	for _, fn := range actions {
+		dataName := naming.ToSnakeCase(fn.Name)
+		for _, field := range keysActionFields(fn) {
+			outputName := outputNaming.Prefix + outputNaming.segmentName(field)
+			if _, taken := usedNames[outputName]; taken {
+				outputName = outputNaming.Prefix + dataName + "_" + outputNaming.segmentName(field)
+			}
+			usedNames[outputName] = struct{}{}
+
+			desc := strings.TrimSpace(fn.Output.Children[field].Description)
+			if desc == "" {
+				desc = fmt.Sprintf("The %s returned by the %s action.", field, fn.Name)
+			}
+			desc += fmt.Sprintf(" Null unless var.%s is true.", enableKeysOutputVariable)
+
+			outBody := body.AppendNewBlock("output", []string{outputName}).Body()
+			outBody.SetAttributeValue("description", cty.StringVal(desc))
+			outBody.SetAttributeRaw("value", hclwrite.TokensForFunctionCall("try", tokensForActionOutput(dataName, format, field), hclwrite.TokensForIdentifier("null")))
+			outBody.SetAttributeValue("sensitive", cty.True)
+			body.AppendNewline()
+		}
+	}
+}
+
+// tokensForActionOutput returns the expression reading field from the output of
+// the first instance of the named azapi_resource_action data source.
+func tokensForActionOutput(dataName string, format BodyFormat, field string) hclwrite.Tokens {
+	instance := hclgen.TokensForTraversal("data", "azapi_resource_action", dataName)
+	instance = append(instance, &hclwrite.Token{Type: hclsyntax.TokenOBrack, Bytes: []byte("[")})
+	instance = append(instance, hclwrite.TokensForValue(cty.NumberIntVal(0))...)
+	instance = append(instance, &hclwrite.Token{Type: hclsyntax.TokenCBrack, Bytes: []byte("]")})
+	instance = append(instance, &hclwrite.Token{Type: hclsyntax.TokenDot, Bytes: []byte(".")})
+	instance = append(instance, &hclwrite.Token{Type: hclsyntax.TokenIdent, Bytes: []byte("output")})
+
+	if format == BodyFormatJSON {
+		instance = hclwrite.TokensForFunctionCall("jsondecode", instance)
+	}
+	// Drop the placeholder root identifier, keeping the attribute and index accessors.
+	return append(instance, hclgen.TokensForTraversalOrIndex("_", field)[1:]...)
+}
@@ -0,0 +1,93 @@
+package terraform
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/matt-FFFFFF/tfmodmake/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func keysOutputSchema() *schema.ResourceSchema {
+	return &schema.ResourceSchema{
+		APIVersion: "2023-01-01",
+		Properties: map[string]*schema.Property{
+			"name": {Name: "name", Type: schema.TypeString, Required: true},
+			"properties": {Name: "properties", Type: schema.TypeObject, Children: map[string]*schema.Property{
+				"keys": {Name: "keys", Type: schema.TypeString, ReadOnly: true},
+			}},
+		},
+		Functions: []*schema.ResourceFunction{
+			{Name: "listConnectionStrings", APIVersion: "2023-01-01", Output: &schema.Property{Type: schema.TypeObject, Children: map[string]*schema.Property{
+				"connectionStrings": {Name: "connectionStrings", Type: schema.TypeArray, ReadOnly: true, Description: "The connection strings."},
+			}}},
+			{Name: "listKeys", APIVersion: "2023-01-01", Output: &schema.Property{Type: schema.TypeObject, Children: map[string]*schema.Property{
+				"keys":       {Name: "keys", Type: schema.TypeArray, ReadOnly: true},
+				"primaryKey": {Name: "primaryKey", Type: schema.TypeString, ReadOnly: true, Description: "The primary key."},
+			}}},
+			{Name: "regenerateKey", APIVersion: "2023-01-01", Output: &schema.Property{Type: schema.TypeObject, Children: map[string]*schema.Property{
+				"primaryKey": {Name: "primaryKey", Type: schema.TypeString},
+			}}},
+		},
+	}
+}
+
+func TestKeysActions(t *testing.T) {
+	actions := keysActions(keysOutputSchema())
+	require.Len(t, actions, 2)
+	assert.Equal(t, "listKeys", actions[0].Name)
+	assert.Equal(t, "listConnectionStrings", actions[1].Name)
+	assert.Equal(t, []string{"keys", "primaryKey"}, keysActionFields(actions[0]))
+
+	assert.Empty(t, keysActions(&schema.ResourceSchema{}))
+}
+
+func TestGenerate_KeysOutput(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+
+	require.NoError(t, os.Chdir(tmpDir))
+
+	require.NoError(t, Generate("Microsoft.Test/vaults", WithResourceSchema(keysOutputSchema()), WithAPIVersion("2023-01-01"), WithKeysOutput(true)))
+
+	variablesBody := parseHCLBody(t, "variables.tf")
+	enable := requireBlock(t, variablesBody, "variable", "enable_keys_output")
+	assert.Equal(t, "false", expressionString(t, enable.Body.Attributes["default"].Expr))
+
+	mainBody := parseHCLBody(t, "main.tf")
+	listKeys := requireBlock(t, mainBody, "data", "azapi_resource_action", "list_keys")
+	assert.Equal(t, "var.enable_keys_output ? 1 : 0", expressionString(t, listKeys.Body.Attributes["count"].Expr))
+	assert.Equal(t, "Microsoft.Test/vaults@2023-01-01", attributeStringValue(t, listKeys.Body.Attributes["type"]))
+	assert.Equal(t, "azapi_resource.this.id", expressionString(t, listKeys.Body.Attributes["resource_id"].Expr))
+	assert.Equal(t, "listKeys", attributeStringValue(t, listKeys.Body.Attributes["action"]))
+	assert.Equal(t, `["keys","primaryKey"]`, strings.Join(strings.Fields(expressionString(t, listKeys.Body.Attributes["response_export_values"].Expr)), ""))
+	requireBlock(t, mainBody, "data", "azapi_resource_action", "list_connection_strings")
+
+	outputsBody := parseHCLBody(t, "outputs.tf")
+	primaryKey := requireBlock(t, outputsBody, "output", "primary_key")
+	assert.Equal(t, "try(data.azapi_resource_action.list_keys[0].output.primaryKey, null)", expressionString(t, primaryKey.Body.Attributes["value"].Expr))
+	assert.Equal(t, "true", expressionString(t, primaryKey.Body.Attributes["sensitive"].Expr))
+	assert.Equal(t, "The primary key. Null unless var.enable_keys_output is true.", attributeStringValue(t, primaryKey.Body.Attributes["description"]))
+	// "keys" is taken by the output of the properties.keys export.
+	requireBlock(t, outputsBody, "output", "keys")
+	requireBlock(t, outputsBody, "output", "list_keys_keys")
+	requireBlock(t, outputsBody, "output", "connection_strings")
+	assert.Nil(t, findBlock(outputsBody, "output", "regenerate_key_primary_key"))
+}
+
+func TestGenerate_KeysOutputRequiresAction(t *testing.T) {
+	_, err := GenerateInMemory("Microsoft.Test/vaults", WithResourceSchema(&schema.ResourceSchema{
+		Properties: map[string]*schema.Property{"name": {Name: "name", Type: schema.TypeString}},
+	}), WithKeysOutput(true))
+	assert.ErrorContains(t, err, "listKeys")
+}
+
+func TestTokensForActionOutput_JSON(t *testing.T) {
+	got := string(tokensForActionOutput("list_keys", BodyFormatJSON, "primaryKey").Bytes())
+	assert.Equal(t, "jsondecode(data.azapi_resource_action.list_keys[0].output).primaryKey", strings.ReplaceAll(got, " ", ""))
+}
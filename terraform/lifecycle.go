@@ -0,0 +1,117 @@
+package terraform
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/matt-FFFFFF/tfmodmake/hclgen"
+	"github.com/matt-FFFFFF/tfmodmake/schema"
+)
+
+// ignoreChangesRule identifies a writable property the service rewrites after
+// creation because a sibling property hands its control to the platform.
+type ignoreChangesRule struct {
+	property string
+	sibling  string
+}
+
+// defaultIgnoreChangesRules are the built-in patterns applied to every resource.
+var defaultIgnoreChangesRules = []ignoreChangesRule{
+	// Autoscaler-managed node counts (e.g. AKS agent pools).
+	{property: "count", sibling: "enableAutoScaling"},
+}
+
+// resolveIgnoreChanges returns the sorted, de-duplicated body paths to render into
+// lifecycle ignore_changes: the configured paths plus any built-in rule matches.
+// Configured paths must exist in the schema and must be writable.
+func resolveIgnoreChanges(rs *schema.ResourceSchema, configured []string) ([]string, error) {
+	seen := make(map[string]struct{})
+	var paths []string
+	add := func(path string) {
+		if _, ok := seen[path]; ok {
+			return
+		}
+		seen[path] = struct{}{}
+		paths = append(paths, path)
+	}
+
+	for _, path := range configured {
+		path = strings.TrimPrefix(strings.TrimSpace(path), "body.")
+		if path == "" {
+			continue
+		}
+		if rs != nil {
+			prop := propertyForExportPath(rs, path)
+			if prop == nil {
+				return nil, fmt.Errorf("ignore_changes path %q does not exist in the resource schema", path)
+			}
+			if !isWritableProperty(prop) {
+				return nil, fmt.Errorf("ignore_changes path %q is read-only", path)
+			}
+		}
+		add(path)
+	}
+
+	if rs != nil {
+		collectDefaultIgnoreChanges(rs.Properties, "", add)
+	}
+
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// collectDefaultIgnoreChanges walks nested objects (not array items, which
+// ignore_changes cannot address without an index) applying the built-in rules.
+func collectDefaultIgnoreChanges(props map[string]*schema.Property, prefix string, add func(string)) {
+	for _, rule := range defaultIgnoreChangesRules {
+		prop, ok := props[rule.property]
+		if !ok || prop == nil || !isWritableProperty(prop) {
+			continue
+		}
+		if sibling, ok := props[rule.sibling]; !ok || sibling == nil || !isWritableProperty(sibling) {
+			continue
+		}
+		add(prefix + rule.property)
+	}
+
+	for name, prop := range props {
+		if prop == nil || prop.Type != schema.TypeObject || len(prop.Children) == 0 || !isWritableProperty(prop) {
+			continue
+		}
+		collectDefaultIgnoreChanges(prop.Children, prefix+name+".", add)
+	}
+}
+
+// appendLifecycleBlock appends a lifecycle block with ignore_changes for the given
+// body paths and the resolved preconditions. Nothing is emitted when both are empty.
+func appendLifecycleBlock(resourceBody *hclwrite.Body, ignoreChanges []string, preconditions []resolvedPrecondition) {
+	if len(ignoreChanges) == 0 && len(preconditions) == 0 {
+		return
+	}
+
+	lifecycle := resourceBody.AppendNewBlock("lifecycle", nil)
+	if len(ignoreChanges) > 0 {
+		lifecycle.Body().SetAttributeRaw("ignore_changes", tokensForIgnoreChanges(ignoreChanges))
+	}
+	appendPreconditionBlocks(lifecycle.Body(), preconditions)
+}
+
+func tokensForIgnoreChanges(ignoreChanges []string) hclwrite.Tokens {
+	tokens := hclwrite.Tokens{
+		&hclwrite.Token{Type: hclsyntax.TokenOBrack, Bytes: []byte("[")},
+		&hclwrite.Token{Type: hclsyntax.TokenNewline, Bytes: []byte("\n")},
+	}
+	for _, path := range ignoreChanges {
+		parts := append([]string{"body"}, strings.Split(path, ".")...)
+		tokens = append(tokens, hclgen.TokensForTraversalOrIndex(parts...)...)
+		tokens = append(tokens,
+			&hclwrite.Token{Type: hclsyntax.TokenComma, Bytes: []byte(",")},
+			&hclwrite.Token{Type: hclsyntax.TokenNewline, Bytes: []byte("\n")},
+		)
+	}
+	tokens = append(tokens, &hclwrite.Token{Type: hclsyntax.TokenCBrack, Bytes: []byte("]")})
+	return tokens
+}
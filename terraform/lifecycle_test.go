@@ -0,0 +1,85 @@
+package terraform
+
+import (
+	"os"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/matt-FFFFFF/tfmodmake/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func agentPoolSchema() *schema.ResourceSchema {
+	return &schema.ResourceSchema{
+		Properties: map[string]*schema.Property{
+			"properties": {Name: "properties", Type: schema.TypeObject, Children: map[string]*schema.Property{
+				"count":             {Name: "count", Type: schema.TypeInteger},
+				"enableAutoScaling": {Name: "enableAutoScaling", Type: schema.TypeBoolean},
+				"vmSize":            {Name: "vmSize", Type: schema.TypeString},
+				"nodeImageVersion":  {Name: "nodeImageVersion", Type: schema.TypeString, ReadOnly: true},
+				"upgradeSettings": {Name: "upgradeSettings", Type: schema.TypeObject, Children: map[string]*schema.Property{
+					"maxSurge": {Name: "maxSurge", Type: schema.TypeString},
+				}},
+			}},
+		},
+	}
+}
+
+func TestResolveIgnoreChanges_DefaultsAndConfigured(t *testing.T) {
+	paths, err := resolveIgnoreChanges(agentPoolSchema(), []string{"body.properties.vmSize", "properties.upgradeSettings.maxSurge", "properties.count"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"properties.count", "properties.upgradeSettings.maxSurge", "properties.vmSize"}, paths)
+}
+
+func TestResolveIgnoreChanges_RejectsUnknownAndReadOnlyPaths(t *testing.T) {
+	_, err := resolveIgnoreChanges(agentPoolSchema(), []string{"properties.doesNotExist"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "does not exist")
+
+	_, err = resolveIgnoreChanges(agentPoolSchema(), []string{"properties.nodeImageVersion"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "read-only")
+}
+
+func TestResolveIgnoreChanges_NoDefaultWithoutSibling(t *testing.T) {
+	rs := &schema.ResourceSchema{
+		Properties: map[string]*schema.Property{
+			"properties": {Name: "properties", Type: schema.TypeObject, Children: map[string]*schema.Property{
+				"count": {Name: "count", Type: schema.TypeInteger},
+			}},
+		},
+	}
+	paths, err := resolveIgnoreChanges(rs, nil)
+	require.NoError(t, err)
+	assert.Empty(t, paths)
+}
+
+func TestGenerate_LifecycleIgnoreChanges(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+
+	require.NoError(t, os.Chdir(tmpDir))
+
+	err = Generate("Microsoft.ContainerService/managedClusters/agentPools",
+		WithResourceSchema(agentPoolSchema()),
+		WithAPIVersion("2025-01-01"),
+		WithIgnoreChanges("properties.vmSize"),
+	)
+	require.NoError(t, err)
+
+	body := parseHCLBody(t, "main.tf")
+	resource := requireBlock(t, body, "resource", "azapi_resource", "this")
+	lifecycle := requireBlock(t, resource.Body, "lifecycle")
+	attr := lifecycle.Body.Attributes["ignore_changes"]
+	require.NotNil(t, attr)
+
+	tuple, ok := attr.Expr.(*hclsyntax.TupleConsExpr)
+	require.True(t, ok)
+	require.Len(t, tuple.Exprs, 2)
+	assert.Equal(t, "body.properties.count", expressionString(t, tuple.Exprs[0]))
+	assert.Equal(t, "body.properties.vmSize", expressionString(t, tuple.Exprs[1]))
+}
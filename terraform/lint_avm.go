@@ -0,0 +1,400 @@
+package terraform
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/ext/typeexpr"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// LintLevel is the severity of a lint finding, named after the SARIF result levels.
+type LintLevel string
+
+// Lint finding levels. Only errors are AVM requirement violations.
+const (
+	LintError   LintLevel = "error"
+	LintWarning LintLevel = "warning"
+	LintNote    LintLevel = "note"
+)
+
+// LintRule is a statically checkable AVM resource module requirement.
+type LintRule struct {
+	ID          string    `json:"id"`
+	Level       LintLevel `json:"level"`
+	Description string    `json:"description"`
+}
+
+// AVMLintRules are the rules LintAVM checks.
+var AVMLintRules = []LintRule{
+	{ID: "required-variable", Level: LintError, Description: "AVM resource modules declare the name and enable_telemetry variables, and location and tags when the resource has them."},
+	{ID: "required-output", Level: LintError, Description: "AVM resource modules output resource_id, resource and name."},
+	{ID: "provider-constraint", Level: LintError, Description: "Terraform and every required provider are constrained with a source and a version with an upper bound."},
+	{ID: "telemetry", Level: LintError, Description: "AVM resource modules send telemetry with a modtm_telemetry resource gated by var.enable_telemetry."},
+	{ID: "interface", Level: LintWarning, Description: "AVM resource modules expose the lock, role_assignments and diagnostic_settings interfaces, and managed_identities when the resource has an identity."},
+	{ID: "naming", Level: LintError, Description: "Variables and outputs are snake_case."},
+	{ID: "variable-type", Level: LintError, Description: "Every variable has a type."},
+	{ID: "description", Level: LintError, Description: "Every variable and output has a description."},
+	{ID: "variable-validation", Level: LintNote, Description: "Variables accepting free-form values have a validation block."},
+}
+
+// LintFinding is a violation of a lint rule. File is relative to the linted module
+// and Line is 0 when the finding concerns the module as a whole.
+type LintFinding struct {
+	Rule    string    `json:"rule"`
+	Level   LintLevel `json:"level"`
+	Message string    `json:"message"`
+	File    string    `json:"file,omitempty"`
+	Line    int       `json:"line,omitempty"`
+}
+
+// lintModule holds the blocks of the .tf files of a module.
+type lintModule struct {
+	variables map[string]lintBlock
+	outputs   map[string]lintBlock
+	resources map[string]lintBlock
+	terraform []lintBlock
+}
+
+type lintBlock struct {
+	file  string
+	block *hclsyntax.Block
+}
+
+func (b lintBlock) line() int { return b.block.DefRange().Start.Line }
+
+// LintAVM checks the module in dir against the AVM resource module requirements
+// that can be verified statically, returning the findings sorted by file and line.
+// The module does not need to have been generated by tfmodmake.
+func LintAVM(dir string) ([]LintFinding, error) {
+	mod, err := parseLintModule(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var findings []LintFinding
+	add := func(rule, file string, line int, format string, args ...any) {
+		findings = append(findings, LintFinding{Rule: rule, Level: lintRuleLevel(rule), Message: fmt.Sprintf(format, args...), File: file, Line: line})
+	}
+
+	resource, hasResource := mod.resources["azapi_resource.this"]
+	required := []string{"enable_telemetry"}
+	// Singleton resources are given their fixed name instead of var.name.
+	if !hasResource || literalString(resource.block.Body.Attributes["name"]) == "" {
+		required = append(required, "name")
+	}
+	if hasResource && resource.block.Body.Attributes["location"] != nil {
+		required = append(required, "location")
+	}
+	if hasResource && resource.block.Body.Attributes["tags"] != nil {
+		required = append(required, "tags")
+	}
+	for _, name := range required {
+		if _, ok := mod.variables[name]; !ok {
+			add("required-variable", "variables.tf", 0, "required variable %q is missing", name)
+		}
+	}
+	for _, name := range avmRequiredOutputs {
+		if _, ok := mod.outputs[name]; !ok {
+			add("required-output", "outputs.tf", 0, "required output %q is missing", name)
+		}
+	}
+
+	findings = append(findings, lintProviderConstraints(mod)...)
+
+	if _, ok := mod.resources["modtm_telemetry.telemetry"]; !ok {
+		add("telemetry", "main.telemetry.tf", 0, "no modtm_telemetry resource; generate one with -telemetry")
+	}
+
+	interfaces := []string{"lock", "role_assignments", "diagnostic_settings"}
+	if hasResource && (lintHasBlock(resource.block.Body, "identity") || lintHasDynamicBlock(resource.block.Body, "identity")) {
+		interfaces = append(interfaces, "managed_identities")
+	}
+	for _, name := range interfaces {
+		if _, ok := mod.variables[name]; !ok {
+			add("interface", "variables.tf", 0, "interface variable %q is missing; scaffold it with add avm-interfaces", name)
+		}
+	}
+
+	for _, name := range sortedLintNames(mod.variables) {
+		v := mod.variables[name]
+		attrs := v.block.Body.Attributes
+		if !avmNamePattern.MatchString(name) {
+			add("naming", v.file, v.line(), "variable %q is not snake_case", name)
+		}
+		if strings.TrimSpace(literalString(attrs["description"])) == "" {
+			add("description", v.file, v.line(), "variable %q has no description", name)
+		}
+		typeAttr, ok := attrs["type"]
+		if !ok {
+			add("variable-type", v.file, v.line(), "variable %q has no type", name)
+			continue
+		}
+		if !lintHasBlock(v.block.Body, "validation") && lintNeedsValidation(typeAttr) {
+			add("variable-validation", v.file, v.line(), "variable %q has no validation", name)
+		}
+	}
+	for _, name := range sortedLintNames(mod.outputs) {
+		o := mod.outputs[name]
+		if !avmNamePattern.MatchString(name) {
+			add("naming", o.file, o.line(), "output %q is not snake_case", name)
+		}
+		if strings.TrimSpace(literalString(o.block.Body.Attributes["description"])) == "" {
+			add("description", o.file, o.line(), "output %q has no description", name)
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].File != findings[j].File {
+			return findings[i].File < findings[j].File
+		}
+		return findings[i].Line < findings[j].Line
+	})
+	return findings, nil
+}
+
+func parseLintModule(dir string) (*lintModule, error) {
+	mod := &lintModule{
+		variables: map[string]lintBlock{},
+		outputs:   map[string]lintBlock{},
+		resources: map[string]lintBlock{},
+	}
+	found := false
+	err := parseTFFiles(dir, func(name string, _ []byte, body *hclsyntax.Body) error {
+		found = true
+		for _, block := range body.Blocks {
+			b := lintBlock{file: name, block: block}
+			switch {
+			case block.Type == "variable" && len(block.Labels) == 1:
+				mod.variables[block.Labels[0]] = b
+			case block.Type == "output" && len(block.Labels) == 1:
+				mod.outputs[block.Labels[0]] = b
+			case block.Type == "resource" && len(block.Labels) == 2:
+				mod.resources[block.Labels[0]+"."+block.Labels[1]] = b
+			case block.Type == "terraform":
+				mod.terraform = append(mod.terraform, b)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("no .tf files in %s", dir)
+	}
+	return mod, nil
+}
+
+// lintProviderConstraints checks required_version and the required_providers.
+func lintProviderConstraints(mod *lintModule) []LintFinding {
+	finding := func(file string, line int, format string, args ...any) LintFinding {
+		return LintFinding{Rule: "provider-constraint", Level: LintError, Message: fmt.Sprintf(format, args...), File: file, Line: line}
+	}
+	if len(mod.terraform) == 0 {
+		return []LintFinding{finding("terraform.tf", 0, "no terraform block constraining the Terraform and provider versions")}
+	}
+
+	var findings []LintFinding
+	hasRequiredVersion := false
+	providers := map[string]bool{}
+	for _, tf := range mod.terraform {
+		if attr := tf.block.Body.Attributes["required_version"]; attr != nil {
+			hasRequiredVersion = true
+			if version := literalString(attr); !lintBoundedConstraint(version) {
+				findings = append(findings, finding(tf.file, attr.SrcRange.Start.Line, "required_version %q has no upper bound; use a ~> constraint", version))
+			}
+		}
+		for _, block := range tf.block.Body.Blocks {
+			if block.Type != "required_providers" {
+				continue
+			}
+			for _, name := range sortedAttributeNames(block.Body.Attributes) {
+				attr := block.Body.Attributes[name]
+				providers[name] = true
+				value, diags := attr.Expr.Value(nil)
+				if diags.HasErrors() || !value.Type().IsObjectType() {
+					findings = append(findings, finding(tf.file, attr.SrcRange.Start.Line, "provider %q must be an object with source and version", name))
+					continue
+				}
+				source, version := lintObjectString(value, "source"), lintObjectString(value, "version")
+				if source == "" {
+					findings = append(findings, finding(tf.file, attr.SrcRange.Start.Line, "provider %q has no source", name))
+				}
+				if !lintBoundedConstraint(version) {
+					findings = append(findings, finding(tf.file, attr.SrcRange.Start.Line, "provider %q version %q has no upper bound; use a ~> constraint", name, version))
+				}
+			}
+		}
+	}
+	if !hasRequiredVersion {
+		findings = append(findings, finding(mod.terraform[0].file, mod.terraform[0].line(), "no required_version constraint"))
+	}
+	if _, ok := mod.resources["modtm_telemetry.telemetry"]; ok && !providers["modtm"] {
+		findings = append(findings, finding(mod.terraform[0].file, mod.terraform[0].line(), "the modtm provider used by telemetry is not required"))
+	}
+	return findings
+}
+
+// lintBoundedConstraint reports whether a version constraint has an upper bound.
+func lintBoundedConstraint(constraint string) bool {
+	return strings.Contains(constraint, "~>") || strings.Contains(constraint, "<")
+}
+
+func lintObjectString(value cty.Value, name string) string {
+	if !value.Type().HasAttribute(name) {
+		return ""
+	}
+	attr := value.GetAttr(name)
+	if attr.IsNull() || !attr.Type().Equals(cty.String) {
+		return ""
+	}
+	return attr.AsString()
+}
+
+// lintNeedsValidation reports whether values of the type are free-form: strings,
+// numbers and collections of them. Booleans and structured objects are not.
+func lintNeedsValidation(attr *hclsyntax.Attribute) bool {
+	ty, diags := typeexpr.TypeConstraint(attr.Expr)
+	if diags.HasErrors() {
+		return false
+	}
+	if ty.IsCollectionType() {
+		ty = ty.ElementType()
+	}
+	return ty == cty.String || ty == cty.Number
+}
+
+func lintHasBlock(body *hclsyntax.Body, blockType string) bool {
+	for _, block := range body.Blocks {
+		if block.Type == blockType {
+			return true
+		}
+	}
+	return false
+}
+
+func lintHasDynamicBlock(body *hclsyntax.Body, blockType string) bool {
+	for _, block := range body.Blocks {
+		if block.Type == "dynamic" && len(block.Labels) == 1 && block.Labels[0] == blockType {
+			return true
+		}
+	}
+	return false
+}
+
+func lintRuleLevel(id string) LintLevel {
+	for _, rule := range AVMLintRules {
+		if rule.ID == id {
+			return rule.Level
+		}
+	}
+	return LintError
+}
+
+func sortedLintNames(blocks map[string]lintBlock) []string {
+	names := make([]string, 0, len(blocks))
+	for name := range blocks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func sortedAttributeNames(attrs hclsyntax.Attributes) []string {
+	names := make([]string, 0, len(attrs))
+	for name := range attrs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// WriteLintJSON writes the findings as an indented JSON array.
+func WriteLintJSON(w io.Writer, findings []LintFinding) error {
+	if findings == nil {
+		findings = []LintFinding{}
+	}
+	data, err := json.MarshalIndent(findings, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// WriteLintSARIF writes the findings as a SARIF 2.1.0 log for code scanning.
+func WriteLintSARIF(w io.Writer, findings []LintFinding) error {
+	type message struct {
+		Text string `json:"text"`
+	}
+	type region struct {
+		StartLine int `json:"startLine"`
+	}
+	type artifactLocation struct {
+		URI string `json:"uri"`
+	}
+	type physicalLocation struct {
+		ArtifactLocation artifactLocation `json:"artifactLocation"`
+		Region           *region          `json:"region,omitempty"`
+	}
+	type location struct {
+		PhysicalLocation physicalLocation `json:"physicalLocation"`
+	}
+	type result struct {
+		RuleID    string     `json:"ruleId"`
+		Level     LintLevel  `json:"level"`
+		Message   message    `json:"message"`
+		Locations []location `json:"locations,omitempty"`
+	}
+	type rule struct {
+		ID                   string  `json:"id"`
+		ShortDescription     message `json:"shortDescription"`
+		DefaultConfiguration struct {
+			Level LintLevel `json:"level"`
+		} `json:"defaultConfiguration"`
+	}
+
+	rules := make([]rule, 0, len(AVMLintRules))
+	for _, r := range AVMLintRules {
+		sr := rule{ID: r.ID, ShortDescription: message{Text: r.Description}}
+		sr.DefaultConfiguration.Level = r.Level
+		rules = append(rules, sr)
+	}
+	results := make([]result, 0, len(findings))
+	for _, f := range findings {
+		r := result{RuleID: f.Rule, Level: f.Level, Message: message{Text: f.Message}}
+		if f.File != "" {
+			loc := location{PhysicalLocation: physicalLocation{ArtifactLocation: artifactLocation{URI: filepath.ToSlash(f.File)}}}
+			if f.Line > 0 {
+				loc.PhysicalLocation.Region = &region{StartLine: f.Line}
+			}
+			r.Locations = []location{loc}
+		}
+		results = append(results, r)
+	}
+
+	log := map[string]any{
+		"$schema": "https://json.schemastore.org/sarif-2.1.0.json",
+		"version": "2.1.0",
+		"runs": []any{map[string]any{
+			"tool": map[string]any{"driver": map[string]any{
+				"name":           "tfmodmake",
+				"informationUri": "https://github.com/matt-FFFFFF/tfmodmake",
+				"rules":          rules,
+			}},
+			"results": results,
+		}},
+	}
+	data, err := json.MarshalIndent(log, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
@@ -0,0 +1,138 @@
+package terraform
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func lintFindingsOf(findings []LintFinding, level LintLevel) []LintFinding {
+	var out []LintFinding
+	for _, f := range findings {
+		if f.Level == level {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+func TestLintAVM_GeneratedModule(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, Generate("Microsoft.Test/widgets", WithResourceSchema(avmStrictSchema()), WithAPIVersion("2024-01-01"), WithAVMStrict(true), WithOutputDir(dir)))
+	_, err := GenerateInterfaces("Microsoft.Test/widgets", avmStrictSchema(), dir, nil, nil)
+	require.NoError(t, err)
+
+	findings, err := LintAVM(dir)
+	require.NoError(t, err)
+	assert.Empty(t, lintFindingsOf(findings, LintError))
+	assert.Empty(t, lintFindingsOf(findings, LintWarning))
+}
+
+func TestLintAVM_Violations(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"terraform.tf": `terraform {
+  required_providers {
+    azapi = {
+      source  = "azure/azapi"
+      version = ">= 2.0"
+    }
+  }
+}
+`,
+		"main.tf": `resource "azapi_resource" "this" {
+  type     = "Microsoft.Test/widgets@2024-01-01"
+  name     = var.name
+  location = var.location
+  body     = {}
+}
+`,
+		"variables.tf": `variable "name" {
+  type        = string
+  description = "The name."
+}
+
+variable "SkuName" {
+  type = string
+}
+`,
+		"outputs.tf": `output "resource_id" {
+  description = "The ID."
+  value       = azapi_resource.this.id
+}
+`,
+	}
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+
+	findings, err := LintAVM(dir)
+	require.NoError(t, err)
+
+	messages := map[string][]string{}
+	for _, f := range findings {
+		messages[f.Rule] = append(messages[f.Rule], f.Message)
+	}
+	assert.ElementsMatch(t, []string{`required variable "enable_telemetry" is missing`, `required variable "location" is missing`}, messages["required-variable"])
+	assert.ElementsMatch(t, []string{`required output "resource" is missing`, `required output "name" is missing`}, messages["required-output"])
+	assert.ElementsMatch(t, []string{"no required_version constraint", `provider "azapi" version ">= 2.0" has no upper bound; use a ~> constraint`}, messages["provider-constraint"])
+	assert.Len(t, messages["telemetry"], 1)
+	assert.Len(t, messages["interface"], 3)
+	assert.Equal(t, []string{`variable "SkuName" is not snake_case`}, messages["naming"])
+	assert.Equal(t, []string{`variable "SkuName" has no description`}, messages["description"])
+	assert.ElementsMatch(t, []string{`variable "SkuName" has no validation`, `variable "name" has no validation`}, messages["variable-validation"])
+
+	for _, f := range findings {
+		if f.Rule == "naming" {
+			assert.Equal(t, "variables.tf", f.File)
+			assert.Equal(t, 6, f.Line)
+		}
+	}
+}
+
+func TestWriteLintSARIF(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteLintSARIF(&buf, []LintFinding{
+		{Rule: "naming", Level: LintError, Message: `variable "SkuName" is not snake_case`, File: "variables.tf", Line: 6},
+		{Rule: "telemetry", Level: LintError, Message: "no modtm_telemetry resource", File: "main.telemetry.tf"},
+	}))
+
+	var log struct {
+		Version string `json:"version"`
+		Runs    []struct {
+			Tool struct {
+				Driver struct {
+					Rules []struct {
+						ID string `json:"id"`
+					} `json:"rules"`
+				} `json:"driver"`
+			} `json:"tool"`
+			Results []struct {
+				RuleID    string `json:"ruleId"`
+				Locations []struct {
+					PhysicalLocation struct {
+						ArtifactLocation struct {
+							URI string `json:"uri"`
+						} `json:"artifactLocation"`
+						Region *struct {
+							StartLine int `json:"startLine"`
+						} `json:"region"`
+					} `json:"physicalLocation"`
+				} `json:"locations"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &log))
+	assert.Equal(t, "2.1.0", log.Version)
+	require.Len(t, log.Runs, 1)
+	assert.Len(t, log.Runs[0].Tool.Driver.Rules, len(AVMLintRules))
+	require.Len(t, log.Runs[0].Results, 2)
+	assert.Equal(t, "naming", log.Runs[0].Results[0].RuleID)
+	assert.Equal(t, 6, log.Runs[0].Results[0].Locations[0].PhysicalLocation.Region.StartLine)
+	assert.Nil(t, log.Runs[0].Results[1].Locations[0].PhysicalLocation.Region)
+}
@@ -0,0 +1,300 @@
+package terraform
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/ext/typeexpr"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/matt-FFFFFF/tfmodmake/hclgen"
+	"github.com/matt-FFFFFF/tfmodmake/naming"
+	"github.com/matt-FFFFFF/tfmodmake/schema"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// ModuleInterfaceFileName is the file the module interface manifest is written to.
+const ModuleInterfaceFileName = "module-interface.json"
+
+// ModuleInterface describes the inputs and outputs of a generated module for
+// service catalogs, provisioning UIs and policy engines. Unlike the HCL, it keeps
+// the link from each variable and output to the resource body path it maps to.
+type ModuleInterface struct {
+	ResourceType string `json:"resource_type"`
+	APIVersion   string `json:"api_version,omitempty"`
+	// Kind is "tracked", "proxy" or "extension"; see schema.ResourceKind. It
+	// is omitted when the module was generated without a schema.
+	Kind      schema.ResourceKind `json:"kind,omitempty"`
+	Variables []InterfaceVariable `json:"variables"`
+	Outputs   []InterfaceOutput   `json:"outputs"`
+}
+
+// InterfaceVariable describes a module variable. Schema is the JSON Schema of its
+// type; Default is omitted for required variables.
+type InterfaceVariable struct {
+	Name        string                `json:"name"`
+	Description string                `json:"description,omitempty"`
+	Schema      map[string]any        `json:"schema"`
+	Required    bool                  `json:"required"`
+	Default     json.RawMessage       `json:"default,omitempty"`
+	Sensitive   bool                  `json:"sensitive,omitempty"`
+	Ephemeral   bool                  `json:"ephemeral,omitempty"`
+	Validations []InterfaceValidation `json:"validations,omitempty"`
+	SourcePath  string                `json:"source_path,omitempty"`
+}
+
+// InterfaceValidation is a validation block of a variable; Condition is its HCL source.
+type InterfaceValidation struct {
+	Condition    string `json:"condition"`
+	ErrorMessage string `json:"error_message"`
+}
+
+// InterfaceOutput describes a module output. SourcePath is the response path it reads.
+type InterfaceOutput struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Sensitive   bool   `json:"sensitive,omitempty"`
+	SourcePath  string `json:"source_path,omitempty"`
+}
+
+// buildModuleInterface describes the generated variables and outputs files.
+// variableSources and outputSources map names to the body or response paths.
+func buildModuleInterface(resourceType, apiVersion string, kind schema.ResourceKind, variables, outputs *hclwrite.File, variableSources, outputSources map[string]string) (*ModuleInterface, error) {
+	mi := &ModuleInterface{
+		ResourceType: cleanTypeString(resourceType),
+		APIVersion:   apiVersion,
+		Kind:         kind,
+		Variables:    []InterfaceVariable{},
+		Outputs:      []InterfaceOutput{},
+	}
+
+	if variables != nil {
+		src := variables.Bytes()
+		body, err := parseSyntaxBody(src, "variables.tf")
+		if err != nil {
+			return nil, err
+		}
+		for _, block := range body.Blocks {
+			if block.Type != "variable" || len(block.Labels) != 1 {
+				continue
+			}
+			v, err := interfaceVariable(block, src)
+			if err != nil {
+				return nil, fmt.Errorf("describing variable %s: %w", block.Labels[0], err)
+			}
+			v.SourcePath = variableSources[v.Name]
+			mi.Variables = append(mi.Variables, v)
+		}
+	}
+
+	if outputs != nil {
+		body, err := parseSyntaxBody(outputs.Bytes(), "outputs.tf")
+		if err != nil {
+			return nil, err
+		}
+		for _, block := range body.Blocks {
+			if block.Type != "output" || len(block.Labels) != 1 {
+				continue
+			}
+			name := block.Labels[0]
+			mi.Outputs = append(mi.Outputs, InterfaceOutput{
+				Name:        name,
+				Description: literalString(block.Body.Attributes["description"]),
+				Sensitive:   literalBool(block.Body.Attributes["sensitive"]),
+				SourcePath:  outputSources[name],
+			})
+		}
+	}
+
+	return mi, nil
+}
+
+func interfaceVariable(block *hclsyntax.Block, src []byte) (InterfaceVariable, error) {
+	attrs := block.Body.Attributes
+	v := InterfaceVariable{
+		Name:        block.Labels[0],
+		Description: literalString(attrs["description"]),
+		Sensitive:   literalBool(attrs["sensitive"]),
+		Ephemeral:   literalBool(attrs["ephemeral"]),
+		Schema:      map[string]any{},
+	}
+
+	if typeAttr, ok := attrs["type"]; ok {
+		ty, _, diags := typeexpr.TypeConstraintWithDefaults(typeAttr.Expr)
+		if diags.HasErrors() {
+			return v, fmt.Errorf("invalid type: %s", diags.Error())
+		}
+		v.Schema = jsonSchemaForType(ty)
+	}
+
+	if defaultAttr, ok := attrs["default"]; ok {
+		value, diags := defaultAttr.Expr.Value(nil)
+		if diags.HasErrors() {
+			return v, fmt.Errorf("invalid default: %s", diags.Error())
+		}
+		raw, err := ctyjson.Marshal(value, value.Type())
+		if err != nil {
+			return v, err
+		}
+		v.Default = raw
+	} else {
+		v.Required = true
+	}
+
+	for _, validation := range block.Body.Blocks {
+		if validation.Type != "validation" {
+			continue
+		}
+		condition, ok := validation.Body.Attributes["condition"]
+		if !ok {
+			continue
+		}
+		v.Validations = append(v.Validations, InterfaceValidation{
+			Condition:    string(condition.Expr.Range().SliceBytes(src)),
+			ErrorMessage: literalString(validation.Body.Attributes["error_message"]),
+		})
+	}
+	return v, nil
+}
+
+// literalString evaluates a constant string attribute, returning "" otherwise.
+func literalString(attr *hclsyntax.Attribute) string {
+	if attr == nil {
+		return ""
+	}
+	value, diags := attr.Expr.Value(nil)
+	if diags.HasErrors() || value.IsNull() || !value.Type().Equals(cty.String) {
+		return ""
+	}
+	return strings.TrimSuffix(value.AsString(), "\n")
+}
+
+// literalBool evaluates a constant bool attribute, returning false otherwise.
+func literalBool(attr *hclsyntax.Attribute) bool {
+	if attr == nil {
+		return false
+	}
+	value, diags := attr.Expr.Value(nil)
+	return !diags.HasErrors() && !value.IsNull() && value.Type().Equals(cty.Bool) && value.True()
+}
+
+// jsonSchemaForType converts a Terraform type constraint into a JSON Schema.
+func jsonSchemaForType(ty cty.Type) map[string]any {
+	switch {
+	case ty == cty.String:
+		return map[string]any{"type": "string"}
+	case ty == cty.Number:
+		return map[string]any{"type": "number"}
+	case ty == cty.Bool:
+		return map[string]any{"type": "boolean"}
+	case ty.IsListType():
+		return map[string]any{"type": "array", "items": jsonSchemaForType(ty.ElementType())}
+	case ty.IsSetType():
+		return map[string]any{"type": "array", "items": jsonSchemaForType(ty.ElementType()), "uniqueItems": true}
+	case ty.IsMapType():
+		return map[string]any{"type": "object", "additionalProperties": jsonSchemaForType(ty.ElementType())}
+	case ty.IsTupleType():
+		items := make([]any, 0, len(ty.TupleElementTypes()))
+		for _, et := range ty.TupleElementTypes() {
+			items = append(items, jsonSchemaForType(et))
+		}
+		return map[string]any{"type": "array", "prefixItems": items}
+	case ty.IsObjectType():
+		properties := make(map[string]any, len(ty.AttributeTypes()))
+		var required []string
+		for name, at := range ty.AttributeTypes() {
+			properties[name] = jsonSchemaForType(at)
+			if !ty.AttributeOptional(name) {
+				required = append(required, name)
+			}
+		}
+		sort.Strings(required)
+		out := map[string]any{"type": "object", "properties": properties, "additionalProperties": false}
+		if len(required) > 0 {
+			out["required"] = required
+		}
+		return out
+	}
+	// any
+	return map[string]any{}
+}
+
+// variableSourcePaths maps the variables generated from the resource body to
+// their body paths, mirroring the naming of buildVariables.
+func variableSourcePaths(rs *schema.ResourceSchema, resourceType string, supportsIdentity bool, secrets []secretField, moduleNamePrefix string) map[string]string {
+	sources := make(map[string]string)
+	if rs == nil {
+		return sources
+	}
+	varName := func(name string) string {
+		tfName := naming.ToSnakeCase(name)
+		if moduleNamePrefix != "" && tfName == "version" {
+			tfName = moduleNamePrefix + "_version"
+		}
+		return tfName
+	}
+	for name, prop := range rs.Properties {
+		if prop == nil {
+			continue
+		}
+		switch name {
+		case "identity":
+			if supportsIdentity {
+				sources["managed_identities"] = "identity"
+			}
+		case "properties":
+			for childName, child := range prop.Children {
+				if child != nil && isWritableProperty(child) {
+					sources[varName(childName)] = "properties." + childName
+				}
+			}
+		default:
+			if isWritableProperty(prop) {
+				sources[varName(name)] = name
+			}
+		}
+	}
+	for _, ref := range associationReferences(rs, resourceType) {
+		if ref.subResource {
+			delete(sources, varName(ref.property))
+			sources[ref.variable] = "properties." + ref.property + ".id"
+		}
+	}
+	for _, secret := range secrets {
+		if _, ok := sources[secret.varName]; !ok {
+			sources[secret.varName] = secret.path
+		}
+	}
+	return sources
+}
+
+// outputSourcePaths maps the generated outputs to the response paths they read.
+func outputSourcePaths(exports []exportedOutput, supportsIdentity bool) map[string]string {
+	sources := map[string]string{"resource_id": "id", "name": "name"}
+	if supportsIdentity {
+		sources["system_assigned_mi_principal_id"] = "identity.principalId"
+		sources["system_assigned_mi_tenant_id"] = "identity.tenantId"
+		sources["user_assigned_identities"] = "identity.userAssignedIdentities"
+	}
+	for _, export := range exports {
+		sources[export.name] = export.path
+	}
+	return sources
+}
+
+// writeModuleInterface writes the manifest as indented JSON to w. HTML is not
+// escaped, so conditions keep their operators readable, e.g. >= rather than \u003e=.
+func writeModuleInterface(w hclgen.Writer, mi *ModuleInterface) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(mi); err != nil {
+		return err
+	}
+	return w.WriteFile(ModuleInterfaceFileName, buf.Bytes())
+}
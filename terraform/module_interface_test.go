@@ -0,0 +1,108 @@
+package terraform
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/matt-FFFFFF/tfmodmake/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestJSONSchemaForType(t *testing.T) {
+	ty := cty.ObjectWithOptionalAttrs(map[string]cty.Type{
+		"name":  cty.String,
+		"count": cty.Number,
+		"tags":  cty.Map(cty.String),
+		"ids":   cty.Set(cty.String),
+	}, []string{"count", "tags", "ids"})
+
+	assert.Equal(t, map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"name":  map[string]any{"type": "string"},
+			"count": map[string]any{"type": "number"},
+			"tags":  map[string]any{"type": "object", "additionalProperties": map[string]any{"type": "string"}},
+			"ids":   map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "uniqueItems": true},
+		},
+		"required":             []string{"name"},
+		"additionalProperties": false,
+	}, jsonSchemaForType(ty))
+	assert.Equal(t, map[string]any{"type": "array", "items": map[string]any{"type": "boolean"}}, jsonSchemaForType(cty.List(cty.Bool)))
+	assert.Equal(t, map[string]any{}, jsonSchemaForType(cty.DynamicPseudoType))
+}
+
+func TestGenerate_ModuleInterface(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+
+	require.NoError(t, os.Chdir(tmpDir))
+
+	rs := &schema.ResourceSchema{
+		Properties: map[string]*schema.Property{
+			"name": {Name: "name", Type: schema.TypeString, Required: true},
+			"properties": {Name: "properties", Type: schema.TypeObject, Children: map[string]*schema.Property{
+				"replicaCount":  {Name: "replicaCount", Type: schema.TypeInteger, Description: "Number of replicas.", Constraints: schema.Constraints{MinValue: ptrInt64(1)}},
+				"adminPassword": {Name: "adminPassword", Type: schema.TypeString, Sensitive: true},
+				"fqdn":          {Name: "fqdn", Type: schema.TypeString, ReadOnly: true, Description: "The FQDN."},
+			}},
+		},
+	}
+
+	require.NoError(t, Generate("Microsoft.Test/widgets", WithResourceSchema(rs), WithAPIVersion("2024-01-01"), WithModuleInterface(true)))
+
+	data, err := os.ReadFile(ModuleInterfaceFileName)
+	require.NoError(t, err)
+	var mi ModuleInterface
+	require.NoError(t, json.Unmarshal(data, &mi))
+
+	assert.Equal(t, "Microsoft.Test/widgets", mi.ResourceType)
+	assert.Equal(t, "2024-01-01", mi.APIVersion)
+	assert.Equal(t, schema.ResourceKindProxy, mi.Kind)
+
+	variables := make(map[string]InterfaceVariable)
+	for _, v := range mi.Variables {
+		variables[v.Name] = v
+	}
+	replicas := variables["replica_count"]
+	assert.Equal(t, "properties.replicaCount", replicas.SourcePath)
+	assert.Equal(t, "Number of replicas.", replicas.Description)
+	assert.Equal(t, map[string]any{"type": "number"}, replicas.Schema)
+	assert.False(t, replicas.Required)
+	assert.JSONEq(t, "null", string(replicas.Default))
+	require.NotEmpty(t, replicas.Validations)
+	assert.Contains(t, replicas.Validations[0].Condition, "var.replica_count")
+	assert.Contains(t, string(data), replicas.Validations[0].Condition)
+	assert.NotContains(t, string(data), `\u003e`)
+
+	assert.True(t, variables["parent_id"].Required)
+	assert.Empty(t, variables["parent_id"].SourcePath)
+	assert.Equal(t, "name", variables["name"].SourcePath)
+	assert.Equal(t, "properties.adminPassword", variables["admin_password"].SourcePath)
+	assert.True(t, variables["admin_password"].Ephemeral || variables["admin_password"].Sensitive)
+
+	outputs := make(map[string]InterfaceOutput)
+	for _, o := range mi.Outputs {
+		outputs[o.Name] = o
+	}
+	assert.Equal(t, "id", outputs["resource_id"].SourcePath)
+	assert.Equal(t, InterfaceOutput{Name: "fqdn", Description: "The FQDN.", SourcePath: "properties.fqdn"}, outputs["fqdn"])
+}
+
+func TestGenerate_ModuleInterfaceOptIn(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+
+	require.NoError(t, os.Chdir(tmpDir))
+
+	require.NoError(t, Generate("Microsoft.Test/widgets", WithResourceSchema(&schema.ResourceSchema{}), WithAPIVersion("2024-01-01")))
+	assert.NoFileExists(t, ModuleInterfaceFileName)
+}
@@ -0,0 +1,73 @@
+package terraform
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/matt-FFFFFF/tfmodmake/hclgen"
+)
+
+// resourceMovesForRegeneration compares the resources declared by an existing
+// main.tf in dir with those of the regenerated main file. A resource type that lost
+// exactly one label and gained exactly one is treated as renamed, so state migrates
+// with a moved block instead of a destroy and re-create.
+func resourceMovesForRegeneration(dir string, newMain *hclwrite.File) ([]hclgen.Move, error) {
+	path := filepath.Join(dir, "main.tf")
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	oldMain, err := ParseHCLFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	oldLabels := resourceLabelsByType(oldMain)
+	newLabels := resourceLabelsByType(newMain)
+
+	var moves []hclgen.Move
+	for resourceType, labels := range newLabels {
+		removed := labelDifference(oldLabels[resourceType], labels)
+		added := labelDifference(labels, oldLabels[resourceType])
+		if len(removed) != 1 || len(added) != 1 {
+			continue
+		}
+		moves = append(moves, hclgen.Move{
+			From: resourceType + "." + removed[0],
+			To:   resourceType + "." + added[0],
+		})
+	}
+	sort.Slice(moves, func(i, j int) bool { return moves[i].From < moves[j].From })
+	return moves, nil
+}
+
+func resourceLabelsByType(file *hclwrite.File) map[string]map[string]struct{} {
+	labels := make(map[string]map[string]struct{})
+	if file == nil {
+		return labels
+	}
+	for _, block := range file.Body().Blocks() {
+		if block.Type() != "resource" || len(block.Labels()) != 2 {
+			continue
+		}
+		resourceType, name := block.Labels()[0], block.Labels()[1]
+		if labels[resourceType] == nil {
+			labels[resourceType] = make(map[string]struct{})
+		}
+		labels[resourceType][name] = struct{}{}
+	}
+	return labels
+}
+
+// labelDifference returns the labels in a that are not in b.
+func labelDifference(a, b map[string]struct{}) []string {
+	var diff []string
+	for label := range a {
+		if _, ok := b[label]; !ok {
+			diff = append(diff, label)
+		}
+	}
+	return diff
+}
@@ -0,0 +1,49 @@
+package terraform
+
+import (
+	"os"
+	"testing"
+
+	"github.com/matt-FFFFFF/tfmodmake/hclgen"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate_RegenerationWritesMovedBlocks(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+
+	require.NoError(t, os.Chdir(tmpDir))
+
+	// A module generated with a different resource label.
+	require.NoError(t, os.WriteFile("main.tf", []byte(`resource "azapi_resource" "main" {
+  type = "Microsoft.Test/widgets@2024-01-01"
+}
+`), 0o644))
+
+	require.NoError(t, Generate("Microsoft.Test/widgets", WithResourceSchema(authSchema()), WithAPIVersion("2025-01-01")))
+
+	movedBody := parseHCLBody(t, hclgen.MovedFileName)
+	moved := requireBlock(t, movedBody, "moved")
+	assert.Equal(t, "azapi_resource.main", expressionString(t, moved.Body.Attributes["from"].Expr))
+	assert.Equal(t, "azapi_resource.this", expressionString(t, moved.Body.Attributes["to"].Expr))
+}
+
+func TestGenerate_RegenerationWithoutRenameWritesNoMovedFile(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+
+	require.NoError(t, os.Chdir(tmpDir))
+
+	require.NoError(t, Generate("Microsoft.Test/widgets", WithResourceSchema(authSchema()), WithAPIVersion("2025-01-01")))
+	require.NoError(t, Generate("Microsoft.Test/widgets", WithResourceSchema(authSchema()), WithAPIVersion("2025-01-01")))
+
+	_, err = os.Stat(hclgen.MovedFileName)
+	assert.True(t, os.IsNotExist(err))
+}
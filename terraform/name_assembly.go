@@ -0,0 +1,225 @@
+package terraform
+
+import (
+	"fmt"
+	"regexp/syntax"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/matt-FFFFFF/tfmodmake/hclgen"
+	"github.com/matt-FFFFFF/tfmodmake/schema"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// namingVariableName is the object variable a name is assembled from when var.name is null.
+const namingVariableName = "naming"
+
+// appendNamingVariable appends the naming object variable.
+func appendNamingVariable(body *hclwrite.Body) {
+	varBody := body.AppendNewBlock("variable", []string{namingVariableName}).Body()
+	hclgen.SetDescriptionAttribute(varBody, `Assembles the resource name when var.name is null.
+
+- prefix: Text placed before the random part.
+- suffix: Text placed after the random part.
+- random_length: Number of random lowercase alphanumeric characters between prefix and suffix.
+
+Characters the resource type does not allow are removed. A precondition checks the result against the length and pattern of the resource name.`)
+	varBody.SetAttributeRaw("type", hclwrite.TokensForFunctionCall("object", hclwrite.TokensForObject([]hclwrite.ObjectAttrTokens{
+		{Name: hclwrite.TokensForIdentifier("prefix"), Value: hclwrite.TokensForFunctionCall("optional", hclwrite.TokensForIdentifier("string"), hclwrite.TokensForValue(cty.StringVal("")))},
+		{Name: hclwrite.TokensForIdentifier("suffix"), Value: hclwrite.TokensForFunctionCall("optional", hclwrite.TokensForIdentifier("string"), hclwrite.TokensForValue(cty.StringVal("")))},
+		{Name: hclwrite.TokensForIdentifier("random_length"), Value: hclwrite.TokensForFunctionCall("optional", hclwrite.TokensForIdentifier("number"), hclwrite.TokensForValue(cty.NumberIntVal(0)))},
+	})))
+	varBody.SetAttributeRaw("default", hclwrite.TokensForObject(nil))
+	varBody.SetAttributeValue("nullable", cty.False)
+	body.AppendNewline()
+}
+
+// appendRandomNameResource appends the random_string supplying the random part of
+// an assembled name. It only exists when a random part is requested.
+func appendRandomNameResource(body *hclwrite.Body) {
+	resourceBody := body.AppendNewBlock("resource", []string{"random_string", "name"}).Body()
+	var count hclwrite.Tokens
+	count = append(count, hclgen.TokensForTraversal("var", "name")...)
+	count = append(count, &hclwrite.Token{Type: hclsyntax.TokenEqualOp, Bytes: []byte(" == ")})
+	count = append(count, hclwrite.TokensForIdentifier("null")...)
+	count = append(count, &hclwrite.Token{Type: hclsyntax.TokenAnd, Bytes: []byte(" && ")})
+	count = append(count, hclgen.TokensForTraversal("var", namingVariableName, "random_length")...)
+	count = append(count, &hclwrite.Token{Type: hclsyntax.TokenGreaterThan, Bytes: []byte(" > ")})
+	count = append(count, hclwrite.TokensForValue(cty.NumberIntVal(0))...)
+	count = append(count, &hclwrite.Token{Type: hclsyntax.TokenQuestion, Bytes: []byte(" ? ")})
+	count = append(count, hclwrite.TokensForIdentifier("1")...)
+	count = append(count, &hclwrite.Token{Type: hclsyntax.TokenColon, Bytes: []byte(" : ")})
+	count = append(count, hclwrite.TokensForIdentifier("0")...)
+	resourceBody.SetAttributeRaw("count", count)
+	resourceBody.SetAttributeRaw("length", hclgen.TokensForTraversal("var", namingVariableName, "random_length"))
+	resourceBody.SetAttributeValue("lower", cty.True)
+	resourceBody.SetAttributeValue("numeric", cty.True)
+	resourceBody.SetAttributeValue("special", cty.False)
+	resourceBody.SetAttributeValue("upper", cty.False)
+	body.AppendNewline()
+}
+
+// tokensForNameLocal returns var.name, or the name assembled from var.naming
+// without the characters nameProp does not allow.
+func tokensForNameLocal(nameProp *schema.Property) hclwrite.Tokens {
+	assembled := hclwrite.TokensForFunctionCall("join",
+		hclwrite.TokensForValue(cty.StringVal("")),
+		hclwrite.TokensForTuple([]hclwrite.Tokens{
+			hclgen.TokensForTraversal("var", namingVariableName, "prefix"),
+			hclwrite.TokensForFunctionCall("try", tokensForRandomNameResult(), hclwrite.TokensForValue(cty.StringVal(""))),
+			hclgen.TokensForTraversal("var", namingVariableName, "suffix"),
+		}),
+	)
+	if nameProp != nil {
+		if class, ok := nameCharacterClass(nameProp.Constraints.Pattern); ok {
+			assembled = hclwrite.TokensForFunctionCall("replace", assembled, hclwrite.TokensForValue(cty.StringVal("/[^"+class+"]/")), hclwrite.TokensForValue(cty.StringVal("")))
+		}
+	}
+
+	var t hclwrite.Tokens
+	t = append(t, hclgen.TokensForTraversal("var", "name")...)
+	t = append(t, &hclwrite.Token{Type: hclsyntax.TokenEqualOp, Bytes: []byte(" == ")})
+	t = append(t, hclwrite.TokensForIdentifier("null")...)
+	t = append(t, &hclwrite.Token{Type: hclsyntax.TokenQuestion, Bytes: []byte(" ? ")})
+	t = append(t, assembled...)
+	t = append(t, &hclwrite.Token{Type: hclsyntax.TokenColon, Bytes: []byte(" : ")})
+	t = append(t, hclgen.TokensForTraversal("var", "name")...)
+	return t
+}
+
+// assembledNamePreconditions checks the name assembled from var.naming against
+// the length and pattern of nameProp, so a name the resource type rejects fails
+// at plan instead of being shortened or sent as is. A var.name set by the caller
+// is checked by its own validations.
+func assembledNamePreconditions(nameProp *schema.Property) []resolvedPrecondition {
+	if nameProp == nil {
+		return nil
+	}
+	check := func(inner hclwrite.Tokens, message string) resolvedPrecondition {
+		var condition hclwrite.Tokens
+		condition = append(condition, hclgen.TokensForTraversal("var", "name")...)
+		condition = append(condition, &hclwrite.Token{Type: hclsyntax.TokenNotEqual, Bytes: []byte(" != ")})
+		condition = append(condition, hclwrite.TokensForIdentifier("null")...)
+		condition = append(condition, &hclwrite.Token{Type: hclsyntax.TokenOr, Bytes: []byte(" || ")})
+		condition = append(condition, inner...)
+		return resolvedPrecondition{condition: condition, errorMessage: "The name assembled from var.naming " + message, variable: namingVariableName}
+	}
+	length := func(op hclsyntax.TokenType, opBytes string, n int64) hclwrite.Tokens {
+		t := hclwrite.TokensForFunctionCall("length", hclgen.TokensForTraversal("local", "name"))
+		t = append(t, &hclwrite.Token{Type: op, Bytes: []byte(opBytes)})
+		return append(t, hclwrite.TokensForValue(cty.NumberIntVal(n))...)
+	}
+
+	var preconditions []resolvedPrecondition
+	c := nameProp.Constraints
+	if c.MinLength != nil && *c.MinLength > 0 {
+		preconditions = append(preconditions, check(length(hclsyntax.TokenGreaterThanEq, " >= ", *c.MinLength), fmt.Sprintf("must have a minimum length of %d.", *c.MinLength)))
+	}
+	if c.MaxLength != nil {
+		preconditions = append(preconditions, check(length(hclsyntax.TokenLessThanEq, " <= ", *c.MaxLength), fmt.Sprintf("must have a maximum length of %d.", *c.MaxLength)))
+	}
+	if c.Pattern != "" {
+		match := hclwrite.TokensForFunctionCall("can", hclwrite.TokensForFunctionCall("regex", hclwrite.TokensForValue(cty.StringVal(c.Pattern)), hclgen.TokensForTraversal("local", "name")))
+		preconditions = append(preconditions, check(match, fmt.Sprintf("must match the pattern: %s.", c.Pattern)))
+	}
+	return preconditions
+}
+
+// tokensForRandomNameResult returns random_string.name[0].result.
+func tokensForRandomNameResult() hclwrite.Tokens {
+	t := hclgen.TokensForTraversal("random_string", "name")
+	t = append(t, &hclwrite.Token{Type: hclsyntax.TokenOBrack, Bytes: []byte("[")})
+	t = append(t, hclwrite.TokensForValue(cty.NumberIntVal(0))...)
+	t = append(t, &hclwrite.Token{Type: hclsyntax.TokenCBrack, Bytes: []byte("]")})
+	t = append(t, &hclwrite.Token{Type: hclsyntax.TokenDot, Bytes: []byte(".")})
+	return append(t, &hclwrite.Token{Type: hclsyntax.TokenIdent, Bytes: []byte("result")})
+}
+
+// nameCharacterClass returns a regular expression character class (without the
+// brackets) of every character the name pattern admits. It only succeeds for
+// patterns built from literals, character classes, anchors and repetitions, which
+// covers the usual ARM name patterns such as ^[a-z0-9][a-z0-9-]{1,61}[a-z0-9]$.
+func nameCharacterClass(pattern string) (string, bool) {
+	if pattern == "" {
+		return "", false
+	}
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return "", false
+	}
+
+	var ranges [][2]rune
+	var walk func(*syntax.Regexp) bool
+	walk = func(r *syntax.Regexp) bool {
+		switch r.Op {
+		case syntax.OpBeginText, syntax.OpEndText, syntax.OpBeginLine, syntax.OpEndLine, syntax.OpEmptyMatch:
+			return true
+		case syntax.OpCharClass:
+			for i := 0; i+1 < len(r.Rune); i += 2 {
+				ranges = append(ranges, [2]rune{r.Rune[i], r.Rune[i+1]})
+			}
+			return true
+		case syntax.OpLiteral:
+			for _, c := range r.Rune {
+				ranges = append(ranges, [2]rune{c, c})
+				if r.Flags&syntax.FoldCase != 0 {
+					for f := unicode.SimpleFold(c); f != c; f = unicode.SimpleFold(f) {
+						ranges = append(ranges, [2]rune{f, f})
+					}
+				}
+			}
+			return true
+		case syntax.OpConcat, syntax.OpCapture, syntax.OpStar, syntax.OpPlus, syntax.OpQuest, syntax.OpRepeat:
+			for _, sub := range r.Sub {
+				if !walk(sub) {
+					return false
+				}
+			}
+			return true
+		}
+		return false
+	}
+	if !walk(re) || len(ranges) == 0 {
+		return "", false
+	}
+
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i][0] < ranges[j][0] })
+	merged := [][2]rune{ranges[0]}
+	for _, rg := range ranges[1:] {
+		last := &merged[len(merged)-1]
+		if rg[0] <= last[1]+1 {
+			if rg[1] > last[1] {
+				last[1] = rg[1]
+			}
+			continue
+		}
+		merged = append(merged, rg)
+	}
+
+	var sb strings.Builder
+	for _, rg := range merged {
+		sb.WriteString(classRune(rg[0]))
+		if rg[1] != rg[0] {
+			if rg[1] > rg[0]+1 {
+				sb.WriteString("-")
+			}
+			sb.WriteString(classRune(rg[1]))
+		}
+	}
+	return sb.String(), true
+}
+
+// classRune escapes r for use inside a character class.
+func classRune(r rune) string {
+	switch {
+	case r < 0x20 || r > 0x7e:
+		return fmt.Sprintf(`\x{%x}`, r)
+	case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_':
+		return string(r)
+	default:
+		return `\` + string(r)
+	}
+}
@@ -0,0 +1,87 @@
+package terraform
+
+import (
+	"os"
+	"testing"
+
+	"github.com/matt-FFFFFF/tfmodmake/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNameCharacterClass(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    string
+		ok      bool
+	}{
+		{pattern: "^[a-z0-9][a-z0-9-]{1,61}[a-z0-9]$", want: `\-0-9a-z`, ok: true},
+		{pattern: "^[a-zA-Z0-9_.-]+$", want: `\-\.0-9A-Z_a-z`, ok: true},
+		{pattern: "^kv-[a-z]+$", want: `\-a-z`, ok: true},
+		{pattern: "^(foo|bar)$"},
+		{pattern: "^.*$"},
+		{pattern: ""},
+	}
+	for _, tc := range tests {
+		got, ok := nameCharacterClass(tc.pattern)
+		assert.Equal(t, tc.ok, ok, tc.pattern)
+		assert.Equal(t, tc.want, got, tc.pattern)
+	}
+}
+
+func TestGenerate_NamingVariable(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+
+	require.NoError(t, os.Chdir(tmpDir))
+
+	minLength, maxLength := int64(3), int64(24)
+	rs := &schema.ResourceSchema{
+		Properties: map[string]*schema.Property{
+			"name": {Name: "name", Type: schema.TypeString, Required: true, Constraints: schema.Constraints{MinLength: &minLength, MaxLength: &maxLength, Pattern: "^[a-z0-9]+$"}},
+			"properties": {Name: "properties", Type: schema.TypeObject, Children: map[string]*schema.Property{
+				"tier": {Name: "tier", Type: schema.TypeString},
+			}},
+		},
+	}
+
+	require.NoError(t, Generate("Microsoft.Test/widgets", WithResourceSchema(rs), WithAPIVersion("2024-01-01"), WithNamingVariable(true)))
+
+	varsBody := parseHCLBody(t, "variables.tf")
+	name := requireBlock(t, varsBody, "variable", "name")
+	assert.Equal(t, "null", expressionString(t, name.Body.Attributes["default"].Expr))
+	requireBlock(t, varsBody, "variable", "naming")
+
+	localsBody := parseHCLBody(t, "locals.tf")
+	locals := requireBlock(t, localsBody, "locals")
+	assert.Equal(t, `var.name == null ? replace(join("", [var.naming.prefix, try(random_string.name[0].result, ""), var.naming.suffix]), "/[^0-9a-z]/", "") : var.name`,
+		expressionString(t, locals.Body.Attributes["name"].Expr))
+	assert.Contains(t, expressionString(t, locals.Body.Attributes["resource_body"].Expr), "name = local.name")
+	assert.NotContains(t, expressionString(t, locals.Body.Attributes["resource_body"].Expr), "var.name")
+
+	mainBody := parseHCLBody(t, "main.tf")
+	random := requireBlock(t, mainBody, "resource", "random_string", "name")
+	assert.Equal(t, "var.name == null && var.naming.random_length > 0 ? 1 : 0", expressionString(t, random.Body.Attributes["count"].Expr))
+	resource := requireBlock(t, mainBody, "resource", "azapi_resource", "this")
+	assert.Equal(t, "local.name", expressionString(t, resource.Body.Attributes["name"].Expr))
+
+	// The assembled name is checked against the rule rather than truncated.
+	lifecycle := requireBlock(t, resource.Body, "lifecycle")
+	messages := map[string]string{}
+	for _, block := range findAllBlocks(lifecycle.Body, "precondition") {
+		messages[expressionString(t, block.Body.Attributes["condition"].Expr)] = expressionString(t, block.Body.Attributes["error_message"].Expr)
+	}
+	assert.Equal(t, map[string]string{
+		`var.name != null || length(local.name) >= 3`:               `"The name assembled from var.naming must have a minimum length of 3."`,
+		`var.name != null || length(local.name) <= 24`:              `"The name assembled from var.naming must have a maximum length of 24."`,
+		`var.name != null || can(regex("^[a-z0-9]+$", local.name))`: `"The name assembled from var.naming must match the pattern: ^[a-z0-9]+$."`,
+	}, messages)
+
+	tfBody := parseHCLBody(t, "terraform.tf")
+	terraformBlock := requireBlock(t, tfBody, "terraform")
+	providers := requireBlock(t, terraformBlock.Body, "required_providers")
+	assert.Contains(t, providers.Body.Attributes, "random")
+}
@@ -0,0 +1,67 @@
+package terraform
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/matt-FFFFFF/tfmodmake/naming"
+	"github.com/matt-FFFFFF/tfmodmake/schema"
+)
+
+// withNamingRule returns rs with the constraints of its name property completed
+// from the embedded naming rules of resourceType. Constraints present in the spec
+// take precedence; rs itself is not modified.
+func withNamingRule(rs *schema.ResourceSchema, resourceType string) *schema.ResourceSchema {
+	if rs == nil {
+		return nil
+	}
+	rule, ok := naming.RuleFor(cleanTypeString(resourceType))
+	existing := rs.Properties["name"]
+	if !ok || existing == nil {
+		return rs
+	}
+
+	name := *existing
+	c := &name.Constraints
+	if c.MinLength == nil && rule.MinLength > 0 {
+		c.MinLength = &rule.MinLength
+	}
+	if c.MaxLength == nil && rule.MaxLength > 0 {
+		c.MaxLength = &rule.MaxLength
+	}
+	if c.Pattern == "" {
+		c.Pattern = rule.Pattern
+	}
+
+	copied := *rs
+	copied.Properties = make(map[string]*schema.Property, len(rs.Properties))
+	for k, v := range rs.Properties {
+		copied.Properties[k] = v
+	}
+	copied.Properties["name"] = &name
+	return &copied
+}
+
+// nameVariableDescription describes var.name with its length limits and, when the
+// naming rules know it, the scope within which the name must be unique.
+func nameVariableDescription(rs *schema.ResourceSchema, resourceType string, namingVariable bool) string {
+	parts := []string{"The name of the resource."}
+	if rs != nil && rs.Properties["name"] != nil {
+		c := rs.Properties["name"].Constraints
+		switch {
+		case c.MinLength != nil && c.MaxLength != nil:
+			parts = append(parts, fmt.Sprintf("Must be between %d and %d characters.", *c.MinLength, *c.MaxLength))
+		case c.MaxLength != nil:
+			parts = append(parts, fmt.Sprintf("Must be at most %d characters.", *c.MaxLength))
+		}
+	}
+	if rule, ok := naming.RuleFor(cleanTypeString(resourceType)); ok {
+		if uniqueness := rule.UniquenessDescription(); uniqueness != "" {
+			parts = append(parts, uniqueness)
+		}
+	}
+	if namingVariable {
+		parts = append(parts, "When null, the name is assembled from var.naming.")
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,122 @@
+package terraform
+
+import (
+	"os"
+	"testing"
+
+	"github.com/matt-FFFFFF/tfmodmake/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithNamingRule(t *testing.T) {
+	specMax := int64(30)
+	rs := &schema.ResourceSchema{
+		Properties: map[string]*schema.Property{
+			"name": {Name: "name", Type: schema.TypeString, Required: true, Constraints: schema.Constraints{MaxLength: &specMax}},
+		},
+	}
+
+	got := withNamingRule(rs, "Microsoft.Storage/storageAccounts")
+	name := got.Properties["name"]
+	require.NotNil(t, name.Constraints.MinLength)
+	assert.Equal(t, int64(3), *name.Constraints.MinLength)
+	assert.Equal(t, int64(30), *name.Constraints.MaxLength, "spec constraints take precedence")
+	assert.Equal(t, "^[a-z0-9]{3,24}$", name.Constraints.Pattern)
+
+	assert.Nil(t, rs.Properties["name"].Constraints.MinLength, "the original schema is not modified")
+	assert.Same(t, rs, withNamingRule(rs, "Microsoft.Test/unknown"))
+}
+
+func TestGenerate_NamingRulesValidations(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+
+	require.NoError(t, os.Chdir(tmpDir))
+
+	rs := &schema.ResourceSchema{
+		Properties: map[string]*schema.Property{
+			"name": {Name: "name", Type: schema.TypeString, Required: true},
+			"properties": {Name: "properties", Type: schema.TypeObject, Children: map[string]*schema.Property{
+				"enableSoftDelete": {Name: "enableSoftDelete", Type: schema.TypeBoolean},
+			}},
+		},
+	}
+
+	require.NoError(t, Generate("Microsoft.KeyVault/vaults", WithResourceSchema(rs), WithAPIVersion("2023-07-01")))
+
+	varsBody := parseHCLBody(t, "variables.tf")
+	name := requireBlock(t, varsBody, "variable", "name")
+	assert.Equal(t, "The name of the resource. Must be between 3 and 24 characters. The name must be globally unique.\n",
+		attributeStringValue(t, name.Body.Attributes["description"]))
+
+	var conditions []string
+	for _, block := range name.Body.Blocks {
+		if block.Type == "validation" {
+			conditions = append(conditions, expressionString(t, block.Body.Attributes["condition"].Expr))
+		}
+	}
+	assert.Equal(t, []string{
+		"length(var.name) >= 3",
+		"length(var.name) <= 24",
+		`can(regex("^[a-zA-Z][a-zA-Z0-9-]{1,22}[a-zA-Z0-9]$", var.name))`,
+	}, conditions)
+}
+
+func TestGenerate_SingletonUsesFixedName(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+
+	require.NoError(t, os.Chdir(tmpDir))
+
+	rs := &schema.ResourceSchema{
+		Properties: map[string]*schema.Property{
+			"name": {Name: "name", Type: schema.TypeString, Required: true, Enum: []string{"default"}},
+			"properties": {Name: "properties", Type: schema.TypeObject, Children: map[string]*schema.Property{
+				"enabled": {Name: "enabled", Type: schema.TypeBoolean},
+			}},
+		},
+	}
+
+	require.NoError(t, Generate("Microsoft.Test/widgets/settings", WithResourceSchema(rs), WithAPIVersion("2024-01-01"), WithAVMStrict(true)))
+
+	varsBody := parseHCLBody(t, "variables.tf")
+	for _, block := range varsBody.Blocks {
+		assert.False(t, block.Type == "variable" && block.Labels[0] == "name", "singletons have no name variable")
+	}
+	mainBody := parseHCLBody(t, "main.tf")
+	resource := requireBlock(t, mainBody, "resource", "azapi_resource", "this")
+	assert.Equal(t, `"default"`, expressionString(t, resource.Body.Attributes["name"].Expr))
+
+	err = Generate("Microsoft.Test/widgets/settings", WithResourceSchema(rs), WithAPIVersion("2024-01-01"), WithNamingVariable(true))
+	assert.ErrorContains(t, err, `always has the name "default"`)
+}
+
+func TestGenerate_SingletonInstancePath(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+
+	require.NoError(t, os.Chdir(tmpDir))
+
+	rs := &schema.ResourceSchema{
+		Properties: map[string]*schema.Property{
+			"name": {Name: "name", Type: schema.TypeString, Required: true, Enum: []string{"default"}},
+		},
+	}
+
+	require.NoError(t, Generate("Microsoft.Test/widgets/{widgetName}/settings/default", WithResourceSchema(rs), WithAPIVersion("2024-01-01")))
+
+	mainBody := parseHCLBody(t, "main.tf")
+	resource := requireBlock(t, mainBody, "resource", "azapi_resource", "this")
+	assert.Equal(t, `"Microsoft.Test/widgets/settings@2024-01-01"`, expressionString(t, resource.Body.Attributes["type"].Expr))
+	assert.Equal(t, `"default"`, expressionString(t, resource.Body.Attributes["name"].Expr))
+}
@@ -0,0 +1,134 @@
+package terraform
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/matt-FFFFFF/tfmodmake/schema"
+)
+
+// resolveObjectOutputs validates response paths of read-only objects that are
+// exported and output whole instead of attribute by attribute.
+func resolveObjectOutputs(rs *schema.ResourceSchema, paths []string) ([]string, error) {
+	if len(paths) == 0 {
+		return nil, nil
+	}
+	if rs == nil {
+		return nil, fmt.Errorf("object outputs require a resource schema")
+	}
+
+	seen := make(map[string]struct{}, len(paths))
+	var resolved []string
+	for _, path := range paths {
+		path = strings.TrimPrefix(strings.TrimSpace(path), "body.")
+		if path == "" {
+			continue
+		}
+		if _, ok := seen[path]; ok {
+			continue
+		}
+		seen[path] = struct{}{}
+
+		prop, readOnly := propertyForObjectOutput(rs, path)
+		switch {
+		case prop == nil:
+			return nil, fmt.Errorf("object output %q does not exist in the resource schema", path)
+		case prop.Type != schema.TypeObject:
+			return nil, fmt.Errorf("object output %q is a %s, not an object", path, prop.Type)
+		case !readOnly:
+			return nil, fmt.Errorf("object output %q is writable; only read-only objects can be output", path)
+		}
+		resolved = append(resolved, path)
+	}
+
+	sort.Strings(resolved)
+	return resolved, nil
+}
+
+// propertyForObjectOutput returns the property at path and whether it, or one of
+// the objects it is nested in, is read-only.
+func propertyForObjectOutput(rs *schema.ResourceSchema, path string) (*schema.Property, bool) {
+	props := rs.Properties
+	var prop *schema.Property
+	readOnly := false
+	for _, segment := range strings.Split(path, ".") {
+		prop = props[segment]
+		if prop == nil {
+			return nil, false
+		}
+		readOnly = readOnly || prop.ReadOnly
+		props = prop.Children
+	}
+	return prop, readOnly
+}
+
+// withObjectOutputs adds the object paths to exportPaths and drops the paths
+// nested beneath them, which the object outputs already carry.
+func withObjectOutputs(exportPaths, objectPaths []string) []string {
+	if len(objectPaths) == 0 {
+		return exportPaths
+	}
+	merged := mergeExportPaths(exportPaths, objectPaths)
+	out := merged[:0]
+	for _, path := range merged {
+		if !isBeneathAny(path, objectPaths) {
+			out = append(out, path)
+		}
+	}
+	return out
+}
+
+func isBeneathAny(path string, parents []string) bool {
+	for _, parent := range parents {
+		if strings.HasPrefix(path, parent+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// objectOutputDescription documents the attributes of an exported object as the
+// GET response returns them, i.e. with their API (camelCase) names.
+func objectOutputDescription(prop *schema.Property, description string) string {
+	return description + "\n\nAttributes:\n" + strings.TrimSuffix(describeResponseAttributes(prop, ""), "\n")
+}
+
+func describeResponseAttributes(prop *schema.Property, indent string) string {
+	if prop == nil || len(prop.Children) == 0 {
+		return ""
+	}
+
+	names := make([]string, 0, len(prop.Children))
+	for name, child := range prop.Children {
+		if child != nil && !child.WriteOnly {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var sb strings.Builder
+	for _, name := range names {
+		child := prop.Children[name]
+		desc := strings.ReplaceAll(strings.TrimSpace(child.Description), "\n", " ")
+		if desc == "" {
+			desc = fmt.Sprintf("The %s property.", name)
+		}
+		fmt.Fprintf(&sb, "%s- `%s` (%s) - %s\n", indent, name, responseTypeLabel(child), desc)
+		if child.Type == schema.TypeObject {
+			sb.WriteString(describeResponseAttributes(child, indent+"  "))
+		}
+	}
+	return sb.String()
+}
+
+// responseTypeLabel names the type of a response attribute, e.g. "array of string".
+func responseTypeLabel(prop *schema.Property) string {
+	switch {
+	case prop.Type == schema.TypeArray && prop.ItemType != nil:
+		return "array of " + responseTypeLabel(prop.ItemType)
+	case prop.Type == schema.TypeObject && len(prop.Children) == 0 && prop.AdditionalProperties != nil:
+		return "map of " + responseTypeLabel(prop.AdditionalProperties)
+	}
+	return prop.Type.String()
+}
@@ -0,0 +1,78 @@
+package terraform
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/matt-FFFFFF/tfmodmake/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func objectOutputsSchema() *schema.ResourceSchema {
+	return &schema.ResourceSchema{
+		Properties: map[string]*schema.Property{
+			"properties": {Name: "properties", Type: schema.TypeObject, Children: map[string]*schema.Property{
+				"networkProfile": {Name: "networkProfile", Type: schema.TypeObject, ReadOnly: true, Description: "Network status.", Children: map[string]*schema.Property{
+					"outboundIps": {Name: "outboundIps", Type: schema.TypeArray, ItemType: &schema.Property{Type: schema.TypeString}, Description: "Outbound IP addresses."},
+					"dns": {Name: "dns", Type: schema.TypeObject, Children: map[string]*schema.Property{
+						"fqdn": {Name: "fqdn", Type: schema.TypeString},
+					}},
+				}},
+				"sku":   {Name: "sku", Type: schema.TypeObject, Children: map[string]*schema.Property{}},
+				"state": {Name: "state", Type: schema.TypeString, ReadOnly: true},
+			}},
+		},
+	}
+}
+
+func TestResolveObjectOutputs(t *testing.T) {
+	rs := objectOutputsSchema()
+
+	got, err := resolveObjectOutputs(rs, []string{"body.properties.networkProfile", "properties.networkProfile.dns", "properties.networkProfile"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"properties.networkProfile", "properties.networkProfile.dns"}, got)
+
+	_, err = resolveObjectOutputs(rs, []string{"properties.missing"})
+	assert.ErrorContains(t, err, "does not exist")
+	_, err = resolveObjectOutputs(rs, []string{"properties.state"})
+	assert.ErrorContains(t, err, "not an object")
+	_, err = resolveObjectOutputs(rs, []string{"properties.sku"})
+	assert.ErrorContains(t, err, "writable")
+	_, err = resolveObjectOutputs(nil, []string{"properties.sku"})
+	assert.Error(t, err)
+}
+
+func TestWithObjectOutputs(t *testing.T) {
+	got := withObjectOutputs(
+		[]string{"properties.networkProfile.dns.fqdn", "properties.networkProfile.outboundIps", "properties.networkProfileId", "properties.state"},
+		[]string{"properties.networkProfile"},
+	)
+	assert.Equal(t, []string{"properties.networkProfile", "properties.networkProfileId", "properties.state"}, got)
+}
+
+func TestGenerate_ObjectOutputDocumentsAttributes(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+
+	require.NoError(t, os.Chdir(tmpDir))
+
+	require.NoError(t, Generate("Microsoft.App/managedEnvironments", WithResourceSchema(objectOutputsSchema()), WithAPIVersion("2024-01-01"),
+		WithEndpointOutputSuffixes(), WithObjectOutputs("properties.networkProfile")))
+
+	mainBody := parseHCLBody(t, "main.tf")
+	resource := requireBlock(t, mainBody, "resource", "azapi_resource", "this")
+	assert.Equal(t, `["properties.networkProfile","properties.state"]`, strings.Join(strings.Fields(expressionString(t, resource.Body.Attributes["response_export_values"].Expr)), ""))
+
+	outputsBody := parseHCLBody(t, "outputs.tf")
+	profile := requireBlock(t, outputsBody, "output", "network_profile")
+	assert.Equal(t, "try(azapi_resource.this.output.properties.networkProfile, {})", expressionString(t, profile.Body.Attributes["value"].Expr))
+	assert.Equal(t, "Network status.\n\nAttributes:\n- `dns` (object) - The dns property.\n  - `fqdn` (string) - The fqdn property.\n- `outboundIps` (array of string) - Outbound IP addresses.\n",
+		attributeStringValue(t, profile.Body.Attributes["description"]))
+	assert.Nil(t, findBlock(outputsBody, "output", "network_profile_outbound_ips"))
+	assert.Nil(t, findBlock(outputsBody, "output", "network_profile_dns_fqdn"))
+}
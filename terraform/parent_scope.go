@@ -0,0 +1,259 @@
+package terraform
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Azure/bicep-types/src/bicep-types-go/types"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/matt-FFFFFF/tfmodmake/hclgen"
+	"github.com/matt-FFFFFF/tfmodmake/naming"
+	"github.com/matt-FFFFFF/tfmodmake/schema"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// parentScope describes what the azapi parent_id of the module's resource refers to.
+type parentScope struct {
+	kind parentScopeKind
+	// scopes lists the deployment scopes of a top-level resource deployable at more
+	// than one non-resource-group scope; it is only used for the description.
+	scopes types.ScopeType
+	// parentType and nameVariables describe the parent resource of a child type when
+	// the parent ID is built from components; parentType is empty for resources
+	// deployed directly into a resource group.
+	parentType    string
+	nameVariables []string
+}
+
+type parentScopeKind int
+
+const (
+	// parentScopeResource is a resource group or parent resource (the default).
+	parentScopeResource parentScopeKind = iota
+	// parentScopeExtension is an arbitrary resource the extension resource is applied to.
+	parentScopeExtension
+	// parentScopeTenant is the tenant root ("/").
+	parentScopeTenant
+	// parentScopeManagementGroup is a management group.
+	parentScopeManagementGroup
+	// parentScopeSubscription is a subscription, defaulting to the provider's.
+	parentScopeSubscription
+	// parentScopeMixed is any of several tenant-level scopes.
+	parentScopeMixed
+	// parentScopeComponents is a resource group or parent resource whose ID is built
+	// from a subscription ID, resource group name and parent resource names.
+	parentScopeComponents
+)
+
+const (
+	subscriptionIDVariable    = "subscription_id"
+	resourceGroupNameVariable = "resource_group_name"
+)
+
+// managementGroupIDPattern matches management group resource IDs.
+const managementGroupIDPattern = `^/providers/Microsoft\.Management/managementGroups/[^/]+$`
+
+// resolveParentScope classifies the parent of the resource from its writable scopes.
+// Only top-level resource types are deployed directly at a tenant, management group
+// or subscription; child types always take their parent resource ID.
+func resolveParentScope(rs *schema.ResourceSchema, resourceType string, scopeResource bool) parentScope {
+	if scopeResource || rs.IsExtensionResource() {
+		return parentScope{kind: parentScopeExtension}
+	}
+	if rs == nil || strings.Count(cleanTypeString(resourceType), "/") != 1 {
+		return parentScope{kind: parentScopeResource}
+	}
+
+	switch scopes := rs.WritableScopes; scopes {
+	case types.ScopeTypeTenant:
+		return parentScope{kind: parentScopeTenant}
+	case types.ScopeTypeManagementGroup:
+		return parentScope{kind: parentScopeManagementGroup}
+	case types.ScopeTypeSubscription:
+		return parentScope{kind: parentScopeSubscription}
+	default:
+		if scopes != types.ScopeTypeNone && scopes&types.ScopeTypeResourceGroup == 0 {
+			return parentScope{kind: parentScopeMixed, scopes: scopes}
+		}
+	}
+	return parentScope{kind: parentScopeResource}
+}
+
+// withComponents switches a resource group or parent resource scope to one built
+// with azapi provider functions from a subscription ID, resource group name and one
+// name variable per parent resource, e.g. managed_environment_name for
+// Microsoft.App/managedEnvironments/storages. An extension resource keeps its
+// scope variable, since its scope need not be in a resource group.
+func (p parentScope) withComponents(rs *schema.ResourceSchema, resourceType string) (parentScope, error) {
+	if p.kind == parentScopeExtension {
+		return p, nil
+	}
+	if p.kind != parentScopeResource || (rs != nil && rs.WritableScopes != types.ScopeTypeNone && rs.WritableScopes&types.ScopeTypeResourceGroup == 0) {
+		return p, fmt.Errorf("parent ID components require a resource deployed to a resource group; %s is not", resourceType)
+	}
+
+	segments := strings.Split(cleanTypeString(resourceType), "/")
+	if len(segments) < 2 {
+		return p, fmt.Errorf("invalid resource type %q", resourceType)
+	}
+	components := parentScope{kind: parentScopeComponents}
+	if parentSegments := segments[1 : len(segments)-1]; len(parentSegments) > 0 {
+		components.parentType = segments[0] + "/" + strings.Join(parentSegments, "/")
+		for _, segment := range parentSegments {
+			components.nameVariables = append(components.nameVariables, singularize(naming.ToSnakeCase(segment))+"_name")
+		}
+	}
+	return components, nil
+}
+
+// singularize strips the plural suffix of a resource type segment.
+func singularize(name string) string {
+	switch {
+	case strings.HasSuffix(name, "ies"):
+		return strings.TrimSuffix(name, "ies") + "y"
+	case strings.HasSuffix(name, "sses"), strings.HasSuffix(name, "xes"):
+		return strings.TrimSuffix(name, "es")
+	case strings.HasSuffix(name, "s") && !strings.HasSuffix(name, "ss"):
+		return strings.TrimSuffix(name, "s")
+	}
+	return name
+}
+
+// variableName returns the module variable holding the parent ID, or "" when the
+// parent is fixed or built from components.
+func (p parentScope) variableName() string {
+	switch p.kind {
+	case parentScopeExtension:
+		return "scope"
+	case parentScopeTenant, parentScopeComponents:
+		return ""
+	default:
+		return "parent_id"
+	}
+}
+
+// variableNames returns every module variable the parent ID is derived from.
+func (p parentScope) variableNames() []string {
+	if p.kind == parentScopeComponents {
+		return append([]string{subscriptionIDVariable, resourceGroupNameVariable}, p.nameVariables...)
+	}
+	if name := p.variableName(); name != "" {
+		return []string{name}
+	}
+	return nil
+}
+
+// needsClientConfig reports whether the parent ID defaults from the azapi_client_config data source.
+func (p parentScope) needsClientConfig() bool {
+	return p.kind == parentScopeSubscription || p.kind == parentScopeComponents
+}
+
+// appendVariable appends the parent ID variable to body, if one is generated.
+func (p parentScope) appendVariable(body *hclwrite.Body) {
+	if p.kind == parentScopeComponents {
+		p.appendComponentVariables(body)
+		return
+	}
+
+	name := p.variableName()
+	if name == "" {
+		return
+	}
+
+	var description string
+	switch p.kind {
+	case parentScopeExtension:
+		description = "The ID of the resource, resource group, subscription or management group this resource is applied to."
+	case parentScopeManagementGroup:
+		description = "The ID of the management group this resource is deployed to, in the form /providers/Microsoft.Management/managementGroups/{managementGroupId}."
+	case parentScopeSubscription:
+		description = "The ID of the subscription this resource is deployed to, in the form /subscriptions/{subscriptionId}. Defaults to the subscription of the azapi provider."
+	case parentScopeMixed:
+		description = fmt.Sprintf("The ID of the scope this resource is deployed to. Supported scopes: %s. Use \"/\" for the tenant.", strings.Join(schema.ScopeNames(p.scopes), ", "))
+	default:
+		description = "The parent resource ID for this resource."
+	}
+
+	varBody := body.AppendNewBlock("variable", []string{name}).Body()
+	hclgen.SetDescriptionAttribute(varBody, description)
+	varBody.SetAttributeRaw("type", hclwrite.TokensForIdentifier("string"))
+
+	switch p.kind {
+	case parentScopeSubscription:
+		varBody.SetAttributeRaw("default", hclwrite.TokensForIdentifier("null"))
+	case parentScopeManagementGroup:
+		validation := varBody.AppendNewBlock("validation", nil).Body()
+		validation.SetAttributeRaw("condition", hclwrite.TokensForFunctionCall("can",
+			hclwrite.TokensForFunctionCall("regex", hclwrite.TokensForValue(cty.StringVal(managementGroupIDPattern)), hclgen.TokensForTraversal("var", name)),
+		))
+		validation.SetAttributeValue("error_message", cty.StringVal("parent_id must be a management group ID."))
+	}
+	body.AppendNewline()
+}
+
+// appendComponentVariables appends the variables the parent ID is built from.
+func (p parentScope) appendComponentVariables(body *hclwrite.Body) {
+	appendString := func(name, description string) *hclwrite.Body {
+		varBody := body.AppendNewBlock("variable", []string{name}).Body()
+		hclgen.SetDescriptionAttribute(varBody, description)
+		varBody.SetAttributeRaw("type", hclwrite.TokensForIdentifier("string"))
+		return varBody
+	}
+
+	subscription := appendString(subscriptionIDVariable, "The ID (GUID) of the subscription this resource is deployed to. Defaults to the subscription of the azapi provider.")
+	subscription.SetAttributeRaw("default", hclwrite.TokensForIdentifier("null"))
+	body.AppendNewline()
+
+	appendString(resourceGroupNameVariable, "The name of the resource group this resource is deployed to.")
+	body.AppendNewline()
+
+	parentTypeSegments := strings.Split(p.parentType, "/")
+	for i, name := range p.nameVariables {
+		parentType := parentTypeSegments[0] + "/" + strings.Join(parentTypeSegments[1:i+2], "/")
+		appendString(name, fmt.Sprintf("The name of the parent %s resource.", parentType))
+		body.AppendNewline()
+	}
+}
+
+// tokensForParentID returns the expression assigned to the azapi parent_id argument.
+func (p parentScope) tokensForParentID() hclwrite.Tokens {
+	switch p.kind {
+	case parentScopeTenant:
+		return hclwrite.TokensForValue(cty.StringVal("/"))
+	case parentScopeSubscription:
+		return hclwrite.TokensForFunctionCall("coalesce",
+			hclgen.TokensForTraversal("var", "parent_id"),
+			hclgen.TokensForInterpolatedString("/subscriptions/", "data", "azapi_client_config", "current", "subscription_id"),
+		)
+	case parentScopeComponents:
+		return p.tokensForComponentsParentID()
+	default:
+		return hclgen.TokensForTraversal("var", p.variableName())
+	}
+}
+
+// tokensForComponentsParentID builds the parent ID with the azapi resource ID functions.
+func (p parentScope) tokensForComponentsParentID() hclwrite.Tokens {
+	subscriptionID := hclwrite.TokensForFunctionCall("coalesce",
+		hclgen.TokensForTraversal("var", subscriptionIDVariable),
+		hclgen.TokensForTraversal("data", "azapi_client_config", "current", "subscription_id"),
+	)
+	if p.parentType == "" {
+		return hclwrite.TokensForFunctionCall("provider::azapi::subscription_resource_id",
+			subscriptionID,
+			hclwrite.TokensForValue(cty.StringVal("Microsoft.Resources/resourceGroups")),
+			hclwrite.TokensForTuple([]hclwrite.Tokens{hclgen.TokensForTraversal("var", resourceGroupNameVariable)}),
+		)
+	}
+
+	names := make([]hclwrite.Tokens, 0, len(p.nameVariables))
+	for _, name := range p.nameVariables {
+		names = append(names, hclgen.TokensForTraversal("var", name))
+	}
+	return hclwrite.TokensForFunctionCall("provider::azapi::resource_group_resource_id",
+		subscriptionID,
+		hclgen.TokensForTraversal("var", resourceGroupNameVariable),
+		hclwrite.TokensForValue(cty.StringVal(p.parentType)),
+		hclwrite.TokensForTuple(names),
+	)
+}
@@ -0,0 +1,154 @@
+package terraform
+
+import (
+	"os"
+	"testing"
+
+	"github.com/Azure/bicep-types/src/bicep-types-go/types"
+	"github.com/matt-FFFFFF/tfmodmake/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveParentScope(t *testing.T) {
+	tests := []struct {
+		name         string
+		resourceType string
+		scopes       types.ScopeType
+		want         parentScopeKind
+	}{
+		{name: "resource group", resourceType: "Microsoft.Storage/storageAccounts", scopes: types.ScopeTypeResourceGroup, want: parentScopeResource},
+		{name: "tenant", resourceType: "Microsoft.Management/managementGroups", scopes: types.ScopeTypeTenant, want: parentScopeTenant},
+		{name: "management group", resourceType: "Microsoft.Test/mgThings", scopes: types.ScopeTypeManagementGroup, want: parentScopeManagementGroup},
+		{name: "subscription", resourceType: "Microsoft.Resources/resourceGroups", scopes: types.ScopeTypeSubscription, want: parentScopeSubscription},
+		{name: "mixed", resourceType: "Microsoft.Authorization/policyDefinitions", scopes: types.ScopeTypeTenant | types.ScopeTypeManagementGroup | types.ScopeTypeSubscription, want: parentScopeMixed},
+		{name: "child of subscription resource", resourceType: "Microsoft.Test/subThings/children", scopes: types.ScopeTypeSubscription, want: parentScopeResource},
+		{name: "extension", resourceType: "Microsoft.Authorization/locks", scopes: types.AllExceptExtension | types.ScopeTypeExtension, want: parentScopeExtension},
+		{name: "unknown scopes", resourceType: "Microsoft.Test/things", scopes: types.ScopeTypeNone, want: parentScopeResource},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rs := &schema.ResourceSchema{WritableScopes: tc.scopes}
+			assert.Equal(t, tc.want, resolveParentScope(rs, tc.resourceType, false).kind)
+		})
+	}
+}
+
+func TestGenerate_SubscriptionScopedParentID(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+
+	require.NoError(t, os.Chdir(tmpDir))
+
+	rs := &schema.ResourceSchema{
+		ReadableScopes: types.ScopeTypeSubscription,
+		WritableScopes: types.ScopeTypeSubscription,
+		Properties: map[string]*schema.Property{
+			"properties": {Name: "properties", Type: schema.TypeObject, Children: map[string]*schema.Property{
+				"tier": {Name: "tier", Type: schema.TypeString},
+			}},
+		},
+	}
+
+	require.NoError(t, Generate("Microsoft.Security/pricings", WithResourceSchema(rs), WithAPIVersion("2024-01-01")))
+
+	varsBody := parseHCLBody(t, "variables.tf")
+	parentID := requireBlock(t, varsBody, "variable", "parent_id")
+	assert.Equal(t, "null", expressionString(t, parentID.Body.Attributes["default"].Expr))
+
+	mainBody := parseHCLBody(t, "main.tf")
+	requireBlock(t, mainBody, "data", "azapi_client_config", "current")
+	resource := requireBlock(t, mainBody, "resource", "azapi_resource", "this")
+	assert.Equal(t, `coalesce(var.parent_id, "/subscriptions/${data.azapi_client_config.current.subscription_id}")`, expressionString(t, resource.Body.Attributes["parent_id"].Expr))
+}
+
+func TestGenerate_TenantScopedParentID(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+
+	require.NoError(t, os.Chdir(tmpDir))
+
+	rs := &schema.ResourceSchema{
+		ReadableScopes: types.ScopeTypeTenant,
+		WritableScopes: types.ScopeTypeTenant,
+		Properties: map[string]*schema.Property{
+			"properties": {Name: "properties", Type: schema.TypeObject, Children: map[string]*schema.Property{
+				"displayName": {Name: "displayName", Type: schema.TypeString},
+			}},
+		},
+	}
+
+	require.NoError(t, Generate("Microsoft.Management/managementGroups", WithResourceSchema(rs), WithAPIVersion("2023-04-01")))
+
+	varsBody := parseHCLBody(t, "variables.tf")
+	assert.Nil(t, findBlock(varsBody, "variable", "parent_id"))
+
+	mainBody := parseHCLBody(t, "main.tf")
+	resource := requireBlock(t, mainBody, "resource", "azapi_resource", "this")
+	assert.Equal(t, `"/"`, expressionString(t, resource.Body.Attributes["parent_id"].Expr))
+}
+
+func TestGenerate_ParentIDComponents(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+
+	require.NoError(t, os.Chdir(tmpDir))
+
+	rs := &schema.ResourceSchema{
+		ReadableScopes: types.ScopeTypeResourceGroup,
+		WritableScopes: types.ScopeTypeResourceGroup,
+		Properties: map[string]*schema.Property{
+			"properties": {Name: "properties", Type: schema.TypeObject, Children: map[string]*schema.Property{
+				"shareName": {Name: "shareName", Type: schema.TypeString},
+			}},
+		},
+	}
+
+	require.NoError(t, Generate("Microsoft.App/managedEnvironments/storages", WithResourceSchema(rs), WithAPIVersion("2024-03-01"), WithParentIDComponents(true)))
+
+	varsBody := parseHCLBody(t, "variables.tf")
+	assert.Nil(t, findBlock(varsBody, "variable", "parent_id"))
+	subscription := requireBlock(t, varsBody, "variable", "subscription_id")
+	assert.Equal(t, "null", expressionString(t, subscription.Body.Attributes["default"].Expr))
+	requireBlock(t, varsBody, "variable", "resource_group_name")
+	requireBlock(t, varsBody, "variable", "managed_environment_name")
+
+	mainBody := parseHCLBody(t, "main.tf")
+	requireBlock(t, mainBody, "data", "azapi_client_config", "current")
+	resource := requireBlock(t, mainBody, "resource", "azapi_resource", "this")
+	assert.Equal(t, `provider::azapi::resource_group_resource_id(coalesce(var.subscription_id, data.azapi_client_config.current.subscription_id), var.resource_group_name, "Microsoft.App/managedEnvironments", [var.managed_environment_name])`,
+		expressionString(t, resource.Body.Attributes["parent_id"].Expr))
+}
+
+func TestParentScopeWithComponents(t *testing.T) {
+	rs := &schema.ResourceSchema{WritableScopes: types.ScopeTypeResourceGroup}
+
+	top, err := resolveParentScope(rs, "Microsoft.Storage/storageAccounts", false).withComponents(rs, "Microsoft.Storage/storageAccounts")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"subscription_id", "resource_group_name"}, top.variableNames())
+	assert.Equal(t, `provider::azapi::subscription_resource_id(coalesce(var.subscription_id, data.azapi_client_config.current.subscription_id), "Microsoft.Resources/resourceGroups", [var.resource_group_name])`,
+		string(top.tokensForParentID().Bytes()))
+
+	nested, err := resolveParentScope(rs, "Microsoft.Network/virtualNetworks/subnets/things", false).withComponents(rs, "Microsoft.Network/virtualNetworks/subnets/things")
+	require.NoError(t, err)
+	assert.Equal(t, "Microsoft.Network/virtualNetworks/subnets", nested.parentType)
+	assert.Equal(t, []string{"virtual_network_name", "subnet_name"}, nested.nameVariables)
+
+	tenant := &schema.ResourceSchema{WritableScopes: types.ScopeTypeTenant}
+	_, err = resolveParentScope(tenant, "Microsoft.Management/managementGroups", false).withComponents(tenant, "Microsoft.Management/managementGroups")
+	assert.Error(t, err)
+
+	extension := &schema.ResourceSchema{WritableScopes: types.ScopeTypeExtension}
+	scope, err := resolveParentScope(extension, "Microsoft.Resources/tags", false).withComponents(extension, "Microsoft.Resources/tags")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"scope"}, scope.variableNames())
+}
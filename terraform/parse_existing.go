@@ -9,6 +9,7 @@ import (
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/hclsyntax"
 	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
 )
 
 // ParseHCLFile reads and parses an HCL file from disk using hclwrite.
@@ -24,6 +25,38 @@ func ParseHCLFile(path string) (*hclwrite.File, error) {
 	return file, nil
 }
 
+// parseTFFiles parses the .tf files of dir in name order, calling fn with the
+// base name, source and body of each.
+func parseTFFiles(dir string, fn func(name string, src []byte, body *hclsyntax.Body) error) error {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.tf"))
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		body, err := parseSyntaxBody(src, path)
+		if err != nil {
+			return err
+		}
+		if err := fn(filepath.Base(path), src, body); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseSyntaxBody parses the HCL source src, read from filename.
+func parseSyntaxBody(src []byte, filename string) (*hclsyntax.Body, error) {
+	file, diags := hclsyntax.ParseConfig(src, filename, hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("parsing %s: %s", filename, diags.Error())
+	}
+	return file.Body.(*hclsyntax.Body), nil
+}
+
 // ParseModuleFile is a convenience wrapper that parses a named HCL file inside a module directory.
 func ParseModuleFile(moduleDir, filename string) (*hclwrite.File, error) {
 	return ParseHCLFile(filepath.Join(moduleDir, filename))
@@ -61,6 +94,46 @@ func ExtractResourceTypeAndVersion(mainFile *hclwrite.File) (string, string, err
 	return "", "", fmt.Errorf("no azapi_resource \"this\" block found in main.tf")
 }
 
+// ExtractResponseExportValues returns the paths listed in the response_export_values
+// of azapi_resource "this". The boolean is false when the attribute is absent or is
+// not a literal list of strings.
+func ExtractResponseExportValues(mainFile *hclwrite.File) ([]string, bool) {
+	if mainFile == nil {
+		return nil, false
+	}
+	for _, block := range mainFile.Body().Blocks() {
+		if block.Type() != "resource" {
+			continue
+		}
+		labels := block.Labels()
+		if len(labels) < 2 || labels[0] != "azapi_resource" || labels[1] != "this" {
+			continue
+		}
+		attr := block.Body().GetAttribute("response_export_values")
+		if attr == nil {
+			return nil, false
+		}
+		expr, diags := hclsyntax.ParseExpression(attr.Expr().BuildTokens(nil).Bytes(), "main.tf", hcl.Pos{Line: 1, Column: 1})
+		if diags.HasErrors() {
+			return nil, false
+		}
+		value, diags := expr.Value(nil)
+		if diags.HasErrors() || !value.CanIterateElements() {
+			return nil, false
+		}
+		paths := make([]string, 0, value.LengthInt())
+		for it := value.ElementIterator(); it.Next(); {
+			_, v := it.Element()
+			if v.IsNull() || v.Type() != cty.String {
+				return nil, false
+			}
+			paths = append(paths, v.AsString())
+		}
+		return paths, true
+	}
+	return nil, false
+}
+
 // splitTypeAndVersion splits "Microsoft.App/managedEnvironments@2025-10-02-preview"
 // into ("Microsoft.App/managedEnvironments", "2025-10-02-preview").
 func splitTypeAndVersion(typeStr string) (string, string, error) {
@@ -242,3 +242,32 @@ variable "location" {
 		t.Error("expected variable 'location' in result")
 	}
 }
+
+func TestExtractResponseExportValues(t *testing.T) {
+	src := `resource "azapi_resource" "this" {
+  type = "Microsoft.App/managedEnvironments@2025-10-02-preview"
+  response_export_values = [
+    "properties.defaultDomain",
+    "properties.staticIp",
+  ]
+}`
+	file, diags := hclwrite.ParseConfig([]byte(src), "main.tf", hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		t.Fatalf("parse error: %s", diags.Error())
+	}
+
+	paths, ok := ExtractResponseExportValues(file)
+	if !ok {
+		t.Fatal("expected response_export_values to be found")
+	}
+	if len(paths) != 2 || paths[0] != "properties.defaultDomain" || paths[1] != "properties.staticIp" {
+		t.Errorf("unexpected paths %v", paths)
+	}
+
+	noExports, _ := hclwrite.ParseConfig([]byte(`resource "azapi_resource" "this" {
+  type = "Microsoft.App/managedEnvironments@2025-10-02-preview"
+}`), "main.tf", hcl.Pos{Line: 1, Column: 1})
+	if _, ok := ExtractResponseExportValues(noExports); ok {
+		t.Error("expected no response_export_values")
+	}
+}
@@ -0,0 +1,180 @@
+package terraform
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// pipelineTemplates are the CI workflows scaffolded for a module repository. They
+// use [[ ]] delimiters, as both GitHub Actions and Azure Pipelines expressions
+// are written ${{ }}.
+//
+//go:embed templates/pipeline/*.tmpl
+var pipelineTemplates embed.FS
+
+const (
+	// GitHubWorkflowPath is the GitHub Actions workflow written by ScaffoldPipeline.
+	GitHubWorkflowPath = ".github/workflows/ci.yml"
+	// AzurePipelinesPath is the Azure DevOps pipeline written by ScaffoldPipeline.
+	AzurePipelinesPath = "azure-pipelines.yml"
+
+	// pipelineTerraformDocsVersion is the terraform-docs release the docs check runs.
+	pipelineTerraformDocsVersion = "v0.19.0"
+	// e2eDir is the terratest module scaffolded by ScaffoldAVMLayout.
+	e2eDir = "tests/e2e"
+)
+
+// PipelineOptions configures the CI workflows written by ScaffoldPipeline.
+type PipelineOptions struct {
+	// AzureDevOps also writes an Azure DevOps pipeline next to the GitHub
+	// Actions workflow.
+	AzureDevOps bool
+	// E2E adds a job running the end-to-end test in tests/e2e, which must exist.
+	E2E bool
+}
+
+// pipelineLayout is the module repository layout the workflows check.
+type pipelineLayout struct {
+	// TerraformVersion is the required_version of terraform.tf, empty when unset.
+	TerraformVersion     string
+	TerraformDocsVersion string
+	// Dirs are the Terraform root and child modules and examples to validate.
+	Dirs []string
+	// TFLint is true when the module has a .tflint.hcl.
+	TFLint bool
+	// DocsDirs are the directories with a terraform-docs configuration.
+	DocsDirs []string
+	E2E      bool
+	E2EDir   string
+}
+
+// ScaffoldPipeline writes a CI workflow for the module repository in dir, as laid
+// out by gen avm: a format check, terraform validate of the module, its
+// submodules and its examples, tflint when there is a .tflint.hcl, a check that
+// the terraform-docs READMEs are up to date when there are terraform-docs
+// configurations, and optionally the end-to-end test. Existing files are left
+// untouched. It returns the paths it created, relative to dir.
+func ScaffoldPipeline(dir string, opts PipelineOptions) ([]string, error) {
+	layout, err := readPipelineLayout(dir)
+	if err != nil {
+		return nil, err
+	}
+	if opts.E2E {
+		if _, err := os.Stat(filepath.Join(dir, e2eDir, "go.mod")); err != nil {
+			return nil, fmt.Errorf("no end-to-end test in %s; run gen avm to scaffold it: %w", e2eDir, err)
+		}
+		layout.E2E = true
+		layout.E2EDir = e2eDir
+	}
+
+	github, err := renderPipelineTemplate("github.yml", layout)
+	if err != nil {
+		return nil, err
+	}
+	files := []scaffoldFile{{filepath.FromSlash(GitHubWorkflowPath), github}}
+	if opts.AzureDevOps {
+		azure, err := renderPipelineTemplate("azure-pipelines.yml", layout)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, scaffoldFile{AzurePipelinesPath, azure})
+	}
+	return writeScaffoldFiles(dir, files, nil)
+}
+
+// readPipelineLayout finds the Terraform modules and tool configurations below dir.
+// Hidden directories and tests are not modules of the repository.
+func readPipelineLayout(dir string) (*pipelineLayout, error) {
+	layout := &pipelineLayout{TerraformDocsVersion: pipelineTerraformDocsVersion}
+	if _, err := os.Stat(filepath.Join(dir, "main.tf")); err != nil {
+		return nil, fmt.Errorf("no module in %s: %w", dir, err)
+	}
+
+	modules := map[string]bool{}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if d.IsDir() {
+			if rel != "." && (strings.HasPrefix(d.Name(), ".") || rel == "tests") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		parent := filepath.ToSlash(filepath.Dir(rel))
+		switch {
+		case strings.HasSuffix(d.Name(), ".tf"):
+			modules[parent] = true
+		case d.Name() == ".terraform-docs.yml":
+			layout.DocsDirs = append(layout.DocsDirs, parent)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for module := range modules {
+		layout.Dirs = append(layout.Dirs, module)
+	}
+	sort.Strings(layout.Dirs)
+	sort.Strings(layout.DocsDirs)
+
+	if _, err := os.Stat(filepath.Join(dir, ".tflint.hcl")); err == nil {
+		layout.TFLint = true
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	terraformBlock, err := moduleTerraformBlock(dir)
+	if err != nil {
+		return nil, err
+	}
+	if terraformBlock != nil {
+		if attr := terraformBlock.Body().GetAttribute("required_version"); attr != nil {
+			layout.TerraformVersion = strings.Trim(strings.TrimSpace(string(attr.Expr().BuildTokens(nil).Bytes())), `"`)
+		}
+	}
+	return layout, nil
+}
+
+// renderPipelineTemplate renders templates/pipeline/<name>.tmpl for the layout.
+func renderPipelineTemplate(name string, layout *pipelineLayout) ([]byte, error) {
+	tmpl, err := template.New(name+".tmpl").Delims("[[", "]]").Funcs(template.FuncMap{
+		"jobName": pipelineJobName,
+	}).ParseFS(pipelineTemplates, "templates/pipeline/"+name+".tmpl")
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, layout); err != nil {
+		return nil, fmt.Errorf("rendering %s: %w", name, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// pipelineJobName names the Azure Pipelines matrix entry of a module directory,
+// which may only contain letters, digits and underscores.
+func pipelineJobName(dir string) string {
+	if dir == "." {
+		return "root"
+	}
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '_'
+	}, dir)
+}
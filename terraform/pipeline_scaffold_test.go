@@ -0,0 +1,121 @@
+package terraform
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+// scaffoldAVMModule generates a module with its AVM repository layout and a submodule.
+func scaffoldAVMModule(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	require.NoError(t, Generate("Microsoft.Test/widgets", WithResourceSchema(avmStrictSchema()), WithAPIVersion("2024-01-01"), WithAVMStrict(true), WithOutputDir(dir)))
+	_, err := ScaffoldAVMLayout(dir, nil)
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "modules", "gadget"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "modules", "gadget", "main.tf"), []byte("# gadget\n"), 0o644))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, ".terraform", "modules"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".terraform", "modules", "main.tf"), []byte("# cached\n"), 0o644))
+	return dir
+}
+
+func readYAML(t *testing.T, path string) map[string]any {
+	t.Helper()
+	src, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var doc map[string]any
+	require.NoError(t, yaml.Unmarshal(src, &doc), "%s is valid YAML", path)
+	return doc
+}
+
+func TestScaffoldPipeline_GitHub(t *testing.T) {
+	dir := scaffoldAVMModule(t)
+
+	created, err := ScaffoldPipeline(dir, PipelineOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.FromSlash(GitHubWorkflowPath)}, created)
+
+	workflow := readYAML(t, filepath.Join(dir, GitHubWorkflowPath))
+	jobs := workflow["jobs"].(map[string]any)
+	assert.ElementsMatch(t, []string{"fmt", "validate", "lint", "docs"}, keysOf(jobs))
+
+	validate := jobs["validate"].(map[string]any)
+	matrix := validate["strategy"].(map[string]any)["matrix"].(map[string]any)
+	assert.Equal(t, []any{".", "examples/complete", "examples/default", "modules/gadget"}, matrix["dir"])
+
+	src, err := os.ReadFile(filepath.Join(dir, GitHubWorkflowPath))
+	require.NoError(t, err)
+	assert.Contains(t, string(src), `terraform_version: "~> 1.12"`)
+	assert.Contains(t, string(src), "working-directory: ${{ matrix.dir }}")
+	assert.Contains(t, string(src), "\n          terraform-docs \".\"\n          terraform-docs \"examples/complete\"\n          terraform-docs \"examples/default\"\n")
+	assert.NotContains(t, string(src), "e2e")
+
+	// Existing workflows are left untouched.
+	created, err = ScaffoldPipeline(dir, PipelineOptions{E2E: true, AzureDevOps: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{AzurePipelinesPath}, created)
+	unchanged, err := os.ReadFile(filepath.Join(dir, GitHubWorkflowPath))
+	require.NoError(t, err)
+	assert.Equal(t, string(src), string(unchanged))
+}
+
+func TestScaffoldPipeline_E2EAndAzureDevOps(t *testing.T) {
+	dir := scaffoldAVMModule(t)
+
+	_, err := ScaffoldPipeline(dir, PipelineOptions{E2E: true, AzureDevOps: true})
+	require.NoError(t, err)
+
+	workflow := readYAML(t, filepath.Join(dir, GitHubWorkflowPath))
+	e2e := workflow["jobs"].(map[string]any)["e2e"].(map[string]any)
+	assert.Equal(t, []any{"fmt", "validate"}, e2e["needs"])
+	assert.Equal(t, "write", e2e["permissions"].(map[string]any)["id-token"])
+	steps := e2e["steps"].([]any)
+	test := steps[len(steps)-1].(map[string]any)
+	assert.Equal(t, "tests/e2e", test["working-directory"])
+
+	pipeline := readYAML(t, filepath.Join(dir, AzurePipelinesPath))
+	stages := pipeline["stages"].([]any)
+	require.Len(t, stages, 2)
+	assert.Equal(t, "e2e", stages[1].(map[string]any)["stage"])
+	check := stages[0].(map[string]any)["jobs"].([]any)
+	var names []string
+	for _, job := range check {
+		names = append(names, job.(map[string]any)["job"].(string))
+	}
+	assert.Equal(t, []string{"fmt", "validate", "lint", "docs"}, names)
+	matrix := check[1].(map[string]any)["strategy"].(map[string]any)["matrix"].(map[string]any)
+	assert.Equal(t, map[string]any{"dir": "examples/default"}, matrix["examples_default"])
+	assert.Equal(t, map[string]any{"dir": "."}, matrix["root"])
+}
+
+func TestScaffoldPipeline_Errors(t *testing.T) {
+	_, err := ScaffoldPipeline(t.TempDir(), PipelineOptions{})
+	assert.ErrorContains(t, err, "no module in")
+
+	// A module without the AVM layout has no end-to-end test to run.
+	dir := t.TempDir()
+	require.NoError(t, Generate("Microsoft.Test/widgets", WithResourceSchema(avmStrictSchema()), WithAPIVersion("2024-01-01"), WithOutputDir(dir)))
+	_, err = ScaffoldPipeline(dir, PipelineOptions{E2E: true})
+	assert.ErrorContains(t, err, "no end-to-end test in tests/e2e")
+
+	created, err := ScaffoldPipeline(dir, PipelineOptions{})
+	require.NoError(t, err)
+	assert.Len(t, created, 1)
+	src, err := os.ReadFile(filepath.Join(dir, GitHubWorkflowPath))
+	require.NoError(t, err)
+	assert.NotContains(t, string(src), "tflint")
+	assert.NotContains(t, string(src), "terraform-docs")
+}
+
+func keysOf(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	return keys
+}
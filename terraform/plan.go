@@ -0,0 +1,24 @@
+package terraform
+
+import (
+	"strings"
+
+	"github.com/matt-FFFFFF/tfmodmake/schema"
+)
+
+// planRequiredAttributes identify a marketplace offer and must be set together.
+var planRequiredAttributes = []string{"name", "publisher", "product"}
+
+// planProperty returns the writable top-level marketplace plan object of the schema, or nil.
+func planProperty(rs *schema.ResourceSchema) *schema.Property {
+	return rootObjectProperty(rs, "plan")
+}
+
+// planDescription documents the plan variable.
+func planDescription(prop *schema.Property) string {
+	desc := strings.TrimSpace(prop.Description)
+	if desc == "" {
+		desc = "The marketplace plan of the resource."
+	}
+	return desc + " Required when deploying a marketplace offer; the name, publisher and product identify the offer and must be set together.\n\n" + buildNestedDescription(prop, "")
+}
@@ -0,0 +1,57 @@
+package terraform
+
+import (
+	"os"
+	"testing"
+
+	"github.com/matt-FFFFFF/tfmodmake/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate_PlanVariable(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+
+	require.NoError(t, os.Chdir(tmpDir))
+
+	rs := &schema.ResourceSchema{
+		Properties: map[string]*schema.Property{
+			"plan": {Name: "plan", Type: schema.TypeObject, Children: map[string]*schema.Property{
+				"name":          {Name: "name", Type: schema.TypeString, Required: true},
+				"publisher":     {Name: "publisher", Type: schema.TypeString, Required: true},
+				"product":       {Name: "product", Type: schema.TypeString, Required: true},
+				"promotionCode": {Name: "promotionCode", Type: schema.TypeString},
+				"version":       {Name: "version", Type: schema.TypeString},
+			}},
+			"properties": {Name: "properties", Type: schema.TypeObject, Children: map[string]*schema.Property{
+				"managedResourceGroupId": {Name: "managedResourceGroupId", Type: schema.TypeString},
+			}},
+		},
+	}
+
+	require.NoError(t, Generate("Microsoft.Solutions/applications", WithResourceSchema(rs), WithAPIVersion("2021-07-01")))
+
+	varsBody := parseHCLBody(t, "variables.tf")
+	plan := requireBlock(t, varsBody, "variable", "plan")
+	assert.Equal(t, "null", expressionString(t, plan.Body.Attributes["default"].Expr))
+
+	var conditions []string
+	for _, block := range findAllBlocks(plan.Body, "validation") {
+		conditions = append(conditions, expressionString(t, block.Body.Attributes["condition"].Expr))
+	}
+	assert.Equal(t, []string{
+		`var.plan == null || var.plan.name != null`,
+		`var.plan == null || var.plan.publisher != null`,
+		`var.plan == null || var.plan.product != null`,
+	}, conditions)
+
+	localsBody := parseHCLBody(t, "locals.tf")
+	locals := requireBlock(t, localsBody, "locals")
+	body := expressionString(t, locals.Body.Attributes["resource_body"].Expr)
+	assert.Contains(t, body, "plan = var.plan == null ? null : {")
+	assert.Contains(t, body, "promotionCode = var.plan.promotion_code")
+}
@@ -0,0 +1,142 @@
+package terraform
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/matt-FFFFFF/tfmodmake/hclgen"
+	"github.com/matt-FFFFFF/tfmodmake/naming"
+	"github.com/matt-FFFFFF/tfmodmake/schema"
+)
+
+// postCreateLocalSuffix is appended to the body local name to form the local
+// holding properties applied after creation by azapi_update_resource.
+const postCreateLocalSuffix = "_post_create"
+
+// resolvePostCreateProperties validates body paths of properties that can only be
+// set once the resource exists. bicep-types merges the PUT and PATCH bodies into one
+// type, so these cannot be detected from the schema and must be declared.
+//
+// Each path must address a whole module variable: a child of "properties"
+// (e.g. "properties.networkAcls") or another writable root property.
+func resolvePostCreateProperties(rs *schema.ResourceSchema, paths []string, secrets []secretField) ([]string, error) {
+	if len(paths) == 0 {
+		return nil, nil
+	}
+	if rs == nil {
+		return nil, fmt.Errorf("post-create properties require a resource schema")
+	}
+
+	secretPaths := newSecretPathSet(secrets)
+	seen := make(map[string]struct{}, len(paths))
+	var resolved []string
+	for _, path := range paths {
+		path = strings.TrimPrefix(strings.TrimSpace(path), "body.")
+		if path == "" {
+			continue
+		}
+		if _, ok := seen[path]; ok {
+			continue
+		}
+		seen[path] = struct{}{}
+
+		segments := strings.Split(path, ".")
+		switch {
+		case len(segments) == 2 && segments[0] == "properties":
+		case len(segments) == 1 && !isReservedRootProperty(segments[0]):
+		default:
+			return nil, fmt.Errorf("post-create path %q must be a child of properties or a root property", path)
+		}
+
+		prop := propertyForExportPath(rs, path)
+		if prop == nil || !isWritableProperty(prop) {
+			return nil, fmt.Errorf("post-create path %q does not exist in the resource schema or is read-only", path)
+		}
+		if _, ok := secretPaths[path]; ok {
+			return nil, fmt.Errorf("post-create path %q is a secret; secrets are only sent through sensitive_body", path)
+		}
+		resolved = append(resolved, path)
+	}
+
+	sort.Strings(resolved)
+	return resolved, nil
+}
+
+func isReservedRootProperty(name string) bool {
+	switch name {
+	case "properties", "name", "location", "tags", "identity":
+		return true
+	}
+	return false
+}
+
+// postCreateRootProperty returns a synthetic root containing only the post-create
+// properties, suitable for constructValue.
+func postCreateRootProperty(rs *schema.ResourceSchema, paths []string) *schema.Property {
+	root := &schema.Property{Type: schema.TypeObject, Children: map[string]*schema.Property{}}
+	for _, path := range paths {
+		segments := strings.Split(path, ".")
+		if len(segments) == 1 {
+			root.Children[path] = rs.Properties[path]
+			continue
+		}
+		bag, ok := root.Children["properties"]
+		if !ok {
+			original := rs.Properties["properties"]
+			copied := *original
+			copied.Children = map[string]*schema.Property{}
+			bag = &copied
+			root.Children["properties"] = bag
+		}
+		bag.Children[segments[1]] = rs.Properties["properties"].Children[segments[1]]
+	}
+	return root
+}
+
+// postCreateVariableNames returns the module variables that feed post-create properties.
+func postCreateVariableNames(paths []string, moduleNamePrefix string) []string {
+	names := make([]string, 0, len(paths))
+	for _, path := range paths {
+		segments := strings.Split(path, ".")
+		name := naming.ToSnakeCase(segments[len(segments)-1])
+		if moduleNamePrefix != "" && name == "version" {
+			name = moduleNamePrefix + "_version"
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// appendPostCreateUpdateResource appends an azapi_update_resource that applies the
+// post-create properties once azapi_resource.this exists. It is only instantiated
+// when at least one of the feeding variables is set.
+func appendPostCreateUpdateResource(body *hclwrite.Body, typeTokens hclwrite.Tokens, localName string, format BodyFormat, varNames []string) {
+	body.AppendNewline()
+	block := body.AppendNewBlock("resource", []string{"azapi_update_resource", "post_create"})
+	updateBody := block.Body()
+
+	var count hclwrite.Tokens
+	for i, name := range varNames {
+		if i > 0 {
+			count = append(count, &hclwrite.Token{Type: hclsyntax.TokenOr, Bytes: []byte(" || ")})
+		}
+		count = append(count, hclgen.TokensForTraversal("var", name)...)
+		count = append(count, &hclwrite.Token{Type: hclsyntax.TokenNotEqual, Bytes: []byte(" != ")})
+		count = append(count, hclwrite.TokensForIdentifier("null")...)
+	}
+	count = append(count, &hclwrite.Token{Type: hclsyntax.TokenQuestion, Bytes: []byte(" ? ")})
+	count = append(count, hclwrite.TokensForIdentifier("1")...)
+	count = append(count, &hclwrite.Token{Type: hclsyntax.TokenColon, Bytes: []byte(" : ")})
+	count = append(count, hclwrite.TokensForIdentifier("0")...)
+
+	updateBody.SetAttributeRaw("count", count)
+	updateBody.SetAttributeRaw("type", typeTokens)
+	updateBody.SetAttributeRaw("resource_id", hclgen.TokensForTraversal("azapi_resource", "this", "id"))
+	updateBody.SetAttributeRaw("body", tokensForBody(format, hclgen.TokensForTraversal("local", localName+postCreateLocalSuffix)))
+	updateBody.SetAttributeRaw("depends_on", hclwrite.TokensForTuple([]hclwrite.Tokens{
+		hclgen.TokensForTraversal("azapi_resource", "this"),
+	}))
+}
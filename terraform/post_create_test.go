@@ -0,0 +1,80 @@
+package terraform
+
+import (
+	"os"
+	"testing"
+
+	"github.com/matt-FFFFFF/tfmodmake/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func postCreateSchema() *schema.ResourceSchema {
+	return &schema.ResourceSchema{
+		Properties: map[string]*schema.Property{
+			"properties": {Name: "properties", Type: schema.TypeObject, Children: map[string]*schema.Property{
+				"sku": {Name: "sku", Type: schema.TypeString},
+				"networkAcls": {Name: "networkAcls", Type: schema.TypeObject, Children: map[string]*schema.Property{
+					"defaultAction": {Name: "defaultAction", Type: schema.TypeString},
+				}},
+				"state": {Name: "state", Type: schema.TypeString, ReadOnly: true},
+			}},
+		},
+	}
+}
+
+func TestResolvePostCreateProperties_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{name: "nested path", path: "properties.networkAcls.defaultAction", want: "must be a child of properties"},
+		{name: "unknown path", path: "properties.missing", want: "does not exist"},
+		{name: "read-only path", path: "properties.state", want: "read-only"},
+		{name: "reserved root", path: "location", want: "must be a child of properties"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := resolvePostCreateProperties(postCreateSchema(), []string{tc.path}, nil)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.want)
+		})
+	}
+}
+
+func TestGenerate_PostCreateProperties(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+
+	require.NoError(t, os.Chdir(tmpDir))
+
+	err = Generate("Microsoft.Test/widgets",
+		WithResourceSchema(postCreateSchema()),
+		WithAPIVersion("2025-01-01"),
+		WithPostCreateProperties("body.properties.networkAcls"),
+	)
+	require.NoError(t, err)
+
+	localsBody := parseHCLBody(t, "locals.tf")
+	locals := requireBlock(t, localsBody, "locals")
+	mainLocal := expressionString(t, locals.Body.Attributes["resource_body"].Expr)
+	assert.NotContains(t, mainLocal, "networkAcls")
+	assert.Contains(t, mainLocal, "sku = var.sku")
+
+	postCreateLocal := expressionString(t, locals.Body.Attributes["resource_body_post_create"].Expr)
+	assert.Contains(t, postCreateLocal, "networkAcls")
+	assert.Contains(t, postCreateLocal, "defaultAction = var.network_acls.default_action")
+	assert.NotContains(t, postCreateLocal, "sku")
+
+	mainBody := parseHCLBody(t, "main.tf")
+	update := requireBlock(t, mainBody, "resource", "azapi_update_resource", "post_create")
+	assert.Equal(t, "var.network_acls != null ? 1 : 0", expressionString(t, update.Body.Attributes["count"].Expr))
+	assert.Equal(t, "Microsoft.Test/widgets@2025-01-01", attributeStringValue(t, update.Body.Attributes["type"]))
+	assert.Equal(t, "azapi_resource.this.id", expressionString(t, update.Body.Attributes["resource_id"].Expr))
+	assert.Equal(t, "local.resource_body_post_create", expressionString(t, update.Body.Attributes["body"].Expr))
+	assert.Equal(t, "[azapi_resource.this]", expressionString(t, update.Body.Attributes["depends_on"].Expr))
+}
@@ -0,0 +1,301 @@
+package terraform
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/matt-FFFFFF/tfmodmake/hclgen"
+	"github.com/matt-FFFFFF/tfmodmake/naming"
+	"github.com/matt-FFFFFF/tfmodmake/schema"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// PreconditionRule declares that the property at Require must be set whenever the
+// property at When equals Equals. Paths are relative to the resource body,
+// e.g. "properties.networkProfile.networkPlugin".
+type PreconditionRule struct {
+	When    string
+	Equals  string
+	Require string
+}
+
+// requiredWhenPattern matches description phrases such as
+// "Required when authType is 'ServicePrincipal'" or "Must be set if mode is Enabled".
+var requiredWhenPattern = regexp.MustCompile(
+	"(?i)\\b(?:required|mandatory|must\\s+be\\s+(?:set|specified|provided))\\s+(?:when|if)\\s+(?:the\\s+)?[`'\"]?([A-Za-z][A-Za-z0-9]*)[`'\"]?\\s+(?:property\\s+)?(?:is\\s+(?:set\\s+to\\s+)?|==?\\s*|equals\\s+)[`'\"]?([A-Za-z0-9_-]+)[`'\"]?",
+)
+
+// inferPreconditionRules derives rules from property descriptions and from
+// built-in heuristics. A phrase only produces a rule when it names a writable
+// sibling whose enum (or boolean type) accepts the stated value, which keeps
+// free-text false positives out. See enabledSettingsRule for the heuristics.
+func inferPreconditionRules(rs *schema.ResourceSchema) []PreconditionRule {
+	if rs == nil {
+		return nil
+	}
+	var rules []PreconditionRule
+	inferPreconditionRulesRecursive(rs.Properties, "", &rules)
+	sort.Slice(rules, func(i, j int) bool {
+		if rules[i].Require != rules[j].Require {
+			return rules[i].Require < rules[j].Require
+		}
+		return rules[i].When < rules[j].When
+	})
+	return rules
+}
+
+func inferPreconditionRulesRecursive(props map[string]*schema.Property, prefix string, rules *[]PreconditionRule) {
+	if rule, ok := enabledSettingsRule(props, prefix); ok {
+		*rules = append(*rules, rule)
+	}
+	for name, prop := range props {
+		if prop == nil || !isWritableProperty(prop) {
+			continue
+		}
+
+		if match := requiredWhenPattern.FindStringSubmatch(prop.Description); match != nil {
+			if siblingName, sibling := findSiblingFold(props, match[1]); sibling != nil && siblingName != name && isWritableProperty(sibling) {
+				if value, ok := canonicalGateValue(sibling, match[2]); ok {
+					*rules = append(*rules, PreconditionRule{
+						When:    prefix + siblingName,
+						Equals:  value,
+						Require: prefix + name,
+					})
+				}
+			}
+		}
+
+		if prop.Type == schema.TypeObject && len(prop.Children) > 0 {
+			inferPreconditionRulesRecursive(prop.Children, prefix+name+".", rules)
+		}
+	}
+}
+
+// enabledSettingsRule returns the rule of an object whose writable boolean
+// enabled (or isEnabled) property switches on a feature configured by a single
+// optional sibling named settings, configuration or config, or ending in one
+// of those, e.g. "properties.backup.enabled == true requires
+// properties.backup.settings". Objects with several candidate siblings are
+// ambiguous and produce no rule.
+func enabledSettingsRule(props map[string]*schema.Property, prefix string) (PreconditionRule, bool) {
+	var gate, settings []string
+	for name, prop := range props {
+		if prop == nil || !isWritableProperty(prop) {
+			continue
+		}
+		lower := strings.ToLower(name)
+		switch {
+		case (lower == "enabled" || lower == "isenabled") && prop.Type == schema.TypeBoolean:
+			gate = append(gate, name)
+		case !prop.Required && (strings.HasSuffix(lower, "settings") || strings.HasSuffix(lower, "configuration") || strings.HasSuffix(lower, "config")):
+			settings = append(settings, name)
+		}
+	}
+	if len(gate) != 1 || len(settings) != 1 {
+		return PreconditionRule{}, false
+	}
+	return PreconditionRule{When: prefix + gate[0], Equals: "true", Require: prefix + settings[0]}, true
+}
+
+func findSiblingFold(props map[string]*schema.Property, name string) (string, *schema.Property) {
+	for k, p := range props {
+		if strings.EqualFold(k, name) {
+			return k, p
+		}
+	}
+	return "", nil
+}
+
+// canonicalGateValue returns the value as the schema spells it, or false when the
+// gate property cannot take that value.
+func canonicalGateValue(gate *schema.Property, value string) (string, bool) {
+	if gate.Type == schema.TypeBoolean {
+		lower := strings.ToLower(value)
+		if lower == "true" || lower == "false" {
+			return lower, true
+		}
+		return "", false
+	}
+	for _, v := range gate.Enum {
+		if strings.EqualFold(v, value) {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// resolvedPrecondition is a rule bound to module variable references.
+type resolvedPrecondition struct {
+	condition    hclwrite.Tokens
+	errorMessage string
+	// variable is the module variable holding the required property.
+	variable string
+}
+
+// resolvePreconditions binds inferred and configured rules to variable references.
+// Inferred rules that cannot be expressed over module variables are dropped;
+// configured rules that cannot be expressed are an error.
+func resolvePreconditions(rs *schema.ResourceSchema, configured []PreconditionRule, secrets []secretField, moduleNamePrefix string) ([]resolvedPrecondition, error) {
+	if rs == nil {
+		return nil, nil
+	}
+
+	secretPaths := make(map[string]struct{}, len(secrets))
+	for _, s := range secrets {
+		secretPaths[s.path] = struct{}{}
+	}
+
+	seen := make(map[PreconditionRule]struct{})
+	var resolved []resolvedPrecondition
+	add := func(rule PreconditionRule, strict bool) error {
+		if _, ok := seen[rule]; ok {
+			return nil
+		}
+		seen[rule] = struct{}{}
+		p, err := resolvePrecondition(rs, rule, secretPaths, moduleNamePrefix)
+		if err != nil {
+			if strict {
+				return err
+			}
+			return nil
+		}
+		resolved = append(resolved, p)
+		return nil
+	}
+
+	for _, rule := range configured {
+		rule.When = strings.TrimPrefix(strings.TrimSpace(rule.When), "body.")
+		rule.Require = strings.TrimPrefix(strings.TrimSpace(rule.Require), "body.")
+		if err := add(rule, true); err != nil {
+			return nil, err
+		}
+	}
+	for _, rule := range inferPreconditionRules(rs) {
+		_ = add(rule, false)
+	}
+
+	return resolved, nil
+}
+
+func resolvePrecondition(rs *schema.ResourceSchema, rule PreconditionRule, secretPaths map[string]struct{}, moduleNamePrefix string) (resolvedPrecondition, error) {
+	gate := propertyForExportPath(rs, rule.When)
+	if gate == nil || !isWritableProperty(gate) {
+		return resolvedPrecondition{}, fmt.Errorf("precondition path %q does not exist in the resource schema or is read-only", rule.When)
+	}
+	target := propertyForExportPath(rs, rule.Require)
+	if target == nil || !isWritableProperty(target) {
+		return resolvedPrecondition{}, fmt.Errorf("precondition path %q does not exist in the resource schema or is read-only", rule.Require)
+	}
+	if _, ok := secretPaths[rule.Require]; ok {
+		return resolvedPrecondition{}, fmt.Errorf("precondition path %q is a secret and cannot be referenced", rule.Require)
+	}
+
+	gateValue := hclwrite.TokensForValue(cty.StringVal(rule.Equals))
+	if gate.Type == schema.TypeBoolean {
+		value, ok := canonicalGateValue(gate, rule.Equals)
+		if !ok {
+			return resolvedPrecondition{}, fmt.Errorf("precondition value %q is not a boolean for %q", rule.Equals, rule.When)
+		}
+		gateValue = hclwrite.TokensForIdentifier(value)
+	}
+
+	gateParts, gateGuards, err := variablePartsForBodyPath(rule.When, moduleNamePrefix)
+	if err != nil {
+		return resolvedPrecondition{}, err
+	}
+	targetParts, targetGuards, err := variablePartsForBodyPath(rule.Require, moduleNamePrefix)
+	if err != nil {
+		return resolvedPrecondition{}, err
+	}
+
+	var inner hclwrite.Tokens
+	inner = append(inner, hclgen.TokensForTraversal(gateParts...)...)
+	inner = append(inner, &hclwrite.Token{Type: hclsyntax.TokenNotEqual, Bytes: []byte(" != ")})
+	inner = append(inner, gateValue...)
+	inner = append(inner, &hclwrite.Token{Type: hclsyntax.TokenOr, Bytes: []byte(" || ")})
+	inner = append(inner, wrapAncestorGuards(targetGuards, gateGuards, hclgen.TokensForTraversal(targetParts...))...)
+	inner = append(inner, &hclwrite.Token{Type: hclsyntax.TokenNotEqual, Bytes: []byte(" != ")})
+	inner = append(inner, hclwrite.TokensForIdentifier("null")...)
+
+	condition := inner
+	for i := len(gateGuards) - 1; i >= 0; i-- {
+		condition = wrapWithNullGuard(hclgen.TokensForTraversal(gateGuards[i]...), condition)
+	}
+
+	return resolvedPrecondition{
+		variable:  targetParts[1],
+		condition: condition,
+		errorMessage: fmt.Sprintf("%s must be set when %s is %s.",
+			strings.Join(targetParts, "."), strings.Join(gateParts, "."), string(gateValue.Bytes())),
+	}, nil
+}
+
+// wrapAncestorGuards makes the target reference null-safe for ancestors that the
+// gate guards do not already cover, using try() since a null ancestor means the
+// target is unset.
+func wrapAncestorGuards(targetGuards, gateGuards [][]string, ref hclwrite.Tokens) hclwrite.Tokens {
+	covered := make(map[string]struct{}, len(gateGuards))
+	for _, g := range gateGuards {
+		covered[strings.Join(g, ".")] = struct{}{}
+	}
+	for _, g := range targetGuards {
+		if _, ok := covered[strings.Join(g, ".")]; !ok {
+			return hclwrite.TokensForFunctionCall("try", ref, hclwrite.TokensForIdentifier("null"))
+		}
+	}
+	return ref
+}
+
+// variablePartsForBodyPath maps a body path onto the module variable traversal
+// that carries it, returning the traversal and the ancestor traversals that need
+// null guards. Only paths through nested objects (not arrays) can be mapped.
+func variablePartsForBodyPath(path, moduleNamePrefix string) ([]string, [][]string, error) {
+	segments := strings.Split(path, ".")
+	if segments[0] == "properties" {
+		segments = segments[1:]
+	}
+	if len(segments) == 0 {
+		return nil, nil, fmt.Errorf("precondition path %q does not map to a module variable", path)
+	}
+
+	varName := naming.ToSnakeCase(segments[0])
+	if moduleNamePrefix != "" && varName == "version" {
+		varName = moduleNamePrefix + "_version"
+	}
+	parts := []string{"var", varName}
+	var guards [][]string
+	for _, seg := range segments[1:] {
+		guards = append(guards, append([]string(nil), parts...))
+		parts = append(parts, naming.ToSnakeCase(seg))
+	}
+	return parts, guards, nil
+}
+
+// appendPreconditionBlocks appends one precondition block per rule to a lifecycle body.
+func appendPreconditionBlocks(lifecycleBody *hclwrite.Body, preconditions []resolvedPrecondition) {
+	for _, p := range preconditions {
+		block := lifecycleBody.AppendNewBlock("precondition", nil)
+		block.Body().SetAttributeRaw("condition", p.condition)
+		block.Body().SetAttributeValue("error_message", cty.StringVal(p.errorMessage))
+	}
+}
+
+// appendPreconditionValidations appends each rule as a validation block to the
+// variable holding its required property, and returns the rules whose variable
+// is not declared in variables, which remain lifecycle preconditions.
+func appendPreconditionValidations(variables *hclwrite.File, preconditions []resolvedPrecondition) []resolvedPrecondition {
+	var remaining []resolvedPrecondition
+	for _, p := range preconditions {
+		block := variables.Body().FirstMatchingBlock("variable", []string{p.variable})
+		if block == nil {
+			remaining = append(remaining, p)
+			continue
+		}
+		appendValidation(block.Body(), p.condition, p.errorMessage)
+	}
+	return remaining
+}
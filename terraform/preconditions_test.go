@@ -0,0 +1,157 @@
+package terraform
+
+import (
+	"os"
+	"testing"
+
+	"github.com/matt-FFFFFF/tfmodmake/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func authSchema() *schema.ResourceSchema {
+	return &schema.ResourceSchema{
+		Properties: map[string]*schema.Property{
+			"properties": {Name: "properties", Type: schema.TypeObject, Children: map[string]*schema.Property{
+				"authType": {Name: "authType", Type: schema.TypeString, Enum: []string{"None", "ServicePrincipal"}},
+				"clientId": {Name: "clientId", Type: schema.TypeString, Description: "The client ID. Required when authType is 'ServicePrincipal'."},
+				"network": {Name: "network", Type: schema.TypeObject, Children: map[string]*schema.Property{
+					"mode":     {Name: "mode", Type: schema.TypeString, Enum: []string{"Public", "Private"}},
+					"subnetId": {Name: "subnetId", Type: schema.TypeString, Description: "Must be set if mode is private."},
+				}},
+				"enableBackup": {Name: "enableBackup", Type: schema.TypeBoolean},
+				"backupVault":  {Name: "backupVault", Type: schema.TypeString, Description: "Required if enableBackup is true."},
+				"notes":        {Name: "notes", Type: schema.TypeString, Description: "Required when tier is Premium."},
+			}},
+		},
+	}
+}
+
+func TestInferPreconditionRules(t *testing.T) {
+	rules := inferPreconditionRules(authSchema())
+
+	assert.Equal(t, []PreconditionRule{
+		{When: "properties.enableBackup", Equals: "true", Require: "properties.backupVault"},
+		{When: "properties.authType", Equals: "ServicePrincipal", Require: "properties.clientId"},
+		{When: "properties.network.mode", Equals: "Private", Require: "properties.network.subnetId"},
+	}, rules)
+}
+
+func TestInferPreconditionRules_IgnoresValuesOutsideEnum(t *testing.T) {
+	rs := &schema.ResourceSchema{
+		Properties: map[string]*schema.Property{
+			"mode":  {Name: "mode", Type: schema.TypeString, Enum: []string{"A", "B"}},
+			"extra": {Name: "extra", Type: schema.TypeString, Description: "Required when mode is C."},
+		},
+	}
+	assert.Empty(t, inferPreconditionRules(rs))
+}
+
+func TestInferPreconditionRules_EnabledSettings(t *testing.T) {
+	rs := &schema.ResourceSchema{
+		Properties: map[string]*schema.Property{
+			"properties": {Name: "properties", Type: schema.TypeObject, Children: map[string]*schema.Property{
+				"backup": {Name: "backup", Type: schema.TypeObject, Children: map[string]*schema.Property{
+					"enabled":  {Name: "enabled", Type: schema.TypeBoolean},
+					"settings": {Name: "settings", Type: schema.TypeObject},
+				}},
+				"logging": {Name: "logging", Type: schema.TypeObject, Children: map[string]*schema.Property{
+					"isEnabled":           {Name: "isEnabled", Type: schema.TypeBoolean},
+					"destinationConfig":   {Name: "destinationConfig", Type: schema.TypeString},
+					"retentionPolicy":     {Name: "retentionPolicy", Type: schema.TypeObject},
+					"readOnlyStatusCheck": {Name: "readOnlyStatusCheck", Type: schema.TypeString, ReadOnly: true},
+				}},
+				"ambiguous": {Name: "ambiguous", Type: schema.TypeObject, Children: map[string]*schema.Property{
+					"enabled":       {Name: "enabled", Type: schema.TypeBoolean},
+					"settings":      {Name: "settings", Type: schema.TypeObject},
+					"configuration": {Name: "configuration", Type: schema.TypeObject},
+				}},
+				"mandatory": {Name: "mandatory", Type: schema.TypeObject, Children: map[string]*schema.Property{
+					"enabled":  {Name: "enabled", Type: schema.TypeBoolean},
+					"settings": {Name: "settings", Type: schema.TypeObject, Required: true},
+				}},
+				"stringGate": {Name: "stringGate", Type: schema.TypeObject, Children: map[string]*schema.Property{
+					"enabled":  {Name: "enabled", Type: schema.TypeString},
+					"settings": {Name: "settings", Type: schema.TypeObject},
+				}},
+			}},
+		},
+	}
+
+	assert.Equal(t, []PreconditionRule{
+		{When: "properties.backup.enabled", Equals: "true", Require: "properties.backup.settings"},
+		{When: "properties.logging.isEnabled", Equals: "true", Require: "properties.logging.destinationConfig"},
+	}, inferPreconditionRules(rs))
+}
+
+func TestResolvePreconditions_ConfiguredRuleMustExist(t *testing.T) {
+	_, err := resolvePreconditions(authSchema(), []PreconditionRule{{When: "properties.missing", Equals: "x", Require: "properties.clientId"}}, nil, "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "properties.missing")
+}
+
+func TestGenerate_Preconditions(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+
+	require.NoError(t, os.Chdir(tmpDir))
+
+	err = Generate("Microsoft.Test/widgets",
+		WithResourceSchema(authSchema()),
+		WithAPIVersion("2025-01-01"),
+		WithPreconditions(PreconditionRule{When: "body.properties.authType", Equals: "None", Require: "properties.notes"}),
+	)
+	require.NoError(t, err)
+
+	body := parseHCLBody(t, "main.tf")
+	resource := requireBlock(t, body, "resource", "azapi_resource", "this")
+	lifecycle := requireBlock(t, resource.Body, "lifecycle")
+
+	var conditions []string
+	for _, block := range findAllBlocks(lifecycle.Body, "precondition") {
+		conditions = append(conditions, expressionString(t, block.Body.Attributes["condition"].Expr))
+	}
+
+	assert.Equal(t, []string{
+		`var.auth_type != "None" || var.notes != null`,
+		`var.enable_backup != true || var.backup_vault != null`,
+		`var.auth_type != "ServicePrincipal" || var.client_id != null`,
+		`var.network == null || var.network.mode != "Private" || var.network.subnet_id != null`,
+	}, conditions)
+}
+
+func TestGenerate_PreconditionValidations(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+
+	require.NoError(t, os.Chdir(tmpDir))
+
+	err = Generate("Microsoft.Test/widgets",
+		WithResourceSchema(authSchema()),
+		WithAPIVersion("2025-01-01"),
+		WithPreconditionValidations(true),
+	)
+	require.NoError(t, err)
+
+	resource := requireBlock(t, parseHCLBody(t, "main.tf"), "resource", "azapi_resource", "this")
+	assert.Nil(t, findBlock(resource.Body, "lifecycle"))
+
+	variables := parseHCLBody(t, "variables.tf")
+	validations := func(name string) map[string]string {
+		messages := map[string]string{}
+		for _, block := range findAllBlocks(requireBlock(t, variables, "variable", name).Body, "validation") {
+			messages[expressionString(t, block.Body.Attributes["condition"].Expr)] = expressionString(t, block.Body.Attributes["error_message"].Expr)
+		}
+		return messages
+	}
+	assert.Equal(t, `"var.network.subnet_id must be set when var.network.mode is \"Private\"."`,
+		validations("network")[`var.network == null || var.network.mode != "Private" || var.network.subnet_id != null`])
+	assert.Contains(t, validations("client_id"), `var.auth_type != "ServicePrincipal" || var.client_id != null`)
+	assert.Contains(t, validations("backup_vault"), `var.enable_backup != true || var.backup_vault != null`)
+}
@@ -0,0 +1,54 @@
+package terraform
+
+import (
+	"strings"
+
+	"github.com/matt-FFFFFF/tfmodmake/schema"
+)
+
+// DefaultPropertyBlocklist are the body paths that never become variables, body
+// entries or outputs, even where a spec marks them writable: ARM metadata and
+// status the service owns.
+var DefaultPropertyBlocklist = []string{"systemData", "etag", "properties.provisioningState"}
+
+// withPropertyBlocklist returns rs without the properties at the given body paths
+// (dot-separated, case-insensitive), so they are left out of the variables,
+// locals, descriptions and secrets, and of the outputs and exported response
+// values. Paths the schema does not have are ignored; rs itself is not modified.
+func withPropertyBlocklist(rs *schema.ResourceSchema, paths []string) *schema.ResourceSchema {
+	if rs == nil || len(paths) == 0 {
+		return rs
+	}
+	copied := *rs
+	copied.Properties = rs.Properties
+	for _, path := range paths {
+		copied.Properties = blockProperty(copied.Properties, strings.Split(path, "."))
+	}
+	return &copied
+}
+
+// blockProperty returns props without the property at segments. The maps and
+// properties along the path are copied; props is not modified.
+func blockProperty(props map[string]*schema.Property, segments []string) map[string]*schema.Property {
+	for key, prop := range props {
+		if prop == nil || !strings.EqualFold(key, segments[0]) {
+			continue
+		}
+		copied := make(map[string]*schema.Property, len(props))
+		for k, v := range props {
+			copied[k] = v
+		}
+		if len(segments) == 1 {
+			delete(copied, key)
+			return copied
+		}
+		if prop.Children == nil {
+			return props
+		}
+		parent := *prop
+		parent.Children = blockProperty(prop.Children, segments[1:])
+		copied[key] = &parent
+		return copied
+	}
+	return props
+}
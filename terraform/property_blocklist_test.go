@@ -0,0 +1,102 @@
+package terraform
+
+import (
+	"os"
+	"testing"
+
+	"github.com/matt-FFFFFF/tfmodmake/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func blocklistTestSchema() *schema.ResourceSchema {
+	return &schema.ResourceSchema{
+		Properties: map[string]*schema.Property{
+			"name": {Name: "name", Type: schema.TypeString, Required: true},
+			"eTag": {Name: "eTag", Type: schema.TypeString},
+			"systemData": {Name: "systemData", Type: schema.TypeObject, Children: map[string]*schema.Property{
+				"createdBy": {Name: "createdBy", Type: schema.TypeString},
+			}},
+			"properties": {Name: "properties", Type: schema.TypeObject, Children: map[string]*schema.Property{
+				"provisioningState": {Name: "provisioningState", Type: schema.TypeString, Required: true},
+				"replicaCount":      {Name: "replicaCount", Type: schema.TypeInteger},
+				"adminPassword":     {Name: "adminPassword", Type: schema.TypeString, Sensitive: true},
+			}},
+		},
+	}
+}
+
+func variableNames(t *testing.T) []string {
+	t.Helper()
+	var names []string
+	for _, block := range parseHCLBody(t, "variables.tf").Blocks {
+		if block.Type == "variable" {
+			names = append(names, block.Labels[0])
+		}
+	}
+	return names
+}
+
+func TestGenerate_DefaultPropertyBlocklist(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+
+	require.NoError(t, os.Chdir(tmpDir))
+
+	rs := blocklistTestSchema()
+	require.NoError(t, Generate("Microsoft.Test/widgets", WithResourceSchema(rs), WithAPIVersion("2024-01-01")))
+
+	names := variableNames(t)
+	assert.Contains(t, names, "replica_count")
+	assert.Contains(t, names, "admin_password")
+	assert.NotContains(t, names, "e_tag")
+	assert.NotContains(t, names, "system_data")
+	assert.NotContains(t, names, "provisioning_state")
+
+	locals, err := os.ReadFile("locals.tf")
+	require.NoError(t, err)
+	assert.NotContains(t, string(locals), "provisioningState")
+	assert.NotContains(t, string(locals), "systemData")
+
+	// Blocklisted properties are not read back either.
+	outputs, err := os.ReadFile("outputs.tf")
+	require.NoError(t, err)
+	assert.NotContains(t, string(outputs), "provisioning")
+	assert.NotContains(t, string(outputs), "system_data")
+	resource := requireBlock(t, parseHCLBody(t, "main.tf"), "resource", "azapi_resource", "this")
+	exports := expressionString(t, resource.Body.Attributes["response_export_values"].Expr)
+	assert.NotContains(t, exports, "provisioningState")
+	assert.NotContains(t, exports, "systemData")
+
+	// The schema given to the generator is left as it was.
+	assert.False(t, rs.Properties["properties"].Children["provisioningState"].ReadOnly)
+	assert.True(t, rs.Properties["properties"].Children["provisioningState"].Required)
+}
+
+func TestGenerate_PropertyBlocklistReplacesDefaults(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+
+	require.NoError(t, os.Chdir(tmpDir))
+
+	require.NoError(t, Generate("Microsoft.Test/widgets", WithResourceSchema(blocklistTestSchema()), WithAPIVersion("2024-01-01"),
+		WithPropertyBlocklist("Properties.AdminPassword")))
+
+	names := variableNames(t)
+	assert.Contains(t, names, "e_tag")
+	assert.Contains(t, names, "provisioning_state")
+	assert.NotContains(t, names, "admin_password")
+
+	// An empty blocklist disables it.
+	require.NoError(t, Generate("Microsoft.Test/widgets", WithResourceSchema(blocklistTestSchema()), WithAPIVersion("2024-01-01"),
+		WithPropertyBlocklist()))
+	names = variableNames(t)
+	assert.Contains(t, names, "e_tag")
+	assert.Contains(t, names, "admin_password")
+}
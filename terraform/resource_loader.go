@@ -3,9 +3,12 @@ package terraform
 import (
 	"context"
 	"fmt"
+	"slices"
 
 	"github.com/matt-FFFFFF/tfmodmake/bicepdata"
+	"github.com/matt-FFFFFF/tfmodmake/profile"
 	"github.com/matt-FFFFFF/tfmodmake/schema"
+	"golang.org/x/sync/errgroup"
 )
 
 // ResourceLoadResult contains all information needed to generate a Terraform module.
@@ -19,8 +22,15 @@ type LoadOption func(*loadOptions)
 type loadOptions struct {
 	apiVersion     string
 	includePreview bool
+	typesPath      string
+	typesRef       string
+	cache          *bicepdata.Cache
 }
 
+// DefaultLoadConcurrency is the number of schemas LoadResourceSchemas loads at
+// once when no concurrency is given.
+const DefaultLoadConcurrency = 8
+
 // WithAPIVersionLoad sets a specific API version to load.
 func WithAPIVersionLoad(version string) LoadOption {
 	return func(o *loadOptions) {
@@ -35,25 +45,106 @@ func WithIncludePreview(include bool) LoadOption {
 	}
 }
 
+// WithTypesPath loads the resource from a local bicep-types-az checkout instead of
+// the published types.
+func WithTypesPath(path string) LoadOption {
+	return func(o *loadOptions) {
+		o.typesPath = path
+	}
+}
+
+// WithTypesRef reads the published types at a bicep-types-az branch, tag or
+// commit instead of main.
+func WithTypesRef(ref string) LoadOption {
+	return func(o *loadOptions) {
+		o.typesRef = ref
+	}
+}
+
+// WithLoadCache shares the index and types files fetched by loads using the same
+// cache, so a types file serving several resource types is fetched and parsed once.
+func WithLoadCache(cache *bicepdata.Cache) LoadOption {
+	return func(o *loadOptions) {
+		o.cache = cache
+	}
+}
+
 // LoadResource loads a resource type using bicep-types-az data.
 func LoadResource(ctx context.Context, resourceType string, opts ...LoadOption) (GeneratorOption, error) {
+	rs, err := LoadResourceSchema(ctx, resourceType, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return WithLoadedSchema(rs), nil
+}
+
+// WithLoadedSchema generates from a loaded schema, at its API version.
+func WithLoadedSchema(rs *schema.ResourceSchema) GeneratorOption {
+	return func(o *generatorOptions) {
+		o.schema = rs
+		o.apiVersion = rs.APIVersion
+	}
+}
+
+// LoadResourceSchema loads and converts the schema of a resource type using bicep-types-az data.
+func LoadResourceSchema(ctx context.Context, resourceType string, opts ...LoadOption) (*schema.ResourceSchema, error) {
 	lo := &loadOptions{}
 	for _, opt := range opts {
 		opt(lo)
 	}
 
-	loaded, err := bicepdata.LoadResource(ctx, resourceType, lo.apiVersion, lo.includePreview, nil)
+	var fetchOpts *bicepdata.FetchOptions
+	if lo.typesPath != "" || lo.typesRef != "" || lo.cache != nil {
+		fetchOpts = &bicepdata.FetchOptions{LocalPath: lo.typesPath, Ref: lo.typesRef, Cache: lo.cache}
+	}
+
+	loaded, err := bicepdata.LoadResource(ctx, resourceType, lo.apiVersion, lo.includePreview, fetchOpts)
 	if err != nil {
 		return nil, fmt.Errorf("loading resource %s: %w", resourceType, err)
 	}
 
+	done := profile.FromContext(ctx).Track(profile.PhaseConvert)
 	rs, err := schema.ConvertResource(loaded)
+	done()
 	if err != nil {
 		return nil, fmt.Errorf("converting resource %s: %w", resourceType, err)
 	}
+	return rs, nil
+}
+
+// SchemaRequest is a resource type for LoadResourceSchemas to load, with its own
+// load options.
+type SchemaRequest struct {
+	ResourceType string
+	Options      []LoadOption
+}
+
+// LoadResourceSchemas loads the schemas of the requests with at most concurrency
+// loads at a time (DefaultLoadConcurrency when not positive), returning them in
+// the order of the requests. The loads share a cache, unless opts, which apply to
+// every request before its own options, set one. The first failure cancels the
+// remaining loads and is returned.
+func LoadResourceSchemas(ctx context.Context, requests []SchemaRequest, concurrency int, opts ...LoadOption) ([]*schema.ResourceSchema, error) {
+	if concurrency <= 0 {
+		concurrency = DefaultLoadConcurrency
+	}
+	cache := bicepdata.NewCache()
+	defer cache.Close()
+	opts = append([]LoadOption{WithLoadCache(cache)}, opts...)
 
-	return func(o *generatorOptions) {
-		o.schema = rs
-		o.apiVersion = rs.APIVersion
-	}, nil
+	schemas := make([]*schema.ResourceSchema, len(requests))
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(concurrency)
+	for i, request := range requests {
+		g.Go(func() error {
+			rs, err := LoadResourceSchema(ctx, request.ResourceType, append(slices.Clone(opts), request.Options...)...)
+			schemas[i] = rs
+			return err
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	return schemas, nil
 }
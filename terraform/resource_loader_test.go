@@ -0,0 +1,36 @@
+package terraform
+
+import (
+	"context"
+	"testing"
+
+	"github.com/matt-FFFFFF/tfmodmake/internal/typestest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadResourceSchemas(t *testing.T) {
+	typesPath := typestest.Write(t, typestest.Resource{Type: "Microsoft.Test/widgets"}, typestest.Resource{Type: "Microsoft.Test/widgets/gadgets"}, typestest.Resource{Type: "Microsoft.Test/widgets/gizmos"})
+
+	var requests []SchemaRequest
+	for _, resourceType := range []string{"Microsoft.Test/widgets/gizmos", "Microsoft.Test/widgets", "Microsoft.Test/widgets/gadgets"} {
+		requests = append(requests, SchemaRequest{ResourceType: resourceType, Options: []LoadOption{WithAPIVersionLoad("2024-01-01")}})
+	}
+	schemas, err := LoadResourceSchemas(context.Background(), requests, 2, WithTypesPath(typesPath))
+	require.NoError(t, err)
+	require.Len(t, schemas, 3)
+	for i, rs := range schemas {
+		assert.Equal(t, requests[i].ResourceType, rs.ResourceType)
+		assert.Equal(t, "2024-01-01", rs.APIVersion)
+	}
+}
+
+func TestLoadResourceSchemas_Error(t *testing.T) {
+	typesPath := typestest.Write(t, typestest.Resource{Type: "Microsoft.Test/widgets"})
+
+	_, err := LoadResourceSchemas(context.Background(), []SchemaRequest{
+		{ResourceType: "Microsoft.Test/widgets"},
+		{ResourceType: "Microsoft.Test/missing"},
+	}, 0, WithTypesPath(typesPath))
+	assert.ErrorContains(t, err, "loading resource Microsoft.Test/missing")
+}
@@ -160,3 +160,70 @@ func isTimestampField(path string) bool {
 
 	return false
 }
+
+// DefaultEndpointSuffixes are the property name suffixes of read-only strings that
+// are always exported and output, since consumers need them to wire other resources.
+var DefaultEndpointSuffixes = []string{"Endpoint", "Url", "Uri", "Fqdn", "HostName"}
+
+// extractEndpointPaths returns the paths of read-only string properties (or strings
+// nested in read-only objects) whose name ends with one of suffixes, compared
+// case-insensitively. Unlike extractComputedPaths, no blocklist is applied.
+func extractEndpointPaths(rs *schema.ResourceSchema, suffixes []string) []string {
+	if rs == nil || len(suffixes) == 0 {
+		return nil
+	}
+	lowerSuffixes := make([]string, len(suffixes))
+	for i, s := range suffixes {
+		lowerSuffixes[i] = strings.ToLower(s)
+	}
+
+	var paths []string
+	var walk func(props map[string]*schema.Property, prefix string, readOnly bool)
+	walk = func(props map[string]*schema.Property, prefix string, readOnly bool) {
+		for name, prop := range props {
+			if prop == nil {
+				continue
+			}
+			path := name
+			if prefix != "" {
+				path = prefix + "." + name
+			}
+			propReadOnly := readOnly || prop.ReadOnly
+			if prop.Type == schema.TypeString && propReadOnly && hasAnySuffix(strings.ToLower(name), lowerSuffixes) {
+				paths = append(paths, path)
+				continue
+			}
+			walk(prop.Children, path, propReadOnly)
+		}
+	}
+	walk(rs.Properties, "", false)
+
+	sort.Strings(paths)
+	return paths
+}
+
+func hasAnySuffix(s string, suffixes []string) bool {
+	for _, suffix := range suffixes {
+		if strings.HasSuffix(s, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeExportPaths returns the sorted union of the given path lists.
+func mergeExportPaths(lists ...[]string) []string {
+	seen := make(map[string]struct{})
+	var out []string
+	for _, list := range lists {
+		for _, p := range list {
+			if _, ok := seen[p]; ok {
+				continue
+			}
+			seen[p] = struct{}{}
+			out = append(out, p)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
@@ -337,3 +337,31 @@ func TestShouldBlockPath(t *testing.T) {
 		})
 	}
 }
+
+func TestExtractEndpointPaths(t *testing.T) {
+	rs := &schema.ResourceSchema{
+		Properties: map[string]*schema.Property{
+			"properties": {Name: "properties", Type: schema.TypeObject, Children: map[string]*schema.Property{
+				"primaryEndpoint": {Name: "primaryEndpoint", Type: schema.TypeString, ReadOnly: true},
+				"callbackUrl":     {Name: "callbackUrl", Type: schema.TypeString},
+				"defaultHostName": {Name: "defaultHostName", Type: schema.TypeString, ReadOnly: true},
+				"status": {Name: "status", Type: schema.TypeObject, ReadOnly: true, Children: map[string]*schema.Property{
+					"fqdn":  {Name: "fqdn", Type: schema.TypeString},
+					"phase": {Name: "phase", Type: schema.TypeString},
+				}},
+			}},
+		},
+	}
+
+	assert.Equal(t, []string{
+		"properties.defaultHostName",
+		"properties.primaryEndpoint",
+		"properties.status.fqdn",
+	}, extractEndpointPaths(rs, DefaultEndpointSuffixes))
+	assert.Equal(t, []string{"properties.status.fqdn"}, extractEndpointPaths(rs, []string{"FQDN"}))
+	assert.Empty(t, extractEndpointPaths(rs, nil))
+}
+
+func TestMergeExportPaths(t *testing.T) {
+	assert.Equal(t, []string{"a", "b", "c"}, mergeExportPaths([]string{"c", "a"}, []string{"b", "a"}))
+}
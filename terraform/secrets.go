@@ -268,3 +268,78 @@ func tokensForSensitiveBody(secrets []secretField, valueFor func(secretField) hc
 
 	return render(root, nil)
 }
+
+// secretNameSuffixes and secretNameFragments flag response property names whose
+// values are credentials even when the spec does not mark them x-ms-secret.
+var (
+	secretNameSuffixes  = []string{"key", "keys"}
+	secretNameFragments = []string{"password", "secret", "connectionstring", "token"}
+)
+
+// isSecretName reports whether a property name looks like it holds a credential,
+// e.g. primaryKey, accessKeys or connectionString. Public keys are not secrets.
+func isSecretName(name string) bool {
+	lower := strings.ToLower(name)
+	if strings.Contains(lower, "public") {
+		return false
+	}
+	for _, fragment := range secretNameFragments {
+		if strings.Contains(lower, fragment) {
+			return true
+		}
+	}
+	for _, suffix := range secretNameSuffixes {
+		if strings.HasSuffix(lower, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// isSensitiveExportPath reports whether an exported response path must only be
+// surfaced through sensitive outputs: a property along the path is x-ms-secret or
+// write-only or has a credential-like name, or the exported container holds one.
+func isSensitiveExportPath(rs *schema.ResourceSchema, exportPath string) bool {
+	if rs == nil {
+		return false
+	}
+	props := rs.Properties
+	var prop *schema.Property
+	for _, segment := range strings.Split(exportPath, ".") {
+		if isSecretName(segment) {
+			return true
+		}
+		prop = props[segment]
+		if prop == nil {
+			return false
+		}
+		if isSecretField(prop) {
+			return true
+		}
+		props = prop.Children
+	}
+	return containsSensitiveResponse(prop)
+}
+
+// containsSensitiveResponse reports whether any property beneath prop, read-only or
+// not, is a secret by flag or name.
+func containsSensitiveResponse(prop *schema.Property) bool {
+	if prop == nil {
+		return false
+	}
+	if prop.ItemType != nil && (isSecretField(prop.ItemType) || containsSensitiveResponse(prop.ItemType)) {
+		return true
+	}
+	if prop.AdditionalProperties != nil && (isSecretField(prop.AdditionalProperties) || containsSensitiveResponse(prop.AdditionalProperties)) {
+		return true
+	}
+	for name, child := range prop.Children {
+		if child == nil {
+			continue
+		}
+		if isSecretName(name) || isSecretField(child) || containsSensitiveResponse(child) {
+			return true
+		}
+	}
+	return false
+}
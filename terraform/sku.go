@@ -0,0 +1,81 @@
+package terraform
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/matt-FFFFFF/tfmodmake/naming"
+	"github.com/matt-FFFFFF/tfmodmake/schema"
+)
+
+// skuPrimaryAttributes are listed first in the sku description, in this order.
+var skuPrimaryAttributes = []string{"name", "tier", "capacity"}
+
+// skuProperty returns the writable top-level sku object of the schema, or nil when
+// the resource has none (or models it as a plain string).
+func skuProperty(rs *schema.ResourceSchema) *schema.Property {
+	return rootObjectProperty(rs, "sku")
+}
+
+// rootObjectProperty returns the writable top-level object property name of the
+// schema when it has children, or nil.
+func rootObjectProperty(rs *schema.ResourceSchema, name string) *schema.Property {
+	if rs == nil {
+		return nil
+	}
+	prop := rs.Properties[name]
+	if prop == nil || prop.Type != schema.TypeObject || len(prop.Children) == 0 || !isWritableProperty(prop) {
+		return nil
+	}
+	return prop
+}
+
+// skuDescription documents the sku variable with its attributes and, where the
+// schema provides them, their possible values and numeric bounds.
+func skuDescription(prop *schema.Property) string {
+	var sb strings.Builder
+	desc := strings.TrimSpace(prop.Description)
+	if desc == "" {
+		desc = "The SKU of the resource."
+	}
+	sb.WriteString(desc)
+	sb.WriteString("\n\n")
+
+	names := make([]string, 0, len(prop.Children))
+	for name, child := range prop.Children {
+		if child != nil && isWritableProperty(child) {
+			names = append(names, name)
+		}
+	}
+	rank := func(name string) int {
+		for i, primary := range skuPrimaryAttributes {
+			if name == primary {
+				return i
+			}
+		}
+		return len(skuPrimaryAttributes)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if ri, rj := rank(names[i]), rank(names[j]); ri != rj {
+			return ri < rj
+		}
+		return names[i] < names[j]
+	})
+
+	for _, name := range names {
+		child := prop.Children[name]
+		childDesc := strings.ReplaceAll(strings.TrimSpace(child.Description), "\n", " ")
+		if childDesc == "" {
+			childDesc = fmt.Sprintf("The %s of the SKU.", name)
+		}
+		if values, ok := enumValues(child); ok {
+			childDesc += fmt.Sprintf(" Possible values: `%s`.", strings.Join(values, "`, `"))
+		}
+		if c := child.Constraints; child.Type == schema.TypeInteger && c.MinValue != nil && c.MaxValue != nil {
+			childDesc += fmt.Sprintf(" Must be between %d and %d.", *c.MinValue, *c.MaxValue)
+		}
+		sb.WriteString(fmt.Sprintf("- `%s` - %s\n", naming.ToSnakeCase(name), childDesc))
+	}
+	return sb.String()
+}
@@ -0,0 +1,82 @@
+package terraform
+
+import (
+	"os"
+	"testing"
+
+	"github.com/matt-FFFFFF/tfmodmake/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func skuSchema() *schema.ResourceSchema {
+	minCapacity, maxCapacity := int64(1), int64(10)
+	return &schema.ResourceSchema{
+		Properties: map[string]*schema.Property{
+			"sku": {Name: "sku", Type: schema.TypeObject, Required: true, Children: map[string]*schema.Property{
+				"name":     {Name: "name", Type: schema.TypeString, Required: true, Enum: []string{"Standard", "Basic"}},
+				"tier":     {Name: "tier", Type: schema.TypeString, Enum: []string{"Free", "Paid"}},
+				"capacity": {Name: "capacity", Type: schema.TypeInteger, Constraints: schema.Constraints{MinValue: &minCapacity, MaxValue: &maxCapacity}},
+				"family":   {Name: "family", Type: schema.TypeString, Description: "The SKU family."},
+			}},
+			"properties": {Name: "properties", Type: schema.TypeObject, Children: map[string]*schema.Property{
+				"enabled": {Name: "enabled", Type: schema.TypeBoolean},
+			}},
+		},
+	}
+}
+
+func TestSkuDescription(t *testing.T) {
+	assert.Equal(t, "The SKU of the resource.\n\n"+
+		"- `name` - The name of the SKU. Possible values: `Basic`, `Standard`.\n"+
+		"- `tier` - The tier of the SKU. Possible values: `Free`, `Paid`.\n"+
+		"- `capacity` - The capacity of the SKU. Must be between 1 and 10.\n"+
+		"- `family` - The SKU family.\n",
+		skuDescription(skuSchema().Properties["sku"]))
+}
+
+func TestSkuProperty_IgnoresStringSku(t *testing.T) {
+	rs := &schema.ResourceSchema{Properties: map[string]*schema.Property{
+		"sku": {Name: "sku", Type: schema.TypeString},
+	}}
+	assert.Nil(t, skuProperty(rs))
+}
+
+func TestGenerate_SkuVariable(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+
+	require.NoError(t, os.Chdir(tmpDir))
+
+	require.NoError(t, Generate("Microsoft.Test/widgets", WithResourceSchema(skuSchema()), WithAPIVersion("2025-01-01")))
+
+	varsBody := parseHCLBody(t, "variables.tf")
+	var names []string
+	for _, block := range varsBody.Blocks {
+		names = append(names, block.Labels[0])
+	}
+	require.GreaterOrEqual(t, len(names), 4)
+	assert.Equal(t, []string{"name", "parent_id", "location", "sku"}, names[:4])
+	assert.Equal(t, 1, countOccurrences(names, "sku"))
+
+	sku := requireBlock(t, varsBody, "variable", "sku")
+	assert.Nil(t, sku.Body.Attributes["default"])
+	assert.Len(t, findAllBlocks(sku.Body, "validation"), 4)
+
+	localsBody := parseHCLBody(t, "locals.tf")
+	locals := requireBlock(t, localsBody, "locals")
+	assert.Contains(t, expressionString(t, locals.Body.Attributes["resource_body"].Expr), "name     = var.sku.name")
+}
+
+func countOccurrences(values []string, want string) int {
+	n := 0
+	for _, v := range values {
+		if v == want {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,317 @@
+package terraform
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/matt-FFFFFF/tfmodmake/config"
+	"github.com/matt-FFFFFF/tfmodmake/naming"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// specExampleSkippedKeys are root body keys that never map to a variable of
+// the same name: read-only fields, the flattened properties bag and the identity,
+// which the module takes as managed_identities.
+var specExampleSkippedKeys = map[string]bool{
+	"id":         true,
+	"name":       true,
+	"type":       true,
+	"etag":       true,
+	"systemData": true,
+	"properties": true,
+	"identity":   true,
+}
+
+// specExampleValues are realistic values of module variables harvested from the
+// x-ms-examples of a spec, keyed by variable name. They are the JSON values of
+// the example request bodies, decoded with json.Number for numbers.
+type specExampleValues map[string]any
+
+// specExample is one x-ms-examples file creating the resource.
+type specExample struct {
+	file string
+	body map[string]any
+	// name is the resource name from the request parameters, if found.
+	name   string
+	leaves int
+}
+
+// loadSpecExampleValues reads the x-ms-examples files in dir and maps the request
+// bodies of those creating resourceType onto the variables generated from them:
+// root body keys and the children of properties become snake_case variables, and
+// the resource name parameter becomes name. When several examples set a variable,
+// the example setting the most values wins.
+func loadSpecExampleValues(dir, resourceType string) (specExampleValues, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	var examples []specExample
+	for _, path := range paths {
+		example, ok, err := readSpecExample(path, resourceType)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			examples = append(examples, example)
+		}
+	}
+	sort.SliceStable(examples, func(i, j int) bool {
+		if examples[i].leaves != examples[j].leaves {
+			return examples[i].leaves > examples[j].leaves
+		}
+		return examples[i].file < examples[j].file
+	})
+
+	values := specExampleValues{}
+	set := func(name string, value any) {
+		if _, ok := values[name]; !ok && value != nil {
+			values[name] = value
+		}
+	}
+	for _, example := range examples {
+		if example.name != "" {
+			set("name", example.name)
+		}
+		for _, key := range sortedKeys(example.body) {
+			if !specExampleSkippedKeys[key] {
+				set(naming.ToSnakeCase(key), example.body[key])
+			}
+		}
+		if properties, ok := example.body["properties"].(map[string]any); ok {
+			for _, key := range sortedKeys(properties) {
+				set(naming.ToSnakeCase(key), properties[key])
+			}
+		}
+	}
+	return values, nil
+}
+
+// readSpecExample parses an x-ms-examples file and reports whether it creates
+// resourceType: a response body has the type and a request parameter is a body
+// with properties, location or tags.
+func readSpecExample(path, resourceType string) (specExample, bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return specExample{}, false, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var file struct {
+		Parameters map[string]any `json:"parameters"`
+		Responses  map[string]struct {
+			Body map[string]any `json:"body"`
+		} `json:"responses"`
+	}
+	if err := dec.Decode(&file); err != nil {
+		return specExample{}, false, fmt.Errorf("parsing example %s: %w", path, err)
+	}
+
+	createsType := false
+	for _, response := range file.Responses {
+		if t, ok := response.Body["type"].(string); ok && strings.EqualFold(t, resourceType) {
+			createsType = true
+		}
+	}
+	if !createsType {
+		return specExample{}, false, nil
+	}
+
+	example := specExample{file: filepath.Base(path)}
+	segments := strings.Split(resourceType, "/")
+	nameParameter := singularize(segments[len(segments)-1]) + "Name"
+	for _, key := range sortedKeys(file.Parameters) {
+		switch value := file.Parameters[key].(type) {
+		case map[string]any:
+			_, hasProperties := value["properties"]
+			_, hasLocation := value["location"]
+			_, hasTags := value["tags"]
+			if example.body == nil && (hasProperties || hasLocation || hasTags) {
+				example.body = value
+			}
+		case string:
+			if strings.EqualFold(key, nameParameter) {
+				example.name = value
+			}
+		}
+	}
+	if example.body == nil {
+		return specExample{}, false, nil
+	}
+	example.leaves = countLeaves(example.body)
+	return example, true, nil
+}
+
+// moduleSpecExampleValues loads the example values configured for the module in
+// dir by spec_examples in its tfmodmake.json, or returns nil when there are none.
+func moduleSpecExampleValues(dir, resourceType string) (specExampleValues, error) {
+	cfg, err := config.LoadFromDir(dir)
+	if err != nil || cfg.SpecExamples == "" || resourceType == "" {
+		return nil, err
+	}
+	examplesDir := cfg.SpecExamples
+	if !filepath.IsAbs(examplesDir) {
+		examplesDir = filepath.Join(dir, examplesDir)
+	}
+	values, err := loadSpecExampleValues(examplesDir, resourceType)
+	if err != nil {
+		return nil, fmt.Errorf("loading spec examples: %w", err)
+	}
+	return values, nil
+}
+
+// moduleResourceType returns the resource type of azapi_resource.this in the
+// main.tf of dir, or "" when there is none.
+func moduleResourceType(dir string) (resourceType, apiVersion string) {
+	if main, err := ParseHCLFile(filepath.Join(dir, "main.tf")); err == nil {
+		resourceType, apiVersion, _ = ExtractResourceTypeAndVersion(main)
+	}
+	return resourceType, apiVersion
+}
+
+// specValue converts a harvested JSON value to ty, matching object attributes
+// to the snake_case of the JSON keys. Attributes the example leaves out get a
+// placeholder when they are required, or when the builder is complete. It
+// reports false when the value does not fit the type.
+func (b *exampleBuilder) specValue(path string, raw any, ty cty.Type) (cty.Value, bool) {
+	switch {
+	case ty == cty.DynamicPseudoType:
+		return specDynamicValue(raw)
+	case ty == cty.String:
+		s, ok := raw.(string)
+		return cty.StringVal(s), ok
+	case ty == cty.Number:
+		n, ok := raw.(json.Number)
+		if !ok {
+			return cty.NilVal, false
+		}
+		f, _, err := big.ParseFloat(n.String(), 10, 512, big.ToNearestEven)
+		return cty.NumberVal(f), err == nil
+	case ty == cty.Bool:
+		v, ok := raw.(bool)
+		return cty.BoolVal(v), ok
+	case ty.IsListType() || ty.IsSetType():
+		items, ok := raw.([]any)
+		if !ok {
+			return cty.NilVal, false
+		}
+		values := make([]cty.Value, 0, len(items))
+		for _, item := range items {
+			v, ok := b.specValue(path+".*", item, ty.ElementType())
+			if !ok {
+				return cty.NilVal, false
+			}
+			values = append(values, v)
+		}
+		// Examples are rendered, never converted, so a tuple stands in for both.
+		return cty.TupleVal(values), true
+	case ty.IsMapType():
+		entries, ok := raw.(map[string]any)
+		if !ok {
+			return cty.NilVal, false
+		}
+		values := make(map[string]cty.Value, len(entries))
+		for key, entry := range entries {
+			v, ok := b.specValue(path+".*", entry, ty.ElementType())
+			if !ok {
+				return cty.NilVal, false
+			}
+			values[key] = v
+		}
+		return cty.ObjectVal(values), true
+	case ty.IsObjectType():
+		entries, ok := raw.(map[string]any)
+		if !ok {
+			return cty.NilVal, false
+		}
+		byAttribute := make(map[string]any, len(entries))
+		for key, entry := range entries {
+			byAttribute[naming.ToSnakeCase(key)] = entry
+		}
+		attrs := map[string]cty.Value{}
+		for attrName, attrType := range ty.AttributeTypes() {
+			attrPath := path + "." + attrName
+			if entry, ok := byAttribute[attrName]; ok && entry != nil {
+				v, ok := b.specValue(attrPath, entry, attrType)
+				if !ok {
+					return cty.NilVal, false
+				}
+				attrs[attrName] = v
+			} else if b.complete || !ty.AttributeOptional(attrName) {
+				attrs[attrName] = b.value(attrPath, attrName, attrType)
+			}
+		}
+		return cty.ObjectVal(attrs), true
+	}
+	return cty.NilVal, false
+}
+
+// specDynamicValue converts a JSON value of a variable typed any.
+func specDynamicValue(raw any) (cty.Value, bool) {
+	switch v := raw.(type) {
+	case string:
+		return cty.StringVal(v), true
+	case bool:
+		return cty.BoolVal(v), true
+	case json.Number:
+		f, _, err := big.ParseFloat(v.String(), 10, 512, big.ToNearestEven)
+		return cty.NumberVal(f), err == nil
+	case []any:
+		values := make([]cty.Value, 0, len(v))
+		for _, item := range v {
+			value, ok := specDynamicValue(item)
+			if !ok {
+				return cty.NilVal, false
+			}
+			values = append(values, value)
+		}
+		return cty.TupleVal(values), true
+	case map[string]any:
+		values := make(map[string]cty.Value, len(v))
+		for key, item := range v {
+			value, ok := specDynamicValue(item)
+			if !ok {
+				return cty.NilVal, false
+			}
+			values[key] = value
+		}
+		return cty.ObjectVal(values), true
+	}
+	return cty.NilVal, false
+}
+
+// countLeaves counts the scalar values of a JSON value.
+func countLeaves(raw any) int {
+	switch v := raw.(type) {
+	case map[string]any:
+		n := 0
+		for _, item := range v {
+			n += countLeaves(item)
+		}
+		return n
+	case []any:
+		n := 0
+		for _, item := range v {
+			n += countLeaves(item)
+		}
+		return n
+	case nil:
+		return 0
+	}
+	return 1
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,153 @@
+package terraform
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const widgetsCreateExample = `{
+  "parameters": {
+    "subscriptionId": "00000000-0000-0000-0000-000000000000",
+    "widgetName": "contoso-widget",
+    "api-version": "2024-01-01",
+    "parameters": {
+      "location": "westus2",
+      "tags": {"env": "prod"},
+      "properties": {
+        "displayName": "Contoso widget",
+        "capacity": 3,
+        "network": {"allowedIps": ["10.0.0.0/24"], "publicAccess": "Disabled"}
+      }
+    }
+  },
+  "responses": {
+    "200": {"body": {"id": "/subscriptions/x/widgets/contoso-widget", "type": "Microsoft.Test/widgets", "name": "contoso-widget"}}
+  }
+}`
+
+func writeSpecExamples(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(dir, 0o755))
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+}
+
+func TestLoadSpecExampleValues(t *testing.T) {
+	dir := t.TempDir()
+	writeSpecExamples(t, dir, map[string]string{
+		"Widgets_CreateOrUpdate.json": widgetsCreateExample,
+		"Widgets_CreateMinimal.json": `{
+  "parameters": {"widgetName": "minimal-widget", "parameters": {"location": "eastus", "properties": {"displayName": "Minimal"}}},
+  "responses": {"201": {"body": {"type": "Microsoft.Test/widgets"}}}
+}`,
+		"Gadgets_Create.json": `{
+  "parameters": {"gadgetName": "gadget", "parameters": {"location": "eastus", "properties": {"size": 1}}},
+  "responses": {"200": {"body": {"type": "Microsoft.Test/gadgets"}}}
+}`,
+		"Widgets_Get.json": `{
+  "parameters": {"widgetName": "read-widget"},
+  "responses": {"200": {"body": {"type": "Microsoft.Test/widgets"}}}
+}`,
+	})
+
+	values, err := loadSpecExampleValues(dir, "microsoft.test/Widgets")
+	require.NoError(t, err)
+
+	// The fullest example wins; GET and other types are ignored.
+	assert.Equal(t, "contoso-widget", values["name"])
+	assert.Equal(t, "westus2", values["location"])
+	assert.Equal(t, "Contoso widget", values["display_name"])
+	assert.Contains(t, values, "capacity")
+	assert.Contains(t, values, "network")
+	assert.NotContains(t, values, "size")
+	assert.NotContains(t, values, "properties")
+}
+
+func TestLoadSpecExampleValues_InvalidJSON(t *testing.T) {
+	dir := t.TempDir()
+	writeSpecExamples(t, dir, map[string]string{"broken.json": "{"})
+
+	_, err := loadSpecExampleValues(dir, "Microsoft.Test/widgets")
+	assert.ErrorContains(t, err, "parsing example")
+}
+
+func TestWriteTFVarsExample_UsesSpecExamples(t *testing.T) {
+	dir := t.TempDir()
+	writeSpecExamples(t, filepath.Join(dir, "examples-spec"), map[string]string{"Widgets_CreateOrUpdate.json": widgetsCreateExample})
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "tfmodmake.json"), []byte(`{"spec_examples": "examples-spec"}`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.tf"), []byte(`resource "azapi_resource" "this" {
+  type = "Microsoft.Test/widgets@2024-01-01"
+}
+`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "variables.tf"), []byte(`variable "name" {
+  type = string
+}
+
+variable "capacity" {
+  type = number
+}
+
+variable "network" {
+  type = object({
+    allowed_ips   = optional(list(string))
+    public_access = string
+    bypass        = optional(string)
+  })
+  default = null
+}
+
+variable "sku" {
+  type    = string
+  default = "Standard"
+}
+`), 0o644))
+
+	require.NoError(t, WriteTFVarsExample(dir, nil))
+	src, err := os.ReadFile(filepath.Join(dir, TFVarsExampleFileName))
+	require.NoError(t, err)
+	assert.Contains(t, string(src), "\nname = \"contoso-widget\"\n")
+	assert.Contains(t, string(src), "\ncapacity = 3\n")
+	assert.Contains(t, string(src), `# network = {
+#   allowed_ips   = ["10.0.0.0/24"]
+#   bypass        = "example"
+#   public_access = "Disabled"
+# }`)
+	// Variables the examples do not set keep their placeholders.
+	assert.Contains(t, string(src), `# sku = "example"`)
+}
+
+func TestScaffoldAVMLayout_UsesSpecExamples(t *testing.T) {
+	dir := t.TempDir()
+	writeSpecExamples(t, filepath.Join(dir, "examples-spec"), map[string]string{"Widgets_CreateOrUpdate.json": widgetsCreateExample})
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "tfmodmake.json"), []byte(`{"spec_examples": "examples-spec"}`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.tf"), []byte(`resource "azapi_resource" "this" {
+  type = "Microsoft.Test/widgets@2024-01-01"
+}
+`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "variables.tf"), []byte(`variable "name" {
+  type = string
+}
+
+variable "display_name" {
+  type    = string
+  default = null
+}
+`), 0o644))
+
+	_, err := ScaffoldAVMLayout(dir, nil)
+	require.NoError(t, err)
+
+	defaultMain, err := os.ReadFile(filepath.Join(dir, "examples", "default", "main.tf"))
+	require.NoError(t, err)
+	assert.Contains(t, string(defaultMain), `name             = "contoso-widget"`)
+	assert.NotContains(t, string(defaultMain), "display_name")
+
+	completeMain, err := os.ReadFile(filepath.Join(dir, "examples", "complete", "main.tf"))
+	require.NoError(t, err)
+	assert.Contains(t, string(completeMain), `display_name     = "Contoso widget"`)
+}
@@ -0,0 +1,31 @@
+package terraform
+
+import (
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/matt-FFFFFF/tfmodmake/hclgen"
+	"github.com/matt-FFFFFF/tfmodmake/schema"
+)
+
+// tagsBodyPath returns the body path of the resource's writable tags: the standard
+// top-level tags, or tags nested in the properties bag (flattened to var.tags).
+// It returns "" when the resource has no tags.
+func tagsBodyPath(rs *schema.ResourceSchema) string {
+	if SupportsTags(rs) {
+		return "tags"
+	}
+	if rs == nil || rs.Properties["properties"] == nil {
+		return ""
+	}
+	if tags := rs.Properties["properties"].Children["tags"]; tags != nil && tags.Type == schema.TypeObject && isWritableProperty(tags) {
+		return "properties.tags"
+	}
+	return ""
+}
+
+// tokensForMergedTags returns the module tags layered over the inherited tags.
+func tokensForMergedTags() hclwrite.Tokens {
+	return hclwrite.TokensForFunctionCall("merge",
+		hclgen.TokensForTraversal("var", "inherited_tags"),
+		hclgen.TokensForTraversal("var", "tags"),
+	)
+}
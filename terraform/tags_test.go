@@ -0,0 +1,100 @@
+package terraform
+
+import (
+	"os"
+	"testing"
+
+	"github.com/matt-FFFFFF/tfmodmake/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate_InheritedTagsVariable(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+
+	require.NoError(t, os.Chdir(tmpDir))
+
+	tags := &schema.Property{Name: "tags", Type: schema.TypeObject, AdditionalProperties: &schema.Property{Type: schema.TypeString}}
+	rs := &schema.ResourceSchema{
+		SupportsTags: true,
+		Properties: map[string]*schema.Property{
+			"tags": tags,
+			"properties": {Name: "properties", Type: schema.TypeObject, Children: map[string]*schema.Property{
+				"enabled": {Name: "enabled", Type: schema.TypeBoolean},
+			}},
+		},
+	}
+
+	require.NoError(t, Generate("Microsoft.Test/widgets", WithResourceSchema(rs), WithAPIVersion("2025-01-01"), WithInheritedTagsVariable(true)))
+
+	varsBody := parseHCLBody(t, "variables.tf")
+	inherited := requireBlock(t, varsBody, "variable", "inherited_tags")
+	assert.Equal(t, "{}", expressionString(t, inherited.Body.Attributes["default"].Expr))
+
+	mainBody := parseHCLBody(t, "main.tf")
+	resource := requireBlock(t, mainBody, "resource", "azapi_resource", "this")
+	assert.Equal(t, "merge(var.inherited_tags, var.tags)", expressionString(t, resource.Body.Attributes["tags"].Expr))
+
+	localsBody := parseHCLBody(t, "locals.tf")
+	locals := requireBlock(t, localsBody, "locals")
+	assert.Contains(t, expressionString(t, locals.Body.Attributes["resource_body"].Expr), "tags = merge(var.inherited_tags, var.tags)")
+}
+
+func TestGenerate_InheritedTagsInProperties(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+
+	require.NoError(t, os.Chdir(tmpDir))
+
+	rs := &schema.ResourceSchema{
+		Properties: map[string]*schema.Property{
+			"properties": {Name: "properties", Type: schema.TypeObject, Children: map[string]*schema.Property{
+				"tags": {Name: "tags", Type: schema.TypeObject, AdditionalProperties: &schema.Property{Type: schema.TypeString}},
+			}},
+		},
+	}
+
+	require.NoError(t, Generate("Microsoft.Test/widgets", WithResourceSchema(rs), WithAPIVersion("2025-01-01"), WithInheritedTagsVariable(true)))
+
+	varsBody := parseHCLBody(t, "variables.tf")
+	requireBlock(t, varsBody, "variable", "inherited_tags")
+	requireBlock(t, varsBody, "variable", "tags")
+
+	mainBody := parseHCLBody(t, "main.tf")
+	resource := requireBlock(t, mainBody, "resource", "azapi_resource", "this")
+	assert.Nil(t, resource.Body.Attributes["tags"])
+
+	localsBody := parseHCLBody(t, "locals.tf")
+	locals := requireBlock(t, localsBody, "locals")
+	assert.Contains(t, expressionString(t, locals.Body.Attributes["resource_body"].Expr), "tags = merge(var.inherited_tags, var.tags)")
+}
+
+func TestGenerate_NoInheritedTagsWithoutTags(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+
+	require.NoError(t, os.Chdir(tmpDir))
+
+	rs := &schema.ResourceSchema{
+		Properties: map[string]*schema.Property{
+			"properties": {Name: "properties", Type: schema.TypeObject, Children: map[string]*schema.Property{
+				"enabled": {Name: "enabled", Type: schema.TypeBoolean},
+			}},
+		},
+	}
+
+	require.NoError(t, Generate("Microsoft.Test/widgets", WithResourceSchema(rs), WithAPIVersion("2025-01-01"), WithInheritedTagsVariable(true)))
+
+	varsBody := parseHCLBody(t, "variables.tf")
+	assert.Nil(t, findBlock(varsBody, "variable", "inherited_tags"))
+}
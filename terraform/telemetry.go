@@ -0,0 +1,59 @@
+package terraform
+
+import (
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/matt-FFFFFF/tfmodmake/hclgen"
+)
+
+// telemetryFileName is the file the AVM telemetry resources are written to.
+const telemetryFileName = "main.telemetry.tf"
+
+// buildTelemetry generates the AVM telemetry resources, verbatim from the AVM
+// module template: a modtm_telemetry resource tagged with the subscription, tenant,
+// module source and version, a random ID and, for located resources, the location.
+// Everything is gated by var.enable_telemetry.
+//
+// See https://aka.ms/avm/telemetryinfo.
+func buildTelemetry(supportsLocation bool) *hclwrite.File {
+	file := hclwrite.NewEmptyFile()
+	body := file.Body()
+
+	enabled := interfaceExpression(`var.enable_telemetry ? 1 : 0`)
+
+	clientConfig := body.AppendNewBlock("data", []string{"azapi_client_config", "telemetry"}).Body()
+	clientConfig.SetAttributeRaw("count", enabled)
+	body.AppendNewline()
+
+	moduleSource := body.AppendNewBlock("data", []string{"modtm_module_source", "telemetry"}).Body()
+	moduleSource.SetAttributeRaw("count", enabled)
+	moduleSource.AppendNewline()
+	moduleSource.SetAttributeRaw("module_path", hclgen.TokensForTraversal("path", "module"))
+	body.AppendNewline()
+
+	if supportsLocation {
+		locals := body.AppendNewBlock("locals", nil).Body()
+		locals.SetAttributeRaw("main_location", hclgen.TokensForTraversal("var", "location"))
+		body.AppendNewline()
+	}
+
+	randomID := body.AppendNewBlock("resource", []string{"random_uuid", "telemetry"}).Body()
+	randomID.SetAttributeRaw("count", enabled)
+	body.AppendNewline()
+
+	tags := `{
+  subscription_id = one(data.azapi_client_config.telemetry).subscription_id
+  tenant_id       = one(data.azapi_client_config.telemetry).tenant_id
+  module_source   = one(data.modtm_module_source.telemetry).module_source
+  module_version  = one(data.modtm_module_source.telemetry).module_version
+  random_id       = one(random_uuid.telemetry).result
+}`
+	if supportsLocation {
+		tags = "merge(" + tags + ", { location = local.main_location })"
+	}
+	telemetry := body.AppendNewBlock("resource", []string{"modtm_telemetry", "telemetry"}).Body()
+	telemetry.SetAttributeRaw("count", enabled)
+	telemetry.AppendNewline()
+	telemetry.SetAttributeRaw("tags", interfaceExpression(tags))
+
+	return file
+}
@@ -0,0 +1,53 @@
+package terraform
+
+import (
+	"os"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate_Telemetry(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+
+	require.NoError(t, os.Chdir(tmpDir))
+
+	require.NoError(t, Generate("Microsoft.Test/widgets", WithResourceSchema(avmStrictSchema()), WithAPIVersion("2024-01-01"), WithTelemetry(true)))
+
+	telemetryBody := parseHCLBody(t, telemetryFileName)
+	requireBlock(t, telemetryBody, "data", "azapi_client_config", "telemetry")
+	requireBlock(t, telemetryBody, "data", "modtm_module_source", "telemetry")
+	requireBlock(t, telemetryBody, "resource", "random_uuid", "telemetry")
+	telemetry := requireBlock(t, telemetryBody, "resource", "modtm_telemetry", "telemetry")
+	assert.Equal(t, "var.enable_telemetry ? 1 : 0", expressionString(t, telemetry.Body.Attributes["count"].Expr))
+	assert.Contains(t, expressionString(t, telemetry.Body.Attributes["tags"].Expr), "{ location = local.main_location }")
+
+	terraformBody := parseHCLBody(t, "terraform.tf")
+	tf := requireBlock(t, terraformBody, "terraform")
+	providers := requireBlock(t, tf.Body, "required_providers")
+	assert.Contains(t, providers.Body.Attributes, "modtm")
+	assert.Contains(t, providers.Body.Attributes, "random")
+}
+
+func TestBuildTelemetry_WithoutLocation(t *testing.T) {
+	src := string(hclwrite.Format(buildTelemetry(false).Bytes()))
+	assert.NotContains(t, src, "main_location")
+	assert.Contains(t, src, "random_id       = one(random_uuid.telemetry).result")
+}
+
+func TestGenerate_TelemetryDisabledByDefault(t *testing.T) {
+	mod, err := GenerateInMemory("Microsoft.Test/widgets", WithResourceSchema(avmStrictSchema()), WithAPIVersion("2024-01-01"))
+	require.NoError(t, err)
+	assert.Nil(t, mod.Telemetry)
+	assert.NotContains(t, string(mod.Terraform.Bytes()), "modtm")
+
+	mod, err = GenerateInMemory("Microsoft.Test/widgets", WithResourceSchema(avmStrictSchema()), WithAPIVersion("2024-01-01"), WithAVMStrict(true))
+	require.NoError(t, err)
+	assert.NotNil(t, mod.Telemetry)
+}
@@ -0,0 +1,123 @@
+package terraform
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+// TemplateExt is the extension of the override templates in a templates directory.
+const TemplateExt = ".tmpl"
+
+// templateHeaderSuffix marks a template rendered above a generated file rather
+// than in place of it, e.g. outputs.tf.header.tmpl.
+const templateHeaderSuffix = ".header"
+
+// overridableFiles are the fixed-content files a template can replace. The
+// schema-driven files only take a header.
+var overridableFiles = map[string]bool{"terraform.tf": true, telemetryFileName: true}
+
+// TemplateData is what override templates are rendered with.
+type TemplateData struct {
+	ResourceType string
+	APIVersion   string
+	// Telemetry reports whether the AVM telemetry resources are generated, and
+	// so whether terraform.tf must require the modtm and random providers.
+	Telemetry bool
+	// Generated is the content tfmodmake would have written.
+	Generated string
+}
+
+// applyTemplates renders the override templates of dir onto the module files:
+// <file>.tmpl replaces terraform.tf or main.telemetry.tf, and <file>.header.tmpl
+// is written above any generated .tf file. Templates for files the module does
+// not have are skipped; other names are rejected, so typos surface.
+func applyTemplates(dir string, mod *GeneratedModule, data TemplateData) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("reading templates: %w", err)
+	}
+	files := map[string]**hclwrite.File{
+		"terraform.tf":    &mod.Terraform,
+		"variables.tf":    &mod.Variables,
+		"locals.tf":       &mod.Locals,
+		"main.tf":         &mod.Main,
+		"outputs.tf":      &mod.Outputs,
+		telemetryFileName: &mod.Telemetry,
+	}
+
+	// Replacements are rendered before headers, whatever the directory order.
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), TemplateExt) {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Slice(names, func(i, j int) bool {
+		iHeader, jHeader := isHeaderTemplate(names[i]), isHeaderTemplate(names[j])
+		if iHeader != jHeader {
+			return jHeader
+		}
+		return names[i] < names[j]
+	})
+
+	for _, name := range names {
+		target := strings.TrimSuffix(name, TemplateExt)
+		header := isHeaderTemplate(name)
+		if header {
+			target = strings.TrimSuffix(target, templateHeaderSuffix)
+		}
+		file, ok := files[target]
+		if !ok || (!header && !overridableFiles[target]) {
+			return fmt.Errorf("unknown template %s: only terraform.tf and %s can be replaced, and .tf files take a header", name, telemetryFileName)
+		}
+		if *file == nil {
+			continue
+		}
+
+		data.Generated = string((*file).Bytes())
+		rendered, err := renderTemplate(filepath.Join(dir, name), data)
+		if err != nil {
+			return err
+		}
+		if header {
+			if len(rendered) > 0 && !bytes.HasSuffix(rendered, []byte("\n")) {
+				rendered = append(rendered, '\n')
+			}
+			rendered = append(rendered, (*file).Bytes()...)
+		}
+		parsed, diags := hclwrite.ParseConfig(rendered, name, hcl.Pos{Line: 1, Column: 1})
+		if diags.HasErrors() {
+			return fmt.Errorf("template %s does not render valid HCL: %s", name, diags.Error())
+		}
+		*file = parsed
+	}
+	return nil
+}
+
+func isHeaderTemplate(name string) bool {
+	return strings.HasSuffix(strings.TrimSuffix(name, TemplateExt), templateHeaderSuffix)
+}
+
+func renderTemplate(path string, data TemplateData) ([]byte, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading template: %w", err)
+	}
+	tmpl, err := template.New(filepath.Base(path)).Option("missingkey=error").Parse(string(src))
+	if err != nil {
+		return nil, fmt.Errorf("parsing template: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("rendering template: %w", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,23 @@
+# tflint configuration for an azapi-based Azure Verified Module. The AVM ruleset
+# checks the module interface requirements; no provider ruleset is enabled, as
+# azapi resources are validated against the API schemas at plan time.
+config {
+  call_module_type = "local"
+}
+
+plugin "terraform" {
+  enabled = true
+  preset  = "recommended"
+}
+
+plugin "avm" {
+  enabled = true
+  version = "0.13.0"
+  source  = "github.com/Azure/tflint-ruleset-avm"
+}
+
+# AVM modules keep interfaces and child modules in main.<name>.tf and
+# variables.<name>.tf files, which the standard structure rule reports.
+rule "terraform_standard_module_structure" {
+  enabled = false
+}
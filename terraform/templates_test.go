@@ -0,0 +1,95 @@
+package terraform
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/matt-FFFFFF/tfmodmake/hclgen"
+	"github.com/matt-FFFFFF/tfmodmake/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTemplates(t *testing.T, templates map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range templates {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+	return dir
+}
+
+func TestGenerate_Templates(t *testing.T) {
+	dir := writeTemplates(t, map[string]string{
+		"terraform.tf.tmpl": `terraform {
+  required_version = ">= 1.9, < 2.0"
+  required_providers {
+    azapi = {
+      source  = "azure/azapi"
+      version = ">= 2.5"
+    }
+{{- if .Telemetry}}
+    modtm = {
+      source  = "azure/modtm"
+      version = "~> 0.3"
+    }
+{{- end}}
+  }
+}
+`,
+		"terraform.tf.header.tmpl": "# Copyright Contoso.\n",
+		"outputs.tf.header.tmpl":   "# Outputs of {{.ResourceType}}@{{.APIVersion}}",
+	})
+
+	files := hclgen.MemoryWriter{}
+	require.NoError(t, Generate("Microsoft.Test/widgets", WithResourceSchema(&schema.ResourceSchema{}),
+		WithAPIVersion("2024-01-01"), WithTemplatesDir(dir), WithWriter(files)))
+
+	terraformTF := string(files["terraform.tf"])
+	assert.Contains(t, terraformTF, "# Copyright Contoso.\nterraform {")
+	assert.Contains(t, terraformTF, `required_version = ">= 1.9, < 2.0"`)
+	assert.NotContains(t, terraformTF, "modtm")
+	assert.Contains(t, string(files["outputs.tf"]), "# Outputs of Microsoft.Test/widgets@2024-01-01\noutput ")
+	assert.NotContains(t, string(files["main.tf"]), "#")
+
+	files = hclgen.MemoryWriter{}
+	require.NoError(t, Generate("Microsoft.Test/widgets", WithResourceSchema(&schema.ResourceSchema{}),
+		WithAPIVersion("2024-01-01"), WithTemplatesDir(dir), WithTelemetry(true), WithWriter(files)))
+	assert.Contains(t, string(files["terraform.tf"]), `source  = "azure/modtm"`)
+}
+
+func TestGenerate_TemplatesGeneratedContent(t *testing.T) {
+	dir := writeTemplates(t, map[string]string{
+		telemetryFileName + ".tmpl": "# Telemetry, as generated.\n{{.Generated}}",
+	})
+
+	files := hclgen.MemoryWriter{}
+	require.NoError(t, Generate("Microsoft.Test/widgets", WithResourceSchema(&schema.ResourceSchema{}),
+		WithTemplatesDir(dir), WithTelemetry(true), WithWriter(files)))
+	telemetry := string(files[telemetryFileName])
+	assert.Contains(t, telemetry, "# Telemetry, as generated.\n")
+	assert.Contains(t, telemetry, `resource "modtm_telemetry" "telemetry"`)
+}
+
+func TestGenerate_TemplatesErrors(t *testing.T) {
+	for templates, want := range map[string]string{
+		"main.tf.tmpl":          "unknown template main.tf.tmpl",
+		"README.md.header.tmpl": "unknown template README.md.header.tmpl",
+	} {
+		dir := writeTemplates(t, map[string]string{templates: ""})
+		err := Generate("Microsoft.Test/widgets", WithResourceSchema(&schema.ResourceSchema{}), WithTemplatesDir(dir), WithWriter(hclgen.MemoryWriter{}))
+		assert.ErrorContains(t, err, want)
+	}
+
+	dir := writeTemplates(t, map[string]string{"main.tf.header.tmpl": "not hcl {{.ResourceType}}"})
+	err := Generate("Microsoft.Test/widgets", WithResourceSchema(&schema.ResourceSchema{}), WithTemplatesDir(dir), WithWriter(hclgen.MemoryWriter{}))
+	assert.ErrorContains(t, err, "template main.tf.header.tmpl does not render valid HCL")
+
+	dir = writeTemplates(t, map[string]string{"main.tf.header.tmpl": "# {{.Missing}}"})
+	err = Generate("Microsoft.Test/widgets", WithResourceSchema(&schema.ResourceSchema{}), WithTemplatesDir(dir), WithWriter(hclgen.MemoryWriter{}))
+	assert.ErrorContains(t, err, "rendering template")
+
+	err = Generate("Microsoft.Test/widgets", WithResourceSchema(&schema.ResourceSchema{}), WithTemplatesDir(filepath.Join(t.TempDir(), "missing")), WithWriter(hclgen.MemoryWriter{}))
+	assert.ErrorContains(t, err, "reading templates")
+}
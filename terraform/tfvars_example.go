@@ -0,0 +1,111 @@
+package terraform
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/matt-FFFFFF/tfmodmake/hclgen"
+)
+
+// TFVarsExampleFileName is the name of the example variable definitions file
+// written alongside a module.
+const TFVarsExampleFileName = "terraform.tfvars.example"
+
+// WriteTFVarsExample writes terraform.tfvars.example for the module in dir,
+// listing every variable declared by its .tf files with its type, default and
+// an example value, taken from the spec examples configured in its
+// tfmodmake.json where there are any. Required variables come first and are assigned a
+// placeholder; optional variables follow with their assignment commented out
+// and every optional attribute set.
+// The file is rewritten from the module on every call, so it follows the
+// variables through regeneration, and is recorded in journal, which may be nil.
+func WriteTFVarsExample(dir string, journal *hclgen.Journal) error {
+	variables, err := readModuleVariables(dir)
+	if err != nil {
+		return err
+	}
+	resourceType, _ := moduleResourceType(dir)
+	spec, err := moduleSpecExampleValues(dir, resourceType)
+	if err != nil {
+		return err
+	}
+	return journal.WriteIfChanged(filepath.Join(dir, TFVarsExampleFileName), buildTFVarsExample(variables, spec))
+}
+
+// buildTFVarsExample renders the variables as commented variable definitions,
+// with values from the spec examples where they fit.
+func buildTFVarsExample(variables []moduleVariable, spec specExampleValues) []byte {
+	var required, optional []moduleVariable
+	for _, v := range variables {
+		if v.required {
+			required = append(required, v)
+		} else {
+			optional = append(optional, v)
+		}
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("# Example variable definitions for this module, generated by tfmodmake.\n")
+	buf.WriteString("# Copy this file to terraform.tfvars and replace the placeholders. It is\n")
+	buf.WriteString("# rewritten when the module is regenerated, so keep your values elsewhere.\n")
+	groups := []struct {
+		heading   string
+		variables []moduleVariable
+		commented bool
+	}{
+		{"Required variables", required, false},
+		{"Optional variables", optional, true},
+	}
+	for _, group := range groups {
+		if len(group.variables) == 0 {
+			continue
+		}
+		fmt.Fprintf(&buf, "\n# %s\n# %s\n", group.heading, strings.Repeat("-", len(group.heading)))
+		for _, v := range group.variables {
+			buf.WriteString("\n")
+			writeTFVarsEntry(&buf, v, spec, group.commented)
+		}
+	}
+	return buf.Bytes()
+}
+
+// writeTFVarsEntry writes the type and default of a variable as comments,
+// followed by its assignment to an example value.
+func writeTFVarsEntry(buf *bytes.Buffer, v moduleVariable, spec specExampleValues, commented bool) {
+	typeSource := v.typeSource
+	if typeSource == "" {
+		typeSource = "any"
+	}
+	writeCommentedLines(buf, "# type: ", typeSource)
+	if v.defaultSource != "" {
+		writeCommentedLines(buf, "# default: ", v.defaultSource)
+	}
+
+	// Optional variables show the shape of every attribute and collection, as
+	// they are only uncommented to set more than the defaults.
+	b := &exampleBuilder{constraints: v.constraints, complete: commented, spec: spec}
+	file := hclwrite.NewEmptyFile()
+	file.Body().SetAttributeRaw(v.name, b.tokens(v.name, v.name, v.ty))
+	assignment := strings.TrimRight(string(hclwrite.Format(file.Bytes())), "\n")
+	if !commented {
+		buf.WriteString(assignment + "\n")
+		return
+	}
+	writeCommentedLines(buf, "# ", assignment)
+}
+
+// writeCommentedLines writes text as comment lines, the first starting with
+// prefix and the others indented below it.
+func writeCommentedLines(buf *bytes.Buffer, prefix, text string) {
+	indent := "#" + strings.Repeat(" ", len(prefix)-1)
+	for i, line := range strings.Split(text, "\n") {
+		if i == 0 {
+			buf.WriteString(strings.TrimRight(prefix+line, " ") + "\n")
+			continue
+		}
+		buf.WriteString(strings.TrimRight(indent+line, " ") + "\n")
+	}
+}
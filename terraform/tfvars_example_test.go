@@ -0,0 +1,81 @@
+package terraform
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteTFVarsExample(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "variables.tf"), []byte(`variable "lock" {
+  type = object({
+    kind = string
+    name = optional(string, null)
+  })
+  default = null
+
+  validation {
+    condition     = var.lock == null || contains(["CanNotDelete", "ReadOnly"], var.lock.kind)
+    error_message = "The lock kind must be CanNotDelete or ReadOnly."
+  }
+}
+
+variable "name" {
+  type = string
+}
+`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "variables.extra.tf"), []byte(`variable "sku" {
+  type    = string
+  default = "Standard"
+}
+`), 0o644))
+
+	require.NoError(t, WriteTFVarsExample(dir, nil))
+	src, err := os.ReadFile(filepath.Join(dir, TFVarsExampleFileName))
+	require.NoError(t, err)
+	assert.Contains(t, string(src), `# Required variables
+# ------------------
+
+# type: string
+name = "example"
+
+# Optional variables
+# ------------------
+
+# type: object({
+#         kind = string
+#         name = optional(string, null)
+#       })
+# default: null
+# lock = {
+#   kind = "CanNotDelete"
+#   name = "example"
+# }
+
+# type: string
+# default: "Standard"
+# sku = "example"
+`)
+}
+
+func TestGenerate_WritesTFVarsExample(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, Generate("Microsoft.Test/widgets", WithResourceSchema(avmStrictSchema()), WithAPIVersion("2024-01-01"), WithAVMStrict(true), WithOutputDir(dir)))
+
+	src, err := os.ReadFile(filepath.Join(dir, TFVarsExampleFileName))
+	require.NoError(t, err)
+	assert.Contains(t, string(src), "\nname = \"example\"\n")
+	assert.Contains(t, string(src), "\nparent_id = ")
+	assert.Contains(t, string(src), "# enable_telemetry = ")
+
+	// Adding interfaces declares more variables, which the example follows.
+	_, err = GenerateInterfaces("Microsoft.Test/widgets", avmStrictSchema(), dir, []string{"lock"}, nil)
+	require.NoError(t, err)
+	src, err = os.ReadFile(filepath.Join(dir, TFVarsExampleFileName))
+	require.NoError(t, err)
+	assert.Contains(t, string(src), "# lock = {")
+}
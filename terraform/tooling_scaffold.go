@@ -0,0 +1,32 @@
+package terraform
+
+import (
+	"embed"
+	"fmt"
+	"path/filepath"
+)
+
+// toolingTemplates are the tflint and terraform-docs configurations scaffolded
+// into AVM module repositories. They are stored without the leading dot of the
+// file names they are written to.
+//
+//go:embed templates/tooling/*
+var toolingTemplates embed.FS
+
+// toolingFile returns the content of templates/tooling/<name>.
+func toolingFile(name string) ([]byte, error) {
+	return toolingTemplates.ReadFile("templates/tooling/" + name)
+}
+
+// buildModuleHeader is the terraform-docs header of the module README, naming
+// the resource type the module deploys.
+func buildModuleHeader(dir, resourceType string) []byte {
+	name := filepath.Base(dir)
+	if abs, err := filepath.Abs(dir); err == nil {
+		name = filepath.Base(abs)
+	}
+	if resourceType == "" {
+		return []byte(fmt.Sprintf("# %s\n\nThis is a Terraform module deploying an Azure resource with the azapi provider.\n", name))
+	}
+	return []byte(fmt.Sprintf("# %s\n\nThis is a Terraform module deploying `%s` with the azapi provider.\n", name, resourceType))
+}
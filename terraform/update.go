@@ -7,6 +7,9 @@ import (
 	"path/filepath"
 
 	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/matt-FFFFFF/tfmodmake/bicepdata"
+	"github.com/matt-FFFFFF/tfmodmake/hclgen"
+	"github.com/matt-FFFFFF/tfmodmake/schema"
 )
 
 // UpdateResult holds the outcome of an update operation.
@@ -17,6 +20,23 @@ type UpdateResult struct {
 	Locals             UpdateSummary
 	MainUpdated        bool
 	OutputsRegenerated bool
+	// Changes are the changes to the writable properties of the resource type
+	// between OldVersion and NewVersion, classified as breaking or additive.
+	Changes []schema.VersionChange
+}
+
+// HasBreakingChanges reports whether the update removes a variable or the new
+// API version has a breaking change.
+func (r *UpdateResult) HasBreakingChanges() bool {
+	if len(r.Variables.Removed) > 0 {
+		return true
+	}
+	for _, c := range r.Changes {
+		if c.Breaking {
+			return true
+		}
+	}
+	return false
 }
 
 // UpdateSummary classifies the changes made to a set of named items (variables or locals).
@@ -47,6 +67,15 @@ type UpdateOptions struct {
 	LocalName string
 	// DryRun, when true, computes changes without writing to disk.
 	DryRun bool
+	// TypesPath and TypesRef read the schemas from a local bicep-types-az
+	// checkout, or the published types at a ref, instead of the published
+	// types of main.
+	TypesPath string
+	TypesRef  string
+	// RejectBreaking, when true, makes Update fail with ErrBreakingChanges
+	// before writing anything when the result has breaking changes. The result
+	// is returned with the error.
+	RejectBreaking bool
 }
 
 // Update upgrades an existing Terraform module to a new API version while preserving
@@ -99,12 +128,15 @@ func Update(ctx context.Context, opts UpdateOptions) (*UpdateResult, error) {
 	}
 
 	// Step 2: Generate baseline from old (current) API version for dirty detection.
-	baselineResult, err := LoadResource(ctx, resourceType, WithAPIVersionLoad(oldVersion))
+	cache := bicepdata.NewCache()
+	defer cache.Close()
+	spec := []LoadOption{WithTypesPath(opts.TypesPath), WithTypesRef(opts.TypesRef), WithLoadCache(cache)}
+	baselineSchema, err := LoadResourceSchema(ctx, resourceType, append(spec, WithAPIVersionLoad(oldVersion))...)
 	if err != nil {
 		return nil, fmt.Errorf("loading resource for old API version: %w", err)
 	}
 	baselineModule, err := GenerateInMemory(resourceType,
-		baselineResult,
+		WithLoadedSchema(baselineSchema),
 		WithLocalName(opts.LocalName),
 	)
 	if err != nil {
@@ -117,14 +149,16 @@ func Update(ctx context.Context, opts UpdateOptions) (*UpdateResult, error) {
 	}
 
 	// Step 3: Generate new module from new API version.
-	newResult, err := LoadResource(ctx, resourceType, WithAPIVersionLoad(opts.NewAPIVersion), WithIncludePreview(opts.IncludePreview))
+	newSchema, err := LoadResourceSchema(ctx, resourceType, append(spec, WithAPIVersionLoad(opts.NewAPIVersion), WithIncludePreview(opts.IncludePreview))...)
 	if err != nil {
 		return nil, fmt.Errorf("loading resource for new API version: %w", err)
 	}
-	newModule, err := GenerateInMemory(resourceType,
-		newResult,
-		WithLocalName(opts.LocalName),
-	)
+	newOpts := []GeneratorOption{WithLoadedSchema(newSchema), WithLocalName(opts.LocalName)}
+	// Keep the exports chosen on disk so the regenerated outputs match them.
+	if exportPaths, ok := ExtractResponseExportValues(mainFile); ok {
+		newOpts = append(newOpts, WithResponseExportValues(exportPaths...))
+	}
+	newModule, err := GenerateInMemory(resourceType, newOpts...)
 	if err != nil {
 		return nil, fmt.Errorf("generating new module: %w", err)
 	}
@@ -143,6 +177,7 @@ func Update(ctx context.Context, opts UpdateOptions) (*UpdateResult, error) {
 	result := &UpdateResult{
 		OldVersion: oldVersion,
 		NewVersion: newVersion,
+		Changes:    schema.DiffVersions(baselineSchema, newSchema),
 	}
 
 	// Step 4: 3-way comparison and apply changes.
@@ -151,6 +186,15 @@ func Update(ctx context.Context, opts UpdateOptions) (*UpdateResult, error) {
 	varComparison := CompareVariables(onDiskVarTypes, baselineVarTypes, newVarTypes)
 	localComparison := CompareLocals(onDiskLocalAssignments, baselineLocalAssignments, newLocalAssignments)
 
+	if opts.RejectBreaking && !opts.DryRun {
+		summarized := *result
+		summarized.Variables = summarizeComparison(varComparison)
+		summarized.Locals = summarizeComparison(localComparison)
+		if summarized.HasBreakingChanges() {
+			return &summarized, fmt.Errorf("updating %s from %s to %s: %w", resourceType, oldVersion, newVersion, ErrBreakingChanges)
+		}
+	}
+
 	if !opts.DryRun {
 		// Update variables.tf
 		result.Variables = applyVariableChanges(varsFile, newModule.Variables, newVarTypes, varComparison)
@@ -191,6 +235,10 @@ func Update(ctx context.Context, opts UpdateOptions) (*UpdateResult, error) {
 			}
 			result.OutputsRegenerated = true
 		}
+
+		if err := WriteTFVarsExample(opts.ModuleDir, nil); err != nil {
+			return nil, fmt.Errorf("writing %s: %w", TFVarsExampleFileName, err)
+		}
 	} else {
 		// Dry run: compute what would change using the 3-way comparison.
 		result.Variables = summarizeComparison(varComparison)
@@ -310,5 +358,5 @@ func summarizeComparison(comparison map[string]CompareResult) UpdateSummary {
 
 // writeHCLFile writes a parsed HCL file back to disk.
 func writeHCLFile(path string, file *hclwrite.File) error {
-	return os.WriteFile(path, file.Bytes(), 0o644)
+	return hclgen.WriteFile(path, file)
 }
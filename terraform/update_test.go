@@ -0,0 +1,18 @@
+package terraform
+
+import (
+	"testing"
+
+	"github.com/matt-FFFFFF/tfmodmake/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdateResult_HasBreakingChanges(t *testing.T) {
+	additive := schema.VersionChange{Path: "properties.tier", Kind: schema.VersionChangeAdded}
+	breaking := schema.VersionChange{Path: "properties.legacy", Kind: schema.VersionChangeRemoved, Breaking: true}
+
+	assert.False(t, (&UpdateResult{}).HasBreakingChanges())
+	assert.False(t, (&UpdateResult{Changes: []schema.VersionChange{additive}}).HasBreakingChanges())
+	assert.True(t, (&UpdateResult{Changes: []schema.VersionChange{additive, breaking}}).HasBreakingChanges())
+	assert.True(t, (&UpdateResult{Variables: UpdateSummary{Removed: []string{"legacy"}}}).HasBreakingChanges())
+}
@@ -169,6 +169,24 @@ func appendValidationsForExpr(varBody *hclwrite.Body, displayName string, parent
 	}
 }
 
+// appendRequiredIfSetValidations requires the given attributes of the object variable
+// tfName to be non-null whenever the variable itself is set. Attributes missing from
+// prop are skipped.
+func appendRequiredIfSetValidations(varBody *hclwrite.Body, tfName string, prop *schema.Property, attrs ...string) {
+	parentRef := hclgen.TokensForTraversal("var", tfName)
+	for _, attr := range attrs {
+		if prop == nil || prop.Children[attr] == nil {
+			continue
+		}
+		snake := naming.ToSnakeCase(attr)
+		var condition hclwrite.Tokens
+		condition = append(condition, hclgen.TokensForTraversal("var", tfName, snake)...)
+		condition = append(condition, &hclwrite.Token{Type: hclsyntax.TokenNotEqual, Bytes: []byte(" != ")})
+		condition = append(condition, hclwrite.TokensForIdentifier("null")...)
+		appendValidation(varBody, wrapWithNullGuard(parentRef, condition), fmt.Sprintf("%s.%s must be set when %s is set.", tfName, snake, tfName))
+	}
+}
+
 func appendValidation(varBody *hclwrite.Body, condition hclwrite.Tokens, errorMessage string) {
 	validation := varBody.AppendNewBlock("validation", nil)
 	validationBody := validation.Body()
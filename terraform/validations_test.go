@@ -333,6 +333,46 @@ func TestGenerateValidations_Enum(t *testing.T) {
 	assert.Contains(t, errorMsg, "Windows_Server")
 }
 
+func TestGenerate_NameValidationFromSchema(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+
+	require.NoError(t, os.Chdir(tmpDir))
+
+	rs := &schema.ResourceSchema{
+		Properties: map[string]*schema.Property{
+			"name": {
+				Name:     "name",
+				Type:     schema.TypeString,
+				Required: true,
+				Constraints: schema.Constraints{
+					MinLength: ptrInt64(3),
+					MaxLength: ptrInt64(24),
+					Pattern:   "^[a-z0-9]+$",
+				},
+			},
+		},
+	}
+
+	require.NoError(t, Generate("Microsoft.Storage/storageAccounts", WithResourceSchema(rs), WithAPIVersion("2025-01-01")))
+
+	varsBody := parseHCLBody(t, "variables.tf")
+	nameVar := requireBlock(t, varsBody, "variable", "name")
+
+	var conditions []string
+	for _, block := range findAllBlocks(nameVar.Body, "validation") {
+		conditions = append(conditions, expressionString(t, block.Body.Attributes["condition"].Expr))
+	}
+	assert.Equal(t, []string{
+		"length(var.name) >= 3",
+		"length(var.name) <= 24",
+		`can(regex("^[a-z0-9]+$", var.name))`,
+	}, conditions)
+}
+
 // Helper function to find all blocks of a given type
 func findAllBlocks(body *hclsyntax.Body, typ string) []*hclsyntax.Block {
 	var blocks []*hclsyntax.Block
@@ -0,0 +1,69 @@
+package terraform
+
+import (
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/matt-FFFFFF/tfmodmake/hclgen"
+	"github.com/matt-FFFFFF/tfmodmake/schema"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// availabilityZones are the logical zone numbers accepted by Azure regions.
+var availabilityZones = []string{"1", "2", "3"}
+
+// zonesProperty returns the writable top-level zones list of the schema, or nil.
+func zonesProperty(rs *schema.ResourceSchema) *schema.Property {
+	if rs == nil {
+		return nil
+	}
+	if prop := rs.Properties["zones"]; isZonesProperty(prop) {
+		return prop
+	}
+	return nil
+}
+
+// isZonesProperty reports whether prop is a writable list of zone strings.
+func isZonesProperty(prop *schema.Property) bool {
+	return prop != nil && prop.Type == schema.TypeArray && prop.ItemType != nil && prop.ItemType.Type == schema.TypeString && isWritableProperty(prop)
+}
+
+// zonesDescription documents the zones variable, including the zone-redundancy implications.
+func zonesDescription(prop *schema.Property) string {
+	desc := strings.TrimSpace(prop.Description)
+	if desc == "" {
+		desc = "The availability zones of the resource."
+	}
+	return desc + ` Valid values are "1", "2" and "3". Specifying more than one zone makes the resource zone-redundant; changing the zones of an existing resource may force it to be recreated. The zones are sorted in the request body, so reordering them does not cause a diff.`
+}
+
+// appendZonesValidation adds a validation that every zone is a valid availability zone.
+func appendZonesValidation(varBody *hclwrite.Body, tfName string) {
+	zoneValues := make([]cty.Value, 0, len(availabilityZones))
+	for _, z := range availabilityZones {
+		zoneValues = append(zoneValues, cty.StringVal(z))
+	}
+
+	var forExpr hclwrite.Tokens
+	forExpr = append(forExpr, &hclwrite.Token{Type: hclsyntax.TokenOBrack, Bytes: []byte("[")})
+	forExpr = append(forExpr, &hclwrite.Token{Type: hclsyntax.TokenIdent, Bytes: []byte("for")})
+	forExpr = append(forExpr, &hclwrite.Token{Type: hclsyntax.TokenIdent, Bytes: []byte("zone")})
+	forExpr = append(forExpr, &hclwrite.Token{Type: hclsyntax.TokenIdent, Bytes: []byte("in")})
+	forExpr = append(forExpr, hclgen.TokensForTraversal("var", tfName)...)
+	forExpr = append(forExpr, &hclwrite.Token{Type: hclsyntax.TokenColon, Bytes: []byte(":")})
+	forExpr = append(forExpr, hclwrite.TokensForFunctionCall("contains",
+		hclwrite.TokensForValue(cty.ListVal(zoneValues)),
+		hclwrite.TokensForIdentifier("zone"),
+	)...)
+	forExpr = append(forExpr, &hclwrite.Token{Type: hclsyntax.TokenCBrack, Bytes: []byte("]")})
+
+	condition := wrapWithNullGuard(hclgen.TokensForTraversal("var", tfName), hclwrite.TokensForFunctionCall("alltrue", forExpr))
+	appendValidation(varBody, condition, tfName+` must only contain the availability zones "1", "2" and "3".`)
+}
+
+// tokensForZonesValue returns the body value for the zones variable, sorted so that
+// reordering the set does not produce a plan diff.
+func tokensForZonesValue(accessPath hclwrite.Tokens) hclwrite.Tokens {
+	return hclgen.NullEqualityTernary(accessPath, hclwrite.TokensForFunctionCall("sort", accessPath))
+}
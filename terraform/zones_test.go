@@ -0,0 +1,56 @@
+package terraform
+
+import (
+	"os"
+	"testing"
+
+	"github.com/matt-FFFFFF/tfmodmake/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate_ZonesVariable(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+
+	require.NoError(t, os.Chdir(tmpDir))
+
+	rs := &schema.ResourceSchema{
+		Properties: map[string]*schema.Property{
+			"zones": {Name: "zones", Type: schema.TypeArray, ItemType: &schema.Property{Type: schema.TypeString}},
+			"properties": {Name: "properties", Type: schema.TypeObject, Children: map[string]*schema.Property{
+				"enabled": {Name: "enabled", Type: schema.TypeBoolean},
+			}},
+		},
+	}
+
+	require.NoError(t, Generate("Microsoft.Test/widgets", WithResourceSchema(rs), WithAPIVersion("2025-01-01")))
+
+	varsBody := parseHCLBody(t, "variables.tf")
+	zones := requireBlock(t, varsBody, "variable", "zones")
+	assert.Equal(t, "set(string)", expressionString(t, zones.Body.Attributes["type"].Expr))
+	assert.Equal(t, "null", expressionString(t, zones.Body.Attributes["default"].Expr))
+
+	validations := findAllBlocks(zones.Body, "validation")
+	require.Len(t, validations, 1)
+	assert.Equal(t, `var.zones == null || alltrue([for zone in var.zones : contains(["1", "2", "3"], zone)])`, expressionString(t, validations[0].Body.Attributes["condition"].Expr))
+
+	localsBody := parseHCLBody(t, "locals.tf")
+	locals := requireBlock(t, localsBody, "locals")
+	assert.Contains(t, expressionString(t, locals.Body.Attributes["resource_body"].Expr), "zones = var.zones == null ? null : sort(var.zones)")
+
+	// expressionString reformats the expression, so check the rendered file too.
+	rendered, err := os.ReadFile("locals.tf")
+	require.NoError(t, err)
+	assert.Contains(t, string(rendered), "    zones = var.zones == null ? null : sort(var.zones)\n")
+}
+
+func TestZonesProperty_IgnoresReadOnly(t *testing.T) {
+	rs := &schema.ResourceSchema{Properties: map[string]*schema.Property{
+		"zones": {Name: "zones", Type: schema.TypeArray, ReadOnly: true, ItemType: &schema.Property{Type: schema.TypeString}},
+	}}
+	assert.Nil(t, zonesProperty(rs))
+}